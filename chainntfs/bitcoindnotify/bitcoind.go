@@ -0,0 +1,803 @@
+package bitcoindnotify
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lightningnetwork/lnd/chainntfs"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcrpcclient"
+)
+
+const (
+	// DefaultPollInterval is the default interval at which bitcoind is
+	// polled for a new best block.
+	DefaultPollInterval = time.Second * 5
+
+	// maxBlocksPerPoll is the maximum number of blocks which will be
+	// connected by a single poll. When further behind, the remaining
+	// blocks are connected by the following polls, so registrations
+	// aren't held up by a long catch-up.
+	maxBlocksPerPoll = 100
+)
+
+var (
+	// ErrNotifierShuttingDown is returned when attempting to register for
+	// a notification while the notifier is shutting down.
+	ErrNotifierShuttingDown = errors.New("notifier is shutting down")
+)
+
+// BitcoindNotifier implements the ChainNotifier interface by polling Bitcoin
+// Core's JSON-RPC interface. The current best block is polled via
+// getbestblockhash, each newly connected block is fetched in full by height
+// via getblockhash, and getblock, and confirmations of transactions which confirmed before a
+// notification was registered are looked up via getrawtransaction. If a
+// height hint cache is present, then any blocks connected since a watched
+// txid or outpoint was last checked are rescanned upon registration.
+type BitcoindNotifier struct {
+	started int32 // To be used atomically.
+	stopped int32 // To be used atomically.
+
+	chainConn    *btcrpcclient.Client
+	pollInterval time.Duration
+
+	hintCache chainntnfs.HeightHintCache
+
+	// bestHash, and bestHeight are the notifier's view of the current
+	// best block. Both are only accessed by the notificationDispatcher
+	// once the notifier has been started.
+	bestHash   wire.ShaHash
+	bestHeight int32
+
+	notificationRegistry chan interface{}
+
+	spendNotifications map[wire.OutPoint]*spendNotification
+	confNotifications  map[wire.ShaHash]*confirmationsNotification
+	confHeap           *confirmationHeap
+
+	// blockEpochClients are sent an epoch for each block connected to the
+	// main chain.
+	blockEpochClients []chan *chainntnfs.BlockEpoch
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// Ensure BitcoindNotifier implements the ChainNotifier interface at compile
+// time.
+var _ chainntnfs.ChainNotifier = (*BitcoindNotifier)(nil)
+
+// NewBitcoindNotifier returns a new BitcoindNotifier instance which polls the
+// bitcoind node detailed in the passed configuration every pollInterval. As
+// bitcoind doesn't offer websockets, the connection is made in HTTP POST
+// mode. The hintCache is optional, if nil then no height hints are
+// persisted.
+func NewBitcoindNotifier(config *btcrpcclient.ConnConfig,
+	pollInterval time.Duration,
+	hintCache chainntnfs.HeightHintCache) (*BitcoindNotifier, error) {
+
+	chainConn, err := newBitcoindClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if pollInterval == 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	return &BitcoindNotifier{
+		chainConn:    chainConn,
+		pollInterval: pollInterval,
+		hintCache:    hintCache,
+
+		notificationRegistry: make(chan interface{}),
+
+		spendNotifications: make(map[wire.OutPoint]*spendNotification),
+		confNotifications:  make(map[wire.ShaHash]*confirmationsNotification),
+		confHeap:           newConfirmationHeap(),
+
+		quit: make(chan struct{}),
+	}, nil
+}
+
+// newBitcoindClient creates a new RPC client for bitcoind's JSON-RPC
+// interface. The passed config is copied before being modified.
+func newBitcoindClient(config *btcrpcclient.ConnConfig) (*btcrpcclient.Client, error) {
+	connConfig := *config
+	connConfig.HTTPPostMode = true
+	connConfig.DisableConnectOnNew = true
+
+	return btcrpcclient.New(&connConfig, nil)
+}
+
+// Start fetches the current best block from bitcoind, then launches the
+// goroutine which polls for new blocks, and dispatches notifications.
+func (b *BitcoindNotifier) Start() error {
+	// Already started?
+	if atomic.AddInt32(&b.started, 1) != 1 {
+		return nil
+	}
+
+	bestHash, err := b.chainConn.GetBestBlockHash()
+	if err != nil {
+		return err
+	}
+	bestHeight, err := b.chainConn.GetBlockCount()
+	if err != nil {
+		return err
+	}
+	b.bestHash = *bestHash
+	b.bestHeight = int32(bestHeight)
+
+	b.wg.Add(1)
+	go b.notificationDispatcher()
+
+	return nil
+}
+
+// Stop shutsdown the BitcoindNotifier.
+func (b *BitcoindNotifier) Stop() error {
+	// Already shutting down?
+	if atomic.AddInt32(&b.stopped, 1) != 1 {
+		return nil
+	}
+
+	close(b.quit)
+	b.wg.Wait()
+
+	b.chainConn.Shutdown()
+
+	// Notify all pending clients of our shutdown by closing the related
+	// notification channels.
+	for _, spendClient := range b.spendNotifications {
+		close(spendClient.spendChan)
+	}
+	for _, confClient := range b.confNotifications {
+		close(confClient.finConf)
+		close(confClient.negativeConf)
+	}
+	for _, epochChan := range b.blockEpochClients {
+		close(epochChan)
+	}
+
+	return nil
+}
+
+// notificationDispatcher is the primary goroutine which handles client
+// notification registrations, polls bitcoind for new blocks, and dispatches
+// notifications.
+func (b *BitcoindNotifier) notificationDispatcher() {
+	pollTicker := time.NewTicker(b.pollInterval)
+	defer pollTicker.Stop()
+
+out:
+	for {
+		select {
+		case registerMsg := <-b.notificationRegistry:
+			switch msg := registerMsg.(type) {
+			case *spendNotification:
+				b.spendNotifications[*msg.targetOutpoint] = msg
+				b.catchUpSpend(msg.targetOutpoint)
+			case *confirmationsNotification:
+				chainntnfs.Log.Infof("New confirmations "+
+					"subscription: txid=%v, numconfs=%v",
+					*msg.txid, msg.numConfirmations)
+				b.registerConfs(msg)
+			case *blockEpochRegistration:
+				b.blockEpochClients = append(
+					b.blockEpochClients, msg.epochChan,
+				)
+				b.notifyBlockEpoch(msg.epochChan, b.bestHeight,
+					b.bestHash)
+			}
+		case <-pollTicker.C:
+			if err := b.pollBestBlock(); err != nil {
+				chainntnfs.Log.Errorf("Unable to poll bitcoind: %v",
+					err)
+			}
+		case <-b.quit:
+			break out
+		}
+	}
+	b.wg.Done()
+}
+
+// pollBestBlock queries bitcoind for its current best block. If it differs
+// from our best block, then the blocks connected since are fetched by height,
+// and processed in order, up to maxBlocksPerPoll at a time.
+func (b *BitcoindNotifier) pollBestBlock() error {
+	newHash, err := b.chainConn.GetBestBlockHash()
+	if err != nil {
+		return err
+	}
+	if *newHash == b.bestHash {
+		return nil
+	}
+	tipHeight, err := b.chainConn.GetBlockCount()
+	if err != nil {
+		return err
+	}
+
+	// If our best block is no longer within the main chain, then the
+	// chain has re-orged beneath us, so we'll rewind to the block at
+	// which it forked, then connect the blocks of the new chain.
+	if err := b.rewindToFork(int32(tipHeight)); err != nil {
+		return err
+	}
+
+	targetHeight := int32(tipHeight)
+	if targetHeight-b.bestHeight > maxBlocksPerPoll {
+		targetHeight = b.bestHeight + maxBlocksPerPoll
+	}
+	for height := b.bestHeight + 1; height <= targetHeight; height++ {
+		block, err := b.fetchBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+
+		// Should the chain re-org while we're catching up, then the
+		// remaining blocks are connected by the next poll, once it has
+		// rewound to the fork.
+		if block.Header.PrevBlock != b.bestHash {
+			return nil
+		}
+
+		b.connectBlock(block, height)
+	}
+
+	return nil
+}
+
+// rewindToFork walks our best block back until it's within bitcoind's main
+// chain, whose tip is at the passed height. As bitcoind retains the blocks of
+// stale chains, each block which was re-orged out can still be fetched in
+// order to find its parent. The clients of any transactions confirmed within
+// the re-orged blocks are notified of the depth of the re-org.
+func (b *BitcoindNotifier) rewindToFork(tipHeight int32) error {
+	staleHeight := b.bestHeight
+	defer func() {
+		if b.bestHeight < staleHeight {
+			b.disconnectConfs(staleHeight - b.bestHeight)
+		}
+	}()
+
+	for b.bestHeight > 0 {
+		if b.bestHeight <= tipHeight {
+			mainHash, err := b.chainConn.GetBlockHash(
+				int64(b.bestHeight),
+			)
+			if err != nil {
+				return err
+			}
+			if *mainHash == b.bestHash {
+				return nil
+			}
+		}
+
+		block, err := b.chainConn.GetBlock(&b.bestHash)
+		if err != nil {
+			return err
+		}
+
+		chainntnfs.Log.Warnf("Block %v at height %v was re-orged out",
+			b.bestHash, b.bestHeight)
+
+		b.bestHash = block.MsgBlock().Header.PrevBlock
+		b.bestHeight--
+	}
+
+	return nil
+}
+
+// disconnectConfs returns each pending confirmation notification whose
+// transaction was included within a block above our best block to the set of
+// unconfirmed notifications, sending the depth of the re-org which removed the
+// block to its client. The notification is then dispatched once the
+// transaction is included within the new chain.
+func (b *BitcoindNotifier) disconnectConfs(depth int32) {
+	// TODO(roasbeef): notifications which have already been dispatched,
+	// along with spends within the re-orged blocks aren't revoked.
+	var confirmed []*confEntry
+	for _, entry := range b.confHeap.items {
+		if entry.initialConfirmHeight <= uint32(b.bestHeight) {
+			confirmed = append(confirmed, entry)
+			continue
+		}
+
+		select {
+		case entry.negativeConf <- depth:
+		default:
+		}
+
+		b.confNotifications[*entry.txid] = entry.confirmationsNotification
+		b.putConfirmHint(entry.txid, uint32(b.bestHeight+1))
+	}
+
+	b.confHeap.items = confirmed
+	heap.Init(b.confHeap)
+}
+
+// connectBlock dispatches all notifications triggered by the connection of
+// the passed block at the passed height, then marks the block as our best
+// block.
+func (b *BitcoindNotifier) connectBlock(block *wire.MsgBlock, height int32) {
+	blockHash := block.BlockSha()
+	chainntnfs.Log.Infof("New block: height=%v, sha=%v", height, blockHash)
+
+	for _, tx := range block.Transactions {
+		b.checkSpendTrigger(tx)
+
+		txSha := tx.TxSha()
+		b.checkConfirmationTrigger(&txSha, height)
+	}
+
+	b.bestHash = blockHash
+	b.bestHeight = height
+
+	// All pending notifications have now been checked against this
+	// block, so advance their height hints.
+	b.updateScannedHeights(height)
+
+	// A new block has been connected to the main chain. Send out any N
+	// confirmation notifications which may have been triggered by this
+	// new block.
+	b.notifyConfs(height)
+
+	for _, epochChan := range b.blockEpochClients {
+		b.notifyBlockEpoch(epochChan, height, blockHash)
+	}
+}
+
+// notifyBlockEpoch sends an epoch for the block with the passed height, and
+// hash to a block epoch client.
+func (b *BitcoindNotifier) notifyBlockEpoch(
+	epochChan chan *chainntnfs.BlockEpoch, height int32, hash wire.ShaHash) {
+
+	epoch := &chainntnfs.BlockEpoch{
+		Height: height,
+		Hash:   &hash,
+	}
+	select {
+	case epochChan <- epoch:
+	case <-b.quit:
+	}
+}
+
+// registerConfs adds the passed confirmation notification to the set of
+// notifications to be dispatched. If the transaction has already been
+// included within the chain, then the notification is either dispatched
+// immediately, or scheduled for dispatch once the remaining confirmations
+// have been reached.
+func (b *BitcoindNotifier) registerConfs(ntfn *confirmationsNotification) {
+	// An error indicates that bitcoind doesn't know of the transaction
+	// (or that it lacks a transaction index), so we'll fall back to the
+	// height hint cache to catch up on any blocks we may have missed, and
+	// then wait for it to be included within a new block.
+	txInfo, err := b.chainConn.GetRawTransactionVerbose(ntfn.txid)
+	if err != nil || txInfo.Confirmations == 0 {
+		b.confNotifications[*ntfn.txid] = ntfn
+		b.catchUpConfirmation(ntfn.txid)
+		return
+	}
+
+	// The number of confirmations is relative to bitcoind's tip, which
+	// may be ahead of our best block, so we'll look up the height of the
+	// including block instead. If we haven't yet processed that block,
+	// then the notification is triggered once we do.
+	confHeight, err := b.txBlockHeight(txInfo.BlockHash,
+		int32(txInfo.Confirmations))
+	if err != nil {
+		chainntnfs.Log.Errorf("Unable to find block of txid=%v: %v",
+			ntfn.txid, err)
+	}
+	if err != nil || confHeight > b.bestHeight {
+		b.confNotifications[*ntfn.txid] = ntfn
+		b.catchUpConfirmation(ntfn.txid)
+		return
+	}
+
+	ntfn.initialConfirmHeight = uint32(confHeight)
+	finalConfHeight := ntfn.initialConfirmHeight + ntfn.numConfirmations - 1
+	if finalConfHeight <= uint32(b.bestHeight) {
+		ntfn.finConf <- int32(finalConfHeight)
+		b.deleteConfirmHint(ntfn.txid)
+		return
+	}
+
+	b.putConfirmHint(ntfn.txid, ntfn.initialConfirmHeight)
+	heap.Push(b.confHeap, &confEntry{
+		ntfn,
+		finalConfHeight,
+	})
+}
+
+// txBlockHeight returns the height of the main chain block with the passed
+// hash, which getrawtransaction reported as including a transaction with the
+// passed number of confirmations. The height implied by bitcoind's current tip
+// is checked first via getblockhash, walking back from there should further
+// blocks have been connected since the transaction was looked up.
+func (b *BitcoindNotifier) txBlockHeight(blockHashStr string,
+	confs int32) (int32, error) {
+
+	blockHash, err := wire.NewShaHashFromStr(blockHashStr)
+	if err != nil {
+		return 0, err
+	}
+	tipHeight, err := b.chainConn.GetBlockCount()
+	if err != nil {
+		return 0, err
+	}
+
+	startHeight := int32(tipHeight) - confs + 1
+	for height := startHeight; height >= 0; height-- {
+		if startHeight-height >= maxBlocksPerPoll {
+			break
+		}
+
+		mainHash, err := b.chainConn.GetBlockHash(int64(height))
+		if err != nil {
+			return 0, err
+		}
+		if *mainHash == *blockHash {
+			return height, nil
+		}
+	}
+
+	return 0, fmt.Errorf("block %v isn't within the main chain", blockHash)
+}
+
+// catchUpConfirmation consults the height hint cache for the passed txid,
+// rescanning all blocks connected since the txid was last checked. If the
+// transaction is found, then the pending notification is triggered as if the
+// block were newly connected. If no hint exists, then the txid is watched for
+// the first time, so a new hint is stored starting at the next block.
+func (b *BitcoindNotifier) catchUpConfirmation(txid *wire.ShaHash) {
+	if b.hintCache == nil {
+		return
+	}
+
+	hint, err := b.hintCache.FetchConfirmHint(txid)
+	switch {
+	case err == channeldb.ErrHeightHintNotFound:
+		b.putConfirmHint(txid, uint32(b.bestHeight+1))
+		return
+	case err != nil:
+		chainntnfs.Log.Errorf("Unable to fetch height hint for "+
+			"txid=%v: %v", txid, err)
+		return
+	}
+
+	startHeight := chainntnfs.RescanStartHeight(hint)
+	if startHeight <= b.bestHeight {
+		chainntnfs.Log.Infof("Rescanning blocks %v-%v for txid=%v",
+			startHeight, b.bestHeight, txid)
+	}
+	for height := startHeight; height <= b.bestHeight; height++ {
+		block, err := b.fetchBlockByHeight(height)
+		if err != nil {
+			chainntnfs.Log.Errorf("Unable to rescan block %v: %v",
+				height, err)
+			return
+		}
+
+		for _, tx := range block.Transactions {
+			txSha := tx.TxSha()
+			if txSha != *txid {
+				continue
+			}
+
+			b.checkConfirmationTrigger(&txSha, height)
+			b.notifyConfs(b.bestHeight)
+			return
+		}
+	}
+
+	b.updateScannedHeights(b.bestHeight)
+}
+
+// catchUpSpend consults the height hint cache for the passed outpoint,
+// rescanning all blocks connected since the outpoint was last checked. If a
+// spend is found, then the pending notification is dispatched. If no hint
+// exists, then the outpoint is watched for the first time, so a new hint is
+// stored starting at the next block.
+func (b *BitcoindNotifier) catchUpSpend(op *wire.OutPoint) {
+	if b.hintCache == nil {
+		return
+	}
+
+	hint, err := b.hintCache.FetchSpendHint(op)
+	switch {
+	case err == channeldb.ErrHeightHintNotFound:
+		hint = &channeldb.HeightHint{
+			StartHeight:   uint32(b.bestHeight + 1),
+			ScannedHeight: uint32(b.bestHeight),
+		}
+		if err := b.hintCache.PutSpendHint(op, hint); err != nil {
+			chainntnfs.Log.Errorf("Unable to store height hint "+
+				"for outpoint=%v: %v", op, err)
+		}
+		return
+	case err != nil:
+		chainntnfs.Log.Errorf("Unable to fetch height hint for "+
+			"outpoint=%v: %v", op, err)
+		return
+	}
+
+	startHeight := chainntnfs.RescanStartHeight(hint)
+	if startHeight <= b.bestHeight {
+		chainntnfs.Log.Infof("Rescanning blocks %v-%v for outpoint=%v",
+			startHeight, b.bestHeight, op)
+	}
+	for height := startHeight; height <= b.bestHeight; height++ {
+		block, err := b.fetchBlockByHeight(height)
+		if err != nil {
+			chainntnfs.Log.Errorf("Unable to rescan block %v: %v",
+				height, err)
+			return
+		}
+
+		for _, tx := range block.Transactions {
+			b.checkSpendTrigger(tx)
+		}
+		if _, ok := b.spendNotifications[*op]; !ok {
+			return
+		}
+	}
+
+	b.updateScannedHeights(b.bestHeight)
+}
+
+// fetchBlockByHeight returns the main chain block at the target height.
+func (b *BitcoindNotifier) fetchBlockByHeight(height int32) (*wire.MsgBlock, error) {
+	blockHash, err := b.chainConn.GetBlockHash(int64(height))
+	if err != nil {
+		return nil, err
+	}
+	block, err := b.chainConn.GetBlock(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return block.MsgBlock(), nil
+}
+
+// putConfirmHint stores a height hint for txid which has either confirmed at,
+// or can't confirm before confHeight.
+func (b *BitcoindNotifier) putConfirmHint(txid *wire.ShaHash, confHeight uint32) {
+	if b.hintCache == nil {
+		return
+	}
+
+	hint := &channeldb.HeightHint{
+		StartHeight:   confHeight,
+		ScannedHeight: confHeight - 1,
+	}
+	if err := b.hintCache.PutConfirmHint(txid, hint); err != nil {
+		chainntnfs.Log.Errorf("Unable to store height hint for "+
+			"txid=%v: %v", txid, err)
+	}
+}
+
+// deleteConfirmHint removes the height hint for txid once its confirmation
+// notification has been dispatched.
+func (b *BitcoindNotifier) deleteConfirmHint(txid *wire.ShaHash) {
+	if b.hintCache == nil {
+		return
+	}
+
+	if err := b.hintCache.DeleteConfirmHint(txid); err != nil {
+		chainntnfs.Log.Errorf("Unable to delete height hint for "+
+			"txid=%v: %v", txid, err)
+	}
+}
+
+// updateScannedHeights advances the height hints of all transactions, and
+// outpoints which are still waiting to be included within a block.
+func (b *BitcoindNotifier) updateScannedHeights(height int32) {
+	if b.hintCache == nil {
+		return
+	}
+
+	txids := make([]wire.ShaHash, 0, len(b.confNotifications))
+	for txid := range b.confNotifications {
+		txids = append(txids, txid)
+	}
+	ops := make([]wire.OutPoint, 0, len(b.spendNotifications))
+	for op := range b.spendNotifications {
+		ops = append(ops, op)
+	}
+
+	err := b.hintCache.UpdateScannedHeights(txids, ops, uint32(height))
+	if err != nil {
+		chainntnfs.Log.Errorf("Unable to update height hints: %v", err)
+	}
+}
+
+// checkSpendTrigger dispatches a spend notification for each input of the
+// passed transaction which spends a watched outpoint.
+func (b *BitcoindNotifier) checkSpendTrigger(tx *wire.MsgTx) {
+	for i, txIn := range tx.TxIn {
+		prevOut := txIn.PreviousOutPoint
+
+		ntfn, ok := b.spendNotifications[prevOut]
+		if !ok {
+			continue
+		}
+
+		spenderSha := tx.TxSha()
+		ntfn.spendChan <- &chainntnfs.SpendDetail{
+			SpentOutPoint:     ntfn.targetOutpoint,
+			SpenderTxHash:     &spenderSha,
+			SpendingTx:        tx,
+			SpenderInputIndex: uint32(i),
+		}
+
+		delete(b.spendNotifications, prevOut)
+
+		if b.hintCache == nil {
+			continue
+		}
+		if err := b.hintCache.DeleteSpendHint(&prevOut); err != nil {
+			chainntnfs.Log.Errorf("Unable to delete height hint "+
+				"for outpoint=%v: %v", prevOut, err)
+		}
+	}
+}
+
+// notifyConfs examines the current confirmation heap, sending off any
+// notifications which have been triggered by the connection of a new block at
+// newBlockHeight.
+func (b *BitcoindNotifier) notifyConfs(newBlockHeight int32) {
+	// If the heap is empty, we have nothing to do.
+	if b.confHeap.Len() == 0 {
+		return
+	}
+
+	// The heap is a min-heap, so the confirmation notification which
+	// requires the smallest block-height will always be at the top of the
+	// heap. Fire off eligible notifications until there are no more
+	// eligible entries.
+	nextConf := heap.Pop(b.confHeap).(*confEntry)
+	for nextConf.triggerHeight <= uint32(newBlockHeight) {
+		nextConf.finConf <- newBlockHeight
+		b.deleteConfirmHint(nextConf.txid)
+
+		if b.confHeap.Len() == 0 {
+			return
+		}
+
+		nextConf = heap.Pop(b.confHeap).(*confEntry)
+	}
+
+	heap.Push(b.confHeap, nextConf)
+}
+
+// checkConfirmationTrigger determines if the passed txSha included at
+// blockHeight triggers any single confirmation notifications. In the event
+// that the txid matches, yet needs additional confirmations, it is added to
+// the confirmation heap to be triggered at a later time.
+func (b *BitcoindNotifier) checkConfirmationTrigger(txSha *wire.ShaHash, blockHeight int32) {
+	confNtfn, ok := b.confNotifications[*txSha]
+	if !ok {
+		return
+	}
+
+	delete(b.confNotifications, *txSha)
+	if confNtfn.numConfirmations == 1 {
+		chainntnfs.Log.Infof("Dispatching single conf "+
+			"notification, sha=%v, height=%v", txSha,
+			blockHeight)
+		confNtfn.finConf <- blockHeight
+		b.deleteConfirmHint(txSha)
+		return
+	}
+
+	// The registered notification requires more than one confirmation
+	// before triggering, so we'll add an entry to the heap to be fired off
+	// once the final confirmation height has been reached. The height
+	// hint now points at the confirming block, so it can be found again
+	// after a restart.
+	confNtfn.initialConfirmHeight = uint32(blockHeight)
+	b.putConfirmHint(txSha, confNtfn.initialConfirmHeight)
+	finalConfHeight := confNtfn.initialConfirmHeight + confNtfn.numConfirmations - 1
+	heap.Push(b.confHeap, &confEntry{
+		confNtfn,
+		finalConfHeight,
+	})
+}
+
+// spendNotification couples a target outpoint along with the channel used for
+// notifications once a spend of the outpoint has been detected.
+type spendNotification struct {
+	targetOutpoint *wire.OutPoint
+
+	spendChan chan *chainntnfs.SpendDetail
+}
+
+// RegisterSpendNtfn registers an intent to be notified once the target
+// outpoint has been spent by a transaction on-chain. Spends are detected as
+// the blocks including them are connected.
+func (b *BitcoindNotifier) RegisterSpendNtfn(outpoint *wire.OutPoint) (*chainntnfs.SpendEvent, error) {
+	ntfn := &spendNotification{
+		targetOutpoint: outpoint,
+		spendChan:      make(chan *chainntnfs.SpendDetail, 1),
+	}
+
+	select {
+	case b.notificationRegistry <- ntfn:
+	case <-b.quit:
+		return nil, ErrNotifierShuttingDown
+	}
+
+	return &chainntnfs.SpendEvent{ntfn.spendChan}, nil
+}
+
+// confirmationNotification represents a client's intent to receive a
+// notification once the target txid reaches numConfirmations confirmations.
+type confirmationsNotification struct {
+	txid *wire.ShaHash
+
+	initialConfirmHeight uint32
+	numConfirmations     uint32
+
+	finConf      chan int32
+	negativeConf chan int32 // TODO(roasbeef): re-org funny business
+}
+
+// RegisterConfirmationsNtfn registers a notification with BitcoindNotifier
+// which will be triggered once the txid reaches numConfs number of
+// confirmations.
+func (b *BitcoindNotifier) RegisterConfirmationsNtfn(txid *wire.ShaHash,
+	numConfs uint32) (*chainntnfs.ConfirmationEvent, error) {
+
+	ntfn := &confirmationsNotification{
+		txid:             txid,
+		numConfirmations: numConfs,
+		finConf:          make(chan int32, 1),
+		negativeConf:     make(chan int32, 1),
+	}
+
+	select {
+	case b.notificationRegistry <- ntfn:
+	case <-b.quit:
+		return nil, ErrNotifierShuttingDown
+	}
+
+	return &chainntnfs.ConfirmationEvent{
+		Confirmed:    ntfn.finConf,
+		NegativeConf: ntfn.negativeConf,
+	}, nil
+}
+
+// blockEpochRegistration represents a client's intent to receive an epoch for
+// each new block connected to the main chain.
+type blockEpochRegistration struct {
+	epochChan chan *chainntnfs.BlockEpoch
+}
+
+// RegisterBlockEpochNtfn returns a BlockEpochEvent which subscribes the
+// caller to receive notifications of each new block connected to the main
+// chain. The first epoch sent is that of our best block, so the caller learns
+// the current height right away.
+//
+// TODO(roasbeef): targetHeight is currently unused.
+func (b *BitcoindNotifier) RegisterBlockEpochNtfn(targetHeight int32) (*chainntnfs.BlockEpochEvent, error) {
+	registration := &blockEpochRegistration{
+		epochChan: make(chan *chainntnfs.BlockEpoch, 20),
+	}
+
+	select {
+	case b.notificationRegistry <- registration:
+	case <-b.quit:
+		return nil, ErrNotifierShuttingDown
+	}
+
+	return &chainntnfs.BlockEpochEvent{
+		Epochs: registration.epochChan,
+	}, nil
+}
@@ -0,0 +1,785 @@
+package bitcoindnotify
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/chainntfs"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/roasbeef/btcd/chaincfg"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcrpcclient"
+)
+
+// rpcRequest, and rpcResponse are the subset of a JSON-RPC request, and
+// response understood by the mockBitcoind.
+type rpcRequest struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	ID     interface{}       `json:"id"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	Result interface{} `json:"result"`
+	Error  *rpcError   `json:"error"`
+	ID     interface{} `json:"id"`
+}
+
+// mockBitcoind is a local HTTP stand-in for bitcoind's JSON-RPC interface. It
+// serves canned responses derived from an in-memory chain of blocks which is
+// extended by the test via mineBlock.
+type mockBitcoind struct {
+	sync.Mutex
+
+	server *httptest.Server
+
+	blocks   []*wire.MsgBlock
+	txHeight map[wire.ShaHash]int32
+
+	// staleBlocks are the blocks which were re-orged out of the chain,
+	// which, like bitcoind, the mock can still serve via getblock. Each
+	// re-org increments numReorgs, so the blocks of the new chain differ
+	// from those they replace.
+	staleBlocks []*wire.MsgBlock
+	numReorgs   uint32
+
+	// noTxIndex mimics a bitcoind node running without -txindex, which
+	// is unable to look up confirmed transactions via getrawtransaction.
+	noTxIndex bool
+
+	// blockHashQueries counts the number of getblockhash calls, each of
+	// which is made when rescanning a block by height.
+	blockHashQueries int
+
+	sentTxns []*wire.MsgTx
+}
+
+// newMockBitcoind starts a new mockBitcoind whose chain consists of only a
+// single block at height zero.
+func newMockBitcoind() *mockBitcoind {
+	m := &mockBitcoind{
+		txHeight: make(map[wire.ShaHash]int32),
+	}
+	m.mineBlock()
+
+	m.server = httptest.NewServer(http.HandlerFunc(m.handleRequest))
+	return m
+}
+
+// connConfig returns a config which can be used to connect to the
+// mockBitcoind.
+func (m *mockBitcoind) connConfig() *btcrpcclient.ConnConfig {
+	return &btcrpcclient.ConnConfig{
+		Host:         strings.TrimPrefix(m.server.URL, "http://"),
+		DisableTLS:   true,
+		HTTPPostMode: true,
+	}
+}
+
+// mineBlock extends the mock chain with a new block including the passed
+// transactions.
+func (m *mockBitcoind) mineBlock(txns ...*wire.MsgTx) {
+	m.Lock()
+	defer m.Unlock()
+
+	height := int32(len(m.blocks))
+
+	var prevHash wire.ShaHash
+	if height != 0 {
+		prevHash = m.blocks[height-1].BlockSha()
+	}
+
+	block := &wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Version:   1,
+			PrevBlock: prevHash,
+			Timestamp: time.Unix(1466000000+int64(height), 0),
+			Nonce:     uint32(height) + m.numReorgs<<16,
+		},
+	}
+	for _, tx := range txns {
+		block.AddTransaction(tx)
+		m.txHeight[tx.TxSha()] = height
+	}
+
+	m.blocks = append(m.blocks, block)
+}
+
+// reorg replaces the last depth blocks of the mock chain, and the
+// transactions they include, with the blocks of a new chain including the
+// passed transactions. The new chain has one block more than those replaced,
+// so it becomes the best chain.
+func (m *mockBitcoind) reorg(depth int, txns ...*wire.MsgTx) {
+	m.Lock()
+	forkHeight := len(m.blocks) - depth
+	for _, block := range m.blocks[forkHeight:] {
+		for _, tx := range block.Transactions {
+			delete(m.txHeight, tx.TxSha())
+		}
+	}
+	m.staleBlocks = append(m.staleBlocks, m.blocks[forkHeight:]...)
+	m.blocks = m.blocks[:forkHeight]
+	m.numReorgs++
+	m.Unlock()
+
+	m.mineBlock(txns...)
+	for i := 0; i < depth; i++ {
+		m.mineBlock()
+	}
+}
+
+// findTx returns the transaction with the passed txid, along with the height
+// of the block which includes it.
+func (m *mockBitcoind) findTx(txid wire.ShaHash) (*wire.MsgTx, int32, bool) {
+	height, ok := m.txHeight[txid]
+	if !ok {
+		return nil, 0, false
+	}
+	for _, tx := range m.blocks[height].Transactions {
+		if tx.TxSha() == txid {
+			return tx, height, true
+		}
+	}
+	return nil, 0, false
+}
+
+// handleRequest decodes a single JSON-RPC request, and replies with the
+// canned response for the requested method.
+func (m *mockBitcoind) handleRequest(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, rpcErr := m.dispatch(&req)
+	json.NewEncoder(w).Encode(&rpcResponse{
+		Result: result,
+		Error:  rpcErr,
+		ID:     req.ID,
+	})
+}
+
+// dispatch computes the result of the passed JSON-RPC request.
+func (m *mockBitcoind) dispatch(req *rpcRequest) (interface{}, *rpcError) {
+	m.Lock()
+	defer m.Unlock()
+
+	var strParam string
+	if len(req.Params) > 0 {
+		json.Unmarshal(req.Params[0], &strParam)
+	}
+
+	tip := int32(len(m.blocks) - 1)
+
+	switch req.Method {
+	case "getbestblockhash":
+		return m.blocks[tip].BlockSha().String(), nil
+
+	case "getblockcount":
+		return tip, nil
+
+	case "getblockhash":
+		var height int32
+		if len(req.Params) > 0 {
+			json.Unmarshal(req.Params[0], &height)
+		}
+		if height < 0 || height > tip {
+			return nil, &rpcError{-8, "Block height out of range"}
+		}
+		m.blockHashQueries++
+		return m.blocks[height].BlockSha().String(), nil
+
+	case "getblock":
+		var blocks []*wire.MsgBlock
+		blocks = append(blocks, m.blocks...)
+		blocks = append(blocks, m.staleBlocks...)
+		for _, block := range blocks {
+			if block.BlockSha().String() != strParam {
+				continue
+			}
+
+			var b bytes.Buffer
+			if err := block.Serialize(&b); err != nil {
+				return nil, &rpcError{-1, err.Error()}
+			}
+			return hex.EncodeToString(b.Bytes()), nil
+		}
+		return nil, &rpcError{-5, "Block not found"}
+
+	case "getrawtransaction":
+		txid, err := wire.NewShaHashFromStr(strParam)
+		if err != nil {
+			return nil, &rpcError{-8, err.Error()}
+		}
+		tx, height, ok := m.findTx(*txid)
+		if !ok || m.noTxIndex {
+			return nil, &rpcError{-5, "No information available " +
+				"about transaction"}
+		}
+
+		var b bytes.Buffer
+		if err := tx.Serialize(&b); err != nil {
+			return nil, &rpcError{-1, err.Error()}
+		}
+		return map[string]interface{}{
+			"hex":           hex.EncodeToString(b.Bytes()),
+			"txid":          txid.String(),
+			"blockhash":     m.blocks[height].BlockSha().String(),
+			"confirmations": tip - height + 1,
+		}, nil
+
+	case "sendrawtransaction":
+		txBytes, err := hex.DecodeString(strParam)
+		if err != nil {
+			return nil, &rpcError{-22, "TX decode failed"}
+		}
+		tx := wire.NewMsgTx()
+		if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+			return nil, &rpcError{-22, "TX decode failed"}
+		}
+		m.sentTxns = append(m.sentTxns, tx)
+		return tx.TxSha().String(), nil
+	}
+
+	return nil, &rpcError{-32601, "Method not found"}
+}
+
+// createTestTx returns a new transaction spending the passed outpoint. The
+// scripts are irrelevant as the mockBitcoind doesn't validate transactions.
+func createTestTx(prevOut *wire.OutPoint) *wire.MsgTx {
+	tx := wire.NewMsgTx()
+	tx.AddTxIn(wire.NewTxIn(prevOut, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1e8, []byte{0x51}))
+	return tx
+}
+
+func waitForConf(confIntent *chainntnfs.ConfirmationEvent, t *testing.T) int32 {
+	select {
+	case height := <-confIntent.Confirmed:
+		return height
+	case <-time.After(2 * time.Second):
+		t.Fatalf("confirmation notification never received")
+	}
+	return 0
+}
+
+func testSingleConfirmationNotification(node *mockBitcoind,
+	notifier chainntnfs.ChainNotifier, t *testing.T) {
+
+	// Register for a single confirmation of a transaction which hasn't
+	// yet been included within the chain.
+	tx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x01}})
+	txid := tx.TxSha()
+	confIntent, err := notifier.RegisterConfirmationsNtfn(&txid, 1)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+
+	// Once a block including the transaction is found by the poller, the
+	// notification should be dispatched with the block's height.
+	node.mineBlock(tx)
+	confHeight := waitForConf(confIntent, t)
+	if expected := node.txHeight[txid]; confHeight != expected {
+		t.Fatalf("confirmation height mismatch: expected %v, got %v",
+			expected, confHeight)
+	}
+}
+
+func testMultiConfirmationNotification(node *mockBitcoind,
+	notifier chainntnfs.ChainNotifier, t *testing.T) {
+
+	tx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x02}})
+	txid := tx.TxSha()
+	confIntent, err := notifier.RegisterConfirmationsNtfn(&txid, 3)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+
+	// After only two confirmations, the notification shouldn't yet have
+	// been dispatched.
+	node.mineBlock(tx)
+	node.mineBlock()
+	select {
+	case <-confIntent.Confirmed:
+		t.Fatalf("notification dispatched before 3 confirmations")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The third block should trigger the notification.
+	node.mineBlock()
+	waitForConf(confIntent, t)
+}
+
+func testConfirmedBeforeRegistration(node *mockBitcoind,
+	notifier chainntnfs.ChainNotifier, t *testing.T) {
+
+	// Include a transaction within the chain, and bury it under two more
+	// blocks before registering for its confirmation.
+	tx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x03}})
+	txid := tx.TxSha()
+	node.mineBlock(tx)
+	node.mineBlock()
+	node.mineBlock()
+
+	// As the transaction already has three confirmations, as reported by
+	// getrawtransaction, the notification should be dispatched without
+	// waiting for any new blocks.
+	confIntent, err := notifier.RegisterConfirmationsNtfn(&txid, 3)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	waitForConf(confIntent, t)
+}
+
+func testSpendNotification(node *mockBitcoind,
+	notifier chainntnfs.ChainNotifier, t *testing.T) {
+
+	outpoint := &wire.OutPoint{Hash: wire.ShaHash{0x04}, Index: 1}
+	spendIntent, err := notifier.RegisterSpendNtfn(outpoint)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+
+	// Include a transaction spending the outpoint, alongside another
+	// unrelated transaction, within a new block.
+	unrelatedTx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x05}})
+	spendingTx := createTestTx(outpoint)
+	node.mineBlock(unrelatedTx, spendingTx)
+
+	select {
+	case spendDetails := <-spendIntent.Spend:
+		if *spendDetails.SpentOutPoint != *outpoint {
+			t.Fatalf("spend detail has wrong outpoint: expected %v, "+
+				"got %v", outpoint, spendDetails.SpentOutPoint)
+		}
+		spenderSha := spendingTx.TxSha()
+		if *spendDetails.SpenderTxHash != spenderSha {
+			t.Fatalf("spend detail has wrong spender: expected %v, "+
+				"got %v", spenderSha, spendDetails.SpenderTxHash)
+		}
+		if spendDetails.SpenderInputIndex != 0 {
+			t.Fatalf("spend detail has wrong input index: "+
+				"expected 0, got %v", spendDetails.SpenderInputIndex)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("spend notification never received")
+	}
+}
+
+func testManyMissedBlocks(node *mockBitcoind,
+	notifier chainntnfs.ChainNotifier, t *testing.T) {
+
+	tx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x09}})
+	txid := tx.TxSha()
+	confIntent, err := notifier.RegisterConfirmationsNtfn(&txid, 1)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	outpoint := &wire.OutPoint{Hash: wire.ShaHash{0x0a}}
+	spendIntent, err := notifier.RegisterSpendNtfn(outpoint)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+
+	// Connect more blocks than are processed by a single poll, with the
+	// transaction confirmed within the first batch, and the outpoint
+	// spent within the last. Neither should be missed.
+	node.Lock()
+	startHeight := int32(len(node.blocks))
+	node.Unlock()
+	for i := 0; i < 2*maxBlocksPerPoll+10; i++ {
+		switch i {
+		case 10:
+			node.mineBlock(tx)
+		case 2*maxBlocksPerPoll + 5:
+			node.mineBlock(createTestTx(outpoint))
+		default:
+			node.mineBlock()
+		}
+	}
+
+	confHeight := waitForConf(confIntent, t)
+	if expected := startHeight + 10; confHeight != expected {
+		t.Fatalf("confirmation height mismatch: expected %v, got %v",
+			expected, confHeight)
+	}
+	select {
+	case <-spendIntent.Spend:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("spend notification never received")
+	}
+}
+
+func testReorg(node *mockBitcoind,
+	notifier chainntnfs.ChainNotifier, t *testing.T) {
+
+	// Wait for a new block to be processed, so the notifier's best block
+	// is the mock's tip.
+	staleTx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x0b}})
+	staleTxid := staleTx.TxSha()
+	staleIntent, err := notifier.RegisterConfirmationsNtfn(&staleTxid, 1)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	node.mineBlock(staleTx)
+	node.mineBlock()
+	waitForConf(staleIntent, t)
+
+	// Re-org out both blocks, replacing them with a chain including a
+	// transaction we're now waiting on. The notifier should rewind to the
+	// fork, then find the transaction within the new chain.
+	tx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x0c}})
+	txid := tx.TxSha()
+	confIntent, err := notifier.RegisterConfirmationsNtfn(&txid, 2)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	node.reorg(2, tx)
+
+	confHeight := waitForConf(confIntent, t)
+	node.Lock()
+	expected := node.txHeight[txid] + 1
+	node.Unlock()
+	if confHeight != expected {
+		t.Fatalf("confirmation height mismatch: expected %v, got %v",
+			expected, confHeight)
+	}
+}
+
+func testReorgNegativeConf(node *mockBitcoind,
+	notifier chainntnfs.ChainNotifier, t *testing.T) {
+
+	// Include a transaction awaiting three confirmations within the
+	// chain, followed by a block including a transaction awaiting a
+	// single confirmation, which signals once both blocks have been
+	// processed.
+	tx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x0d}})
+	txid := tx.TxSha()
+	confIntent, err := notifier.RegisterConfirmationsNtfn(&txid, 3)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	syncTx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x0e}})
+	syncTxid := syncTx.TxSha()
+	syncIntent, err := notifier.RegisterConfirmationsNtfn(&syncTxid, 1)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	node.mineBlock(tx)
+	node.mineBlock(syncTx)
+	waitForConf(syncIntent, t)
+
+	// Re-orging out both blocks should notify the client of the depth of
+	// the re-org.
+	node.reorg(2)
+	select {
+	case depth := <-confIntent.NegativeConf:
+		if depth != 2 {
+			t.Fatalf("expected re-org depth of 2, got %v", depth)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("negative confirmation notification never received")
+	}
+
+	// Once the transaction is included within the new chain, and has
+	// three confirmations, the notification should be dispatched.
+	node.mineBlock(tx)
+	node.mineBlock()
+	node.mineBlock()
+	confHeight := waitForConf(confIntent, t)
+	node.Lock()
+	expected := node.txHeight[txid] + 2
+	node.Unlock()
+	if confHeight != expected {
+		t.Fatalf("confirmation height mismatch: expected %v, got %v",
+			expected, confHeight)
+	}
+}
+
+func testBlockEpochNotification(node *mockBitcoind,
+	notifier chainntnfs.ChainNotifier, t *testing.T) {
+
+	// Sync up with the notifier, so its best block is the mock's tip.
+	tx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x10}})
+	txid := tx.TxSha()
+	confIntent, err := notifier.RegisterConfirmationsNtfn(&txid, 1)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	node.mineBlock(tx)
+	waitForConf(confIntent, t)
+
+	epochEvent, err := notifier.RegisterBlockEpochNtfn(0)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	expectEpoch := func() {
+		node.Lock()
+		tip := node.blocks[len(node.blocks)-1]
+		tipHeight := int32(len(node.blocks) - 1)
+		node.Unlock()
+
+		select {
+		case epoch := <-epochEvent.Epochs:
+			if epoch.Height != tipHeight ||
+				*epoch.Hash != tip.BlockSha() {
+
+				t.Fatalf("expected epoch for block %v at "+
+					"height %v, got %v at height %v",
+					tip.BlockSha(), tipHeight, epoch.Hash,
+					epoch.Height)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("block epoch never received")
+		}
+	}
+
+	// The first epoch should be that of the current best block, followed
+	// by one for each newly connected block.
+	expectEpoch()
+	node.mineBlock()
+	expectEpoch()
+	node.mineBlock()
+	expectEpoch()
+}
+
+var ntfnTests = []func(node *mockBitcoind, notifier chainntnfs.ChainNotifier, t *testing.T){
+	testSingleConfirmationNotification,
+	testMultiConfirmationNotification,
+	testConfirmedBeforeRegistration,
+	testSpendNotification,
+	testManyMissedBlocks,
+	testReorg,
+	testReorgNegativeConf,
+	testBlockEpochNotification,
+}
+
+func TestBitcoindNotifier(t *testing.T) {
+	node := newMockBitcoind()
+	defer node.server.Close()
+
+	notifier, err := NewBitcoindNotifier(node.connConfig(),
+		10*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("unable to create notifier: %v", err)
+	}
+	if err := notifier.Start(); err != nil {
+		t.Fatalf("unable to start notifier: %v", err)
+	}
+	defer notifier.Stop()
+
+	for _, ntfnTest := range ntfnTests {
+		ntfnTest(node, notifier, t)
+	}
+}
+
+// TestBitcoindNotifierHeightHints ensures that transactions confirmed, and
+// outpoints spent while the notifier was offline are found after a restart,
+// and that only the blocks connected while offline are rescanned.
+func TestBitcoindNotifierHeightHints(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "bitcoindnotify")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := channeldb.Open(tempDirName, &chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	node := newMockBitcoind()
+	defer node.server.Close()
+	node.noTxIndex = true
+
+	startNotifier := func() *BitcoindNotifier {
+		notifier, err := NewBitcoindNotifier(node.connConfig(),
+			10*time.Millisecond, cdb)
+		if err != nil {
+			t.Fatalf("unable to create notifier: %v", err)
+		}
+		if err := notifier.Start(); err != nil {
+			t.Fatalf("unable to start notifier: %v", err)
+		}
+		return notifier
+	}
+
+	// Register for the confirmation of a transaction, and the spend of an
+	// outpoint, neither of which have yet made it into the chain.
+	notifier := startNotifier()
+	tx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x07}})
+	txid := tx.TxSha()
+	if _, err := notifier.RegisterConfirmationsNtfn(&txid, 1); err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	outpoint := &wire.OutPoint{Hash: wire.ShaHash{0x08}}
+	if _, err := notifier.RegisterSpendNtfn(outpoint); err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+
+	// Connect a few unrelated blocks, the scanned height of both hints
+	// should be advanced as each is processed.
+	node.mineBlock()
+	node.mineBlock()
+	for i := 0; ; i++ {
+		hint, err := cdb.FetchConfirmHint(&txid)
+		if err == nil && hint.ScannedHeight == 2 {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("confirm hint never advanced: %v, %v", hint,
+				err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	spendHint, err := cdb.FetchSpendHint(outpoint)
+	if err != nil {
+		t.Fatalf("unable to fetch spend hint: %v", err)
+	}
+	if spendHint.StartHeight != 1 || spendHint.ScannedHeight != 2 {
+		t.Fatalf("spend hint not advanced: %v", spendHint)
+	}
+	notifier.Stop()
+
+	// While the notifier is offline, both the transaction, and the spend
+	// of the outpoint are included within the chain, buried under a
+	// couple more blocks.
+	node.mineBlock(tx)
+	node.mineBlock(createTestTx(outpoint))
+	node.mineBlock()
+
+	node.Lock()
+	node.blockHashQueries = 0
+	node.Unlock()
+
+	// After a restart, both notifications should be dispatched once
+	// they've been registered again.
+	notifier = startNotifier()
+	defer notifier.Stop()
+
+	confIntent, err := notifier.RegisterConfirmationsNtfn(&txid, 1)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	if confHeight := waitForConf(confIntent, t); confHeight != 3 {
+		t.Fatalf("confirmation height mismatch: expected 3, got %v",
+			confHeight)
+	}
+	spendIntent, err := notifier.RegisterSpendNtfn(outpoint)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	select {
+	case <-spendIntent.Spend:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("spend notification never received")
+	}
+
+	// Only the blocks connected while offline should've been rescanned:
+	// the first block for the confirmation, and the first two blocks for
+	// the spend.
+	node.Lock()
+	numQueries := node.blockHashQueries
+	node.Unlock()
+	if numQueries != 3 {
+		t.Fatalf("expected 3 blocks to be rescanned, instead %v were",
+			numQueries)
+	}
+
+	// Now that both notifications have been dispatched, their hints
+	// should have been removed.
+	if _, err := cdb.FetchConfirmHint(&txid); err != channeldb.ErrHeightHintNotFound {
+		t.Fatalf("confirm hint not removed: %v", err)
+	}
+	if _, err := cdb.FetchSpendHint(outpoint); err != channeldb.ErrHeightHintNotFound {
+		t.Fatalf("spend hint not removed: %v", err)
+	}
+}
+
+// TestBitcoindNotifierConfirmedAhead ensures that the confirmation height of
+// a transaction which was confirmed before registration is that of its block,
+// even if bitcoind's tip is ahead of the notifier's best block.
+func TestBitcoindNotifierConfirmedAhead(t *testing.T) {
+	node := newMockBitcoind()
+	defer node.server.Close()
+
+	tx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x0f}})
+	txid := tx.TxSha()
+	node.mineBlock(tx)
+
+	// The notifier never polls, so its best block remains the block
+	// including the transaction, while bitcoind's tip moves ahead.
+	notifier, err := NewBitcoindNotifier(node.connConfig(), time.Hour,
+		nil)
+	if err != nil {
+		t.Fatalf("unable to create notifier: %v", err)
+	}
+	if err := notifier.Start(); err != nil {
+		t.Fatalf("unable to start notifier: %v", err)
+	}
+	defer notifier.Stop()
+
+	node.mineBlock()
+	node.mineBlock()
+
+	// A single confirmation should be dispatched with the height of the
+	// transaction's block.
+	confIntent, err := notifier.RegisterConfirmationsNtfn(&txid, 1)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	if confHeight := waitForConf(confIntent, t); confHeight != 1 {
+		t.Fatalf("confirmation height mismatch: expected 1, got %v",
+			confHeight)
+	}
+
+	// As the notifier has yet to process the block confirming the
+	// transaction a second time, a notification for two confirmations
+	// shouldn't be dispatched.
+	confIntent, err = notifier.RegisterConfirmationsNtfn(&txid, 2)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+	select {
+	case <-confIntent.Confirmed:
+		t.Fatalf("notification dispatched before 2 confirmations")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBroadcaster(t *testing.T) {
+	node := newMockBitcoind()
+	defer node.server.Close()
+
+	broadcaster, err := NewBroadcaster(node.connConfig())
+	if err != nil {
+		t.Fatalf("unable to create broadcaster: %v", err)
+	}
+
+	tx := createTestTx(&wire.OutPoint{Hash: wire.ShaHash{0x06}})
+	if err := broadcaster.BroadcastTransaction(tx); err != nil {
+		t.Fatalf("unable to broadcast tx: %v", err)
+	}
+
+	node.Lock()
+	defer node.Unlock()
+	if len(node.sentTxns) != 1 || node.sentTxns[0].TxSha() != tx.TxSha() {
+		t.Fatalf("transaction wasn't sent to bitcoind")
+	}
+}
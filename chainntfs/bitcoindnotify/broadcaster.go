@@ -0,0 +1,30 @@
+package bitcoindnotify
+
+import (
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcrpcclient"
+)
+
+// Broadcaster publishes transactions to the network via bitcoind's
+// sendrawtransaction JSON-RPC call.
+type Broadcaster struct {
+	chainConn *btcrpcclient.Client
+}
+
+// NewBroadcaster returns a new Broadcaster which publishes transactions via
+// the bitcoind node detailed in the passed configuration.
+func NewBroadcaster(config *btcrpcclient.ConnConfig) (*Broadcaster, error) {
+	chainConn, err := newBitcoindClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Broadcaster{chainConn}, nil
+}
+
+// BroadcastTransaction submits the passed transaction to bitcoind's mempool,
+// from which it'll be relayed to the rest of the network.
+func (b *Broadcaster) BroadcastTransaction(tx *wire.MsgTx) error {
+	_, err := b.chainConn.SendRawTransaction(tx, false)
+	return err
+}
@@ -2,27 +2,60 @@ package btcdnotify
 
 import (
 	"container/heap"
-	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/lightningnetwork/lnd/chainntfs"
+	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/roasbeef/btcd/btcjson"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcrpcclient"
 	"github.com/roasbeef/btcutil"
 )
 
+const (
+	// initialReconnectBackoff is the time waited before the first attempt
+	// to re-establish a lost connection to btcd.
+	initialReconnectBackoff = time.Second
+
+	// maxReconnectBackoff is the maximum time waited between two attempts
+	// to re-establish a lost connection to btcd.
+	maxReconnectBackoff = time.Minute
+)
+
 // BtcdNotifier implements the ChainNotifier interface using btcd's websockets
 // notifications. Multiple concurrent clients are supported. All notifications
 // are achieved via non-blocking sends on client channels.
+//
+// If the websockets connection to btcd is lost, then the notifier reconnects
+// with an exponential backoff. Once reconnected, all blocks connected during
+// the outage are fed through the regular dispatch path before live
+// notifications are resumed.
 type BtcdNotifier struct {
 	started int32 // To be used atomically.
 	stopped int32 // To be used atomically.
 
+	// connMtx guards chainConn, which is replaced each time the
+	// connection to btcd is re-established.
+	connMtx   sync.RWMutex
 	chainConn *btcrpcclient.Client
 
+	config        btcrpcclient.ConnConfig
+	ntfnCallbacks *btcrpcclient.NotificationHandlers
+
+	// reconnected is sent upon by the connectionMonitor once a lost
+	// connection to btcd has been re-established.
+	reconnected chan struct{}
+
+	// bestHash, and bestHeight are the hash, and height of the last
+	// processed block. Both are only accessed by the
+	// notificationDispatcher once the notifier has been started.
+	bestHash   wire.ShaHash
+	bestHeight int32
+
+	hintCache chainntnfs.HeightHintCache
+
 	notificationRegistry chan interface{}
 
 	// TODO(roasbeef): make map point to slices? Would allow for multiple
@@ -31,6 +64,10 @@ type BtcdNotifier struct {
 	confNotifications  map[wire.ShaHash]*confirmationsNotification
 	confHeap           *confirmationHeap
 
+	// blockEpochClients are sent an epoch for each block connected to the
+	// main chain.
+	blockEpochClients []chan *chainntnfs.BlockEpoch
+
 	connectedBlockHashes    chan *blockNtfn
 	disconnectedBlockHashes chan *blockNtfn
 	relevantTxs             chan *btcutil.Tx
@@ -44,9 +81,14 @@ var _ chainntnfs.ChainNotifier = (*BtcdNotifier)(nil)
 
 // NewBtcdNotifier returns a new BtcdNotifier instance. This function assumes
 // the btcd node detailed in the passed configuration is already running, and
-// willing to accept new websockets clients.
-func NewBtcdNotifier(config *btcrpcclient.ConnConfig) (*BtcdNotifier, error) {
+// willing to accept new websockets clients. The hintCache is optional, if nil
+// then no height hints are persisted, and no rescans are performed.
+func NewBtcdNotifier(config *btcrpcclient.ConnConfig,
+	hintCache chainntnfs.HeightHintCache) (*BtcdNotifier, error) {
+
 	notifier := &BtcdNotifier{
+		hintCache: hintCache,
+
 		notificationRegistry: make(chan interface{}),
 
 		spendNotifications: make(map[wire.OutPoint]*spendNotification),
@@ -57,20 +99,26 @@ func NewBtcdNotifier(config *btcrpcclient.ConnConfig) (*BtcdNotifier, error) {
 		disconnectedBlockHashes: make(chan *blockNtfn, 20),
 		relevantTxs:             make(chan *btcutil.Tx, 100),
 
+		reconnected: make(chan struct{}),
+
 		quit: make(chan struct{}),
 	}
 
-	ntfnCallbacks := &btcrpcclient.NotificationHandlers{
+	notifier.ntfnCallbacks = &btcrpcclient.NotificationHandlers{
 		OnBlockConnected:    notifier.onBlockConnected,
 		OnBlockDisconnected: notifier.onBlockDisconnected,
 		OnRedeemingTx:       notifier.onRedeemingTx,
 	}
 
 	// Disable connecting to btcd within the btcrpcclient.New method. We defer
-	// establishing the connection to our .Start() method.
+	// establishing the connection to our .Start() method. The client's
+	// own reconnection logic is disabled, as it silently drops all blocks
+	// connected while it's offline. Instead, the connectionMonitor
+	// reconnects, and catches up on any missed blocks.
 	config.DisableConnectOnNew = true
-	config.DisableAutoReconnect = false
-	chainConn, err := btcrpcclient.New(config, ntfnCallbacks)
+	config.DisableAutoReconnect = true
+	notifier.config = *config
+	chainConn, err := btcrpcclient.New(config, notifier.ntfnCallbacks)
 	if err != nil {
 		return nil, err
 	}
@@ -96,8 +144,16 @@ func (b *BtcdNotifier) Start() error {
 		return err
 	}
 
-	b.wg.Add(1)
+	bestHash, bestHeight, err := b.chainConn.GetBestBlock()
+	if err != nil {
+		return err
+	}
+	b.bestHash = *bestHash
+	b.bestHeight = bestHeight
+
+	b.wg.Add(2)
 	go b.notificationDispatcher()
+	go b.connectionMonitor()
 
 	return nil
 }
@@ -110,11 +166,13 @@ func (b *BtcdNotifier) Stop() error {
 	}
 
 	// Shutdown the rpc client, this gracefully disconnects from btcd, and
-	// cleans up all related resources.
-	b.chainConn.Shutdown()
-
+	// cleans up all related resources. The client is shutdown once more
+	// after all goroutines have exited, as the connectionMonitor may have
+	// swapped in a new client in the meantime.
 	close(b.quit)
+	b.conn().Shutdown()
 	b.wg.Wait()
+	b.conn().Shutdown()
 
 	// Notify all pending clients of our shutdown by closing the related
 	// notification channels.
@@ -125,10 +183,107 @@ func (b *BtcdNotifier) Stop() error {
 		close(confClient.finConf)
 		close(confClient.negativeConf)
 	}
+	for _, epochChan := range b.blockEpochClients {
+		close(epochChan)
+	}
 
 	return nil
 }
 
+// conn returns the current connection to btcd.
+func (b *BtcdNotifier) conn() *btcrpcclient.Client {
+	b.connMtx.RLock()
+	defer b.connMtx.RUnlock()
+
+	return b.chainConn
+}
+
+// connectionMonitor waits for the current connection to btcd to be lost.
+// Once it is, a new connection is established, and the
+// notificationDispatcher is signalled to catch up on any blocks missed while
+// disconnected.
+func (b *BtcdNotifier) connectionMonitor() {
+	defer b.wg.Done()
+
+	for {
+		// With auto-reconnect disabled, the client is shutdown as soon
+		// as the websockets connection is lost.
+		b.conn().WaitForShutdown()
+
+		select {
+		case <-b.quit:
+			return
+		default:
+		}
+
+		chainntnfs.Log.Warnf("Lost connection to btcd, reconnecting")
+
+		chainConn := b.reconnect()
+		if chainConn == nil {
+			return
+		}
+
+		b.connMtx.Lock()
+		b.chainConn = chainConn
+		b.connMtx.Unlock()
+
+		select {
+		case b.reconnected <- struct{}{}:
+		case <-b.quit:
+			return
+		}
+	}
+}
+
+// reconnect attempts to establish a new connection to btcd, doubling the
+// delay after each failed attempt up to maxReconnectBackoff. If the notifier
+// is stopped before a connection could be established, then nil is returned.
+func (b *BtcdNotifier) reconnect() *btcrpcclient.Client {
+	backoff := initialReconnectBackoff
+	for {
+		select {
+		case <-time.After(backoff):
+		case <-b.quit:
+			return nil
+		}
+
+		chainConn, err := b.connect()
+		if err == nil {
+			chainntnfs.Log.Infof("Reconnected to btcd")
+			return chainConn
+		}
+
+		backoff *= 2
+		if backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+
+		chainntnfs.Log.Errorf("Unable to reconnect to btcd, retrying "+
+			"in %v: %v", backoff, err)
+	}
+}
+
+// connect creates a new websockets connection to btcd, and registers for
+// notifications on connected, and disconnected blocks.
+func (b *BtcdNotifier) connect() (*btcrpcclient.Client, error) {
+	config := b.config
+	chainConn, err := btcrpcclient.New(&config, b.ntfnCallbacks)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := chainConn.Connect(1); err != nil {
+		chainConn.Shutdown()
+		return nil, err
+	}
+	if err := chainConn.NotifyBlocks(); err != nil {
+		chainConn.Shutdown()
+		return nil, err
+	}
+
+	return chainConn, nil
+}
+
 // blockNtfn packages a notification of a connected/disconnected block along
 // with its height at the time.
 type blockNtfn struct {
@@ -146,7 +301,10 @@ func (b *BtcdNotifier) onBlockConnected(hash *wire.ShaHash, height int32, t time
 
 // onBlockDisconnected implements on OnBlockDisconnected callback for btcrpcclient.
 func (b *BtcdNotifier) onBlockDisconnected(hash *wire.ShaHash, height int32, t time.Time) {
-	b.onBlockDisconnected(hash, height, t)
+	select {
+	case b.disconnectedBlockHashes <- &blockNtfn{hash, height}:
+	case <-b.quit:
+	}
 }
 
 // onRedeemingTx implements on OnRedeemingTx callback for btcrpcclient.
@@ -167,68 +325,45 @@ out:
 			switch msg := registerMsg.(type) {
 			case *spendNotification:
 				b.spendNotifications[*msg.targetOutpoint] = msg
+				b.catchUpSpend(msg.targetOutpoint)
 			case *confirmationsNotification:
 				chainntnfs.Log.Infof("New confirmations "+
 					"subscription: txid=%v, numconfs=%v",
 					*msg.txid, msg.numConfirmations)
 				b.confNotifications[*msg.txid] = msg
+				b.catchUpConfirmation(msg.txid)
+			case *blockEpochRegistration:
+				b.blockEpochClients = append(
+					b.blockEpochClients, msg.epochChan,
+				)
+				b.notifyBlockEpoch(msg.epochChan, b.bestHeight,
+					b.bestHash)
 			}
-		case staleBlockHash := <-b.disconnectedBlockHashes:
-			// TODO(roasbeef): re-orgs
-			//  * second channel to notify of confirmation decrementing
-			//    re-org?
-			//  * notify of negative confirmations
-			fmt.Println(staleBlockHash)
+		case staleBlock := <-b.disconnectedBlockHashes:
+			// The blocks of the new chain are connected once the
+			// re-org completes, at which point we rewind to the
+			// fork, so there's nothing to be done yet.
+			chainntnfs.Log.Infof("Block disconnected: height=%v, "+
+				"sha=%v", staleBlock.height, staleBlock.sha)
 		case connectedBlock := <-b.connectedBlockHashes:
-			newBlock, err := b.chainConn.GetBlock(connectedBlock.sha)
-			if err != nil {
-				continue
+			if err := b.handleConnectedBlock(connectedBlock); err != nil {
+				chainntnfs.Log.Errorf("Unable to process block "+
+					"%v: %v", connectedBlock.sha, err)
 			}
-
-			chainntnfs.Log.Infof("New block: height=%v, sha=%v",
-				connectedBlock.height, connectedBlock.sha)
-
-			newHeight := connectedBlock.height
-			for _, tx := range newBlock.Transactions() {
-				// Check if the inclusion of this transaction
-				// within a block by itself triggers a block
-				// confirmation threshold, if so send a
-				// notification. Otherwise, place the notification
-				// on a heap to be triggered in the future once
-				// additional confirmations are attained.
-				txSha := tx.Sha()
-				b.checkConfirmationTrigger(txSha, newHeight)
+		case <-b.reconnected:
+			// Any spends, or blocks which occurred while we were
+			// disconnected were never delivered, so re-register
+			// our watched outpoints with the new connection, then
+			// walk forward from our last processed block to the
+			// new tip.
+			if err := b.catchUp(); err != nil {
+				chainntnfs.Log.Errorf("Unable to catch up after "+
+					"reconnecting: %v", err)
 			}
-
-			// A new block has been connected to the main
-			// chain. Send out any N confirmation notifications
-			// which may have been triggered by this new block.
-			b.notifyConfs(newHeight)
 		case newSpend := <-b.relevantTxs:
 			// First, check if this transaction spends an output
 			// that has an existing spend notification for it.
-			for i, txIn := range newSpend.MsgTx().TxIn {
-				prevOut := txIn.PreviousOutPoint
-
-				// If this transaction indeed does spend an
-				// output which we have a registered notification
-				// for, then create a spend summary, finally
-				// sending off the details to the notification
-				// subscriber.
-				if ntfn, ok := b.spendNotifications[prevOut]; ok {
-					spenderSha := newSpend.Sha()
-					spendDetails := &chainntnfs.SpendDetail{
-						SpentOutPoint: ntfn.targetOutpoint,
-						SpenderTxHash: spenderSha,
-						// TODO(roasbeef): copy tx?
-						SpendingTx:        newSpend.MsgTx(),
-						SpenderInputIndex: uint32(i),
-					}
-
-					ntfn.spendChan <- spendDetails
-					delete(b.spendNotifications, prevOut)
-				}
-			}
+			b.checkSpendTrigger(newSpend.MsgTx())
 		case <-b.quit:
 			break out
 		}
@@ -236,6 +371,418 @@ out:
 	b.wg.Done()
 }
 
+// handleConnectedBlock processes a block newly connected to btcd's main chain.
+// If the block directly extends our last processed block, then it's connected
+// right away. Otherwise, either the chain has re-orged, or we've missed some
+// blocks along the way. So we rewind to the fork if our last processed block
+// is no longer within the main chain, then connect each block of the main
+// chain up to, and including the height of the new block. Notifications for
+// blocks which have already been processed while catching up are skipped.
+func (b *BtcdNotifier) handleConnectedBlock(ntfn *blockNtfn) error {
+	if *ntfn.sha == b.bestHash {
+		return nil
+	}
+	if ntfn.height == b.bestHeight+1 {
+		block, err := b.conn().GetBlock(ntfn.sha)
+		if err != nil {
+			return err
+		}
+		if block.MsgBlock().Header.PrevBlock == b.bestHash {
+			b.connectBlock(block.MsgBlock(), ntfn.height)
+			return nil
+		}
+	}
+
+	_, tipHeight, err := b.conn().GetBestBlock()
+	if err != nil {
+		return err
+	}
+	if err := b.rewindToFork(tipHeight); err != nil {
+		return err
+	}
+
+	return b.connectMissedBlocks(ntfn.height)
+}
+
+// rewindToFork walks our last processed block back until it's within btcd's
+// main chain, whose tip is at the passed height. As btcd retains the blocks
+// of stale chains, each block which was re-orged out can still be fetched in
+// order to find its parent. The clients of any transactions confirmed within
+// the re-orged blocks are notified of the depth of the re-org.
+func (b *BtcdNotifier) rewindToFork(tipHeight int32) error {
+	staleHeight := b.bestHeight
+	defer func() {
+		if b.bestHeight < staleHeight {
+			b.disconnectConfs(staleHeight - b.bestHeight)
+		}
+	}()
+
+	for b.bestHeight > 0 {
+		if b.bestHeight <= tipHeight {
+			mainHash, err := b.conn().GetBlockHash(
+				int64(b.bestHeight),
+			)
+			if err != nil {
+				return err
+			}
+			if *mainHash == b.bestHash {
+				return nil
+			}
+		}
+
+		block, err := b.conn().GetBlock(&b.bestHash)
+		if err != nil {
+			return err
+		}
+
+		chainntnfs.Log.Warnf("Block %v at height %v was re-orged out",
+			b.bestHash, b.bestHeight)
+
+		b.bestHash = block.MsgBlock().Header.PrevBlock
+		b.bestHeight--
+	}
+
+	return nil
+}
+
+// disconnectConfs returns each pending confirmation notification whose
+// transaction was included within a block above our last processed block to
+// the set of unconfirmed notifications, sending the depth of the re-org
+// which removed the block to its client. The notification is then dispatched
+// once the transaction is included within the new chain.
+func (b *BtcdNotifier) disconnectConfs(depth int32) {
+	// TODO(roasbeef): notifications which have already been dispatched,
+	// along with spends within the re-orged blocks aren't revoked.
+	var confirmed []*confEntry
+	for _, entry := range b.confHeap.items {
+		if entry.initialConfirmHeight <= uint32(b.bestHeight) {
+			confirmed = append(confirmed, entry)
+			continue
+		}
+
+		select {
+		case entry.negativeConf <- depth:
+		default:
+		}
+
+		b.confNotifications[*entry.txid] = entry.confirmationsNotification
+		b.putConfirmHint(entry.txid, uint32(b.bestHeight+1))
+	}
+
+	b.confHeap.items = confirmed
+	heap.Init(b.confHeap)
+}
+
+// catchUp re-registers all watched outpoints with btcd, then connects each
+// block between our last processed block, and btcd's current best block. If
+// the chain has re-orged while we were disconnected, then we first rewind to
+// the fork.
+func (b *BtcdNotifier) catchUp() error {
+	outpoints := make([]*wire.OutPoint, 0, len(b.spendNotifications))
+	for _, ntfn := range b.spendNotifications {
+		outpoints = append(outpoints, ntfn.targetOutpoint)
+	}
+	if len(outpoints) != 0 {
+		if err := b.conn().NotifySpent(outpoints); err != nil {
+			return err
+		}
+	}
+
+	_, bestHeight, err := b.conn().GetBestBlock()
+	if err != nil {
+		return err
+	}
+	if err := b.rewindToFork(bestHeight); err != nil {
+		return err
+	}
+
+	return b.connectMissedBlocks(bestHeight)
+}
+
+// connectMissedBlocks fetches, and connects each block after our last
+// processed block up to, and including the block at targetHeight.
+func (b *BtcdNotifier) connectMissedBlocks(targetHeight int32) error {
+	if targetHeight <= b.bestHeight {
+		return nil
+	}
+
+	chainntnfs.Log.Infof("Catching up on missed blocks %v-%v",
+		b.bestHeight+1, targetHeight)
+
+	for height := b.bestHeight + 1; height <= targetHeight; height++ {
+		block, err := b.fetchBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+
+		// Should the chain re-org while we're catching up, then the
+		// remaining blocks are connected once the next block of the
+		// new chain is, as we'll then rewind to the fork.
+		if block.Header.PrevBlock != b.bestHash {
+			return nil
+		}
+
+		b.connectBlock(block, height)
+	}
+
+	return nil
+}
+
+// connectBlock dispatches all notifications triggered by the connection of
+// the passed block at the passed height, then marks the block as our last
+// processed block.
+func (b *BtcdNotifier) connectBlock(block *wire.MsgBlock, height int32) {
+	blockHash := block.BlockSha()
+	chainntnfs.Log.Infof("New block: height=%v, sha=%v", height, blockHash)
+
+	for _, tx := range block.Transactions {
+		// Spends are usually delivered as relevant transactions, but
+		// those included within blocks connected while we were
+		// disconnected are only detected here.
+		b.checkSpendTrigger(tx)
+
+		// Check if the inclusion of this transaction within a block
+		// by itself triggers a block confirmation threshold, if so
+		// send a notification. Otherwise, place the notification on
+		// a heap to be triggered in the future once additional
+		// confirmations are attained.
+		txSha := tx.TxSha()
+		b.checkConfirmationTrigger(&txSha, height)
+	}
+
+	// All pending notifications have now been checked against this
+	// block, so advance their height hints.
+	b.bestHash = blockHash
+	b.bestHeight = height
+	b.updateScannedHeights(height)
+
+	// A new block has been connected to the main chain. Send out any N
+	// confirmation notifications which may have been triggered by this
+	// new block.
+	b.notifyConfs(height)
+
+	for _, epochChan := range b.blockEpochClients {
+		b.notifyBlockEpoch(epochChan, height, blockHash)
+	}
+}
+
+// notifyBlockEpoch sends an epoch for the block with the passed height, and
+// hash to a block epoch client.
+func (b *BtcdNotifier) notifyBlockEpoch(epochChan chan *chainntnfs.BlockEpoch,
+	height int32, hash wire.ShaHash) {
+
+	epoch := &chainntnfs.BlockEpoch{
+		Height: height,
+		Hash:   &hash,
+	}
+	select {
+	case epochChan <- epoch:
+	case <-b.quit:
+	}
+}
+
+// checkSpendTrigger dispatches a spend notification for each input of the
+// passed transaction which spends a watched outpoint.
+func (b *BtcdNotifier) checkSpendTrigger(tx *wire.MsgTx) {
+	for i, txIn := range tx.TxIn {
+		prevOut := txIn.PreviousOutPoint
+
+		// If this transaction indeed does spend an output which we
+		// have a registered notification for, then create a spend
+		// summary, finally sending off the details to the
+		// notification subscriber.
+		ntfn, ok := b.spendNotifications[prevOut]
+		if !ok {
+			continue
+		}
+
+		spenderSha := tx.TxSha()
+		ntfn.spendChan <- &chainntnfs.SpendDetail{
+			SpentOutPoint: ntfn.targetOutpoint,
+			SpenderTxHash: &spenderSha,
+			// TODO(roasbeef): copy tx?
+			SpendingTx:        tx,
+			SpenderInputIndex: uint32(i),
+		}
+		delete(b.spendNotifications, prevOut)
+
+		if b.hintCache == nil {
+			continue
+		}
+		if err := b.hintCache.DeleteSpendHint(&prevOut); err != nil {
+			chainntnfs.Log.Errorf("Unable to delete height hint "+
+				"for outpoint=%v: %v", prevOut, err)
+		}
+	}
+}
+
+// catchUpConfirmation consults the height hint cache for the passed txid,
+// rescanning all blocks connected since the txid was last checked. If the
+// transaction is found, then the pending notification is triggered as if the
+// block were newly connected. If no hint exists, then the txid is watched for
+// the first time, so a new hint is stored starting at the next block.
+func (b *BtcdNotifier) catchUpConfirmation(txid *wire.ShaHash) {
+	if b.hintCache == nil {
+		return
+	}
+
+	hint, err := b.hintCache.FetchConfirmHint(txid)
+	switch {
+	case err == channeldb.ErrHeightHintNotFound:
+		b.putConfirmHint(txid, uint32(b.bestHeight+1))
+		return
+	case err != nil:
+		chainntnfs.Log.Errorf("Unable to fetch height hint for "+
+			"txid=%v: %v", txid, err)
+		return
+	}
+
+	startHeight := chainntnfs.RescanStartHeight(hint)
+	if startHeight <= b.bestHeight {
+		chainntnfs.Log.Infof("Rescanning blocks %v-%v for txid=%v",
+			startHeight, b.bestHeight, txid)
+	}
+	for height := startHeight; height <= b.bestHeight; height++ {
+		block, err := b.fetchBlockByHeight(height)
+		if err != nil {
+			chainntnfs.Log.Errorf("Unable to rescan block %v: %v",
+				height, err)
+			return
+		}
+
+		for _, tx := range block.Transactions {
+			txSha := tx.TxSha()
+			if txSha != *txid {
+				continue
+			}
+
+			b.checkConfirmationTrigger(&txSha, height)
+			b.notifyConfs(b.bestHeight)
+			return
+		}
+	}
+
+	b.updateScannedHeights(b.bestHeight)
+}
+
+// catchUpSpend consults the height hint cache for the passed outpoint,
+// rescanning all blocks connected since the outpoint was last checked. If a
+// spend is found, then the pending notification is dispatched. If no hint
+// exists, then the outpoint is watched for the first time, so a new hint is
+// stored starting at the next block.
+func (b *BtcdNotifier) catchUpSpend(op *wire.OutPoint) {
+	if b.hintCache == nil {
+		return
+	}
+
+	hint, err := b.hintCache.FetchSpendHint(op)
+	switch {
+	case err == channeldb.ErrHeightHintNotFound:
+		hint = &channeldb.HeightHint{
+			StartHeight:   uint32(b.bestHeight + 1),
+			ScannedHeight: uint32(b.bestHeight),
+		}
+		if err := b.hintCache.PutSpendHint(op, hint); err != nil {
+			chainntnfs.Log.Errorf("Unable to store height hint "+
+				"for outpoint=%v: %v", op, err)
+		}
+		return
+	case err != nil:
+		chainntnfs.Log.Errorf("Unable to fetch height hint for "+
+			"outpoint=%v: %v", op, err)
+		return
+	}
+
+	startHeight := chainntnfs.RescanStartHeight(hint)
+	if startHeight <= b.bestHeight {
+		chainntnfs.Log.Infof("Rescanning blocks %v-%v for outpoint=%v",
+			startHeight, b.bestHeight, op)
+	}
+	for height := startHeight; height <= b.bestHeight; height++ {
+		block, err := b.fetchBlockByHeight(height)
+		if err != nil {
+			chainntnfs.Log.Errorf("Unable to rescan block %v: %v",
+				height, err)
+			return
+		}
+
+		for _, tx := range block.Transactions {
+			b.checkSpendTrigger(tx)
+		}
+		if _, ok := b.spendNotifications[*op]; !ok {
+			return
+		}
+	}
+
+	b.updateScannedHeights(b.bestHeight)
+}
+
+// fetchBlockByHeight returns the main chain block at the target height.
+func (b *BtcdNotifier) fetchBlockByHeight(height int32) (*wire.MsgBlock, error) {
+	blockHash, err := b.conn().GetBlockHash(int64(height))
+	if err != nil {
+		return nil, err
+	}
+	block, err := b.conn().GetBlock(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return block.MsgBlock(), nil
+}
+
+// putConfirmHint stores a height hint for txid which has either confirmed at,
+// or can't confirm before confHeight.
+func (b *BtcdNotifier) putConfirmHint(txid *wire.ShaHash, confHeight uint32) {
+	if b.hintCache == nil {
+		return
+	}
+
+	hint := &channeldb.HeightHint{
+		StartHeight:   confHeight,
+		ScannedHeight: confHeight - 1,
+	}
+	if err := b.hintCache.PutConfirmHint(txid, hint); err != nil {
+		chainntnfs.Log.Errorf("Unable to store height hint for "+
+			"txid=%v: %v", txid, err)
+	}
+}
+
+// deleteConfirmHint removes the height hint for txid once its confirmation
+// notification has been dispatched.
+func (b *BtcdNotifier) deleteConfirmHint(txid *wire.ShaHash) {
+	if b.hintCache == nil {
+		return
+	}
+
+	if err := b.hintCache.DeleteConfirmHint(txid); err != nil {
+		chainntnfs.Log.Errorf("Unable to delete height hint for "+
+			"txid=%v: %v", txid, err)
+	}
+}
+
+// updateScannedHeights advances the height hints of all transactions, and
+// outpoints which are still waiting to be included within a block.
+func (b *BtcdNotifier) updateScannedHeights(height int32) {
+	if b.hintCache == nil {
+		return
+	}
+
+	txids := make([]wire.ShaHash, 0, len(b.confNotifications))
+	for txid := range b.confNotifications {
+		txids = append(txids, txid)
+	}
+	ops := make([]wire.OutPoint, 0, len(b.spendNotifications))
+	for op := range b.spendNotifications {
+		ops = append(ops, op)
+	}
+
+	err := b.hintCache.UpdateScannedHeights(txids, ops, uint32(height))
+	if err != nil {
+		chainntnfs.Log.Errorf("Unable to update height hints: %v", err)
+	}
+}
+
 // notifyConfs examines the current confirmation heap, sending off any
 // notifications which have been triggered by the connection of a new block at
 // newBlockHeight.
@@ -254,6 +801,7 @@ func (b *BtcdNotifier) notifyConfs(newBlockHeight int32) {
 	nextConf := heap.Pop(b.confHeap).(*confEntry)
 	for nextConf.triggerHeight <= uint32(newBlockHeight) {
 		nextConf.finConf <- newBlockHeight
+		b.deleteConfirmHint(nextConf.txid)
 
 		if b.confHeap.Len() == 0 {
 			return
@@ -283,6 +831,7 @@ func (b *BtcdNotifier) checkConfirmationTrigger(txSha *wire.ShaHash, blockHeight
 				"notification, sha=%v, height=%v", txSha,
 				blockHeight)
 			confNtfn.finConf <- blockHeight
+			b.deleteConfirmHint(txSha)
 			return
 		}
 
@@ -293,6 +842,7 @@ func (b *BtcdNotifier) checkConfirmationTrigger(txSha *wire.ShaHash, blockHeight
 		// which notification(s) we should fire off with
 		// each incoming block.
 		confNtfn.initialConfirmHeight = uint32(blockHeight)
+		b.putConfirmHint(txSha, confNtfn.initialConfirmHeight)
 		finalConfHeight := uint32(confNtfn.initialConfirmHeight + confNtfn.numConfirmations - 1)
 		heapEntry := &confEntry{
 			confNtfn,
@@ -315,7 +865,7 @@ type spendNotification struct {
 // outpoint has been detected, the details of the spending event will be sent
 // across the 'Spend' channel.
 func (b *BtcdNotifier) RegisterSpendNtfn(outpoint *wire.OutPoint) (*chainntnfs.SpendEvent, error) {
-	if err := b.chainConn.NotifySpent([]*wire.OutPoint{outpoint}); err != nil {
+	if err := b.conn().NotifySpent([]*wire.OutPoint{outpoint}); err != nil {
 		return nil, err
 	}
 
@@ -362,10 +912,26 @@ func (b *BtcdNotifier) RegisterConfirmationsNtfn(txid *wire.ShaHash,
 	}, nil
 }
 
+// blockEpochRegistration represents a client's intent to receive an epoch for
+// each new block connected to the main chain.
+type blockEpochRegistration struct {
+	epochChan chan *chainntnfs.BlockEpoch
+}
+
 // RegisterBlockEpochNtfn returns a BlockEpochEvent which subscribes the
-// caller to receive notificationsm, of each new block connected to the main
-// chain.
+// caller to receive notifications of each new block connected to the main
+// chain. The first epoch sent is that of our last processed block, so the
+// caller learns the current height right away.
+//
+// TODO(roasbeef): targetHeight is currently unused.
 func (b *BtcdNotifier) RegisterBlockEpochNtfn(targetHeight int32) (*chainntnfs.BlockEpochEvent, error) {
-	// TODO(roasbeef): implement
-	return nil, nil
+	registration := &blockEpochRegistration{
+		epochChan: make(chan *chainntnfs.BlockEpoch, 20),
+	}
+
+	b.notificationRegistry <- registration
+
+	return &chainntnfs.BlockEpochEvent{
+		Epochs: registration.epochChan,
+	}, nil
 }
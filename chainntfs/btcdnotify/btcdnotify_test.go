@@ -279,11 +279,47 @@ func testSpendNotification(miner *rpctest.Harness,
 	}
 }
 
+func testReconnectCatchUp(miner *rpctest.Harness,
+	notifier chainntnfs.ChainNotifier, t *testing.T) {
+
+	// First, obtain a txid, and register for its first confirmation.
+	txid, err := getTestTxId(miner)
+	if err != nil {
+		t.Fatalf("unable to create test addr: %v", err)
+	}
+	confIntent, err := notifier.RegisterConfirmationsNtfn(txid, 1)
+	if err != nil {
+		t.Fatalf("unable to register ntfn: %v", err)
+	}
+
+	// Next, sever the notifier's websockets connection, then mine the
+	// transaction, along with a few more blocks, while it's offline.
+	notifier.(*BtcdNotifier).conn().Disconnect()
+	if _, err := miner.Node.Generate(3); err != nil {
+		t.Fatalf("unable to generate blocks: %v", err)
+	}
+
+	// Once the notifier has reconnected, it should walk the blocks it
+	// missed, triggering the notification.
+	select {
+	case <-confIntent.Confirmed:
+		break
+	case <-time.After(initialReconnectBackoff + 5*time.Second):
+		t.Fatalf("confirmation notification never received after " +
+			"reconnecting")
+	}
+
+	// Finally, live notifications should be resumed over the new
+	// connection.
+	testSingleConfirmationNotification(miner, notifier, t)
+}
+
 var ntfnTests = []func(node *rpctest.Harness, notifier chainntnfs.ChainNotifier, t *testing.T){
 	testSingleConfirmationNotification,
 	testMultiConfirmationNotification,
 	testBatchConfirmationNotification,
 	testSpendNotification,
+	testReconnectCatchUp,
 }
 
 // TODO(roasbeef): make test generic across all interfaces?
@@ -305,7 +341,7 @@ func TestBtcdNotifier(t *testing.T) {
 	}
 
 	nodeConfig := miner.RPCConfig()
-	notifier, err := NewBtcdNotifier(&nodeConfig)
+	notifier, err := NewBtcdNotifier(&nodeConfig, nil)
 	if err != nil {
 		t.Fatalf("unable to create notifier: %v", err)
 	}
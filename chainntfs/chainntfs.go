@@ -1,6 +1,9 @@
 package chainntnfs
 
-import "github.com/roasbeef/btcd/wire"
+import (
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/roasbeef/btcd/wire"
+)
 
 // ChainNotifier represents a trusted source to receive notifications concerning
 // targeted events on the Bitcoin blockchain. The interface specification is
@@ -104,3 +107,57 @@ type BlockEpoch struct {
 type BlockEpochEvent struct {
 	Epochs chan *BlockEpoch // MUST be buffered.
 }
+
+// HeightHintCache is a persistent store of the height hints for each txid,
+// and outpoint watched by a ChainNotifier. ChainNotifier implementations
+// should store a hint when a notification is first registered, advance the
+// scanned height of all pending hints as new blocks are connected, and
+// remove the hint once the notification has been dispatched. After a
+// restart, the stored hints allow a notifier to only rescan the blocks
+// which were connected while it was offline, rather than the entire chain.
+//
+// The channeldb.DB satisfies this interface.
+type HeightHintCache interface {
+	// PutConfirmHint stores the height hint for the confirmation of txid.
+	PutConfirmHint(txid *wire.ShaHash, hint *channeldb.HeightHint) error
+
+	// FetchConfirmHint returns the height hint for the confirmation of
+	// txid, or channeldb.ErrHeightHintNotFound if none exists.
+	FetchConfirmHint(txid *wire.ShaHash) (*channeldb.HeightHint, error)
+
+	// DeleteConfirmHint removes the height hint for the confirmation of
+	// txid.
+	DeleteConfirmHint(txid *wire.ShaHash) error
+
+	// PutSpendHint stores the height hint for the spend of an outpoint.
+	PutSpendHint(op *wire.OutPoint, hint *channeldb.HeightHint) error
+
+	// FetchSpendHint returns the height hint for the spend of an
+	// outpoint, or channeldb.ErrHeightHintNotFound if none exists.
+	FetchSpendHint(op *wire.OutPoint) (*channeldb.HeightHint, error)
+
+	// DeleteSpendHint removes the height hint for the spend of an
+	// outpoint.
+	DeleteSpendHint(op *wire.OutPoint) error
+
+	// UpdateScannedHeights marks all blocks up to, and including height
+	// as checked for each of the passed txids, and outpoints.
+	UpdateScannedHeights(txids []wire.ShaHash, ops []wire.OutPoint,
+		height uint32) error
+}
+
+// Ensure the channeldb.DB implements the HeightHintCache interface at compile
+// time.
+var _ HeightHintCache = (*channeldb.DB)(nil)
+
+// RescanStartHeight returns the height of the first block which must be
+// examined in order to catch up on any confirmation, or spend which may have
+// occurred since the passed hint was last updated.
+func RescanStartHeight(hint *channeldb.HeightHint) int32 {
+	startHeight := hint.ScannedHeight + 1
+	if hint.StartHeight > startHeight {
+		startHeight = hint.StartHeight
+	}
+
+	return int32(startHeight)
+}
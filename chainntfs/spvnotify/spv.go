@@ -0,0 +1,519 @@
+package spvnotify
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lightningnetwork/lnd/chainntfs"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/uspv"
+	"github.com/roasbeef/btcd/wire"
+)
+
+var (
+	// ErrHardModeRequired is returned when attempting to create an
+	// SPVNotifier on top of a uspv connection which only fetches filtered
+	// blocks.
+	ErrHardModeRequired = errors.New("spv notifier requires a hard mode " +
+		"connection")
+
+	// ErrNotifierShuttingDown is returned when attempting to register for
+	// a notification while the notifier is shutting down.
+	ErrNotifierShuttingDown = errors.New("notifier is shutting down")
+)
+
+// SPVNotifier implements the ChainNotifier interface using the full blocks
+// synced by a uspv connection running in hard mode. As every block is fetched
+// in its entirety, both spends and confirmations can be detected without any
+// help from the remote node.
+type SPVNotifier struct {
+	started int32 // To be used atomically.
+	stopped int32 // To be used atomically.
+
+	con *uspv.SPVCon
+
+	hintCache chainntnfs.HeightHintCache
+
+	notificationRegistry chan interface{}
+
+	spendNotifications map[wire.OutPoint]*spendNotification
+	confNotifications  map[wire.ShaHash]*confirmationsNotification
+	confHeap           *confirmationHeap
+
+	// bestHeight, and bestHash describe the last block ingested by the
+	// uspv connection, and are only accessed by the
+	// notificationDispatcher. Until a block is ingested after we've
+	// started, only the sync height is known, so bestHash is nil.
+	bestHeight int32
+	bestHash   *wire.ShaHash
+
+	// blockEpochClients are sent an epoch for each block connected to the
+	// main chain.
+	blockEpochClients []chan *chainntnfs.BlockEpoch
+
+	connectedBlocks chan *uspv.BlockNtfn
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// Ensure SPVNotifier implements the ChainNotifier interface at compile time.
+var _ chainntnfs.ChainNotifier = (*SPVNotifier)(nil)
+
+// NewSPVNotifier returns a new SPVNotifier instance which will watch all
+// blocks ingested by the passed uspv connection. The connection must be
+// running in hard mode, as filtered blocks don't carry enough information to
+// detect spends of arbitrary outputs. The hintCache is optional, if nil then
+// no height hints are persisted.
+func NewSPVNotifier(con *uspv.SPVCon,
+	hintCache chainntnfs.HeightHintCache) (*SPVNotifier, error) {
+
+	if !con.HardMode {
+		return nil, ErrHardModeRequired
+	}
+
+	return &SPVNotifier{
+		con:       con,
+		hintCache: hintCache,
+
+		notificationRegistry: make(chan interface{}),
+
+		spendNotifications: make(map[wire.OutPoint]*spendNotification),
+		confNotifications:  make(map[wire.ShaHash]*confirmationsNotification),
+		confHeap:           newConfirmationHeap(),
+
+		quit: make(chan struct{}),
+	}, nil
+}
+
+// Start subscribes to the blocks ingested by the uspv connection, and
+// launches all related helper goroutines.
+func (s *SPVNotifier) Start() error {
+	// Already started?
+	if atomic.AddInt32(&s.started, 1) != 1 {
+		return nil
+	}
+
+	syncHeight, err := s.syncHeight()
+	if err != nil {
+		return err
+	}
+	s.bestHeight = syncHeight
+
+	s.connectedBlocks = s.con.SubscribeBlocks()
+
+	s.wg.Add(1)
+	go s.notificationDispatcher()
+
+	return nil
+}
+
+// Stop shutsdown the SPVNotifier.
+func (s *SPVNotifier) Stop() error {
+	// Already shutting down?
+	if atomic.AddInt32(&s.stopped, 1) != 1 {
+		return nil
+	}
+
+	close(s.quit)
+	s.wg.Wait()
+
+	// Notify all pending clients of our shutdown by closing the related
+	// notification channels.
+	for _, spendClient := range s.spendNotifications {
+		close(spendClient.spendChan)
+	}
+	for _, confClient := range s.confNotifications {
+		close(confClient.finConf)
+		close(confClient.negativeConf)
+	}
+	for _, epochChan := range s.blockEpochClients {
+		close(epochChan)
+	}
+
+	return nil
+}
+
+// notificationDispatcher is the primary goroutine which handles client
+// notification registrations, as well as notification dispatches.
+func (s *SPVNotifier) notificationDispatcher() {
+out:
+	for {
+		select {
+		case registerMsg := <-s.notificationRegistry:
+			switch msg := registerMsg.(type) {
+			case *spendNotification:
+				s.spendNotifications[*msg.targetOutpoint] = msg
+				s.checkSpendHint(msg.targetOutpoint)
+			case *confirmationsNotification:
+				chainntnfs.Log.Infof("New confirmations "+
+					"subscription: txid=%v, numconfs=%v",
+					*msg.txid, msg.numConfirmations)
+				s.confNotifications[*msg.txid] = msg
+				s.checkConfirmHint(msg.txid)
+			case *blockEpochRegistration:
+				s.blockEpochClients = append(
+					s.blockEpochClients, msg.epochChan,
+				)
+				s.notifyBlockEpoch(msg.epochChan)
+			}
+		case connectedBlock := <-s.connectedBlocks:
+			newHeight := connectedBlock.Height
+			blockHash := connectedBlock.Block.BlockSha()
+			chainntnfs.Log.Infof("New block: height=%v, sha=%v",
+				newHeight, blockHash)
+
+			for _, tx := range connectedBlock.Block.Transactions {
+				// As we have the full block, spends can be
+				// detected directly from the inputs of each
+				// transaction.
+				s.checkSpendTrigger(tx)
+
+				txSha := tx.TxSha()
+				s.checkConfirmationTrigger(&txSha, newHeight)
+			}
+
+			// All pending notifications have now been checked
+			// against this block, so advance their height hints.
+			s.updateScannedHeights(newHeight)
+
+			// A new block has been connected to the main
+			// chain. Send out any N confirmation notifications
+			// which may have been triggered by this new block.
+			s.notifyConfs(newHeight)
+
+			s.bestHeight = newHeight
+			s.bestHash = &blockHash
+			for _, epochChan := range s.blockEpochClients {
+				s.notifyBlockEpoch(epochChan)
+			}
+		case <-s.quit:
+			break out
+		}
+	}
+	s.wg.Done()
+}
+
+// notifyBlockEpoch sends an epoch for our best block to a block epoch client.
+func (s *SPVNotifier) notifyBlockEpoch(epochChan chan *chainntnfs.BlockEpoch) {
+	epoch := &chainntnfs.BlockEpoch{
+		Height: s.bestHeight,
+		Hash:   s.bestHash,
+	}
+	select {
+	case epochChan <- epoch:
+	case <-s.quit:
+	}
+}
+
+// checkSpendTrigger dispatches a spend notification for each input of the
+// passed transaction which spends a watched outpoint.
+func (s *SPVNotifier) checkSpendTrigger(tx *wire.MsgTx) {
+	for i, txIn := range tx.TxIn {
+		prevOut := txIn.PreviousOutPoint
+
+		ntfn, ok := s.spendNotifications[prevOut]
+		if !ok {
+			continue
+		}
+
+		spenderSha := tx.TxSha()
+		ntfn.spendChan <- &chainntnfs.SpendDetail{
+			SpentOutPoint:     ntfn.targetOutpoint,
+			SpenderTxHash:     &spenderSha,
+			SpendingTx:        tx,
+			SpenderInputIndex: uint32(i),
+		}
+
+		delete(s.spendNotifications, prevOut)
+
+		if s.hintCache == nil {
+			continue
+		}
+		if err := s.hintCache.DeleteSpendHint(&prevOut); err != nil {
+			chainntnfs.Log.Errorf("Unable to delete height hint "+
+				"for outpoint=%v: %v", prevOut, err)
+		}
+	}
+}
+
+// syncHeight returns the height of the last block ingested by the uspv
+// connection.
+func (s *SPVNotifier) syncHeight() (int32, error) {
+	return s.con.TS.GetDBSyncHeight()
+}
+
+// checkConfirmHint consults the height hint cache for the passed txid. If no
+// hint exists, then the txid is watched for the first time, so a new hint is
+// stored starting at the block after our current sync height. Blocks
+// connected after the sync height are delivered by the uspv connection as it
+// syncs, so only a hint lagging behind the sync height indicates missed
+// blocks.
+//
+// TODO(roasbeef): uspv can't yet fetch arbitrary historical blocks, so a
+// lagging hint can only be reported, not rescanned.
+func (s *SPVNotifier) checkConfirmHint(txid *wire.ShaHash) {
+	if s.hintCache == nil {
+		return
+	}
+
+	syncHeight, err := s.syncHeight()
+	if err != nil {
+		chainntnfs.Log.Errorf("Unable to fetch sync height: %v", err)
+		return
+	}
+
+	hint, err := s.hintCache.FetchConfirmHint(txid)
+	switch {
+	case err == channeldb.ErrHeightHintNotFound:
+		s.putConfirmHint(txid, uint32(syncHeight+1))
+	case err != nil:
+		chainntnfs.Log.Errorf("Unable to fetch height hint for "+
+			"txid=%v: %v", txid, err)
+	case chainntnfs.RescanStartHeight(hint) <= syncHeight:
+		chainntnfs.Log.Warnf("Blocks %v-%v must be rescanned for "+
+			"txid=%v", chainntnfs.RescanStartHeight(hint),
+			syncHeight, txid)
+	}
+}
+
+// checkSpendHint consults the height hint cache for the passed outpoint. If
+// no hint exists, then the outpoint is watched for the first time, so a new
+// hint is stored starting at the block after our current sync height.
+func (s *SPVNotifier) checkSpendHint(op *wire.OutPoint) {
+	if s.hintCache == nil {
+		return
+	}
+
+	syncHeight, err := s.syncHeight()
+	if err != nil {
+		chainntnfs.Log.Errorf("Unable to fetch sync height: %v", err)
+		return
+	}
+
+	hint, err := s.hintCache.FetchSpendHint(op)
+	switch {
+	case err == channeldb.ErrHeightHintNotFound:
+		hint = &channeldb.HeightHint{
+			StartHeight:   uint32(syncHeight + 1),
+			ScannedHeight: uint32(syncHeight),
+		}
+		if err := s.hintCache.PutSpendHint(op, hint); err != nil {
+			chainntnfs.Log.Errorf("Unable to store height hint "+
+				"for outpoint=%v: %v", op, err)
+		}
+	case err != nil:
+		chainntnfs.Log.Errorf("Unable to fetch height hint for "+
+			"outpoint=%v: %v", op, err)
+	case chainntnfs.RescanStartHeight(hint) <= syncHeight:
+		chainntnfs.Log.Warnf("Blocks %v-%v must be rescanned for "+
+			"outpoint=%v", chainntnfs.RescanStartHeight(hint),
+			syncHeight, op)
+	}
+}
+
+// putConfirmHint stores a height hint for txid which has either confirmed at,
+// or can't confirm before confHeight.
+func (s *SPVNotifier) putConfirmHint(txid *wire.ShaHash, confHeight uint32) {
+	if s.hintCache == nil {
+		return
+	}
+
+	hint := &channeldb.HeightHint{
+		StartHeight:   confHeight,
+		ScannedHeight: confHeight - 1,
+	}
+	if err := s.hintCache.PutConfirmHint(txid, hint); err != nil {
+		chainntnfs.Log.Errorf("Unable to store height hint for "+
+			"txid=%v: %v", txid, err)
+	}
+}
+
+// deleteConfirmHint removes the height hint for txid once its confirmation
+// notification has been dispatched.
+func (s *SPVNotifier) deleteConfirmHint(txid *wire.ShaHash) {
+	if s.hintCache == nil {
+		return
+	}
+
+	if err := s.hintCache.DeleteConfirmHint(txid); err != nil {
+		chainntnfs.Log.Errorf("Unable to delete height hint for "+
+			"txid=%v: %v", txid, err)
+	}
+}
+
+// updateScannedHeights advances the height hints of all transactions, and
+// outpoints which are still waiting to be included within a block.
+func (s *SPVNotifier) updateScannedHeights(height int32) {
+	if s.hintCache == nil {
+		return
+	}
+
+	txids := make([]wire.ShaHash, 0, len(s.confNotifications))
+	for txid := range s.confNotifications {
+		txids = append(txids, txid)
+	}
+	ops := make([]wire.OutPoint, 0, len(s.spendNotifications))
+	for op := range s.spendNotifications {
+		ops = append(ops, op)
+	}
+
+	err := s.hintCache.UpdateScannedHeights(txids, ops, uint32(height))
+	if err != nil {
+		chainntnfs.Log.Errorf("Unable to update height hints: %v", err)
+	}
+}
+
+// notifyConfs examines the current confirmation heap, sending off any
+// notifications which have been triggered by the connection of a new block at
+// newBlockHeight.
+func (s *SPVNotifier) notifyConfs(newBlockHeight int32) {
+	// If the heap is empty, we have nothing to do.
+	if s.confHeap.Len() == 0 {
+		return
+	}
+
+	// The heap is a min-heap, so the confirmation notification which
+	// requires the smallest block-height will always be at the top of the
+	// heap. Fire off eligible notifications until there are no more
+	// eligible entries.
+	nextConf := heap.Pop(s.confHeap).(*confEntry)
+	for nextConf.triggerHeight <= uint32(newBlockHeight) {
+		nextConf.finConf <- newBlockHeight
+		s.deleteConfirmHint(nextConf.txid)
+
+		if s.confHeap.Len() == 0 {
+			return
+		}
+
+		nextConf = heap.Pop(s.confHeap).(*confEntry)
+	}
+
+	heap.Push(s.confHeap, nextConf)
+}
+
+// checkConfirmationTrigger determines if the passed txSha included at
+// blockHeight triggers any single confirmation notifications. In the event
+// that the txid matches, yet needs additional confirmations, it is added to
+// the confirmation heap to be triggered at a later time.
+func (s *SPVNotifier) checkConfirmationTrigger(txSha *wire.ShaHash, blockHeight int32) {
+	confNtfn, ok := s.confNotifications[*txSha]
+	if !ok {
+		return
+	}
+
+	delete(s.confNotifications, *txSha)
+	if confNtfn.numConfirmations == 1 {
+		chainntnfs.Log.Infof("Dispatching single conf "+
+			"notification, sha=%v, height=%v", txSha,
+			blockHeight)
+		confNtfn.finConf <- blockHeight
+		s.deleteConfirmHint(txSha)
+		return
+	}
+
+	// The registered notification requires more than one confirmation
+	// before triggering, so we'll add an entry to the heap to be fired off
+	// once the final confirmation height has been reached.
+	confNtfn.initialConfirmHeight = uint32(blockHeight)
+	s.putConfirmHint(txSha, confNtfn.initialConfirmHeight)
+	finalConfHeight := confNtfn.initialConfirmHeight + confNtfn.numConfirmations - 1
+	heap.Push(s.confHeap, &confEntry{
+		confNtfn,
+		finalConfHeight,
+	})
+}
+
+// spendNotification couples a target outpoint along with the channel used for
+// notifications once a spend of the outpoint has been detected.
+type spendNotification struct {
+	targetOutpoint *wire.OutPoint
+
+	spendChan chan *chainntnfs.SpendDetail
+}
+
+// RegisterSpendNtfn registers an intent to be notified once the target
+// outpoint has been spent by a transaction on-chain. Once a spend of the
+// target outpoint has been detected, the details of the spending event will be
+// sent across the 'Spend' channel.
+func (s *SPVNotifier) RegisterSpendNtfn(outpoint *wire.OutPoint) (*chainntnfs.SpendEvent, error) {
+	ntfn := &spendNotification{
+		targetOutpoint: outpoint,
+		spendChan:      make(chan *chainntnfs.SpendDetail, 1),
+	}
+
+	select {
+	case s.notificationRegistry <- ntfn:
+	case <-s.quit:
+		return nil, ErrNotifierShuttingDown
+	}
+
+	return &chainntnfs.SpendEvent{ntfn.spendChan}, nil
+}
+
+// confirmationNotification represents a client's intent to receive a
+// notification once the target txid reaches numConfirmations confirmations.
+type confirmationsNotification struct {
+	txid *wire.ShaHash
+
+	initialConfirmHeight uint32
+	numConfirmations     uint32
+
+	finConf      chan int32
+	negativeConf chan int32 // TODO(roasbeef): re-org funny business
+}
+
+// RegisterConfirmationsNtfn registers a notification with SPVNotifier which
+// will be triggered once the txid reaches numConfs number of confirmations.
+func (s *SPVNotifier) RegisterConfirmationsNtfn(txid *wire.ShaHash,
+	numConfs uint32) (*chainntnfs.ConfirmationEvent, error) {
+
+	ntfn := &confirmationsNotification{
+		txid:             txid,
+		numConfirmations: numConfs,
+		finConf:          make(chan int32, 1),
+		negativeConf:     make(chan int32, 1),
+	}
+
+	select {
+	case s.notificationRegistry <- ntfn:
+	case <-s.quit:
+		return nil, ErrNotifierShuttingDown
+	}
+
+	return &chainntnfs.ConfirmationEvent{
+		Confirmed:    ntfn.finConf,
+		NegativeConf: ntfn.negativeConf,
+	}, nil
+}
+
+// blockEpochRegistration represents a client's intent to receive an epoch for
+// each new block connected to the main chain.
+type blockEpochRegistration struct {
+	epochChan chan *chainntnfs.BlockEpoch
+}
+
+// RegisterBlockEpochNtfn returns a BlockEpochEvent which subscribes the
+// caller to receive notifications of each new block connected to the main
+// chain. The first epoch sent is that of the last block ingested by the uspv
+// connection, so the caller learns the current height right away. Its hash
+// is nil if no block has been ingested since the notifier was started.
+//
+// TODO(roasbeef): targetHeight is currently unused.
+func (s *SPVNotifier) RegisterBlockEpochNtfn(targetHeight int32) (*chainntnfs.BlockEpochEvent, error) {
+	registration := &blockEpochRegistration{
+		epochChan: make(chan *chainntnfs.BlockEpoch, 20),
+	}
+
+	select {
+	case s.notificationRegistry <- registration:
+	case <-s.quit:
+		return nil, ErrNotifierShuttingDown
+	}
+
+	return &chainntnfs.BlockEpochEvent{
+		Epochs: registration.epochChan,
+	}, nil
+}
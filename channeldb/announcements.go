@@ -0,0 +1,98 @@
+package channeldb
+
+import (
+	"bytes"
+
+	"github.com/boltdb/bolt"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/wire"
+)
+
+var (
+	// channelAnnouncementBucket stores the complete, signed announcement
+	// of each of our own channels, keyed by the funding outpoint of the
+	// channel. The announcements are kept so they may be periodically
+	// re-broadcast to the network.
+	channelAnnouncementBucket = []byte("cab")
+)
+
+// PutChannelAnnouncement stores the passed announcement of one of our own
+// channels, overwriting any existing announcement of the channel.
+func (d *DB) PutChannelAnnouncement(ann *lnwire.ChannelAnnouncement) error {
+	key, err := outpointKey(ann.ChannelPoint)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := ann.Encode(&b, 0); err != nil {
+		return err
+	}
+
+	return d.store.Update(func(tx *bolt.Tx) error {
+		anns, err := tx.CreateBucketIfNotExists(channelAnnouncementBucket)
+		if err != nil {
+			return err
+		}
+
+		return anns.Put(key, b.Bytes())
+	})
+}
+
+// FetchChannelAnnouncement returns the stored announcement of the channel
+// funded by the target outpoint. If the channel hasn't been announced, then
+// ErrAnnouncementNotFound is returned.
+func (d *DB) FetchChannelAnnouncement(chanPoint *wire.OutPoint) (*lnwire.ChannelAnnouncement, error) {
+	key, err := outpointKey(chanPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var ann *lnwire.ChannelAnnouncement
+	err = d.store.View(func(tx *bolt.Tx) error {
+		anns := tx.Bucket(channelAnnouncementBucket)
+		if anns == nil {
+			return ErrAnnouncementNotFound
+		}
+
+		annBytes := anns.Get(key)
+		if annBytes == nil {
+			return ErrAnnouncementNotFound
+		}
+
+		ann = &lnwire.ChannelAnnouncement{}
+		return ann.Decode(bytes.NewReader(annBytes), 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ann, nil
+}
+
+// FetchAllChannelAnnouncements returns the stored announcements of all our
+// channels.
+func (d *DB) FetchAllChannelAnnouncements() ([]*lnwire.ChannelAnnouncement, error) {
+	var announcements []*lnwire.ChannelAnnouncement
+	err := d.store.View(func(tx *bolt.Tx) error {
+		anns := tx.Bucket(channelAnnouncementBucket)
+		if anns == nil {
+			return nil
+		}
+
+		return anns.ForEach(func(k, v []byte) error {
+			ann := &lnwire.ChannelAnnouncement{}
+			if err := ann.Decode(bytes.NewReader(v), 0); err != nil {
+				return err
+			}
+
+			announcements = append(announcements, ann)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return announcements, nil
+}
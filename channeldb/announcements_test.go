@@ -0,0 +1,91 @@
+package channeldb
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/wire"
+)
+
+func TestChannelAnnouncementStorage(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "channeldb")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := Open(tempDirName, netParams)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	chanPoint := &wire.OutPoint{Hash: wire.ShaHash{0x01}, Index: 1}
+	if _, err := cdb.FetchChannelAnnouncement(chanPoint); err != ErrAnnouncementNotFound {
+		t.Fatalf("expected ErrAnnouncementNotFound, got %v", err)
+	}
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	sig, err := priv.Sign(chanPoint.Hash[:])
+	if err != nil {
+		t.Fatalf("unable to sign: %v", err)
+	}
+	ann := &lnwire.ChannelAnnouncement{
+		FirstNodeSig:     sig,
+		SecondNodeSig:    sig,
+		FirstBitcoinSig:  sig,
+		SecondBitcoinSig: sig,
+		ChannelPoint:     chanPoint,
+		FirstNodeID:      priv.PubKey(),
+		SecondNodeID:     priv.PubKey(),
+		FirstBitcoinKey:  priv.PubKey(),
+		SecondBitcoinKey: priv.PubKey(),
+	}
+	edge := &ChannelEdge{
+		ChannelPoint: *chanPoint,
+		From:         [32]byte{0x0a},
+		To:           [32]byte{0x0b},
+		Capacity:     1e8,
+		LastUpdate:   time.Unix(1466000000, 0),
+	}
+	if err := cdb.AddChannelEdge(edge); err != nil {
+		t.Fatalf("unable to add channel edge: %v", err)
+	}
+	if err := cdb.PutChannelAnnouncement(ann); err != nil {
+		t.Fatalf("unable to store announcement: %v", err)
+	}
+
+	dbAnn, err := cdb.FetchChannelAnnouncement(chanPoint)
+	if err != nil {
+		t.Fatalf("unable to fetch announcement: %v", err)
+	}
+	if !reflect.DeepEqual(ann, dbAnn) {
+		t.Fatalf("announcements don't match: expected %v, got %v",
+			ann, dbAnn)
+	}
+
+	anns, err := cdb.FetchAllChannelAnnouncements()
+	if err != nil {
+		t.Fatalf("unable to fetch announcements: %v", err)
+	}
+	if len(anns) != 1 {
+		t.Fatalf("expected 1 announcement, got %v", len(anns))
+	}
+
+	// Once the channel is pruned from the graph, its announcement is
+	// removed as well.
+	if _, err := cdb.PruneGraph([]*wire.OutPoint{chanPoint}); err != nil {
+		t.Fatalf("unable to prune graph: %v", err)
+	}
+	if _, err := cdb.FetchChannelAnnouncement(chanPoint); err != ErrAnnouncementNotFound {
+		t.Fatalf("expected ErrAnnouncementNotFound, got %v", err)
+	}
+}
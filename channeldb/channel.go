@@ -8,6 +8,7 @@ import (
 
 	"github.com/boltdb/bolt"
 	"github.com/lightningnetwork/lnd/elkrem"
+	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/roasbeef/btcd/btcec"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcutil"
@@ -85,8 +86,44 @@ var (
 	// deliveryScriptsKey stores the scripts for the final delivery in the
 	// case of a cooperative closure.
 	deliveryScriptsKey = []byte("dsk")
+
+	// chanConstraintsKey stores the flow-control limits each side of the
+	// channel imposes on the HTLCs the other side may add.
+	chanConstraintsKey = []byte("cck")
+
+	// chanInitiatorKey stores whether we initiated, and therefore funded
+	// the channel.
+	chanInitiatorKey = []byte("ink")
 )
 
+// ChannelConstraints are the flow-control limits one side of a channel
+// imposes on the HTLCs its counterparty may add to the commitment
+// transactions, along with the dust limit of that side's own commitment
+// transaction. The limits are negotiated during the funding workflow, and
+// enforced by both sides for the lifetime of the channel.
+type ChannelConstraints struct {
+	// DustLimit is the threshold below which outputs are trimmed from
+	// this side's commitment transaction, as they'd otherwise render the
+	// transaction non-standard.
+	DustLimit btcutil.Amount
+
+	// MaxPendingHTLCs is the maximum number of HTLCs the counterparty may
+	// have outstanding at any given time.
+	MaxPendingHTLCs uint16
+
+	// MaxValueInFlight is the maximum total value of the counterparty's
+	// outstanding HTLCs.
+	MaxValueInFlight btcutil.Amount
+
+	// MinHTLC is the smallest HTLC the counterparty may add.
+	MinHTLC btcutil.Amount
+
+	// ChannelReserve is the amount the counterparty must keep on their
+	// side of the channel at all times, such that they always have
+	// something to lose by broadcasting a revoked state.
+	ChannelReserve btcutil.Amount
+}
+
 // OpenChannel...
 // TODO(roasbeef): Copy/Clone method, so CoW on writes?
 //  * CoW method would allow for intelligent partial writes for updates
@@ -100,7 +137,15 @@ type OpenChannel struct {
 	// The ID of a channel is the txid of the funding transaction.
 	ChanID *wire.OutPoint
 
+	// MinFeePerKb is the fee rate, in satoshis per kilobyte, paid by our
+	// current commitment transaction. The initiator of the channel may
+	// update the fee rate as on-chain fees change.
 	MinFeePerKb btcutil.Amount
+
+	// IsInitiator is true if we initiated the channel. As the initiator
+	// funded the channel, they pay the fees of both commitment
+	// transactions.
+	IsInitiator bool
 	// Our reserve. Assume symmetric reserve amounts. Only needed if the
 	// funding type is CLTV.
 	//ReserveAmount btcutil.Amount
@@ -110,10 +155,11 @@ type OpenChannel struct {
 	TheirCommitKey *btcec.PublicKey
 
 	// Tracking total channel capacity, and the amount of funds allocated
-	// to each side.
+	// to each side. The balances are tracked in milli-satoshis, and are
+	// only rounded down to satoshis within the commitment transactions.
 	Capacity     btcutil.Amount
-	OurBalance   btcutil.Amount
-	TheirBalance btcutil.Amount
+	OurBalance   lnwire.MilliSatoshi
+	TheirBalance lnwire.MilliSatoshi
 
 	// Our current commitment transaction along with their signature for
 	// our commitment transaction.
@@ -144,10 +190,16 @@ type OpenChannel struct {
 	OurDeliveryScript   []byte
 	TheirDeliveryScript []byte
 
+	// LocalConstraints are the limits we impose on the HTLCs added by the
+	// remote node, while RemoteConstraints are the limits the remote node
+	// imposes on the HTLCs we add.
+	LocalConstraints  ChannelConstraints
+	RemoteConstraints ChannelConstraints
+
 	NumUpdates            uint64
 	TotalSatoshisSent     uint64
 	TotalSatoshisReceived uint64
-	TotalNetFees          uint64    // TODO(roasbeef): total fees paid too?
+	TotalNetFees          uint64    // In mSAT. TODO(roasbeef): total fees paid too?
 	CreationTime          time.Time // TODO(roasbeef): last update time?
 
 	// isPrevState denotes if this instance of an OpenChannel is a previous,
@@ -273,6 +325,13 @@ func (c *OpenChannel) CloseChannel() error {
 			return err
 		}
 
+		// The channel's forwarding policy is no longer needed either.
+		if policies := tx.Bucket(forwardingPolicyBucket); policies != nil {
+			if err := policies.Delete(outPointBytes); err != nil {
+				return err
+			}
+		}
+
 		// Finally, create a summary of this channel in the closed
 		// channel bucket for this node.
 		return putClosedChannelSummary(tx, outPointBytes)
@@ -290,8 +349,8 @@ type ChannelSnapshot struct {
 	ChannelPoint *wire.OutPoint
 
 	Capacity      btcutil.Amount
-	LocalBalance  btcutil.Amount
-	RemoteBalance btcutil.Amount
+	LocalBalance  lnwire.MilliSatoshi
+	RemoteBalance lnwire.MilliSatoshi
 
 	NumUpdates uint64
 
@@ -402,6 +461,12 @@ func putOpenChannel(openChanBucket *bolt.Bucket, nodeChanBucket *bolt.Bucket,
 	if err := putChanDeliveryScripts(nodeChanBucket, channel); err != nil {
 		return err
 	}
+	if err := putChanConstraints(nodeChanBucket, channel); err != nil {
+		return err
+	}
+	if err := putChanInitiator(nodeChanBucket, channel); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -436,6 +501,12 @@ func fetchOpenChannel(openChanBucket *bolt.Bucket, nodeChanBucket *bolt.Bucket,
 	if err := fetchChanDeliveryScripts(nodeChanBucket, channel); err != nil {
 		return nil, err
 	}
+	if err := fetchChanConstraints(nodeChanBucket, channel); err != nil {
+		return nil, err
+	}
+	if err := fetchChanInitiator(nodeChanBucket, channel); err != nil {
+		return nil, err
+	}
 
 	// With the existence of an open channel bucket with this node verified,
 	// perform a full read of the entire struct. Starting with the prefixed
@@ -500,6 +571,12 @@ func deleteOpenChannel(openChanBucket *bolt.Bucket, nodeChanBucket *bolt.Bucket,
 	if err := deleteChanDeliveryScripts(nodeChanBucket, channelID); err != nil {
 		return err
 	}
+	if err := deleteChanConstraints(nodeChanBucket, channelID); err != nil {
+		return err
+	}
+	if err := deleteChanInitiator(nodeChanBucket, channelID); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -569,11 +646,11 @@ func fetchChanCapacity(openChanBucket *bolt.Bucket, channel *OpenChannel) error
 
 	copy(keyPrefix[:3], selfBalancePrefix)
 	selfBalanceBytes := openChanBucket.Get(keyPrefix)
-	channel.OurBalance = btcutil.Amount(byteOrder.Uint64(selfBalanceBytes))
+	channel.OurBalance = lnwire.MilliSatoshi(byteOrder.Uint64(selfBalanceBytes))
 
 	copy(keyPrefix[:3], theirBalancePrefix)
 	theirBalanceBytes := openChanBucket.Get(keyPrefix)
-	channel.TheirBalance = btcutil.Amount(byteOrder.Uint64(theirBalanceBytes))
+	channel.TheirBalance = lnwire.MilliSatoshi(byteOrder.Uint64(theirBalanceBytes))
 
 	return nil
 }
@@ -1188,6 +1265,136 @@ func fetchChanDeliveryScripts(nodeChanBucket *bolt.Bucket, channel *OpenChannel)
 	return nil
 }
 
+func putChanConstraints(nodeChanBucket *bolt.Bucket, channel *OpenChannel) error {
+	var bc bytes.Buffer
+	if err := writeOutpoint(&bc, channel.ChanID); err != nil {
+		return err
+	}
+	constraintsKey := make([]byte, len(chanConstraintsKey)+bc.Len())
+	copy(constraintsKey[:3], chanConstraintsKey)
+	copy(constraintsKey[3:], bc.Bytes())
+
+	var b bytes.Buffer
+	for _, c := range []*ChannelConstraints{&channel.LocalConstraints,
+		&channel.RemoteConstraints} {
+
+		if err := writeConstraints(&b, c); err != nil {
+			return err
+		}
+	}
+
+	return nodeChanBucket.Put(constraintsKey, b.Bytes())
+}
+
+func deleteChanConstraints(nodeChanBucket *bolt.Bucket, chanID []byte) error {
+	constraintsKey := make([]byte, len(chanConstraintsKey)+len(chanID))
+	copy(constraintsKey[:3], chanConstraintsKey)
+	copy(constraintsKey[3:], chanID)
+	return nodeChanBucket.Delete(constraintsKey)
+}
+
+func fetchChanConstraints(nodeChanBucket *bolt.Bucket, channel *OpenChannel) error {
+	var b bytes.Buffer
+	if err := writeOutpoint(&b, channel.ChanID); err != nil {
+		return err
+	}
+	constraintsKey := make([]byte, len(chanConstraintsKey)+b.Len())
+	copy(constraintsKey[:3], chanConstraintsKey)
+	copy(constraintsKey[3:], b.Bytes())
+
+	// Channels created before flow-control limits were negotiated have no
+	// constraints stored, in which case they're left unrestricted.
+	constraintsBytes := nodeChanBucket.Get(constraintsKey)
+	if constraintsBytes == nil {
+		return nil
+	}
+
+	r := bytes.NewReader(constraintsBytes)
+	if err := readConstraints(r, &channel.LocalConstraints); err != nil {
+		return err
+	}
+	return readConstraints(r, &channel.RemoteConstraints)
+}
+
+func putChanInitiator(nodeChanBucket *bolt.Bucket, channel *OpenChannel) error {
+	var bc bytes.Buffer
+	if err := writeOutpoint(&bc, channel.ChanID); err != nil {
+		return err
+	}
+	initiatorKey := make([]byte, len(chanInitiatorKey)+bc.Len())
+	copy(initiatorKey[:3], chanInitiatorKey)
+	copy(initiatorKey[3:], bc.Bytes())
+
+	var initiator byte
+	if channel.IsInitiator {
+		initiator = 1
+	}
+
+	return nodeChanBucket.Put(initiatorKey, []byte{initiator})
+}
+
+func deleteChanInitiator(nodeChanBucket *bolt.Bucket, chanID []byte) error {
+	initiatorKey := make([]byte, len(chanInitiatorKey)+len(chanID))
+	copy(initiatorKey[:3], chanInitiatorKey)
+	copy(initiatorKey[3:], chanID)
+	return nodeChanBucket.Delete(initiatorKey)
+}
+
+func fetchChanInitiator(nodeChanBucket *bolt.Bucket, channel *OpenChannel) error {
+	var b bytes.Buffer
+	if err := writeOutpoint(&b, channel.ChanID); err != nil {
+		return err
+	}
+	initiatorKey := make([]byte, len(chanInitiatorKey)+b.Len())
+	copy(initiatorKey[:3], chanInitiatorKey)
+	copy(initiatorKey[3:], b.Bytes())
+
+	initiatorBytes := nodeChanBucket.Get(initiatorKey)
+	channel.IsInitiator = len(initiatorBytes) == 1 && initiatorBytes[0] == 1
+
+	return nil
+}
+
+func writeConstraints(w io.Writer, c *ChannelConstraints) error {
+	var scratch [8]byte
+
+	byteOrder.PutUint16(scratch[:2], c.MaxPendingHTLCs)
+	if _, err := w.Write(scratch[:2]); err != nil {
+		return err
+	}
+
+	for _, amt := range []btcutil.Amount{c.DustLimit, c.MaxValueInFlight,
+		c.MinHTLC, c.ChannelReserve} {
+
+		byteOrder.PutUint64(scratch[:], uint64(amt))
+		if _, err := w.Write(scratch[:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readConstraints(r io.Reader, c *ChannelConstraints) error {
+	var scratch [8]byte
+
+	if _, err := io.ReadFull(r, scratch[:2]); err != nil {
+		return err
+	}
+	c.MaxPendingHTLCs = byteOrder.Uint16(scratch[:2])
+
+	for _, amt := range []*btcutil.Amount{&c.DustLimit, &c.MaxValueInFlight,
+		&c.MinHTLC, &c.ChannelReserve} {
+
+		if _, err := io.ReadFull(r, scratch[:]); err != nil {
+			return err
+		}
+		*amt = btcutil.Amount(byteOrder.Uint64(scratch[:]))
+	}
+
+	return nil
+}
+
 func writeOutpoint(w io.Writer, o *wire.OutPoint) error {
 	scratch := make([]byte, 4)
 
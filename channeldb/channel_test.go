@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/lightningnetwork/lnd/elkrem"
+	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/roasbeef/btcd/btcec"
 	"github.com/roasbeef/btcd/chaincfg"
 	"github.com/roasbeef/btcd/txscript"
@@ -144,11 +145,12 @@ func TestOpenChannelPutGetDelete(t *testing.T) {
 		TheirLNID:                  key,
 		ChanID:                     id,
 		MinFeePerKb:                btcutil.Amount(5000),
+		IsInitiator:                true,
 		OurCommitKey:               privKey,
 		TheirCommitKey:             pubKey,
 		Capacity:                   btcutil.Amount(10000),
-		OurBalance:                 btcutil.Amount(3000),
-		TheirBalance:               btcutil.Amount(9000),
+		OurBalance:                 lnwire.MilliSatoshi(3000),
+		TheirBalance:               lnwire.MilliSatoshi(9000),
 		OurCommitTx:                testTx,
 		OurCommitSig:               bytes.Repeat([]byte{1}, 71),
 		LocalElkrem:                sender,
@@ -169,6 +171,20 @@ func TestOpenChannelPutGetDelete(t *testing.T) {
 		TotalNetFees:               9,
 		CreationTime:               time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
 		Db:                         cdb,
+		LocalConstraints: ChannelConstraints{
+			DustLimit:        btcutil.Amount(546),
+			MaxPendingHTLCs:  30,
+			MaxValueInFlight: btcutil.Amount(5000),
+			MinHTLC:          btcutil.Amount(10),
+			ChannelReserve:   btcutil.Amount(100),
+		},
+		RemoteConstraints: ChannelConstraints{
+			DustLimit:        btcutil.Amount(600),
+			MaxPendingHTLCs:  20,
+			MaxValueInFlight: btcutil.Amount(7000),
+			MinHTLC:          btcutil.Amount(1),
+			ChannelReserve:   btcutil.Amount(200),
+		},
 	}
 
 	if err := state.FullSync(); err != nil {
@@ -194,6 +210,9 @@ func TestOpenChannelPutGetDelete(t *testing.T) {
 	if state.MinFeePerKb != newState.MinFeePerKb {
 		t.Fatalf("fee/kb doens't match")
 	}
+	if state.IsInitiator != newState.IsInitiator {
+		t.Fatalf("initiator doesn't match")
+	}
 
 	if !bytes.Equal(state.OurCommitKey.Serialize(),
 		newState.OurCommitKey.Serialize()) {
@@ -253,6 +272,15 @@ func TestOpenChannelPutGetDelete(t *testing.T) {
 		t.Fatalf("their delivery address doesn't match")
 	}
 
+	if state.LocalConstraints != newState.LocalConstraints {
+		t.Fatalf("local constraints don't match: %v vs %v",
+			state.LocalConstraints, newState.LocalConstraints)
+	}
+	if state.RemoteConstraints != newState.RemoteConstraints {
+		t.Fatalf("remote constraints don't match: %v vs %v",
+			state.RemoteConstraints, newState.RemoteConstraints)
+	}
+
 	if state.NumUpdates != newState.NumUpdates {
 		t.Fatalf("num updates doesn't match: %v vs %v",
 			state.NumUpdates, newState.NumUpdates)
@@ -48,7 +48,7 @@ type DB struct {
 
 // Open opens an existing channeldb created under the passed namespace with
 // sensitive data encrypted by the passed EncryptorDecryptor implementation.
-// TODO(roasbeef): versioning?
+// A database of an older version is migrated to the latest version.
 func Open(dbPath string, netParams *chaincfg.Params) (*DB, error) {
 	path := filepath.Join(dbPath, dbName)
 
@@ -63,7 +63,13 @@ func Open(dbPath string, netParams *chaincfg.Params) (*DB, error) {
 		return nil, err
 	}
 
-	return &DB{store: bdb, netParams: netParams}, nil
+	chanDB := &DB{store: bdb, netParams: netParams}
+	if err := chanDB.syncVersions(dbVersions); err != nil {
+		bdb.Close()
+		return nil, err
+	}
+
+	return chanDB, nil
 }
 
 // RegisterCryptoSystem registers an implementation of the EncryptorDecryptor
@@ -120,7 +126,7 @@ func createChannelDB(dbPath string) error {
 			return err
 		}
 
-		return nil
+		return putDBVersion(tx, getLatestDBVersion(dbVersions))
 	})
 	if err != nil {
 		return fmt.Errorf("unable to create new channeldb")
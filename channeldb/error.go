@@ -4,7 +4,30 @@ import "fmt"
 
 var (
 	ErrNoChanDBExists = fmt.Errorf("channel db has not yet been created")
+	ErrDBReversion    = fmt.Errorf("channel db is of a newer version " +
+		"than this software supports")
 
 	ErrNoActiveChannels = fmt.Errorf("no active channels exist")
 	ErrChannelNoExist   = fmt.Errorf("this channel does not exist")
+
+	ErrGraphNodeNotFound = fmt.Errorf("unable to find node")
+	ErrEdgeNotFound      = fmt.Errorf("edge for chanPoint not found")
+	ErrSourceNodeNotSet  = fmt.Errorf("source node does not exist")
+
+	ErrForwardingPolicyNotFound = fmt.Errorf("forwarding policy for " +
+		"chanPoint not found")
+
+	ErrAnnouncementNotFound = fmt.Errorf("announcement for chanPoint " +
+		"not found")
+
+	ErrPaymentInFlight    = fmt.Errorf("payment with hash is already in flight")
+	ErrAlreadyPaid        = fmt.Errorf("payment with hash has already succeeded")
+	ErrPaymentNotInFlight = fmt.Errorf("no in-flight payment with hash")
+	ErrPaymentInterrupted = fmt.Errorf("payment with hash was interrupted " +
+		"by a restart, its outcome is unknown")
+
+	ErrDuplicateInvoice   = fmt.Errorf("invoice with payment hash already exists")
+	ErrInvoiceNotFound    = fmt.Errorf("unable to locate invoice")
+	ErrInvoiceNotAccepted = fmt.Errorf("invoice hasn't been accepted")
+	ErrInvoiceResolved    = fmt.Errorf("invoice already settled or canceled")
 )
@@ -1 +1,163 @@
 package channeldb
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+)
+
+var (
+	// forwardingPolicyBucket stores the forwarding policy of each of our
+	// channels, keyed by the funding outpoint of the channel.
+	forwardingPolicyBucket = []byte("fpb")
+)
+
+// forwardingPolicySize is the size of a serialized ForwardingPolicy:
+// 8 + 4 + 2 + 8 + 8.
+const forwardingPolicySize = 30
+
+// ForwardingPolicy describes the terms under which we're willing to forward
+// HTLCs out across one of our channels. The policy is advertised to the
+// network, and enforced for every HTLC we forward.
+type ForwardingPolicy struct {
+	// BaseFee is the flat fee charged for each HTLC forwarded across the
+	// channel.
+	BaseFee btcutil.Amount
+
+	// FeeRate is the proportional fee charged for each HTLC forwarded
+	// across the channel, in millionths of the forwarded amount.
+	FeeRate uint32
+
+	// TimeLockDelta is the minimum difference between the expiry of an
+	// incoming HTLC, and that of the HTLC forwarded across the channel.
+	TimeLockDelta uint16
+
+	// MinHTLC is the smallest HTLC we'll forward across the channel.
+	MinHTLC btcutil.Amount
+
+	// MaxHTLC is the largest HTLC we'll forward across the channel. A
+	// value of zero indicates that there's no maximum.
+	MaxHTLC btcutil.Amount
+}
+
+// ComputeFee returns the fee which must be paid in order to forward an HTLC
+// of the passed amount across the channel. The proportional fee is computed
+// in milli-satoshis, so it isn't rounded away for small HTLCs.
+func (f *ForwardingPolicy) ComputeFee(amt lnwire.MilliSatoshi) lnwire.MilliSatoshi {
+	baseFee := lnwire.NewMSatFromSatoshis(f.BaseFee)
+	return baseFee + (amt*lnwire.MilliSatoshi(f.FeeRate))/1000000
+}
+
+// PutForwardingPolicy stores the forwarding policy of the channel funded by
+// the target outpoint, overwriting any existing policy.
+func (d *DB) PutForwardingPolicy(chanPoint *wire.OutPoint,
+	policy *ForwardingPolicy) error {
+
+	key, err := outpointKey(chanPoint)
+	if err != nil {
+		return err
+	}
+
+	return d.store.Update(func(tx *bolt.Tx) error {
+		policies, err := tx.CreateBucketIfNotExists(forwardingPolicyBucket)
+		if err != nil {
+			return err
+		}
+
+		return policies.Put(key, serializeForwardingPolicy(policy))
+	})
+}
+
+// FetchForwardingPolicy returns the forwarding policy of the channel funded
+// by the target outpoint. If no policy has been set for the channel, then
+// ErrForwardingPolicyNotFound is returned.
+func (d *DB) FetchForwardingPolicy(chanPoint *wire.OutPoint) (*ForwardingPolicy, error) {
+	key, err := outpointKey(chanPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy *ForwardingPolicy
+	err = d.store.View(func(tx *bolt.Tx) error {
+		policies := tx.Bucket(forwardingPolicyBucket)
+		if policies == nil {
+			return ErrForwardingPolicyNotFound
+		}
+
+		policyBytes := policies.Get(key)
+		if policyBytes == nil {
+			return ErrForwardingPolicyNotFound
+		}
+
+		policy = deserializeForwardingPolicy(policyBytes)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// DeleteForwardingPolicy removes the forwarding policy of the channel funded
+// by the target outpoint.
+func (d *DB) DeleteForwardingPolicy(chanPoint *wire.OutPoint) error {
+	key, err := outpointKey(chanPoint)
+	if err != nil {
+		return err
+	}
+
+	return d.store.Update(func(tx *bolt.Tx) error {
+		policies := tx.Bucket(forwardingPolicyBucket)
+		if policies == nil {
+			return nil
+		}
+
+		return policies.Delete(key)
+	})
+}
+
+// AddNetFees adds the passed fee, earned by forwarding an HTLC across the
+// channel, to the channel's running total of fees in milli-satoshis, writing
+// the new total to disk.
+func (c *OpenChannel) AddNetFees(fee lnwire.MilliSatoshi) error {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.Db.store.Update(func(tx *bolt.Tx) error {
+		chanBucket := tx.Bucket(openChannelBucket)
+		if chanBucket == nil {
+			return ErrNoActiveChannels
+		}
+
+		c.TotalNetFees += uint64(fee)
+		if err := putChanNetFee(chanBucket, c); err != nil {
+			c.TotalNetFees -= uint64(fee)
+			return err
+		}
+
+		return nil
+	})
+}
+
+func serializeForwardingPolicy(policy *ForwardingPolicy) []byte {
+	var scratch [forwardingPolicySize]byte
+	byteOrder.PutUint64(scratch[:8], uint64(policy.BaseFee))
+	byteOrder.PutUint32(scratch[8:12], policy.FeeRate)
+	byteOrder.PutUint16(scratch[12:14], policy.TimeLockDelta)
+	byteOrder.PutUint64(scratch[14:22], uint64(policy.MinHTLC))
+	byteOrder.PutUint64(scratch[22:30], uint64(policy.MaxHTLC))
+
+	return scratch[:]
+}
+
+func deserializeForwardingPolicy(policyBytes []byte) *ForwardingPolicy {
+	return &ForwardingPolicy{
+		BaseFee:       btcutil.Amount(byteOrder.Uint64(policyBytes[:8])),
+		FeeRate:       byteOrder.Uint32(policyBytes[8:12]),
+		TimeLockDelta: byteOrder.Uint16(policyBytes[12:14]),
+		MinHTLC:       btcutil.Amount(byteOrder.Uint64(policyBytes[14:22])),
+		MaxHTLC:       btcutil.Amount(byteOrder.Uint64(policyBytes[22:30])),
+	}
+}
@@ -0,0 +1,67 @@
+package channeldb
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/roasbeef/btcd/wire"
+)
+
+func TestForwardingPolicyPutFetchDelete(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "channeldb")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := Open(tempDirName, netParams)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	chanPoint := wire.OutPoint{Hash: wire.ShaHash{0x01}, Index: 2}
+
+	// Before a policy has been stored, the lookup should fail.
+	_, err = cdb.FetchForwardingPolicy(&chanPoint)
+	if err != ErrForwardingPolicyNotFound {
+		t.Fatalf("expected ErrForwardingPolicyNotFound, got %v", err)
+	}
+
+	policy := &ForwardingPolicy{
+		BaseFee:       1000,
+		FeeRate:       250,
+		TimeLockDelta: 144,
+		MinHTLC:       10,
+		MaxHTLC:       1e6,
+	}
+	if err := cdb.PutForwardingPolicy(&chanPoint, policy); err != nil {
+		t.Fatalf("unable to store policy: %v", err)
+	}
+
+	fetchedPolicy, err := cdb.FetchForwardingPolicy(&chanPoint)
+	if err != nil {
+		t.Fatalf("unable to fetch policy: %v", err)
+	}
+	if !reflect.DeepEqual(policy, fetchedPolicy) {
+		t.Fatalf("policies don't match: expected %v, got %v", policy,
+			fetchedPolicy)
+	}
+
+	// A fee of 1000000 + 20010000*250/1e6 = 1005002 mSAT should be
+	// charged to forward 20010 satoshis, retaining the sub-satoshi
+	// portion of the proportional fee.
+	if fee := fetchedPolicy.ComputeFee(20010000); fee != 1005002 {
+		t.Fatalf("expected fee of 1005002 mSAT, got %v", fee)
+	}
+
+	if err := cdb.DeleteForwardingPolicy(&chanPoint); err != nil {
+		t.Fatalf("unable to delete policy: %v", err)
+	}
+	_, err = cdb.FetchForwardingPolicy(&chanPoint)
+	if err != ErrForwardingPolicyNotFound {
+		t.Fatalf("expected ErrForwardingPolicyNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package channeldb
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/wire"
+)
+
+var (
+	// forwardingLogBucket is an append-only log of all the HTLCs we've
+	// successfully forwarded. Each event is keyed by the time at which
+	// the forward completed, in nanoseconds since the unix epoch, so the
+	// log can be efficiently scanned by time range.
+	forwardingLogBucket = []byte("flb")
+)
+
+// ForwardingEvent records a single HTLC which was successfully forwarded
+// from one of our channels to another.
+type ForwardingEvent struct {
+	// Timestamp is the time at which the forward was settled.
+	Timestamp time.Time
+
+	// IncomingChan is the channel the HTLC arrived over.
+	IncomingChan wire.OutPoint
+
+	// OutgoingChan is the channel the HTLC was forwarded out across.
+	OutgoingChan wire.OutPoint
+
+	// AmtIn is the amount of the incoming HTLC.
+	AmtIn lnwire.MilliSatoshi
+
+	// AmtOut is the amount of the outgoing HTLC.
+	AmtOut lnwire.MilliSatoshi
+}
+
+// Fee returns the fee earned by the forward.
+func (f *ForwardingEvent) Fee() lnwire.MilliSatoshi {
+	return f.AmtIn - f.AmtOut
+}
+
+// ForwardingLogQuery selects a page of the events within a time range of
+// the forwarding log.
+type ForwardingLogQuery struct {
+	// StartTime is the earliest time of the events to be returned.
+	StartTime time.Time
+
+	// EndTime is the latest time of the events to be returned.
+	EndTime time.Time
+
+	// IndexOffset is the number of events within the time range to skip
+	// over, allowing the results to be paginated.
+	IndexOffset uint32
+
+	// NumMaxEvents is the maximum number of events to return.
+	NumMaxEvents uint32
+}
+
+// ForwardingLogTimeSlice is a page of events returned by a query of the
+// forwarding log.
+type ForwardingLogTimeSlice struct {
+	ForwardingLogQuery
+
+	// ForwardingEvents are the events within the page, ordered by time.
+	ForwardingEvents []*ForwardingEvent
+
+	// LastIndexOffset is the index offset of the event following the
+	// last one returned. It should be used as the IndexOffset of the
+	// query for the next page.
+	LastIndexOffset uint32
+}
+
+// AddForwardingEvents appends the passed events to the forwarding log. If an
+// event has the exact same timestamp as an event already within the log,
+// then its timestamp is nudged forward by a nanosecond until it's unique.
+func (d *DB) AddForwardingEvents(events []*ForwardingEvent) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		logBucket, err := tx.CreateBucketIfNotExists(forwardingLogBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			var b bytes.Buffer
+			if err := serializeForwardingEvent(&b, event); err != nil {
+				return err
+			}
+
+			timestamp := uint64(event.Timestamp.UnixNano())
+			var key [8]byte
+			for {
+				byteOrder.PutUint64(key[:], timestamp)
+				if logBucket.Get(key[:]) == nil {
+					break
+				}
+				timestamp++
+			}
+
+			if err := logBucket.Put(key[:], b.Bytes()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ForEachForwardingEvent iterates through all events within the forwarding
+// log which fall within the passed time range, in order of time, executing
+// the passed callback for each. If the callback returns an error, then the
+// iteration is halted, and the error is returned.
+func (d *DB) ForEachForwardingEvent(startTime, endTime time.Time,
+	cb func(*ForwardingEvent) error) error {
+
+	var startKey, endKey [8]byte
+	byteOrder.PutUint64(startKey[:], uint64(startTime.UnixNano()))
+	byteOrder.PutUint64(endKey[:], uint64(endTime.UnixNano()))
+
+	return d.store.View(func(tx *bolt.Tx) error {
+		logBucket := tx.Bucket(forwardingLogBucket)
+		if logBucket == nil {
+			return nil
+		}
+
+		c := logBucket.Cursor()
+		for k, v := c.Seek(startKey[:]); k != nil &&
+			bytes.Compare(k, endKey[:]) <= 0; k, v = c.Next() {
+
+			event, err := deserializeForwardingEvent(k, bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+
+			if err := cb(event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// QueryForwardingLog returns the page of the forwarding log selected by the
+// passed query.
+func (d *DB) QueryForwardingLog(q ForwardingLogQuery) (*ForwardingLogTimeSlice, error) {
+	resp := &ForwardingLogTimeSlice{
+		ForwardingLogQuery: q,
+	}
+
+	// errPageFull is used to halt the iteration once the page has been
+	// filled.
+	errPageFull := io.EOF
+
+	var index uint32
+	err := d.ForEachForwardingEvent(q.StartTime, q.EndTime,
+		func(event *ForwardingEvent) error {
+			index++
+			if index <= q.IndexOffset {
+				return nil
+			}
+
+			resp.ForwardingEvents = append(resp.ForwardingEvents, event)
+			if uint32(len(resp.ForwardingEvents)) >= q.NumMaxEvents {
+				return errPageFull
+			}
+
+			return nil
+		})
+	if err != nil && err != errPageFull {
+		return nil, err
+	}
+
+	resp.LastIndexOffset = q.IndexOffset + uint32(len(resp.ForwardingEvents))
+	return resp, nil
+}
+
+func serializeForwardingEvent(w io.Writer, event *ForwardingEvent) error {
+	if err := writeOutpoint(w, &event.IncomingChan); err != nil {
+		return err
+	}
+	if err := writeOutpoint(w, &event.OutgoingChan); err != nil {
+		return err
+	}
+
+	var scratch [16]byte
+	byteOrder.PutUint64(scratch[:8], uint64(event.AmtIn))
+	byteOrder.PutUint64(scratch[8:], uint64(event.AmtOut))
+	_, err := w.Write(scratch[:])
+	return err
+}
+
+func deserializeForwardingEvent(key []byte, r io.Reader) (*ForwardingEvent, error) {
+	event := &ForwardingEvent{
+		Timestamp: time.Unix(0, int64(byteOrder.Uint64(key))),
+	}
+
+	if err := readOutpoint(r, &event.IncomingChan); err != nil {
+		return nil, err
+	}
+	if err := readOutpoint(r, &event.OutgoingChan); err != nil {
+		return nil, err
+	}
+
+	var scratch [16]byte
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	event.AmtIn = lnwire.MilliSatoshi(byteOrder.Uint64(scratch[:8]))
+	event.AmtOut = lnwire.MilliSatoshi(byteOrder.Uint64(scratch[8:]))
+
+	return event, nil
+}
@@ -0,0 +1,102 @@
+package channeldb
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/wire"
+)
+
+func TestForwardingLogQuery(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "channeldb")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := Open(tempDirName, netParams)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	chanIn := wire.OutPoint{Hash: wire.ShaHash{0x01}, Index: 0}
+	chanOut := wire.OutPoint{Hash: wire.ShaHash{0x02}, Index: 1}
+
+	// Add ten events, a minute apart. The final two events share the
+	// same timestamp, so the second should be nudged forward in order to
+	// be stored separately.
+	startTime := time.Unix(1000000, 0)
+	events := make([]*ForwardingEvent, 10)
+	for i := range events {
+		events[i] = &ForwardingEvent{
+			Timestamp:    startTime.Add(time.Duration(i) * time.Minute),
+			IncomingChan: chanIn,
+			OutgoingChan: chanOut,
+			AmtIn:        lnwire.MilliSatoshi(1000 + i),
+			AmtOut:       1000,
+		}
+	}
+	events[9].Timestamp = events[8].Timestamp
+	if err := cdb.AddForwardingEvents(events); err != nil {
+		t.Fatalf("unable to add events: %v", err)
+	}
+	events[9].Timestamp = events[9].Timestamp.Add(time.Nanosecond)
+
+	// Query the second page of three events, which should return the
+	// fourth through sixth events.
+	resp, err := cdb.QueryForwardingLog(ForwardingLogQuery{
+		StartTime:    startTime,
+		EndTime:      startTime.Add(time.Hour),
+		IndexOffset:  3,
+		NumMaxEvents: 3,
+	})
+	if err != nil {
+		t.Fatalf("unable to query log: %v", err)
+	}
+	if !reflect.DeepEqual(resp.ForwardingEvents, events[3:6]) {
+		t.Fatalf("wrong events returned: expected %v, got %v",
+			events[3:6], resp.ForwardingEvents)
+	}
+	if resp.LastIndexOffset != 6 {
+		t.Fatalf("expected last index offset of 6, got %v",
+			resp.LastIndexOffset)
+	}
+
+	// A query ending before the eighth event should only return the
+	// events prior to it.
+	resp, err = cdb.QueryForwardingLog(ForwardingLogQuery{
+		StartTime:    startTime.Add(5 * time.Minute),
+		EndTime:      startTime.Add(7*time.Minute - time.Second),
+		NumMaxEvents: 100,
+	})
+	if err != nil {
+		t.Fatalf("unable to query log: %v", err)
+	}
+	if !reflect.DeepEqual(resp.ForwardingEvents, events[5:7]) {
+		t.Fatalf("wrong events returned: expected %v, got %v",
+			events[5:7], resp.ForwardingEvents)
+	}
+
+	// Both events sharing a timestamp should be present at the end of
+	// the log.
+	resp, err = cdb.QueryForwardingLog(ForwardingLogQuery{
+		StartTime:    startTime.Add(8 * time.Minute),
+		EndTime:      startTime.Add(time.Hour),
+		NumMaxEvents: 100,
+	})
+	if err != nil {
+		t.Fatalf("unable to query log: %v", err)
+	}
+	if !reflect.DeepEqual(resp.ForwardingEvents, events[8:]) {
+		t.Fatalf("wrong events returned: expected %v, got %v",
+			events[8:], resp.ForwardingEvents)
+	}
+	if fee := resp.ForwardingEvents[1].Fee(); fee != 9 {
+		t.Fatalf("expected fee of 9, got %v", fee)
+	}
+}
@@ -0,0 +1,721 @@
+package channeldb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+)
+
+var (
+	// graphNodeBucket stores all the known nodes within the channel graph,
+	// keyed by their Lightning ID.
+	graphNodeBucket = []byte("gnb")
+
+	// graphEdgeBucket stores all the directed channel edges within the
+	// channel graph. Each edge is keyed by the ID of the node it
+	// originates from, followed by the funding outpoint of the channel:
+	// key = fromID || chanPoint. Prefixing the key with the origin node's
+	// ID allows all the outgoing edges of a node to be retrieved with a
+	// single prefix scan.
+	graphEdgeBucket = []byte("geb")
+
+	// channelPointBucket is an index of all channels within the graph,
+	// mapping the funding outpoint of each channel to the IDs of its two
+	// endpoints: value = nodeID1 || nodeID2.
+	channelPointBucket = []byte("gcb")
+
+	// graphMetaBucket stores meta-data concerning the channel graph
+	// itself, such as the source node.
+	graphMetaBucket = []byte("gmb")
+
+	// sourceKey is the key within the graphMetaBucket under which the ID
+	// of our own node is stored.
+	sourceKey = []byte("source")
+)
+
+// LightningNode is a node within the channel graph.
+type LightningNode struct {
+	// ID is the node's Lightning ID: the sha256 of its compressed
+	// identity public key.
+	ID [32]byte
+
+	// PubKey is the node's identity public key. It may be nil, if only
+	// the ID of the node is known.
+	PubKey *btcec.PublicKey
+
+	// LastUpdate is the time at which the node's information was last
+	// updated.
+	LastUpdate time.Time
+
+	// Alias is an optional human readable name for the node.
+	Alias string
+
+	// Addresses is the list of addresses the node accepts incoming
+	// connections on.
+	Addresses []*net.TCPAddr
+}
+
+// ChannelEdge is a directed edge within the channel graph. Each channel is
+// represented by up to two edges, one in each direction, as each endpoint
+// advertises its own forwarding policy for payments flowing out across the
+// channel.
+type ChannelEdge struct {
+	// ChannelPoint is the funding outpoint of the channel.
+	ChannelPoint wire.OutPoint
+
+	// From is the ID of the node which this edge originates from, and
+	// whose forwarding policy is described by this edge.
+	From [32]byte
+
+	// To is the ID of the node at the other end of the channel.
+	To [32]byte
+
+	// Capacity is the total capacity of the channel.
+	Capacity btcutil.Amount
+
+	// TimeLockDelta is the number of blocks the From node subtracts from
+	// the time-lock of an HTLC forwarded across this edge.
+	TimeLockDelta uint16
+
+	// MinHTLC is the smallest HTLC the From node will forward across
+	// this edge.
+	MinHTLC btcutil.Amount
+
+	// MaxHTLC is the largest HTLC the From node will forward across this
+	// edge. A value of zero indicates that there's no maximum.
+	MaxHTLC btcutil.Amount
+
+	// FeeBase is the base fee charged by the From node for forwarding an
+	// HTLC across this edge.
+	FeeBase btcutil.Amount
+
+	// FeeRate is the proportional fee charged by the From node for
+	// forwarding an HTLC across this edge, in millionths of the HTLC's
+	// amount.
+	FeeRate uint32
+
+	// LastUpdate is the time at which the edge's policy was last
+	// updated.
+	LastUpdate time.Time
+}
+
+// SetSourceNode adds the passed node to the graph, and marks it as the
+// source node. The source node is our own node, and is the starting point
+// for all path finding.
+func (d *DB) SetSourceNode(node *LightningNode) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		nodes, err := tx.CreateBucketIfNotExists(graphNodeBucket)
+		if err != nil {
+			return err
+		}
+		if err := putLightningNode(nodes, node); err != nil {
+			return err
+		}
+
+		meta, err := tx.CreateBucketIfNotExists(graphMetaBucket)
+		if err != nil {
+			return err
+		}
+		return meta.Put(sourceKey, node.ID[:])
+	})
+}
+
+// SourceNode returns the source node of the graph. If no source node has
+// been set, then ErrSourceNodeNotSet is returned.
+func (d *DB) SourceNode() (*LightningNode, error) {
+	var node *LightningNode
+	err := d.store.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(graphMetaBucket)
+		if meta == nil {
+			return ErrSourceNodeNotSet
+		}
+		sourceID := meta.Get(sourceKey)
+		if sourceID == nil {
+			return ErrSourceNodeNotSet
+		}
+
+		var err error
+		node, err = fetchLightningNode(tx.Bucket(graphNodeBucket),
+			sourceID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// AddLightningNode adds the passed node to the graph. If the node already
+// exists, then its information is overwritten.
+func (d *DB) AddLightningNode(node *LightningNode) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		nodes, err := tx.CreateBucketIfNotExists(graphNodeBucket)
+		if err != nil {
+			return err
+		}
+
+		return putLightningNode(nodes, node)
+	})
+}
+
+// FetchLightningNode returns the node with the target ID. If the node isn't
+// within the graph, then ErrGraphNodeNotFound is returned.
+func (d *DB) FetchLightningNode(id [32]byte) (*LightningNode, error) {
+	var node *LightningNode
+	err := d.store.View(func(tx *bolt.Tx) error {
+		var err error
+		node, err = fetchLightningNode(tx.Bucket(graphNodeBucket), id[:])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// ForEachNode iterates through all the nodes within the graph, executing the
+// passed callback for each. If the callback returns an error, then the
+// iteration is halted, and the error is returned.
+func (d *DB) ForEachNode(cb func(*LightningNode) error) error {
+	return d.store.View(func(tx *bolt.Tx) error {
+		nodes := tx.Bucket(graphNodeBucket)
+		if nodes == nil {
+			return nil
+		}
+
+		return nodes.ForEach(func(k, v []byte) error {
+			node, err := deserializeLightningNode(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+
+			return cb(node)
+		})
+	})
+}
+
+// DeleteLightningNode removes the node with the target ID from the graph,
+// along with all the channels it's an endpoint of.
+func (d *DB) DeleteLightningNode(id [32]byte) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		nodes := tx.Bucket(graphNodeBucket)
+		if nodes == nil || nodes.Get(id[:]) == nil {
+			return ErrGraphNodeNotFound
+		}
+
+		chanIndex := tx.Bucket(channelPointBucket)
+		if chanIndex != nil {
+			// Collect the channels first, as a bucket mustn't be
+			// modified while it's being iterated over.
+			var chanKeys [][]byte
+			err := chanIndex.ForEach(func(k, v []byte) error {
+				if bytes.Equal(v[:32], id[:]) ||
+					bytes.Equal(v[32:], id[:]) {
+
+					chanKeys = append(chanKeys,
+						append([]byte(nil), k...))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, chanKey := range chanKeys {
+				if err := deleteChannel(tx, chanKey); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nodes.Delete(id[:])
+	})
+}
+
+// AddChannelEdge adds the passed directed edge to the graph. If the edge
+// already exists, then it's overwritten. Both endpoints of the edge are
+// added to the graph if they aren't yet known.
+func (d *DB) AddChannelEdge(edge *ChannelEdge) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		return putChannelEdge(tx, edge)
+	})
+}
+
+// UpdateEdgePolicy overwrites an existing directed edge within the graph
+// with the passed edge, typically in order to update its forwarding policy.
+// If the edge doesn't yet exist, then ErrEdgeNotFound is returned.
+func (d *DB) UpdateEdgePolicy(edge *ChannelEdge) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		edges := tx.Bucket(graphEdgeBucket)
+		if edges == nil {
+			return ErrEdgeNotFound
+		}
+
+		edgeKey, err := channelEdgeKey(edge.From, &edge.ChannelPoint)
+		if err != nil {
+			return err
+		}
+		if edges.Get(edgeKey) == nil {
+			return ErrEdgeNotFound
+		}
+
+		return putChannelEdge(tx, edge)
+	})
+}
+
+// FetchChannelEdges returns the directed edges of the channel funded by the
+// target outpoint. Either one or two edges are returned, depending on
+// whether the policies of one or both directions are known. If the channel
+// isn't within the graph, then ErrEdgeNotFound is returned.
+func (d *DB) FetchChannelEdges(chanPoint *wire.OutPoint) ([]*ChannelEdge, error) {
+	var channelEdges []*ChannelEdge
+	err := d.store.View(func(tx *bolt.Tx) error {
+		chanIndex := tx.Bucket(channelPointBucket)
+		edges := tx.Bucket(graphEdgeBucket)
+		if chanIndex == nil || edges == nil {
+			return ErrEdgeNotFound
+		}
+
+		chanKey, err := outpointKey(chanPoint)
+		if err != nil {
+			return err
+		}
+		nodeIDs := chanIndex.Get(chanKey)
+		if nodeIDs == nil {
+			return ErrEdgeNotFound
+		}
+
+		for _, from := range [][]byte{nodeIDs[:32], nodeIDs[32:]} {
+			edgeKey := append(append([]byte(nil), from...), chanKey...)
+			edgeBytes := edges.Get(edgeKey)
+			if edgeBytes == nil {
+				continue
+			}
+
+			edge, err := deserializeChannelEdge(bytes.NewReader(edgeBytes))
+			if err != nil {
+				return err
+			}
+			channelEdges = append(channelEdges, edge)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return channelEdges, nil
+}
+
+// DeleteChannelEdges removes both directed edges of the channel funded by
+// the target outpoint from the graph. If the channel isn't within the
+// graph, then ErrEdgeNotFound is returned.
+func (d *DB) DeleteChannelEdges(chanPoint *wire.OutPoint) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		chanKey, err := outpointKey(chanPoint)
+		if err != nil {
+			return err
+		}
+
+		chanIndex := tx.Bucket(channelPointBucket)
+		if chanIndex == nil || chanIndex.Get(chanKey) == nil {
+			return ErrEdgeNotFound
+		}
+
+		return deleteChannel(tx, chanKey)
+	})
+}
+
+// ForEachChannelEdge iterates through all the directed edges within the
+// graph, executing the passed callback for each. If the callback returns an
+// error, then the iteration is halted, and the error is returned.
+func (d *DB) ForEachChannelEdge(cb func(*ChannelEdge) error) error {
+	return d.store.View(func(tx *bolt.Tx) error {
+		edges := tx.Bucket(graphEdgeBucket)
+		if edges == nil {
+			return nil
+		}
+
+		return edges.ForEach(func(k, v []byte) error {
+			edge, err := deserializeChannelEdge(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+
+			return cb(edge)
+		})
+	})
+}
+
+// ForEachNodeChannel iterates through all the directed edges originating
+// from the node with the target ID, executing the passed callback for each.
+// If the callback returns an error, then the iteration is halted, and the
+// error is returned.
+func (d *DB) ForEachNodeChannel(id [32]byte, cb func(*ChannelEdge) error) error {
+	return d.store.View(func(tx *bolt.Tx) error {
+		edges := tx.Bucket(graphEdgeBucket)
+		if edges == nil {
+			return nil
+		}
+
+		c := edges.Cursor()
+		for k, v := c.Seek(id[:]); k != nil && bytes.HasPrefix(k, id[:]); k, v = c.Next() {
+			edge, err := deserializeChannelEdge(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+			if err := cb(edge); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// PruneGraph removes all channels funded by any of the passed spent outputs
+// from the graph, as a spent funding output indicates the channel has been
+// closed. Any node, other than the source node, which is left without any
+// channels is removed as well. The number of channels removed is returned.
+func (d *DB) PruneGraph(spentOutputs []*wire.OutPoint) (int, error) {
+	var numPruned int
+	err := d.store.Update(func(tx *bolt.Tx) error {
+		chanIndex := tx.Bucket(channelPointBucket)
+		if chanIndex == nil {
+			return nil
+		}
+
+		// First, remove each channel, taking note of its endpoints.
+		endpoints := make(map[[32]byte]struct{})
+		for _, spentOutput := range spentOutputs {
+			chanKey, err := outpointKey(spentOutput)
+			if err != nil {
+				return err
+			}
+
+			nodeIDs := chanIndex.Get(chanKey)
+			if nodeIDs == nil {
+				continue
+			}
+
+			var node1, node2 [32]byte
+			copy(node1[:], nodeIDs[:32])
+			copy(node2[:], nodeIDs[32:])
+			endpoints[node1] = struct{}{}
+			endpoints[node2] = struct{}{}
+
+			if err := deleteChannel(tx, chanKey); err != nil {
+				return err
+			}
+			numPruned++
+		}
+
+		// Next, remove any endpoint which no longer has any channels,
+		// sparing the source node.
+		nodes := tx.Bucket(graphNodeBucket)
+		if nodes == nil {
+			return nil
+		}
+		var sourceID []byte
+		if meta := tx.Bucket(graphMetaBucket); meta != nil {
+			sourceID = meta.Get(sourceKey)
+		}
+
+		linkedNodes := make(map[[32]byte]struct{})
+		err := chanIndex.ForEach(func(k, v []byte) error {
+			var node1, node2 [32]byte
+			copy(node1[:], v[:32])
+			copy(node2[:], v[32:])
+			linkedNodes[node1] = struct{}{}
+			linkedNodes[node2] = struct{}{}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for nodeID := range endpoints {
+			if _, ok := linkedNodes[nodeID]; ok {
+				continue
+			}
+			if bytes.Equal(nodeID[:], sourceID) {
+				continue
+			}
+
+			if err := nodes.Delete(nodeID[:]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return numPruned, nil
+}
+
+// putChannelEdge writes the passed directed edge, and the index entry of its
+// channel. Both endpoints of the edge are added to the node bucket if they
+// aren't yet present.
+func putChannelEdge(tx *bolt.Tx, edge *ChannelEdge) error {
+	nodes, err := tx.CreateBucketIfNotExists(graphNodeBucket)
+	if err != nil {
+		return err
+	}
+	edges, err := tx.CreateBucketIfNotExists(graphEdgeBucket)
+	if err != nil {
+		return err
+	}
+	chanIndex, err := tx.CreateBucketIfNotExists(channelPointBucket)
+	if err != nil {
+		return err
+	}
+
+	for _, nodeID := range [][32]byte{edge.From, edge.To} {
+		if nodes.Get(nodeID[:]) != nil {
+			continue
+		}
+		if err := putLightningNode(nodes, &LightningNode{ID: nodeID}); err != nil {
+			return err
+		}
+	}
+
+	chanKey, err := outpointKey(&edge.ChannelPoint)
+	if err != nil {
+		return err
+	}
+	if chanIndex.Get(chanKey) == nil {
+		nodeIDs := make([]byte, 64)
+		copy(nodeIDs[:32], edge.From[:])
+		copy(nodeIDs[32:], edge.To[:])
+		if err := chanIndex.Put(chanKey, nodeIDs); err != nil {
+			return err
+		}
+	}
+
+	var b bytes.Buffer
+	if err := serializeChannelEdge(&b, edge); err != nil {
+		return err
+	}
+
+	edgeKey := append(edge.From[:], chanKey...)
+	return edges.Put(edgeKey, b.Bytes())
+}
+
+// deleteChannel removes both directed edges of the channel indexed under
+// chanKey, along with the index entry itself.
+func deleteChannel(tx *bolt.Tx, chanKey []byte) error {
+	chanIndex := tx.Bucket(channelPointBucket)
+	nodeIDs := chanIndex.Get(chanKey)
+	if nodeIDs == nil {
+		return nil
+	}
+
+	if edges := tx.Bucket(graphEdgeBucket); edges != nil {
+		for _, from := range [][]byte{nodeIDs[:32], nodeIDs[32:]} {
+			edgeKey := append(append([]byte(nil), from...), chanKey...)
+			if err := edges.Delete(edgeKey); err != nil {
+				return err
+			}
+		}
+	}
+
+	// If the channel is one of our own, then its announcement is removed
+	// as well.
+	if anns := tx.Bucket(channelAnnouncementBucket); anns != nil {
+		if err := anns.Delete(chanKey); err != nil {
+			return err
+		}
+	}
+
+	return chanIndex.Delete(chanKey)
+}
+
+// channelEdgeKey returns the key of the directed edge originating from the
+// passed node, within the channel funded by chanPoint.
+func channelEdgeKey(from [32]byte, chanPoint *wire.OutPoint) ([]byte, error) {
+	chanKey, err := outpointKey(chanPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(from[:], chanKey...), nil
+}
+
+func putLightningNode(nodes *bolt.Bucket, node *LightningNode) error {
+	var b bytes.Buffer
+	if err := serializeLightningNode(&b, node); err != nil {
+		return err
+	}
+
+	return nodes.Put(node.ID[:], b.Bytes())
+}
+
+func fetchLightningNode(nodes *bolt.Bucket, id []byte) (*LightningNode, error) {
+	if nodes == nil {
+		return nil, ErrGraphNodeNotFound
+	}
+
+	nodeBytes := nodes.Get(id)
+	if nodeBytes == nil {
+		return nil, ErrGraphNodeNotFound
+	}
+
+	return deserializeLightningNode(bytes.NewReader(nodeBytes))
+}
+
+func serializeLightningNode(w io.Writer, node *LightningNode) error {
+	if _, err := w.Write(node.ID[:]); err != nil {
+		return err
+	}
+
+	var pubKey []byte
+	if node.PubKey != nil {
+		pubKey = node.PubKey.SerializeCompressed()
+	}
+	if err := wire.WriteVarBytes(w, 0, pubKey); err != nil {
+		return err
+	}
+
+	var scratch [8]byte
+	byteOrder.PutUint64(scratch[:], uint64(node.LastUpdate.Unix()))
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+
+	if err := wire.WriteVarString(w, 0, node.Alias); err != nil {
+		return err
+	}
+
+	if len(node.Addresses) > 255 {
+		return fmt.Errorf("too many addresses: %v", len(node.Addresses))
+	}
+	if _, err := w.Write([]byte{uint8(len(node.Addresses))}); err != nil {
+		return err
+	}
+	for _, addr := range node.Addresses {
+		if err := wire.WriteVarBytes(w, 0, addr.IP); err != nil {
+			return err
+		}
+		byteOrder.PutUint16(scratch[:2], uint16(addr.Port))
+		if _, err := w.Write(scratch[:2]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deserializeLightningNode(r io.Reader) (*LightningNode, error) {
+	node := &LightningNode{}
+
+	if _, err := io.ReadFull(r, node.ID[:]); err != nil {
+		return nil, err
+	}
+
+	pubKey, err := wire.ReadVarBytes(r, 0, 33, "pubkey")
+	if err != nil {
+		return nil, err
+	}
+	if len(pubKey) != 0 {
+		node.PubKey, err = btcec.ParsePubKey(pubKey, btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var scratch [8]byte
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	node.LastUpdate = time.Unix(int64(byteOrder.Uint64(scratch[:])), 0)
+
+	node.Alias, err = wire.ReadVarString(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(r, scratch[:1]); err != nil {
+		return nil, err
+	}
+	numAddrs := int(scratch[0])
+	for i := 0; i < numAddrs; i++ {
+		ip, err := wire.ReadVarBytes(r, 0, net.IPv6len, "ip")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, scratch[:2]); err != nil {
+			return nil, err
+		}
+
+		node.Addresses = append(node.Addresses, &net.TCPAddr{
+			IP:   net.IP(ip),
+			Port: int(byteOrder.Uint16(scratch[:2])),
+		})
+	}
+
+	return node, nil
+}
+
+func serializeChannelEdge(w io.Writer, edge *ChannelEdge) error {
+	if err := writeOutpoint(w, &edge.ChannelPoint); err != nil {
+		return err
+	}
+	if _, err := w.Write(edge.From[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(edge.To[:]); err != nil {
+		return err
+	}
+
+	var scratch [46]byte
+	byteOrder.PutUint64(scratch[:8], uint64(edge.Capacity))
+	byteOrder.PutUint16(scratch[8:10], edge.TimeLockDelta)
+	byteOrder.PutUint64(scratch[10:18], uint64(edge.MinHTLC))
+	byteOrder.PutUint64(scratch[18:26], uint64(edge.MaxHTLC))
+	byteOrder.PutUint64(scratch[26:34], uint64(edge.FeeBase))
+	byteOrder.PutUint32(scratch[34:38], edge.FeeRate)
+	byteOrder.PutUint64(scratch[38:46], uint64(edge.LastUpdate.Unix()))
+	_, err := w.Write(scratch[:])
+	return err
+}
+
+func deserializeChannelEdge(r io.Reader) (*ChannelEdge, error) {
+	edge := &ChannelEdge{}
+
+	if err := readOutpoint(r, &edge.ChannelPoint); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, edge.From[:]); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, edge.To[:]); err != nil {
+		return nil, err
+	}
+
+	var scratch [46]byte
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	edge.Capacity = btcutil.Amount(byteOrder.Uint64(scratch[:8]))
+	edge.TimeLockDelta = byteOrder.Uint16(scratch[8:10])
+	edge.MinHTLC = btcutil.Amount(byteOrder.Uint64(scratch[10:18]))
+	edge.MaxHTLC = btcutil.Amount(byteOrder.Uint64(scratch[18:26]))
+	edge.FeeBase = btcutil.Amount(byteOrder.Uint64(scratch[26:34]))
+	edge.FeeRate = byteOrder.Uint32(scratch[34:38])
+	edge.LastUpdate = time.Unix(int64(byteOrder.Uint64(scratch[38:46])), 0)
+
+	return edge, nil
+}
@@ -0,0 +1,278 @@
+package channeldb
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/fastsha256"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/wire"
+)
+
+func makeTestGraphDB() (*DB, func(), error) {
+	tempDirName, err := ioutil.TempDir("", "channeldb")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cdb, err := Open(tempDirName, netParams)
+	if err != nil {
+		os.RemoveAll(tempDirName)
+		return nil, nil, err
+	}
+
+	cleanUp := func() {
+		cdb.Close()
+		os.RemoveAll(tempDirName)
+	}
+
+	return cdb, cleanUp, nil
+}
+
+func createTestNode(alias string) (*LightningNode, error) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+	pub := priv.PubKey()
+
+	return &LightningNode{
+		ID:         fastsha256.Sum256(pub.SerializeCompressed()),
+		PubKey:     pub,
+		LastUpdate: time.Unix(1466000000, 0),
+		Alias:      alias,
+		Addresses: []*net.TCPAddr{
+			{IP: net.ParseIP("127.0.0.1").To4(), Port: 10011},
+		},
+	}, nil
+}
+
+func TestGraphNodes(t *testing.T) {
+	cdb, cleanUp, err := makeTestGraphDB()
+	if err != nil {
+		t.Fatalf("unable to make test database: %v", err)
+	}
+	defer cleanUp()
+
+	if _, err := cdb.SourceNode(); err != ErrSourceNodeNotSet {
+		t.Fatalf("expected ErrSourceNodeNotSet, got %v", err)
+	}
+
+	source, err := createTestNode("source")
+	if err != nil {
+		t.Fatalf("unable to create test node: %v", err)
+	}
+	if err := cdb.SetSourceNode(source); err != nil {
+		t.Fatalf("unable to set source node: %v", err)
+	}
+	node, err := createTestNode("node")
+	if err != nil {
+		t.Fatalf("unable to create test node: %v", err)
+	}
+	if err := cdb.AddLightningNode(node); err != nil {
+		t.Fatalf("unable to add node: %v", err)
+	}
+
+	// Both nodes should be retrievable, with all fields intact.
+	dbSource, err := cdb.SourceNode()
+	if err != nil {
+		t.Fatalf("unable to fetch source node: %v", err)
+	}
+	if !reflect.DeepEqual(dbSource, source) {
+		t.Fatalf("source node mismatch: expected %v, got %v", source,
+			dbSource)
+	}
+	dbNode, err := cdb.FetchLightningNode(node.ID)
+	if err != nil {
+		t.Fatalf("unable to fetch node: %v", err)
+	}
+	if !reflect.DeepEqual(dbNode, node) {
+		t.Fatalf("node mismatch: expected %v, got %v", node, dbNode)
+	}
+
+	numNodes := 0
+	err = cdb.ForEachNode(func(*LightningNode) error {
+		numNodes++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to iterate nodes: %v", err)
+	}
+	if numNodes != 2 {
+		t.Fatalf("expected 2 nodes, found %v", numNodes)
+	}
+
+	if err := cdb.DeleteLightningNode(node.ID); err != nil {
+		t.Fatalf("unable to delete node: %v", err)
+	}
+	if _, err := cdb.FetchLightningNode(node.ID); err != ErrGraphNodeNotFound {
+		t.Fatalf("expected ErrGraphNodeNotFound, got %v", err)
+	}
+}
+
+func TestGraphChannelEdges(t *testing.T) {
+	cdb, cleanUp, err := makeTestGraphDB()
+	if err != nil {
+		t.Fatalf("unable to make test database: %v", err)
+	}
+	defer cleanUp()
+
+	source, err := createTestNode("source")
+	if err != nil {
+		t.Fatalf("unable to create test node: %v", err)
+	}
+	if err := cdb.SetSourceNode(source); err != nil {
+		t.Fatalf("unable to set source node: %v", err)
+	}
+	var nodeA, nodeB [32]byte
+	nodeA[0] = 0xaa
+	nodeB[0] = 0xbb
+
+	// Create two channels: source <-> A, and A -> B, with the policy of
+	// only one direction known for the latter.
+	chanPoint1 := wire.OutPoint{Hash: wire.ShaHash{0x01}, Index: 0}
+	chanPoint2 := wire.OutPoint{Hash: wire.ShaHash{0x02}, Index: 1}
+	edges := []*ChannelEdge{
+		{
+			ChannelPoint:  chanPoint1,
+			From:          source.ID,
+			To:            nodeA,
+			Capacity:      1e8,
+			TimeLockDelta: 10,
+			MinHTLC:       1000,
+			MaxHTLC:       1e7,
+			FeeBase:       1,
+			FeeRate:       100,
+			LastUpdate:    time.Unix(1466000000, 0),
+		},
+		{
+			ChannelPoint: chanPoint1,
+			From:         nodeA,
+			To:           source.ID,
+			Capacity:     1e8,
+			LastUpdate:   time.Unix(1466000000, 0),
+		},
+		{
+			ChannelPoint: chanPoint2,
+			From:         nodeA,
+			To:           nodeB,
+			Capacity:     5e7,
+			LastUpdate:   time.Unix(1466000000, 0),
+		},
+	}
+	for _, edge := range edges {
+		if err := cdb.AddChannelEdge(edge); err != nil {
+			t.Fatalf("unable to add edge: %v", err)
+		}
+	}
+
+	// The endpoints which weren't explicitly added should now be known.
+	for _, nodeID := range [][32]byte{nodeA, nodeB} {
+		if _, err := cdb.FetchLightningNode(nodeID); err != nil {
+			t.Fatalf("endpoint not added to graph: %v", err)
+		}
+	}
+
+	chanEdges, err := cdb.FetchChannelEdges(&chanPoint1)
+	if err != nil {
+		t.Fatalf("unable to fetch channel edges: %v", err)
+	}
+	if len(chanEdges) != 2 {
+		t.Fatalf("expected 2 edges, got %v", len(chanEdges))
+	}
+	for _, edge := range chanEdges {
+		expected := edges[0]
+		if edge.From == nodeA {
+			expected = edges[1]
+		}
+		if !reflect.DeepEqual(edge, expected) {
+			t.Fatalf("edge mismatch: expected %v, got %v",
+				expected, edge)
+		}
+	}
+
+	// Node A has two outgoing edges, while B has none.
+	numEdges := 0
+	err = cdb.ForEachNodeChannel(nodeA, func(edge *ChannelEdge) error {
+		if edge.From != nodeA {
+			t.Fatalf("edge doesn't originate from node A")
+		}
+		numEdges++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to iterate node channels: %v", err)
+	}
+	if numEdges != 2 {
+		t.Fatalf("expected 2 outgoing edges, found %v", numEdges)
+	}
+	numEdges = 0
+	err = cdb.ForEachChannelEdge(func(*ChannelEdge) error {
+		numEdges++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to iterate edges: %v", err)
+	}
+	if numEdges != 3 {
+		t.Fatalf("expected 3 edges, found %v", numEdges)
+	}
+
+	// Updating the policy of an existing edge should overwrite it, while
+	// an unknown edge can't be updated.
+	updatedEdge := *edges[1]
+	updatedEdge.FeeRate = 500
+	if err := cdb.UpdateEdgePolicy(&updatedEdge); err != nil {
+		t.Fatalf("unable to update edge: %v", err)
+	}
+	unknownEdge := updatedEdge
+	unknownEdge.From = nodeB
+	if err := cdb.UpdateEdgePolicy(&unknownEdge); err != ErrEdgeNotFound {
+		t.Fatalf("expected ErrEdgeNotFound, got %v", err)
+	}
+	chanEdges, err = cdb.FetchChannelEdges(&chanPoint1)
+	if err != nil {
+		t.Fatalf("unable to fetch channel edges: %v", err)
+	}
+	for _, edge := range chanEdges {
+		if edge.From == nodeA && edge.FeeRate != 500 {
+			t.Fatalf("edge policy not updated")
+		}
+	}
+
+	// Pruning the second channel should remove it along with node B, as
+	// it no longer has any channels. Node A still has a channel with the
+	// source node, so it should remain.
+	numPruned, err := cdb.PruneGraph([]*wire.OutPoint{&chanPoint2,
+		{Hash: wire.ShaHash{0x03}}})
+	if err != nil {
+		t.Fatalf("unable to prune graph: %v", err)
+	}
+	if numPruned != 1 {
+		t.Fatalf("expected 1 channel to be pruned, got %v", numPruned)
+	}
+	if _, err := cdb.FetchChannelEdges(&chanPoint2); err != ErrEdgeNotFound {
+		t.Fatalf("expected ErrEdgeNotFound, got %v", err)
+	}
+	if _, err := cdb.FetchLightningNode(nodeB); err != ErrGraphNodeNotFound {
+		t.Fatalf("expected node B to be pruned, got %v", err)
+	}
+	if _, err := cdb.FetchLightningNode(nodeA); err != nil {
+		t.Fatalf("node A shouldn't have been pruned: %v", err)
+	}
+
+	// Pruning the final channel should leave only the source node.
+	if _, err := cdb.PruneGraph([]*wire.OutPoint{&chanPoint1}); err != nil {
+		t.Fatalf("unable to prune graph: %v", err)
+	}
+	if _, err := cdb.FetchLightningNode(nodeA); err != ErrGraphNodeNotFound {
+		t.Fatalf("expected node A to be pruned, got %v", err)
+	}
+	if _, err := cdb.SourceNode(); err != nil {
+		t.Fatalf("source node shouldn't have been pruned: %v", err)
+	}
+}
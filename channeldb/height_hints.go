@@ -0,0 +1,243 @@
+package channeldb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/boltdb/bolt"
+	"github.com/roasbeef/btcd/wire"
+)
+
+var (
+	// heightHintBucket is the top-level bucket which houses the height
+	// hints of all transactions, and outpoints currently watched by a
+	// ChainNotifier. The hints themselves are stored within one of the two
+	// nested buckets below.
+	heightHintBucket = []byte("hhb")
+
+	// confHintBucket maps a txid to the height hint for its confirmation.
+	confHintBucket = []byte("chb")
+
+	// spendHintBucket maps a serialized outpoint to the height hint for
+	// its spend.
+	spendHintBucket = []byte("shb")
+
+	// ErrHeightHintNotFound is returned when no height hint has been stored
+	// for a txid or outpoint.
+	ErrHeightHintNotFound = fmt.Errorf("height hint not found")
+)
+
+// HeightHint bounds the range of blocks which must be examined in order to
+// find the confirmation of a transaction, or the spend of an outpoint. Blocks
+// in the range [StartHeight, ScannedHeight] are known to have already been
+// checked, so after a restart a rescan only needs to cover the blocks
+// connected after ScannedHeight.
+type HeightHint struct {
+	// StartHeight is the earliest height at which the transaction could
+	// have been confirmed, or the outpoint spent.
+	StartHeight uint32
+
+	// ScannedHeight is the height of the last block which has been
+	// checked for the confirmation, or spend.
+	ScannedHeight uint32
+}
+
+// PutConfirmHint stores the height hint for the confirmation of txid,
+// overwriting any existing hint.
+func (d *DB) PutConfirmHint(txid *wire.ShaHash, hint *HeightHint) error {
+	return d.putHeightHint(confHintBucket, txid[:], hint)
+}
+
+// FetchConfirmHint returns the height hint for the confirmation of txid. If
+// no hint has been stored, then ErrHeightHintNotFound is returned.
+func (d *DB) FetchConfirmHint(txid *wire.ShaHash) (*HeightHint, error) {
+	return d.fetchHeightHint(confHintBucket, txid[:])
+}
+
+// DeleteConfirmHint removes the height hint for the confirmation of txid.
+func (d *DB) DeleteConfirmHint(txid *wire.ShaHash) error {
+	return d.deleteHeightHint(confHintBucket, txid[:])
+}
+
+// PutSpendHint stores the height hint for the spend of the target outpoint,
+// overwriting any existing hint.
+func (d *DB) PutSpendHint(op *wire.OutPoint, hint *HeightHint) error {
+	key, err := outpointKey(op)
+	if err != nil {
+		return err
+	}
+
+	return d.putHeightHint(spendHintBucket, key, hint)
+}
+
+// FetchSpendHint returns the height hint for the spend of the target
+// outpoint. If no hint has been stored, then ErrHeightHintNotFound is
+// returned.
+func (d *DB) FetchSpendHint(op *wire.OutPoint) (*HeightHint, error) {
+	key, err := outpointKey(op)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.fetchHeightHint(spendHintBucket, key)
+}
+
+// DeleteSpendHint removes the height hint for the spend of the target
+// outpoint.
+func (d *DB) DeleteSpendHint(op *wire.OutPoint) error {
+	key, err := outpointKey(op)
+	if err != nil {
+		return err
+	}
+
+	return d.deleteHeightHint(spendHintBucket, key)
+}
+
+// UpdateScannedHeights marks all blocks up to, and including height as
+// checked for each of the passed txids, and outpoints. Transactions, and
+// outpoints without an existing height hint are skipped. All hints are
+// updated within a single database transaction.
+func (d *DB) UpdateScannedHeights(txids []wire.ShaHash, ops []wire.OutPoint,
+	height uint32) error {
+
+	return d.store.Update(func(tx *bolt.Tx) error {
+		hintBucket, err := tx.CreateBucketIfNotExists(heightHintBucket)
+		if err != nil {
+			return err
+		}
+		confHints, err := hintBucket.CreateBucketIfNotExists(confHintBucket)
+		if err != nil {
+			return err
+		}
+		spendHints, err := hintBucket.CreateBucketIfNotExists(spendHintBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, txid := range txids {
+			if err := updateScannedHeight(confHints, txid[:], height); err != nil {
+				return err
+			}
+		}
+		for _, op := range ops {
+			key, err := outpointKey(&op)
+			if err != nil {
+				return err
+			}
+			if err := updateScannedHeight(spendHints, key, height); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// putHeightHint stores the passed hint under key within the target nested
+// bucket of the height hint bucket.
+func (d *DB) putHeightHint(bucketKey, key []byte, hint *HeightHint) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		hintBucket, err := tx.CreateBucketIfNotExists(heightHintBucket)
+		if err != nil {
+			return err
+		}
+		bucket, err := hintBucket.CreateBucketIfNotExists(bucketKey)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(key, serializeHeightHint(hint))
+	})
+}
+
+// fetchHeightHint returns the hint stored under key within the target nested
+// bucket of the height hint bucket.
+func (d *DB) fetchHeightHint(bucketKey, key []byte) (*HeightHint, error) {
+	var hint *HeightHint
+	err := d.store.View(func(tx *bolt.Tx) error {
+		hintBucket := tx.Bucket(heightHintBucket)
+		if hintBucket == nil {
+			return ErrHeightHintNotFound
+		}
+		bucket := hintBucket.Bucket(bucketKey)
+		if bucket == nil {
+			return ErrHeightHintNotFound
+		}
+
+		hintBytes := bucket.Get(key)
+		if hintBytes == nil {
+			return ErrHeightHintNotFound
+		}
+
+		hint = deserializeHeightHint(hintBytes)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hint, nil
+}
+
+// deleteHeightHint removes the hint stored under key within the target nested
+// bucket of the height hint bucket.
+func (d *DB) deleteHeightHint(bucketKey, key []byte) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		hintBucket := tx.Bucket(heightHintBucket)
+		if hintBucket == nil {
+			return nil
+		}
+		bucket := hintBucket.Bucket(bucketKey)
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.Delete(key)
+	})
+}
+
+// updateScannedHeight sets the scanned height of the hint stored under key
+// within the passed bucket, if one exists.
+func updateScannedHeight(bucket *bolt.Bucket, key []byte, height uint32) error {
+	hintBytes := bucket.Get(key)
+	if hintBytes == nil {
+		return nil
+	}
+
+	hint := deserializeHeightHint(hintBytes)
+	if hint.ScannedHeight >= height {
+		return nil
+	}
+	hint.ScannedHeight = height
+
+	return bucket.Put(key, serializeHeightHint(hint))
+}
+
+// outpointKey returns the serialized outpoint used as the key of a spend
+// hint.
+func outpointKey(op *wire.OutPoint) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeOutpoint(&b, op); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// serializeHeightHint encodes a height hint as start height || scanned
+// height, with each height taking up 4 bytes.
+func serializeHeightHint(hint *HeightHint) []byte {
+	var hintBytes [8]byte
+	byteOrder.PutUint32(hintBytes[:4], hint.StartHeight)
+	byteOrder.PutUint32(hintBytes[4:], hint.ScannedHeight)
+	return hintBytes[:]
+}
+
+// deserializeHeightHint decodes a height hint serialized by
+// serializeHeightHint.
+func deserializeHeightHint(hintBytes []byte) *HeightHint {
+	return &HeightHint{
+		StartHeight:   byteOrder.Uint32(hintBytes[:4]),
+		ScannedHeight: byteOrder.Uint32(hintBytes[4:8]),
+	}
+}
@@ -0,0 +1,101 @@
+package channeldb
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/roasbeef/btcd/wire"
+)
+
+func TestHeightHintPutFetchDelete(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "channeldb")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := Open(tempDirName, netParams)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	txid := wire.ShaHash{0x01}
+	op := wire.OutPoint{Hash: wire.ShaHash{0x02}, Index: 3}
+
+	// Before any hints have been stored, both lookups should fail.
+	if _, err := cdb.FetchConfirmHint(&txid); err != ErrHeightHintNotFound {
+		t.Fatalf("expected ErrHeightHintNotFound, got %v", err)
+	}
+	if _, err := cdb.FetchSpendHint(&op); err != ErrHeightHintNotFound {
+		t.Fatalf("expected ErrHeightHintNotFound, got %v", err)
+	}
+
+	confHint := &HeightHint{StartHeight: 100, ScannedHeight: 99}
+	if err := cdb.PutConfirmHint(&txid, confHint); err != nil {
+		t.Fatalf("unable to store confirm hint: %v", err)
+	}
+	spendHint := &HeightHint{StartHeight: 50, ScannedHeight: 99}
+	if err := cdb.PutSpendHint(&op, spendHint); err != nil {
+		t.Fatalf("unable to store spend hint: %v", err)
+	}
+
+	fetchedHint, err := cdb.FetchConfirmHint(&txid)
+	if err != nil {
+		t.Fatalf("unable to fetch confirm hint: %v", err)
+	}
+	if !reflect.DeepEqual(fetchedHint, confHint) {
+		t.Fatalf("confirm hint mismatch: expected %v, got %v",
+			confHint, fetchedHint)
+	}
+	fetchedHint, err = cdb.FetchSpendHint(&op)
+	if err != nil {
+		t.Fatalf("unable to fetch spend hint: %v", err)
+	}
+	if !reflect.DeepEqual(fetchedHint, spendHint) {
+		t.Fatalf("spend hint mismatch: expected %v, got %v",
+			spendHint, fetchedHint)
+	}
+
+	// Advancing the scanned height should only touch the hints which
+	// already exist, and leave the start heights untouched.
+	unknownTxid := wire.ShaHash{0x03}
+	err = cdb.UpdateScannedHeights([]wire.ShaHash{txid, unknownTxid},
+		[]wire.OutPoint{op}, 120)
+	if err != nil {
+		t.Fatalf("unable to update scanned heights: %v", err)
+	}
+	if _, err := cdb.FetchConfirmHint(&unknownTxid); err != ErrHeightHintNotFound {
+		t.Fatalf("hint created for unknown txid")
+	}
+	fetchedHint, err = cdb.FetchConfirmHint(&txid)
+	if err != nil {
+		t.Fatalf("unable to fetch confirm hint: %v", err)
+	}
+	if fetchedHint.StartHeight != 100 || fetchedHint.ScannedHeight != 120 {
+		t.Fatalf("confirm hint not updated: %v", fetchedHint)
+	}
+	fetchedHint, err = cdb.FetchSpendHint(&op)
+	if err != nil {
+		t.Fatalf("unable to fetch spend hint: %v", err)
+	}
+	if fetchedHint.StartHeight != 50 || fetchedHint.ScannedHeight != 120 {
+		t.Fatalf("spend hint not updated: %v", fetchedHint)
+	}
+
+	// Once deleted, the hints should no longer be found.
+	if err := cdb.DeleteConfirmHint(&txid); err != nil {
+		t.Fatalf("unable to delete confirm hint: %v", err)
+	}
+	if err := cdb.DeleteSpendHint(&op); err != nil {
+		t.Fatalf("unable to delete spend hint: %v", err)
+	}
+	if _, err := cdb.FetchConfirmHint(&txid); err != ErrHeightHintNotFound {
+		t.Fatalf("expected ErrHeightHintNotFound, got %v", err)
+	}
+	if _, err := cdb.FetchSpendHint(&op); err != ErrHeightHintNotFound {
+		t.Fatalf("expected ErrHeightHintNotFound, got %v", err)
+	}
+}
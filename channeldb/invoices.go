@@ -0,0 +1,329 @@
+package channeldb
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+var (
+	// invoiceBucket stores the hold invoices we've created, keyed by
+	// their payment hash.
+	invoiceBucket = []byte("ivb")
+)
+
+// ContractState describes the state of an invoice.
+type ContractState byte
+
+const (
+	// ContractOpen indicates that the invoice is awaiting payment.
+	ContractOpen ContractState = 1
+
+	// ContractAccepted indicates that HTLCs paying the full value of the
+	// invoice are held, awaiting the decision to settle, or cancel them.
+	ContractAccepted ContractState = 2
+
+	// ContractSettled indicates that the invoice has been paid, and its
+	// preimage released.
+	ContractSettled ContractState = 3
+
+	// ContractCanceled indicates that the invoice was cancelled, so it
+	// can no longer be paid.
+	ContractCanceled ContractState = 4
+)
+
+// String returns a human readable representation of the state.
+func (s ContractState) String() string {
+	switch s {
+	case ContractOpen:
+		return "open"
+	case ContractAccepted:
+		return "accepted"
+	case ContractSettled:
+		return "settled"
+	case ContractCanceled:
+		return "canceled"
+	default:
+		return "unknown"
+	}
+}
+
+// Invoice records an invoice created by the daemon.
+type Invoice struct {
+	// PaymentHash is the payment hash HTLCs paying the invoice lock to.
+	PaymentHash [32]byte
+
+	// PaymentPreimage is the preimage of the payment hash. It's only set
+	// once the invoice has been settled.
+	PaymentPreimage [32]byte
+
+	// Value is the amount requested by the invoice.
+	Value lnwire.MilliSatoshi
+
+	// AmtPaid is the total amount of the HTLCs paying the invoice. It's
+	// set once the invoice has been accepted.
+	AmtPaid lnwire.MilliSatoshi
+
+	// Expiry is the duration after the creation date once which the
+	// invoice can no longer be paid. An expiry of zero never expires.
+	Expiry time.Duration
+
+	// State is the current state of the invoice.
+	State ContractState
+
+	// CreationDate is the time at which the invoice was created.
+	CreationDate time.Time
+
+	// ResolutionDate is the time at which the invoice was either settled
+	// or cancelled. It's zero for unresolved invoices.
+	ResolutionDate time.Time
+}
+
+// AddInvoice records a new invoice. ErrDuplicateInvoice is returned if an
+// invoice of the same payment hash already exists.
+func (d *DB) AddInvoice(invoice *Invoice) error {
+	var b bytes.Buffer
+	if err := serializeInvoice(&b, invoice); err != nil {
+		return err
+	}
+
+	return d.store.Update(func(tx *bolt.Tx) error {
+		invoices, err := tx.CreateBucketIfNotExists(invoiceBucket)
+		if err != nil {
+			return err
+		}
+
+		if invoices.Get(invoice.PaymentHash[:]) != nil {
+			return ErrDuplicateInvoice
+		}
+
+		return invoices.Put(invoice.PaymentHash[:], b.Bytes())
+	})
+}
+
+// LookupInvoice returns the invoice of the passed payment hash.
+// ErrInvoiceNotFound is returned if there's no such invoice.
+func (d *DB) LookupInvoice(payHash [32]byte) (*Invoice, error) {
+	var invoice *Invoice
+	err := d.store.View(func(tx *bolt.Tx) error {
+		invoices := tx.Bucket(invoiceBucket)
+		if invoices == nil {
+			return ErrInvoiceNotFound
+		}
+
+		invoiceBytes := invoices.Get(payHash[:])
+		if invoiceBytes == nil {
+			return ErrInvoiceNotFound
+		}
+
+		var err error
+		invoice, err = deserializeInvoice(bytes.NewReader(invoiceBytes))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// AcceptInvoice marks the unresolved invoice of the passed hash as accepted,
+// recording the total amount of the HTLCs paying it.
+func (d *DB) AcceptInvoice(payHash [32]byte, amtPaid lnwire.MilliSatoshi) error {
+	return d.updateInvoice(payHash, func(i *Invoice) error {
+		i.State = ContractAccepted
+		i.AmtPaid = amtPaid
+		return nil
+	})
+}
+
+// SettleInvoice marks the accepted invoice of the passed hash as settled,
+// recording its preimage. ErrInvoiceNotAccepted is returned if the invoice
+// hasn't been accepted.
+func (d *DB) SettleInvoice(payHash, preimage [32]byte) error {
+	return d.updateInvoice(payHash, func(i *Invoice) error {
+		if i.State != ContractAccepted {
+			return ErrInvoiceNotAccepted
+		}
+
+		i.State = ContractSettled
+		i.PaymentPreimage = preimage
+		i.ResolutionDate = time.Now()
+		return nil
+	})
+}
+
+// CancelInvoice marks the unresolved invoice of the passed hash as
+// cancelled.
+func (d *DB) CancelInvoice(payHash [32]byte) error {
+	return d.updateInvoice(payHash, func(i *Invoice) error {
+		i.State = ContractCanceled
+		i.ResolutionDate = time.Now()
+		return nil
+	})
+}
+
+// updateInvoice applies the passed update to the unresolved invoice of the
+// passed hash. ErrInvoiceNotFound is returned if there's no such invoice, and
+// ErrInvoiceResolved if it has already been settled or cancelled.
+func (d *DB) updateInvoice(payHash [32]byte, update func(*Invoice) error) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		invoices := tx.Bucket(invoiceBucket)
+		if invoices == nil {
+			return ErrInvoiceNotFound
+		}
+
+		invoiceBytes := invoices.Get(payHash[:])
+		if invoiceBytes == nil {
+			return ErrInvoiceNotFound
+		}
+
+		invoice, err := deserializeInvoice(bytes.NewReader(invoiceBytes))
+		if err != nil {
+			return err
+		}
+
+		switch invoice.State {
+		case ContractSettled, ContractCanceled:
+			return ErrInvoiceResolved
+		}
+
+		if err := update(invoice); err != nil {
+			return err
+		}
+
+		var b bytes.Buffer
+		if err := serializeInvoice(&b, invoice); err != nil {
+			return err
+		}
+
+		return invoices.Put(payHash[:], b.Bytes())
+	})
+}
+
+// invoicesByDate sorts invoices by their creation date.
+type invoicesByDate []*Invoice
+
+func (i invoicesByDate) Len() int      { return len(i) }
+func (i invoicesByDate) Swap(a, b int) { i[a], i[b] = i[b], i[a] }
+func (i invoicesByDate) Less(a, b int) bool {
+	return i[a].CreationDate.Before(i[b].CreationDate)
+}
+
+// FetchAllInvoices returns all the recorded invoices, ordered by their
+// creation date.
+func (d *DB) FetchAllInvoices() ([]*Invoice, error) {
+	var invoices []*Invoice
+	err := d.store.View(func(tx *bolt.Tx) error {
+		invoicesBucket := tx.Bucket(invoiceBucket)
+		if invoicesBucket == nil {
+			return nil
+		}
+
+		return invoicesBucket.ForEach(func(k, v []byte) error {
+			invoice, err := deserializeInvoice(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+
+			invoices = append(invoices, invoice)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(invoicesByDate(invoices))
+	return invoices, nil
+}
+
+func serializeInvoice(w io.Writer, i *Invoice) error {
+	var scratch [8]byte
+
+	if _, err := w.Write(i.PaymentHash[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(i.PaymentPreimage[:]); err != nil {
+		return err
+	}
+
+	byteOrder.PutUint64(scratch[:], uint64(i.Value))
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+	byteOrder.PutUint64(scratch[:], uint64(i.AmtPaid))
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+	byteOrder.PutUint64(scratch[:], uint64(i.Expiry))
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+
+	if _, err := w.Write([]byte{byte(i.State)}); err != nil {
+		return err
+	}
+
+	byteOrder.PutUint64(scratch[:], uint64(i.CreationDate.UnixNano()))
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+
+	// As with payments, a zero resolution date is stored as zero.
+	var resolutionDate uint64
+	if !i.ResolutionDate.IsZero() {
+		resolutionDate = uint64(i.ResolutionDate.UnixNano())
+	}
+	byteOrder.PutUint64(scratch[:], resolutionDate)
+	_, err := w.Write(scratch[:])
+	return err
+}
+
+func deserializeInvoice(r io.Reader) (*Invoice, error) {
+	var scratch [8]byte
+	i := &Invoice{}
+
+	if _, err := io.ReadFull(r, i.PaymentHash[:]); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, i.PaymentPreimage[:]); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	i.Value = lnwire.MilliSatoshi(byteOrder.Uint64(scratch[:]))
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	i.AmtPaid = lnwire.MilliSatoshi(byteOrder.Uint64(scratch[:]))
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	i.Expiry = time.Duration(byteOrder.Uint64(scratch[:]))
+
+	if _, err := io.ReadFull(r, scratch[:1]); err != nil {
+		return nil, err
+	}
+	i.State = ContractState(scratch[0])
+
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	i.CreationDate = time.Unix(0, int64(byteOrder.Uint64(scratch[:])))
+
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	if resolutionDate := byteOrder.Uint64(scratch[:]); resolutionDate != 0 {
+		i.ResolutionDate = time.Unix(0, int64(resolutionDate))
+	}
+
+	return i, nil
+}
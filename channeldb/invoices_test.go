@@ -0,0 +1,109 @@
+package channeldb
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInvoiceLifecycle(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "channeldb")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := Open(tempDirName, netParams)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	invoice := &Invoice{
+		PaymentHash:  [32]byte{0x01},
+		Value:        1000,
+		Expiry:       time.Hour,
+		State:        ContractOpen,
+		CreationDate: time.Unix(1000000, 0),
+	}
+	if err := cdb.AddInvoice(invoice); err != nil {
+		t.Fatalf("unable to add invoice: %v", err)
+	}
+	if err := cdb.AddInvoice(invoice); err != ErrDuplicateInvoice {
+		t.Fatalf("expected ErrDuplicateInvoice, got %v", err)
+	}
+
+	dbInvoice, err := cdb.LookupInvoice(invoice.PaymentHash)
+	if err != nil {
+		t.Fatalf("unable to lookup invoice: %v", err)
+	}
+	if !reflect.DeepEqual(dbInvoice, invoice) {
+		t.Fatalf("invoices don't match: expected %v, got %v",
+			invoice, dbInvoice)
+	}
+
+	// An open invoice can't be settled, as no HTLCs are held.
+	preimage := [32]byte{0x02}
+	err = cdb.SettleInvoice(invoice.PaymentHash, preimage)
+	if err != ErrInvoiceNotAccepted {
+		t.Fatalf("expected ErrInvoiceNotAccepted, got %v", err)
+	}
+
+	if err := cdb.AcceptInvoice(invoice.PaymentHash, 1200); err != nil {
+		t.Fatalf("unable to accept invoice: %v", err)
+	}
+	if err := cdb.SettleInvoice(invoice.PaymentHash, preimage); err != nil {
+		t.Fatalf("unable to settle invoice: %v", err)
+	}
+
+	dbInvoice, err = cdb.LookupInvoice(invoice.PaymentHash)
+	if err != nil {
+		t.Fatalf("unable to lookup invoice: %v", err)
+	}
+	switch {
+	case dbInvoice.State != ContractSettled:
+		t.Fatalf("expected state settled, got %v", dbInvoice.State)
+	case dbInvoice.AmtPaid != 1200:
+		t.Fatalf("expected amount paid 1200, got %v", dbInvoice.AmtPaid)
+	case dbInvoice.PaymentPreimage != preimage:
+		t.Fatalf("wrong preimage recorded")
+	case dbInvoice.ResolutionDate.IsZero():
+		t.Fatalf("resolution date not recorded")
+	}
+
+	// Once settled, the invoice can no longer be cancelled.
+	if err := cdb.CancelInvoice(invoice.PaymentHash); err != ErrInvoiceResolved {
+		t.Fatalf("expected ErrInvoiceResolved, got %v", err)
+	}
+	if err := cdb.CancelInvoice([32]byte{0x03}); err != ErrInvoiceNotFound {
+		t.Fatalf("expected ErrInvoiceNotFound, got %v", err)
+	}
+
+	canceled := &Invoice{
+		PaymentHash:  [32]byte{0x03},
+		Value:        2000,
+		State:        ContractOpen,
+		CreationDate: time.Unix(1000001, 0),
+	}
+	if err := cdb.AddInvoice(canceled); err != nil {
+		t.Fatalf("unable to add invoice: %v", err)
+	}
+	if err := cdb.CancelInvoice(canceled.PaymentHash); err != nil {
+		t.Fatalf("unable to cancel invoice: %v", err)
+	}
+
+	invoices, err := cdb.FetchAllInvoices()
+	if err != nil {
+		t.Fatalf("unable to fetch invoices: %v", err)
+	}
+	if len(invoices) != 2 {
+		t.Fatalf("expected 2 invoices, got %v", len(invoices))
+	}
+	if invoices[0].PaymentHash != invoice.PaymentHash ||
+		invoices[1].State != ContractCanceled {
+
+		t.Fatalf("invoices not ordered by creation date")
+	}
+}
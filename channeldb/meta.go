@@ -0,0 +1,109 @@
+package channeldb
+
+import "github.com/boltdb/bolt"
+
+var (
+	// metaBucket stores the meta-data of the database itself, such as the
+	// version of its on-disk format.
+	metaBucket = []byte("metadata")
+
+	// dbVersionKey is the key within the metaBucket storing the version
+	// of the database's on-disk format. Databases created before the
+	// version was tracked lack the key, and are of version zero.
+	dbVersionKey = []byte("dbp")
+)
+
+// migration is a function which upgrades the on-disk format of the database
+// from the previous version to the next, within a single transaction.
+type migration func(tx *bolt.Tx) error
+
+// version pairs a version of the database's on-disk format with the
+// migration which upgrades a database of the previous version to it.
+type version struct {
+	number    uint32
+	migration migration
+}
+
+// dbVersions lists each version of the database's on-disk format, in
+// ascending order. New versions, along with their migration, must be
+// appended to the end of the list.
+var dbVersions = []version{
+	{
+		// The base version, prior to the tracking of versions.
+		number:    0,
+		migration: nil,
+	},
+	{
+		// The version in which channel balances, forwarding fees,
+		// payments, and forwarding events are stored in
+		// milli-satoshis rather than satoshis.
+		number:    1,
+		migration: migrateToMilliSatoshis,
+	},
+}
+
+// getLatestDBVersion returns the version of the last of the passed versions.
+func getLatestDBVersion(versions []version) uint32 {
+	return versions[len(versions)-1].number
+}
+
+// syncVersions brings the database up to date with the latest of the passed
+// versions, applying the migration of each newer version in order. All
+// migrations are applied within a single transaction, so the database is
+// never left partially migrated.
+func (d *DB) syncVersions(versions []version) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		current, err := fetchDBVersion(tx)
+		if err != nil {
+			return err
+		}
+
+		latest := getLatestDBVersion(versions)
+		if current == latest {
+			return nil
+		}
+		if current > latest {
+			return ErrDBReversion
+		}
+
+		for _, v := range versions {
+			if v.number <= current || v.migration == nil {
+				continue
+			}
+
+			log.Infof("Migrating database to version %v", v.number)
+			if err := v.migration(tx); err != nil {
+				return err
+			}
+		}
+
+		return putDBVersion(tx, latest)
+	})
+}
+
+// fetchDBVersion returns the version of the database's on-disk format.
+func fetchDBVersion(tx *bolt.Tx) (uint32, error) {
+	meta := tx.Bucket(metaBucket)
+	if meta == nil {
+		return 0, nil
+	}
+
+	versionBytes := meta.Get(dbVersionKey)
+	if versionBytes == nil {
+		return 0, nil
+	}
+
+	return byteOrder.Uint32(versionBytes), nil
+}
+
+// putDBVersion records the version of the database's on-disk format.
+func putDBVersion(tx *bolt.Tx, v uint32) error {
+	meta, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return err
+	}
+
+	var versionBytes [4]byte
+	byteOrder.PutUint32(versionBytes[:], v)
+	return meta.Put(dbVersionKey, versionBytes[:])
+}
@@ -0,0 +1,146 @@
+package channeldb
+
+import (
+	"bytes"
+
+	"github.com/boltdb/bolt"
+)
+
+// migrateToMilliSatoshis converts the amounts which were previously stored in
+// satoshis to milli-satoshis: the balances, and net forwarding fees of each
+// open channel, the amounts of each outgoing payment and its attempts, and
+// the amounts of each forwarding event.
+func migrateToMilliSatoshis(tx *bolt.Tx) error {
+	if err := migrateChannelAmounts(tx); err != nil {
+		return err
+	}
+	if err := migratePaymentAmounts(tx); err != nil {
+		return err
+	}
+
+	return migrateForwardingEventAmounts(tx)
+}
+
+// migrateChannelAmounts converts the balances, and net forwarding fees of
+// each open channel to milli-satoshis.
+func migrateChannelAmounts(tx *bolt.Tx) error {
+	openChanBucket := tx.Bucket(openChannelBucket)
+	if openChanBucket == nil {
+		return nil
+	}
+
+	// The bucket may not be modified while it's being iterated over, so
+	// the converted amounts are collected, then written once the
+	// iteration is complete.
+	amounts := make(map[string][]byte)
+	err := openChanBucket.ForEach(func(k, v []byte) error {
+		// Nested buckets, such as those of each node, have no value.
+		if v == nil || len(k) < 3 || len(v) != 8 {
+			return nil
+		}
+
+		prefix := k[:3]
+		if !bytes.Equal(prefix, selfBalancePrefix) &&
+			!bytes.Equal(prefix, theirBalancePrefix) &&
+			!bytes.Equal(prefix, netFeesPrefix) {
+
+			return nil
+		}
+
+		var amt [8]byte
+		byteOrder.PutUint64(amt[:], byteOrder.Uint64(v)*1000)
+		amounts[string(k)] = amt[:]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for k, v := range amounts {
+		if err := openChanBucket.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migratePaymentAmounts converts the value, and fee of each outgoing payment,
+// and of each of its attempts, to milli-satoshis.
+func migratePaymentAmounts(tx *bolt.Tx) error {
+	payments := tx.Bucket(paymentBucket)
+	if payments == nil {
+		return nil
+	}
+
+	migrated := make(map[string][]byte)
+	err := payments.ForEach(func(k, v []byte) error {
+		p, err := deserializeOutgoingPayment(bytes.NewReader(v))
+		if err != nil {
+			return err
+		}
+
+		p.Value *= 1000
+		p.Fee *= 1000
+		for _, attempt := range p.Attempts {
+			attempt.Amount *= 1000
+			attempt.Fee *= 1000
+		}
+
+		var b bytes.Buffer
+		if err := serializeOutgoingPayment(&b, p); err != nil {
+			return err
+		}
+		migrated[string(k)] = b.Bytes()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for k, v := range migrated {
+		if err := payments.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateForwardingEventAmounts converts the incoming, and outgoing amounts
+// of each forwarding event to milli-satoshis.
+func migrateForwardingEventAmounts(tx *bolt.Tx) error {
+	logBucket := tx.Bucket(forwardingLogBucket)
+	if logBucket == nil {
+		return nil
+	}
+
+	migrated := make(map[string][]byte)
+	err := logBucket.ForEach(func(k, v []byte) error {
+		event, err := deserializeForwardingEvent(k, bytes.NewReader(v))
+		if err != nil {
+			return err
+		}
+
+		event.AmtIn *= 1000
+		event.AmtOut *= 1000
+
+		var b bytes.Buffer
+		if err := serializeForwardingEvent(&b, event); err != nil {
+			return err
+		}
+		migrated[string(k)] = b.Bytes()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for k, v := range migrated {
+		if err := logBucket.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package channeldb
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/roasbeef/btcd/wire"
+)
+
+func TestMigrateToMilliSatoshis(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "channeldb")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := Open(tempDirName, netParams)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+
+	// Populate the database with amounts in satoshis, as written by the
+	// base version, then mark the database as being of that version.
+	payment := &OutgoingPayment{
+		PaymentHash:  [32]byte{0x01},
+		Value:        1000,
+		Path:         [][32]byte{{0x02}},
+		Status:       StatusInFlight,
+		CreationDate: time.Unix(1000000, 0),
+	}
+	if err := cdb.InitPayment(payment); err != nil {
+		t.Fatalf("unable to init payment: %v", err)
+	}
+	attempt := &PaymentAttempt{
+		Path:        [][32]byte{{0x02}},
+		Amount:      1000,
+		Fee:         2,
+		AttemptTime: time.Unix(1000001, 0),
+	}
+	if err := cdb.AddPaymentAttempt(payment.PaymentHash, attempt); err != nil {
+		t.Fatalf("unable to add attempt: %v", err)
+	}
+
+	event := &ForwardingEvent{
+		Timestamp:    time.Unix(1000000, 0),
+		IncomingChan: wire.OutPoint{Hash: wire.ShaHash{0x01}},
+		OutgoingChan: wire.OutPoint{Hash: wire.ShaHash{0x02}},
+		AmtIn:        1001,
+		AmtOut:       1000,
+	}
+	if err := cdb.AddForwardingEvents([]*ForwardingEvent{event}); err != nil {
+		t.Fatalf("unable to add event: %v", err)
+	}
+
+	balanceKey := append(append([]byte{}, selfBalancePrefix...), 0x01)
+	capacityKey := append(append([]byte{}, chanCapacityPrefix...), 0x01)
+	err = cdb.store.Update(func(tx *bolt.Tx) error {
+		openChanBucket := tx.Bucket(openChannelBucket)
+
+		var amt [8]byte
+		byteOrder.PutUint64(amt[:], 5000)
+		if err := openChanBucket.Put(balanceKey, amt[:]); err != nil {
+			return err
+		}
+		if err := openChanBucket.Put(capacityKey, amt[:]); err != nil {
+			return err
+		}
+
+		return putDBVersion(tx, 0)
+	})
+	if err != nil {
+		t.Fatalf("unable to write base version amounts: %v", err)
+	}
+	cdb.Close()
+
+	// Re-opening the database should migrate each amount to
+	// milli-satoshis, leaving the amounts which remain in satoshis, such
+	// as the channel's capacity, untouched.
+	cdb, err = Open(tempDirName, netParams)
+	if err != nil {
+		t.Fatalf("unable to open channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	payments, err := cdb.FetchAllPayments()
+	if err != nil {
+		t.Fatalf("unable to fetch payments: %v", err)
+	}
+	if len(payments) != 1 {
+		t.Fatalf("expected 1 payment, got %v", len(payments))
+	}
+	p := payments[0]
+	if p.Value != 1000000 {
+		t.Fatalf("payment value not migrated: %v", p.Value)
+	}
+	if len(p.Attempts) != 1 || p.Attempts[0].Amount != 1000000 ||
+		p.Attempts[0].Fee != 2000 {
+
+		t.Fatalf("payment attempt not migrated: %v", p.Attempts)
+	}
+
+	var events []*ForwardingEvent
+	err = cdb.ForEachForwardingEvent(event.Timestamp,
+		event.Timestamp.Add(time.Second), func(e *ForwardingEvent) error {
+			events = append(events, e)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("unable to fetch events: %v", err)
+	}
+	if len(events) != 1 || events[0].AmtIn != 1001000 ||
+		events[0].AmtOut != 1000000 {
+
+		t.Fatalf("forwarding event not migrated: %v", events)
+	}
+
+	err = cdb.store.View(func(tx *bolt.Tx) error {
+		openChanBucket := tx.Bucket(openChannelBucket)
+		balance := byteOrder.Uint64(openChanBucket.Get(balanceKey))
+		if balance != 5000000 {
+			t.Fatalf("channel balance not migrated: %v", balance)
+		}
+		capacity := byteOrder.Uint64(openChanBucket.Get(capacityKey))
+		if capacity != 5000 {
+			t.Fatalf("channel capacity migrated: %v", capacity)
+		}
+
+		version, err := fetchDBVersion(tx)
+		if err != nil {
+			return err
+		}
+		if version != getLatestDBVersion(dbVersions) {
+			t.Fatalf("database version not updated: %v", version)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to read channel amounts: %v", err)
+	}
+}
+
+func TestOpenNewerVersion(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "channeldb")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := Open(tempDirName, netParams)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	err = cdb.store.Update(func(tx *bolt.Tx) error {
+		return putDBVersion(tx, getLatestDBVersion(dbVersions)+1)
+	})
+	if err != nil {
+		t.Fatalf("unable to write version: %v", err)
+	}
+	cdb.Close()
+
+	// A database written by newer software mustn't be opened, as its
+	// format is unknown.
+	if _, err := Open(tempDirName, netParams); err != ErrDBReversion {
+		t.Fatalf("expected ErrDBReversion, got %v", err)
+	}
+}
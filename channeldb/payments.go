@@ -0,0 +1,572 @@
+package channeldb
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/wire"
+)
+
+var (
+	// paymentBucket stores all the payments we've attempted to send,
+	// keyed by their payment hash.
+	paymentBucket = []byte("pmb")
+)
+
+// maxFailureReasonLength is the maximum length of the failure reason stored
+// alongside a failed payment.
+const maxFailureReasonLength = 1024
+
+// PaymentStatus describes the state of an outgoing payment.
+type PaymentStatus byte
+
+const (
+	// StatusInFlight indicates that the HTLC of the payment has been
+	// sent, but not yet settled or timed out.
+	StatusInFlight PaymentStatus = 1
+
+	// StatusSucceeded indicates that the HTLC of the payment has been
+	// settled, and the preimage is known.
+	StatusSucceeded PaymentStatus = 2
+
+	// StatusFailed indicates that the payment couldn't be completed.
+	StatusFailed PaymentStatus = 3
+
+	// StatusInterrupted indicates that the payment was still in flight
+	// when the node stopped. As the HTLCs of a channel don't survive a
+	// restart, the outcome of the payment can no longer be learnt, and
+	// its HTLCs may yet be claimed by the receiver.
+	StatusInterrupted PaymentStatus = 4
+)
+
+// String returns a human readable representation of the status.
+func (s PaymentStatus) String() string {
+	switch s {
+	case StatusInFlight:
+		return "in-flight"
+	case StatusSucceeded:
+		return "succeeded"
+	case StatusFailed:
+		return "failed"
+	case StatusInterrupted:
+		return "interrupted"
+	default:
+		return "unknown"
+	}
+}
+
+// OutgoingPayment records a payment we've attempted to send.
+type OutgoingPayment struct {
+	// PaymentHash is the payment hash of the payment's HTLC.
+	PaymentHash [32]byte
+
+	// PaymentPreimage is the preimage of the payment hash. It's only set
+	// once the payment has succeeded.
+	PaymentPreimage [32]byte
+
+	// Value is the amount delivered to the destination, excluding fees.
+	Value lnwire.MilliSatoshi
+
+	// Fee is the total fee paid to the intermediate hops of the routes
+	// of all the payment's successful attempts.
+	Fee lnwire.MilliSatoshi
+
+	// Path is the lightning ID of each hop within the route taken by the
+	// payment's latest successful attempt, ending with the destination.
+	Path [][32]byte
+
+	// Status is the current status of the payment.
+	Status PaymentStatus
+
+	// FailureReason describes why the payment failed. It's only set for
+	// failed payments.
+	FailureReason string
+
+	// CreationDate is the time at which the payment was sent.
+	CreationDate time.Time
+
+	// ResolutionDate is the time at which the payment either succeeded,
+	// failed, or was interrupted. It's zero for in-flight payments.
+	ResolutionDate time.Time
+
+	// Attempts records each route the payment was attempted over, in
+	// order.
+	Attempts []*PaymentAttempt
+}
+
+// PaymentAttempt records a single attempt to deliver a payment over a route.
+// A payment split across several routes makes an attempt for each of its
+// shards.
+type PaymentAttempt struct {
+	// Path is the lightning ID of each hop within the route.
+	Path [][32]byte
+
+	// Amount is the amount the attempt delivers to the destination,
+	// excluding fees.
+	Amount lnwire.MilliSatoshi
+
+	// Fee is the total fee of the route.
+	Fee lnwire.MilliSatoshi
+
+	// AttemptTime is the time at which the attempt was dispatched.
+	AttemptTime time.Time
+
+	// FailureReason describes why the attempt failed. It's empty for a
+	// successful attempt.
+	FailureReason string
+}
+
+// InitPayment records a new in-flight payment. In order to prevent paying
+// the same hash twice, ErrPaymentInFlight is returned if an earlier payment
+// of the hash is still in flight, ErrAlreadyPaid is returned if an earlier
+// payment succeeded, and ErrPaymentInterrupted is returned if an earlier
+// payment was interrupted. A previously failed payment is overwritten.
+func (d *DB) InitPayment(payment *OutgoingPayment) error {
+	var b bytes.Buffer
+	if err := serializeOutgoingPayment(&b, payment); err != nil {
+		return err
+	}
+
+	return d.store.Update(func(tx *bolt.Tx) error {
+		payments, err := tx.CreateBucketIfNotExists(paymentBucket)
+		if err != nil {
+			return err
+		}
+
+		if paymentBytes := payments.Get(payment.PaymentHash[:]); paymentBytes != nil {
+			prior, err := deserializeOutgoingPayment(bytes.NewReader(paymentBytes))
+			if err != nil {
+				return err
+			}
+
+			switch prior.Status {
+			case StatusInFlight:
+				return ErrPaymentInFlight
+			case StatusSucceeded:
+				return ErrAlreadyPaid
+			case StatusInterrupted:
+				return ErrPaymentInterrupted
+			}
+		}
+
+		return payments.Put(payment.PaymentHash[:], b.Bytes())
+	})
+}
+
+// AddPaymentAttempt records an attempt to deliver the in-flight payment of
+// the passed hash. If the attempt succeeded, then its fee is added to that of
+// the payment, and the route of the payment is set to that of the attempt.
+func (d *DB) AddPaymentAttempt(payHash [32]byte, attempt *PaymentAttempt) error {
+	if len(attempt.FailureReason) > maxFailureReasonLength {
+		attempt.FailureReason = attempt.FailureReason[:maxFailureReasonLength]
+	}
+
+	return d.updatePayment(payHash, func(p *OutgoingPayment) {
+		if attempt.FailureReason == "" {
+			p.Path = attempt.Path
+			p.Fee += attempt.Fee
+		}
+		p.Attempts = append(p.Attempts, attempt)
+	})
+}
+
+// SettlePayment marks the in-flight payment of the passed hash as succeeded,
+// recording its preimage.
+func (d *DB) SettlePayment(payHash, preimage [32]byte) error {
+	return d.updatePayment(payHash, func(p *OutgoingPayment) {
+		p.Status = StatusSucceeded
+		p.PaymentPreimage = preimage
+		p.ResolutionDate = time.Now()
+	})
+}
+
+// FailPayment marks the in-flight payment of the passed hash as failed,
+// recording the reason for the failure.
+func (d *DB) FailPayment(payHash [32]byte, reason string) error {
+	if len(reason) > maxFailureReasonLength {
+		reason = reason[:maxFailureReasonLength]
+	}
+
+	return d.updatePayment(payHash, func(p *OutgoingPayment) {
+		p.Status = StatusFailed
+		p.FailureReason = reason
+		p.ResolutionDate = time.Now()
+	})
+}
+
+// updatePayment applies the passed update to the in-flight payment of the
+// passed hash. ErrPaymentNotInFlight is returned if there's no such payment.
+func (d *DB) updatePayment(payHash [32]byte, update func(*OutgoingPayment)) error {
+	return d.store.Update(func(tx *bolt.Tx) error {
+		payments := tx.Bucket(paymentBucket)
+		if payments == nil {
+			return ErrPaymentNotInFlight
+		}
+
+		paymentBytes := payments.Get(payHash[:])
+		if paymentBytes == nil {
+			return ErrPaymentNotInFlight
+		}
+
+		payment, err := deserializeOutgoingPayment(bytes.NewReader(paymentBytes))
+		if err != nil {
+			return err
+		}
+		if payment.Status != StatusInFlight {
+			return ErrPaymentNotInFlight
+		}
+
+		update(payment)
+
+		var b bytes.Buffer
+		if err := serializeOutgoingPayment(&b, payment); err != nil {
+			return err
+		}
+
+		return payments.Put(payHash[:], b.Bytes())
+	})
+}
+
+// InterruptPayments marks all in-flight payments as interrupted, returning
+// the number of payments marked. It's called once at startup, as payments
+// left in flight by an earlier run can no longer be resolved.
+func (d *DB) InterruptPayments() (int, error) {
+	var numInterrupted int
+	err := d.store.Update(func(tx *bolt.Tx) error {
+		payments := tx.Bucket(paymentBucket)
+		if payments == nil {
+			return nil
+		}
+
+		// Keys can't be modified while iterating over the bucket, so
+		// we first gather the payments to update.
+		interrupted := make(map[string][]byte)
+		err := payments.ForEach(func(k, v []byte) error {
+			payment, err := deserializeOutgoingPayment(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+			if payment.Status != StatusInFlight {
+				return nil
+			}
+
+			payment.Status = StatusInterrupted
+			payment.ResolutionDate = time.Now()
+
+			var b bytes.Buffer
+			if err := serializeOutgoingPayment(&b, payment); err != nil {
+				return err
+			}
+			interrupted[string(k)] = b.Bytes()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for k, v := range interrupted {
+			if err := payments.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+
+		numInterrupted = len(interrupted)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return numInterrupted, nil
+}
+
+// paymentsByDate sorts payments by their creation date.
+type paymentsByDate []*OutgoingPayment
+
+func (p paymentsByDate) Len() int      { return len(p) }
+func (p paymentsByDate) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p paymentsByDate) Less(i, j int) bool {
+	return p[i].CreationDate.Before(p[j].CreationDate)
+}
+
+// FetchAllPayments returns all the payments we've attempted to send, ordered
+// by their creation date.
+func (d *DB) FetchAllPayments() ([]*OutgoingPayment, error) {
+	var payments []*OutgoingPayment
+	err := d.store.View(func(tx *bolt.Tx) error {
+		paymentsBucket := tx.Bucket(paymentBucket)
+		if paymentsBucket == nil {
+			return nil
+		}
+
+		return paymentsBucket.ForEach(func(k, v []byte) error {
+			payment, err := deserializeOutgoingPayment(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+
+			payments = append(payments, payment)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(paymentsByDate(payments))
+	return payments, nil
+}
+
+// DeletePayments deletes all payments which are no longer in flight. If
+// failedOnly is true, then only failed payments are deleted. In-flight
+// payments are always retained, as they're needed to prevent the same hash
+// from being paid twice. Deleting an interrupted payment allows its hash to
+// be paid again, so they're only deleted if failedOnly is false. The number
+// of deleted payments is returned.
+func (d *DB) DeletePayments(failedOnly bool) (int, error) {
+	var numDeleted int
+	err := d.store.Update(func(tx *bolt.Tx) error {
+		payments := tx.Bucket(paymentBucket)
+		if payments == nil {
+			return nil
+		}
+
+		// Keys can't be deleted while iterating over the bucket, so
+		// we first gather the hashes of the payments to delete.
+		var toDelete [][]byte
+		err := payments.ForEach(func(k, v []byte) error {
+			payment, err := deserializeOutgoingPayment(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case payment.Status == StatusInFlight:
+				return nil
+			case failedOnly && payment.Status != StatusFailed:
+				return nil
+			}
+
+			toDelete = append(toDelete, append([]byte(nil), k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range toDelete {
+			if err := payments.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		numDeleted = len(toDelete)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return numDeleted, nil
+}
+
+func serializeOutgoingPayment(w io.Writer, p *OutgoingPayment) error {
+	var scratch [8]byte
+
+	if _, err := w.Write(p.PaymentHash[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(p.PaymentPreimage[:]); err != nil {
+		return err
+	}
+
+	byteOrder.PutUint64(scratch[:], uint64(p.Value))
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+	byteOrder.PutUint64(scratch[:], uint64(p.Fee))
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+
+	if err := writePath(w, p.Path); err != nil {
+		return err
+	}
+
+	if _, err := w.Write([]byte{byte(p.Status)}); err != nil {
+		return err
+	}
+	if err := wire.WriteVarString(w, 0, p.FailureReason); err != nil {
+		return err
+	}
+
+	byteOrder.PutUint64(scratch[:], uint64(p.CreationDate.UnixNano()))
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+
+	// A zero resolution date is stored as zero, rather than the unix
+	// nanoseconds of the zero time, which can't be represented.
+	var resolutionDate uint64
+	if !p.ResolutionDate.IsZero() {
+		resolutionDate = uint64(p.ResolutionDate.UnixNano())
+	}
+	byteOrder.PutUint64(scratch[:], resolutionDate)
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+
+	byteOrder.PutUint16(scratch[:2], uint16(len(p.Attempts)))
+	if _, err := w.Write(scratch[:2]); err != nil {
+		return err
+	}
+	for _, attempt := range p.Attempts {
+		if err := writePath(w, attempt.Path); err != nil {
+			return err
+		}
+
+		byteOrder.PutUint64(scratch[:], uint64(attempt.Amount))
+		if _, err := w.Write(scratch[:]); err != nil {
+			return err
+		}
+		byteOrder.PutUint64(scratch[:], uint64(attempt.Fee))
+		if _, err := w.Write(scratch[:]); err != nil {
+			return err
+		}
+		byteOrder.PutUint64(scratch[:], uint64(attempt.AttemptTime.UnixNano()))
+		if _, err := w.Write(scratch[:]); err != nil {
+			return err
+		}
+
+		err := wire.WriteVarString(w, 0, attempt.FailureReason)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deserializeOutgoingPayment(r io.Reader) (*OutgoingPayment, error) {
+	var scratch [8]byte
+	p := &OutgoingPayment{}
+
+	if _, err := io.ReadFull(r, p.PaymentHash[:]); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, p.PaymentPreimage[:]); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	p.Value = lnwire.MilliSatoshi(byteOrder.Uint64(scratch[:]))
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	p.Fee = lnwire.MilliSatoshi(byteOrder.Uint64(scratch[:]))
+
+	path, err := readPath(r)
+	if err != nil {
+		return nil, err
+	}
+	p.Path = path
+
+	if _, err := io.ReadFull(r, scratch[:1]); err != nil {
+		return nil, err
+	}
+	p.Status = PaymentStatus(scratch[0])
+
+	reason, err := wire.ReadVarString(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	p.FailureReason = reason
+
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	p.CreationDate = time.Unix(0, int64(byteOrder.Uint64(scratch[:])))
+
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+	if resolutionDate := byteOrder.Uint64(scratch[:]); resolutionDate != 0 {
+		p.ResolutionDate = time.Unix(0, int64(resolutionDate))
+	}
+
+	if _, err := io.ReadFull(r, scratch[:2]); err != nil {
+		return nil, err
+	}
+	numAttempts := byteOrder.Uint16(scratch[:2])
+	for i := uint16(0); i < numAttempts; i++ {
+		attempt := &PaymentAttempt{}
+
+		attempt.Path, err = readPath(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.ReadFull(r, scratch[:]); err != nil {
+			return nil, err
+		}
+		attempt.Amount = lnwire.MilliSatoshi(byteOrder.Uint64(scratch[:]))
+		if _, err := io.ReadFull(r, scratch[:]); err != nil {
+			return nil, err
+		}
+		attempt.Fee = lnwire.MilliSatoshi(byteOrder.Uint64(scratch[:]))
+		if _, err := io.ReadFull(r, scratch[:]); err != nil {
+			return nil, err
+		}
+		attempt.AttemptTime = time.Unix(0, int64(byteOrder.Uint64(scratch[:])))
+
+		attempt.FailureReason, err = wire.ReadVarString(r, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Attempts = append(p.Attempts, attempt)
+	}
+
+	return p, nil
+}
+
+// writePath serializes a route as the number of hops, followed by the
+// lightning ID of each hop.
+func writePath(w io.Writer, path [][32]byte) error {
+	var scratch [2]byte
+	byteOrder.PutUint16(scratch[:], uint16(len(path)))
+	if _, err := w.Write(scratch[:]); err != nil {
+		return err
+	}
+
+	for _, hop := range path {
+		if _, err := w.Write(hop[:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readPath deserializes a route written by writePath.
+func readPath(r io.Reader) ([][32]byte, error) {
+	var scratch [2]byte
+	if _, err := io.ReadFull(r, scratch[:]); err != nil {
+		return nil, err
+	}
+
+	path := make([][32]byte, byteOrder.Uint16(scratch[:]))
+	for i := range path {
+		if _, err := io.ReadFull(r, path[i][:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return path, nil
+}
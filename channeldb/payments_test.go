@@ -0,0 +1,232 @@
+package channeldb
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPaymentLifecycle(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "channeldb")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := Open(tempDirName, netParams)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	payment := &OutgoingPayment{
+		PaymentHash:  [32]byte{0x01},
+		Value:        1000,
+		Path:         [][32]byte{{0x02}, {0x03}},
+		Status:       StatusInFlight,
+		CreationDate: time.Unix(1000000, 0),
+	}
+	if err := cdb.InitPayment(payment); err != nil {
+		t.Fatalf("unable to init payment: %v", err)
+	}
+
+	// A second attempt to pay the same hash should be rejected while the
+	// first is still in flight.
+	if err := cdb.InitPayment(payment); err != ErrPaymentInFlight {
+		t.Fatalf("expected ErrPaymentInFlight, got %v", err)
+	}
+
+	payments, err := cdb.FetchAllPayments()
+	if err != nil {
+		t.Fatalf("unable to fetch payments: %v", err)
+	}
+	if len(payments) != 1 || !reflect.DeepEqual(payments[0], payment) {
+		t.Fatalf("payments don't match: expected %v, got %v",
+			payment, payments)
+	}
+
+	// Each attempt should be recorded, with the route and fee of the
+	// payment set to those of the successful attempts.
+	attempts := []*PaymentAttempt{
+		{
+			Path:          [][32]byte{{0x02}, {0x03}},
+			Amount:        1000,
+			Fee:           2,
+			AttemptTime:   time.Unix(1000001, 0),
+			FailureReason: "channel policy",
+		},
+		{
+			Path:        [][32]byte{{0x06}, {0x03}},
+			Amount:      1000,
+			Fee:         5,
+			AttemptTime: time.Unix(1000002, 0),
+		},
+	}
+	for _, attempt := range attempts {
+		if err := cdb.AddPaymentAttempt(payment.PaymentHash, attempt); err != nil {
+			t.Fatalf("unable to add attempt: %v", err)
+		}
+	}
+	payments, err = cdb.FetchAllPayments()
+	if err != nil {
+		t.Fatalf("unable to fetch payments: %v", err)
+	}
+	if !reflect.DeepEqual(payments[0].Attempts, attempts) {
+		t.Fatalf("attempts don't match: expected %v, got %v",
+			attempts, payments[0].Attempts)
+	}
+	if payments[0].Fee != 5 || payments[0].Path[0] != [32]byte{0x06} {
+		t.Fatalf("route not updated to successful attempt")
+	}
+
+	// Once settled, the preimage should be recorded, and the hash can no
+	// longer be paid.
+	preimage := [32]byte{0x04}
+	if err := cdb.SettlePayment(payment.PaymentHash, preimage); err != nil {
+		t.Fatalf("unable to settle payment: %v", err)
+	}
+	if err := cdb.InitPayment(payment); err != ErrAlreadyPaid {
+		t.Fatalf("expected ErrAlreadyPaid, got %v", err)
+	}
+	err = cdb.FailPayment(payment.PaymentHash, "too late")
+	if err != ErrPaymentNotInFlight {
+		t.Fatalf("expected ErrPaymentNotInFlight, got %v", err)
+	}
+
+	// A failed payment may be retried.
+	failedPayment := &OutgoingPayment{
+		PaymentHash:  [32]byte{0x05},
+		Value:        500,
+		Path:         [][32]byte{{0x02}},
+		Status:       StatusInFlight,
+		CreationDate: time.Unix(2000000, 0),
+	}
+	if err := cdb.InitPayment(failedPayment); err != nil {
+		t.Fatalf("unable to init payment: %v", err)
+	}
+	if err := cdb.FailPayment(failedPayment.PaymentHash, "no route"); err != nil {
+		t.Fatalf("unable to fail payment: %v", err)
+	}
+
+	payments, err = cdb.FetchAllPayments()
+	if err != nil {
+		t.Fatalf("unable to fetch payments: %v", err)
+	}
+	if len(payments) != 2 {
+		t.Fatalf("expected 2 payments, got %v", len(payments))
+	}
+	if payments[0].Status != StatusSucceeded ||
+		payments[0].PaymentPreimage != preimage ||
+		payments[0].ResolutionDate.IsZero() {
+		t.Fatalf("payment not settled: %v", payments[0])
+	}
+	if payments[1].Status != StatusFailed ||
+		payments[1].FailureReason != "no route" {
+		t.Fatalf("payment not failed: %v", payments[1])
+	}
+
+	if err := cdb.InitPayment(failedPayment); err != nil {
+		t.Fatalf("unable to retry failed payment: %v", err)
+	}
+
+	// Only the settled payment should be deleted, as the retried payment
+	// is now in flight.
+	numDeleted, err := cdb.DeletePayments(false)
+	if err != nil {
+		t.Fatalf("unable to delete payments: %v", err)
+	}
+	if numDeleted != 1 {
+		t.Fatalf("expected 1 payment to be deleted, got %v", numDeleted)
+	}
+	payments, err = cdb.FetchAllPayments()
+	if err != nil {
+		t.Fatalf("unable to fetch payments: %v", err)
+	}
+	if len(payments) != 1 || payments[0].PaymentHash != failedPayment.PaymentHash {
+		t.Fatalf("wrong payments remain: %v", payments)
+	}
+}
+
+func TestInterruptPayments(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "channeldb")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := Open(tempDirName, netParams)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	inFlight := &OutgoingPayment{
+		PaymentHash:  [32]byte{0x01},
+		Value:        1000,
+		Status:       StatusInFlight,
+		CreationDate: time.Unix(1000000, 0),
+	}
+	failed := &OutgoingPayment{
+		PaymentHash:  [32]byte{0x02},
+		Value:        1000,
+		Status:       StatusInFlight,
+		CreationDate: time.Unix(1000001, 0),
+	}
+	for _, payment := range []*OutgoingPayment{inFlight, failed} {
+		if err := cdb.InitPayment(payment); err != nil {
+			t.Fatalf("unable to init payment: %v", err)
+		}
+	}
+	if err := cdb.FailPayment(failed.PaymentHash, "no route"); err != nil {
+		t.Fatalf("unable to fail payment: %v", err)
+	}
+
+	// Only the payment which was still in flight is interrupted.
+	numInterrupted, err := cdb.InterruptPayments()
+	if err != nil {
+		t.Fatalf("unable to interrupt payments: %v", err)
+	}
+	if numInterrupted != 1 {
+		t.Fatalf("expected 1 interrupted payment, got %v",
+			numInterrupted)
+	}
+	payments, err := cdb.FetchAllPayments()
+	if err != nil {
+		t.Fatalf("unable to fetch payments: %v", err)
+	}
+	if payments[0].Status != StatusInterrupted ||
+		payments[0].ResolutionDate.IsZero() {
+		t.Fatalf("payment not interrupted: %v", payments[0])
+	}
+	if payments[1].Status != StatusFailed {
+		t.Fatalf("failed payment changed: %v", payments[1])
+	}
+
+	// The hash of an interrupted payment can't be paid again, as its
+	// HTLCs may yet be claimed.
+	if err := cdb.InitPayment(inFlight); err != ErrPaymentInterrupted {
+		t.Fatalf("expected ErrPaymentInterrupted, got %v", err)
+	}
+
+	// Interrupted payments are only deleted along with succeeded ones,
+	// after which the hash may be paid again.
+	numDeleted, err := cdb.DeletePayments(true)
+	if err != nil {
+		t.Fatalf("unable to delete payments: %v", err)
+	}
+	if numDeleted != 1 {
+		t.Fatalf("expected 1 payment to be deleted, got %v", numDeleted)
+	}
+	numDeleted, err = cdb.DeletePayments(false)
+	if err != nil {
+		t.Fatalf("unable to delete payments: %v", err)
+	}
+	if numDeleted != 1 {
+		t.Fatalf("expected 1 payment to be deleted, got %v", numDeleted)
+	}
+	if err := cdb.InitPayment(inFlight); err != nil {
+		t.Fatalf("unable to pay deleted hash: %v", err)
+	}
+}
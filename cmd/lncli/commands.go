@@ -77,10 +77,25 @@ func newAddress(ctx *cli.Context) error {
 	return nil
 }
 
+// confTargetFlag and satPerByteFlag allow the fee rate paid by an on-chain
+// transaction to be selected either by a confirmation target, or explicitly.
+// If neither is set, lnd falls back to its default confirmation target.
+var (
+	confTargetFlag = cli.IntFlag{
+		Name: "conf_target",
+		Usage: "the number of blocks the transaction should confirm " +
+			"within, used to estimate its fee rate",
+	}
+	satPerByteFlag = cli.IntFlag{
+		Name:  "sat_per_byte",
+		Usage: "an explicit fee rate in satoshis per byte",
+	}
+)
+
 var SendCoinsCommand = cli.Command{
 	Name:        "sendcoins",
 	Description: "send a specified amount of bitcoin to the passed address",
-	Usage:       "sendcoins --addr=<bitcoin addresss> --amt=<num coins in satoshis>",
+	Usage:       "sendcoins --addr=<bitcoin addresss> --amt=<num coins in satoshis> [--conf_target=N | --sat_per_byte=N]",
 	Flags: []cli.Flag{
 		cli.StringFlag{
 			Name:  "addr",
@@ -91,6 +106,8 @@ var SendCoinsCommand = cli.Command{
 			Name:  "amt",
 			Usage: "the number of bitcoin denominated in satoshis to send",
 		},
+		confTargetFlag,
+		satPerByteFlag,
 	},
 	Action: sendCoins,
 }
@@ -100,8 +117,10 @@ func sendCoins(ctx *cli.Context) error {
 	client := getClient(ctx)
 
 	req := &lnrpc.SendCoinsRequest{
-		Addr:   ctx.String("addr"),
-		Amount: int64(ctx.Int("amt")),
+		Addr:       ctx.String("addr"),
+		Amount:     int64(ctx.Int("amt")),
+		TargetConf: int32(ctx.Int("conf_target")),
+		SatPerByte: int64(ctx.Int("sat_per_byte")),
 	}
 	txid, err := client.SendCoins(ctxb, req)
 	if err != nil {
@@ -116,7 +135,11 @@ var SendManyCommand = cli.Command{
 	Name: "sendmany",
 	Description: "create and broadcast a transaction paying the specified " +
 		"amount(s) to the passed address(es)",
-	Usage:  `sendmany '{"ExampleAddr": NumCoinsInSatoshis, "SecondAddr": NumCoins}'`,
+	Usage: `sendmany '{"ExampleAddr": NumCoinsInSatoshis, "SecondAddr": NumCoins}'`,
+	Flags: []cli.Flag{
+		confTargetFlag,
+		satPerByteFlag,
+	},
 	Action: sendMany,
 }
 
@@ -131,7 +154,12 @@ func sendMany(ctx *cli.Context) error {
 	ctxb := context.Background()
 	client := getClient(ctx)
 
-	txid, err := client.SendMany(ctxb, &lnrpc.SendManyRequest{amountToAddr})
+	req := &lnrpc.SendManyRequest{
+		AddrToAmount: amountToAddr,
+		TargetConf:   int32(ctx.Int("conf_target")),
+		SatPerByte:   int64(ctx.Int("sat_per_byte")),
+	}
+	txid, err := client.SendMany(ctxb, req)
 	if err != nil {
 		return err
 	}
@@ -174,7 +202,7 @@ var OpenChannelCommand = cli.Command{
 		"blocking until the channel is 'open'. Once the channel is " +
 		"open, a channelPoint (txid:vout) of the funding output is " +
 		"returned.",
-	Usage: "openchannel --peer_id=X --local_amt=N --remote_amt=N --num_confs=N",
+	Usage: "openchannel --peer_id=X --local_amt=N --remote_amt=N --num_confs=N [--conf_target=N | --sat_per_byte=N]",
 	Flags: []cli.Flag{
 		cli.IntFlag{
 			Name:  "peer_id",
@@ -197,6 +225,8 @@ var OpenChannelCommand = cli.Command{
 			Name:  "block",
 			Usage: "block and wait until the channel is fully open",
 		},
+		confTargetFlag,
+		satPerByteFlag,
 	},
 	Action: openChannel,
 }
@@ -211,6 +241,8 @@ func openChannel(ctx *cli.Context) error {
 		LocalFundingAmount:  int64(ctx.Int("local_amt")),
 		RemoteFundingAmount: int64(ctx.Int("remote_amt")),
 		NumConfs:            uint32(ctx.Int("num_confs")),
+		TargetConf:          int32(ctx.Int("conf_target")),
+		SatPerByte:          int64(ctx.Int("sat_per_byte")),
 	}
 
 	stream, err := client.OpenChannel(ctxb, req)
@@ -278,6 +310,8 @@ var CloseChannelCommand = cli.Command{
 			Name:  "block",
 			Usage: "block until the channel is closed",
 		},
+		confTargetFlag,
+		satPerByteFlag,
 	},
 	Action: closeChannel,
 }
@@ -296,6 +330,8 @@ func closeChannel(ctx *cli.Context) error {
 			FundingTxid: txid[:],
 			OutputIndex: uint32(ctx.Int("output_index")),
 		},
+		TargetConf: int32(ctx.Int("conf_target")),
+		SatPerByte: int64(ctx.Int("sat_per_byte")),
 	}
 
 	stream, err := client.CloseChannel(ctxb, req)
@@ -461,6 +497,31 @@ var SendPaymentCommand = cli.Command{
 			Usage: "skip the HTLC trickle logic, immediately creating a " +
 				"new commitment",
 		},
+		cli.IntFlag{
+			Name: "fee_limit",
+			Usage: "the maximum total fee in satoshis to pay, defaults " +
+				"to 5% of the amount",
+		},
+		cli.IntFlag{
+			Name: "timeout",
+			Usage: "the number of seconds after which no further " +
+				"attempts are made to deliver the payment",
+		},
+		cli.StringFlag{
+			Name: "redemption_hashes",
+			Usage: "the comma separated redemption hashes of an " +
+				"escrow invoice, used in place of the payment hash",
+		},
+		cli.IntFlag{
+			Name: "num_required",
+			Usage: "the number of preimages required to settle an " +
+				"escrow payment",
+		},
+		cli.IntFlag{
+			Name: "final_cltv_delta",
+			Usage: "the number of blocks the HTLC arriving at the " +
+				"recipient must remain valid for, defaults to 9",
+		},
 	},
 	Action: sendPaymentCommand,
 }
@@ -474,10 +535,24 @@ func sendPaymentCommand(ctx *cli.Context) error {
 	}
 	// TODO(roasbeef): remove debug payment hash
 	req := &lnrpc.SendRequest{
-		Dest:     destAddr,
-		Amt:      int64(ctx.Int("amt")),
-		FastSend: ctx.Bool("fast"),
+		Dest:           destAddr,
+		Amt:            int64(ctx.Int("amt")),
+		FastSend:       ctx.Bool("fast"),
+		FeeLimit:       int64(ctx.Int("fee_limit")),
+		TimeoutSeconds: int32(ctx.Int("timeout")),
+	}
+	if ctx.String("payment_hash") != "" {
+		req.PaymentHash, err = hex.DecodeString(ctx.String("payment_hash"))
+		if err != nil {
+			return err
+		}
 	}
+	req.RedemptionHashes, err = decodeHexList(ctx.String("redemption_hashes"))
+	if err != nil {
+		return err
+	}
+	req.NumRequired = uint32(ctx.Int("num_required"))
+	req.FinalCltvDelta = uint32(ctx.Int("final_cltv_delta"))
 
 	paymentStream, err := client.SendPayment(context.Background())
 	if err != nil {
@@ -517,4 +592,521 @@ func showRoutingTable(ctx *cli.Context) error {
 
 	printRespJson(resp)
 	return nil
-}
\ No newline at end of file
+}
+
+var QueryRoutesCommand = cli.Command{
+	Name:        "queryroutes",
+	Description: "query the channel graph for routes to a destination",
+	Usage:       "queryroutes --dest=[node_id] --amt=[in_satoshis]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "dest, d",
+			Usage: "lightning address of the target node",
+		},
+		cli.IntFlag{
+			Name:  "amt, a",
+			Usage: "number of satoshis to deliver to the target node",
+		},
+		cli.IntFlag{
+			Name:  "num_routes, n",
+			Value: 1,
+			Usage: "the maximum number of routes to return",
+		},
+		cli.IntFlag{
+			Name: "final_cltv_delta",
+			Usage: "the number of blocks the HTLC arriving at the " +
+				"target must remain valid for, defaults to 9",
+		},
+	},
+	Action: queryRoutes,
+}
+
+func queryRoutes(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	destAddr, err := hex.DecodeString(ctx.String("dest"))
+	if err != nil {
+		return err
+	}
+
+	req := &lnrpc.QueryRoutesRequest{
+		Dest:           destAddr,
+		Amt:            int64(ctx.Int("amt")),
+		NumRoutes:      int32(ctx.Int("num_routes")),
+		FinalCltvDelta: uint32(ctx.Int("final_cltv_delta")),
+	}
+	resp, err := client.QueryRoutes(ctxb, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+var UpdateChannelPolicyCommand = cli.Command{
+	Name: "updatechanpolicy",
+	Description: "Update the forwarding policy of a channel. If no channel " +
+		"is specified, then the policy is applied to all active channels.",
+	Usage: "updatechanpolicy --base_fee=[in_satoshis] --fee_rate=[in_millionths] " +
+		"--time_lock_delta=[in_blocks] [--funding_txid=T --output_index=N]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "funding_txid",
+			Usage: "the txid of the channel's funding transaction",
+		},
+		cli.IntFlag{
+			Name: "output_index",
+			Usage: "the output index for the funding output of the funding " +
+				"transaction",
+		},
+		cli.IntFlag{
+			Name:  "base_fee",
+			Usage: "the flat fee in satoshis charged for each forwarded HTLC",
+		},
+		cli.IntFlag{
+			Name: "fee_rate",
+			Usage: "the proportional fee charged for each forwarded HTLC, " +
+				"in millionths of the forwarded amount",
+		},
+		cli.IntFlag{
+			Name: "time_lock_delta",
+			Usage: "the minimum number of blocks between the expiries of " +
+				"the incoming and outgoing HTLCs of a forward",
+		},
+		cli.IntFlag{
+			Name:  "min_htlc",
+			Usage: "the smallest HTLC in satoshis which will be forwarded",
+		},
+		cli.IntFlag{
+			Name: "max_htlc",
+			Usage: "the largest HTLC in satoshis which will be forwarded, " +
+				"zero for no maximum",
+		},
+	},
+	Action: updateChannelPolicy,
+}
+
+func updateChannelPolicy(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	req := &lnrpc.PolicyUpdateRequest{
+		BaseFee:       int64(ctx.Int("base_fee")),
+		FeeRate:       uint32(ctx.Int("fee_rate")),
+		TimeLockDelta: uint32(ctx.Int("time_lock_delta")),
+		MinHtlc:       int64(ctx.Int("min_htlc")),
+		MaxHtlc:       int64(ctx.Int("max_htlc")),
+	}
+
+	if ctx.String("funding_txid") != "" {
+		txid, err := wire.NewShaHashFromStr(ctx.String("funding_txid"))
+		if err != nil {
+			return err
+		}
+
+		req.ChanPoint = &lnrpc.ChannelPoint{
+			FundingTxid: txid[:],
+			OutputIndex: uint32(ctx.Int("output_index")),
+		}
+	}
+
+	resp, err := client.UpdateChannelPolicy(ctxb, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+var ForwardingHistoryCommand = cli.Command{
+	Name: "fwdinghistory",
+	Description: "Query the log of HTLCs forwarded by the daemon within a " +
+		"time range. Results are paginated, with the last_offset_index of " +
+		"the response used as the index_offset of the next query.",
+	Usage: "fwdinghistory --start_time=[unix_timestamp] " +
+		"--end_time=[unix_timestamp] --index_offset=N --max_events=N",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "start_time",
+			Usage: "the unix timestamp of the earliest event to return",
+		},
+		cli.IntFlag{
+			Name: "end_time",
+			Usage: "the unix timestamp of the latest event to return, " +
+				"defaulting to the current time",
+		},
+		cli.IntFlag{
+			Name:  "index_offset",
+			Usage: "the number of events within the time range to skip",
+		},
+		cli.IntFlag{
+			Name:  "max_events",
+			Usage: "the maximum number of events to return",
+		},
+	},
+	Action: forwardingHistory,
+}
+
+func forwardingHistory(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	req := &lnrpc.ForwardingHistoryRequest{
+		StartTime:    int64(ctx.Int("start_time")),
+		EndTime:      int64(ctx.Int("end_time")),
+		IndexOffset:  uint32(ctx.Int("index_offset")),
+		NumMaxEvents: uint32(ctx.Int("max_events")),
+	}
+
+	resp, err := client.ForwardingHistory(ctxb, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+var FeeReportCommand = cli.Command{
+	Name: "feereport",
+	Description: "Display the forwarding policy of each active channel, " +
+		"along with the fees earned within the past day, week and month.",
+	Usage:  "feereport",
+	Action: feeReport,
+}
+
+func feeReport(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	resp, err := client.FeeReport(ctxb, &lnrpc.FeeReportRequest{})
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+var ListPaymentsCommand = cli.Command{
+	Name:        "listpayments",
+	Description: "List all payments sent by the daemon, along with their status.",
+	Usage:       "listpayments",
+	Action:      listPayments,
+}
+
+func listPayments(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	resp, err := client.ListPayments(ctxb, &lnrpc.ListPaymentsRequest{})
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+var DeletePaymentsCommand = cli.Command{
+	Name: "deletepayments",
+	Description: "Delete the records of all completed payments. In-flight " +
+		"payments are never deleted. Deleting a payment interrupted by " +
+		"a restart allows its hash to be paid again, even though the " +
+		"interrupted payment may yet succeed.",
+	Usage: "deletepayments [--failed_only]",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "failed_only",
+			Usage: "only delete the records of failed payments",
+		},
+	},
+	Action: deletePayments,
+}
+
+func deletePayments(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	req := &lnrpc.DeletePaymentsRequest{
+		FailedOnly: ctx.Bool("failed_only"),
+	}
+
+	resp, err := client.DeletePayments(ctxb, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+var AddHoldInvoiceCommand = cli.Command{
+	Name: "addholdinvoice",
+	Description: "Add a hold invoice identified by only its payment hash. " +
+		"The HTLCs paying the invoice are held until it's either " +
+		"settled, or cancelled.",
+	Usage: "addholdinvoice --payment_hash=[hash] --value=[in_satoshis]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "payment_hash",
+			Usage: "the hash of the invoice's preimage",
+		},
+		cli.IntFlag{
+			Name:  "value",
+			Usage: "the value of the invoice in satoshis",
+		},
+		cli.IntFlag{
+			Name: "expiry",
+			Usage: "the number of seconds after which the invoice " +
+				"expires, defaults to an hour",
+		},
+	},
+	Action: addHoldInvoice,
+}
+
+func addHoldInvoice(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	payHash, err := hex.DecodeString(ctx.String("payment_hash"))
+	if err != nil {
+		return err
+	}
+
+	req := &lnrpc.AddHoldInvoiceRequest{
+		PaymentHash: payHash,
+		Value:       int64(ctx.Int("value")),
+		Expiry:      int64(ctx.Int("expiry")),
+	}
+
+	resp, err := client.AddHoldInvoice(ctxb, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+var SettleInvoiceCommand = cli.Command{
+	Name:        "settleinvoice",
+	Description: "Settle an accepted hold invoice, revealing its preimage.",
+	Usage:       "settleinvoice --preimage=[preimage]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "preimage",
+			Usage: "the preimage of the invoice's payment hash",
+		},
+	},
+	Action: settleInvoice,
+}
+
+func settleInvoice(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	preimage, err := hex.DecodeString(ctx.String("preimage"))
+	if err != nil {
+		return err
+	}
+
+	req := &lnrpc.SettleInvoiceRequest{
+		Preimage: preimage,
+	}
+
+	resp, err := client.SettleInvoice(ctxb, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+var CancelInvoiceCommand = cli.Command{
+	Name:        "cancelinvoice",
+	Description: "Cancel a hold invoice, along with any HTLCs paying it.",
+	Usage:       "cancelinvoice --payment_hash=[hash]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "payment_hash",
+			Usage: "the payment hash of the invoice",
+		},
+	},
+	Action: cancelInvoice,
+}
+
+func cancelInvoice(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	payHash, err := hex.DecodeString(ctx.String("payment_hash"))
+	if err != nil {
+		return err
+	}
+
+	req := &lnrpc.CancelInvoiceRequest{
+		PaymentHash: payHash,
+	}
+
+	resp, err := client.CancelInvoice(ctxb, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+var SubscribeInvoicesCommand = cli.Command{
+	Name:        "subscribeinvoices",
+	Description: "Print an update each time the state of an invoice changes.",
+	Usage:       "subscribeinvoices",
+	Action:      subscribeInvoices,
+}
+
+func subscribeInvoices(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	stream, err := client.SubscribeInvoices(ctxb,
+		&lnrpc.InvoiceSubscription{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		invoice, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		printRespJson(invoice)
+	}
+}
+
+var AddEscrowInvoiceCommand = cli.Command{
+	Name: "addescrowinvoice",
+	Description: "Add an escrow invoice, which is paid by HTLCs requiring " +
+		"the preimages of N of its M redemption hashes. Preimages " +
+		"which aren't yet known may later be revealed.",
+	Usage: "addescrowinvoice --redemption_hashes=[hash,...] " +
+		"--num_required=[N] --value=[in_satoshis]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "redemption_hashes",
+			Usage: "the comma separated redemption hashes of the " +
+				"invoice, the first of which identifies it",
+		},
+		cli.IntFlag{
+			Name: "num_required",
+			Usage: "the number of preimages required to settle the " +
+				"invoice",
+		},
+		cli.StringFlag{
+			Name:  "preimages",
+			Usage: "the comma separated preimages already known",
+		},
+		cli.IntFlag{
+			Name:  "value",
+			Usage: "the value of the invoice in satoshis",
+		},
+		cli.IntFlag{
+			Name: "expiry",
+			Usage: "the number of seconds after which the invoice " +
+				"expires, by default it never expires",
+		},
+	},
+	Action: addEscrowInvoice,
+}
+
+func addEscrowInvoice(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	redemptionHashes, err := decodeHexList(ctx.String("redemption_hashes"))
+	if err != nil {
+		return err
+	}
+	preimages, err := decodeHexList(ctx.String("preimages"))
+	if err != nil {
+		return err
+	}
+
+	req := &lnrpc.AddEscrowInvoiceRequest{
+		RedemptionHashes: redemptionHashes,
+		NumRequired:      uint32(ctx.Int("num_required")),
+		Preimages:        preimages,
+		Value:            int64(ctx.Int("value")),
+		Expiry:           int64(ctx.Int("expiry")),
+	}
+
+	resp, err := client.AddEscrowInvoice(ctxb, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+var RevealPreimageCommand = cli.Command{
+	Name: "revealpreimage",
+	Description: "Reveal the preimage of a redemption hash of an escrow " +
+		"invoice, settling it once enough preimages are known.",
+	Usage: "revealpreimage --preimage=[preimage]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "preimage",
+			Usage: "the preimage of one of the invoice's redemption hashes",
+		},
+	},
+	Action: revealPreimage,
+}
+
+func revealPreimage(ctx *cli.Context) error {
+	ctxb := context.Background()
+	client := getClient(ctx)
+
+	preimage, err := hex.DecodeString(ctx.String("preimage"))
+	if err != nil {
+		return err
+	}
+
+	req := &lnrpc.RevealPreimageRequest{
+		Preimage: preimage,
+	}
+
+	resp, err := client.RevealPreimage(ctxb, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJson(resp)
+	return nil
+}
+
+// decodeHexList decodes each of the comma separated hex strings within the
+// passed list. An empty list decodes to no values.
+func decodeHexList(list string) ([][]byte, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	var values [][]byte
+	for _, hexStr := range strings.Split(list, ",") {
+		value, err := hex.DecodeString(strings.TrimSpace(hexStr))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
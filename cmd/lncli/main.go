@@ -61,6 +61,18 @@ func main() {
 		PendingChannelsCommand,
 		SendPaymentCommand,
 		ShowRoutingTableCommand,
+		QueryRoutesCommand,
+		UpdateChannelPolicyCommand,
+		ForwardingHistoryCommand,
+		FeeReportCommand,
+		ListPaymentsCommand,
+		DeletePaymentsCommand,
+		AddHoldInvoiceCommand,
+		SettleInvoiceCommand,
+		CancelInvoiceCommand,
+		SubscribeInvoicesCommand,
+		AddEscrowInvoiceCommand,
+		RevealPreimageCommand,
 	}
 
 	if err := app.Run(os.Args); err != nil {
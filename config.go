@@ -7,8 +7,10 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	flags "github.com/btcsuite/go-flags"
+	"github.com/lightningnetwork/lnd/pathfind"
 	"github.com/roasbeef/btcutil"
 )
 
@@ -25,6 +27,21 @@ const (
 	defaultRPCUser        = "user"
 	defaultRPCPass        = "passwd"
 	defaultSPVHostAdr     = "localhost:18333"
+	defaultSPVBirthday    = 21900
+
+	defaultBitcoindRPCHost      = "localhost:18332"
+	defaultBitcoindPollInterval = time.Second * 5
+
+	defaultChanRefreshInterval = time.Hour * 24 * 14
+
+	defaultTrickleDelay = time.Millisecond * 50
+	defaultMaxBatchSize = 20
+
+	defaultFallbackFeeRate = 50
+
+	// Senders use the same final CLTV delta by default, so it may only be
+	// raised if they're told to use the new value when paying.
+	defaultFinalCLTVDelta = pathfind.DefaultFinalCLTVDelta
 )
 
 var (
@@ -38,6 +55,16 @@ var (
 	defaultRPCCertFile = filepath.Join(btcdHomeDir, "rpc.cert")
 )
 
+// bitcoindConfig houses the options used to connect to Bitcoin Core's
+// JSON-RPC interface.
+type bitcoindConfig struct {
+	Active       bool          `long:"active" description:"Use Bitcoin Core's JSON-RPC interface to watch the chain, and broadcast transactions. The SPV wallet (--spvhostadr) is used to hold funds"`
+	RPCHost      string        `long:"rpchost" description:"The host:port of bitcoind's JSON-RPC interface"`
+	RPCUser      string        `long:"rpcuser" description:"Username for bitcoind's JSON-RPC interface"`
+	RPCPass      string        `long:"rpcpass" default-mask:"-" description:"Password for bitcoind's JSON-RPC interface"`
+	PollInterval time.Duration `long:"pollinterval" description:"How often bitcoind is polled for a new best block"`
+}
+
 // config defines the configuration options for lnd.
 //
 // See loadConfig for further details regarding the configuration
@@ -58,17 +85,30 @@ type config struct {
 
 	PeerPort int    `long:"peerport" description:"The port to listen on for incoming p2p connections"`
 	RPCPort  int    `long:"rpcport" description:"The port for the rpc server"`
-	SPVMode  bool   `long:"spv" description:"assert to enter spv wallet mode"`
+	SPVMode  bool   `long:"spv" description:"Use the uspv wallet, and chain notifier instead of btcd"`
 	RPCHost  string `long:"btcdhost" description:"The btcd rpc listening address. "`
 	RPCUser  string `short:"u" long:"rpcuser" description:"Username for RPC connections"`
 	RPCPass  string `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
 
-	RPCCert    string `long:"rpccert" description:"File containing btcd's certificate file"`
-	RPCKey     string `long:"rpckey" description:"File containing btcd's certificate key"`
-	SPVHostAdr string `long:"spvhostadr" description:"Address of full bitcoin node. It is used in SPV mode."`
-	TestNet3   bool   `long:"testnet" description:"Use the test network"`
-	SimNet     bool   `long:"simnet" description:"Use the simulation test network"`
-	SegNet     bool   `long:"segnet" description:"Use the segragated witness test network"`
+	RPCCert     string `long:"rpccert" description:"File containing btcd's certificate file"`
+	RPCKey      string `long:"rpckey" description:"File containing btcd's certificate key"`
+	SPVHostAdr  string `long:"spvhostadr" description:"Address of full bitcoin node. It is used in SPV mode."`
+	SPVBirthday int32  `long:"spvbirthday" description:"The block height to begin syncing from when the SPV wallet is first created"`
+	TestNet3    bool   `long:"testnet" description:"Use the test network"`
+	SimNet      bool   `long:"simnet" description:"Use the simulation test network"`
+	SegNet      bool   `long:"segnet" description:"Use the segragated witness test network"`
+
+	ChanRefreshInterval time.Duration `long:"chanrefreshinterval" description:"Channels of other nodes which haven't been re-announced within this interval are pruned from the channel graph"`
+
+	TrickleDelay time.Duration `long:"trickledelay" description:"The interval at which updates to a channel are batched before being covered by a new commitment"`
+	MaxBatchSize int           `long:"maxbatchsize" description:"The number of batched updates to a channel which are committed immediately, without waiting for the trickle delay"`
+
+	StaticFeeRate   int64 `long:"staticfeerate" description:"If set, the fee rate in satoshis per byte paid by all on-chain transactions, in lieu of fee estimation"`
+	FallbackFeeRate int64 `long:"fallbackfeerate" description:"The fee rate in satoshis per byte used when the chain backend is unable to produce a fee estimate"`
+
+	FinalCLTVDelta uint32 `long:"finalcltvdelta" description:"The minimum number of blocks an HTLC paying one of our invoices must remain valid for"`
+
+	Bitcoind *bitcoindConfig `group:"bitcoind" namespace:"bitcoind"`
 }
 
 // loadConfig initializes and parses the config using a config file and command
@@ -81,19 +121,29 @@ type config struct {
 // 	4) Parse CLI options and overwrite/add any specified options
 func loadConfig() (*config, error) {
 	defaultCfg := config{
-		ConfigFile: defaultConfigFile,
-		DataDir:    defaultDataDir,
-		DebugLevel: defaultLogLevel,
-		LogDir:     defaultLogDir,
-		PeerPort:   defaultPeerPort,
-		RPCPort:    defaultRPCPort,
-		SPVMode:    defaultSPVMode,
-		RPCHost:    defaultRPCHost,
-		RPCUser:    defaultRPCUser,
-		RPCPass:    defaultRPCPass,
-		RPCCert:    defaultRPCCertFile,
-		RPCKey:     defaultRPCKeyFile,
-		SPVHostAdr: defaultSPVHostAdr,
+		ConfigFile:          defaultConfigFile,
+		DataDir:             defaultDataDir,
+		DebugLevel:          defaultLogLevel,
+		LogDir:              defaultLogDir,
+		PeerPort:            defaultPeerPort,
+		RPCPort:             defaultRPCPort,
+		SPVMode:             defaultSPVMode,
+		RPCHost:             defaultRPCHost,
+		RPCUser:             defaultRPCUser,
+		RPCPass:             defaultRPCPass,
+		RPCCert:             defaultRPCCertFile,
+		RPCKey:              defaultRPCKeyFile,
+		SPVHostAdr:          defaultSPVHostAdr,
+		SPVBirthday:         defaultSPVBirthday,
+		ChanRefreshInterval: defaultChanRefreshInterval,
+		TrickleDelay:        defaultTrickleDelay,
+		MaxBatchSize:        defaultMaxBatchSize,
+		FallbackFeeRate:     defaultFallbackFeeRate,
+		FinalCLTVDelta:      defaultFinalCLTVDelta,
+		Bitcoind: &bitcoindConfig{
+			RPCHost:      defaultBitcoindRPCHost,
+			PollInterval: defaultBitcoindPollInterval,
+		},
 	}
 
 	// Pre-parse the command line options to pick up an alternative config
@@ -162,6 +212,46 @@ func loadConfig() (*config, error) {
 		return nil, err
 	}
 
+	// A non-positive poll interval would leave the bitcoind notifier
+	// unable to ever detect a new block.
+	if cfg.Bitcoind.Active && cfg.Bitcoind.PollInterval <= 0 {
+		str := "%s: The bitcoind poll interval must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
+	// Without a positive refresh interval, every channel of the other
+	// nodes within the graph would immediately be considered stale.
+	if cfg.ChanRefreshInterval <= 0 {
+		str := "%s: The channel refresh interval must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
+	// Commitments are only ever signed once the trickle timer ticks, or
+	// the batch is full, so both must be positive.
+	if cfg.TrickleDelay <= 0 || cfg.MaxBatchSize <= 0 {
+		str := "%s: The trickle delay and max batch size must be " +
+			"positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
+	if cfg.StaticFeeRate < 0 || cfg.FallbackFeeRate <= 0 {
+		str := "%s: The static fee rate may not be negative, and " +
+			"the fallback fee rate must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, err
+	}
+
 	// Validate profile port number
 	if cfg.Profile != "" {
 		profilePort, err := strconv.Atoi(cfg.Profile)
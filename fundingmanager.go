@@ -1,25 +1,80 @@
 package main
 
 import (
+	"fmt"
 	"sync"
 	"sync/atomic"
 
+	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/roasbeef/btcd/btcec"
 	"github.com/roasbeef/btcd/txscript"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcutil"
-
-	"github.com/BitfuryLightning/tools/rt"
-	"github.com/BitfuryLightning/tools/rt/graph"
 )
 
 const (
 	// TODO(roasbeef): tune
 	msgBufferSize = 50
+
+	// defaultDustLimit is the dust limit of our commitment transactions.
+	// Outputs below this value, the dust threshold of a P2PKH output at
+	// the default minimum relay fee, would render the commitment
+	// transaction non-standard.
+	defaultDustLimit = btcutil.Amount(546)
+
+	// defaultMaxPendingHTLCs is the maximum number of HTLCs we'll accept
+	// from the remote node at once. Each side is allotted half of the
+	// total number of HTLCs permitted on a channel.
+	defaultMaxPendingHTLCs = lnwallet.MaxPendingPayments / 2
+
+	// defaultMinHTLC is the smallest HTLC we'll accept from the remote
+	// node.
+	defaultMinHTLC = btcutil.Amount(1)
+
+	// defaultReserveDivisor determines the channel reserve we require of
+	// the remote node, as a fraction of the channel's capacity.
+	defaultReserveDivisor = 100
 )
 
+// defaultConstraints returns the flow-control limits we impose on the HTLCs
+// added by the remote node to a channel of the passed capacity, along with the
+// dust limit of our commitment transactions.
+func defaultConstraints(capacity btcutil.Amount) channeldb.ChannelConstraints {
+	return channeldb.ChannelConstraints{
+		DustLimit:        defaultDustLimit,
+		MaxPendingHTLCs:  defaultMaxPendingHTLCs,
+		MaxValueInFlight: capacity,
+		MinHTLC:          defaultMinHTLC,
+		ChannelReserve:   capacity / defaultReserveDivisor,
+	}
+}
+
+// validateConstraints checks that the flow-control limits proposed by the
+// remote node for a channel of the passed capacity are sane. We refuse limits
+// which would leave us unable to ever use the channel.
+func validateConstraints(c channeldb.ChannelConstraints,
+	capacity btcutil.Amount) error {
+
+	switch {
+	case c.DustLimit > capacity:
+		return fmt.Errorf("dust limit of %v exceeds channel capacity "+
+			"of %v", c.DustLimit, capacity)
+	case c.MaxPendingHTLCs > lnwallet.MaxPendingPayments:
+		return fmt.Errorf("max pending htlcs of %v exceeds limit of %v",
+			c.MaxPendingHTLCs, lnwallet.MaxPendingPayments)
+	case c.MinHTLC > capacity:
+		return fmt.Errorf("min htlc of %v exceeds channel capacity "+
+			"of %v", c.MinHTLC, capacity)
+	case c.ChannelReserve > capacity/2:
+		return fmt.Errorf("channel reserve of %v exceeds half the "+
+			"channel capacity of %v", c.ChannelReserve, capacity)
+	}
+
+	return nil
+}
+
 // reservationWithCtx encapsulates a pending channel reservation. This wrapper
 // struct is used internally within the funding manager to track and progress
 // the funding workflow initiated by incoming/outgoing meethods from the target
@@ -114,6 +169,10 @@ type fundingManager struct {
 	// wallet is the daemon's internal Lightning enabled wallet.
 	wallet *lnwallet.LightningWallet
 
+	// feeEstimator is used to select the fee rate paid by the initial
+	// commitment transactions of channels we initiate.
+	feeEstimator lnwallet.FeeEstimator
+
 	// fundingMsgs is a channel which receives wrapped wire messages
 	// related to funding workflow from outside peers.
 	fundingMsgs chan interface{}
@@ -132,10 +191,13 @@ type fundingManager struct {
 
 // newFundingManager creates and initializes a new instance of the
 // fundingManager.
-func newFundingManager(w *lnwallet.LightningWallet) *fundingManager {
+func newFundingManager(w *lnwallet.LightningWallet,
+	feeEstimator lnwallet.FeeEstimator) *fundingManager {
+
 	return &fundingManager{
 		activeReservations: make(map[int32]pendingChannels),
 		wallet:             w,
+		feeEstimator:       feeEstimator,
 		fundingMsgs:        make(chan interface{}, msgBufferSize),
 		fundingRequests:    make(chan *initFundingMsg, msgBufferSize),
 		queries:            make(chan interface{}, 1),
@@ -309,9 +371,11 @@ func (f *fundingManager) handleFundingRequest(fmsg *fundingRequestMsg) {
 	// has insufficient resources to create the channel, then the reservation
 	// attempt may be rejected. Note that since we're on the responding
 	// side of a single funder workflow, we don't commit any funds to the
-	// channel ourselves.
+	// channel ourselves. The initial commitment transactions pay the fee
+	// rate proposed by the initiator, who also pays the fee.
 	// TODO(roasbeef): passing num confs 1 is irrelevant here, make signed?
-	reservation, err := f.wallet.InitChannelReservation(amt, 0, fmsg.peer.lightningID, 1, delay)
+	reservation, err := f.wallet.InitChannelReservation(amt, 0,
+		fmsg.peer.lightningID, 1, delay, msg.FeePerKb, 0)
 	if err != nil {
 		// TODO(roasbeef): push ErrorGeneric message
 		fndgLog.Errorf("Unable to initialize reservation: %v", err)
@@ -345,7 +409,21 @@ func (f *fundingManager) handleFundingRequest(fmsg *fundingRequestMsg) {
 		CommitKey:       msg.CommitmentKey,
 		DeliveryAddress: addrs[0],
 		CsvDelay:        delay,
+		Constraints: channeldb.ChannelConstraints{
+			DustLimit:        msg.DustLimit,
+			MaxPendingHTLCs:  msg.MaxPendingHTLCs,
+			MaxValueInFlight: msg.MaxValueInFlight,
+			MinHTLC:          msg.MinHTLC,
+			ChannelReserve:   msg.ChannelReserve,
+		},
 	}
+	if err := validateConstraints(contribution.Constraints, amt); err != nil {
+		fndgLog.Errorf("Unacceptable channel constraints from %v: %v",
+			fmsg.peer, err)
+		fmsg.peer.Disconnect()
+		return
+	}
+	reservation.SetOurConstraints(defaultConstraints(amt))
 	if err := reservation.ProcessSingleContribution(contribution); err != nil {
 		fndgLog.Errorf("unable to add contribution reservation: %v", err)
 		fmsg.peer.Disconnect()
@@ -362,10 +440,13 @@ func (f *fundingManager) handleFundingRequest(fmsg *fundingRequestMsg) {
 		fndgLog.Errorf("unable to convert address to pkscript: %v", err)
 		return
 	}
+	ourConstraints := ourContribution.Constraints
 	fundingResp := lnwire.NewSingleFundingResponse(msg.ChannelID,
 		ourContribution.RevocationKey, ourContribution.CommitKey,
 		ourContribution.MultiSigKey, ourContribution.CsvDelay,
-		deliveryScript)
+		ourConstraints.DustLimit, ourConstraints.MaxPendingHTLCs,
+		ourConstraints.MaxValueInFlight, ourConstraints.MinHTLC,
+		ourConstraints.ChannelReserve, deliveryScript)
 
 	fmsg.peer.queueMsg(fundingResp, nil)
 }
@@ -405,6 +486,22 @@ func (f *fundingManager) handleFundingResponse(fmsg *fundingResponseMsg) {
 		DeliveryAddress: addrs[0],
 		RevocationKey:   msg.RevocationKey,
 		CsvDelay:        msg.CsvDelay,
+		Constraints: channeldb.ChannelConstraints{
+			DustLimit:        msg.DustLimit,
+			MaxPendingHTLCs:  msg.MaxPendingHTLCs,
+			MaxValueInFlight: msg.MaxValueInFlight,
+			MinHTLC:          msg.MinHTLC,
+			ChannelReserve:   msg.ChannelReserve,
+		},
+	}
+	// As we're the sole funder of the channel, our contribution makes up
+	// its entire capacity.
+	capacity := resCtx.reservation.OurContribution().FundingAmount
+	if err := validateConstraints(contribution.Constraints, capacity); err != nil {
+		fndgLog.Errorf("Unacceptable channel constraints from %v: %v",
+			sourcePeer, err)
+		fmsg.peer.Disconnect()
+		return
 	}
 	if err := resCtx.reservation.ProcessContribution(contribution); err != nil {
 		fndgLog.Errorf("Unable to process contribution from %v: %v",
@@ -617,15 +714,10 @@ func (f *fundingManager) handleFundingOpen(fmsg *fundingOpenMsg) {
 		resCtx.reservation.FundingOutpoint, fmsg.peer.id)
 
 	// ROUTING ADDED
-	capacity := float64(resCtx.reservation.OurContribution().FundingAmount + resCtx.reservation.TheirContribution().FundingAmount)
-	fmsg.peer.server.routingMgr.AddChannel(
-		graph.NewID(fmsg.peer.server.lightningID),
-		graph.NewID([32]byte(fmsg.peer.lightningID)),
-		graph.NewEdgeID(resCtx.reservation.FundingOutpoint().String()),
-		&rt.ChannelInfo{
-			Cpt:capacity,
-		},
-	)
+	capacity := resCtx.reservation.OurContribution().FundingAmount +
+		resCtx.reservation.TheirContribution().FundingAmount
+	fmsg.peer.server.addChannelToGraph(fmsg.peer,
+		resCtx.reservation.FundingOutpoint(), capacity)
 	fmsg.peer.newChannels <- openChan
 }
 
@@ -660,16 +752,28 @@ func (f *fundingManager) handleInitFundingMsg(msg *initFundingMsg) {
 	fndgLog.Infof("Initiating fundingRequest(localAmt=%v, remoteAmt=%v, "+
 		"capacity=%v, numConfs=%v)", localAmt, remoteAmt, capacity, numConfs)
 
+	// As we're the initiator of this channel, we pay the fee of the
+	// initial commitment transactions, so we query our fee estimator for
+	// the rate they should pay.
+	feePerByte, err := f.feeEstimator.EstimateFeePerByte(commitConfTarget)
+	if err != nil {
+		msg.resp <- nil
+		msg.err <- err
+		return
+	}
+	commitFeePerKb := feePerByte * 1000
+
 	// Initialize a funding reservation with the local wallet. If the
 	// wallet doesn't have enough funds to commit to this channel, then
 	// the request will fail, and be aborted.
 	reservation, err := f.wallet.InitChannelReservation(capacity, localAmt,
-		nodeID, uint16(numConfs), 4)
+		nodeID, uint16(numConfs), 4, commitFeePerKb, msg.fundingFeeRate)
 	if err != nil {
 		msg.resp <- nil
 		msg.err <- err
 		return
 	}
+	reservation.SetOurConstraints(defaultConstraints(capacity))
 
 	// Obtain a new pending channel ID which is used to track this
 	// reservation throughout its lifetime.
@@ -712,9 +816,14 @@ func (f *fundingManager) handleInitFundingMsg(msg *initFundingMsg) {
 		chanID,
 		msg.channelType,
 		msg.coinType,
-		0, // TODO(roasbeef): grab from fee estimation model
+		commitFeePerKb,
 		contribution.FundingAmount,
 		contribution.CsvDelay,
+		contribution.Constraints.DustLimit,
+		contribution.Constraints.MaxPendingHTLCs,
+		contribution.Constraints.MaxValueInFlight,
+		contribution.Constraints.MinHTLC,
+		contribution.Constraints.ChannelReserve,
 		contribution.CommitKey,
 		contribution.MultiSigKey,
 		deliveryScript,
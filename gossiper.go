@@ -0,0 +1,1277 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/fastsha256"
+	"github.com/lightningnetwork/lnd/chainntfs"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+)
+
+const (
+	// trickleDelay is the interval at which the batch of announcements
+	// accepted since the last trickle is relayed to our peers.
+	trickleDelay = time.Second * 5
+
+	// rateLimitInterval is the length of the window within which at most
+	// maxPeerAnnouncements announcements are processed from each peer.
+	rateLimitInterval = time.Minute
+
+	// maxPeerAnnouncements is the maximum number of announcements
+	// processed from a single peer within each rateLimitInterval. Any
+	// further announcements within the window are dropped.
+	maxPeerAnnouncements = 500
+
+	// graphPruneInterval is the interval at which the channel graph is
+	// scanned for channels which haven't been refreshed recently enough.
+	graphPruneInterval = time.Hour
+
+	// rebroadcastsPerRefresh is the number of times our own channels are
+	// re-announced within each refresh interval, ensuring other nodes
+	// don't prune them even if some of the re-announcements are lost.
+	rebroadcastsPerRefresh = 3
+
+	// maxPendingProofs is the maximum number of announcement signatures
+	// received for channels we don't yet know of which are kept, awaiting
+	// the opening of the channel.
+	maxPendingProofs = 100
+)
+
+// announcementMsg pairs a channel, channel update, or node announcement with
+// the peer it was received from.
+type announcementMsg struct {
+	msg  lnwire.Message
+	peer *peer
+}
+
+// announcementKey uniquely identifies an announcement within a batch. Channel
+// announcements are identified by their funding outpoint, node announcements
+// by the ID of the announcing node, and channel updates by both, so only the
+// latest announcement of each is relayed.
+type announcementKey struct {
+	chanPoint wire.OutPoint
+	nodeID    [32]byte
+}
+
+// localChannelMsg requests the exchange of announcement signatures with the
+// peer at the other end of one of our channels.
+type localChannelMsg struct {
+	peer    *peer
+	channel *channeldb.OpenChannel
+}
+
+// channelProof tracks the assembly of the announcement of one of our own
+// channels. Either half of the signatures may be known first: ours once the
+// channel is opened, and the remote node's once its AnnounceSignatures
+// message is received.
+type channelProof struct {
+	// ann is the announcement, carrying our half of the signatures. It's
+	// nil until we've signed the announcement.
+	ann *lnwire.ChannelAnnouncement
+
+	// weAreFirst is true if our node is the first node of the
+	// announcement.
+	weAreFirst bool
+
+	// remoteSigs is the remote node's half of the signatures. It's nil
+	// until received.
+	remoteSigs *lnwire.AnnounceSignatures
+}
+
+// gossiper is the subsystem responsible for maintaining the channel graph
+// with the announcements received from our peers. Each announcement is
+// verified before being added to the graph, and relayed to all our other
+// peers in batches. Additionally, the gossiper prunes any channels which
+// have been closed, or haven't been re-announced within the refresh
+// interval.
+type gossiper struct {
+	started  int32 // atomic
+	shutdown int32 // atomic
+
+	server   *server
+	notifier chainntnfs.ChainNotifier
+
+	// refreshInterval is the maximum time a channel may go without being
+	// re-announced before it's pruned from the graph. Our own channels
+	// are never pruned due to staleness.
+	refreshInterval time.Duration
+
+	// maxPeerAnnouncements is the number of announcements accepted from
+	// each peer within a rate limiting window. It's initialized to the
+	// package default, and only overridden by tests.
+	maxPeerAnnouncements int
+
+	announcements chan *announcementMsg
+	localChannels chan *localChannelMsg
+	newChannels   chan *wire.OutPoint
+	spentChannels chan *wire.OutPoint
+
+	// pendingProofs tracks the announcements of our own channels which
+	// are being assembled. It's only accessed by the networkHandler
+	// goroutine.
+	pendingProofs map[wire.OutPoint]*channelProof
+
+	// watchedChannels is the set of channels whose funding outputs are
+	// being watched for a spend. It's only accessed by the
+	// networkHandler goroutine.
+	watchedChannels map[wire.OutPoint]struct{}
+
+	// pendingBatch holds the announcements to be relayed at the next
+	// trickle, and peerCounts the number of announcements received from
+	// each peer within the current rate limiting window. They're only
+	// accessed by the networkHandler goroutine.
+	pendingBatch map[announcementKey]*announcementMsg
+	peerCounts   map[int32]int
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// newGossiper creates a new gossiper which prunes channels that haven't been
+// re-announced within the passed refresh interval.
+func newGossiper(s *server, notifier chainntnfs.ChainNotifier,
+	refreshInterval time.Duration) *gossiper {
+
+	return &gossiper{
+		server:               s,
+		notifier:             notifier,
+		refreshInterval:      refreshInterval,
+		maxPeerAnnouncements: maxPeerAnnouncements,
+		announcements:        make(chan *announcementMsg, 100),
+		localChannels:        make(chan *localChannelMsg, 10),
+		pendingProofs:        make(map[wire.OutPoint]*channelProof),
+		newChannels:          make(chan *wire.OutPoint, 10),
+		spentChannels:        make(chan *wire.OutPoint, 10),
+		watchedChannels:      make(map[wire.OutPoint]struct{}),
+		pendingBatch:         make(map[announcementKey]*announcementMsg),
+		peerCounts:           make(map[int32]int),
+		quit:                 make(chan struct{}),
+	}
+}
+
+// Start launches the gossiper's helper goroutines, watching each channel
+// within the graph for closure.
+func (g *gossiper) Start() error {
+	if !atomic.CompareAndSwapInt32(&g.started, 0, 1) {
+		return nil
+	}
+
+	var chanPoints []wire.OutPoint
+	err := g.server.chanDB.ForEachChannelEdge(func(edge *channeldb.ChannelEdge) error {
+		chanPoints = append(chanPoints, edge.ChannelPoint)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	g.wg.Add(1)
+	go g.networkHandler(chanPoints)
+
+	return nil
+}
+
+// Stop signals all the gossiper's goroutines to exit, then waits until
+// they've done so.
+func (g *gossiper) Stop() error {
+	if !atomic.CompareAndSwapInt32(&g.shutdown, 0, 1) {
+		return nil
+	}
+
+	close(g.quit)
+	g.wg.Wait()
+
+	return nil
+}
+
+// ProcessAnnouncement hands off a channel, channel update, or node
+// announcement received from the target peer to the gossiper. The announcement is only added to
+// the graph, and relayed once it has been verified.
+func (g *gossiper) ProcessAnnouncement(msg lnwire.Message, p *peer) {
+	select {
+	case g.announcements <- &announcementMsg{msg, p}:
+	case <-g.quit:
+	}
+}
+
+// AnnounceChannelPolicy signs, and broadcasts an announcement of the
+// forwarding policy we apply to HTLCs flowing out across the target channel.
+// The policy is also applied to our own edge within the channel graph. If
+// the channel hasn't been announced yet, then the policy is only broadcast
+// along with the channel's announcement.
+func (g *gossiper) AnnounceChannelPolicy(chanPoint *wire.OutPoint,
+	policy *channeldb.ForwardingPolicy) error {
+
+	update, err := g.signChannelUpdate(chanPoint, policy)
+	if err != nil {
+		return err
+	}
+
+	g.ProcessAnnouncement(update, nil)
+	return nil
+}
+
+// signChannelUpdate returns a channel update announcing the passed
+// forwarding policy for our end of the target channel, signed with our
+// identity key.
+func (g *gossiper) signChannelUpdate(chanPoint *wire.OutPoint,
+	policy *channeldb.ForwardingPolicy) (*lnwire.ChannelUpdateAnnouncement, error) {
+
+	identityKey := g.server.identityPriv
+	update := &lnwire.ChannelUpdateAnnouncement{
+		ChannelPoint:  chanPoint,
+		Timestamp:     uint32(time.Now().Unix()),
+		NodeID:        identityKey.PubKey(),
+		TimeLockDelta: policy.TimeLockDelta,
+		MinHTLC:       policy.MinHTLC,
+		MaxHTLC:       policy.MaxHTLC,
+		FeeBase:       policy.BaseFee,
+		FeeRate:       policy.FeeRate,
+	}
+
+	digest, err := update.DataToSign()
+	if err != nil {
+		return nil, err
+	}
+	update.Signature, err = identityKey.Sign(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// signChannelPolicy returns a freshly signed channel update announcing the
+// stored forwarding policy of the target channel, or the default policy if
+// none has been set.
+func (g *gossiper) signChannelPolicy(chanPoint *wire.OutPoint) (*lnwire.ChannelUpdateAnnouncement, error) {
+	policy, err := g.server.chanDB.FetchForwardingPolicy(chanPoint)
+	switch {
+	case err == channeldb.ErrForwardingPolicyNotFound:
+		policy = &defaultForwardingPolicy
+	case err != nil:
+		return nil, err
+	}
+
+	return g.signChannelUpdate(chanPoint, policy)
+}
+
+// AnnounceChannel begins the exchange of announcement signatures with the
+// peer at the other end of one of our channels. Once the signatures of both
+// nodes are known, the complete channel announcement is broadcast, along
+// with a fresh announcement of our own node.
+func (g *gossiper) AnnounceChannel(p *peer, channel *channeldb.OpenChannel) {
+	select {
+	case g.localChannels <- &localChannelMsg{p, channel}:
+	case <-g.quit:
+	}
+}
+
+// PeerConnected resumes the exchange of announcement signatures for each of
+// our channels with the newly connected peer which hasn't yet been
+// announced, such as those opened shortly before a restart.
+func (g *gossiper) PeerConnected(p *peer) {
+	if atomic.LoadInt32(&g.shutdown) != 0 {
+		return
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		chanDB := g.server.chanDB
+		channels, err := chanDB.FetchOpenChannels(&p.lightningID)
+		if err != nil {
+			gsprLog.Errorf("Unable to fetch channels with %v: %v",
+				p, err)
+			return
+		}
+
+		for _, channel := range channels {
+			_, err := chanDB.FetchChannelAnnouncement(channel.ChanID)
+			if err != channeldb.ErrAnnouncementNotFound {
+				continue
+			}
+
+			g.AnnounceChannel(p, channel)
+		}
+	}()
+}
+
+// WatchChannel instructs the gossiper to prune the channel identified by the
+// passed funding outpoint from the graph once the outpoint is spent.
+func (g *gossiper) WatchChannel(chanPoint *wire.OutPoint) {
+	select {
+	case g.newChannels <- chanPoint:
+	case <-g.quit:
+	}
+}
+
+// networkHandler is the gossiper's main event loop. It verifies incoming
+// announcements, relays the accepted ones in batches, and prunes closed, and
+// stale channels from the graph.
+//
+// NOTE: This MUST be run as a goroutine.
+func (g *gossiper) networkHandler(chanPoints []wire.OutPoint) {
+	defer g.wg.Done()
+
+	for _, chanPoint := range chanPoints {
+		g.watchChannel(chanPoint)
+	}
+
+	trickleTicker := time.NewTicker(trickleDelay)
+	defer trickleTicker.Stop()
+	rateLimitTicker := time.NewTicker(rateLimitInterval)
+	defer rateLimitTicker.Stop()
+	pruneTicker := time.NewTicker(graphPruneInterval)
+	defer pruneTicker.Stop()
+	rebroadcastTicker := time.NewTicker(g.refreshInterval / rebroadcastsPerRefresh)
+	defer rebroadcastTicker.Stop()
+
+	for {
+		select {
+		case ann := <-g.announcements:
+			g.handleAnnouncement(ann)
+
+		case msg := <-g.localChannels:
+			chanAnn, err := g.initChannelProof(msg.peer, msg.channel)
+			if err != nil {
+				gsprLog.Errorf("Unable to sign announcement of "+
+					"ChannelPoint(%v): %v", msg.channel.ChanID, err)
+				continue
+			}
+			g.addOwnAnnouncements(chanAnn)
+
+		case chanPoint := <-g.newChannels:
+			g.watchChannel(*chanPoint)
+
+		case chanPoint := <-g.spentChannels:
+			gsprLog.Infof("ChannelPoint(%v) has been closed, pruning "+
+				"from channel graph", chanPoint)
+			if err := g.pruneChannel(*chanPoint); err != nil {
+				gsprLog.Errorf("Unable to prune ChannelPoint(%v): "+
+					"%v", chanPoint, err)
+			}
+
+		case <-trickleTicker.C:
+			if len(g.pendingBatch) == 0 {
+				continue
+			}
+
+			g.relayBatch(g.pendingBatch)
+			g.pendingBatch = make(map[announcementKey]*announcementMsg)
+
+		case <-rateLimitTicker.C:
+			g.peerCounts = make(map[int32]int)
+
+		case <-pruneTicker.C:
+			if err := g.pruneStaleChannels(); err != nil {
+				gsprLog.Errorf("Unable to prune stale channels: %v",
+					err)
+			}
+
+		case <-rebroadcastTicker.C:
+			if err := g.refreshOwnChannels(); err != nil {
+				gsprLog.Errorf("Unable to re-announce our channels: "+
+					"%v", err)
+			}
+
+		case <-g.quit:
+			return
+		}
+	}
+}
+
+// handleAnnouncement processes an announcement received from a peer, or
+// created locally, adding it to the pending batch if it should be relayed.
+// Announcements from peers which have exceeded their rate limit are dropped.
+func (g *gossiper) handleAnnouncement(ann *announcementMsg) {
+	if ann.peer != nil {
+		g.peerCounts[ann.peer.id]++
+		if g.peerCounts[ann.peer.id] > g.maxPeerAnnouncements {
+			gsprLog.Warnf("Peer %v exceeded announcement rate "+
+				"limit, dropping %T", ann.peer, ann.msg)
+			return
+		}
+	}
+
+	// The remote half of the signatures of one of our own channels is
+	// never relayed, but may complete the channel's announcement.
+	if sigs, ok := ann.msg.(*lnwire.AnnounceSignatures); ok {
+		chanAnn, err := g.processAnnounceSignatures(sigs, ann.peer)
+		if err != nil {
+			gsprLog.Errorf("Rejecting announcement signatures "+
+				"from %v: %v", ann.peer, err)
+			return
+		}
+		g.addOwnAnnouncements(chanAnn)
+		return
+	}
+
+	key, relay, err := g.processAnnouncement(ann.msg)
+	if err != nil {
+		gsprLog.Errorf("Rejecting announcement from %v: %v", ann.peer,
+			err)
+		return
+	}
+	if relay {
+		g.pendingBatch[key] = ann
+	}
+}
+
+// initChannelProof signs our half of the announcement of the passed channel,
+// sending our signatures to the peer at the other end of the channel. If the
+// peer's signatures are already known, then the complete announcement is
+// returned. Channels which have already been announced are ignored.
+func (g *gossiper) initChannelProof(p *peer,
+	channel *channeldb.OpenChannel) (*lnwire.ChannelAnnouncement, error) {
+
+	chanPoint := *channel.ChanID
+
+	_, err := g.server.chanDB.FetchChannelAnnouncement(&chanPoint)
+	switch {
+	case err == nil:
+		return nil, nil
+	case err != channeldb.ErrAnnouncementNotFound:
+		return nil, err
+	}
+
+	proof, ok := g.pendingProofs[chanPoint]
+	if ok && proof.ann != nil {
+		return nil, nil
+	}
+	if !ok {
+		proof = &channelProof{}
+	}
+
+	// The node IDs of the announcement must be in canonical order, so
+	// we'll determine which of the two nodes we are.
+	nodeKey := g.server.identityPriv
+	bitcoinKey := channel.OurMultiSigKey
+	proof.weAreFirst = bytes.Compare(
+		nodeKey.PubKey().SerializeCompressed(),
+		p.identityPub.SerializeCompressed()) == -1
+
+	ann := &lnwire.ChannelAnnouncement{
+		ChannelPoint: &chanPoint,
+	}
+	if proof.weAreFirst {
+		ann.FirstNodeID = nodeKey.PubKey()
+		ann.SecondNodeID = p.identityPub
+		ann.FirstBitcoinKey = bitcoinKey.PubKey()
+		ann.SecondBitcoinKey = channel.TheirMultiSigKey
+	} else {
+		ann.FirstNodeID = p.identityPub
+		ann.SecondNodeID = nodeKey.PubKey()
+		ann.FirstBitcoinKey = channel.TheirMultiSigKey
+		ann.SecondBitcoinKey = bitcoinKey.PubKey()
+	}
+
+	digest, err := ann.DataToSign()
+	if err != nil {
+		return nil, err
+	}
+	nodeSig, err := nodeKey.Sign(digest)
+	if err != nil {
+		return nil, err
+	}
+	bitcoinSig, err := bitcoinKey.Sign(digest)
+	if err != nil {
+		return nil, err
+	}
+	if proof.weAreFirst {
+		ann.FirstNodeSig = nodeSig
+		ann.FirstBitcoinSig = bitcoinSig
+	} else {
+		ann.SecondNodeSig = nodeSig
+		ann.SecondBitcoinSig = bitcoinSig
+	}
+	proof.ann = ann
+	g.pendingProofs[chanPoint] = proof
+
+	g.sendToPeer(p, &lnwire.AnnounceSignatures{
+		ChannelPoint:     &chanPoint,
+		NodeSignature:    nodeSig,
+		BitcoinSignature: bitcoinSig,
+	})
+
+	if proof.remoteSigs == nil {
+		return nil, nil
+	}
+	return g.completeChannelProof(proof)
+}
+
+// processAnnounceSignatures handles the remote half of the signatures of the
+// announcement of one of our channels. If our own half is already known,
+// then the complete announcement is returned. If the channel has already
+// been announced, then the peer is sent the complete announcement instead,
+// as it evidently hasn't assembled it itself.
+func (g *gossiper) processAnnounceSignatures(sigs *lnwire.AnnounceSignatures,
+	p *peer) (*lnwire.ChannelAnnouncement, error) {
+
+	chanPoint := *sigs.ChannelPoint
+	chanDB := g.server.chanDB
+
+	chanAnn, err := chanDB.FetchChannelAnnouncement(&chanPoint)
+	switch {
+	case err == nil:
+		g.sendToPeer(p, chanAnn)
+		return nil, nil
+	case err != channeldb.ErrAnnouncementNotFound:
+		return nil, err
+	}
+
+	proof, ok := g.pendingProofs[chanPoint]
+	if ok && proof.ann != nil {
+		proof.remoteSigs = sigs
+		return g.completeChannelProof(proof)
+	}
+
+	// Our half of the signatures isn't yet known. If the channel is
+	// already open, then we'll sign it now, otherwise the signatures are
+	// kept until the channel is opened.
+	channels, err := chanDB.FetchOpenChannels(&p.lightningID)
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		if *channel.ChanID != chanPoint {
+			continue
+		}
+
+		g.pendingProofs[chanPoint] = &channelProof{remoteSigs: sigs}
+		return g.initChannelProof(p, channel)
+	}
+
+	if !ok && len(g.pendingProofs) >= maxPendingProofs {
+		return nil, fmt.Errorf("too many pending announcements, "+
+			"dropping signatures for ChannelPoint(%v)", chanPoint)
+	}
+	g.pendingProofs[chanPoint] = &channelProof{remoteSigs: sigs}
+
+	return nil, nil
+}
+
+// completeChannelProof adds the remote half of the signatures to the
+// announcement of the passed proof, verifying and storing the complete
+// announcement.
+func (g *gossiper) completeChannelProof(
+	proof *channelProof) (*lnwire.ChannelAnnouncement, error) {
+
+	ann := proof.ann
+	if proof.weAreFirst {
+		ann.SecondNodeSig = proof.remoteSigs.NodeSignature
+		ann.SecondBitcoinSig = proof.remoteSigs.BitcoinSignature
+	} else {
+		ann.FirstNodeSig = proof.remoteSigs.NodeSignature
+		ann.FirstBitcoinSig = proof.remoteSigs.BitcoinSignature
+	}
+
+	// Invalid remote signatures are discarded, so that valid ones may
+	// still be received.
+	if err := verifyChannelAnnouncement(ann); err != nil {
+		proof.remoteSigs = nil
+		return nil, err
+	}
+
+	if err := g.server.chanDB.PutChannelAnnouncement(ann); err != nil {
+		return nil, err
+	}
+	delete(g.pendingProofs, *ann.ChannelPoint)
+
+	gsprLog.Infof("Assembled announcement of ChannelPoint(%v)",
+		ann.ChannelPoint)
+
+	return ann, nil
+}
+
+// addOwnAnnouncements adds the passed announcement of one of our own
+// channels to the pending batch, along with a freshly signed channel update
+// carrying our current forwarding policy, and a fresh announcement of our own
+// node. Nodes only accept the announcement of a node with a known channel,
+// so our node is re-announced with each new channel.
+func (g *gossiper) addOwnAnnouncements(chanAnn *lnwire.ChannelAnnouncement) {
+	if chanAnn == nil {
+		return
+	}
+	g.pendingBatch[announcementKey{chanPoint: *chanAnn.ChannelPoint}] =
+		&announcementMsg{msg: chanAnn}
+
+	update, err := g.signChannelPolicy(chanAnn.ChannelPoint)
+	if err != nil {
+		gsprLog.Errorf("Unable to sign policy of ChannelPoint(%v): %v",
+			chanAnn.ChannelPoint, err)
+		return
+	}
+	key, relay, err := g.processAnnouncement(update)
+	if err != nil {
+		gsprLog.Errorf("Unable to update policy of ChannelPoint(%v): "+
+			"%v", chanAnn.ChannelPoint, err)
+		return
+	}
+	if relay {
+		g.pendingBatch[key] = &announcementMsg{msg: update}
+	}
+
+	nodeAnn, err := g.selfAnnouncement()
+	if err != nil {
+		gsprLog.Errorf("Unable to announce our node: %v", err)
+		return
+	}
+	g.pendingBatch[announcementKey{nodeID: g.server.lightningID}] =
+		&announcementMsg{msg: nodeAnn}
+}
+
+// refreshOwnChannels re-announces each of our announced channels, along with
+// its current forwarding policy, and our own node. As other nodes prune
+// channels whose updates grow stale, our channels are periodically
+// re-announced. The channel announcements themselves are only accepted by
+// nodes which missed them, as they carry no timestamp.
+func (g *gossiper) refreshOwnChannels() error {
+	chanAnns, err := g.server.chanDB.FetchAllChannelAnnouncements()
+	if err != nil {
+		return err
+	}
+	if len(chanAnns) == 0 {
+		return nil
+	}
+
+	for _, chanAnn := range chanAnns {
+		g.addOwnAnnouncements(chanAnn)
+	}
+
+	gsprLog.Debugf("Re-announcing %v of our channels", len(chanAnns))
+
+	return nil
+}
+
+// selfAnnouncement signs a fresh announcement of our own node, applying it to
+// our node within the channel graph.
+func (g *gossiper) selfAnnouncement() (*lnwire.NodeAnnouncement, error) {
+	nodeKey := g.server.identityPriv
+	ann := &lnwire.NodeAnnouncement{
+		Timestamp: uint32(time.Now().Unix()),
+		NodeID:    nodeKey.PubKey(),
+		Addresses: externalAddrs(),
+	}
+
+	digest, err := ann.DataToSign()
+	if err != nil {
+		return nil, err
+	}
+	ann.Signature, err = nodeKey.Sign(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	chanDB := g.server.chanDB
+	node, err := chanDB.FetchLightningNode(g.server.lightningID)
+	if err != nil {
+		return nil, err
+	}
+	node.PubKey = ann.NodeID
+	node.LastUpdate = time.Unix(int64(ann.Timestamp), 0)
+	node.Addresses = ann.Addresses
+	if err := chanDB.AddLightningNode(node); err != nil {
+		return nil, err
+	}
+
+	return ann, nil
+}
+
+// externalAddrs returns the addresses advertised within our node
+// announcement: each of the configured external IPs, accepting connections
+// on our peer port unless another port is given.
+func externalAddrs() []*net.TCPAddr {
+	if cfg == nil {
+		return nil
+	}
+
+	var addrs []*net.TCPAddr
+	for _, ip := range cfg.ExternalIPs {
+		if len(addrs) == lnwire.MaxNodeAddresses {
+			break
+		}
+
+		if _, _, err := net.SplitHostPort(ip); err != nil {
+			ip = net.JoinHostPort(ip, strconv.Itoa(cfg.PeerPort))
+		}
+		addr, err := net.ResolveTCPAddr("tcp", ip)
+		if err != nil {
+			gsprLog.Warnf("Unable to resolve external IP %v: %v",
+				ip, err)
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+
+	return addrs
+}
+
+// processAnnouncement verifies the passed announcement, and applies it to the
+// channel graph. The returned boolean indicates whether the announcement
+// carried any new information, and should therefore be relayed.
+func (g *gossiper) processAnnouncement(msg lnwire.Message) (announcementKey, bool, error) {
+	switch a := msg.(type) {
+	case *lnwire.ChannelAnnouncement:
+		key := announcementKey{chanPoint: *a.ChannelPoint}
+		relay, err := g.processChannelAnnouncement(a)
+		return key, relay, err
+
+	case *lnwire.NodeAnnouncement:
+		key := announcementKey{
+			nodeID: fastsha256.Sum256(a.NodeID.SerializeCompressed()),
+		}
+		relay, err := g.processNodeAnnouncement(a)
+		return key, relay, err
+
+	case *lnwire.ChannelUpdateAnnouncement:
+		key := announcementKey{
+			chanPoint: *a.ChannelPoint,
+			nodeID:    fastsha256.Sum256(a.NodeID.SerializeCompressed()),
+		}
+		relay, err := g.processChannelUpdate(a)
+		return key, relay, err
+
+	default:
+		return announcementKey{}, false, fmt.Errorf("unknown "+
+			"announcement type: %T", msg)
+	}
+}
+
+// processChannelAnnouncement verifies the signatures of the announcement, and
+// that its funding output exists on chain, paying to the announced multi-sig
+// keys. If so, an edge is added to the channel graph in each direction.
+// Re-announcements of known channels are ignored, as a channel announcement
+// carries no timestamp: channels are instead kept fresh by the timestamped
+// channel updates of their endpoints.
+func (g *gossiper) processChannelAnnouncement(a *lnwire.ChannelAnnouncement) (bool, error) {
+	chanDB := g.server.chanDB
+
+	edges, err := chanDB.FetchChannelEdges(a.ChannelPoint)
+	if err != nil && err != channeldb.ErrEdgeNotFound {
+		return false, err
+	}
+
+	selfKey := g.server.identityPriv.PubKey()
+	if a.FirstNodeID.IsEqual(selfKey) || a.SecondNodeID.IsEqual(selfKey) {
+		return g.processOwnChannelAnnouncement(a, edges)
+	}
+	if len(edges) != 0 {
+		return false, nil
+	}
+
+	if err := verifyChannelAnnouncement(a); err != nil {
+		return false, err
+	}
+
+	// With the signatures verified, we'll ensure the funding output is
+	// unspent, and is the p2wsh of the 2-of-2 multi-sig script of the two
+	// bitcoin keys. Otherwise the nodes may be announcing a channel which
+	// doesn't exist, or is owned by another pair of nodes. An SPV wallet
+	// has no view of the UTXO set, so it accepts the channel on the
+	// strength of its four signatures alone, recording its capacity as
+	// unknown.
+	var capacity btcutil.Amount
+	fundingOut, err := g.server.lnwallet.GetUtxo(a.ChannelPoint)
+	switch {
+	case err == lnwallet.ErrNoUtxoSet:
+		gsprLog.Debugf("Unable to verify funding output of "+
+			"ChannelPoint(%v) without a UTXO set, accepting on "+
+			"signatures alone", a.ChannelPoint)
+
+	case err != nil:
+		return false, fmt.Errorf("unable to fetch funding output %v: %v",
+			a.ChannelPoint, err)
+
+	default:
+		if err := verifyFundingOutput(a, fundingOut); err != nil {
+			return false, err
+		}
+		capacity = btcutil.Amount(fundingOut.Value)
+	}
+
+	node1, err := g.addGraphNode(a.FirstNodeID)
+	if err != nil {
+		return false, err
+	}
+	node2, err := g.addGraphNode(a.SecondNodeID)
+	if err != nil {
+		return false, err
+	}
+
+	// The forwarding policies of each direction aren't yet known, so both
+	// edges start out with an empty policy.
+	for _, nodes := range [][2][32]byte{{node1, node2}, {node2, node1}} {
+		edge := &channeldb.ChannelEdge{
+			ChannelPoint: *a.ChannelPoint,
+			From:         nodes[0],
+			To:           nodes[1],
+			Capacity:     capacity,
+			LastUpdate:   time.Now(),
+		}
+		if err := chanDB.AddChannelEdge(edge); err != nil {
+			return false, err
+		}
+	}
+
+	g.server.routingMgr.AddChannel(node1, node2, a.ChannelPoint, capacity)
+
+	g.watchChannel(*a.ChannelPoint)
+
+	gsprLog.Infof("Added ChannelPoint(%v) between %x and %x to channel "+
+		"graph", a.ChannelPoint, node1[:], node2[:])
+
+	return true, nil
+}
+
+// processOwnChannelAnnouncement handles an announcement of one of our own
+// channels, assembled by the other endpoint of the channel. We may have
+// missed assembling it ourselves, in which case it's verified, and stored so
+// that we may re-broadcast it. As we know the channel exists, its funding
+// output needn't be checked.
+func (g *gossiper) processOwnChannelAnnouncement(a *lnwire.ChannelAnnouncement,
+	edges []*channeldb.ChannelEdge) (bool, error) {
+
+	chanDB := g.server.chanDB
+
+	if len(edges) == 0 {
+		return false, fmt.Errorf("ChannelPoint(%v) isn't one of our "+
+			"channels", a.ChannelPoint)
+	}
+
+	_, err := chanDB.FetchChannelAnnouncement(a.ChannelPoint)
+	switch {
+	case err == nil:
+		return false, nil
+	case err != channeldb.ErrAnnouncementNotFound:
+		return false, err
+	}
+
+	if err := verifyChannelAnnouncement(a); err != nil {
+		return false, err
+	}
+	if err := chanDB.PutChannelAnnouncement(a); err != nil {
+		return false, err
+	}
+	delete(g.pendingProofs, *a.ChannelPoint)
+
+	gsprLog.Infof("Received announcement of our ChannelPoint(%v)",
+		a.ChannelPoint)
+
+	return true, nil
+}
+
+// processNodeAnnouncement verifies the signature of the announcement, and
+// updates the announcing node within the channel graph. Announcements are
+// only accepted from nodes which already have a verified channel within the
+// graph, and announcements no more recent than the last one received are
+// ignored.
+func (g *gossiper) processNodeAnnouncement(a *lnwire.NodeAnnouncement) (bool, error) {
+	chanDB := g.server.chanDB
+
+	nodeID := fastsha256.Sum256(a.NodeID.SerializeCompressed())
+	node, err := chanDB.FetchLightningNode(nodeID)
+	if err == channeldb.ErrGraphNodeNotFound {
+		return false, fmt.Errorf("node %x has no known channels",
+			nodeID[:])
+	} else if err != nil {
+		return false, err
+	}
+
+	timestamp := time.Unix(int64(a.Timestamp), 0)
+	if !timestamp.After(node.LastUpdate) {
+		gsprLog.Debugf("Ignoring stale announcement for node %x",
+			nodeID[:])
+		return false, nil
+	}
+
+	if err := verifyNodeAnnouncement(a); err != nil {
+		return false, err
+	}
+
+	node.PubKey = a.NodeID
+	node.LastUpdate = timestamp
+	node.Alias = a.Alias
+	node.Addresses = a.Addresses
+	if err := chanDB.AddLightningNode(node); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// processChannelUpdate verifies the signature of the announcement, and
+// applies the announced forwarding policy to the edge originating from the
+// announcing node. Announcements for channels which aren't within the graph
+// are rejected, and announcements older than the edge's current policy are
+// ignored. Updates of our own channels are only relayed once the channel
+// itself has been announced, as other nodes reject updates of unknown
+// channels. The current policy of a channel is announced along with the
+// channel.
+func (g *gossiper) processChannelUpdate(a *lnwire.ChannelUpdateAnnouncement) (bool, error) {
+	chanDB := g.server.chanDB
+
+	edges, err := chanDB.FetchChannelEdges(a.ChannelPoint)
+	if err == channeldb.ErrEdgeNotFound {
+		return false, fmt.Errorf("ChannelPoint(%v) isn't known",
+			a.ChannelPoint)
+	} else if err != nil {
+		return false, err
+	}
+
+	nodeID := fastsha256.Sum256(a.NodeID.SerializeCompressed())
+	var edge *channeldb.ChannelEdge
+	for _, e := range edges {
+		if e.From == nodeID {
+			edge = e
+		}
+	}
+	if edge == nil {
+		return false, fmt.Errorf("node %x isn't an endpoint of "+
+			"ChannelPoint(%v)", nodeID[:], a.ChannelPoint)
+	}
+
+	// As timestamps only have a resolution of a second, an announcement
+	// carrying the same timestamp as the edge's current policy is only
+	// accepted if it changes the policy. Until the edge's first update is
+	// received, its last update is the time the channel was added to the
+	// graph, so any update is accepted.
+	timestamp := time.Unix(int64(a.Timestamp), 0)
+	lastUpdate := edge.LastUpdate.Truncate(time.Second)
+	if edgeHasPolicy(edge) && (timestamp.Before(lastUpdate) ||
+		(timestamp.Equal(lastUpdate) && edgeMatchesUpdate(edge, a))) {
+
+		gsprLog.Debugf("Ignoring stale update for ChannelPoint(%v) "+
+			"from node %x", a.ChannelPoint, nodeID[:])
+		return false, nil
+	}
+
+	if err := verifyChannelUpdate(a); err != nil {
+		return false, err
+	}
+
+	edge.TimeLockDelta = a.TimeLockDelta
+	edge.MinHTLC = a.MinHTLC
+	edge.MaxHTLC = a.MaxHTLC
+	edge.FeeBase = a.FeeBase
+	edge.FeeRate = a.FeeRate
+	edge.LastUpdate = timestamp
+	if err := chanDB.UpdateEdgePolicy(edge); err != nil {
+		return false, err
+	}
+
+	selfID := g.server.lightningID
+	if edge.From != selfID && edge.To != selfID {
+		return true, nil
+	}
+
+	_, err = chanDB.FetchChannelAnnouncement(a.ChannelPoint)
+	switch {
+	case err == channeldb.ErrAnnouncementNotFound:
+		gsprLog.Debugf("Holding update for unannounced "+
+			"ChannelPoint(%v)", a.ChannelPoint)
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	return true, nil
+}
+
+// edgeHasPolicy returns true if a forwarding policy has been announced for
+// the edge, rather than the empty policy it starts out with.
+func edgeHasPolicy(edge *channeldb.ChannelEdge) bool {
+	return edge.TimeLockDelta != 0 || edge.MinHTLC != 0 ||
+		edge.MaxHTLC != 0 || edge.FeeBase != 0 || edge.FeeRate != 0
+}
+
+// edgeMatchesUpdate returns true if the forwarding policy of the edge is
+// identical to that of the channel update.
+func edgeMatchesUpdate(edge *channeldb.ChannelEdge,
+	a *lnwire.ChannelUpdateAnnouncement) bool {
+
+	return edge.TimeLockDelta == a.TimeLockDelta &&
+		edge.MinHTLC == a.MinHTLC &&
+		edge.MaxHTLC == a.MaxHTLC &&
+		edge.FeeBase == a.FeeBase &&
+		edge.FeeRate == a.FeeRate
+}
+
+// addGraphNode adds the node with the passed identity key to the channel
+// graph if it isn't yet known, returning its Lightning ID.
+func (g *gossiper) addGraphNode(pub *btcec.PublicKey) ([32]byte, error) {
+	nodeID := fastsha256.Sum256(pub.SerializeCompressed())
+
+	node, err := g.server.chanDB.FetchLightningNode(nodeID)
+	switch {
+	case err == channeldb.ErrGraphNodeNotFound:
+		node = &channeldb.LightningNode{ID: nodeID}
+	case err != nil:
+		return nodeID, err
+	case node.PubKey != nil:
+		return nodeID, nil
+	}
+
+	// The node is either new, or was only known by its ID, so we'll
+	// record its identity key.
+	node.PubKey = pub
+	return nodeID, g.server.chanDB.AddLightningNode(node)
+}
+
+// relayBatch sends each announcement within the batch to all our peers,
+// other than the peer it was received from. Channel announcements are sent
+// first, as nodes only accept node announcements and channel updates which
+// refer to known channels.
+func (g *gossiper) relayBatch(batch map[announcementKey]*announcementMsg) {
+	for _, p := range g.server.Peers() {
+		var msgs []lnwire.Message
+		for _, ann := range batch {
+			if ann.peer != nil && ann.peer.id == p.id {
+				continue
+			}
+			msgs = append(msgs, ann.msg)
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+
+		sort.Stable(announcementsByKind(msgs))
+		g.sendToPeer(p, msgs...)
+	}
+
+	gsprLog.Debugf("Relayed batch of %v announcements", len(batch))
+}
+
+// sendToPeer sends the passed messages to the target peer. The messages are
+// sent from a dedicated goroutine, so a slow peer doesn't hold up the
+// processing of further announcements.
+func (g *gossiper) sendToPeer(p *peer, msgs ...lnwire.Message) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		for _, msg := range msgs {
+			select {
+			case p.outgoingQueue <- outgoinMsg{msg, nil}:
+			case <-p.quit:
+				return
+			case <-g.quit:
+				return
+			}
+		}
+	}()
+}
+
+// announcementsByKind sorts announcements such that channel announcements
+// come first, followed by node announcements, then channel updates.
+type announcementsByKind []lnwire.Message
+
+func (a announcementsByKind) Len() int      { return len(a) }
+func (a announcementsByKind) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a announcementsByKind) Less(i, j int) bool {
+	return announcementRank(a[i]) < announcementRank(a[j])
+}
+
+// announcementRank returns the position of the passed announcement's kind
+// within a relayed batch.
+func announcementRank(msg lnwire.Message) int {
+	switch msg.(type) {
+	case *lnwire.ChannelAnnouncement:
+		return 0
+	case *lnwire.NodeAnnouncement:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// watchChannel registers for a notification of the spend of the channel's
+// funding outpoint, pruning the channel from the graph once it's closed.
+func (g *gossiper) watchChannel(chanPoint wire.OutPoint) {
+	if _, ok := g.watchedChannels[chanPoint]; ok {
+		return
+	}
+
+	spendNtfn, err := g.notifier.RegisterSpendNtfn(&chanPoint)
+	if err != nil {
+		gsprLog.Errorf("Unable to watch ChannelPoint(%v) for closure: "+
+			"%v", chanPoint, err)
+		return
+	}
+	g.watchedChannels[chanPoint] = struct{}{}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		select {
+		case <-spendNtfn.Spend:
+			select {
+			case g.spentChannels <- &chanPoint:
+			case <-g.quit:
+			}
+		case <-g.quit:
+		}
+	}()
+}
+
+// pruneChannel removes the channel identified by the passed funding outpoint
+// from both the channel graph, and the routing manager.
+func (g *gossiper) pruneChannel(chanPoint wire.OutPoint) error {
+	delete(g.watchedChannels, chanPoint)
+
+	edges, err := g.server.chanDB.FetchChannelEdges(&chanPoint)
+	if err == channeldb.ErrEdgeNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	_, err = g.server.chanDB.PruneGraph([]*wire.OutPoint{&chanPoint})
+	if err != nil {
+		return err
+	}
+
+	g.server.routingMgr.RemoveChannel(&chanPoint)
+
+	return nil
+}
+
+// pruneStaleChannels prunes all channels for which neither endpoint has sent
+// a channel update within the refresh interval. Our own channels are exempt,
+// as they're pruned once closed, and periodically re-announced so that other
+// nodes don't prune them.
+func (g *gossiper) pruneStaleChannels() error {
+	selfID := g.server.lightningID
+	lastUpdates := make(map[wire.OutPoint]time.Time)
+	ownChannels := make(map[wire.OutPoint]struct{})
+
+	err := g.server.chanDB.ForEachChannelEdge(func(edge *channeldb.ChannelEdge) error {
+		if edge.From == selfID || edge.To == selfID {
+			ownChannels[edge.ChannelPoint] = struct{}{}
+		}
+		if edge.LastUpdate.After(lastUpdates[edge.ChannelPoint]) {
+			lastUpdates[edge.ChannelPoint] = edge.LastUpdate
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-g.refreshInterval)
+	for chanPoint, lastUpdate := range lastUpdates {
+		if _, ok := ownChannels[chanPoint]; ok {
+			continue
+		}
+		if !lastUpdate.Before(cutoff) {
+			continue
+		}
+
+		gsprLog.Infof("ChannelPoint(%v) hasn't been refreshed since %v, "+
+			"pruning from channel graph", chanPoint, lastUpdate)
+		if err := g.pruneChannel(chanPoint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyChannelAnnouncement ensures the four signatures of the passed channel
+// announcement are valid: one by each node's identity key, and one by each
+// key of the funding output.
+func verifyChannelAnnouncement(a *lnwire.ChannelAnnouncement) error {
+	digest, err := a.DataToSign()
+	if err != nil {
+		return err
+	}
+
+	sigs := []struct {
+		name string
+		sig  *btcec.Signature
+		key  *btcec.PublicKey
+	}{
+		{"first node", a.FirstNodeSig, a.FirstNodeID},
+		{"second node", a.SecondNodeSig, a.SecondNodeID},
+		{"first bitcoin", a.FirstBitcoinSig, a.FirstBitcoinKey},
+		{"second bitcoin", a.SecondBitcoinSig, a.SecondBitcoinKey},
+	}
+	for _, s := range sigs {
+		if !s.sig.Verify(digest, s.key) {
+			return fmt.Errorf("invalid %v signature for "+
+				"ChannelPoint(%v)", s.name, a.ChannelPoint)
+		}
+	}
+
+	return nil
+}
+
+// verifyFundingOutput ensures the passed funding output of an announced
+// channel is the p2wsh of the 2-of-2 multi-sig script of the announced
+// bitcoin keys.
+func verifyFundingOutput(a *lnwire.ChannelAnnouncement,
+	fundingOut *wire.TxOut) error {
+
+	redeemScript, err := lnwallet.GenMultiSigScript(
+		a.FirstBitcoinKey.SerializeCompressed(),
+		a.SecondBitcoinKey.SerializeCompressed())
+	if err != nil {
+		return err
+	}
+	pkScript, err := lnwallet.WitnessScriptHash(redeemScript)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(pkScript, fundingOut.PkScript) {
+		return fmt.Errorf("funding output %v doesn't pay to the "+
+			"announced multi-sig keys", a.ChannelPoint)
+	}
+
+	return nil
+}
+
+// verifyNodeAnnouncement ensures the passed node announcement is signed by
+// the announcing node's identity key.
+func verifyNodeAnnouncement(a *lnwire.NodeAnnouncement) error {
+	digest, err := a.DataToSign()
+	if err != nil {
+		return err
+	}
+
+	if !a.Signature.Verify(digest, a.NodeID) {
+		return fmt.Errorf("invalid signature for node %x",
+			a.NodeID.SerializeCompressed())
+	}
+
+	return nil
+}
+
+// verifyChannelUpdate ensures the passed channel update is signed by the
+// announcing node's identity key.
+func verifyChannelUpdate(a *lnwire.ChannelUpdateAnnouncement) error {
+	digest, err := a.DataToSign()
+	if err != nil {
+		return err
+	}
+
+	if !a.Signature.Verify(digest, a.NodeID) {
+		return fmt.Errorf("invalid signature for update of "+
+			"ChannelPoint(%v)", a.ChannelPoint)
+	}
+
+	return nil
+}
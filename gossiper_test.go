@@ -0,0 +1,561 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/fastsha256"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/chaincfg"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+)
+
+// newTestGossiper creates a gossiper backed by a fresh channel database, for
+// the node of a newly generated identity key. The returned function cleans up
+// the database.
+func newTestGossiper(t *testing.T) (*gossiper, func()) {
+	tempDirName, err := ioutil.TempDir("", "gossiper")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+
+	cdb, err := channeldb.Open(tempDirName, &chaincfg.SegNet4Params)
+	if err != nil {
+		os.RemoveAll(tempDirName)
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+
+	identityPriv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to generate identity key: %v", err)
+	}
+	s := &server{
+		identityPriv: identityPriv,
+		lightningID: fastsha256.Sum256(
+			identityPriv.PubKey().SerializeCompressed()),
+		chanDB: cdb,
+	}
+	err = cdb.SetSourceNode(&channeldb.LightningNode{
+		ID:     s.lightningID,
+		PubKey: identityPriv.PubKey(),
+	})
+	if err != nil {
+		t.Fatalf("unable to set source node: %v", err)
+	}
+
+	s.routingMgr = newRoutingManager(s.lightningID)
+	s.routingMgr.Start()
+
+	g := newGossiper(s, nil, time.Hour)
+	cleanUp := func() {
+		close(g.quit)
+		g.wg.Wait()
+		s.routingMgr.Stop()
+		cdb.Close()
+		os.RemoveAll(tempDirName)
+	}
+
+	return g, cleanUp
+}
+
+// newTestPeer returns a peer of the passed identity key, whose outgoing
+// messages may be read from its outgoing queue.
+func newTestPeer(id int32, identityPub *btcec.PublicKey) *peer {
+	lightningID := fastsha256.Sum256(identityPub.SerializeCompressed())
+	return &peer{
+		id:            id,
+		identityPub:   identityPub,
+		lightningID:   wire.ShaHash(lightningID),
+		outgoingQueue: make(chan outgoinMsg, 10),
+		quit:          make(chan struct{}),
+	}
+}
+
+// addTestChannel adds a channel between the two passed nodes to the channel
+// graph of the gossiper, with both edges last updated at the passed time.
+func addTestChannel(t *testing.T, g *gossiper, chanPoint *wire.OutPoint,
+	node1, node2 [32]byte, lastUpdate time.Time) {
+
+	for _, nodes := range [][2][32]byte{{node1, node2}, {node2, node1}} {
+		err := g.server.chanDB.AddChannelEdge(&channeldb.ChannelEdge{
+			ChannelPoint: *chanPoint,
+			From:         nodes[0],
+			To:           nodes[1],
+			Capacity:     100000,
+			LastUpdate:   lastUpdate,
+		})
+		if err != nil {
+			t.Fatalf("unable to add edge: %v", err)
+		}
+	}
+}
+
+// signTestUpdate returns a channel update for the passed channel, signed by
+// the passed identity key.
+func signTestUpdate(t *testing.T, identityKey *btcec.PrivateKey,
+	chanPoint *wire.OutPoint, timestamp time.Time,
+	feeBase btcutil.Amount) *lnwire.ChannelUpdateAnnouncement {
+
+	update := &lnwire.ChannelUpdateAnnouncement{
+		ChannelPoint:  chanPoint,
+		Timestamp:     uint32(timestamp.Unix()),
+		NodeID:        identityKey.PubKey(),
+		TimeLockDelta: 144,
+		MinHTLC:       1,
+		FeeBase:       feeBase,
+		FeeRate:       1,
+	}
+	digest, err := update.DataToSign()
+	if err != nil {
+		t.Fatalf("unable to serialize update: %v", err)
+	}
+	update.Signature, err = identityKey.Sign(digest)
+	if err != nil {
+		t.Fatalf("unable to sign update: %v", err)
+	}
+
+	return update
+}
+
+// newTestNode generates an identity key for a remote node, returning it along
+// with the node's Lightning ID.
+func newTestNode(t *testing.T) (*btcec.PrivateKey, [32]byte) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	return priv, fastsha256.Sum256(priv.PubKey().SerializeCompressed())
+}
+
+// servePeers answers the server's queries for its peers with the passed
+// peers, until the gossiper is stopped.
+func servePeers(g *gossiper, peers ...*peer) {
+	g.server.queries = make(chan interface{})
+	go func() {
+		for {
+			select {
+			case query := <-g.server.queries:
+				if msg, ok := query.(*listPeersMsg); ok {
+					msg.resp <- peers
+				}
+			case <-g.quit:
+				return
+			}
+		}
+	}()
+}
+
+// storeOwnAnnouncement stores an announcement of our channel with the node of
+// the passed identity key, as if its proof had been assembled. The
+// announcement's signatures are placeholders, as stored announcements aren't
+// verified again.
+func storeOwnAnnouncement(t *testing.T, g *gossiper,
+	nodeKey *btcec.PrivateKey, chanPoint *wire.OutPoint) {
+
+	sig, err := nodeKey.Sign(chanPoint.Hash[:])
+	if err != nil {
+		t.Fatalf("unable to sign: %v", err)
+	}
+	selfKey := g.server.identityPriv.PubKey()
+	chanAnn := &lnwire.ChannelAnnouncement{
+		FirstNodeSig:     sig,
+		SecondNodeSig:    sig,
+		FirstBitcoinSig:  sig,
+		SecondBitcoinSig: sig,
+		ChannelPoint:     chanPoint,
+		FirstNodeID:      selfKey,
+		SecondNodeID:     nodeKey.PubKey(),
+		FirstBitcoinKey:  selfKey,
+		SecondBitcoinKey: nodeKey.PubKey(),
+	}
+	if err := g.server.chanDB.PutChannelAnnouncement(chanAnn); err != nil {
+		t.Fatalf("unable to store announcement: %v", err)
+	}
+}
+
+// nextPeerMsg returns the next message sent to the passed test peer.
+func nextPeerMsg(t *testing.T, p *peer) lnwire.Message {
+	select {
+	case msg := <-p.outgoingQueue:
+		return msg.msg
+	case <-time.After(time.Second * 5):
+		t.Fatalf("no message sent to peer %v", p.id)
+	}
+	return nil
+}
+
+func TestGossiperChannelProofExchange(t *testing.T) {
+	gossiperA, cleanUpA := newTestGossiper(t)
+	defer cleanUpA()
+	gossiperB, cleanUpB := newTestGossiper(t)
+	defer cleanUpB()
+
+	peerA := newTestPeer(1, gossiperA.server.identityPriv.PubKey())
+	peerB := newTestPeer(2, gossiperB.server.identityPriv.PubKey())
+
+	multiSigA, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	multiSigB, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	chanPoint := &wire.OutPoint{Hash: wire.ShaHash{0x01}}
+	channelA := &channeldb.OpenChannel{
+		ChanID:           chanPoint,
+		OurMultiSigKey:   multiSigA,
+		TheirMultiSigKey: multiSigB.PubKey(),
+	}
+	channelB := &channeldb.OpenChannel{
+		ChanID:           chanPoint,
+		OurMultiSigKey:   multiSigB,
+		TheirMultiSigKey: multiSigA.PubKey(),
+	}
+
+	// Each node signs its half of the announcement, sending the
+	// signatures to the other.
+	chanAnn, err := gossiperA.initChannelProof(peerB, channelA)
+	if err != nil || chanAnn != nil {
+		t.Fatalf("expected incomplete proof, got %v: %v", chanAnn, err)
+	}
+	sigsA, ok := nextPeerMsg(t, peerB).(*lnwire.AnnounceSignatures)
+	if !ok {
+		t.Fatalf("expected AnnounceSignatures to be sent")
+	}
+
+	chanAnn, err = gossiperB.initChannelProof(peerA, channelB)
+	if err != nil || chanAnn != nil {
+		t.Fatalf("expected incomplete proof, got %v: %v", chanAnn, err)
+	}
+	sigsB, ok := nextPeerMsg(t, peerA).(*lnwire.AnnounceSignatures)
+	if !ok {
+		t.Fatalf("expected AnnounceSignatures to be sent")
+	}
+
+	// Once each node receives the other's signatures, both assemble the
+	// same valid announcement.
+	annB, err := gossiperB.processAnnounceSignatures(sigsA, peerA)
+	if err != nil {
+		t.Fatalf("unable to process signatures: %v", err)
+	}
+	annA, err := gossiperA.processAnnounceSignatures(sigsB, peerB)
+	if err != nil {
+		t.Fatalf("unable to process signatures: %v", err)
+	}
+	if annA == nil || annB == nil {
+		t.Fatalf("announcement not assembled")
+	}
+	if err := verifyChannelAnnouncement(annA); err != nil {
+		t.Fatalf("invalid announcement: %v", err)
+	}
+	if err := annA.Validate(); err != nil {
+		t.Fatalf("invalid announcement: %v", err)
+	}
+	if !annA.FirstNodeSig.IsEqual(annB.FirstNodeSig) ||
+		!annA.SecondNodeSig.IsEqual(annB.SecondNodeSig) {
+
+		t.Fatalf("nodes assembled different announcements")
+	}
+
+	if _, err := gossiperA.server.chanDB.FetchChannelAnnouncement(chanPoint); err != nil {
+		t.Fatalf("announcement not stored: %v", err)
+	}
+
+	// A node which receives signatures for an announced channel responds
+	// with the complete announcement, as the sender evidently hasn't
+	// assembled it.
+	chanAnn, err = gossiperA.processAnnounceSignatures(sigsB, peerB)
+	if err != nil || chanAnn != nil {
+		t.Fatalf("expected no new announcement, got %v: %v", chanAnn,
+			err)
+	}
+	if _, ok := nextPeerMsg(t, peerB).(*lnwire.ChannelAnnouncement); !ok {
+		t.Fatalf("expected ChannelAnnouncement to be sent")
+	}
+
+	// Signatures over a different announcement are rejected: node A
+	// signed the announcement of its channel with B, not C.
+	gossiperC, cleanUpC := newTestGossiper(t)
+	defer cleanUpC()
+	if _, err := gossiperC.initChannelProof(peerA, channelB); err != nil {
+		t.Fatalf("unable to sign announcement: %v", err)
+	}
+	nextPeerMsg(t, peerA)
+	if _, err := gossiperC.processAnnounceSignatures(sigsA, peerA); err == nil {
+		t.Fatalf("invalid signatures accepted")
+	}
+}
+
+func TestGossiperRateLimit(t *testing.T) {
+	g, cleanUp := newTestGossiper(t)
+	defer cleanUp()
+	g.maxPeerAnnouncements = 2
+
+	nodeKey, nodeID := newTestNode(t)
+	_, otherID := newTestNode(t)
+	chanPoint := &wire.OutPoint{Hash: wire.ShaHash{0x01}}
+	start := time.Now().Add(-time.Minute)
+	addTestChannel(t, g, chanPoint, nodeID, otherID, start)
+
+	p := newTestPeer(1, nodeKey.PubKey())
+	key := announcementKey{chanPoint: *chanPoint, nodeID: nodeID}
+	for i := 1; i <= 3; i++ {
+		update := signTestUpdate(t, nodeKey, chanPoint,
+			start.Add(time.Second*time.Duration(i)), btcutil.Amount(i))
+		g.handleAnnouncement(&announcementMsg{update, p})
+	}
+
+	// Only the first two updates are accepted, the second replacing the
+	// first within the pending batch.
+	ann, ok := g.pendingBatch[key]
+	if !ok {
+		t.Fatalf("update not added to pending batch")
+	}
+	if fee := ann.msg.(*lnwire.ChannelUpdateAnnouncement).FeeBase; fee != 2 {
+		t.Fatalf("expected second update to be pending, got fee %v", fee)
+	}
+
+	// Once the rate limiting window is reset, the peer's announcements
+	// are accepted again.
+	g.peerCounts = make(map[int32]int)
+	update := signTestUpdate(t, nodeKey, chanPoint,
+		start.Add(time.Second*4), 4)
+	g.handleAnnouncement(&announcementMsg{update, p})
+	if fee := g.pendingBatch[key].msg.(*lnwire.ChannelUpdateAnnouncement).FeeBase; fee != 4 {
+		t.Fatalf("update not accepted after rate limit reset, got "+
+			"fee %v", fee)
+	}
+}
+
+func TestGossiperDeduplication(t *testing.T) {
+	g, cleanUp := newTestGossiper(t)
+	defer cleanUp()
+
+	nodeKey, nodeID := newTestNode(t)
+	otherKey, otherID := newTestNode(t)
+	chanPoint := &wire.OutPoint{Hash: wire.ShaHash{0x01}}
+	added := time.Now()
+	addTestChannel(t, g, chanPoint, nodeID, otherID, added)
+
+	// A re-announcement of a known channel is ignored.
+	chanAnn := &lnwire.ChannelAnnouncement{
+		ChannelPoint: chanPoint,
+		FirstNodeID:  nodeKey.PubKey(),
+		SecondNodeID: otherKey.PubKey(),
+	}
+	if relay, err := g.processChannelAnnouncement(chanAnn); err != nil || relay {
+		t.Fatalf("known channel re-announced: %v", err)
+	}
+
+	// The first update of an edge is accepted, even if signed before the
+	// channel was added to our graph.
+	first := added.Add(-time.Minute)
+	update := signTestUpdate(t, nodeKey, chanPoint, first, 10)
+	if relay, err := g.processChannelUpdate(update); err != nil || !relay {
+		t.Fatalf("first update rejected: %v", err)
+	}
+
+	// Replays, and older updates are ignored, as are updates of the same
+	// timestamp which don't change the policy.
+	stale := []*lnwire.ChannelUpdateAnnouncement{
+		update,
+		signTestUpdate(t, nodeKey, chanPoint, first, 10),
+		signTestUpdate(t, nodeKey, chanPoint, first.Add(-time.Second), 20),
+	}
+	for i, update := range stale {
+		if relay, err := g.processChannelUpdate(update); err != nil || relay {
+			t.Fatalf("stale update #%v accepted: %v", i, err)
+		}
+	}
+
+	// Newer updates, or updates of the same timestamp which change the
+	// policy are accepted.
+	fresh := []*lnwire.ChannelUpdateAnnouncement{
+		signTestUpdate(t, nodeKey, chanPoint, first, 20),
+		signTestUpdate(t, nodeKey, chanPoint, first.Add(time.Second), 20),
+	}
+	for i, update := range fresh {
+		if relay, err := g.processChannelUpdate(update); err != nil || !relay {
+			t.Fatalf("fresh update #%v rejected: %v", i, err)
+		}
+	}
+
+	// Updates of the other endpoint are tracked independently.
+	update = signTestUpdate(t, otherKey, chanPoint, first, 10)
+	if relay, err := g.processChannelUpdate(update); err != nil || !relay {
+		t.Fatalf("update of other endpoint rejected: %v", err)
+	}
+}
+
+func TestGossiperRelayBatch(t *testing.T) {
+	g, cleanUp := newTestGossiper(t)
+	defer cleanUp()
+
+	nodeKey, nodeID := newTestNode(t)
+	otherKey, otherID := newTestNode(t)
+	chanPoint := &wire.OutPoint{Hash: wire.ShaHash{0x01}}
+	addTestChannel(t, g, chanPoint, nodeID, otherID, time.Now())
+
+	peer1 := newTestPeer(1, nodeKey.PubKey())
+	peer2 := newTestPeer(2, otherKey.PubKey())
+	servePeers(g, peer1, peer2)
+
+	update := signTestUpdate(t, nodeKey, chanPoint, time.Now(), 10)
+	chanAnn := &lnwire.ChannelAnnouncement{ChannelPoint: chanPoint}
+	g.relayBatch(map[announcementKey]*announcementMsg{
+		{chanPoint: *chanPoint, nodeID: nodeID}: {update, peer1},
+		{chanPoint: *chanPoint}:                 {chanAnn, nil},
+	})
+
+	// The peer which sent the update only receives the channel
+	// announcement, while the other receives both, channel announcement
+	// first.
+	if msg := nextPeerMsg(t, peer2); msg != chanAnn {
+		t.Fatalf("expected channel announcement first, got %T", msg)
+	}
+	if msg := nextPeerMsg(t, peer2); msg != update {
+		t.Fatalf("expected channel update, got %T", msg)
+	}
+	if msg := nextPeerMsg(t, peer1); msg != chanAnn {
+		t.Fatalf("expected channel announcement, got %T", msg)
+	}
+
+	select {
+	case msg := <-peer1.outgoingQueue:
+		t.Fatalf("announcement relayed back to sender: %T", msg.msg)
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestGossiperPruneStaleChannels(t *testing.T) {
+	g, cleanUp := newTestGossiper(t)
+	defer cleanUp()
+
+	selfID := g.server.lightningID
+	_, nodeID := newTestNode(t)
+	_, otherID := newTestNode(t)
+	stale := time.Now().Add(-g.refreshInterval * 2)
+
+	staleChan := &wire.OutPoint{Hash: wire.ShaHash{0x01}}
+	freshChan := &wire.OutPoint{Hash: wire.ShaHash{0x02}}
+	ownChan := &wire.OutPoint{Hash: wire.ShaHash{0x03}}
+	addTestChannel(t, g, staleChan, nodeID, otherID, stale)
+	addTestChannel(t, g, freshChan, nodeID, otherID, time.Now())
+	addTestChannel(t, g, ownChan, selfID, nodeID, stale)
+
+	if err := g.pruneStaleChannels(); err != nil {
+		t.Fatalf("unable to prune stale channels: %v", err)
+	}
+
+	// Only the stale channel of the remote nodes is pruned, as our own
+	// channels are kept until closed.
+	chanDB := g.server.chanDB
+	if _, err := chanDB.FetchChannelEdges(staleChan); err != channeldb.ErrEdgeNotFound {
+		t.Fatalf("stale channel not pruned: %v", err)
+	}
+	for _, chanPoint := range []*wire.OutPoint{freshChan, ownChan} {
+		if _, err := chanDB.FetchChannelEdges(chanPoint); err != nil {
+			t.Fatalf("ChannelPoint(%v) pruned: %v", chanPoint, err)
+		}
+	}
+}
+
+func TestGossiperRefreshOwnChannels(t *testing.T) {
+	g, cleanUp := newTestGossiper(t)
+	defer cleanUp()
+
+	selfID := g.server.lightningID
+	nodeKey, nodeID := newTestNode(t)
+	chanPoint := &wire.OutPoint{Hash: wire.ShaHash{0x01}}
+	addTestChannel(t, g, chanPoint, selfID, nodeID,
+		time.Now().Add(-time.Hour))
+
+	storeOwnAnnouncement(t, g, nodeKey, chanPoint)
+
+	if err := g.refreshOwnChannels(); err != nil {
+		t.Fatalf("unable to refresh channels: %v", err)
+	}
+
+	// The stored channel announcement is re-broadcast, along with our
+	// node's announcement, and a fresh update of our own edge carrying
+	// the default policy.
+	if _, ok := g.pendingBatch[announcementKey{chanPoint: *chanPoint}]; !ok {
+		t.Fatalf("channel announcement not re-broadcast")
+	}
+	if _, ok := g.pendingBatch[announcementKey{nodeID: selfID}]; !ok {
+		t.Fatalf("node announcement not re-broadcast")
+	}
+	ann, ok := g.pendingBatch[announcementKey{chanPoint: *chanPoint,
+		nodeID: selfID}]
+	if !ok {
+		t.Fatalf("channel update not re-broadcast")
+	}
+	update := ann.msg.(*lnwire.ChannelUpdateAnnouncement)
+	if update.TimeLockDelta != defaultForwardingPolicy.TimeLockDelta {
+		t.Fatalf("expected default policy, got time lock delta %v",
+			update.TimeLockDelta)
+	}
+
+	// Our own edge is refreshed by the update.
+	edges, err := g.server.chanDB.FetchChannelEdges(chanPoint)
+	if err != nil {
+		t.Fatalf("unable to fetch edges: %v", err)
+	}
+	for _, edge := range edges {
+		if edge.From != selfID {
+			continue
+		}
+		if time.Since(edge.LastUpdate) > time.Minute {
+			t.Fatalf("own edge not refreshed: %v", edge.LastUpdate)
+		}
+	}
+}
+
+func TestGossiperHoldsUnannouncedUpdates(t *testing.T) {
+	g, cleanUp := newTestGossiper(t)
+	defer cleanUp()
+
+	selfID := g.server.lightningID
+	nodeKey, nodeID := newTestNode(t)
+	chanPoint := &wire.OutPoint{Hash: wire.ShaHash{0x01}}
+	start := time.Now().Add(-time.Minute)
+	addTestChannel(t, g, chanPoint, selfID, nodeID, start)
+
+	// An update of our channel is applied to our own graph before the
+	// channel is announced, but isn't relayed, as other nodes don't yet
+	// know of the channel.
+	update := signTestUpdate(t, g.server.identityPriv, chanPoint, start, 10)
+	if relay, err := g.processChannelUpdate(update); err != nil || relay {
+		t.Fatalf("update of unannounced channel relayed: %v", err)
+	}
+	edges, err := g.server.chanDB.FetchChannelEdges(chanPoint)
+	if err != nil {
+		t.Fatalf("unable to fetch edges: %v", err)
+	}
+	for _, edge := range edges {
+		if edge.From == selfID && edge.FeeBase != 10 {
+			t.Fatalf("update not applied to own edge")
+		}
+	}
+
+	// The same holds for updates of the remote end of the channel.
+	update = signTestUpdate(t, nodeKey, chanPoint, start, 10)
+	if relay, err := g.processChannelUpdate(update); err != nil || relay {
+		t.Fatalf("update of unannounced channel relayed: %v", err)
+	}
+
+	// Once the channel has been announced, its updates are relayed.
+	storeOwnAnnouncement(t, g, nodeKey, chanPoint)
+	update = signTestUpdate(t, g.server.identityPriv, chanPoint,
+		start.Add(time.Second), 20)
+	if relay, err := g.processChannelUpdate(update); err != nil || !relay {
+		t.Fatalf("update of announced channel not relayed: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// hopPayloadSize is the size of a serialized hopPayload: 32 + 8 + 4.
+const hopPayloadSize = 44
+
+// hopPayload holds the forwarding instructions of a single hop within a
+// route, carried within the OnionBlob of an HTLCAddRequest. The blob is the
+// concatenation of the payloads of each remaining hop, with each hop
+// stripping off its own payload before forwarding the remainder.
+//
+// TODO(roasbeef): replace with the sphinx mix-header once it's integrated,
+// as the payloads are currently visible to every hop in the route.
+type hopPayload struct {
+	// nextNode is the lightning ID of the node the HTLC should be
+	// forwarded to. An all-zero ID marks the final hop of the route.
+	nextNode [32]byte
+
+	// amtToForward is the amount of the HTLC which should be forwarded to
+	// the next node.
+	amtToForward lnwire.MilliSatoshi
+
+	// outgoingExpiry is the expiry of the HTLC which should be forwarded
+	// to the next node.
+	outgoingExpiry uint32
+}
+
+// isExit returns true if the payload marks the final hop within the route.
+func (h *hopPayload) isExit() bool {
+	return h.nextNode == [32]byte{}
+}
+
+// encodeHopPayloads serializes the passed payloads into a blob suitable for
+// the OnionBlob of an HTLCAddRequest.
+func encodeHopPayloads(hops []*hopPayload) []byte {
+	blob := make([]byte, 0, len(hops)*hopPayloadSize)
+	for _, hop := range hops {
+		var scratch [hopPayloadSize]byte
+		copy(scratch[:32], hop.nextNode[:])
+		binary.BigEndian.PutUint64(scratch[32:40], uint64(hop.amtToForward))
+		binary.BigEndian.PutUint32(scratch[40:44], hop.outgoingExpiry)
+
+		blob = append(blob, scratch[:]...)
+	}
+
+	return blob
+}
+
+// decodeHopPayload parses our own payload from the front of the passed
+// blob, returning it along with the blob which should be passed on to the
+// next hop.
+func decodeHopPayload(blob []byte) (*hopPayload, []byte, error) {
+	if len(blob) < hopPayloadSize || len(blob)%hopPayloadSize != 0 {
+		return nil, nil, fmt.Errorf("malformed routing blob of %v bytes",
+			len(blob))
+	}
+
+	hop := &hopPayload{
+		amtToForward:   lnwire.MilliSatoshi(binary.BigEndian.Uint64(blob[32:40])),
+		outgoingExpiry: binary.BigEndian.Uint32(blob[40:44]),
+	}
+	copy(hop.nextNode[:], blob[:32])
+
+	return hop, blob[hopPayloadSize:], nil
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"container/list"
 	"encoding/hex"
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/lnwire"
@@ -18,32 +20,192 @@ const (
 	htlcQueueSize = 20
 )
 
+// defaultForwardingPolicy is the forwarding policy applied to any channel
+// which hasn't had a policy explicitly set.
+var defaultForwardingPolicy = channeldb.ForwardingPolicy{
+	BaseFee:       1,
+	FeeRate:       1,
+	TimeLockDelta: 144,
+	MinHTLC:       1,
+}
+
 // link represents a an active channel capable of forwarding HTLC's. Each
 // active channel registered with the htlc switch creates a new link which will
 // be used for forwarding outgoing HTLC's. The link also has additional
-// meta-data such as the current available bandwidth of the link (in satoshis)
+// meta-data such as the current available bandwidth of the link (in mSAT)
 // which aide the switch in optimally forwarding HTLC's.
 type link struct {
 	capacity btcutil.Amount
 
-	availableBandwidth btcutil.Amount
+	// bandwidth is the available bandwidth of the link, as last reported
+	// by the link itself.
+	bandwidth *linkBandwidth
+
+	// numAddsSent is the number of HTLCs the switch has sent to the link.
+	// The amounts of those not yet reflected within the link's latest
+	// bandwidth report are held in pendingAdds, and are reserved from the
+	// link's bandwidth until they are.
+	numAddsSent uint64
+	pendingAdds []lnwire.MilliSatoshi
+
+	// policy is the forwarding policy enforced for all HTLCs forwarded
+	// out across the link. It's only accessed by the htlcForwarder.
+	policy *channeldb.ForwardingPolicy
+
+	linkChan chan *htlcPacket
+
+	// packets accepts the packets sent to the link by the switch, which
+	// are queued by the link's packetQueue until the link reads them from
+	// its linkChan. The switch therefore never blocks on a busy link,
+	// which may itself be blocked sending a packet to the switch.
+	packets chan *htlcPacket
 
-	linkChan chan lnwire.Message
+	// quit is closed once the link is unregistered, stopping its
+	// packetQueue.
+	quit chan struct{}
 
 	peer *peer
 
 	chanPoint *wire.OutPoint
 }
 
+// availableBandwidth returns the bandwidth of the link available to new HTLCs.
+// It's the bandwidth last reported by the link, less the amounts of the HTLCs
+// sent to the link since.
+func (l *link) availableBandwidth() lnwire.MilliSatoshi {
+	bandwidth, numAdds := l.bandwidth.latest()
+
+	// HTLCs which the link had processed at the time of its report are
+	// already reflected within it, so their reservation is released.
+	numPending := l.numAddsSent - numAdds
+	if numPending < uint64(len(l.pendingAdds)) {
+		numProcessed := uint64(len(l.pendingAdds)) - numPending
+		l.pendingAdds = l.pendingAdds[numProcessed:]
+	}
+
+	for _, amt := range l.pendingAdds {
+		bandwidth -= amt
+	}
+
+	return bandwidth
+}
+
+// reserveBandwidth reserves bandwidth for an HTLC of the passed amount which
+// is about to be sent to the link.
+func (l *link) reserveBandwidth(amt lnwire.MilliSatoshi) {
+	l.numAddsSent++
+	l.pendingAdds = append(l.pendingAdds, amt)
+}
+
+// linkBandwidth is the available bandwidth of a link, reported by the link's
+// htlcManager after each update to the channel's commitment state, and read
+// by the switch when selecting a link for an HTLC.
+type linkBandwidth struct {
+	sync.Mutex
+
+	bandwidth lnwire.MilliSatoshi
+
+	// numAdds is the number of HTLCs received from the switch which the
+	// htlcManager had processed at the time of the report.
+	numAdds uint64
+}
+
+// report records the latest available bandwidth of the link, having
+// processed numAdds HTLCs received from the switch.
+func (b *linkBandwidth) report(bandwidth lnwire.MilliSatoshi, numAdds uint64) {
+	b.Lock()
+	b.bandwidth = bandwidth
+	b.numAdds = numAdds
+	b.Unlock()
+}
+
+// latest returns the latest reported bandwidth, along with the number of
+// HTLCs received from the switch which it reflects.
+func (b *linkBandwidth) latest() (lnwire.MilliSatoshi, uint64) {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.bandwidth, b.numAdds
+}
+
 // htlcPacket is a wrapper around an lnwire message which adds, timesout, or
 // settles an active HTLC. The dest field denotes the name of the interface to
 // forward this htlcPacket on.
 type htlcPacket struct {
 	dest wire.ShaHash
 
+	// payHash is the payment hash of the HTLC the packet adds, settles, or
+	// times out.
+	payHash [32]byte
+
+	// srcLink is the channel point of the link the packet was received
+	// over. It's nil for payments initiated by the daemon itself.
+	srcLink *wire.OutPoint
+
+	// circuitID identifies the circuit of the HTLC the packet adds,
+	// settles, or times out. It's assigned by the switch as the HTLC is
+	// sent out over a link, and zero for packets not yet assigned one.
+	circuitID uint64
+
+	// incomingIndex is the log index of the incoming HTLC which is to be
+	// forwarded, used to settle, or time it out once the forward is
+	// resolved.
+	incomingIndex uint32
+
+	// outgoingChan is the channel the HTLC of a payment initiated by the
+	// daemon itself should preferably be sent over, as it's the first hop
+	// of the HTLC's route.
+	outgoingChan *wire.OutPoint
+
+	// incomingAmt and incomingExpiry are the amount, and expiry of the
+	// incoming HTLC which is to be forwarded. They're used to ensure the
+	// HTLC satisfies the forwarding policy of the outgoing link.
+	incomingAmt    lnwire.MilliSatoshi
+	incomingExpiry uint32
+
+	// fee is the fee earned by forwarding the HTLC, set on settles sent
+	// back to the link the HTLC was received over.
+	fee lnwire.MilliSatoshi
+
+	// result is set on the packets of payments initiated by the daemon
+	// itself. The outcome of the payment is sent over it once the HTLC
+	// is either settled or cancelled. It must be buffered.
+	result chan *paymentResult
+
 	msg lnwire.Message
 }
 
+// paymentResult is the outcome of the HTLC of a payment initiated by the
+// daemon itself.
+type paymentResult struct {
+	// settled is true if the HTLC was settled, in which case the
+	// pre-images revealed by its settlement are set. Otherwise the HTLC
+	// was cancelled.
+	settled   bool
+	preimages [][32]byte
+
+	// failCode and erringNode describe why, and by which node the HTLC
+	// was cancelled.
+	failCode   lnwire.FailCode
+	erringNode [32]byte
+}
+
+// paymentCircuit links an incoming HTLC to the outgoing HTLC it was
+// forwarded as. Once the outgoing HTLC is settled, or timed out, the circuit
+// is used to propagate the settle, or timeout back to the incoming link.
+// Circuits of payments initiated by the daemon itself have no incoming
+// channel, and instead deliver the outcome over their result channel.
+type paymentCircuit struct {
+	incomingChan  *wire.OutPoint
+	incomingIndex uint32
+	outgoingChan  *wire.OutPoint
+
+	incomingAmt lnwire.MilliSatoshi
+	outgoingAmt lnwire.MilliSatoshi
+
+	result chan *paymentResult
+}
+
 // HtlcSwitch is a central messaging bus for all incoming/outgoing HTLC's.
 // Connected peers with active channels are treated as named interfaces which
 // refer to active channels as links. A link is the switche's message
@@ -60,6 +222,22 @@ type htlcSwitch struct {
 	chanIndex  map[wire.OutPoint]*link
 	interfaces map[wire.ShaHash][]*link
 
+	// circuits holds all HTLCs which have been forwarded, but not yet
+	// settled or timed out, indexed by their circuit ID. Several HTLCs
+	// may share a payment hash, as is the case for the shards of a
+	// multi-path payment. It's only accessed by the htlcForwarder.
+	circuits      map[uint64]*paymentCircuit
+	nextCircuitID uint64
+
+	// chanDB is used to load the forwarding policies of newly registered
+	// links, and to record each completed forward within the forwarding
+	// log.
+	chanDB *channeldb.DB
+
+	// selfID is our own lightning ID, named as the erring node of any
+	// HTLCs we cancel.
+	selfID [32]byte
+
 	// TODO(roasbeef): msgs for dynamic link quality
 	linkControl chan interface{}
 
@@ -67,20 +245,34 @@ type htlcSwitch struct {
 
 	htlcPlex chan *htlcPacket
 
+	// policyUpdates carries requests to update the forwarding policy of a
+	// link to the htlcForwarder, which enforces the policies.
+	policyUpdates chan *updatePolicyMsg
+
+	bandwidthQueries chan *bandwidthQuery
+
 	// TODO(roasbeef): messaging chan to/from upper layer (routing - L3)
 
 	wg   sync.WaitGroup
 	quit chan struct{}
 }
 
-// newHtlcSwitch creates a new htlcSwitch.
-func newHtlcSwitch() *htlcSwitch {
+// newHtlcSwitch creates a new htlcSwitch which loads the forwarding policies
+// of its links from the passed database.
+func newHtlcSwitch(selfID [32]byte, chanDB *channeldb.DB) *htlcSwitch {
 	return &htlcSwitch{
+		selfID:           selfID,
 		chanIndex:        make(map[wire.OutPoint]*link),
 		interfaces:       make(map[wire.ShaHash][]*link),
+		circuits:         make(map[uint64]*paymentCircuit),
+		nextCircuitID:    1,
+		chanDB:           chanDB,
 		linkControl:      make(chan interface{}),
 		htlcPlex:         make(chan *htlcPacket, htlcQueueSize),
-		outgoingPayments: make(chan *htlcPacket, 20),
+		outgoingPayments: make(chan *htlcPacket),
+		policyUpdates:    make(chan *updatePolicyMsg),
+		bandwidthQueries: make(chan *bandwidthQuery),
+		quit:             make(chan struct{}),
 	}
 }
 
@@ -111,13 +303,17 @@ func (h *htlcSwitch) Stop() error {
 }
 
 // SendHTLC queues a HTLC packet for forwarding over the designated interface.
-// In the event that the interface has insufficient capacity for the payment,
-// an error is returned. Additionally, if the interface cannot be found, an
-// alternative error is returned.
+// The outcome of the payment, including a failure to find a link with
+// sufficient capacity, is delivered over the packet's result channel. As the
+// packet is handed directly to the htlcForwarder, the bandwidth used by the
+// HTLC is reflected within any later call to LinkBandwidths.
 func (h *htlcSwitch) SendHTLC(htlcPkt *htlcPacket) error {
-	// TODO(roasbeef): hook in errors
-	h.outgoingPayments <- htlcPkt
-	return nil
+	select {
+	case h.outgoingPayments <- htlcPkt:
+		return nil
+	case <-h.quit:
+		return fmt.Errorf("htlc switch shutting down")
+	}
 }
 
 // htlcForwarder is responsible for optimally forwarding (and possibly
@@ -128,36 +324,22 @@ out:
 	for {
 		select {
 		case htlcPkt := <-h.outgoingPayments:
-			chanInterface, ok := h.interfaces[htlcPkt.dest]
-			if !ok {
-				hswcLog.Errorf("unable to locate link %x", htlcPkt.dest[:])
-				continue
-			}
-
-			wireMsg := htlcPkt.msg.(*lnwire.HTLCAddRequest)
-			amt := btcutil.Amount(wireMsg.Amount)
-			hswcLog.Debugf("attempting to send %v to %v", amt,
-				hex.EncodeToString(htlcPkt.dest[:]))
-
-			for _, link := range chanInterface {
-				// TODO(roasbeef): implement HTLC fragmentation
-				if link.availableBandwidth >= amt {
-					hswcLog.Debugf("selected %v for payment of %v to %x",
-						link.chanPoint, amt, htlcPkt.dest[:])
-
-					wireMsg.ChannelPoint = link.chanPoint
-					link.linkChan <- wireMsg
-					// TODO(roasbeef): update link info on
-					// timeout/settle
-					link.availableBandwidth -= amt
-				}
+			h.handleLocalPayment(htlcPkt)
+		case query := <-h.bandwidthQueries:
+			bandwidths := make(map[wire.OutPoint]lnwire.MilliSatoshi)
+			for chanPoint, link := range h.chanIndex {
+				bandwidths[chanPoint] = link.availableBandwidth()
 			}
-
-			if wireMsg.ChannelPoint == nil {
-				hswcLog.Errorf("unable to send payment, " +
-					"insufficient capacity")
+			query.resp <- bandwidths
+		case req := <-h.policyUpdates:
+			h.handleUpdatePolicy(req)
+		case htlcPkt := <-h.htlcPlex:
+			switch htlcPkt.msg.(type) {
+			case *lnwire.HTLCAddRequest:
+				h.handleForward(htlcPkt)
+			case *lnwire.HTLCSettleRequest, *lnwire.HTLCTimeoutRequest:
+				h.handleCircuitResolution(htlcPkt)
 			}
-		case <-h.htlcPlex:
 		case <-h.quit:
 			break out
 		}
@@ -165,6 +347,353 @@ out:
 	h.wg.Done()
 }
 
+// addCircuit records a circuit for an HTLC which is about to be sent out
+// over a link, returning the ID assigned to it.
+func (h *htlcSwitch) addCircuit(circuit *paymentCircuit) uint64 {
+	circuitID := h.nextCircuitID
+	h.nextCircuitID++
+
+	h.circuits[circuitID] = circuit
+	return circuitID
+}
+
+// handleLocalPayment sends the HTLC of a payment initiated by the daemon
+// itself over the packet's preferred outgoing link, or otherwise over the
+// first link to the packet's destination which has sufficient bandwidth. A
+// circuit is recorded for the HTLC so that the outcome of the payment can be
+// delivered over the packet's result channel. Payments which exceed the
+// bandwidth of any single link are split into shards by the
+// paymentController, so the HTLC itself is never fragmented.
+func (h *htlcSwitch) handleLocalPayment(htlcPkt *htlcPacket) {
+	wireMsg := htlcPkt.msg.(*lnwire.HTLCAddRequest)
+	payHash := wireMsg.RedemptionHashes[0]
+
+	chanInterface, ok := h.interfaces[htlcPkt.dest]
+	if !ok {
+		hswcLog.Errorf("unable to locate link %x", htlcPkt.dest[:])
+		h.failLocalPayment(htlcPkt, lnwire.FailCodeUnknownNextPeer)
+		return
+	}
+
+	amt := wireMsg.Amount
+	hswcLog.Debugf("attempting to send %v to %v", amt,
+		hex.EncodeToString(htlcPkt.dest[:]))
+
+	// The preferred link, if any, is tried before all others.
+	links := chanInterface
+	if htlcPkt.outgoingChan != nil {
+		if preferred, ok := h.chanIndex[*htlcPkt.outgoingChan]; ok {
+			links = append([]*link{preferred}, chanInterface...)
+		}
+	}
+
+	for _, link := range links {
+		if link.availableBandwidth() < amt {
+			continue
+		}
+
+		hswcLog.Debugf("selected %v for payment of %v to %x",
+			link.chanPoint, amt, htlcPkt.dest[:])
+
+		circuitID := h.addCircuit(&paymentCircuit{
+			outgoingChan: link.chanPoint,
+			outgoingAmt:  amt,
+			result:       htlcPkt.result,
+		})
+
+		wireMsg.ChannelPoint = link.chanPoint
+		link.reserveBandwidth(amt)
+		h.sendToLink(link, &htlcPacket{
+			payHash:   payHash,
+			circuitID: circuitID,
+			msg:       wireMsg,
+		})
+		return
+	}
+
+	hswcLog.Errorf("unable to send payment, insufficient capacity")
+	h.failLocalPayment(htlcPkt, lnwire.FailCodeInsufficientCapacity)
+}
+
+// failLocalPayment delivers the failure of a payment initiated by the daemon
+// itself, which couldn't be sent over any of our links.
+func (h *htlcSwitch) failLocalPayment(htlcPkt *htlcPacket,
+	failCode lnwire.FailCode) {
+
+	if htlcPkt.result == nil {
+		return
+	}
+
+	htlcPkt.result <- &paymentResult{
+		failCode:   failCode,
+		erringNode: h.selfID,
+	}
+}
+
+// handleForward forwards an incoming HTLC to the interface named within the
+// packet, over the first link which has sufficient bandwidth, and whose
+// forwarding policy is satisfied by the HTLC. If no such link exists, then
+// the incoming HTLC is timed out.
+func (h *htlcSwitch) handleForward(htlcPkt *htlcPacket) {
+	htlc := htlcPkt.msg.(*lnwire.HTLCAddRequest)
+	amt := htlc.Amount
+
+	chanInterface, ok := h.interfaces[htlcPkt.dest]
+	if !ok {
+		hswcLog.Errorf("unable to forward HTLC %x, unable to locate "+
+			"link %x", htlcPkt.payHash[:], htlcPkt.dest[:])
+		h.timeoutIncoming(htlcPkt, lnwire.FailCodeUnknownNextPeer)
+		return
+	}
+
+	var (
+		forwardErr error
+		failCode   lnwire.FailCode
+	)
+	for _, link := range chanInterface {
+		err := checkForwardingPolicy(link.policy, htlcPkt.incomingAmt,
+			htlcPkt.incomingExpiry, amt, htlc.Expiry)
+		if err != nil {
+			forwardErr = err
+			failCode = lnwire.FailCodeChannelPolicy
+			continue
+		}
+		if link.availableBandwidth() < amt {
+			forwardErr = fmt.Errorf("insufficient bandwidth")
+			failCode = lnwire.FailCodeInsufficientCapacity
+			continue
+		}
+
+		circuitID := h.addCircuit(&paymentCircuit{
+			incomingChan:  htlcPkt.srcLink,
+			incomingIndex: htlcPkt.incomingIndex,
+			outgoingChan:  link.chanPoint,
+			incomingAmt:   htlcPkt.incomingAmt,
+			outgoingAmt:   amt,
+		})
+
+		hswcLog.Debugf("forwarding HTLC %x from ChannelPoint(%v) to "+
+			"ChannelPoint(%v), amt=%v, fee=%v", htlcPkt.payHash[:],
+			htlcPkt.srcLink, link.chanPoint, amt,
+			htlcPkt.incomingAmt-amt)
+
+		htlc.ChannelPoint = link.chanPoint
+		link.reserveBandwidth(amt)
+		h.sendToLink(link, &htlcPacket{
+			payHash:   htlcPkt.payHash,
+			circuitID: circuitID,
+			msg:       htlc,
+		})
+		return
+	}
+
+	hswcLog.Errorf("rejecting forward of HTLC %x from ChannelPoint(%v): "+
+		"%v", htlcPkt.payHash[:], htlcPkt.srcLink, forwardErr)
+	h.timeoutIncoming(htlcPkt, failCode)
+}
+
+// handleCircuitResolution propagates the settle, or timeout of an outgoing
+// HTLC back to the link the HTLC was originally received over. The outcome
+// of payments initiated by the daemon itself is instead delivered over the
+// circuit's result channel.
+func (h *htlcSwitch) handleCircuitResolution(htlcPkt *htlcPacket) {
+	circuit, ok := h.circuits[htlcPkt.circuitID]
+	if !ok {
+		hswcLog.Debugf("no circuit found for resolution of HTLC %x "+
+			"over ChannelPoint(%v)", htlcPkt.payHash[:],
+			htlcPkt.srcLink)
+		return
+	}
+	delete(h.circuits, htlcPkt.circuitID)
+
+	if circuit.incomingChan == nil {
+		h.resolveLocalPayment(circuit, htlcPkt)
+		return
+	}
+
+	// A settle completes the forward, so it's recorded within the
+	// forwarding log regardless of whether the incoming link is still
+	// active.
+	if _, ok := htlcPkt.msg.(*lnwire.HTLCSettleRequest); ok {
+		event := &channeldb.ForwardingEvent{
+			Timestamp:    time.Now(),
+			IncomingChan: *circuit.incomingChan,
+			OutgoingChan: *circuit.outgoingChan,
+			AmtIn:        circuit.incomingAmt,
+			AmtOut:       circuit.outgoingAmt,
+		}
+		err := h.chanDB.AddForwardingEvents([]*channeldb.ForwardingEvent{event})
+		if err != nil {
+			hswcLog.Errorf("unable to record forward of HTLC %x: %v",
+				htlcPkt.payHash[:], err)
+		}
+	}
+
+	incomingLink, ok := h.chanIndex[*circuit.incomingChan]
+	if !ok {
+		hswcLog.Errorf("unable to propagate resolution of HTLC %x, "+
+			"ChannelPoint(%v) is no longer active",
+			htlcPkt.payHash[:], circuit.incomingChan)
+		return
+	}
+
+	var msg lnwire.Message
+	var fee lnwire.MilliSatoshi
+	switch wireMsg := htlcPkt.msg.(type) {
+	case *lnwire.HTLCSettleRequest:
+		fee = circuit.incomingAmt - circuit.outgoingAmt
+		msg = &lnwire.HTLCSettleRequest{
+			ChannelPoint:     circuit.incomingChan,
+			HTLCKey:          lnwire.HTLCKey(circuit.incomingIndex),
+			RedemptionProofs: wireMsg.RedemptionProofs,
+		}
+	case *lnwire.HTLCTimeoutRequest:
+		msg = &lnwire.HTLCTimeoutRequest{
+			ChannelPoint: circuit.incomingChan,
+			HTLCKey:      lnwire.HTLCKey(circuit.incomingIndex),
+			FailCode:     wireMsg.FailCode,
+			ErringNode:   wireMsg.ErringNode,
+		}
+	}
+
+	hswcLog.Debugf("propagating %T of HTLC %x from ChannelPoint(%v) to "+
+		"ChannelPoint(%v)", msg, htlcPkt.payHash[:],
+		circuit.outgoingChan, circuit.incomingChan)
+
+	h.sendToLink(incomingLink, &htlcPacket{
+		payHash: htlcPkt.payHash,
+		fee:     fee,
+		msg:     msg,
+	})
+}
+
+// resolveLocalPayment delivers the settle, or timeout of the HTLC of a
+// payment initiated by the daemon itself over the circuit's result channel.
+func (h *htlcSwitch) resolveLocalPayment(circuit *paymentCircuit,
+	htlcPkt *htlcPacket) {
+
+	if circuit.result == nil {
+		return
+	}
+
+	result := &paymentResult{}
+	switch wireMsg := htlcPkt.msg.(type) {
+	case *lnwire.HTLCSettleRequest:
+		result.preimages = wireMsg.RedemptionProofs
+		result.settled = true
+	case *lnwire.HTLCTimeoutRequest:
+		result.failCode = wireMsg.FailCode
+		result.erringNode = wireMsg.ErringNode
+	}
+
+	circuit.result <- result
+}
+
+// timeoutIncoming times out the incoming HTLC of a packet which couldn't be
+// forwarded, returning the funds to the previous hop. We're named as the
+// erring node, along with the passed reason for the failure.
+func (h *htlcSwitch) timeoutIncoming(htlcPkt *htlcPacket,
+	failCode lnwire.FailCode) {
+
+	if htlcPkt.srcLink == nil {
+		return
+	}
+
+	incomingLink, ok := h.chanIndex[*htlcPkt.srcLink]
+	if !ok {
+		return
+	}
+
+	h.sendToLink(incomingLink, &htlcPacket{
+		payHash: htlcPkt.payHash,
+		msg: &lnwire.HTLCTimeoutRequest{
+			ChannelPoint: htlcPkt.srcLink,
+			HTLCKey:      lnwire.HTLCKey(htlcPkt.incomingIndex),
+			FailCode:     failCode,
+			ErringNode:   h.selfID,
+		},
+	})
+}
+
+// sendToLink hands the passed packet off to the link's packetQueue. The
+// hand-off only waits on the packetQueue, never on the link itself.
+func (h *htlcSwitch) sendToLink(l *link, pkt *htlcPacket) {
+	select {
+	case l.packets <- pkt:
+	case <-l.quit:
+		hswcLog.Debugf("dropping packet for HTLC %x, ChannelPoint(%v) "+
+			"is no longer active", pkt.payHash[:], l.chanPoint)
+	case <-h.quit:
+	}
+}
+
+// packetQueue delivers the packets sent to the link by the switch over the
+// link's linkChan, in order, queueing them for as long as the link is busy.
+//
+// NOTE: This MUST be run as a goroutine.
+func (h *htlcSwitch) packetQueue(l *link) {
+	defer h.wg.Done()
+
+	pendingPkts := list.New()
+	for {
+		// The link is only offered a packet once one is pending, as
+		// a send on a nil channel blocks forever.
+		var (
+			linkChan chan *htlcPacket
+			nextPkt  *htlcPacket
+		)
+		if next := pendingPkts.Front(); next != nil {
+			linkChan = l.linkChan
+			nextPkt = next.Value.(*htlcPacket)
+		}
+
+		select {
+		case pkt := <-l.packets:
+			pendingPkts.PushBack(pkt)
+		case linkChan <- nextPkt:
+			pendingPkts.Remove(pendingPkts.Front())
+		case <-l.quit:
+			return
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+// checkForwardingPolicy ensures that an incoming HTLC pays the fee, and
+// leaves the time-lock delta required by the passed policy in order to be
+// forwarded as an HTLC of the outgoing amount and expiry.
+func checkForwardingPolicy(policy *channeldb.ForwardingPolicy,
+	incomingAmt lnwire.MilliSatoshi, incomingExpiry uint32,
+	outgoingAmt lnwire.MilliSatoshi, outgoingExpiry uint32) error {
+
+	if outgoingAmt < lnwire.NewMSatFromSatoshis(policy.MinHTLC) {
+		return fmt.Errorf("amount of %v is below minimum HTLC of %v",
+			outgoingAmt, policy.MinHTLC)
+	}
+	if policy.MaxHTLC != 0 &&
+		outgoingAmt > lnwire.NewMSatFromSatoshis(policy.MaxHTLC) {
+		return fmt.Errorf("amount of %v exceeds maximum HTLC of %v",
+			outgoingAmt, policy.MaxHTLC)
+	}
+
+	fee := policy.ComputeFee(outgoingAmt)
+	if incomingAmt < outgoingAmt+fee {
+		return fmt.Errorf("insufficient fee: forwarding %v requires "+
+			"fee of %v, got %v", outgoingAmt, fee,
+			incomingAmt-outgoingAmt)
+	}
+
+	timeLockDelta := uint32(policy.TimeLockDelta)
+	if incomingExpiry < outgoingExpiry+timeLockDelta {
+		return fmt.Errorf("insufficient time-lock delta: expected "+
+			"at least %v, got incoming expiry %v, outgoing expiry %v",
+			timeLockDelta, incomingExpiry, outgoingExpiry)
+	}
+
+	return nil
+}
+
 // networkAdmin is responsible for handline requests to register, unregister,
 // and close any link. In the event that a unregister requests leaves an
 // interface with no active links, that interface is garbage collected.
@@ -192,15 +721,34 @@ out:
 // adds the link to the existing set of links for the target interface.
 func (h *htlcSwitch) handleRegisterLink(req *registerLinkMsg) {
 	chanPoint := req.linkInfo.ChannelPoint
+
+	// Channels which haven't had a forwarding policy explicitly set use
+	// the default policy.
+	policy, err := h.chanDB.FetchForwardingPolicy(chanPoint)
+	if err != nil {
+		if err != channeldb.ErrForwardingPolicyNotFound {
+			hswcLog.Errorf("unable to fetch forwarding policy for "+
+				"ChannelPoint(%v): %v", chanPoint, err)
+		}
+		defaultPolicy := defaultForwardingPolicy
+		policy = &defaultPolicy
+	}
+
 	newLink := &link{
-		capacity:           req.linkInfo.Capacity,
-		availableBandwidth: req.linkInfo.LocalBalance,
-		linkChan:           req.linkChan,
-		peer:               req.peer,
-		chanPoint:          chanPoint,
+		capacity:  req.linkInfo.Capacity,
+		bandwidth: req.bandwidth,
+		policy:    policy,
+		linkChan:  req.linkChan,
+		packets:   make(chan *htlcPacket),
+		quit:      make(chan struct{}),
+		peer:      req.peer,
+		chanPoint: chanPoint,
 	}
 	h.chanIndex[*chanPoint] = newLink
 
+	h.wg.Add(1)
+	go h.packetQueue(newLink)
+
 	interfaceID := req.peer.lightningID
 	h.interfaces[interfaceID] = append(h.interfaces[interfaceID], newLink)
 
@@ -230,6 +778,7 @@ func (h *htlcSwitch) handleUnregisterLink(req *unregisterLinkMsg) {
 
 		for _, link := range links {
 			delete(h.chanIndex, *link.chanPoint)
+			close(link.quit)
 		}
 		links = nil
 	} else {
@@ -237,7 +786,8 @@ func (h *htlcSwitch) handleUnregisterLink(req *unregisterLinkMsg) {
 
 		for i := 0; i < len(links); i++ {
 			chanLink := links[i]
-			if chanLink.chanPoint == req.chanPoint {
+			if *chanLink.chanPoint == *req.chanPoint {
+				close(chanLink.quit)
 				copy(links[i:], links[i+1:])
 				links[len(links)-1] = nil
 				links = links[:len(links)-1]
@@ -258,6 +808,21 @@ func (h *htlcSwitch) handleUnregisterLink(req *unregisterLinkMsg) {
 	}
 }
 
+// handleUpdatePolicy replaces the forwarding policy of the target link. If
+// the link isn't currently active, then the request is ignored, as the new
+// policy is loaded from disk once the link is registered.
+func (h *htlcSwitch) handleUpdatePolicy(req *updatePolicyMsg) {
+	if targetLink, ok := h.chanIndex[*req.chanPoint]; ok {
+		hswcLog.Infof("updating forwarding policy of ChannelPoint(%v) "+
+			"to %+v", req.chanPoint, req.policy)
+		targetLink.policy = req.policy
+	}
+
+	if req.done != nil {
+		req.done <- struct{}{}
+	}
+}
+
 // handleCloseLink sends a message to the peer responsible for the target
 // channel point, instructing it to initiate a cooperative channel closure.
 func (h *htlcSwitch) handleCloseLink(req *closeLinkReq) {
@@ -278,18 +843,20 @@ type registerLinkMsg struct {
 	peer     *peer
 	linkInfo *channeldb.ChannelSnapshot
 
-	linkChan chan lnwire.Message
+	linkChan  chan *htlcPacket
+	bandwidth *linkBandwidth
 
 	done chan struct{}
 }
 
 // RegisterLink requests the htlcSwitch to register a new active link. The new
-// link encapsulates an active channel.
+// link encapsulates an active channel, whose available bandwidth is reported
+// by the link through the passed linkBandwidth.
 func (h *htlcSwitch) RegisterLink(p *peer, linkInfo *channeldb.ChannelSnapshot,
-	linkChan chan lnwire.Message) chan *htlcPacket {
+	linkChan chan *htlcPacket, bandwidth *linkBandwidth) chan *htlcPacket {
 
 	done := make(chan struct{}, 1)
-	req := &registerLinkMsg{p, linkInfo, linkChan, done}
+	req := &registerLinkMsg{p, linkInfo, linkChan, bandwidth, done}
 	h.linkControl <- req
 
 	<-done
@@ -316,11 +883,63 @@ func (h *htlcSwitch) UnregisterLink(chanInterface [32]byte, chanPoint *wire.OutP
 	<-done
 }
 
+// bandwidthQuery is a request for the available bandwidth of each active
+// link.
+type bandwidthQuery struct {
+	resp chan map[wire.OutPoint]lnwire.MilliSatoshi
+}
+
+// LinkBandwidths returns the bandwidth available to new HTLCs of each active
+// link, indexed by channel point. The bandwidth of a link reflects the HTLCs
+// already in flight across it, including those not yet committed.
+func (h *htlcSwitch) LinkBandwidths() (map[wire.OutPoint]lnwire.MilliSatoshi, error) {
+	query := &bandwidthQuery{
+		resp: make(chan map[wire.OutPoint]lnwire.MilliSatoshi, 1),
+	}
+
+	select {
+	case h.bandwidthQueries <- query:
+	case <-h.quit:
+		return nil, fmt.Errorf("htlc switch shutting down")
+	}
+
+	return <-query.resp, nil
+}
+
+// updatePolicyMsg is a message which requests the forwarding policy of an
+// active link be updated.
+type updatePolicyMsg struct {
+	chanPoint *wire.OutPoint
+	policy    *channeldb.ForwardingPolicy
+
+	done chan struct{}
+}
+
+// UpdateLinkPolicy requests the htlcSwitch to enforce the passed forwarding
+// policy for all HTLCs forwarded out across the target link.
+func (h *htlcSwitch) UpdateLinkPolicy(chanPoint *wire.OutPoint,
+	policy *channeldb.ForwardingPolicy) {
+
+	done := make(chan struct{}, 1)
+
+	select {
+	case h.policyUpdates <- &updatePolicyMsg{chanPoint, policy, done}:
+	case <-h.quit:
+		return
+	}
+
+	<-done
+}
+
 // closeChanReq represents a request to close a particular channel specified
 // by its outpoint.
 type closeLinkReq struct {
 	chanPoint *wire.OutPoint
 
+	// feePerKb is the fee rate in satoshis per kilobyte paid by the
+	// closure transaction.
+	feePerKb btcutil.Amount
+
 	resp chan *closeLinkResp
 	err  chan error
 }
@@ -335,11 +954,13 @@ type closeLinkResp struct {
 // CloseLink closes an active link targetted by it's channel point. Closing the
 // link initiates a cooperative channel closure.
 // TODO(roabeef): bool flag for timeout/force
-func (h *htlcSwitch) CloseLink(chanPoint *wire.OutPoint) (chan *closeLinkResp, chan error) {
+func (h *htlcSwitch) CloseLink(chanPoint *wire.OutPoint,
+	feePerKb btcutil.Amount) (chan *closeLinkResp, chan error) {
+
 	respChan := make(chan *closeLinkResp, 1)
 	errChan := make(chan error, 1)
 
-	h.linkControl <- &closeLinkReq{chanPoint, respChan, errChan}
+	h.linkControl <- &closeLinkReq{chanPoint, feePerKb, respChan, errChan}
 
 	return respChan, errChan
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/chaincfg"
+	"github.com/roasbeef/btcd/wire"
+)
+
+func TestCheckForwardingPolicy(t *testing.T) {
+	policy := &channeldb.ForwardingPolicy{
+		BaseFee:       10,
+		FeeRate:       1000,
+		TimeLockDelta: 6,
+		MinHTLC:       100,
+		MaxHTLC:       1e6,
+	}
+
+	// Forwarding 10000000 mSAT requires a fee of
+	// 10000 + 10000000*1000/1e6 = 20000 mSAT.
+	tests := []struct {
+		incomingAmt    int64
+		incomingExpiry uint32
+		outgoingAmt    int64
+		outgoingExpiry uint32
+		valid          bool
+	}{
+		{10020000, 106, 10000000, 100, true},
+		{10030000, 110, 10000000, 100, true},
+		{10019999, 106, 10000000, 100, false},
+		{10020000, 105, 10000000, 100, false},
+		{120000, 106, 99999, 100, false},
+		{2e9, 106, 1e9 + 1, 100, false},
+	}
+	for i, test := range tests {
+		err := checkForwardingPolicy(policy,
+			lnwire.MilliSatoshi(test.incomingAmt), test.incomingExpiry,
+			lnwire.MilliSatoshi(test.outgoingAmt), test.outgoingExpiry)
+		if test.valid && err != nil {
+			t.Fatalf("test #%v: valid forward rejected: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("test #%v: invalid forward accepted", i)
+		}
+	}
+}
+
+func TestLinkBandwidthReservation(t *testing.T) {
+	l := &link{
+		bandwidth: &linkBandwidth{bandwidth: 10000},
+	}
+
+	// HTLCs sent to the link should be reserved from the reported
+	// bandwidth until the link reports having processed them.
+	l.reserveBandwidth(1000)
+	l.reserveBandwidth(2000)
+	if bandwidth := l.availableBandwidth(); bandwidth != 7000 {
+		t.Fatalf("expected bandwidth of 7000, got %v", bandwidth)
+	}
+
+	// Once the link reports having processed the first HTLC, only the
+	// second remains reserved.
+	l.bandwidth.report(9000, 1)
+	if bandwidth := l.availableBandwidth(); bandwidth != 7000 {
+		t.Fatalf("expected bandwidth of 7000, got %v", bandwidth)
+	}
+
+	// Once the second HTLC has been processed too, the bandwidth is
+	// entirely that reported by the link.
+	l.bandwidth.report(8000, 2)
+	if bandwidth := l.availableBandwidth(); bandwidth != 8000 {
+		t.Fatalf("expected bandwidth of 8000, got %v", bandwidth)
+	}
+	if len(l.pendingAdds) != 0 {
+		t.Fatalf("expected no pending adds, got %v", len(l.pendingAdds))
+	}
+}
+
+func TestLinkPacketQueue(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "htlcswitch")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := channeldb.Open(tempDirName, &chaincfg.SegNet4Params)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	h := newHtlcSwitch([32]byte{}, cdb)
+	h.Start()
+	defer h.Stop()
+
+	chanPoint := &wire.OutPoint{Hash: wire.ShaHash{0x01}}
+	linkChan := make(chan *htlcPacket)
+	h.RegisterLink(&peer{}, &channeldb.ChannelSnapshot{
+		ChannelPoint: chanPoint,
+	}, linkChan, &linkBandwidth{})
+
+	// Sending packets to a link which isn't reading them mustn't block
+	// the switch, regardless of how many are sent.
+	l := h.chanIndex[*chanPoint]
+	numPackets := htlcQueueSize * 2
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < numPackets; i++ {
+			h.sendToLink(l, &htlcPacket{circuitID: uint64(i)})
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("switch blocked on busy link")
+	}
+
+	// The packets are then delivered to the link in order.
+	for i := 0; i < numPackets; i++ {
+		select {
+		case pkt := <-linkChan:
+			if pkt.circuitID != uint64(i) {
+				t.Fatalf("expected packet %v, got %v", i,
+					pkt.circuitID)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("packet %v not delivered", i)
+		}
+	}
+
+	// Once the link is unregistered, packets sent to it are dropped
+	// rather than blocking the switch.
+	h.UnregisterLink([32]byte{}, chanPoint)
+	done := make(chan struct{})
+	go func() {
+		h.sendToLink(l, &htlcPacket{})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("switch blocked on unregistered link")
+	}
+}
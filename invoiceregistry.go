@@ -2,53 +2,606 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/btcsuite/fastsha256"
+	"github.com/lightningnetwork/lnd/chainntfs"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/roasbeef/btcd/wire"
-	"github.com/roasbeef/btcutil"
 )
 
+// mppTimeout is the time the shards of a multi-path payment are held while
+// waiting for the remainder of the invoice total to arrive. Once it expires,
+// all the held shards are cancelled.
+const mppTimeout = 60 * time.Second
+
+const (
+	// defaultHoldInvoiceExpiry is the expiry of hold invoices created
+	// without one.
+	defaultHoldInvoiceExpiry = time.Hour
+
+	// holdCancelDelta is the number of blocks before the earliest of the
+	// HTLCs paying a hold invoice expires at which they're cancelled,
+	// should the invoice not have been settled by then.
+	holdCancelDelta = 3
+)
+
+var (
+	// errUnknownInvoice is returned when an HTLC pays to a payment hash
+	// which matches no invoice.
+	errUnknownInvoice = errors.New("no invoice for payment hash")
+
+	// errInvoiceContractMismatch is returned when an HTLC doesn't commit
+	// to the contract of the invoice it pays.
+	errInvoiceContractMismatch = errors.New("HTLC contract doesn't " +
+		"match invoice")
+
+	// errFinalExpiryTooSoon is returned when an HTLC expires before the
+	// final CLTV delta required by the registry.
+	errFinalExpiryTooSoon = errors.New("HTLC expiry too soon")
+
+	// errInvoiceExpired is returned when an HTLC pays an expired invoice.
+	errInvoiceExpired = errors.New("invoice expired")
+
+	// errInvoiceAlreadyPaid is returned when an HTLC pays an invoice which
+	// has already been settled, and may not be paid again.
+	errInvoiceAlreadyPaid = errors.New("invoice already paid")
+
+	// errInvoiceCanceled is returned when an HTLC pays an invoice which
+	// has been cancelled.
+	errInvoiceCanceled = errors.New("invoice canceled")
+
+	// errNotHoldInvoice is returned when attempting to settle, or cancel
+	// an invoice which isn't a hold invoice.
+	errNotHoldInvoice = errors.New("not a hold invoice")
+
+	// errInvoiceNotAccepted is returned when attempting to settle a hold
+	// invoice whose HTLCs aren't being held.
+	errInvoiceNotAccepted = errors.New("invoice hasn't been accepted")
+)
+
+// invoiceFailCode returns the fail code an HTLC rejected by the invoice
+// registry with the passed error is cancelled with.
+func invoiceFailCode(err error) lnwire.FailCode {
+	switch err {
+	case errFinalExpiryTooSoon:
+		return lnwire.FailCodeFinalExpiryTooSoon
+	case errInvoiceExpired:
+		return lnwire.FailCodeInvoiceExpired
+	case errInvoiceAlreadyPaid:
+		return lnwire.FailCodeInvoiceAlreadyPaid
+	default:
+		return lnwire.FailCodeUnknownPaymentHash
+	}
+}
+
 // invoice represents a payment invoice which will be dispatched via the
 // Lightning Network.
 type invoice struct {
-	value btcutil.Amount
+	// value is the amount requested by the invoice. A value of zero
+	// accepts a payment of any amount.
+	value lnwire.MilliSatoshi
+
+	paymentHash wire.ShaHash
+
+	// contractType and redemptionHashes are the N-of-M contract which
+	// HTLCs paying the invoice must commit to. Regular invoices have a
+	// contract type of zero, and their payment hash as their single
+	// redemption hash, while multi-hash escrow invoices list the hash of
+	// each party to the escrow, the first being the payment hash.
+	contractType     uint8
+	redemptionHashes [][32]byte
+
+	// preimages are the known pre-images of the invoice's redemption
+	// hashes, indexed by hash. The held shards are only settled once
+	// enough are known, so the pre-images held by the other parties to an
+	// escrow may be revealed after the payment arrives.
+	preimages map[[32]byte][32]byte
+
+	// creationDate is the time the invoice was added. Once expiry has
+	// elapsed since then, HTLCs paying the invoice are rejected. An
+	// expiry of zero never expires.
+	creationDate time.Time
+	expiry       time.Duration
+
+	// state is the current state of the invoice. Once settled, later
+	// payments are rejected rather than being handed its pre-images
+	// again, unless the invoice is reusable.
+	state          channeldb.ContractState
+	reusable       bool
+	resolutionDate time.Time
+
+	// hold is true for hold invoices, whose pre-image is only handed to
+	// the registry once the decision to settle them has been made. Their
+	// state is persisted within the database.
+	hold bool
+
+	// amtPaid is the total amount of the shards which either settled the
+	// invoice, or were accepted for a hold invoice.
+	amtPaid lnwire.MilliSatoshi
+
+	// heldAmt is the total amount of the shards currently being held
+	// until the invoice total arrives.
+	heldAmt lnwire.MilliSatoshi
+
+	// holders are the links holding the shards, each of which is
+	// notified once the shards are to be either settled, or cancelled.
+	holders []*shardHolder
 
-	paymentHash     wire.ShaHash
-	paymentPreimage wire.ShaHash
+	// shardTimer cancels the held shards once the registry's shard
+	// timeout has elapsed since the first of them arrived.
+	shardTimer *time.Timer
+
+	// holdDeadline is the height of the block at which the shards of a
+	// hold invoice are to be cancelled, as the earliest of them is
+	// nearing expiry. An accepted hold invoice is cancelled once a block
+	// at this height is connected.
+	holdDeadline uint32
 
 	// TODO(roasbeef): other contract stuff
 }
 
+// shardResolution instructs a link to either settle, or cancel all the
+// shards of a payment it holds. The shards are settled with the pre-images of
+// the invoice's redemption hashes.
+type shardResolution struct {
+	payHash   [32]byte
+	preimages [][32]byte
+	settle    bool
+}
+
+// shardHolder is a link holding shards of a multi-path payment.
+type shardHolder struct {
+	// resolutions receives the resolution of the held shards.
+	resolutions chan<- *shardResolution
+
+	// quit is closed once the link exits, at which point the resolution
+	// is no longer delivered.
+	quit <-chan struct{}
+}
+
 // invoiceRegistry is a central registry of all the outstanding invoices
 // created by the daemon. The registry is a thin wrapper around a map in order
 // to ensure that all updates/reads are thread safe.
 type invoiceRegistry struct {
 	sync.RWMutex
 	invoiceIndex map[wire.ShaHash]*invoice
+
+	// shardTimeout is the time shards are held waiting for the rest of
+	// the invoice total to arrive.
+	shardTimeout time.Duration
+
+	// finalCLTVDelta is the minimum number of blocks an HTLC paying an
+	// invoice must remain valid for.
+	finalCLTVDelta uint32
+
+	// bestHeight is the height of the latest block connected to the main
+	// chain, against which the hold deadlines of hold invoices are set.
+	bestHeight uint32
+
+	// db persists the hold invoices.
+	db *channeldb.DB
+
+	// subscriptions receive an update each time the state of an invoice
+	// changes.
+	subscriptions    map[uint64]*invoiceSubscription
+	nextSubscriberID uint64
+
+	wg   sync.WaitGroup
+	quit chan struct{}
 }
 
-// newInvoiceRegistry creates a new invoice registry.
-func newInvoiceRegistry() *invoiceRegistry {
+// newInvoiceRegistry creates a new invoice registry which only accepts HTLCs
+// remaining valid for at least finalCLTVDelta blocks. Hold invoices are
+// persisted within the passed database.
+func newInvoiceRegistry(db *channeldb.DB,
+	finalCLTVDelta uint32) *invoiceRegistry {
+
 	return &invoiceRegistry{
-		invoiceIndex: make(map[wire.ShaHash]*invoice),
+		invoiceIndex:   make(map[wire.ShaHash]*invoice),
+		shardTimeout:   mppTimeout,
+		finalCLTVDelta: finalCLTVDelta,
+		db:             db,
+		subscriptions:  make(map[uint64]*invoiceSubscription),
+		quit:           make(chan struct{}),
+	}
+}
+
+// start subscribes the registry to the blocks connected to the main chain,
+// which drive the cancellation of accepted hold invoices once the HTLCs
+// paying them near expiry.
+func (i *invoiceRegistry) start(notifier chainntnfs.ChainNotifier) error {
+	blockEpochs, err := notifier.RegisterBlockEpochNtfn(0)
+	if err != nil {
+		return err
+	}
+
+	i.wg.Add(1)
+	go i.epochHandler(blockEpochs)
+
+	return nil
+}
+
+// stop signals the registry's goroutines to exit, then waits for them to do
+// so.
+func (i *invoiceRegistry) stop() {
+	close(i.quit)
+	i.wg.Wait()
+}
+
+// epochHandler passes each block epoch to blockConnected until either the
+// registry is stopped, or the chain notifier is shut down.
+//
+// NOTE: This MUST be run as a goroutine.
+func (i *invoiceRegistry) epochHandler(blockEpochs *chainntnfs.BlockEpochEvent) {
+	defer i.wg.Done()
+
+	for {
+		select {
+		case epoch, ok := <-blockEpochs.Epochs:
+			if !ok {
+				return
+			}
+			i.blockConnected(uint32(epoch.Height))
+		case <-i.quit:
+			return
+		}
+	}
+}
+
+// blockConnected records the height of a newly connected block, cancelling
+// each accepted hold invoice whose hold deadline has been reached.
+func (i *invoiceRegistry) blockConnected(height uint32) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.bestHeight = height
+
+	for _, inv := range i.invoiceIndex {
+		if inv.state != channeldb.ContractAccepted ||
+			inv.holdDeadline == 0 || height < inv.holdDeadline {
+
+			continue
+		}
+
+		ltndLog.Infof("Cancelling hold invoice %v, as its HTLCs are "+
+			"nearing expiry", inv.paymentHash)
+		if err := i.cancelInvoice(inv); err != nil {
+			ltndLog.Errorf("unable to cancel invoice %v: %v",
+				inv.paymentHash, err)
+		}
 	}
 }
 
 // addInvoice adds an invoice for the specified amount, identified by the
 // passed preimage. Once this invoice is added, sub-systems within the daemon
 // add/forward HTLC's are able to obtain the proper preimage required for
-// redemption in the case that we're the final destination.
-func (i *invoiceRegistry) addInvoice(amt btcutil.Amount, preimage wire.ShaHash) {
-	paymentHash := wire.ShaHash(fastsha256.Sum256(preimage[:]))
+// redemption in the case that we're the final destination. The invoice
+// expires after the passed expiry, and may only be paid more than once if
+// it's reusable.
+func (i *invoiceRegistry) addInvoice(amt lnwire.MilliSatoshi,
+	preimage wire.ShaHash, expiry time.Duration, reusable bool) {
 
-	i.Lock()
-	i.invoiceIndex[paymentHash] = &invoice{
-		value:           amt,
-		paymentHash:     paymentHash,
-		paymentPreimage: preimage,
+	paymentHash := fastsha256.Sum256(preimage[:])
+
+	// A regular invoice can't fail to be added, as we know the pre-image
+	// of its single redemption hash.
+	i.addMultiHashInvoice(amt, 0, [][32]byte{paymentHash},
+		[][32]byte{[32]byte(preimage)}, expiry, reusable)
+}
+
+// addMultiHashInvoice adds an escrow invoice for the specified amount, which
+// is paid by HTLCs requiring the pre-images of N of the M passed redemption
+// hashes, as encoded by the contract type. The invoice is identified by its
+// first redemption hash. Only the pre-images we already know are passed, the
+// rest may be revealed later via revealPreimage, for example by the arbiter
+// of the escrow. The invoice's expiry, and whether it's reusable are as for
+// addInvoice.
+func (i *invoiceRegistry) addMultiHashInvoice(amt lnwire.MilliSatoshi,
+	contractType uint8, redemptionHashes [][32]byte,
+	preimages [][32]byte, expiry time.Duration, reusable bool) error {
+
+	_, numHashes := lnwire.ParseContractType(contractType)
+	if len(redemptionHashes) != int(numHashes) {
+		return fmt.Errorf("contract type %v requires %v redemption "+
+			"hashes, got %v", contractType, numHashes,
+			len(redemptionHashes))
+	}
+
+	inv := &invoice{
+		value:            amt,
+		paymentHash:      wire.ShaHash(redemptionHashes[0]),
+		contractType:     contractType,
+		redemptionHashes: redemptionHashes,
+		preimages:        make(map[[32]byte][32]byte),
+		creationDate:     time.Now(),
+		expiry:           expiry,
+		state:            channeldb.ContractOpen,
+		reusable:         reusable,
+	}
+	for _, preimage := range preimages {
+		if !inv.addPreimage(preimage) {
+			return fmt.Errorf("pre-image %x doesn't match any "+
+				"redemption hash", preimage[:])
+		}
 	}
+
+	i.Lock()
+	i.invoiceIndex[inv.paymentHash] = inv
+	i.notifySubscribers(inv)
 	i.Unlock()
+
+	return nil
+}
+
+// addHoldInvoice adds an invoice for the specified amount, identified by only
+// its payment hash. As the pre-image isn't known, the HTLCs paying the
+// invoice are held once its total arrives, marking it as accepted. They're
+// held until the invoice is either settled via settleHoldInvoice, or
+// cancelled via cancelHoldInvoice. Should neither happen in time, the
+// invoice is cancelled shortly before the earliest of its HTLCs expires.
+func (i *invoiceRegistry) addHoldInvoice(amt lnwire.MilliSatoshi,
+	payHash [32]byte, expiry time.Duration) error {
+
+	i.Lock()
+	defer i.Unlock()
+
+	if _, ok := i.invoiceIndex[wire.ShaHash(payHash)]; ok {
+		return channeldb.ErrDuplicateInvoice
+	}
+
+	inv := &invoice{
+		value:            amt,
+		paymentHash:      wire.ShaHash(payHash),
+		redemptionHashes: [][32]byte{payHash},
+		preimages:        make(map[[32]byte][32]byte),
+		creationDate:     time.Now(),
+		expiry:           expiry,
+		state:            channeldb.ContractOpen,
+		hold:             true,
+	}
+	if err := i.db.AddInvoice(inv.toDBInvoice()); err != nil {
+		return err
+	}
+
+	i.invoiceIndex[inv.paymentHash] = inv
+	i.notifySubscribers(inv)
+
+	return nil
+}
+
+// loadHoldInvoices adds the hold invoices persisted within the database to
+// the registry. The HTLCs of a channel don't survive a restart, so those held
+// for an accepted invoice can no longer be settled, or cancelled by their
+// channel. Accepted invoices are therefore cancelled, rather than left to be
+// settled without the HTLCs paying them ever being claimed.
+func (i *invoiceRegistry) loadHoldInvoices() error {
+	dbInvoices, err := i.db.FetchAllInvoices()
+	if err != nil {
+		return err
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	for _, dbInvoice := range dbInvoices {
+		inv := &invoice{
+			value:            dbInvoice.Value,
+			paymentHash:      wire.ShaHash(dbInvoice.PaymentHash),
+			redemptionHashes: [][32]byte{dbInvoice.PaymentHash},
+			preimages:        make(map[[32]byte][32]byte),
+			creationDate:     dbInvoice.CreationDate,
+			expiry:           dbInvoice.Expiry,
+			state:            dbInvoice.State,
+			resolutionDate:   dbInvoice.ResolutionDate,
+			hold:             true,
+			amtPaid:          dbInvoice.AmtPaid,
+		}
+		if dbInvoice.State == channeldb.ContractSettled {
+			inv.preimages[dbInvoice.PaymentHash] = dbInvoice.PaymentPreimage
+		}
+
+		i.invoiceIndex[inv.paymentHash] = inv
+
+		if inv.state != channeldb.ContractAccepted {
+			continue
+		}
+
+		ltndLog.Warnf("HTLCs held for hold invoice %v were lost by a "+
+			"restart, cancelling invoice", inv.paymentHash)
+		if err := i.cancelInvoice(inv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// settleHoldInvoice settles the accepted hold invoice of the passed
+// pre-image, instructing the holders of its HTLCs to settle them.
+func (i *invoiceRegistry) settleHoldInvoice(preimage [32]byte) error {
+	payHash := fastsha256.Sum256(preimage[:])
+
+	i.Lock()
+	defer i.Unlock()
+
+	inv, ok := i.invoiceIndex[wire.ShaHash(payHash)]
+	switch {
+	case !ok:
+		return errUnknownInvoice
+	case !inv.hold:
+		return errNotHoldInvoice
+	case inv.state != channeldb.ContractAccepted || len(inv.holders) == 0:
+		return errInvoiceNotAccepted
+	}
+
+	// The settlement is recorded before the pre-image is released, so a
+	// failure leaves the HTLCs held.
+	inv.preimages[payHash] = preimage
+	inv.amtPaid = inv.heldAmt
+	if err := i.updateState(inv, channeldb.ContractSettled); err != nil {
+		delete(inv.preimages, payHash)
+		return err
+	}
+	i.resolveShards(inv, true)
+
+	return nil
+}
+
+// cancelHoldInvoice cancels the unresolved hold invoice of the passed hash,
+// instructing the holders of any of its HTLCs to cancel them. Later payments
+// of the invoice are rejected.
+func (i *invoiceRegistry) cancelHoldInvoice(payHash [32]byte) error {
+	i.Lock()
+	defer i.Unlock()
+
+	inv, ok := i.invoiceIndex[wire.ShaHash(payHash)]
+	switch {
+	case !ok:
+		return errUnknownInvoice
+	case !inv.hold:
+		return errNotHoldInvoice
+	}
+
+	return i.cancelInvoice(inv)
+}
+
+// cancelInvoice cancels the invoice, along with any of its held shards.
+//
+// NOTE: The mutex MUST be held when calling this method.
+func (i *invoiceRegistry) cancelInvoice(inv *invoice) error {
+	if err := i.updateState(inv, channeldb.ContractCanceled); err != nil {
+		return err
+	}
+	if len(inv.holders) != 0 {
+		i.resolveShards(inv, false)
+	}
+
+	return nil
+}
+
+// settleShards marks the invoice as settled, then instructs the holders of
+// its shards to settle them.
+//
+// NOTE: The mutex MUST be held when calling this method.
+func (i *invoiceRegistry) settleShards(inv *invoice) {
+	inv.amtPaid = inv.heldAmt
+	if err := i.updateState(inv, channeldb.ContractSettled); err != nil {
+		ltndLog.Errorf("unable to settle invoice %v: %v",
+			inv.paymentHash, err)
+	}
+	i.resolveShards(inv, true)
+}
+
+// updateState transitions the invoice to the passed state, then notifies the
+// subscribers. The transitions of hold invoices are persisted, so an error is
+// returned if the transition couldn't be recorded.
+//
+// NOTE: The mutex MUST be held when calling this method.
+func (i *invoiceRegistry) updateState(inv *invoice,
+	state channeldb.ContractState) error {
+
+	if inv.hold {
+		payHash := [32]byte(inv.paymentHash)
+
+		var err error
+		switch state {
+		case channeldb.ContractAccepted:
+			err = i.db.AcceptInvoice(payHash, inv.amtPaid)
+		case channeldb.ContractSettled:
+			err = i.db.SettleInvoice(payHash, inv.preimages[payHash])
+		case channeldb.ContractCanceled:
+			err = i.db.CancelInvoice(payHash)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	inv.state = state
+	switch state {
+	case channeldb.ContractSettled, channeldb.ContractCanceled:
+		inv.resolutionDate = time.Now()
+	}
+	i.notifySubscribers(inv)
+
+	return nil
+}
+
+// toDBInvoice returns the database representation of the invoice.
+func (inv *invoice) toDBInvoice() *channeldb.Invoice {
+	payHash := [32]byte(inv.paymentHash)
+	dbInvoice := &channeldb.Invoice{
+		PaymentHash:    payHash,
+		Value:          inv.value,
+		AmtPaid:        inv.amtPaid,
+		Expiry:         inv.expiry,
+		State:          inv.state,
+		CreationDate:   inv.creationDate,
+		ResolutionDate: inv.resolutionDate,
+	}
+	if inv.state == channeldb.ContractSettled {
+		dbInvoice.PaymentPreimage = inv.preimages[payHash]
+	}
+
+	return dbInvoice
+}
+
+// addPreimage records the passed pre-image if it matches one of the
+// invoice's redemption hashes, returning false if it doesn't.
+func (inv *invoice) addPreimage(preimage [32]byte) bool {
+	hash := fastsha256.Sum256(preimage[:])
+	for _, redemptionHash := range inv.redemptionHashes {
+		if hash == redemptionHash {
+			inv.preimages[hash] = preimage
+			return true
+		}
+	}
+
+	return false
+}
+
+// isExpired returns true if the invoice's expiry has elapsed.
+func (inv *invoice) isExpired() bool {
+	return inv.expiry != 0 && time.Since(inv.creationDate) > inv.expiry
+}
+
+// canSettle returns true if enough pre-images of the invoice's redemption
+// hashes are known to settle the HTLCs paying it.
+func (inv *invoice) canSettle() bool {
+	numRequired, _ := lnwire.ParseContractType(inv.contractType)
+	return len(inv.preimages) >= int(numRequired)
+}
+
+// revealPreimage records the pre-image of a redemption hash of an escrow
+// invoice. If the invoice total has already arrived, and the pre-image
+// completes the set required, then the held shards are settled. An error is
+// returned if the pre-image matches no invoice.
+func (i *invoiceRegistry) revealPreimage(preimage [32]byte) error {
+	i.Lock()
+	defer i.Unlock()
+
+	for _, inv := range i.invoiceIndex {
+		// Hold invoices are only settled via settleHoldInvoice.
+		if inv.hold || !inv.addPreimage(preimage) {
+			continue
+		}
+
+		if len(inv.holders) != 0 && inv.heldAmt >= inv.value &&
+			inv.canSettle() {
+
+			i.settleShards(inv)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("no invoice for pre-image %x", preimage[:])
 }
 
 // lookupInvoice looks up an invoice by it's payment hash (R-Hash), if found
@@ -61,6 +614,159 @@ func (i *invoiceRegistry) lookupInvoice(hash wire.ShaHash) (*invoice, bool) {
 	return inv, ok
 }
 
+// acceptShard holds an HTLC paying to the invoice of the passed hash until
+// the sum of all the held HTLCs reaches the invoice total. Once it does, the
+// holders of every shard are instructed to settle them together. If the
+// total fails to arrive within the shard timeout, then they're instead instructed
+// to cancel them, so a payment short of the invoice value is never settled.
+//
+// An error is returned if there's no invoice for the hash, if the HTLC's
+// contract doesn't match that of the invoice, if the HTLC's expiry doesn't
+// leave the final CLTV delta, or if the invoice has expired, or has already
+// been paid. The HTLC should then be cancelled with the fail code returned by
+// invoiceFailCode.
+//
+// If the total of an escrow invoice arrives before enough pre-images are
+// known, then the shards remain held until the missing pre-images are
+// revealed. Likewise, the shards of a hold invoice remain held until it's
+// either settled or cancelled.
+func (i *invoiceRegistry) acceptShard(hash wire.ShaHash,
+	amt lnwire.MilliSatoshi, expiry uint32, contractType uint8,
+	redemptionHashes [][32]byte, holder *shardHolder) error {
+
+	i.Lock()
+	defer i.Unlock()
+
+	inv, ok := i.invoiceIndex[hash]
+	if !ok {
+		return errUnknownInvoice
+	}
+
+	// Otherwise, the sender could pay an escrow invoice with an HTLC
+	// which doesn't commit to the hashes of the other parties.
+	if contractType != inv.contractType ||
+		!reflect.DeepEqual(redemptionHashes, inv.redemptionHashes) {
+
+		return errInvoiceContractMismatch
+	}
+
+	// We need enough blocks before the HTLC expires to claim it on-chain
+	// should the channel be closed before it's settled.
+	if expiry < i.finalCLTVDelta {
+		return errFinalExpiryTooSoon
+	}
+
+	switch {
+	case inv.state == channeldb.ContractSettled && !inv.reusable:
+		return errInvoiceAlreadyPaid
+	case inv.state == channeldb.ContractCanceled:
+		return errInvoiceCanceled
+	case inv.isExpired():
+		return errInvoiceExpired
+	}
+
+	// A link holding several shards only needs to be notified once.
+	isHolder := false
+	for _, h := range inv.holders {
+		if h.resolutions == holder.resolutions {
+			isHolder = true
+			break
+		}
+	}
+	if !isHolder {
+		inv.holders = append(inv.holders, holder)
+	}
+	inv.heldAmt += amt
+
+	// The shards of a hold invoice mustn't be held past the block at
+	// which the earliest of them is nearing expiry.
+	if inv.hold {
+		deadline := i.bestHeight + expiry - holdCancelDelta
+		if inv.holdDeadline == 0 || deadline < inv.holdDeadline {
+			inv.holdDeadline = deadline
+		}
+	}
+
+	if inv.heldAmt >= inv.value {
+		if inv.canSettle() {
+			i.settleShards(inv)
+			return nil
+		}
+
+		// The whole payment has arrived, so it's no longer at risk
+		// of being cancelled, and is instead held until the missing
+		// pre-images are revealed.
+		if inv.shardTimer != nil {
+			inv.shardTimer.Stop()
+			inv.shardTimer = nil
+		}
+		if !inv.hold {
+			return nil
+		}
+
+		// A hold invoice is accepted, then cancelled by blockConnected
+		// at the hold deadline unless it has been settled by then.
+		inv.amtPaid = inv.heldAmt
+		err := i.updateState(inv, channeldb.ContractAccepted)
+		if err != nil {
+			ltndLog.Errorf("unable to accept invoice %v: %v",
+				inv.paymentHash, err)
+		}
+		return nil
+	}
+
+	if inv.shardTimer == nil {
+		inv.shardTimer = time.AfterFunc(i.shardTimeout, func() {
+			i.Lock()
+			defer i.Unlock()
+
+			if len(inv.holders) != 0 {
+				i.resolveShards(inv, false)
+			}
+		})
+	}
+
+	return nil
+}
+
+// resolveShards notifies all the holders of shards of the invoice to either
+// settle, or cancel them, then resets the invoice's held shards.
+//
+// NOTE: The mutex MUST be held when calling this method.
+func (i *invoiceRegistry) resolveShards(inv *invoice, settle bool) {
+	resolution := &shardResolution{
+		payHash: [32]byte(inv.paymentHash),
+		settle:  settle,
+	}
+
+	// The pre-images are presented in the order of the redemption hashes.
+	for _, hash := range inv.redemptionHashes {
+		if preimage, ok := inv.preimages[hash]; ok {
+			resolution.preimages = append(resolution.preimages,
+				preimage)
+		}
+	}
+
+	// Each notification is delivered within its own goroutine, as the
+	// holder may well be the caller of acceptShard.
+	for _, holder := range inv.holders {
+		go func(h *shardHolder) {
+			select {
+			case h.resolutions <- resolution:
+			case <-h.quit:
+			}
+		}(holder)
+	}
+
+	if inv.shardTimer != nil {
+		inv.shardTimer.Stop()
+		inv.shardTimer = nil
+	}
+	inv.holdDeadline = 0
+	inv.heldAmt = 0
+	inv.holders = nil
+}
+
 var (
 	debugPre, _ = wire.NewShaHash(bytes.Repeat([]byte{1}, 32))
 	debugHash   = wire.ShaHash(fastsha256.Sum256(debugPre[:]))
@@ -70,8 +776,105 @@ var (
 // daemon.
 func (i *invoiceRegistry) debugInvoice() *invoice {
 	return &invoice{
-		value:           btcutil.Amount(100000 * 1e8),
-		paymentPreimage: *debugPre,
-		paymentHash:     debugHash,
+		value:            lnwire.NewMSatFromSatoshis(100000 * 1e8),
+		paymentHash:      debugHash,
+		redemptionHashes: [][32]byte{[32]byte(debugHash)},
+		preimages: map[[32]byte][32]byte{
+			[32]byte(debugHash): [32]byte(*debugPre),
+		},
+		creationDate: time.Now(),
+		state:        channeldb.ContractOpen,
+		reusable:     true,
+	}
+}
+
+// invoiceSubscription receives an update each time the state of an invoice
+// changes.
+type invoiceSubscription struct {
+	id uint64
+
+	// updates receives the updated invoices, in the order of their
+	// updates.
+	updates chan *channeldb.Invoice
+
+	// ntfnQueue receives the updates from the registry, which are queued
+	// until they're read from updates, so a slow subscriber never blocks
+	// the registry.
+	ntfnQueue chan *channeldb.Invoice
+
+	quit chan struct{}
+}
+
+// subscribe returns a new subscription to the updates of all invoices. The
+// subscription must be cancelled via unsubscribe once it's no longer needed.
+func (i *invoiceRegistry) subscribe() *invoiceSubscription {
+	i.Lock()
+	defer i.Unlock()
+
+	sub := &invoiceSubscription{
+		id:        i.nextSubscriberID,
+		updates:   make(chan *channeldb.Invoice),
+		ntfnQueue: make(chan *channeldb.Invoice),
+		quit:      make(chan struct{}),
+	}
+	i.nextSubscriberID++
+	i.subscriptions[sub.id] = sub
+
+	go sub.queueUpdates()
+
+	return sub
+}
+
+// unsubscribe cancels the passed subscription.
+func (i *invoiceRegistry) unsubscribe(sub *invoiceSubscription) {
+	i.Lock()
+	defer i.Unlock()
+
+	if _, ok := i.subscriptions[sub.id]; !ok {
+		return
+	}
+	delete(i.subscriptions, sub.id)
+	close(sub.quit)
+}
+
+// notifySubscribers delivers the current state of the invoice to all the
+// subscribers.
+//
+// NOTE: The mutex MUST be held when calling this method.
+func (i *invoiceRegistry) notifySubscribers(inv *invoice) {
+	for _, sub := range i.subscriptions {
+		select {
+		case sub.ntfnQueue <- inv.toDBInvoice():
+		case <-sub.quit:
+		}
+	}
+}
+
+// queueUpdates queues the updates received from the registry until the
+// subscriber reads them.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *invoiceSubscription) queueUpdates() {
+	var queue []*channeldb.Invoice
+	for {
+		// The next update is only offered to the subscriber if one is
+		// queued.
+		var (
+			updates chan *channeldb.Invoice
+			next    *channeldb.Invoice
+		)
+		if len(queue) != 0 {
+			updates = s.updates
+			next = queue[0]
+		}
+
+		select {
+		case inv := <-s.ntfnQueue:
+			queue = append(queue, inv)
+		case updates <- next:
+			queue = queue[1:]
+		case <-s.quit:
+			return
+		}
 	}
 }
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/fastsha256"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/chaincfg"
+	"github.com/roasbeef/btcd/wire"
+)
+
+func TestInvoiceRegistryHoldShards(t *testing.T) {
+	registry := newInvoiceRegistry(nil, 9)
+	registry.shardTimeout = 50 * time.Millisecond
+
+	preimage := wire.ShaHash{0x01}
+	payHash := wire.ShaHash(fastsha256.Sum256(preimage[:]))
+	// The invoice is reusable, so that it may be paid more than once.
+	registry.addInvoice(1000, preimage, 0, true)
+	hashes := [][32]byte{[32]byte(payHash)}
+
+	quit := make(chan struct{})
+	defer close(quit)
+
+	resolutionsA := make(chan *shardResolution, 1)
+	resolutionsB := make(chan *shardResolution, 1)
+	holderA := &shardHolder{resolutions: resolutionsA, quit: quit}
+	holderB := &shardHolder{resolutions: resolutionsB, quit: quit}
+
+	expectResolution := func(resolutions chan *shardResolution,
+		settle bool) {
+
+		select {
+		case res := <-resolutions:
+			if res.settle != settle {
+				t.Fatalf("expected settle=%v, got %v", settle,
+					res.settle)
+			}
+			if res.settle && res.preimages[0] != [32]byte(preimage) {
+				t.Fatalf("wrong preimage in resolution")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("shards weren't resolved")
+		}
+	}
+	expectNoResolution := func(resolutions chan *shardResolution) {
+		select {
+		case <-resolutions:
+			t.Fatalf("shards resolved before the invoice total " +
+				"arrived")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	// A payment to an unknown hash should be rejected.
+	if err := registry.acceptShard(wire.ShaHash{0x02}, 1000, 9, 0, nil, holderA); err == nil {
+		t.Fatalf("shard of unknown invoice accepted")
+	}
+
+	// The first shards fall short of the invoice total, so they should be
+	// held.
+	if err := registry.acceptShard(payHash, 300, 9, 0, hashes, holderA); err != nil {
+		t.Fatalf("unable to accept shard: %v", err)
+	}
+	if err := registry.acceptShard(payHash, 300, 9, 0, hashes, holderB); err != nil {
+		t.Fatalf("unable to accept shard: %v", err)
+	}
+	expectNoResolution(resolutionsA)
+
+	// Once the total arrives, every holder should be told to settle,
+	// including a holder with several shards, which is only notified
+	// once.
+	if err := registry.acceptShard(payHash, 400, 9, 0, hashes, holderA); err != nil {
+		t.Fatalf("unable to accept shard: %v", err)
+	}
+	expectResolution(resolutionsA, true)
+	expectResolution(resolutionsB, true)
+	expectNoResolution(resolutionsA)
+
+	// If the rest of a later payment never arrives, its shards should be
+	// cancelled once the shard timeout expires.
+	if err := registry.acceptShard(payHash, 500, 9, 0, hashes, holderB); err != nil {
+		t.Fatalf("unable to accept shard: %v", err)
+	}
+	expectResolution(resolutionsB, false)
+}
+
+func TestInvoiceRegistryMultiHashEscrow(t *testing.T) {
+	registry := newInvoiceRegistry(nil, 9)
+
+	// The invoice is paid by a 2-of-3 HTLC, of whose pre-images we only
+	// know our own. The buyer, and the arbiter of the escrow hold the
+	// others.
+	preimages := [][32]byte{{0x01}, {0x02}, {0x03}}
+	hashes := make([][32]byte, len(preimages))
+	for i, preimage := range preimages {
+		hashes[i] = fastsha256.Sum256(preimage[:])
+	}
+	payHash := wire.ShaHash(hashes[0])
+	contractType := lnwire.NewMultiHashContract(2, 3)
+
+	err := registry.addMultiHashInvoice(1000, contractType, hashes,
+		[][32]byte{{0x04}}, 0, false)
+	if err == nil {
+		t.Fatalf("invoice with unrelated pre-image added")
+	}
+	err = registry.addMultiHashInvoice(1000, contractType, hashes,
+		preimages[:1], 0, false)
+	if err != nil {
+		t.Fatalf("unable to add invoice: %v", err)
+	}
+
+	quit := make(chan struct{})
+	defer close(quit)
+	resolutions := make(chan *shardResolution, 1)
+	holder := &shardHolder{resolutions: resolutions, quit: quit}
+
+	// An HTLC which doesn't commit to the hashes of the other parties
+	// should be rejected.
+	err = registry.acceptShard(payHash, 1000, 9, 0, hashes[:1], holder)
+	if err == nil {
+		t.Fatalf("HTLC with mismatched contract accepted")
+	}
+
+	// The whole payment arrives, but it should be held until the
+	// arbiter reveals their pre-image.
+	err = registry.acceptShard(payHash, 1000, 9, contractType, hashes, holder)
+	if err != nil {
+		t.Fatalf("unable to accept shard: %v", err)
+	}
+	select {
+	case <-resolutions:
+		t.Fatalf("payment resolved before enough pre-images known")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := registry.revealPreimage([32]byte{0x04}); err == nil {
+		t.Fatalf("unrelated pre-image accepted")
+	}
+	if err := registry.revealPreimage(preimages[2]); err != nil {
+		t.Fatalf("unable to reveal pre-image: %v", err)
+	}
+
+	select {
+	case res := <-resolutions:
+		if !res.settle {
+			t.Fatalf("escrow payment cancelled")
+		}
+		expected := [][32]byte{preimages[0], preimages[2]}
+		if !reflect.DeepEqual(res.preimages, expected) {
+			t.Fatalf("wrong pre-images: expected %x, got %x",
+				expected, res.preimages)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("escrow payment wasn't settled")
+	}
+}
+
+func TestInvoiceRegistryValidation(t *testing.T) {
+	registry := newInvoiceRegistry(nil, 9)
+
+	preimage := wire.ShaHash{0x01}
+	payHash := wire.ShaHash(fastsha256.Sum256(preimage[:]))
+	hashes := [][32]byte{[32]byte(payHash)}
+	registry.addInvoice(1000, preimage, 0, false)
+
+	expiredPreimage := wire.ShaHash{0x02}
+	expiredHash := wire.ShaHash(fastsha256.Sum256(expiredPreimage[:]))
+	registry.addInvoice(1000, expiredPreimage, time.Millisecond, false)
+
+	quit := make(chan struct{})
+	defer close(quit)
+	resolutions := make(chan *shardResolution, 1)
+	holder := &shardHolder{resolutions: resolutions, quit: quit}
+
+	// An HTLC which doesn't leave the final CLTV delta should be
+	// rejected.
+	err := registry.acceptShard(payHash, 1000, 8, 0, hashes, holder)
+	if err != errFinalExpiryTooSoon {
+		t.Fatalf("expected %v, got %v", errFinalExpiryTooSoon, err)
+	}
+
+	// Once the invoice is paid, the pre-image shouldn't be released to a
+	// second payment.
+	err = registry.acceptShard(payHash, 1000, 9, 0, hashes, holder)
+	if err != nil {
+		t.Fatalf("unable to accept shard: %v", err)
+	}
+	select {
+	case res := <-resolutions:
+		if !res.settle {
+			t.Fatalf("payment cancelled")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("payment wasn't settled")
+	}
+	err = registry.acceptShard(payHash, 1000, 9, 0, hashes, holder)
+	if err != errInvoiceAlreadyPaid {
+		t.Fatalf("expected %v, got %v", errInvoiceAlreadyPaid, err)
+	}
+
+	// A payment of an expired invoice should be rejected.
+	time.Sleep(5 * time.Millisecond)
+	err = registry.acceptShard(expiredHash, 1000, 9, 0,
+		[][32]byte{[32]byte(expiredHash)}, holder)
+	if err != errInvoiceExpired {
+		t.Fatalf("expected %v, got %v", errInvoiceExpired, err)
+	}
+
+	if code := invoiceFailCode(errInvoiceExpired); code !=
+		lnwire.FailCodeInvoiceExpired {
+
+		t.Fatalf("wrong fail code: %v", code)
+	}
+}
+
+func TestInvoiceRegistryHoldInvoice(t *testing.T) {
+	tempDirName, err := ioutil.TempDir("", "invoiceregistry")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDirName)
+
+	cdb, err := channeldb.Open(tempDirName, &chaincfg.SegNet4Params)
+	if err != nil {
+		t.Fatalf("unable to create channeldb: %v", err)
+	}
+	defer cdb.Close()
+
+	registry := newInvoiceRegistry(cdb, 9)
+	sub := registry.subscribe()
+	defer registry.unsubscribe(sub)
+
+	quit := make(chan struct{})
+	defer close(quit)
+	resolutions := make(chan *shardResolution, 1)
+	holder := &shardHolder{resolutions: resolutions, quit: quit}
+
+	expectUpdate := func(payHash [32]byte, state channeldb.ContractState) {
+		select {
+		case inv := <-sub.updates:
+			if inv.PaymentHash != payHash || inv.State != state {
+				t.Fatalf("expected invoice %x to be %v, got "+
+					"%x in state %v", payHash[:], state,
+					inv.PaymentHash[:], inv.State)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no invoice update received")
+		}
+	}
+	expectResolution := func(settle bool) *shardResolution {
+		select {
+		case res := <-resolutions:
+			if res.settle != settle {
+				t.Fatalf("expected settle=%v, got %v", settle,
+					res.settle)
+			}
+			return res
+		case <-time.After(time.Second):
+			t.Fatalf("shards weren't resolved")
+		}
+		return nil
+	}
+
+	preimage := [32]byte{0x01}
+	payHash := fastsha256.Sum256(preimage[:])
+	hashes := [][32]byte{payHash}
+	if err := registry.addHoldInvoice(1000, payHash, time.Hour); err != nil {
+		t.Fatalf("unable to add invoice: %v", err)
+	}
+	expectUpdate(payHash, channeldb.ContractOpen)
+
+	// An open invoice can't be settled, as there are no HTLCs to settle.
+	if err := registry.settleHoldInvoice(preimage); err != errInvoiceNotAccepted {
+		t.Fatalf("expected %v, got %v", errInvoiceNotAccepted, err)
+	}
+
+	// Once the whole payment arrives, the invoice should be accepted,
+	// with the HTLC held until the invoice is settled.
+	err = registry.acceptShard(wire.ShaHash(payHash), 1000, 100, 0, hashes,
+		holder)
+	if err != nil {
+		t.Fatalf("unable to accept shard: %v", err)
+	}
+	expectUpdate(payHash, channeldb.ContractAccepted)
+	select {
+	case <-resolutions:
+		t.Fatalf("hold invoice resolved before being settled")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := registry.settleHoldInvoice(preimage); err != nil {
+		t.Fatalf("unable to settle invoice: %v", err)
+	}
+	expectUpdate(payHash, channeldb.ContractSettled)
+	res := expectResolution(true)
+	if !reflect.DeepEqual(res.preimages, [][32]byte{preimage}) {
+		t.Fatalf("wrong preimages in resolution")
+	}
+
+	dbInvoice, err := cdb.LookupInvoice(payHash)
+	if err != nil {
+		t.Fatalf("unable to lookup invoice: %v", err)
+	}
+	if dbInvoice.State != channeldb.ContractSettled ||
+		dbInvoice.AmtPaid != 1000 || dbInvoice.PaymentPreimage != preimage {
+
+		t.Fatalf("settled invoice not persisted: %v", dbInvoice)
+	}
+
+	// A cancelled invoice should cancel its held HTLC, and reject any
+	// later payment.
+	cancelPreimage := [32]byte{0x02}
+	cancelHash := fastsha256.Sum256(cancelPreimage[:])
+	err = registry.addHoldInvoice(1000, cancelHash, time.Hour)
+	if err != nil {
+		t.Fatalf("unable to add invoice: %v", err)
+	}
+	expectUpdate(cancelHash, channeldb.ContractOpen)
+	err = registry.acceptShard(wire.ShaHash(cancelHash), 1000, 100, 0,
+		[][32]byte{cancelHash}, holder)
+	if err != nil {
+		t.Fatalf("unable to accept shard: %v", err)
+	}
+	expectUpdate(cancelHash, channeldb.ContractAccepted)
+
+	if err := registry.cancelHoldInvoice(cancelHash); err != nil {
+		t.Fatalf("unable to cancel invoice: %v", err)
+	}
+	expectUpdate(cancelHash, channeldb.ContractCanceled)
+	expectResolution(false)
+
+	err = registry.acceptShard(wire.ShaHash(cancelHash), 1000, 100, 0,
+		[][32]byte{cancelHash}, holder)
+	if err != errInvoiceCanceled {
+		t.Fatalf("expected %v, got %v", errInvoiceCanceled, err)
+	}
+
+	// Should the invoice not be settled in time, then it should be
+	// cancelled once the block at which its HTLC nears expiry is
+	// connected.
+	registry.blockConnected(100)
+	expiringPreimage := [32]byte{0x03}
+	expiringHash := fastsha256.Sum256(expiringPreimage[:])
+	err = registry.addHoldInvoice(1000, expiringHash, time.Hour)
+	if err != nil {
+		t.Fatalf("unable to add invoice: %v", err)
+	}
+	expectUpdate(expiringHash, channeldb.ContractOpen)
+	err = registry.acceptShard(wire.ShaHash(expiringHash), 1000, 9, 0,
+		[][32]byte{expiringHash}, holder)
+	if err != nil {
+		t.Fatalf("unable to accept shard: %v", err)
+	}
+	expectUpdate(expiringHash, channeldb.ContractAccepted)
+
+	registry.blockConnected(105)
+	select {
+	case inv := <-sub.updates:
+		t.Fatalf("invoice %x updated before its hold deadline",
+			inv.PaymentHash[:])
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	registry.blockConnected(100 + 9 - holdCancelDelta)
+	expectUpdate(expiringHash, channeldb.ContractCanceled)
+	expectResolution(false)
+
+	// An invoice left accepted when the node shuts down loses its HTLCs.
+	acceptedPreimage := [32]byte{0x04}
+	acceptedHash := fastsha256.Sum256(acceptedPreimage[:])
+	err = registry.addHoldInvoice(1000, acceptedHash, time.Hour)
+	if err != nil {
+		t.Fatalf("unable to add invoice: %v", err)
+	}
+	expectUpdate(acceptedHash, channeldb.ContractOpen)
+	err = registry.acceptShard(wire.ShaHash(acceptedHash), 1000, 100, 0,
+		[][32]byte{acceptedHash}, holder)
+	if err != nil {
+		t.Fatalf("unable to accept shard: %v", err)
+	}
+	expectUpdate(acceptedHash, channeldb.ContractAccepted)
+
+	// After a restart, the persisted invoices should be restored, so a
+	// payment of the settled invoice is still rejected.
+	restored := newInvoiceRegistry(cdb, 9)
+	if err := restored.loadHoldInvoices(); err != nil {
+		t.Fatalf("unable to load invoices: %v", err)
+	}
+	err = restored.acceptShard(wire.ShaHash(payHash), 1000, 100, 0, hashes,
+		holder)
+	if err != errInvoiceAlreadyPaid {
+		t.Fatalf("expected %v, got %v", errInvoiceAlreadyPaid, err)
+	}
+
+	// The accepted invoice should be cancelled, as the HTLCs paying it
+	// can no longer be claimed.
+	dbInvoice, err = cdb.LookupInvoice(acceptedHash)
+	if err != nil {
+		t.Fatalf("unable to lookup invoice: %v", err)
+	}
+	if dbInvoice.State != channeldb.ContractCanceled {
+		t.Fatalf("expected accepted invoice to be cancelled, got %v",
+			dbInvoice.State)
+	}
+	err = restored.settleHoldInvoice(acceptedPreimage)
+	if err != errInvoiceNotAccepted {
+		t.Fatalf("expected %v, got %v", errInvoiceNotAccepted, err)
+	}
+}
@@ -13,9 +13,15 @@ import (
 
 	"google.golang.org/grpc"
 
+	"github.com/lightningnetwork/lnd/chainntfs"
+	"github.com/lightningnetwork/lnd/chainntfs/bitcoindnotify"
+	"github.com/lightningnetwork/lnd/chainntfs/btcdnotify"
+	"github.com/lightningnetwork/lnd/chainntfs/spvnotify"
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/roasbeef/btcrpcclient"
+	"github.com/roasbeef/btcutil"
 )
 
 var (
@@ -39,11 +45,6 @@ func lndMain() error {
 	// Show version at startup.
 	ltndLog.Infof("Version %s", version())
 
-	if loadedConfig.SPVMode == true {
-		shell(loadedConfig.SPVHostAdr, activeNetParams.Params)
-		return err
-	}
-
 	// Enable http profiling server if requested.
 	if cfg.Profile != "" {
 		go func() {
@@ -64,31 +65,165 @@ func lndMain() error {
 	}
 	defer chanDB.Close()
 
-	// Read btcd's for lnwallet's convenience.
-	f, err := os.Open(loadedConfig.RPCCert)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return err
+	// With the channeldb opened, create the wallet controller, signer, and
+	// chain notifier for the selected chain backend, along with a fee
+	// estimator. In SPV mode all four are backed by a single uspv
+	// connection to the configured full node.
+	// With the bitcoind backend, the SPV wallet still holds our funds, but
+	// the chain is watched, and transactions are broadcast via bitcoind's
+	// JSON-RPC interface. Otherwise btcd's RPC interface is used.
+	var (
+		walletController lnwallet.WalletController
+		signer           lnwallet.Signer
+		notifier         chainntnfs.ChainNotifier
+		feeEstimator     lnwallet.FeeEstimator
+	)
+	fallbackFeeRate := btcutil.Amount(loadedConfig.FallbackFeeRate)
+	if loadedConfig.SPVMode || loadedConfig.Bitcoind.Active {
+		// bitcoind doesn't support websockets, so its JSON-RPC
+		// interface is accessed via HTTP POST requests.
+		bitcoindConfig := &btcrpcclient.ConnConfig{
+			Host:         loadedConfig.Bitcoind.RPCHost,
+			User:         loadedConfig.Bitcoind.RPCUser,
+			Pass:         loadedConfig.Bitcoind.RPCPass,
+			DisableTLS:   true,
+			HTTPPostMode: true,
+		}
+
+		spvDir := filepath.Join(loadedConfig.DataDir, "spv")
+		if err := os.MkdirAll(spvDir, 0700); err != nil {
+			return err
+		}
+
+		spvConfig := &lnwallet.SPVConfig{
+			DataDir:    spvDir,
+			RemoteNode: loadedConfig.SPVHostAdr,
+			Birthday:   loadedConfig.SPVBirthday,
+			NetParams:  activeNetParams.Params,
+		}
+		if loadedConfig.Bitcoind.Active {
+			broadcaster, err := bitcoindnotify.NewBroadcaster(
+				bitcoindConfig)
+			if err != nil {
+				return err
+			}
+			spvConfig.Broadcaster = broadcaster
+		}
+		spvWallet, err := lnwallet.NewSPVWallet(spvConfig)
+		if err != nil {
+			fmt.Printf("unable to create spv wallet: %v\n", err)
+			return err
+		}
+
+		cryptoSystem, err := spvWallet.CryptoSystem()
+		if err != nil {
+			return err
+		}
+		chanDB.RegisterCryptoSystem(cryptoSystem)
+
+		// The channeldb doubles as the notifier's height hint cache,
+		// so only the blocks missed while we were offline need to be
+		// rescanned after a restart.
+		if loadedConfig.Bitcoind.Active {
+			notifier, err = bitcoindnotify.NewBitcoindNotifier(
+				bitcoindConfig, loadedConfig.Bitcoind.PollInterval,
+				chanDB)
+		} else {
+			notifier, err = spvnotify.NewSPVNotifier(
+				spvWallet.SPVCon(), chanDB)
+		}
+		if err != nil {
+			fmt.Printf("unable to create notifier: %v\n", err)
+			return err
+		}
+		walletController = spvWallet
+		signer = spvWallet
+
+		// bitcoind shares btcd's estimatefee call, so it's preferred
+		// for fee estimates, as the SPV connection only learns of
+		// fees from the full blocks it ingests in hard mode.
+		if loadedConfig.Bitcoind.Active {
+			feeEstimator, err = lnwallet.NewBtcdFeeEstimator(
+				*bitcoindConfig, fallbackFeeRate)
+			if err != nil {
+				fmt.Printf("unable to create fee estimator: "+
+					"%v\n", err)
+				return err
+			}
+		} else {
+			feeEstimator = lnwallet.NewSPVFeeEstimator(
+				spvWallet.SPVCon(), fallbackFeeRate)
+		}
+	} else {
+		// Read btcd's for lnwallet's convenience.
+		f, err := os.Open(loadedConfig.RPCCert)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+		cert, err := ioutil.ReadAll(f)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+		defer f.Close()
+
+		rpcHost := fmt.Sprintf("%v:%v", loadedConfig.RPCHost,
+			activeNetParams.rpcPort)
+		config := &lnwallet.Config{
+			PrivatePass: []byte("hello"),
+			DataDir:     filepath.Join(loadedConfig.DataDir, "lnwallet"),
+			RpcHost:     rpcHost,
+			RpcUser:     loadedConfig.RPCUser,
+			RpcPass:     loadedConfig.RPCPass,
+			CACert:      cert,
+			NetParams:   activeNetParams.Params,
+		}
+		btcWallet, err := lnwallet.NewBtcWallet(config, chanDB)
+		if err != nil {
+			fmt.Printf("unable to create wallet: %v\n", err)
+			return err
+		}
+		chanDB.RegisterCryptoSystem(btcWallet.CryptoSystem())
+
+		rpcConfig := &btcrpcclient.ConnConfig{
+			Host:                 rpcHost,
+			Endpoint:             "ws",
+			User:                 loadedConfig.RPCUser,
+			Pass:                 loadedConfig.RPCPass,
+			Certificates:         cert,
+			DisableTLS:           false,
+			DisableConnectOnNew:  true,
+			DisableAutoReconnect: false,
+		}
+		notifier, err = btcdnotify.NewBtcdNotifier(rpcConfig, chanDB)
+		if err != nil {
+			fmt.Printf("unable to create notifier: %v\n", err)
+			return err
+		}
+		walletController = btcWallet
+		signer = btcWallet
+
+		feeEstimator, err = lnwallet.NewBtcdFeeEstimator(*rpcConfig,
+			fallbackFeeRate)
+		if err != nil {
+			fmt.Printf("unable to create fee estimator: %v\n", err)
+			return err
+		}
 	}
-	cert, err := ioutil.ReadAll(f)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return err
+
+	// A static fee rate, if configured, overrides the estimates of the
+	// chain backend.
+	if loadedConfig.StaticFeeRate != 0 {
+		feeEstimator = &lnwallet.StaticFeeEstimator{
+			FeeRate: btcutil.Amount(loadedConfig.StaticFeeRate),
+		}
 	}
-	defer f.Close()
 
 	// Create, and start the lnwallet, which handles the core payment channel
 	// logic, and exposes control via proxy state machines.
-	config := &lnwallet.Config{
-		PrivatePass: []byte("hello"),
-		DataDir:     filepath.Join(loadedConfig.DataDir, "lnwallet"),
-		RpcHost:     fmt.Sprintf("%v:%v", loadedConfig.RPCHost, activeNetParams.rpcPort),
-		RpcUser:     loadedConfig.RPCUser,
-		RpcPass:     loadedConfig.RPCPass,
-		CACert:      cert,
-		NetParams:   activeNetParams.Params,
-	}
-	wallet, err := lnwallet.NewLightningWallet(config, chanDB)
+	wallet, err := lnwallet.NewLightningWallet(chanDB, notifier,
+		walletController, signer, activeNetParams.Params)
 	if err != nil {
 		fmt.Printf("unable to create wallet: %v\n", err)
 		return err
@@ -99,15 +234,14 @@ func lndMain() error {
 	}
 	ltndLog.Info("LightningWallet opened")
 
-	ec := &lnwallet.WaddrmgrEncryptorDecryptor{wallet.Manager}
-	chanDB.RegisterCryptoSystem(ec)
-
 	// Set up the core server which will listen for incoming peer
 	// connections.
 	defaultListenAddrs := []string{
 		net.JoinHostPort("", strconv.Itoa(loadedConfig.PeerPort)),
 	}
-	server, err := newServer(defaultListenAddrs, wallet, chanDB)
+	server, err := newServer(defaultListenAddrs, wallet, chanDB,
+		feeEstimator, loadedConfig.ChanRefreshInterval, loadedConfig.TrickleDelay,
+		loadedConfig.MaxBatchSize, loadedConfig.FinalCLTVDelta)
 	if err != nil {
 		srvrLog.Errorf("unable to create server: %v\n", err)
 		return err
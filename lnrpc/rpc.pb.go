@@ -39,6 +39,38 @@ It has these top-level messages:
 	PendingChannelResponse
 	WalletBalanceRequest
 	WalletBalanceResponse
+	ShowRoutingTableRequest
+	ShowRoutingTableResponse
+	QueryRoutesRequest
+	Hop
+	Route
+	QueryRoutesResponse
+	PolicyUpdateRequest
+	PolicyUpdateResponse
+	ForwardingHistoryRequest
+	ForwardingEvent
+	ForwardingHistoryResponse
+	FeeReportRequest
+	ChannelFeeReport
+	FeeReportResponse
+	Payment
+	PaymentAttempt
+	ListPaymentsRequest
+	ListPaymentsResponse
+	DeletePaymentsRequest
+	DeletePaymentsResponse
+	AddHoldInvoiceRequest
+	AddHoldInvoiceResponse
+	SettleInvoiceRequest
+	SettleInvoiceResponse
+	CancelInvoiceRequest
+	CancelInvoiceResponse
+	InvoiceSubscription
+	AddEscrowInvoiceRequest
+	AddEscrowInvoiceResponse
+	RevealPreimageRequest
+	RevealPreimageResponse
+	Invoice
 */
 package lnrpc
 
@@ -112,11 +144,89 @@ func (NewAddressRequest_AddressType) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor0, []int{8, 0}
 }
 
+type Payment_PaymentStatus int32
+
+const (
+	Payment_UNKNOWN     Payment_PaymentStatus = 0
+	Payment_IN_FLIGHT   Payment_PaymentStatus = 1
+	Payment_SUCCEEDED   Payment_PaymentStatus = 2
+	Payment_FAILED      Payment_PaymentStatus = 3
+	Payment_INTERRUPTED Payment_PaymentStatus = 4
+)
+
+var Payment_PaymentStatus_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "IN_FLIGHT",
+	2: "SUCCEEDED",
+	3: "FAILED",
+	4: "INTERRUPTED",
+}
+var Payment_PaymentStatus_value = map[string]int32{
+	"UNKNOWN":     0,
+	"IN_FLIGHT":   1,
+	"SUCCEEDED":   2,
+	"FAILED":      3,
+	"INTERRUPTED": 4,
+}
+
+func (x Payment_PaymentStatus) String() string {
+	return proto.EnumName(Payment_PaymentStatus_name, int32(x))
+}
+func (Payment_PaymentStatus) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{44, 0} }
+
+type Invoice_InvoiceState int32
+
+const (
+	Invoice_UNKNOWN  Invoice_InvoiceState = 0
+	Invoice_OPEN     Invoice_InvoiceState = 1
+	Invoice_ACCEPTED Invoice_InvoiceState = 2
+	Invoice_SETTLED  Invoice_InvoiceState = 3
+	Invoice_CANCELED Invoice_InvoiceState = 4
+)
+
+var Invoice_InvoiceState_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "OPEN",
+	2: "ACCEPTED",
+	3: "SETTLED",
+	4: "CANCELED",
+}
+var Invoice_InvoiceState_value = map[string]int32{
+	"UNKNOWN":  0,
+	"OPEN":     1,
+	"ACCEPTED": 2,
+	"SETTLED":  3,
+	"CANCELED": 4,
+}
+
+func (x Invoice_InvoiceState) String() string {
+	return proto.EnumName(Invoice_InvoiceState_name, int32(x))
+}
+func (Invoice_InvoiceState) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{61, 0} }
+
 type SendRequest struct {
 	Dest        []byte `protobuf:"bytes,1,opt,name=dest,proto3" json:"dest,omitempty"`
 	Amt         int64  `protobuf:"varint,2,opt,name=amt" json:"amt,omitempty"`
 	PaymentHash []byte `protobuf:"bytes,3,opt,name=payment_hash,proto3" json:"payment_hash,omitempty"`
 	FastSend    bool   `protobuf:"varint,4,opt,name=fast_send" json:"fast_send,omitempty"`
+	// The maximum total fee to pay, defaulting to 5% of the amount.
+	FeeLimit int64 `protobuf:"varint,5,opt,name=fee_limit" json:"fee_limit,omitempty"`
+	// The number of seconds after which no further attempts are made to
+	// deliver the payment, defaulting to 60.
+	TimeoutSeconds int32 `protobuf:"varint,6,opt,name=timeout_seconds" json:"timeout_seconds,omitempty"`
+	// The amount to send and the maximum total fee in millisatoshis. If
+	// set, these take precedence over amt and fee_limit.
+	AmtMsat      int64 `protobuf:"varint,7,opt,name=amt_msat" json:"amt_msat,omitempty"`
+	FeeLimitMsat int64 `protobuf:"varint,8,opt,name=fee_limit_msat" json:"fee_limit_msat,omitempty"`
+	// To pay an escrow invoice, its redemption hashes are listed in full,
+	// taking the place of payment_hash, along with the number of their
+	// preimages the receiver must reveal in order to settle the payment.
+	RedemptionHashes [][]byte `protobuf:"bytes,9,rep,name=redemption_hashes,proto3" json:"redemption_hashes,omitempty"`
+	NumRequired      uint32   `protobuf:"varint,10,opt,name=num_required" json:"num_required,omitempty"`
+	// The number of blocks the HTLCs arriving at the receiver remain valid
+	// for, which must satisfy the receiver's minimum. If unset, then the
+	// default of 9 blocks is used.
+	FinalCltvDelta uint32 `protobuf:"varint,11,opt,name=final_cltv_delta" json:"final_cltv_delta,omitempty"`
 }
 
 func (m *SendRequest) Reset()                    { *m = SendRequest{} }
@@ -125,6 +235,14 @@ func (*SendRequest) ProtoMessage()               {}
 func (*SendRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
 type SendResponse struct {
+	// Set if the payment failed, otherwise the preimage, and the route of
+	// each successful shard of the payment are set.
+	PaymentError    string   `protobuf:"bytes,1,opt,name=payment_error" json:"payment_error,omitempty"`
+	PaymentPreimage []byte   `protobuf:"bytes,2,opt,name=payment_preimage,proto3" json:"payment_preimage,omitempty"`
+	PaymentRoutes   []*Route `protobuf:"bytes,3,rep,name=payment_routes" json:"payment_routes,omitempty"`
+	// Every preimage revealed by the receiver, of which payment_preimage
+	// is the first. The settlement of an escrow payment reveals several.
+	PaymentPreimages [][]byte `protobuf:"bytes,4,rep,name=payment_preimages,proto3" json:"payment_preimages,omitempty"`
 }
 
 func (m *SendResponse) Reset()                    { *m = SendResponse{} }
@@ -132,6 +250,13 @@ func (m *SendResponse) String() string            { return proto.CompactTextStri
 func (*SendResponse) ProtoMessage()               {}
 func (*SendResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
 
+func (m *SendResponse) GetPaymentRoutes() []*Route {
+	if m != nil {
+		return m.PaymentRoutes
+	}
+	return nil
+}
+
 type ChannelPoint struct {
 	FundingTxid []byte `protobuf:"bytes,1,opt,name=funding_txid,proto3" json:"funding_txid,omitempty"`
 	OutputIndex uint32 `protobuf:"varint,2,opt,name=output_index" json:"output_index,omitempty"`
@@ -154,6 +279,11 @@ func (*LightningAddress) Descriptor() ([]byte, []int) { return fileDescriptor0,
 
 type SendManyRequest struct {
 	AddrToAmount map[string]int64 `protobuf:"bytes,1,rep,name=AddrToAmount" json:"AddrToAmount,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// The number of blocks the transaction should confirm within, used
+	// to estimate its fee rate. Mutually exclusive with sat_per_byte.
+	TargetConf int32 `protobuf:"varint,2,opt,name=target_conf" json:"target_conf,omitempty"`
+	// An explicit fee rate in satoshis per byte.
+	SatPerByte int64 `protobuf:"varint,3,opt,name=sat_per_byte" json:"sat_per_byte,omitempty"`
 }
 
 func (m *SendManyRequest) Reset()                    { *m = SendManyRequest{} }
@@ -180,6 +310,11 @@ func (*SendManyResponse) Descriptor() ([]byte, []int) { return fileDescriptor0,
 type SendCoinsRequest struct {
 	Addr   string `protobuf:"bytes,1,opt,name=addr" json:"addr,omitempty"`
 	Amount int64  `protobuf:"varint,2,opt,name=amount" json:"amount,omitempty"`
+	// The number of blocks the transaction should confirm within, used
+	// to estimate its fee rate. Mutually exclusive with sat_per_byte.
+	TargetConf int32 `protobuf:"varint,3,opt,name=target_conf" json:"target_conf,omitempty"`
+	// An explicit fee rate in satoshis per byte.
+	SatPerByte int64 `protobuf:"varint,4,opt,name=sat_per_byte" json:"sat_per_byte,omitempty"`
 }
 
 func (m *SendCoinsRequest) Reset()                    { *m = SendCoinsRequest{} }
@@ -261,6 +396,10 @@ type ActiveChannel struct {
 	UnsettledBelance int64   `protobuf:"varint,6,opt,name=unsettled_belance" json:"unsettled_belance,omitempty"`
 	PendingHtlcs     []*HTLC `protobuf:"bytes,7,rep,name=pending_htlcs" json:"pending_htlcs,omitempty"`
 	NumUpdates       uint64  `protobuf:"varint,8,opt,name=num_updates" json:"num_updates,omitempty"`
+	// The balances in millisatoshis, local_balance and remote_balance are
+	// rounded down to whole satoshis.
+	LocalBalanceMsat  int64 `protobuf:"varint,9,opt,name=local_balance_msat" json:"local_balance_msat,omitempty"`
+	RemoteBalanceMsat int64 `protobuf:"varint,10,opt,name=remote_balance_msat" json:"remote_balance_msat,omitempty"`
 }
 
 func (m *ActiveChannel) Reset()                    { *m = ActiveChannel{} }
@@ -386,6 +525,11 @@ type CloseChannelRequest struct {
 	ChannelPoint    *ChannelPoint `protobuf:"bytes,1,opt,name=channel_point" json:"channel_point,omitempty"`
 	TimeLimit       int64         `protobuf:"varint,2,opt,name=time_limit" json:"time_limit,omitempty"`
 	AllowForceClose bool          `protobuf:"varint,3,opt,name=allow_force_close" json:"allow_force_close,omitempty"`
+	// The number of blocks the transaction should confirm within, used
+	// to estimate its fee rate. Mutually exclusive with sat_per_byte.
+	TargetConf int32 `protobuf:"varint,4,opt,name=target_conf" json:"target_conf,omitempty"`
+	// An explicit fee rate in satoshis per byte.
+	SatPerByte int64 `protobuf:"varint,5,opt,name=sat_per_byte" json:"sat_per_byte,omitempty"`
 }
 
 func (m *CloseChannelRequest) Reset()                    { *m = CloseChannelRequest{} }
@@ -528,6 +672,11 @@ type OpenChannelRequest struct {
 	RemoteFundingAmount int64             `protobuf:"varint,4,opt,name=remote_funding_amount" json:"remote_funding_amount,omitempty"`
 	CommissionSize      int64             `protobuf:"varint,5,opt,name=commission_size" json:"commission_size,omitempty"`
 	NumConfs            uint32            `protobuf:"varint,6,opt,name=num_confs" json:"num_confs,omitempty"`
+	// The number of blocks the funding transaction should confirm within, used
+	// to estimate its fee rate. Mutually exclusive with sat_per_byte.
+	TargetConf int32 `protobuf:"varint,7,opt,name=target_conf" json:"target_conf,omitempty"`
+	// An explicit fee rate in satoshis per byte.
+	SatPerByte int64 `protobuf:"varint,8,opt,name=sat_per_byte" json:"sat_per_byte,omitempty"`
 }
 
 func (m *OpenChannelRequest) Reset()                    { *m = OpenChannelRequest{} }
@@ -724,6 +873,442 @@ func (m *WalletBalanceResponse) String() string            { return proto.Compac
 func (*WalletBalanceResponse) ProtoMessage()               {}
 func (*WalletBalanceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
 
+type ShowRoutingTableRequest struct {
+}
+
+func (m *ShowRoutingTableRequest) Reset()                    { *m = ShowRoutingTableRequest{} }
+func (m *ShowRoutingTableRequest) String() string            { return proto.CompactTextString(m) }
+func (*ShowRoutingTableRequest) ProtoMessage()               {}
+func (*ShowRoutingTableRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+type ShowRoutingTableResponse struct {
+	Rt string `protobuf:"bytes,1,opt,name=rt" json:"rt,omitempty"`
+}
+
+func (m *ShowRoutingTableResponse) Reset()                    { *m = ShowRoutingTableResponse{} }
+func (m *ShowRoutingTableResponse) String() string            { return proto.CompactTextString(m) }
+func (*ShowRoutingTableResponse) ProtoMessage()               {}
+func (*ShowRoutingTableResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+type QueryRoutesRequest struct {
+	Dest      []byte `protobuf:"bytes,1,opt,name=dest,proto3" json:"dest,omitempty"`
+	Amt       int64  `protobuf:"varint,2,opt,name=amt" json:"amt,omitempty"`
+	NumRoutes int32  `protobuf:"varint,3,opt,name=num_routes" json:"num_routes,omitempty"`
+	// The amount in millisatoshis, taking precedence over amt if set.
+	AmtMsat int64 `protobuf:"varint,4,opt,name=amt_msat" json:"amt_msat,omitempty"`
+	// The final CLTV delta required by the target, defaulting to 9 blocks.
+	FinalCltvDelta uint32 `protobuf:"varint,5,opt,name=final_cltv_delta" json:"final_cltv_delta,omitempty"`
+}
+
+func (m *QueryRoutesRequest) Reset()                    { *m = QueryRoutesRequest{} }
+func (m *QueryRoutesRequest) String() string            { return proto.CompactTextString(m) }
+func (*QueryRoutesRequest) ProtoMessage()               {}
+func (*QueryRoutesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+type Hop struct {
+	ChanPoint        string `protobuf:"bytes,1,opt,name=chan_point" json:"chan_point,omitempty"`
+	NodeId           string `protobuf:"bytes,2,opt,name=node_id" json:"node_id,omitempty"`
+	ChanCapacity     int64  `protobuf:"varint,3,opt,name=chan_capacity" json:"chan_capacity,omitempty"`
+	AmtToForward     int64  `protobuf:"varint,4,opt,name=amt_to_forward" json:"amt_to_forward,omitempty"`
+	Fee              int64  `protobuf:"varint,5,opt,name=fee" json:"fee,omitempty"`
+	Expiry           uint32 `protobuf:"varint,6,opt,name=expiry" json:"expiry,omitempty"`
+	AmtToForwardMsat int64  `protobuf:"varint,7,opt,name=amt_to_forward_msat" json:"amt_to_forward_msat,omitempty"`
+	FeeMsat          int64  `protobuf:"varint,8,opt,name=fee_msat" json:"fee_msat,omitempty"`
+}
+
+func (m *Hop) Reset()                    { *m = Hop{} }
+func (m *Hop) String() string            { return proto.CompactTextString(m) }
+func (*Hop) ProtoMessage()               {}
+func (*Hop) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+type Route struct {
+	TotalTimeLock uint32 `protobuf:"varint,1,opt,name=total_time_lock" json:"total_time_lock,omitempty"`
+	TotalFees     int64  `protobuf:"varint,2,opt,name=total_fees" json:"total_fees,omitempty"`
+	TotalAmt      int64  `protobuf:"varint,3,opt,name=total_amt" json:"total_amt,omitempty"`
+	Hops          []*Hop `protobuf:"bytes,4,rep,name=hops" json:"hops,omitempty"`
+	TotalFeesMsat int64  `protobuf:"varint,5,opt,name=total_fees_msat" json:"total_fees_msat,omitempty"`
+	TotalAmtMsat  int64  `protobuf:"varint,6,opt,name=total_amt_msat" json:"total_amt_msat,omitempty"`
+}
+
+func (m *Route) Reset()                    { *m = Route{} }
+func (m *Route) String() string            { return proto.CompactTextString(m) }
+func (*Route) ProtoMessage()               {}
+func (*Route) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *Route) GetHops() []*Hop {
+	if m != nil {
+		return m.Hops
+	}
+	return nil
+}
+
+type QueryRoutesResponse struct {
+	Routes []*Route `protobuf:"bytes,1,rep,name=routes" json:"routes,omitempty"`
+}
+
+func (m *QueryRoutesResponse) Reset()                    { *m = QueryRoutesResponse{} }
+func (m *QueryRoutesResponse) String() string            { return proto.CompactTextString(m) }
+func (*QueryRoutesResponse) ProtoMessage()               {}
+func (*QueryRoutesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *QueryRoutesResponse) GetRoutes() []*Route {
+	if m != nil {
+		return m.Routes
+	}
+	return nil
+}
+
+type PolicyUpdateRequest struct {
+	// If unset, then the policy is applied to all active channels.
+	ChanPoint     *ChannelPoint `protobuf:"bytes,1,opt,name=chan_point" json:"chan_point,omitempty"`
+	BaseFee       int64         `protobuf:"varint,2,opt,name=base_fee" json:"base_fee,omitempty"`
+	FeeRate       uint32        `protobuf:"varint,3,opt,name=fee_rate" json:"fee_rate,omitempty"`
+	TimeLockDelta uint32        `protobuf:"varint,4,opt,name=time_lock_delta" json:"time_lock_delta,omitempty"`
+	MinHtlc       int64         `protobuf:"varint,5,opt,name=min_htlc" json:"min_htlc,omitempty"`
+	MaxHtlc       int64         `protobuf:"varint,6,opt,name=max_htlc" json:"max_htlc,omitempty"`
+}
+
+func (m *PolicyUpdateRequest) Reset()                    { *m = PolicyUpdateRequest{} }
+func (m *PolicyUpdateRequest) String() string            { return proto.CompactTextString(m) }
+func (*PolicyUpdateRequest) ProtoMessage()               {}
+func (*PolicyUpdateRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *PolicyUpdateRequest) GetChanPoint() *ChannelPoint {
+	if m != nil {
+		return m.ChanPoint
+	}
+	return nil
+}
+
+type PolicyUpdateResponse struct {
+}
+
+func (m *PolicyUpdateResponse) Reset()                    { *m = PolicyUpdateResponse{} }
+func (m *PolicyUpdateResponse) String() string            { return proto.CompactTextString(m) }
+func (*PolicyUpdateResponse) ProtoMessage()               {}
+func (*PolicyUpdateResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+type ForwardingHistoryRequest struct {
+	// Unix timestamps bounding the events to return. If end_time is
+	// unset, then it defaults to the current time.
+	StartTime int64 `protobuf:"varint,1,opt,name=start_time" json:"start_time,omitempty"`
+	EndTime   int64 `protobuf:"varint,2,opt,name=end_time" json:"end_time,omitempty"`
+	// The number of events within the time range to skip over.
+	IndexOffset uint32 `protobuf:"varint,3,opt,name=index_offset" json:"index_offset,omitempty"`
+	// The maximum number of events to return, defaulting to 100.
+	NumMaxEvents uint32 `protobuf:"varint,4,opt,name=num_max_events" json:"num_max_events,omitempty"`
+}
+
+func (m *ForwardingHistoryRequest) Reset()                    { *m = ForwardingHistoryRequest{} }
+func (m *ForwardingHistoryRequest) String() string            { return proto.CompactTextString(m) }
+func (*ForwardingHistoryRequest) ProtoMessage()               {}
+func (*ForwardingHistoryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+type ForwardingEvent struct {
+	Timestamp    int64  `protobuf:"varint,1,opt,name=timestamp" json:"timestamp,omitempty"`
+	ChanPointIn  string `protobuf:"bytes,2,opt,name=chan_point_in" json:"chan_point_in,omitempty"`
+	ChanPointOut string `protobuf:"bytes,3,opt,name=chan_point_out" json:"chan_point_out,omitempty"`
+	AmtIn        int64  `protobuf:"varint,4,opt,name=amt_in" json:"amt_in,omitempty"`
+	AmtOut       int64  `protobuf:"varint,5,opt,name=amt_out" json:"amt_out,omitempty"`
+	Fee          int64  `protobuf:"varint,6,opt,name=fee" json:"fee,omitempty"`
+	AmtInMsat    int64  `protobuf:"varint,7,opt,name=amt_in_msat" json:"amt_in_msat,omitempty"`
+	AmtOutMsat   int64  `protobuf:"varint,8,opt,name=amt_out_msat" json:"amt_out_msat,omitempty"`
+	FeeMsat      int64  `protobuf:"varint,9,opt,name=fee_msat" json:"fee_msat,omitempty"`
+}
+
+func (m *ForwardingEvent) Reset()                    { *m = ForwardingEvent{} }
+func (m *ForwardingEvent) String() string            { return proto.CompactTextString(m) }
+func (*ForwardingEvent) ProtoMessage()               {}
+func (*ForwardingEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+type ForwardingHistoryResponse struct {
+	ForwardingEvents []*ForwardingEvent `protobuf:"bytes,1,rep,name=forwarding_events" json:"forwarding_events,omitempty"`
+	// The index_offset which should be used to query the next page.
+	LastOffsetIndex uint32 `protobuf:"varint,2,opt,name=last_offset_index" json:"last_offset_index,omitempty"`
+}
+
+func (m *ForwardingHistoryResponse) Reset()                    { *m = ForwardingHistoryResponse{} }
+func (m *ForwardingHistoryResponse) String() string            { return proto.CompactTextString(m) }
+func (*ForwardingHistoryResponse) ProtoMessage()               {}
+func (*ForwardingHistoryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *ForwardingHistoryResponse) GetForwardingEvents() []*ForwardingEvent {
+	if m != nil {
+		return m.ForwardingEvents
+	}
+	return nil
+}
+
+type FeeReportRequest struct {
+}
+
+func (m *FeeReportRequest) Reset()                    { *m = FeeReportRequest{} }
+func (m *FeeReportRequest) String() string            { return proto.CompactTextString(m) }
+func (*FeeReportRequest) ProtoMessage()               {}
+func (*FeeReportRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+type ChannelFeeReport struct {
+	ChanPoint string `protobuf:"bytes,1,opt,name=chan_point" json:"chan_point,omitempty"`
+	BaseFee   int64  `protobuf:"varint,2,opt,name=base_fee" json:"base_fee,omitempty"`
+	FeeRate   uint32 `protobuf:"varint,3,opt,name=fee_rate" json:"fee_rate,omitempty"`
+	// The fees earned by forwarding HTLCs out across the channel within
+	// the past day, week and month.
+	DayFeeSum       int64 `protobuf:"varint,4,opt,name=day_fee_sum" json:"day_fee_sum,omitempty"`
+	WeekFeeSum      int64 `protobuf:"varint,5,opt,name=week_fee_sum" json:"week_fee_sum,omitempty"`
+	MonthFeeSum     int64 `protobuf:"varint,6,opt,name=month_fee_sum" json:"month_fee_sum,omitempty"`
+	DayFeeSumMsat   int64 `protobuf:"varint,7,opt,name=day_fee_sum_msat" json:"day_fee_sum_msat,omitempty"`
+	WeekFeeSumMsat  int64 `protobuf:"varint,8,opt,name=week_fee_sum_msat" json:"week_fee_sum_msat,omitempty"`
+	MonthFeeSumMsat int64 `protobuf:"varint,9,opt,name=month_fee_sum_msat" json:"month_fee_sum_msat,omitempty"`
+}
+
+func (m *ChannelFeeReport) Reset()                    { *m = ChannelFeeReport{} }
+func (m *ChannelFeeReport) String() string            { return proto.CompactTextString(m) }
+func (*ChannelFeeReport) ProtoMessage()               {}
+func (*ChannelFeeReport) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+
+type FeeReportResponse struct {
+	ChannelFees     []*ChannelFeeReport `protobuf:"bytes,1,rep,name=channel_fees" json:"channel_fees,omitempty"`
+	DayFeeSum       int64               `protobuf:"varint,2,opt,name=day_fee_sum" json:"day_fee_sum,omitempty"`
+	WeekFeeSum      int64               `protobuf:"varint,3,opt,name=week_fee_sum" json:"week_fee_sum,omitempty"`
+	MonthFeeSum     int64               `protobuf:"varint,4,opt,name=month_fee_sum" json:"month_fee_sum,omitempty"`
+	DayFeeSumMsat   int64               `protobuf:"varint,5,opt,name=day_fee_sum_msat" json:"day_fee_sum_msat,omitempty"`
+	WeekFeeSumMsat  int64               `protobuf:"varint,6,opt,name=week_fee_sum_msat" json:"week_fee_sum_msat,omitempty"`
+	MonthFeeSumMsat int64               `protobuf:"varint,7,opt,name=month_fee_sum_msat" json:"month_fee_sum_msat,omitempty"`
+}
+
+func (m *FeeReportResponse) Reset()                    { *m = FeeReportResponse{} }
+func (m *FeeReportResponse) String() string            { return proto.CompactTextString(m) }
+func (*FeeReportResponse) ProtoMessage()               {}
+func (*FeeReportResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+
+func (m *FeeReportResponse) GetChannelFees() []*ChannelFeeReport {
+	if m != nil {
+		return m.ChannelFees
+	}
+	return nil
+}
+
+type Payment struct {
+	PaymentHash string `protobuf:"bytes,1,opt,name=payment_hash" json:"payment_hash,omitempty"`
+	// Only set once the payment has succeeded.
+	PaymentPreimage string `protobuf:"bytes,2,opt,name=payment_preimage" json:"payment_preimage,omitempty"`
+	Value           int64  `protobuf:"varint,3,opt,name=value" json:"value,omitempty"`
+	Fee             int64  `protobuf:"varint,4,opt,name=fee" json:"fee,omitempty"`
+	// The lightning ID of each hop of the route, ending with the
+	// destination.
+	Path           []string              `protobuf:"bytes,5,rep,name=path" json:"path,omitempty"`
+	Status         Payment_PaymentStatus `protobuf:"varint,6,opt,name=status,enum=lnrpc.Payment_PaymentStatus" json:"status,omitempty"`
+	FailureReason  string                `protobuf:"bytes,7,opt,name=failure_reason" json:"failure_reason,omitempty"`
+	CreationDate   int64                 `protobuf:"varint,8,opt,name=creation_date" json:"creation_date,omitempty"`
+	ResolutionDate int64                 `protobuf:"varint,9,opt,name=resolution_date" json:"resolution_date,omitempty"`
+	Attempts       []*PaymentAttempt     `protobuf:"bytes,10,rep,name=attempts" json:"attempts,omitempty"`
+	ValueMsat      int64                 `protobuf:"varint,11,opt,name=value_msat" json:"value_msat,omitempty"`
+	FeeMsat        int64                 `protobuf:"varint,12,opt,name=fee_msat" json:"fee_msat,omitempty"`
+}
+
+func (m *Payment) Reset()                    { *m = Payment{} }
+func (m *Payment) String() string            { return proto.CompactTextString(m) }
+func (*Payment) ProtoMessage()               {}
+func (*Payment) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *Payment) GetAttempts() []*PaymentAttempt {
+	if m != nil {
+		return m.Attempts
+	}
+	return nil
+}
+
+type PaymentAttempt struct {
+	Path        []string `protobuf:"bytes,1,rep,name=path" json:"path,omitempty"`
+	Fee         int64    `protobuf:"varint,2,opt,name=fee" json:"fee,omitempty"`
+	AttemptTime int64    `protobuf:"varint,3,opt,name=attempt_time" json:"attempt_time,omitempty"`
+	// Empty for successful attempts.
+	FailureReason string `protobuf:"bytes,4,opt,name=failure_reason" json:"failure_reason,omitempty"`
+	// The amount delivered by the attempt, a payment split into shards
+	// makes an attempt for each shard.
+	Amt     int64 `protobuf:"varint,5,opt,name=amt" json:"amt,omitempty"`
+	FeeMsat int64 `protobuf:"varint,6,opt,name=fee_msat" json:"fee_msat,omitempty"`
+	AmtMsat int64 `protobuf:"varint,7,opt,name=amt_msat" json:"amt_msat,omitempty"`
+}
+
+func (m *PaymentAttempt) Reset()                    { *m = PaymentAttempt{} }
+func (m *PaymentAttempt) String() string            { return proto.CompactTextString(m) }
+func (*PaymentAttempt) ProtoMessage()               {}
+func (*PaymentAttempt) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+type ListPaymentsRequest struct {
+}
+
+func (m *ListPaymentsRequest) Reset()                    { *m = ListPaymentsRequest{} }
+func (m *ListPaymentsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListPaymentsRequest) ProtoMessage()               {}
+func (*ListPaymentsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
+
+type ListPaymentsResponse struct {
+	Payments []*Payment `protobuf:"bytes,1,rep,name=payments" json:"payments,omitempty"`
+}
+
+func (m *ListPaymentsResponse) Reset()                    { *m = ListPaymentsResponse{} }
+func (m *ListPaymentsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListPaymentsResponse) ProtoMessage()               {}
+func (*ListPaymentsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+func (m *ListPaymentsResponse) GetPayments() []*Payment {
+	if m != nil {
+		return m.Payments
+	}
+	return nil
+}
+
+type DeletePaymentsRequest struct {
+	// If set, then only failed payments are deleted. In-flight payments
+	// are never deleted, and payments interrupted by a restart are only
+	// deleted if unset.
+	FailedOnly bool `protobuf:"varint,1,opt,name=failed_only" json:"failed_only,omitempty"`
+}
+
+func (m *DeletePaymentsRequest) Reset()                    { *m = DeletePaymentsRequest{} }
+func (m *DeletePaymentsRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeletePaymentsRequest) ProtoMessage()               {}
+func (*DeletePaymentsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{48} }
+
+type DeletePaymentsResponse struct {
+	NumDeleted uint32 `protobuf:"varint,1,opt,name=num_deleted" json:"num_deleted,omitempty"`
+}
+
+func (m *DeletePaymentsResponse) Reset()                    { *m = DeletePaymentsResponse{} }
+func (m *DeletePaymentsResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeletePaymentsResponse) ProtoMessage()               {}
+func (*DeletePaymentsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{49} }
+
+type AddHoldInvoiceRequest struct {
+	PaymentHash []byte `protobuf:"bytes,1,opt,name=payment_hash,proto3" json:"payment_hash,omitempty"`
+	// If value_msat is set, then it takes precedence over value.
+	Value     int64 `protobuf:"varint,2,opt,name=value" json:"value,omitempty"`
+	ValueMsat int64 `protobuf:"varint,3,opt,name=value_msat" json:"value_msat,omitempty"`
+	// The number of seconds after which the invoice expires. If unset,
+	// then the invoice expires after an hour.
+	Expiry int64 `protobuf:"varint,4,opt,name=expiry" json:"expiry,omitempty"`
+}
+
+func (m *AddHoldInvoiceRequest) Reset()                    { *m = AddHoldInvoiceRequest{} }
+func (m *AddHoldInvoiceRequest) String() string            { return proto.CompactTextString(m) }
+func (*AddHoldInvoiceRequest) ProtoMessage()               {}
+func (*AddHoldInvoiceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{50} }
+
+type AddHoldInvoiceResponse struct {
+}
+
+func (m *AddHoldInvoiceResponse) Reset()                    { *m = AddHoldInvoiceResponse{} }
+func (m *AddHoldInvoiceResponse) String() string            { return proto.CompactTextString(m) }
+func (*AddHoldInvoiceResponse) ProtoMessage()               {}
+func (*AddHoldInvoiceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{51} }
+
+type SettleInvoiceRequest struct {
+	Preimage []byte `protobuf:"bytes,1,opt,name=preimage,proto3" json:"preimage,omitempty"`
+}
+
+func (m *SettleInvoiceRequest) Reset()                    { *m = SettleInvoiceRequest{} }
+func (m *SettleInvoiceRequest) String() string            { return proto.CompactTextString(m) }
+func (*SettleInvoiceRequest) ProtoMessage()               {}
+func (*SettleInvoiceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{52} }
+
+type SettleInvoiceResponse struct {
+}
+
+func (m *SettleInvoiceResponse) Reset()                    { *m = SettleInvoiceResponse{} }
+func (m *SettleInvoiceResponse) String() string            { return proto.CompactTextString(m) }
+func (*SettleInvoiceResponse) ProtoMessage()               {}
+func (*SettleInvoiceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{53} }
+
+type CancelInvoiceRequest struct {
+	PaymentHash []byte `protobuf:"bytes,1,opt,name=payment_hash,proto3" json:"payment_hash,omitempty"`
+}
+
+func (m *CancelInvoiceRequest) Reset()                    { *m = CancelInvoiceRequest{} }
+func (m *CancelInvoiceRequest) String() string            { return proto.CompactTextString(m) }
+func (*CancelInvoiceRequest) ProtoMessage()               {}
+func (*CancelInvoiceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{54} }
+
+type CancelInvoiceResponse struct {
+}
+
+func (m *CancelInvoiceResponse) Reset()                    { *m = CancelInvoiceResponse{} }
+func (m *CancelInvoiceResponse) String() string            { return proto.CompactTextString(m) }
+func (*CancelInvoiceResponse) ProtoMessage()               {}
+func (*CancelInvoiceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{55} }
+
+type InvoiceSubscription struct {
+}
+
+func (m *InvoiceSubscription) Reset()                    { *m = InvoiceSubscription{} }
+func (m *InvoiceSubscription) String() string            { return proto.CompactTextString(m) }
+func (*InvoiceSubscription) ProtoMessage()               {}
+func (*InvoiceSubscription) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{56} }
+
+type AddEscrowInvoiceRequest struct {
+	// The invoice is paid by HTLCs requiring the preimages of num_required
+	// of its redemption hashes, and is identified by the first of them.
+	RedemptionHashes [][]byte `protobuf:"bytes,1,rep,name=redemption_hashes,proto3" json:"redemption_hashes,omitempty"`
+	NumRequired      uint32   `protobuf:"varint,2,opt,name=num_required" json:"num_required,omitempty"`
+	// The preimages which are already known, the rest are revealed via
+	// RevealPreimage, for example by the arbiter of the escrow.
+	Preimages [][]byte `protobuf:"bytes,3,rep,name=preimages,proto3" json:"preimages,omitempty"`
+	// If value_msat is set, then it takes precedence over value.
+	Value     int64 `protobuf:"varint,4,opt,name=value" json:"value,omitempty"`
+	ValueMsat int64 `protobuf:"varint,5,opt,name=value_msat" json:"value_msat,omitempty"`
+	// The number of seconds after which the invoice expires. If unset,
+	// then the invoice never expires.
+	Expiry int64 `protobuf:"varint,6,opt,name=expiry" json:"expiry,omitempty"`
+}
+
+func (m *AddEscrowInvoiceRequest) Reset()                    { *m = AddEscrowInvoiceRequest{} }
+func (m *AddEscrowInvoiceRequest) String() string            { return proto.CompactTextString(m) }
+func (*AddEscrowInvoiceRequest) ProtoMessage()               {}
+func (*AddEscrowInvoiceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{57} }
+
+type AddEscrowInvoiceResponse struct {
+}
+
+func (m *AddEscrowInvoiceResponse) Reset()                    { *m = AddEscrowInvoiceResponse{} }
+func (m *AddEscrowInvoiceResponse) String() string            { return proto.CompactTextString(m) }
+func (*AddEscrowInvoiceResponse) ProtoMessage()               {}
+func (*AddEscrowInvoiceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{58} }
+
+type RevealPreimageRequest struct {
+	Preimage []byte `protobuf:"bytes,1,opt,name=preimage,proto3" json:"preimage,omitempty"`
+}
+
+func (m *RevealPreimageRequest) Reset()                    { *m = RevealPreimageRequest{} }
+func (m *RevealPreimageRequest) String() string            { return proto.CompactTextString(m) }
+func (*RevealPreimageRequest) ProtoMessage()               {}
+func (*RevealPreimageRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{59} }
+
+type RevealPreimageResponse struct {
+}
+
+func (m *RevealPreimageResponse) Reset()                    { *m = RevealPreimageResponse{} }
+func (m *RevealPreimageResponse) String() string            { return proto.CompactTextString(m) }
+func (*RevealPreimageResponse) ProtoMessage()               {}
+func (*RevealPreimageResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{60} }
+
+type Invoice struct {
+	PaymentHash string `protobuf:"bytes,1,opt,name=payment_hash" json:"payment_hash,omitempty"`
+	// Only set once the invoice has been settled.
+	PaymentPreimage string               `protobuf:"bytes,2,opt,name=payment_preimage" json:"payment_preimage,omitempty"`
+	ValueMsat       int64                `protobuf:"varint,3,opt,name=value_msat" json:"value_msat,omitempty"`
+	AmtPaidMsat     int64                `protobuf:"varint,4,opt,name=amt_paid_msat" json:"amt_paid_msat,omitempty"`
+	State           Invoice_InvoiceState `protobuf:"varint,5,opt,name=state,enum=lnrpc.Invoice_InvoiceState" json:"state,omitempty"`
+	CreationDate    int64                `protobuf:"varint,6,opt,name=creation_date" json:"creation_date,omitempty"`
+	Expiry          int64                `protobuf:"varint,7,opt,name=expiry" json:"expiry,omitempty"`
+	ResolutionDate  int64                `protobuf:"varint,8,opt,name=resolution_date" json:"resolution_date,omitempty"`
+}
+
+func (m *Invoice) Reset()                    { *m = Invoice{} }
+func (m *Invoice) String() string            { return proto.CompactTextString(m) }
+func (*Invoice) ProtoMessage()               {}
+func (*Invoice) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{61} }
+
 func init() {
 	proto.RegisterType((*SendRequest)(nil), "lnrpc.SendRequest")
 	proto.RegisterType((*SendResponse)(nil), "lnrpc.SendResponse")
@@ -756,8 +1341,42 @@ func init() {
 	proto.RegisterType((*PendingChannelResponse_PendingChannel)(nil), "lnrpc.PendingChannelResponse.PendingChannel")
 	proto.RegisterType((*WalletBalanceRequest)(nil), "lnrpc.WalletBalanceRequest")
 	proto.RegisterType((*WalletBalanceResponse)(nil), "lnrpc.WalletBalanceResponse")
+	proto.RegisterType((*ShowRoutingTableRequest)(nil), "lnrpc.ShowRoutingTableRequest")
+	proto.RegisterType((*ShowRoutingTableResponse)(nil), "lnrpc.ShowRoutingTableResponse")
+	proto.RegisterType((*QueryRoutesRequest)(nil), "lnrpc.QueryRoutesRequest")
+	proto.RegisterType((*Hop)(nil), "lnrpc.Hop")
+	proto.RegisterType((*Route)(nil), "lnrpc.Route")
+	proto.RegisterType((*QueryRoutesResponse)(nil), "lnrpc.QueryRoutesResponse")
+	proto.RegisterType((*PolicyUpdateRequest)(nil), "lnrpc.PolicyUpdateRequest")
+	proto.RegisterType((*PolicyUpdateResponse)(nil), "lnrpc.PolicyUpdateResponse")
+	proto.RegisterType((*ForwardingHistoryRequest)(nil), "lnrpc.ForwardingHistoryRequest")
+	proto.RegisterType((*ForwardingEvent)(nil), "lnrpc.ForwardingEvent")
+	proto.RegisterType((*ForwardingHistoryResponse)(nil), "lnrpc.ForwardingHistoryResponse")
+	proto.RegisterType((*FeeReportRequest)(nil), "lnrpc.FeeReportRequest")
+	proto.RegisterType((*ChannelFeeReport)(nil), "lnrpc.ChannelFeeReport")
+	proto.RegisterType((*FeeReportResponse)(nil), "lnrpc.FeeReportResponse")
+	proto.RegisterType((*Payment)(nil), "lnrpc.Payment")
+	proto.RegisterType((*PaymentAttempt)(nil), "lnrpc.PaymentAttempt")
+	proto.RegisterType((*ListPaymentsRequest)(nil), "lnrpc.ListPaymentsRequest")
+	proto.RegisterType((*ListPaymentsResponse)(nil), "lnrpc.ListPaymentsResponse")
+	proto.RegisterType((*DeletePaymentsRequest)(nil), "lnrpc.DeletePaymentsRequest")
+	proto.RegisterType((*DeletePaymentsResponse)(nil), "lnrpc.DeletePaymentsResponse")
+	proto.RegisterType((*AddHoldInvoiceRequest)(nil), "lnrpc.AddHoldInvoiceRequest")
+	proto.RegisterType((*AddHoldInvoiceResponse)(nil), "lnrpc.AddHoldInvoiceResponse")
+	proto.RegisterType((*SettleInvoiceRequest)(nil), "lnrpc.SettleInvoiceRequest")
+	proto.RegisterType((*SettleInvoiceResponse)(nil), "lnrpc.SettleInvoiceResponse")
+	proto.RegisterType((*CancelInvoiceRequest)(nil), "lnrpc.CancelInvoiceRequest")
+	proto.RegisterType((*CancelInvoiceResponse)(nil), "lnrpc.CancelInvoiceResponse")
+	proto.RegisterType((*InvoiceSubscription)(nil), "lnrpc.InvoiceSubscription")
+	proto.RegisterType((*AddEscrowInvoiceRequest)(nil), "lnrpc.AddEscrowInvoiceRequest")
+	proto.RegisterType((*AddEscrowInvoiceResponse)(nil), "lnrpc.AddEscrowInvoiceResponse")
+	proto.RegisterType((*RevealPreimageRequest)(nil), "lnrpc.RevealPreimageRequest")
+	proto.RegisterType((*RevealPreimageResponse)(nil), "lnrpc.RevealPreimageResponse")
+	proto.RegisterType((*Invoice)(nil), "lnrpc.Invoice")
 	proto.RegisterEnum("lnrpc.ChannelStatus", ChannelStatus_name, ChannelStatus_value)
 	proto.RegisterEnum("lnrpc.NewAddressRequest_AddressType", NewAddressRequest_AddressType_name, NewAddressRequest_AddressType_value)
+	proto.RegisterEnum("lnrpc.Payment_PaymentStatus", Payment_PaymentStatus_name, Payment_PaymentStatus_value)
+	proto.RegisterEnum("lnrpc.Invoice_InvoiceState", Invoice_InvoiceState_name, Invoice_InvoiceState_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -782,6 +1401,19 @@ type LightningClient interface {
 	CloseChannel(ctx context.Context, in *CloseChannelRequest, opts ...grpc.CallOption) (Lightning_CloseChannelClient, error)
 	PendingChannels(ctx context.Context, in *PendingChannelRequest, opts ...grpc.CallOption) (*PendingChannelResponse, error)
 	SendPayment(ctx context.Context, opts ...grpc.CallOption) (Lightning_SendPaymentClient, error)
+	ShowRoutingTable(ctx context.Context, in *ShowRoutingTableRequest, opts ...grpc.CallOption) (*ShowRoutingTableResponse, error)
+	QueryRoutes(ctx context.Context, in *QueryRoutesRequest, opts ...grpc.CallOption) (*QueryRoutesResponse, error)
+	UpdateChannelPolicy(ctx context.Context, in *PolicyUpdateRequest, opts ...grpc.CallOption) (*PolicyUpdateResponse, error)
+	ForwardingHistory(ctx context.Context, in *ForwardingHistoryRequest, opts ...grpc.CallOption) (*ForwardingHistoryResponse, error)
+	FeeReport(ctx context.Context, in *FeeReportRequest, opts ...grpc.CallOption) (*FeeReportResponse, error)
+	ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*ListPaymentsResponse, error)
+	DeletePayments(ctx context.Context, in *DeletePaymentsRequest, opts ...grpc.CallOption) (*DeletePaymentsResponse, error)
+	AddHoldInvoice(ctx context.Context, in *AddHoldInvoiceRequest, opts ...grpc.CallOption) (*AddHoldInvoiceResponse, error)
+	SettleInvoice(ctx context.Context, in *SettleInvoiceRequest, opts ...grpc.CallOption) (*SettleInvoiceResponse, error)
+	CancelInvoice(ctx context.Context, in *CancelInvoiceRequest, opts ...grpc.CallOption) (*CancelInvoiceResponse, error)
+	SubscribeInvoices(ctx context.Context, in *InvoiceSubscription, opts ...grpc.CallOption) (Lightning_SubscribeInvoicesClient, error)
+	AddEscrowInvoice(ctx context.Context, in *AddEscrowInvoiceRequest, opts ...grpc.CallOption) (*AddEscrowInvoiceResponse, error)
+	RevealPreimage(ctx context.Context, in *RevealPreimageRequest, opts ...grpc.CallOption) (*RevealPreimageResponse, error)
 }
 
 type lightningClient struct {
@@ -959,6 +1591,146 @@ func (x *lightningSendPaymentClient) Recv() (*SendResponse, error) {
 	return m, nil
 }
 
+func (c *lightningClient) ShowRoutingTable(ctx context.Context, in *ShowRoutingTableRequest, opts ...grpc.CallOption) (*ShowRoutingTableResponse, error) {
+	out := new(ShowRoutingTableResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/ShowRoutingTable", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) QueryRoutes(ctx context.Context, in *QueryRoutesRequest, opts ...grpc.CallOption) (*QueryRoutesResponse, error) {
+	out := new(QueryRoutesResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/QueryRoutes", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) UpdateChannelPolicy(ctx context.Context, in *PolicyUpdateRequest, opts ...grpc.CallOption) (*PolicyUpdateResponse, error) {
+	out := new(PolicyUpdateResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/UpdateChannelPolicy", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) ForwardingHistory(ctx context.Context, in *ForwardingHistoryRequest, opts ...grpc.CallOption) (*ForwardingHistoryResponse, error) {
+	out := new(ForwardingHistoryResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/ForwardingHistory", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) FeeReport(ctx context.Context, in *FeeReportRequest, opts ...grpc.CallOption) (*FeeReportResponse, error) {
+	out := new(FeeReportResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/FeeReport", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*ListPaymentsResponse, error) {
+	out := new(ListPaymentsResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/ListPayments", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) DeletePayments(ctx context.Context, in *DeletePaymentsRequest, opts ...grpc.CallOption) (*DeletePaymentsResponse, error) {
+	out := new(DeletePaymentsResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/DeletePayments", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) AddHoldInvoice(ctx context.Context, in *AddHoldInvoiceRequest, opts ...grpc.CallOption) (*AddHoldInvoiceResponse, error) {
+	out := new(AddHoldInvoiceResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/AddHoldInvoice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) SettleInvoice(ctx context.Context, in *SettleInvoiceRequest, opts ...grpc.CallOption) (*SettleInvoiceResponse, error) {
+	out := new(SettleInvoiceResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/SettleInvoice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) CancelInvoice(ctx context.Context, in *CancelInvoiceRequest, opts ...grpc.CallOption) (*CancelInvoiceResponse, error) {
+	out := new(CancelInvoiceResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/CancelInvoice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) SubscribeInvoices(ctx context.Context, in *InvoiceSubscription, opts ...grpc.CallOption) (Lightning_SubscribeInvoicesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Lightning_serviceDesc.Streams[3], c.cc, "/lnrpc.Lightning/SubscribeInvoices", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &lightningSubscribeInvoicesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Lightning_SubscribeInvoicesClient interface {
+	Recv() (*Invoice, error)
+	grpc.ClientStream
+}
+
+type lightningSubscribeInvoicesClient struct {
+	grpc.ClientStream
+}
+
+func (x *lightningSubscribeInvoicesClient) Recv() (*Invoice, error) {
+	m := new(Invoice)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *lightningClient) AddEscrowInvoice(ctx context.Context, in *AddEscrowInvoiceRequest, opts ...grpc.CallOption) (*AddEscrowInvoiceResponse, error) {
+	out := new(AddEscrowInvoiceResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/AddEscrowInvoice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) RevealPreimage(ctx context.Context, in *RevealPreimageRequest, opts ...grpc.CallOption) (*RevealPreimageResponse, error) {
+	out := new(RevealPreimageResponse)
+	err := grpc.Invoke(ctx, "/lnrpc.Lightning/RevealPreimage", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Lightning service
 
 type LightningServer interface {
@@ -973,6 +1745,19 @@ type LightningServer interface {
 	CloseChannel(*CloseChannelRequest, Lightning_CloseChannelServer) error
 	PendingChannels(context.Context, *PendingChannelRequest) (*PendingChannelResponse, error)
 	SendPayment(Lightning_SendPaymentServer) error
+	ShowRoutingTable(context.Context, *ShowRoutingTableRequest) (*ShowRoutingTableResponse, error)
+	QueryRoutes(context.Context, *QueryRoutesRequest) (*QueryRoutesResponse, error)
+	UpdateChannelPolicy(context.Context, *PolicyUpdateRequest) (*PolicyUpdateResponse, error)
+	ForwardingHistory(context.Context, *ForwardingHistoryRequest) (*ForwardingHistoryResponse, error)
+	FeeReport(context.Context, *FeeReportRequest) (*FeeReportResponse, error)
+	ListPayments(context.Context, *ListPaymentsRequest) (*ListPaymentsResponse, error)
+	DeletePayments(context.Context, *DeletePaymentsRequest) (*DeletePaymentsResponse, error)
+	AddHoldInvoice(context.Context, *AddHoldInvoiceRequest) (*AddHoldInvoiceResponse, error)
+	SettleInvoice(context.Context, *SettleInvoiceRequest) (*SettleInvoiceResponse, error)
+	CancelInvoice(context.Context, *CancelInvoiceRequest) (*CancelInvoiceResponse, error)
+	SubscribeInvoices(*InvoiceSubscription, Lightning_SubscribeInvoicesServer) error
+	AddEscrowInvoice(context.Context, *AddEscrowInvoiceRequest) (*AddEscrowInvoiceResponse, error)
+	RevealPreimage(context.Context, *RevealPreimageRequest) (*RevealPreimageResponse, error)
 }
 
 func RegisterLightningServer(s *grpc.Server, srv LightningServer) {
@@ -1191,6 +1976,243 @@ func (x *lightningSendPaymentServer) Recv() (*SendRequest, error) {
 	return m, nil
 }
 
+func _Lightning_ShowRoutingTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShowRoutingTableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).ShowRoutingTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/ShowRoutingTable",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).ShowRoutingTable(ctx, req.(*ShowRoutingTableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_QueryRoutes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRoutesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).QueryRoutes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/QueryRoutes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).QueryRoutes(ctx, req.(*QueryRoutesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_UpdateChannelPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PolicyUpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).UpdateChannelPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/UpdateChannelPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).UpdateChannelPolicy(ctx, req.(*PolicyUpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_ForwardingHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForwardingHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).ForwardingHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/ForwardingHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).ForwardingHistory(ctx, req.(*ForwardingHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_FeeReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FeeReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).FeeReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/FeeReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).FeeReport(ctx, req.(*FeeReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_ListPayments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPaymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).ListPayments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/ListPayments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).ListPayments(ctx, req.(*ListPaymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_DeletePayments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePaymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).DeletePayments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/DeletePayments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).DeletePayments(ctx, req.(*DeletePaymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_AddHoldInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddHoldInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).AddHoldInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/AddHoldInvoice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).AddHoldInvoice(ctx, req.(*AddHoldInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_SettleInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SettleInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).SettleInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/SettleInvoice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).SettleInvoice(ctx, req.(*SettleInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_CancelInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).CancelInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/CancelInvoice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).CancelInvoice(ctx, req.(*CancelInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_SubscribeInvoices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(InvoiceSubscription)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LightningServer).SubscribeInvoices(m, &lightningSubscribeInvoicesServer{stream})
+}
+
+type Lightning_SubscribeInvoicesServer interface {
+	Send(*Invoice) error
+	grpc.ServerStream
+}
+
+type lightningSubscribeInvoicesServer struct {
+	grpc.ServerStream
+}
+
+func (x *lightningSubscribeInvoicesServer) Send(m *Invoice) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Lightning_AddEscrowInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddEscrowInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).AddEscrowInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/AddEscrowInvoice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).AddEscrowInvoice(ctx, req.(*AddEscrowInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_RevealPreimage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevealPreimageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).RevealPreimage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/RevealPreimage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).RevealPreimage(ctx, req.(*RevealPreimageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Lightning_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "lnrpc.Lightning",
 	HandlerType: (*LightningServer)(nil),
@@ -1227,6 +2249,54 @@ var _Lightning_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PendingChannels",
 			Handler:    _Lightning_PendingChannels_Handler,
 		},
+		{
+			MethodName: "ShowRoutingTable",
+			Handler:    _Lightning_ShowRoutingTable_Handler,
+		},
+		{
+			MethodName: "QueryRoutes",
+			Handler:    _Lightning_QueryRoutes_Handler,
+		},
+		{
+			MethodName: "UpdateChannelPolicy",
+			Handler:    _Lightning_UpdateChannelPolicy_Handler,
+		},
+		{
+			MethodName: "ForwardingHistory",
+			Handler:    _Lightning_ForwardingHistory_Handler,
+		},
+		{
+			MethodName: "FeeReport",
+			Handler:    _Lightning_FeeReport_Handler,
+		},
+		{
+			MethodName: "ListPayments",
+			Handler:    _Lightning_ListPayments_Handler,
+		},
+		{
+			MethodName: "DeletePayments",
+			Handler:    _Lightning_DeletePayments_Handler,
+		},
+		{
+			MethodName: "AddHoldInvoice",
+			Handler:    _Lightning_AddHoldInvoice_Handler,
+		},
+		{
+			MethodName: "SettleInvoice",
+			Handler:    _Lightning_SettleInvoice_Handler,
+		},
+		{
+			MethodName: "CancelInvoice",
+			Handler:    _Lightning_CancelInvoice_Handler,
+		},
+		{
+			MethodName: "AddEscrowInvoice",
+			Handler:    _Lightning_AddEscrowInvoice_Handler,
+		},
+		{
+			MethodName: "RevealPreimage",
+			Handler:    _Lightning_RevealPreimage_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1245,6 +2315,11 @@ var _Lightning_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "SubscribeInvoices",
+			Handler:       _Lightning_SubscribeInvoices_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: fileDescriptor0,
 }
@@ -1252,94 +2327,187 @@ var _Lightning_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1412 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xa4, 0x57, 0x4d, 0x6f, 0xdb, 0x46,
-	0x13, 0x0e, 0xf5, 0x61, 0x49, 0x23, 0xcb, 0x96, 0xd6, 0x5f, 0x0a, 0x93, 0x00, 0x79, 0x89, 0x24,
-	0x30, 0x82, 0xbc, 0x4e, 0xea, 0xf4, 0x10, 0x24, 0x68, 0x0a, 0xc7, 0x71, 0xe3, 0x34, 0xae, 0xed,
-	0xd6, 0x0e, 0x82, 0x9e, 0x08, 0x8a, 0x5c, 0x47, 0x44, 0x28, 0x92, 0xd5, 0x2e, 0x93, 0xa8, 0x87,
-	0x02, 0xbd, 0xf4, 0xda, 0x6b, 0x7f, 0x45, 0xff, 0x44, 0x8f, 0x05, 0xfa, 0x63, 0xfa, 0x0b, 0x3a,
-	0xfb, 0x41, 0xf1, 0x4b, 0x0e, 0x50, 0xf4, 0x24, 0x70, 0x76, 0xf6, 0x99, 0x99, 0x67, 0x67, 0x9e,
-	0x5d, 0x41, 0x67, 0x1a, 0xbb, 0x3b, 0xf1, 0x34, 0xe2, 0x11, 0x69, 0x06, 0x21, 0x7e, 0x58, 0xdf,
-	0x42, 0xf7, 0x8c, 0x86, 0xde, 0x77, 0xf4, 0x87, 0x84, 0x32, 0x4e, 0x96, 0xa1, 0xe1, 0xe1, 0xef,
-	0xd0, 0xb8, 0x69, 0x6c, 0x2f, 0x93, 0x2e, 0xd4, 0x9d, 0x09, 0x1f, 0xd6, 0xf0, 0xa3, 0x4e, 0xd6,
-	0x61, 0x39, 0x76, 0x66, 0x13, 0x1a, 0x72, 0x7b, 0xec, 0xb0, 0xf1, 0xb0, 0x2e, 0x5d, 0x06, 0xd0,
-	0xb9, 0x70, 0x18, 0xb7, 0x19, 0x82, 0x0c, 0x1b, 0x68, 0x6a, 0x5b, 0x2b, 0xb0, 0xac, 0x20, 0x59,
-	0x1c, 0x85, 0x8c, 0x5a, 0x8f, 0x61, 0x79, 0x7f, 0xec, 0x84, 0x21, 0x0d, 0x4e, 0x23, 0x3f, 0xe4,
-	0x02, 0xe8, 0x22, 0x09, 0x3d, 0x3f, 0x7c, 0x6b, 0xf3, 0x8f, 0xbe, 0xa7, 0x63, 0xa1, 0x35, 0x4a,
-	0x78, 0x9c, 0x70, 0xdb, 0x0f, 0x3d, 0xfa, 0x51, 0x06, 0xed, 0x59, 0x9f, 0x43, 0xff, 0xc8, 0x7f,
-	0x3b, 0xe6, 0x21, 0x7a, 0xef, 0x79, 0xde, 0x94, 0x32, 0x46, 0x08, 0x40, 0x9c, 0x8c, 0x5e, 0xd1,
-	0xd9, 0xa1, 0x48, 0x43, 0xec, 0xee, 0x88, 0xbc, 0xc7, 0x11, 0x53, 0xa9, 0x76, 0xac, 0x5f, 0x0c,
-	0x58, 0x15, 0x29, 0x7c, 0xe3, 0x84, 0xb3, 0xb4, 0xb2, 0xa7, 0xb0, 0x2c, 0x00, 0xce, 0xa3, 0xbd,
-	0x49, 0x94, 0x84, 0xa2, 0xc2, 0xfa, 0x76, 0x77, 0x77, 0x7b, 0x47, 0xd2, 0xb0, 0x53, 0xf2, 0xde,
-	0xc9, 0xbb, 0x1e, 0x84, 0x7c, 0x3a, 0x33, 0x1f, 0xc2, 0xa0, 0x62, 0x14, 0x04, 0xbd, 0xa3, 0x33,
-	0x9d, 0x43, 0x0f, 0x9a, 0xef, 0x9d, 0x20, 0xa1, 0x8a, 0xaf, 0xc7, 0xb5, 0x47, 0x86, 0x75, 0x13,
-	0xfa, 0x19, 0xb2, 0xa2, 0x43, 0xa4, 0x3a, 0x2f, 0xbb, 0x63, 0x3d, 0x50, 0x1e, 0xfb, 0xc8, 0x0c,
-	0xcb, 0x1d, 0x82, 0x83, 0xa1, 0x34, 0xec, 0x0a, 0x2c, 0x39, 0x2a, 0x65, 0x89, 0x6b, 0xfd, 0x0f,
-	0x06, 0xb9, 0x1d, 0x0b, 0x41, 0x7f, 0x33, 0x60, 0x70, 0x4c, 0x3f, 0x68, 0xc2, 0x52, 0xd8, 0x5d,
-	0xf4, 0x99, 0xc5, 0x54, 0xfa, 0xac, 0xec, 0xde, 0xd2, 0x95, 0x57, 0xfc, 0x76, 0xf4, 0xe7, 0x39,
-	0xfa, 0x5a, 0x27, 0xd0, 0xcd, 0x7d, 0x92, 0x2d, 0x58, 0x7b, 0xf3, 0xf2, 0xfc, 0xf8, 0xe0, 0xec,
-	0xcc, 0x3e, 0x7d, 0xfd, 0xec, 0xd5, 0xc1, 0xf7, 0xf6, 0xe1, 0xde, 0xd9, 0x61, 0xff, 0x0a, 0xd9,
-	0x04, 0x82, 0xd6, 0xf3, 0x83, 0xe7, 0x05, 0xbb, 0x41, 0x56, 0xa1, 0x9b, 0x37, 0xd4, 0xac, 0xdb,
-	0xe8, 0x98, 0x8b, 0xa8, 0xd3, 0x5f, 0x85, 0x96, 0xa3, 0x4c, 0xba, 0x82, 0x27, 0x40, 0xf6, 0x23,
-	0x6c, 0x19, 0x97, 0x9f, 0x52, 0x3a, 0x4d, 0x2b, 0xb8, 0x9d, 0x23, 0xa6, 0xbb, 0xbb, 0xa5, 0x2b,
-	0x28, 0x37, 0x88, 0x75, 0x07, 0xd6, 0x0a, 0x9b, 0xb3, 0x20, 0x31, 0x7e, 0xdb, 0x9a, 0xa6, 0xa6,
-	0xf5, 0x1c, 0x1a, 0x87, 0xe7, 0x47, 0xfb, 0xd8, 0x4f, 0x35, 0x6d, 0xab, 0x97, 0xd9, 0x16, 0xfd,
-	0x2d, 0xba, 0xdd, 0x0e, 0x22, 0xf7, 0x9d, 0x6e, 0x79, 0x3c, 0x67, 0x1e, 0xd9, 0x09, 0xd3, 0xed,
-	0xfe, 0x97, 0x01, 0xbd, 0x3d, 0x97, 0xfb, 0xef, 0xa9, 0xee, 0x72, 0xb1, 0x67, 0x4a, 0x27, 0x11,
-	0xa7, 0x69, 0xa8, 0x0e, 0xd9, 0x80, 0x9e, 0xab, 0x56, 0xed, 0x58, 0x0c, 0x81, 0x6a, 0x54, 0xd2,
-	0x87, 0xb6, 0xeb, 0xc4, 0x8e, 0xeb, 0xf3, 0x99, 0x04, 0xaf, 0x0b, 0x47, 0x0c, 0xe5, 0x04, 0xf6,
-	0xc8, 0x09, 0x9c, 0xd0, 0xa5, 0x32, 0x48, 0x1d, 0xf9, 0x5d, 0xd1, 0x90, 0xa9, 0xbd, 0x29, 0xed,
-	0x57, 0x61, 0x90, 0x60, 0x6d, 0x9c, 0x07, 0xd4, 0xb3, 0x47, 0x54, 0x2d, 0x2d, 0xc9, 0x25, 0x0b,
-	0x7a, 0x31, 0x55, 0x63, 0x36, 0xe6, 0x81, 0xcb, 0x86, 0x2d, 0xd9, 0xf1, 0x5d, 0xcd, 0x9a, 0xac,
-	0x7c, 0x0d, 0xba, 0x61, 0x32, 0xb1, 0x93, 0xd8, 0x73, 0x38, 0x65, 0xc3, 0x36, 0x6e, 0x6c, 0x58,
-	0x7f, 0x18, 0xd0, 0x10, 0xc4, 0x89, 0x91, 0x0c, 0x52, 0x6e, 0xb3, 0x52, 0x72, 0x34, 0x8a, 0x22,
-	0x9a, 0xf9, 0xc3, 0xab, 0x4b, 0x0f, 0x24, 0x74, 0x34, 0x43, 0x3c, 0x21, 0x0a, 0x5c, 0x16, 0xd0,
-	0xc8, 0x6c, 0x53, 0xea, 0xbe, 0x97, 0xc9, 0x37, 0x44, 0xf5, 0xcc, 0xe1, 0xca, 0x4b, 0xe5, 0xac,
-	0x2d, 0xd2, 0xa7, 0x25, 0x2d, 0x08, 0xee, 0x87, 0x23, 0x3c, 0x10, 0x4f, 0x66, 0xd7, 0x26, 0x77,
-	0x90, 0x32, 0xc5, 0x24, 0x1b, 0x76, 0x64, 0x45, 0xeb, 0xba, 0xa2, 0xc2, 0x21, 0x58, 0x44, 0x28,
-	0x07, 0x93, 0x1d, 0x90, 0x76, 0xb6, 0x75, 0x1f, 0x06, 0x39, 0x9b, 0x6e, 0x0b, 0x13, 0x9a, 0xa2,
-	0x1e, 0xa6, 0x15, 0x21, 0xe5, 0x47, 0x38, 0x59, 0x7d, 0x58, 0x79, 0x41, 0xf9, 0xcb, 0xf0, 0x22,
-	0x4a, 0x21, 0x7e, 0x45, 0x69, 0x99, 0x9b, 0x34, 0xc2, 0x62, 0x9e, 0x86, 0xd0, 0xf7, 0x3d, 0x2c,
-	0x0d, 0xcf, 0xd6, 0x4e, 0xf9, 0x51, 0xa7, 0x7e, 0x1d, 0xd6, 0x05, 0xeb, 0xe9, 0xe9, 0xcc, 0xcb,
-	0x11, 0xec, 0xf5, 0xc8, 0x35, 0x58, 0x13, 0xab, 0x8e, 0xac, 0x26, 0x5b, 0x6c, 0xc8, 0x45, 0x6c,
-	0x2d, 0xb5, 0x55, 0x24, 0xdc, 0x94, 0x12, 0xf9, 0x5a, 0x8e, 0xca, 0x85, 0x3f, 0x9d, 0x38, 0xdc,
-	0x8f, 0xc2, 0xd7, 0xf2, 0x2c, 0x85, 0xe3, 0x48, 0xf4, 0xac, 0xcd, 0xc6, 0x4e, 0xa6, 0xb0, 0xca,
-	0x34, 0xa6, 0x22, 0x5b, 0x7d, 0x7a, 0xd8, 0x59, 0x02, 0xd1, 0x45, 0x08, 0x66, 0x07, 0xf4, 0x82,
-	0xab, 0x34, 0xac, 0x2f, 0x61, 0xa0, 0xa9, 0x3c, 0xc1, 0x44, 0x35, 0xea, 0xdd, 0x72, 0x1b, 0xab,
-	0x49, 0x5c, 0xd3, 0x9c, 0xe5, 0x65, 0x5e, 0x8e, 0xb0, 0xfa, 0xde, 0x0f, 0x22, 0x46, 0x35, 0x02,
-	0x26, 0xe1, 0xe2, 0x67, 0x49, 0xfc, 0xf1, 0x94, 0x59, 0xe2, 0xba, 0x29, 0x45, 0x6d, 0x2b, 0xc6,
-	0x11, 0x16, 0xbb, 0x34, 0x42, 0x2a, 0x00, 0xff, 0x22, 0xbe, 0xe8, 0x38, 0xee, 0x4f, 0xa8, 0x1d,
-	0xf8, 0x13, 0x3f, 0x9d, 0x66, 0x1c, 0x17, 0x27, 0x08, 0xa2, 0x0f, 0xf6, 0x45, 0x34, 0x75, 0x91,
-	0x5c, 0x11, 0x42, 0xd6, 0xdb, 0xb6, 0x7e, 0x46, 0xcd, 0x94, 0x21, 0xcf, 0xb8, 0xc3, 0x13, 0xa6,
-	0xd3, 0xfd, 0x0c, 0xd3, 0xcd, 0x91, 0xab, 0xe3, 0x5d, 0x4d, 0xe3, 0x55, 0x78, 0x3f, 0xbc, 0x42,
-	0xee, 0x03, 0x88, 0x1c, 0x35, 0x78, 0xad, 0xb8, 0xa1, 0x42, 0xc8, 0xe1, 0x95, 0x67, 0x6d, 0x58,
-	0x52, 0x03, 0x28, 0x26, 0x8f, 0x08, 0xb6, 0x4b, 0x55, 0xe3, 0x11, 0x71, 0x67, 0xfa, 0x96, 0x72,
-	0xbb, 0xa0, 0x5f, 0xe4, 0x1e, 0x74, 0xb5, 0x3d, 0x8c, 0xbc, 0x34, 0xd4, 0x65, 0xaa, 0x28, 0xba,
-	0x4e, 0x29, 0x4b, 0x7a, 0xf9, 0x6a, 0x9d, 0x53, 0xba, 0x73, 0x03, 0x36, 0xb4, 0xc0, 0x94, 0x96,
-	0x95, 0xfe, 0x6c, 0xc1, 0xaa, 0x1b, 0x4d, 0x26, 0x3e, 0x63, 0x58, 0xaa, 0xcd, 0xfc, 0x1f, 0x53,
-	0x01, 0xd2, 0x0d, 0x29, 0xdb, 0x47, 0x0e, 0x71, 0xcf, 0xfa, 0x09, 0xfa, 0xa2, 0x88, 0xff, 0xca,
-	0xe3, 0xff, 0xa1, 0x23, 0x79, 0x8c, 0x10, 0x4b, 0xd7, 0x36, 0x2c, 0xd2, 0x98, 0x35, 0x66, 0x81,
-	0xc5, 0x2f, 0x60, 0xe3, 0x54, 0x8d, 0x56, 0x89, 0xc7, 0x5b, 0xb0, 0xc4, 0x64, 0x52, 0xfa, 0x0a,
-	0x5c, 0x2f, 0xc2, 0xa9, 0x84, 0xad, 0xdf, 0x6b, 0xb0, 0x59, 0xde, 0xaf, 0x07, 0xfd, 0x2b, 0xe8,
-	0x57, 0x86, 0x56, 0xa9, 0xc6, 0xbd, 0xb9, 0x6a, 0x2c, 0xda, 0x58, 0x32, 0x9b, 0x7f, 0x1a, 0xb0,
-	0x52, 0x34, 0x55, 0x2e, 0xa7, 0x8a, 0xa8, 0xd4, 0x16, 0xdf, 0x23, 0xf5, 0xca, 0x3d, 0xd2, 0x58,
-	0x7c, 0x8f, 0x34, 0x2f, 0xb9, 0x47, 0x96, 0xd2, 0xc7, 0x5d, 0x61, 0x2c, 0x5b, 0x12, 0x36, 0x23,
-	0xac, 0xfd, 0x09, 0xc2, 0xee, 0xc1, 0xfa, 0x1b, 0x9c, 0x2a, 0xca, 0x9f, 0x29, 0xc8, 0x94, 0x6e,
-	0xc4, 0xfc, 0xe0, 0xf3, 0x10, 0x7b, 0xcf, 0x8e, 0xc2, 0x40, 0xbd, 0x92, 0xda, 0xd6, 0x36, 0x6c,
-	0x94, 0xbc, 0xb3, 0xeb, 0x39, 0xcd, 0x49, 0x78, 0x1a, 0x77, 0x77, 0xa1, 0x57, 0x08, 0x44, 0x5a,
-	0x50, 0xdf, 0x3b, 0x3a, 0xc2, 0xd7, 0x46, 0x17, 0x5a, 0x27, 0xa7, 0x07, 0xc7, 0x2f, 0x8f, 0x5f,
-	0xe0, 0x13, 0x03, 0x3f, 0xf6, 0x8f, 0x4e, 0xce, 0xc4, 0x47, 0x6d, 0xf7, 0xef, 0x26, 0x74, 0xe6,
-	0x9d, 0x4f, 0xbe, 0x86, 0x5e, 0x21, 0x16, 0xb9, 0xa6, 0x0b, 0x58, 0x94, 0xaf, 0x79, 0x7d, 0xf1,
-	0xa2, 0x4e, 0xef, 0x09, 0xb4, 0xd3, 0xa7, 0x1c, 0xd9, 0x5c, 0xfc, 0x6a, 0x34, 0xb7, 0x2a, 0x76,
-	0xbd, 0xf9, 0x29, 0x74, 0xe6, 0x6f, 0x36, 0x92, 0xf7, 0xca, 0xbf, 0xfb, 0xcc, 0x61, 0x75, 0x41,
-	0xef, 0xdf, 0x03, 0xc8, 0x5e, 0x4d, 0x64, 0x78, 0xd9, 0xd3, 0xcd, 0xbc, 0xba, 0x60, 0x45, 0x43,
-	0x3c, 0x87, 0x6e, 0xee, 0x51, 0x44, 0x72, 0xa3, 0x57, 0x7a, 0x65, 0x99, 0xe6, 0xa2, 0xa5, 0xac,
-	0x90, 0xf9, 0x0d, 0x4a, 0x32, 0xa9, 0x29, 0xde, 0xb3, 0xf3, 0x42, 0xaa, 0x97, 0xed, 0x23, 0x68,
-	0xe9, 0xdb, 0x93, 0x6c, 0x68, 0xa7, 0xe2, 0x05, 0x6b, 0x6e, 0x96, 0xcd, 0x59, 0xfe, 0x39, 0x69,
-	0x9c, 0xe7, 0x5f, 0x95, 0x4b, 0xf3, 0x52, 0x95, 0x78, 0x60, 0x90, 0x17, 0xf8, 0x5f, 0x24, 0x77,
-	0xaf, 0x90, 0x79, 0xad, 0xd5, 0xcb, 0xc6, 0xbc, 0x5c, 0xb4, 0x11, 0xe8, 0x18, 0x56, 0x8b, 0x13,
-	0x8c, 0x02, 0x7b, 0x89, 0x06, 0x28, 0xb4, 0x1b, 0x9f, 0x54, 0x08, 0xf2, 0x58, 0xfd, 0x0f, 0x3b,
-	0x55, 0xff, 0xb0, 0x08, 0xc9, 0xb5, 0x42, 0x8a, 0xb0, 0x56, 0xb0, 0xa9, 0x7d, 0xdb, 0xc6, 0x03,
-	0x63, 0xb4, 0x24, 0xff, 0xd1, 0x3d, 0xfc, 0x27, 0x00, 0x00, 0xff, 0xff, 0xd8, 0xf3, 0x62, 0x93,
-	0xde, 0x0d, 0x00, 0x00,
+	// 2900 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x59, 0x4b, 0x6f, 0x24, 0x57,
+	0xf5, 0x9f, 0xea, 0xea, 0xe7, 0xe9, 0x87, 0xbb, 0x6f, 0xdb, 0xed, 0x72, 0x8d, 0xe7, 0x1f, 0xff,
+	0x4b, 0x79, 0x98, 0x68, 0x32, 0x49, 0x1c, 0x16, 0x51, 0x22, 0x82, 0x9c, 0x76, 0xcf, 0xd8, 0x89,
+	0xd3, 0x63, 0xdc, 0x1e, 0x46, 0x88, 0x45, 0x51, 0xee, 0xba, 0x6d, 0x97, 0x52, 0x5d, 0x55, 0xa9,
+	0xba, 0xed, 0x99, 0x66, 0x81, 0x60, 0x85, 0xc4, 0x06, 0xc4, 0x0a, 0x89, 0x05, 0x6c, 0xd8, 0x22,
+	0xf1, 0x05, 0xd8, 0xb0, 0x64, 0xcd, 0x0a, 0xf1, 0x15, 0x58, 0xf0, 0x09, 0xd0, 0x7d, 0xd5, 0xbb,
+	0x47, 0x13, 0xb1, 0xb2, 0xfa, 0x9c, 0x5b, 0xe7, 0x9e, 0xf3, 0x3b, 0x8f, 0x7b, 0xce, 0x31, 0xb4,
+	0xc2, 0x60, 0xfe, 0x28, 0x08, 0x7d, 0xe2, 0xa3, 0x9a, 0xeb, 0x85, 0xc1, 0xdc, 0xf8, 0x8f, 0x02,
+	0xed, 0x19, 0xf6, 0xec, 0x4b, 0xfc, 0xcd, 0x0a, 0x47, 0x04, 0x75, 0xa0, 0x6a, 0xe3, 0x88, 0x68,
+	0xca, 0x81, 0x72, 0xd8, 0x41, 0x6d, 0x50, 0xad, 0x25, 0xd1, 0x2a, 0x07, 0xca, 0xa1, 0x8a, 0xb6,
+	0xa1, 0x13, 0x58, 0xeb, 0x25, 0xf6, 0x88, 0x79, 0x6b, 0x45, 0xb7, 0x9a, 0xca, 0x8e, 0x0c, 0xa0,
+	0xb5, 0xb0, 0x22, 0x62, 0x46, 0xd8, 0xb3, 0xb5, 0xea, 0x81, 0x72, 0xd8, 0x64, 0x24, 0x8c, 0x4d,
+	0xd7, 0x59, 0x3a, 0x44, 0xab, 0xb1, 0x6f, 0x77, 0x61, 0x8b, 0x38, 0x4b, 0xec, 0xaf, 0xe8, 0xc1,
+	0xb9, 0xef, 0xd9, 0x91, 0x56, 0x3f, 0x50, 0x0e, 0x6b, 0xa8, 0x0f, 0x4d, 0x6b, 0x49, 0xcc, 0x65,
+	0x64, 0x11, 0xad, 0xc1, 0x8e, 0x8e, 0xa0, 0x17, 0x7f, 0xcd, 0xe9, 0x4d, 0x46, 0xdf, 0x83, 0x41,
+	0x88, 0x6d, 0xbc, 0x0c, 0x88, 0xe3, 0x7b, 0x4c, 0x03, 0x1c, 0x69, 0xad, 0x03, 0xf5, 0xb0, 0x43,
+	0x35, 0xf3, 0x56, 0x4b, 0x33, 0xc4, 0xdf, 0xac, 0x9c, 0x10, 0xdb, 0x1a, 0x1c, 0x28, 0x87, 0x5d,
+	0xa4, 0x41, 0x7f, 0xe1, 0x78, 0x96, 0x6b, 0xce, 0x5d, 0x72, 0x67, 0xda, 0xd8, 0x25, 0x96, 0xd6,
+	0xa6, 0x1c, 0xe3, 0xe7, 0x0a, 0x74, 0xb8, 0xd1, 0x51, 0xe0, 0x7b, 0x11, 0x46, 0x3b, 0xd0, 0x95,
+	0xa6, 0xe1, 0x30, 0xf4, 0x43, 0x66, 0x7e, 0x8b, 0x4a, 0x90, 0xe4, 0x20, 0xc4, 0xce, 0xd2, 0xba,
+	0xc1, 0x0c, 0x8b, 0x0e, 0x7a, 0x13, 0x7a, 0x92, 0x13, 0xfa, 0x2b, 0x82, 0x23, 0x4d, 0x3d, 0x50,
+	0x0f, 0xdb, 0x47, 0x9d, 0x47, 0x0c, 0xd6, 0x47, 0x97, 0x94, 0x48, 0x55, 0xce, 0x7f, 0x1f, 0x69,
+	0x55, 0xaa, 0xb2, 0xf1, 0x09, 0x74, 0xc6, 0xb7, 0x96, 0xe7, 0x61, 0xf7, 0xc2, 0x77, 0x3c, 0x42,
+	0x4d, 0x58, 0xac, 0x3c, 0xdb, 0xf1, 0x6e, 0x4c, 0xf2, 0xd2, 0xb1, 0x05, 0xfe, 0xdb, 0xd0, 0xf1,
+	0x57, 0x24, 0x58, 0x11, 0xd3, 0xf1, 0x6c, 0xfc, 0x92, 0x5d, 0xde, 0x35, 0xbe, 0x0b, 0xfd, 0x73,
+	0xe7, 0xe6, 0x96, 0x78, 0x8e, 0x77, 0x73, 0x6c, 0xdb, 0x21, 0x8e, 0x22, 0x84, 0x00, 0x82, 0xd5,
+	0xf5, 0x97, 0x78, 0x7d, 0x4a, 0x5d, 0xc3, 0xd5, 0xef, 0x40, 0xf5, 0xd6, 0x8f, 0xb8, 0xfb, 0x5a,
+	0xc6, 0x5f, 0x14, 0xd8, 0xa2, 0x46, 0x7f, 0x65, 0x79, 0x6b, 0xe9, 0xed, 0xcf, 0xa0, 0x43, 0x05,
+	0x5c, 0xf9, 0xc7, 0x4b, 0x7f, 0xe5, 0x51, 0xaf, 0x53, 0x23, 0x0e, 0x85, 0x11, 0xb9, 0xd3, 0x8f,
+	0xd2, 0x47, 0x27, 0x1e, 0x09, 0xd7, 0x68, 0x08, 0x6d, 0x62, 0x85, 0x37, 0x98, 0x98, 0x73, 0xdf,
+	0x5b, 0xb0, 0x8b, 0x6a, 0x54, 0xe9, 0xc8, 0x22, 0x66, 0x80, 0x43, 0xf3, 0x7a, 0x4d, 0x30, 0x8b,
+	0x13, 0x55, 0xff, 0x08, 0x06, 0xc5, 0xef, 0xdb, 0xa0, 0x7e, 0x8d, 0xd7, 0x42, 0xdd, 0x2e, 0xd4,
+	0xee, 0x2c, 0x77, 0xc5, 0x21, 0x56, 0x3f, 0xa9, 0x7c, 0xac, 0x18, 0x07, 0xd0, 0x4f, 0x94, 0x10,
+	0xbe, 0xea, 0x40, 0x35, 0x46, 0xa8, 0x65, 0xfc, 0x98, 0x9f, 0x18, 0xfb, 0x8e, 0x17, 0xa5, 0x62,
+	0xd8, 0xb2, 0x6d, 0xe9, 0xc4, 0x1e, 0xd4, 0x2d, 0x6e, 0x1d, 0x0f, 0xe3, 0x9c, 0xce, 0x6a, 0xa9,
+	0xce, 0x34, 0x90, 0x55, 0xe3, 0xff, 0x61, 0x90, 0x12, 0x5e, 0x7a, 0xff, 0xef, 0x14, 0x18, 0x4c,
+	0xf1, 0x0b, 0xe1, 0x06, 0xa9, 0xc1, 0x11, 0x54, 0xc9, 0x3a, 0xc0, 0xec, 0x4c, 0xef, 0xe8, 0x4d,
+	0x81, 0x67, 0xe1, 0xdc, 0x23, 0xf1, 0xf3, 0x6a, 0x1d, 0x60, 0xe3, 0x29, 0xb4, 0x53, 0x3f, 0xd1,
+	0x2e, 0x0c, 0x9f, 0x9f, 0x5d, 0x4d, 0x27, 0xb3, 0x99, 0x79, 0xf1, 0xec, 0xf3, 0x2f, 0x27, 0x3f,
+	0x32, 0x4f, 0x8f, 0x67, 0xa7, 0xfd, 0x7b, 0x68, 0x04, 0x68, 0x3a, 0x99, 0x5d, 0x4d, 0x4e, 0x32,
+	0x74, 0x05, 0x6d, 0x41, 0x3b, 0x4d, 0xa8, 0x18, 0x6f, 0x01, 0x4a, 0xdf, 0x28, 0xd4, 0xdf, 0x82,
+	0x86, 0xc5, 0x49, 0xc2, 0x82, 0x4f, 0x01, 0x8d, 0x7d, 0xcf, 0xc3, 0x73, 0x72, 0x81, 0x71, 0x28,
+	0x2d, 0x78, 0x2b, 0x85, 0x61, 0xfb, 0x68, 0x57, 0x58, 0x90, 0x0f, 0x3b, 0xe3, 0x6d, 0x18, 0x66,
+	0x3e, 0x4e, 0x2e, 0x09, 0x30, 0x0e, 0x4d, 0x01, 0x53, 0xcd, 0x38, 0x81, 0xea, 0xe9, 0xd5, 0xf9,
+	0x18, 0x01, 0x54, 0x04, 0x4d, 0x2d, 0x38, 0x66, 0x00, 0x2d, 0x9a, 0xd5, 0xa6, 0xeb, 0xcf, 0xbf,
+	0x16, 0xc5, 0xa5, 0x0b, 0x35, 0xe2, 0x9b, 0xab, 0x88, 0x17, 0x16, 0xe3, 0x97, 0x15, 0xe8, 0x1e,
+	0xcf, 0x89, 0x73, 0x87, 0x45, 0xee, 0xd0, 0x6f, 0x42, 0xbc, 0xf4, 0x09, 0x96, 0x57, 0xb5, 0x68,
+	0x2e, 0xcf, 0x39, 0xd7, 0x0c, 0x7c, 0x47, 0x48, 0x6f, 0xd1, 0x42, 0x33, 0xb7, 0x02, 0x6b, 0xee,
+	0x90, 0x35, 0x8f, 0x48, 0x7a, 0xd0, 0xf5, 0xe7, 0x96, 0x6b, 0x5e, 0x5b, 0xae, 0xe5, 0xcd, 0x85,
+	0xd3, 0x69, 0xfd, 0x11, 0x22, 0x25, 0xbd, 0x26, 0xeb, 0xcf, 0xca, 0x8b, 0x30, 0x21, 0x2e, 0xb6,
+	0xcd, 0x6b, 0xcc, 0x59, 0x75, 0xc6, 0x32, 0xa0, 0x1b, 0x60, 0x9e, 0xbc, 0xb7, 0xc4, 0x9d, 0x47,
+	0x5a, 0x83, 0xe5, 0x51, 0x5b, 0xa0, 0xc6, 0x2c, 0x1f, 0x42, 0x9b, 0xd6, 0xa8, 0x55, 0x60, 0x5b,
+	0xb4, 0x5c, 0xd0, 0x9a, 0x56, 0x45, 0x3a, 0xa0, 0x8c, 0x0a, 0xbc, 0xde, 0xb5, 0x98, 0xd0, 0xfb,
+	0x30, 0xcc, 0xea, 0xc1, 0x99, 0xc0, 0x22, 0xf3, 0x6f, 0x0a, 0x54, 0x29, 0xe2, 0x34, 0x70, 0x5d,
+	0xe9, 0x94, 0x04, 0x83, 0x14, 0xfe, 0x3c, 0x27, 0x53, 0x5e, 0x57, 0xd9, 0x09, 0x04, 0x40, 0x03,
+	0x3d, 0xa2, 0x75, 0x9b, 0x30, 0xcb, 0xab, 0x09, 0x2d, 0xc4, 0xf3, 0x3b, 0x66, 0x75, 0x95, 0xc2,
+	0x46, 0x13, 0x83, 0x9d, 0xe2, 0xc6, 0x0a, 0x0a, 0x3b, 0xc3, 0x2b, 0xf6, 0x16, 0x34, 0x1c, 0xef,
+	0xda, 0x5f, 0x79, 0x36, 0x33, 0xab, 0x89, 0xde, 0x86, 0xa6, 0x70, 0x01, 0xaf, 0xd0, 0xed, 0xa3,
+	0x6d, 0x01, 0x45, 0xc6, 0x7b, 0x06, 0xa2, 0x85, 0x2c, 0x62, 0xa1, 0x23, 0x53, 0xc2, 0x78, 0x1f,
+	0x06, 0x29, 0x9a, 0x88, 0x27, 0x1d, 0x6a, 0xd4, 0x9e, 0x48, 0x53, 0x32, 0xc0, 0xd2, 0x43, 0x46,
+	0x1f, 0x7a, 0x4f, 0x30, 0x39, 0xf3, 0x16, 0xbe, 0x14, 0xf1, 0x6b, 0x05, 0xb6, 0x62, 0x92, 0x90,
+	0x50, 0x8e, 0x93, 0x06, 0x7d, 0xc7, 0xc6, 0x1e, 0x71, 0xc8, 0xda, 0x94, 0xf8, 0xf0, 0x70, 0xd9,
+	0x87, 0x6d, 0xea, 0x2e, 0xe9, 0xd6, 0xd8, 0x1c, 0x95, 0x3d, 0x2d, 0xf7, 0x61, 0x48, 0xb9, 0x16,
+	0xb3, 0x26, 0x61, 0x56, 0x19, 0x73, 0x00, 0x2d, 0xfe, 0x29, 0x55, 0xb8, 0xc6, 0x2a, 0xf6, 0x33,
+	0x96, 0x63, 0x0b, 0x27, 0x5c, 0x5a, 0xf4, 0xf5, 0x7a, 0xc6, 0x82, 0x80, 0x1e, 0xbc, 0xa6, 0xc1,
+	0x6e, 0x46, 0xb7, 0x56, 0x52, 0xf0, 0x39, 0xe9, 0x16, 0x53, 0x6d, 0x85, 0xf7, 0x46, 0xd0, 0xa3,
+	0x12, 0x69, 0xbd, 0x8a, 0x4c, 0x17, 0x2f, 0x08, 0x57, 0xc3, 0xf8, 0x3e, 0x0c, 0x04, 0x94, 0x4f,
+	0x03, 0x2c, 0xa5, 0xbe, 0x9b, 0x8f, 0x7f, 0x9e, 0xc2, 0x43, 0x81, 0x59, 0xfa, 0xd5, 0x61, 0xb9,
+	0xcf, 0x7f, 0x8f, 0x5d, 0x3f, 0xc2, 0x42, 0xc2, 0x36, 0x74, 0xe6, 0xae, 0x1f, 0xe5, 0xde, 0xa2,
+	0x2d, 0x68, 0x44, 0xab, 0xf9, 0x5c, 0x42, 0xd4, 0x34, 0x7e, 0xaf, 0xc0, 0x90, 0x7d, 0x26, 0x44,
+	0xc8, 0xd2, 0xf1, 0x2d, 0x14, 0xa0, 0x21, 0x47, 0xfb, 0x02, 0xd1, 0x2b, 0x54, 0x64, 0xa2, 0x59,
+	0xae, 0xeb, 0xbf, 0x30, 0x17, 0x7e, 0x38, 0xc7, 0x26, 0x55, 0x85, 0x3f, 0x22, 0xcd, 0x7c, 0xed,
+	0xae, 0x96, 0xd6, 0x6e, 0x96, 0xae, 0xc6, 0x2f, 0x14, 0x18, 0x30, 0xed, 0x66, 0xc4, 0x22, 0xab,
+	0x48, 0x98, 0xf6, 0x21, 0x74, 0xe6, 0x29, 0x47, 0x08, 0xd5, 0xf6, 0xa4, 0x6a, 0x05, 0x1f, 0x9d,
+	0xde, 0x43, 0xef, 0x03, 0x50, 0x73, 0x84, 0x1e, 0x95, 0xec, 0x07, 0x05, 0xf0, 0x4e, 0xef, 0x7d,
+	0xde, 0x84, 0x3a, 0xcf, 0x72, 0xe3, 0xdf, 0x0a, 0x20, 0xea, 0x99, 0x1c, 0x40, 0x23, 0xe8, 0x09,
+	0x2b, 0x32, 0x45, 0x12, 0x3d, 0x8c, 0xad, 0xf3, 0x7c, 0x5b, 0x5e, 0xb5, 0xa9, 0xf4, 0xd2, 0x08,
+	0xe5, 0xb5, 0x43, 0xf6, 0x0d, 0xa2, 0x98, 0xf2, 0xe2, 0xf6, 0x00, 0x76, 0x44, 0xf5, 0xc8, 0xb1,
+	0xab, 0xb2, 0x1f, 0x9b, 0xfb, 0xcb, 0xa5, 0x13, 0x45, 0xb4, 0x99, 0x8a, 0x9c, 0x9f, 0xca, 0x2a,
+	0x27, 0x82, 0x97, 0x85, 0x1a, 0x4b, 0xf8, 0x6e, 0x1e, 0xf4, 0x46, 0x29, 0xe8, 0xac, 0x47, 0x33,
+	0x7e, 0x06, 0x7d, 0x6a, 0xef, 0xff, 0x0a, 0xf9, 0x7b, 0xd0, 0x62, 0x90, 0xfb, 0x01, 0xf6, 0x04,
+	0x0c, 0x5a, 0x16, 0xf1, 0x24, 0xde, 0x33, 0x80, 0x7f, 0x0f, 0x76, 0x2e, 0x78, 0xc6, 0xe6, 0x20,
+	0x7f, 0x13, 0xea, 0x11, 0x53, 0x4a, 0x3c, 0xc9, 0xdb, 0x59, 0x71, 0x5c, 0x61, 0xe3, 0xcf, 0x15,
+	0x18, 0xe5, 0xbf, 0x17, 0xf5, 0xe3, 0x31, 0xf4, 0x0b, 0xb5, 0x80, 0x17, 0xa3, 0x87, 0x71, 0x31,
+	0x2a, 0xfb, 0x30, 0x47, 0xd6, 0xff, 0xae, 0x40, 0x2f, 0x4b, 0x2a, 0x3c, 0x96, 0x85, 0x5a, 0x55,
+	0x29, 0x7f, 0xd7, 0xd4, 0xc2, 0xbb, 0x56, 0x2d, 0x7f, 0xd7, 0x6a, 0x1b, 0xde, 0xb5, 0xba, 0x6c,
+	0xeb, 0x33, 0xd9, 0xde, 0x60, 0x62, 0x13, 0xc0, 0x9a, 0xaf, 0x00, 0xec, 0x21, 0x6c, 0x3f, 0xb7,
+	0x5c, 0x17, 0x93, 0xcf, 0xb9, 0x48, 0x09, 0xf7, 0x36, 0x74, 0x5e, 0x38, 0xc4, 0xc3, 0x51, 0x64,
+	0xfa, 0x9e, 0xcb, 0x1b, 0xbc, 0xa6, 0x71, 0x08, 0x3b, 0xb9, 0xd3, 0x49, 0xbb, 0x20, 0x75, 0xa2,
+	0x27, 0x15, 0x63, 0x0f, 0x76, 0x67, 0xb7, 0xfe, 0x0b, 0xda, 0x45, 0x3b, 0xde, 0xcd, 0x95, 0x75,
+	0xed, 0x4a, 0xd1, 0xc6, 0xdb, 0xa0, 0x15, 0x59, 0x42, 0x0e, 0x40, 0x25, 0x24, 0xa2, 0xad, 0xf1,
+	0x01, 0xfd, 0x60, 0x85, 0xc3, 0xf5, 0x25, 0x6b, 0xcf, 0x5f, 0x63, 0xbc, 0x41, 0x00, 0x6c, 0x88,
+	0x90, 0xed, 0x7c, 0x7e, 0x3a, 0xe1, 0xe0, 0x96, 0x0d, 0x15, 0xbc, 0xc6, 0xff, 0x49, 0x01, 0xf5,
+	0xd4, 0x0f, 0xa8, 0x1c, 0x16, 0xbc, 0x49, 0xed, 0x63, 0xef, 0x31, 0x4d, 0xe9, 0x82, 0x33, 0xcd,
+	0x5c, 0x4b, 0x32, 0x82, 0x1e, 0xbd, 0x8f, 0xf8, 0xb4, 0xf6, 0xbd, 0xb0, 0x42, 0x5b, 0xdc, 0xda,
+	0x06, 0x75, 0x81, 0xa5, 0x23, 0x7b, 0x50, 0xc7, 0x2f, 0x03, 0x27, 0x5c, 0x8b, 0xfc, 0xbc, 0x0f,
+	0xc3, 0xec, 0x47, 0xe9, 0x69, 0xaa, 0x0f, 0x4d, 0x3a, 0x4d, 0x25, 0x73, 0x94, 0xf1, 0x5b, 0x05,
+	0x6a, 0x7c, 0x3c, 0xa1, 0x43, 0x99, 0x4f, 0x2c, 0xd7, 0xe4, 0x25, 0x98, 0xb6, 0x5d, 0x0a, 0x93,
+	0x48, 0xab, 0x32, 0x63, 0x2c, 0x30, 0x8e, 0x92, 0xee, 0x8c, 0xd3, 0x28, 0x62, 0xaa, 0xc0, 0xa2,
+	0x7a, 0xeb, 0x07, 0x7c, 0xa2, 0x69, 0x1f, 0x81, 0xec, 0x76, 0xfc, 0x20, 0x91, 0x4c, 0x05, 0xf0,
+	0xcb, 0xe3, 0x20, 0x8c, 0xa5, 0x70, 0x3a, 0x0b, 0x42, 0xe3, 0x23, 0x18, 0x66, 0xbc, 0x25, 0x1c,
+	0xba, 0x0f, 0x75, 0xe1, 0x0f, 0xa5, 0x38, 0x5e, 0x19, 0x7f, 0x50, 0x60, 0x78, 0xe1, 0xbb, 0xce,
+	0x7c, 0xcd, 0x4b, 0x81, 0x74, 0xf2, 0x3b, 0x05, 0x0f, 0x6c, 0x78, 0x7d, 0xfa, 0xd0, 0xbc, 0xb6,
+	0x22, 0x4c, 0xb5, 0xd4, 0x2a, 0x69, 0xb8, 0x42, 0x4b, 0xcc, 0x2d, 0x5d, 0x39, 0xb9, 0x32, 0x78,
+	0x84, 0xbf, 0xf9, 0x33, 0xdf, 0x87, 0xe6, 0xd2, 0xf1, 0x58, 0xc3, 0x27, 0x8c, 0xa3, 0x14, 0xeb,
+	0x25, 0xa7, 0x70, 0xb3, 0x46, 0xb0, 0x9d, 0x55, 0x90, 0xdb, 0x65, 0x78, 0xa0, 0x3d, 0xe6, 0xbe,
+	0x72, 0xbc, 0x9b, 0x53, 0x27, 0x22, 0x7e, 0x18, 0xcf, 0x64, 0x08, 0x20, 0x22, 0x56, 0x48, 0x98,
+	0x57, 0x44, 0xab, 0xdc, 0x87, 0x26, 0xf6, 0x6c, 0x4e, 0x89, 0x87, 0x71, 0x36, 0x12, 0x9a, 0xfe,
+	0x62, 0x11, 0x61, 0xd1, 0x10, 0xc8, 0x46, 0x81, 0x6a, 0x81, 0xef, 0xb0, 0x47, 0x44, 0x4b, 0x62,
+	0xfc, 0x55, 0x81, 0xad, 0xe4, 0xc2, 0x09, 0x65, 0x31, 0x87, 0x3a, 0x4b, 0x1c, 0x11, 0x6b, 0x19,
+	0x88, 0x6b, 0x64, 0x54, 0x32, 0xe0, 0x4c, 0xc7, 0x13, 0xc1, 0x3a, 0x82, 0x5e, 0x8a, 0xec, 0xaf,
+	0x64, 0xe9, 0x61, 0x0d, 0x3c, 0x3b, 0x57, 0x95, 0x7d, 0xa0, 0xb5, 0xe4, 0x07, 0x6a, 0xe9, 0xb0,
+	0xad, 0xcb, 0xb9, 0x8b, 0x9f, 0x4e, 0x87, 0xe7, 0x36, 0x74, 0xc4, 0x27, 0xe9, 0x51, 0x3f, 0x1d,
+	0xb4, 0xac, 0x19, 0x36, 0x1c, 0xd8, 0x2b, 0x01, 0x4c, 0x44, 0xc9, 0x87, 0x30, 0x58, 0xc4, 0x4c,
+	0x69, 0x38, 0x0f, 0x98, 0x91, 0x70, 0x7b, 0xde, 0xf8, 0x3d, 0x18, 0xb8, 0x74, 0x6b, 0xc1, 0xd1,
+	0xcb, 0x4c, 0xd7, 0x08, 0xfa, 0x8f, 0x31, 0xbe, 0xc4, 0x81, 0x1f, 0x12, 0x59, 0x74, 0xfe, 0xa5,
+	0x40, 0x5f, 0x44, 0x4e, 0xcc, 0x2b, 0x4d, 0xf4, 0xd7, 0x89, 0xa8, 0x21, 0xb4, 0x6d, 0x6b, 0x4d,
+	0x8f, 0x98, 0xd1, 0x6a, 0x29, 0xb0, 0xa3, 0x15, 0x13, 0xe3, 0xaf, 0x63, 0x6a, 0x4d, 0x3a, 0x64,
+	0xe9, 0x7b, 0xe4, 0x36, 0x26, 0xd7, 0x65, 0x11, 0x4a, 0x49, 0x48, 0xe3, 0xb9, 0x07, 0x83, 0xb4,
+	0x98, 0x34, 0xa8, 0x3a, 0xa0, 0x8c, 0xac, 0x34, 0xbc, 0xff, 0x50, 0x60, 0x90, 0x32, 0x5a, 0xe0,
+	0xfa, 0x1e, 0x74, 0xe4, 0x8b, 0xc3, 0x0a, 0x01, 0x87, 0x74, 0x37, 0x9b, 0x49, 0x09, 0x1e, 0x39,
+	0xbb, 0x2a, 0xa5, 0x76, 0xa9, 0xe5, 0x76, 0x55, 0x37, 0xda, 0x55, 0xdb, 0x6c, 0x57, 0xfd, 0x15,
+	0x76, 0x35, 0x78, 0x13, 0xa8, 0x42, 0xe3, 0x82, 0x6f, 0x60, 0x0a, 0xeb, 0xab, 0x57, 0xaf, 0x78,
+	0x52, 0xeb, 0x08, 0x35, 0x1d, 0xcb, 0x5c, 0xd1, 0x0e, 0x54, 0x03, 0x8b, 0xdc, 0x6a, 0xb5, 0x03,
+	0xf5, 0xb0, 0x85, 0x1e, 0xc6, 0x6f, 0x65, 0x9d, 0xbd, 0x95, 0xfb, 0xb2, 0x23, 0xe0, 0x82, 0xe5,
+	0x5f, 0xfe, 0x66, 0xb2, 0xfd, 0x96, 0xe5, 0xb8, 0xab, 0x10, 0x9b, 0x21, 0xb6, 0x22, 0xdf, 0x13,
+	0x2f, 0x2e, 0x4d, 0xbe, 0x10, 0xb3, 0x46, 0xc8, 0xa4, 0xc5, 0x42, 0xb8, 0x6d, 0x17, 0xb6, 0x42,
+	0x1c, 0xf9, 0xee, 0x2a, 0x61, 0xf0, 0xf9, 0xf0, 0x1d, 0x68, 0x5a, 0x84, 0xd0, 0x7d, 0x58, 0xa4,
+	0x01, 0xf3, 0xcc, 0x4e, 0xf6, 0xde, 0x63, 0xce, 0xa5, 0x71, 0xca, 0x0c, 0xe1, 0xc0, 0xb4, 0x0b,
+	0x19, 0xd6, 0x61, 0x50, 0x3d, 0x87, 0x6e, 0x56, 0xcf, 0x36, 0x34, 0x9e, 0x4d, 0xbf, 0x9c, 0x3e,
+	0x7d, 0x3e, 0xed, 0xdf, 0x43, 0x5d, 0x68, 0x9d, 0x4d, 0xcd, 0xc7, 0xe7, 0x67, 0x4f, 0x4e, 0xaf,
+	0xfa, 0x0a, 0xfd, 0x39, 0x7b, 0x36, 0x1e, 0x4f, 0x26, 0x27, 0x93, 0x93, 0x7e, 0x05, 0x01, 0xd4,
+	0x1f, 0x1f, 0x9f, 0x9d, 0x4f, 0x4e, 0xfa, 0xb4, 0x08, 0xb4, 0xcf, 0xa6, 0x57, 0x93, 0xcb, 0xcb,
+	0x67, 0x17, 0x57, 0x93, 0x93, 0x7e, 0xd5, 0xf8, 0x15, 0xed, 0x78, 0xb2, 0x1a, 0x49, 0xf8, 0x14,
+	0x06, 0x9f, 0x40, 0x36, 0x8e, 0x17, 0x61, 0x15, 0xaf, 0x76, 0xf1, 0xbb, 0x98, 0xc3, 0xac, 0xca,
+	0x30, 0x13, 0x0f, 0x78, 0xad, 0x60, 0x53, 0x3c, 0xaa, 0x66, 0x97, 0x8b, 0xc6, 0x0e, 0x0c, 0xd9,
+	0x74, 0xc9, 0xf5, 0x89, 0x87, 0xce, 0x8f, 0x61, 0x3b, 0x4b, 0x16, 0x19, 0x70, 0x00, 0x4d, 0x11,
+	0x1d, 0x32, 0xfa, 0x7b, 0x59, 0x8c, 0x8d, 0x87, 0xb0, 0x73, 0x82, 0x5d, 0x4c, 0x70, 0x4e, 0x24,
+	0xcd, 0x06, 0xaa, 0x32, 0xb6, 0xd3, 0x1d, 0xd0, 0x7b, 0x30, 0xca, 0x9f, 0x16, 0x37, 0x89, 0xf5,
+	0x80, 0xcd, 0xb8, 0xbc, 0x11, 0xec, 0x1a, 0x3f, 0x81, 0x9d, 0x63, 0xdb, 0x3e, 0xf5, 0x5d, 0xfb,
+	0xcc, 0xbb, 0xf3, 0x9d, 0x4c, 0x7f, 0x55, 0x88, 0xe5, 0x4e, 0x6e, 0x81, 0x96, 0xf3, 0xbb, 0x9a,
+	0xeb, 0x1d, 0xf8, 0x86, 0x4b, 0x83, 0x51, 0xfe, 0x06, 0xf1, 0x44, 0x1d, 0xc2, 0xf6, 0x8c, 0x2d,
+	0x3b, 0x72, 0x57, 0xf7, 0xa1, 0x19, 0x27, 0x0a, 0xbb, 0xd6, 0xd8, 0x85, 0x9d, 0xdc, 0x49, 0x21,
+	0xe2, 0x21, 0x6c, 0x8f, 0x69, 0x53, 0xe7, 0xbe, 0x8e, 0xf6, 0x54, 0x4c, 0xee, 0xb4, 0x10, 0xb3,
+	0x03, 0x43, 0x41, 0x9a, 0xad, 0xae, 0xa3, 0x79, 0xe8, 0xb0, 0x0d, 0xb0, 0xf1, 0x1b, 0x05, 0x76,
+	0x8f, 0x6d, 0x7b, 0x12, 0xcd, 0x43, 0xff, 0x45, 0xee, 0x86, 0xd2, 0x5d, 0xb1, 0x52, 0xba, 0x2b,
+	0xae, 0xc8, 0x99, 0x3d, 0xd9, 0xd0, 0xaa, 0x07, 0x6a, 0x1a, 0xcd, 0x6a, 0x09, 0x9a, 0x65, 0x9d,
+	0x98, 0x6a, 0xe8, 0xa0, 0x15, 0x35, 0x12, 0x56, 0x7c, 0x07, 0x76, 0x2e, 0xf1, 0x1d, 0xb6, 0xdc,
+	0x0b, 0x71, 0xcf, 0x66, 0x40, 0x35, 0x18, 0xe5, 0x8f, 0x0a, 0x21, 0x7f, 0xac, 0x40, 0x43, 0x08,
+	0xfe, 0xd6, 0xf5, 0xac, 0x2c, 0x1c, 0x76, 0xa0, 0x4b, 0x13, 0x24, 0xb0, 0x1c, 0x3b, 0xdd, 0xe4,
+	0xbe, 0x0b, 0x35, 0x5a, 0xd0, 0x78, 0xc3, 0xd9, 0x3b, 0xba, 0x2f, 0x62, 0x5e, 0xdc, 0x2c, 0xff,
+	0xd2, 0x3a, 0x81, 0x8b, 0x65, 0xab, 0x9e, 0x83, 0xa6, 0xb1, 0xa9, 0x8c, 0xf1, 0x76, 0x74, 0x0a,
+	0x9d, 0x8c, 0xbc, 0x4c, 0xd9, 0x69, 0x42, 0xf5, 0xe9, 0xc5, 0x64, 0xda, 0x57, 0x50, 0x07, 0x9a,
+	0xc7, 0xe3, 0xf1, 0x84, 0xd5, 0x94, 0x0a, 0x3d, 0x34, 0x9b, 0x5c, 0x5d, 0xf1, 0x8a, 0xd3, 0x81,
+	0xe6, 0xf8, 0x78, 0x3a, 0x9e, 0xd0, 0x5f, 0xd5, 0x77, 0x8f, 0xa0, 0x9b, 0x99, 0x51, 0x50, 0x03,
+	0xd4, 0xe3, 0xf3, 0xf3, 0xfe, 0x3d, 0xfa, 0x11, 0x15, 0x76, 0x36, 0x7d, 0xd2, 0x57, 0xe8, 0x8f,
+	0xf1, 0xf9, 0xd3, 0x19, 0xfd, 0x51, 0x39, 0xfa, 0x67, 0x17, 0x5a, 0xf1, 0x7c, 0x8d, 0xbe, 0x80,
+	0x6e, 0x66, 0x4c, 0x41, 0xd2, 0xfe, 0xb2, 0x51, 0x47, 0xdf, 0x2f, 0x67, 0x8a, 0xb4, 0xfe, 0x14,
+	0x9a, 0x72, 0x81, 0x8d, 0x46, 0xe5, 0x6b, 0x75, 0x7d, 0xb7, 0x40, 0x17, 0x1f, 0x7f, 0x06, 0xad,
+	0x78, 0xfd, 0x8c, 0xd2, 0xa7, 0xd2, 0xdb, 0x6e, 0x5d, 0x2b, 0x32, 0xc4, 0xf7, 0xc7, 0x00, 0xc9,
+	0x02, 0x18, 0x69, 0x9b, 0xb6, 0xd0, 0xfa, 0x5e, 0x09, 0x47, 0x88, 0x38, 0x81, 0x76, 0x6a, 0xbf,
+	0x8b, 0x52, 0x53, 0x7b, 0x6e, 0x61, 0xac, 0xeb, 0x65, 0xac, 0xc4, 0x90, 0x78, 0xa7, 0x87, 0x92,
+	0x85, 0x46, 0x76, 0xf3, 0xa7, 0x6b, 0x45, 0x86, 0xf8, 0xfe, 0x63, 0x68, 0x88, 0x7d, 0x1e, 0x92,
+	0x6f, 0x5c, 0x76, 0xe5, 0xa7, 0x8f, 0xf2, 0xe4, 0x44, 0xff, 0xd4, 0x02, 0x26, 0xd6, 0xbf, 0xb8,
+	0x94, 0xd1, 0x37, 0x2e, 0x18, 0x3e, 0x50, 0xd0, 0x13, 0xe8, 0xa4, 0x17, 0x5d, 0x28, 0xb6, 0xb5,
+	0xb8, 0xfd, 0xd2, 0x37, 0xaf, 0x86, 0x3e, 0x50, 0xd0, 0x14, 0xb6, 0xb2, 0xc3, 0x7f, 0x84, 0xf6,
+	0x37, 0xac, 0x0f, 0xb8, 0xb4, 0x07, 0xaf, 0x5c, 0x2e, 0xa0, 0x4f, 0xf8, 0x3f, 0xef, 0x64, 0x8b,
+	0x83, 0x52, 0xa1, 0x20, 0x25, 0x0c, 0x33, 0x34, 0xfe, 0xdd, 0xa1, 0xf2, 0x81, 0x82, 0x66, 0xd0,
+	0xcf, 0x0f, 0xd2, 0xe8, 0xff, 0xe4, 0xe1, 0xf2, 0xe1, 0x5b, 0x7f, 0x63, 0x23, 0x3f, 0xc1, 0x3b,
+	0x35, 0xc7, 0xc5, 0x78, 0x17, 0x27, 0x71, 0x5d, 0x2f, 0x63, 0x09, 0x29, 0x53, 0x18, 0x72, 0xc8,
+	0xe2, 0x69, 0x8d, 0xce, 0x50, 0x31, 0xec, 0x25, 0x33, 0x9f, 0x7e, 0xbf, 0x94, 0x27, 0xe4, 0xfd,
+	0x10, 0x06, 0x85, 0xe9, 0x01, 0xbd, 0x51, 0x18, 0x0d, 0xb2, 0x83, 0x98, 0x7e, 0xb0, 0xf9, 0x40,
+	0x12, 0xd7, 0x49, 0xfb, 0x2b, 0xe3, 0x3a, 0x3f, 0x3c, 0xe8, 0x5a, 0x91, 0x21, 0xbe, 0x7f, 0x02,
+	0x9d, 0x74, 0xdb, 0x11, 0x1b, 0x58, 0xd2, 0xa2, 0xe8, 0xf7, 0x4b, 0x79, 0x42, 0xd0, 0x57, 0xd0,
+	0xcb, 0xf6, 0x15, 0x71, 0x58, 0x95, 0x36, 0x27, 0xfa, 0x83, 0x0d, 0xdc, 0x44, 0x5c, 0xb6, 0x2b,
+	0x88, 0xc5, 0x95, 0xb6, 0x23, 0xfa, 0x83, 0x0d, 0x5c, 0x21, 0xee, 0x0b, 0xe8, 0x66, 0x1a, 0x84,
+	0xb8, 0xa0, 0x96, 0x35, 0x18, 0xfa, 0x7e, 0x39, 0x33, 0x91, 0x95, 0xe9, 0x12, 0x62, 0x59, 0x65,
+	0x9d, 0x86, 0xbe, 0x5f, 0xce, 0x8c, 0xeb, 0xe3, 0x40, 0x74, 0x14, 0xd7, 0xf2, 0x9e, 0xc4, 0x07,
+	0x25, 0x2d, 0x87, 0xde, 0xcb, 0xf2, 0x78, 0x12, 0xe5, 0x5f, 0xfc, 0x38, 0x89, 0x36, 0x34, 0x27,
+	0xfa, 0x1b, 0x1b, 0xf9, 0x09, 0xfc, 0xd9, 0xf7, 0x3f, 0x86, 0xbf, 0xb4, 0x83, 0xd0, 0x1f, 0x6c,
+	0xe0, 0x72, 0x71, 0xd7, 0x75, 0xf6, 0x0f, 0xff, 0x8f, 0xfe, 0x3b, 0x00, 0x25, 0x15, 0xc2, 0x5b,
+	0xfd, 0x1f, 0x00, 0x00,
 }
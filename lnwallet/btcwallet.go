@@ -0,0 +1,516 @@
+package lnwallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/chaincfg"
+	"github.com/roasbeef/btcd/txscript"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+	"github.com/roasbeef/btcwallet/chain"
+	"github.com/roasbeef/btcwallet/waddrmgr"
+	base "github.com/roasbeef/btcwallet/wallet"
+)
+
+const (
+	// defaultAccount is the btcwallet account all funds controlled by the
+	// BtcWallet are stored within.
+	defaultAccount = uint32(waddrmgr.DefaultAccountNum)
+)
+
+// BtcWallet is an implementation of the WalletController, and Signer
+// interfaces backed by btcwallet. The wallet's chain backend is a btcd full
+// node which is accessed over websockets.
+type BtcWallet struct {
+	started int32 // To be used atomically.
+	stopped int32 // To be used atomically.
+
+	// wallet is the active instance of btcwallet.
+	wallet *base.Wallet
+
+	// rpc is an active RPC connection to btcd. In addition to syncing the
+	// wallet, it's used in order to query the UTXO set.
+	rpc *chain.RPCClient
+
+	// chanDB is used to retrieve the stored hash of the identity public
+	// key.
+	chanDB *channeldb.DB
+
+	netParams *chaincfg.Params
+}
+
+// A compile time check to ensure that BtcWallet implements the
+// WalletController, and Signer interfaces.
+var _ WalletController = (*BtcWallet)(nil)
+var _ Signer = (*BtcWallet)(nil)
+
+// NewBtcWallet creates/opens and initializes a BtcWallet instance. If the
+// wallet has never been created (according to the passed dataDir), first-time
+// setup is executed, which includes storing the hash of the wallet's identity
+// public key within the channeldb.
+func NewBtcWallet(config *Config, cdb *channeldb.DB) (*BtcWallet, error) {
+	// Ensure the wallet exists or create it when the create flag is set.
+	netDir := networkDir(config.DataDir, config.NetParams)
+
+	var pubPass []byte
+	if config.PublicPass == nil {
+		pubPass = defaultPubPassphrase
+	} else {
+		pubPass = config.PublicPass
+	}
+
+	loader := base.NewLoader(config.NetParams, netDir)
+	walletExists, err := loader.WalletExists()
+	if err != nil {
+		return nil, err
+	}
+
+	var createID bool
+	var wallet *base.Wallet
+	if !walletExists {
+		// Wallet has never been created, perform initial set up.
+		wallet, err = loader.CreateNewWallet(pubPass, config.PrivatePass,
+			config.HdSeed)
+		if err != nil {
+			return nil, err
+		}
+
+		createID = true
+	} else {
+		// Wallet has been created and been initialized at this point, open it
+		// along with all the required DB namepsaces, and the DB itself.
+		wallet, err = loader.OpenExistingWallet(pubPass, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := wallet.Manager.Unlock(config.PrivatePass); err != nil {
+		return nil, err
+	}
+
+	// If we just created the wallet, then reserve, and store a key for
+	// our ID within the Lightning Network.
+	if createID {
+		adrs, err := wallet.Manager.NextInternalAddresses(defaultAccount,
+			1, waddrmgr.WitnessPubKey)
+		if err != nil {
+			return nil, err
+		}
+
+		idPubkeyHash := adrs[0].Address().ScriptAddress()
+		if err := cdb.PutIdKey(idPubkeyHash); err != nil {
+			return nil, err
+		}
+		log.Infof("stored identity key pubkey hash in channeldb")
+	}
+
+	// Create a special websockets rpc client for btcd which will be used
+	// by the wallet for notifications, calls, etc.
+	rpcc, err := chain.NewRPCClient(config.NetParams, config.RpcHost,
+		config.RpcUser, config.RpcPass, config.CACert, false, 20)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BtcWallet{
+		wallet:    wallet,
+		rpc:       rpcc,
+		chanDB:    cdb,
+		netParams: config.NetParams,
+	}, nil
+}
+
+// Start initializes the underlying rpc connection, the wallet itself, and
+// begins syncing to the current available blockchain state.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) Start() error {
+	if atomic.AddInt32(&b.started, 1) != 1 {
+		return nil
+	}
+
+	// Establish an RPC connection in additino to starting the goroutines
+	// in the underlying wallet.
+	if err := b.rpc.Start(); err != nil {
+		return err
+	}
+	b.wallet.Start()
+
+	// Pass the rpc client into the wallet so it can sync up to the current
+	// main chain.
+	b.wallet.SynchronizeRPC(b.rpc)
+
+	return nil
+}
+
+// Stop signals the wallet for shutdown. Shutdown may entail closing
+// any active sockets, database handles, stopping goroutines, etc.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) Stop() error {
+	if atomic.AddInt32(&b.stopped, 1) != 1 {
+		return nil
+	}
+
+	b.wallet.Stop()
+	b.rpc.Shutdown()
+
+	return nil
+}
+
+// WaitForShutdown blocks until the wallet, and its rpc connection have
+// finished shutting down.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) WaitForShutdown() error {
+	b.wallet.WaitForShutdown()
+	b.rpc.WaitForShutdown()
+
+	return nil
+}
+
+// ConfirmedBalance returns the sum of all the wallet's unspent outputs that
+// have at least confs confirmations. If confs is set to zero, then all unspent
+// outputs, including those currently in the mempool will be included in the
+// final sum.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) ConfirmedBalance(confs int32) (btcutil.Amount, error) {
+	return b.wallet.CalculateBalance(confs)
+}
+
+// NewAddress returns the next external address for the wallet. If witness is
+// true, then a p2wkh address is returned, otherwise a regular p2pkh address.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) NewAddress(witness bool) (btcutil.Address, error) {
+	addrType := waddrmgr.PubKeyHash
+	if witness {
+		addrType = waddrmgr.WitnessPubKey
+	}
+
+	return b.wallet.NewAddress(defaultAccount, addrType)
+}
+
+// NewChangeAddress returns a new change address for the wallet, derived from
+// the internal branch of the default account.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) NewChangeAddress(witness bool) (btcutil.Address, error) {
+	addrType := waddrmgr.PubKeyHash
+	if witness {
+		addrType = waddrmgr.WitnessPubKey
+	}
+
+	return b.wallet.NewChangeAddress(defaultAccount, addrType)
+}
+
+// GetPrivKey retrives the underlying private key associated with the passed
+// address. If the address isn't under the control of the wallet, then an
+// error is returned.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) GetPrivKey(a btcutil.Address) (*btcec.PrivateKey, error) {
+	walletAddr, err := b.wallet.Manager.Address(a)
+	if err != nil {
+		return nil, err
+	}
+
+	pkAddr, ok := walletAddr.(waddrmgr.ManagedPubKeyAddress)
+	if !ok {
+		return nil, fmt.Errorf("address %v isn't a public key "+
+			"address", a)
+	}
+
+	return pkAddr.PrivKey()
+}
+
+// NewRawKey retrieves the next key within our HD key-chain for use within as
+// a multi-sig key within the funding transaction, or within the commitment
+// transaction's outputs.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) NewRawKey() (*btcec.PrivateKey, error) {
+	nextAddr, err := b.wallet.Manager.NextExternalAddresses(defaultAccount,
+		1, waddrmgr.WitnessPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	pkAddr := nextAddr[0].(waddrmgr.ManagedPubKeyAddress)
+
+	return pkAddr.PrivKey()
+}
+
+// FetchIdentityKey returns the private key corresponding to the identity
+// public key hash which was stored within the channeldb when the wallet was
+// first created.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) FetchIdentityKey() (*btcec.PrivateKey, error) {
+	idAddr, err := b.chanDB.GetIdAdr()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.GetPrivKey(idAddr)
+}
+
+// FundTransaction creates a new unsigned transactions paying to the passed
+// outputs, using the wallet's unlocked witness outputs as inputs. If change is
+// required, it's sent to changeAddr.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) FundTransaction(outputs []*wire.TxOut,
+	changeAddr btcutil.Address, feePerByte btcutil.Amount) (*wire.MsgTx, error) {
+
+	utxos, err := b.ListUnspentWitness(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return fundTransaction(utxos, outputs, changeAddr, feePerByte)
+}
+
+// SignTransaction generates a valid witness for all the inputs within the
+// passed transaction that spend outputs controlled by the wallet.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) SignTransaction(tx *wire.MsgTx) error {
+	return signWalletInputs(b, tx, b.netParams)
+}
+
+// BroadcastTransaction broadcasts the passed transaction to the Bitcoin
+// network via the connected btcd node.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) BroadcastTransaction(tx *wire.MsgTx) error {
+	return b.wallet.PublishTransaction(tx)
+}
+
+// SendMany funds, signs, and broadcasts a Bitcoin transaction paying out to
+// the specified outputs. The transaction is funded by the wallet's own coin
+// selection, rather than btcwallet's, so the fee rate can be controlled.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) SendMany(outputs []*wire.TxOut,
+	feePerByte btcutil.Amount) (*wire.ShaHash, error) {
+
+	changeAddr, err := b.NewChangeAddress(true)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := b.FundTransaction(outputs, changeAddr, feePerByte)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.SignTransaction(tx); err != nil {
+		return nil, err
+	}
+	if err := b.BroadcastTransaction(tx); err != nil {
+		return nil, err
+	}
+
+	txid := tx.TxSha()
+	return &txid, nil
+}
+
+// ListUnspentWitness returns a slice of all the unspent outputs the wallet
+// controls which pay to witness programs either directly or indirectly.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) ListUnspentWitness(minConfs int32) ([]*Utxo, error) {
+	// First, grab all the unfiltered currently unspent outputs.
+	maxConfs := int32(math.MaxInt32)
+	unspentOutputs, err := b.wallet.ListUnspent(minConfs, maxConfs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Next, we'll run through all the regular outputs, only saving those
+	// which are p2wkh outputs or a p2wsh output nested within a p2sh output.
+	witnessOutputs := make([]*Utxo, 0, len(unspentOutputs))
+	for _, output := range unspentOutputs {
+		pkScript, err := hex.DecodeString(output.ScriptPubKey)
+		if err != nil {
+			return nil, err
+		}
+
+		// TODO(roasbeef): this assumes all p2sh outputs returned by
+		// the wallet are nested p2sh...
+		if txscript.IsPayToWitnessPubKeyHash(pkScript) ||
+			txscript.IsPayToScriptHash(pkScript) {
+
+			txid, err := wire.NewShaHashFromStr(output.TxID)
+			if err != nil {
+				return nil, err
+			}
+
+			// btcjson.ListUnspentResult shows the amount in BTC,
+			// translate into Satoshi so coin selection can work
+			// properly.
+			amt, err := btcutil.NewAmount(output.Amount)
+			if err != nil {
+				return nil, err
+			}
+
+			utxo := &Utxo{
+				Value:    amt,
+				OutPoint: *wire.NewOutPoint(txid, output.Vout),
+			}
+			witnessOutputs = append(witnessOutputs, utxo)
+		}
+
+	}
+
+	return witnessOutputs, nil
+}
+
+// FetchInputInfo returns the previous output referenced by the passed
+// outpoint if it's controlled by the wallet.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) FetchInputInfo(prevOut *wire.OutPoint) (*wire.TxOut, error) {
+	// First, does the wallet even know about the transaction?
+	txDetail, err := b.wallet.TxStore.TxDetails(&prevOut.Hash)
+	if err != nil {
+		return nil, err
+	} else if txDetail == nil {
+		return nil, ErrNotMine
+	}
+
+	numOutputs := uint32(len(txDetail.TxRecord.MsgTx.TxOut))
+	if prevOut.Index >= numOutputs {
+		return nil, fmt.Errorf("invalid output index %v for "+
+			"transaction with %v outputs", prevOut.Index, numOutputs)
+	}
+	output := txDetail.TxRecord.MsgTx.TxOut[prevOut.Index]
+
+	// Finally, ensure that the output itself pays to an address that we
+	// control.
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(output.PkScript,
+		b.netParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, ErrNotMine
+	}
+	if _, err := b.wallet.Manager.Address(addrs[0]); err != nil {
+		return nil, ErrNotMine
+	}
+
+	return output, nil
+}
+
+// GetUtxo queries the connected btcd node for the passed outpoint, returning
+// the output if it's currently unspent.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) GetUtxo(prevOut *wire.OutPoint) (*wire.TxOut, error) {
+	output, err := b.rpc.GetTxOut(&prevOut.Hash, prevOut.Index, false)
+	if err != nil {
+		return nil, err
+	} else if output == nil {
+		return nil, fmt.Errorf("output %v does not exist", prevOut)
+	}
+
+	pkScript, err := hex.DecodeString(output.ScriptPubKey.Hex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sadly, gettxout returns the output value in BTC instead of
+	// satoshis.
+	amt, err := btcutil.NewAmount(output.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return wire.NewTxOut(int64(amt), pkScript), nil
+}
+
+// LockOutpoint marks an outpoint as locked meaning it will no longer be deemed
+// as eligble for coin selection.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) LockOutpoint(o wire.OutPoint) {
+	b.wallet.LockOutpoint(o)
+}
+
+// UnlockOutpoint unlocks an previously locked output, marking it eligible for
+// coin seleciton.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) UnlockOutpoint(o wire.OutPoint) {
+	b.wallet.UnlockOutpoint(o)
+}
+
+// ImportScript imports the passed redeem script into the wallet's database,
+// and requests notifications for any outputs paying to it.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) ImportScript(script []byte) error {
+	lastBlock := b.wallet.Manager.SyncedTo()
+	scriptAddr, err := b.wallet.Manager.ImportScript(script, &lastBlock)
+	if err != nil {
+		return err
+	}
+
+	return b.rpc.NotifyReceived([]btcutil.Address{scriptAddr.Address()})
+}
+
+// SignOutputRaw generates a signature for the passed transaction according to
+// the data within the passed SignDescriptor.
+//
+// This is a part of the Signer interface.
+func (b *BtcWallet) SignOutputRaw(tx *wire.MsgTx, signDesc *SignDescriptor) ([]byte, error) {
+	return signOutputRaw(b, tx, signDesc, b.netParams)
+}
+
+// ComputeInputScript generates a complete InputScript for the passed
+// transaction spending an output controlled by the wallet.
+//
+// This is a part of the Signer interface.
+func (b *BtcWallet) ComputeInputScript(tx *wire.MsgTx,
+	signDesc *SignDescriptor) (*InputScript, error) {
+
+	return signWalletInput(b, tx, signDesc, b.netParams)
+}
+
+// CryptoSystem returns an implementation of the channeldb.EncryptorDecryptor
+// interface which uses the wallet's private key encryption key. This should
+// be registered with the channeldb before any channel state is written.
+func (b *BtcWallet) CryptoSystem() channeldb.EncryptorDecryptor {
+	return &WaddrmgrEncryptorDecryptor{b.wallet.Manager}
+}
+
+// WaddrmgrEncryptorDecryptor implements the channeldb.EncryptorDecryptor
+// interface using the private key encryption key of a waddrmgr.Manager.
+type WaddrmgrEncryptorDecryptor struct {
+	M *waddrmgr.Manager
+}
+
+// Encrypt encrypts the passed plaintext using the manager's private key
+// encryption key.
+func (w *WaddrmgrEncryptorDecryptor) Encrypt(p []byte) ([]byte, error) {
+	return w.M.Encrypt(waddrmgr.CKTPrivate, p)
+}
+
+// Decrypt decrypts the passed ciphertext using the manager's private key
+// encryption key.
+func (w *WaddrmgrEncryptorDecryptor) Decrypt(c []byte) ([]byte, error) {
+	return w.M.Decrypt(waddrmgr.CKTPrivate, c)
+}
+
+// OverheadSize returns the number of bytes encryption adds to a plaintext.
+func (w *WaddrmgrEncryptorDecryptor) OverheadSize() uint32 {
+	return 24
+}
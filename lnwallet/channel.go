@@ -23,6 +23,37 @@ var zeroHash wire.ShaHash
 
 var (
 	ErrChanClosing = fmt.Errorf("channel is being closed, operation disallowed")
+
+	// ErrMaxHTLCNumber is returned when adding an HTLC would exceed the
+	// maximum number of HTLCs in flight permitted by the channel.
+	ErrMaxHTLCNumber = fmt.Errorf("htlc exceeds the max number of " +
+		"pending htlcs")
+
+	// ErrMaxValueInFlight is returned when adding an HTLC would exceed the
+	// maximum total value in flight permitted by the channel.
+	ErrMaxValueInFlight = fmt.Errorf("htlc exceeds the max value in " +
+		"flight")
+
+	// ErrBelowMinHTLC is returned when the value of an HTLC is below the
+	// minimum HTLC size permitted by the channel.
+	ErrBelowMinHTLC = fmt.Errorf("htlc value is below the minimum " +
+		"htlc size")
+
+	// ErrBelowChanReserve is returned when adding an HTLC would dip the
+	// balance of the sender below their channel reserve.
+	ErrBelowChanReserve = fmt.Errorf("htlc would dip the sender's " +
+		"balance below the channel reserve")
+
+	// ErrInsufficientBalance is returned when the value of an HTLC
+	// exceeds the available balance of its sender.
+	ErrInsufficientBalance = fmt.Errorf("htlc exceeds the sender's " +
+		"available balance")
+
+	// ErrNotInitiator is returned when a fee update is sent by the party
+	// which didn't initiate the channel, as only the initiator pays the
+	// commitment fee.
+	ErrNotInitiator = fmt.Errorf("only the channel initiator may " +
+		"update the commitment fee")
 )
 
 const (
@@ -38,6 +69,21 @@ const (
 	// extend the other's commitment chain non-interactively, and also
 	// serves as a flow control mechanism to a degree.
 	InitialRevocationWindow = 4
+
+	// commitBaseSize is the estimated size in bytes of a commitment
+	// transaction without any HTLC outputs, including the witness which
+	// spends the funding output: 4 + 2 + 1 + 41 + 1 + 43 + 31 + 4 + 222.
+	commitBaseSize = 349
+
+	// htlcOutputSize is the size in bytes of each HTLC output added to a
+	// commitment transaction: an 8 byte value, along with a length
+	// prefixed 34 byte P2WSH script.
+	htlcOutputSize = 43
+
+	// closeTxSize is the estimated size in bytes of a cooperative closure
+	// transaction, which pays out to two p2wkh outputs:
+	// 4 + 2 + 1 + 41 + 1 + 31 + 31 + 4 + 222.
+	closeTxSize = 337
 )
 
 // channelState is an enum like type which represents the current state of a
@@ -84,6 +130,7 @@ const (
 	Add updateType = iota
 	Timeout
 	Settle
+	FeeUpdate
 )
 
 // PaymentDescriptor represents a commitment state update which either adds,
@@ -95,15 +142,29 @@ type PaymentDescriptor struct {
 	sync.RWMutex
 
 	// RHash is the payment hash for this HTLC. The HTLC can be settled iff
-	// the preimage to this hash is presented.
+	// the preimage to this hash is presented. For N-of-M "multi-sig"
+	// HTLCs, this is the first of the HTLC's redemption hashes.
 	RHash PaymentHash
 
+	// ContractType is the contract type of the HTLC, encoding the number
+	// of pre-images required to settle it, N, out of its M redemption
+	// hashes. Regular HTLCs have a contract type of zero.
+	ContractType uint8
+
+	// RedemptionHashes are all the redemption hashes of the HTLC, N of
+	// whose pre-images are required to settle it.
+	RedemptionHashes [][32]byte
+
 	// Timeout is the absolute timeout in blocks, afterwhich this HTLC
 	// expires.
 	Timeout uint32
 
-	// Amount is the HTLC amount in satoshis.
-	Amount btcutil.Amount
+	// Amount is the HTLC amount in milli-satoshis.
+	Amount lnwire.MilliSatoshi
+
+	// FeePerKb is the new fee rate in satoshis per kilobyte set by a
+	// FeeUpdate.
+	FeePerKb btcutil.Amount
 
 	// IsIncoming denotes if this is an incoming HTLC add/settle/timeout.
 	IsIncoming bool
@@ -182,9 +243,16 @@ type commitment struct {
 	// [our|their]Balance represents the settled balances at this point
 	// within the commitment chain. This balance is computed by properly
 	// evaluating all the add/remove/settle log entries before the listed
-	// indexes.
-	ourBalance   btcutil.Amount
-	theirBalance btcutil.Amount
+	// indexes. The balances are only rounded down to satoshis within the
+	// commitment transaction itself.
+	ourBalance   lnwire.MilliSatoshi
+	theirBalance lnwire.MilliSatoshi
+
+	// feePerKb is the fee rate paid by this commitment transaction, and
+	// fee is the resulting fee, which is paid by the initiator of the
+	// channel out of their balance.
+	feePerKb btcutil.Amount
+	fee      btcutil.Amount
 }
 
 // commitmentChain represents a chain of unrevoked commitments. The tail of the
@@ -379,6 +447,8 @@ func NewLightningChannel(wallet *LightningWallet, events chainntnfs.ChainNotifie
 		ourMessageIndex:   0,
 		theirBalance:      state.TheirBalance,
 		theirMessageIndex: 0,
+		feePerKb:          state.MinFeePerKb,
+		fee:               commitFee(state.MinFeePerKb, 0),
 	}
 	lc.localCommitChain.addCommitment(initialCommitment)
 	lc.remoteCommitChain.addCommitment(initialCommitment)
@@ -386,7 +456,7 @@ func NewLightningChannel(wallet *LightningWallet, events chainntnfs.ChainNotifie
 	// TODO(roasbeef): do a NotifySpent for the funding input, and
 	// NotifyReceived for all commitment outputs.
 
-	fundingPkScript, err := witnessScriptHash(state.FundingRedeemScript)
+	fundingPkScript, err := WitnessScriptHash(state.FundingRedeemScript)
 	if err != nil {
 		return nil, err
 	}
@@ -472,13 +542,16 @@ func (lc *LightningChannel) fetchCommitmentView(remoteChain bool,
 	}
 
 	// TODO(roasbeef): don't assume view is always fetched from tip?
-	var ourBalance, theirBalance btcutil.Amount
+	var ourBalance, theirBalance lnwire.MilliSatoshi
+	var feePerKb btcutil.Amount
 	if commitChain.tip() == nil {
 		ourBalance = lc.channelState.OurBalance
 		theirBalance = lc.channelState.TheirBalance
+		feePerKb = lc.channelState.MinFeePerKb
 	} else {
 		ourBalance = commitChain.tip().ourBalance
 		theirBalance = commitChain.tip().theirBalance
+		feePerKb = commitChain.tip().feePerKb
 	}
 
 	// Run through all the HTLC's that will be covered by this transaction
@@ -489,8 +562,22 @@ func (lc *LightningChannel) fetchCommitmentView(remoteChain bool,
 	nextHeight := commitChain.tip().height + 1
 	logViewEntries := lc.fetchHTLCView(theirLogIndex, ourLogIndex)
 	htlcs := make([]*PaymentDescriptor, 0, len(logViewEntries))
+	feeUpdated := false
 	for i := len(logViewEntries) - 1; i >= 0; i-- {
 		logEntry := logViewEntries[i]
+
+		// As the log is evaluated in reverse, the first fee update
+		// encountered is the most recent one, which sets the fee rate
+		// of this commitment.
+		if logEntry.entryType == FeeUpdate {
+			if !feeUpdated {
+				feePerKb = logEntry.FeePerKb
+				feeUpdated = true
+			}
+			processFeeUpdate(logEntry, nextHeight, remoteChain)
+			continue
+		}
+
 		if _, ok := skip[logEntry.RHash]; ok {
 			continue
 		}
@@ -501,6 +588,33 @@ func (lc *LightningChannel) fetchCommitmentView(remoteChain bool,
 		}
 	}
 
+	// Each commitment transaction is trimmed according to the dust limit
+	// of its owner, so both sides arrive at an identical transaction.
+	dustLimit := lc.channelState.LocalConstraints.DustLimit
+	if remoteChain {
+		dustLimit = lc.channelState.RemoteConstraints.DustLimit
+	}
+
+	// The fee is determined by the number of HTLC outputs which survive
+	// trimming, and is paid by the initiator of the channel. The balances
+	// recorded within the commitment are left untouched by the fee, as
+	// the fee is recomputed for each new commitment. Both balances are
+	// rounded down to whole satoshis within the transaction, with any
+	// remainder going to fees.
+	var numHTLCOutputs int
+	for _, htlc := range htlcs {
+		if htlc.Amount.ToSatoshis() >= dustLimit {
+			numHTLCOutputs++
+		}
+	}
+	fee := commitFee(feePerKb, numHTLCOutputs)
+	ourOutput, theirOutput := ourBalance.ToSatoshis(), theirBalance.ToSatoshis()
+	if lc.channelState.IsInitiator {
+		fee, ourOutput = deductFee(fee, ourOutput)
+	} else {
+		fee, theirOutput = deductFee(fee, theirOutput)
+	}
+
 	var selfKey *btcec.PublicKey
 	var remoteKey *btcec.PublicKey
 	var delay uint32
@@ -509,25 +623,30 @@ func (lc *LightningChannel) fetchCommitmentView(remoteChain bool,
 		selfKey = lc.channelState.TheirCommitKey
 		remoteKey = lc.channelState.OurCommitKey.PubKey()
 		delay = lc.channelState.RemoteCsvDelay
-		delayBalance = theirBalance
-		p2wkhBalance = ourBalance
+		delayBalance = theirOutput
+		p2wkhBalance = ourOutput
 	} else {
 		selfKey = lc.channelState.OurCommitKey.PubKey()
 		remoteKey = lc.channelState.TheirCommitKey
 		delay = lc.channelState.LocalCsvDelay
-		delayBalance = ourBalance
-		p2wkhBalance = theirBalance
+		delayBalance = ourOutput
+		p2wkhBalance = theirOutput
 	}
 
 	// Generate a new commitment transaction with all the latest
-	// unsettled/un-timed out HTLC's.
+	// unsettled/un-timed out HTLC's. HTLCs below the dust limit don't
+	// receive an output, with their value instead going to fees.
 	ourCommitTx := !remoteChain
 	commitTx, err := createCommitTx(lc.fundingTxIn, selfKey, remoteKey,
-		revocationKey, delay, delayBalance, p2wkhBalance)
+		revocationKey, delay, delayBalance, p2wkhBalance, dustLimit)
 	if err != nil {
 		return nil, err
 	}
 	for _, htlc := range htlcs {
+		if htlc.Amount.ToSatoshis() < dustLimit {
+			continue
+		}
+
 		if err := lc.addHTLC(commitTx, ourCommitTx, htlc,
 			revocationHash, delay); err != nil {
 			return nil, err
@@ -546,9 +665,47 @@ func (lc *LightningChannel) fetchCommitmentView(remoteChain bool,
 		ourMessageIndex:   ourLogIndex,
 		theirMessageIndex: theirLogIndex,
 		theirBalance:      theirBalance,
+		feePerKb:          feePerKb,
+		fee:               fee,
 	}, nil
 }
 
+// commitFee returns the fee paid by a commitment transaction with the passed
+// number of HTLC outputs at the passed fee rate in satoshis per kilobyte.
+func commitFee(feePerKb btcutil.Amount, numHTLCOutputs int) btcutil.Amount {
+	size := commitBaseSize + numHTLCOutputs*htlcOutputSize
+	return feePerKb * btcutil.Amount(size) / 1000
+}
+
+// deductFee deducts the passed fee from the balance of the initiator. If the
+// balance is unable to cover the fee in full, then the entire balance is
+// paid as the fee instead. The fee actually paid, along with the remaining
+// balance are returned.
+func deductFee(fee, balance btcutil.Amount) (btcutil.Amount, btcutil.Amount) {
+	if fee > balance {
+		fee = balance
+	}
+
+	return fee, balance - fee
+}
+
+// processFeeUpdate records the height of the commitment which first includes
+// the passed fee update within either the remote or local commitment chain.
+// Once the update has been included within both chains, it's removed from the
+// log.
+func processFeeUpdate(feeUpdate *PaymentDescriptor, nextHeight uint64,
+	remoteChain bool) {
+
+	addHeight := &feeUpdate.addCommitHeightLocal
+	if remoteChain {
+		addHeight = &feeUpdate.addCommitHeightRemote
+	}
+
+	if *addHeight == 0 {
+		*addHeight = nextHeight
+	}
+}
+
 // processLogEntry processes a log entry within the HTLC log. Processes entries
 // either add new HTLCs to the commitment which weren't present in prior
 // commitments, or remove a commited HTLC which is being settled or timedout.
@@ -557,7 +714,7 @@ func (lc *LightningChannel) fetchCommitmentView(remoteChain bool,
 // the hash of the HTLC which should be excluded from the commitment
 // transaction.
 func processLogEntry(skip map[PaymentHash]struct{}, htlc *PaymentDescriptor,
-	ourBalance, theirBalance *btcutil.Amount, ourLogIndex, theirLogIndex uint32,
+	ourBalance, theirBalance *lnwire.MilliSatoshi, ourLogIndex, theirLogIndex uint32,
 	nextHeight uint64, remoteChain bool) bool {
 
 	if htlc.entryType == Add {
@@ -581,7 +738,7 @@ func processLogEntry(skip map[PaymentHash]struct{}, htlc *PaymentDescriptor,
 // If the HTLC hasn't yet been committed in either chain, then the height it
 // was commited is updated. Keeping track of this inclusion height allows us to
 // later compact the log once the change is fully committed in both chains.
-func processAddEntry(htlc *PaymentDescriptor, ourBalance, theirBalance *btcutil.Amount,
+func processAddEntry(htlc *PaymentDescriptor, ourBalance, theirBalance *lnwire.MilliSatoshi,
 	nextHeight uint64, ourLogIndex, theirLogIndex uint32, remoteChain bool) {
 
 	// If we're evaluating this entry for the remote chain (to create/view
@@ -617,7 +774,7 @@ func processAddEntry(htlc *PaymentDescriptor, ourBalance, theirBalance *btcutil.
 // previously added HTLC. If the removal entry has already been processed, it
 // is skipped.
 func processRemoveEntry(htlc *PaymentDescriptor, ourBalance,
-	theirBalance *btcutil.Amount, nextHeight uint64,
+	theirBalance *lnwire.MilliSatoshi, nextHeight uint64,
 	ourLogIndex, theirLogIndex uint32, remoteChain bool) {
 
 	var removeHeight *uint64
@@ -841,6 +998,7 @@ func (lc *LightningChannel) RevokeCurrentCommitment() (*lnwire.CommitRevocation,
 	lc.channelState.OurBalance = tail.ourBalance
 	lc.channelState.TheirBalance = tail.theirBalance
 	lc.channelState.OurCommitSig = tail.sig
+	lc.channelState.MinFeePerKb = tail.feePerKb
 	lc.channelState.NumUpdates++
 
 	log.Tracef("ChannelPoint(%v): state transition accepted: "+
@@ -942,7 +1100,17 @@ func (lc *LightningChannel) ReceiveRevocation(revMsg *lnwire.CommitRevocation) (
 		next = e.Next()
 		htlc := e.Value.(*PaymentDescriptor)
 
-		if htlc.entryType != Add {
+		if htlc.entryType == FeeUpdate {
+			// A fee update can be removed from the log once it has
+			// been locked into both of our chains.
+			if htlc.addCommitHeightRemote != 0 &&
+				htlc.addCommitHeightLocal != 0 &&
+				remoteChainTail >= htlc.addCommitHeightRemote &&
+				localChainTail >= htlc.addCommitHeightLocal {
+
+				lc.stateUpdateLog.Remove(e)
+			}
+		} else if htlc.entryType != Add {
 			// If this entry is either a timeout or settle, then we
 			// can remove it from our log once the update it locked
 			// into both of our chains.
@@ -997,14 +1165,31 @@ func (lc *LightningChannel) ExtendRevocationWindow() (*lnwire.CommitRevocation,
 }
 
 // AddPayment adds a new HTLC to either the local or remote HTLC log depending
-// on the value of 'incoming'.
-func (lc *LightningChannel) AddHTLC(htlc *lnwire.HTLCAddRequest, incoming bool) error {
+// on the value of 'incoming'. The log index assigned to the HTLC is returned.
+// Outgoing HTLCs which violate the channel's flow-control limits aren't added
+// to the log. However, the remote party may already have signed a commitment
+// including an incoming HTLC by the time it's received, so an incoming HTLC
+// violating the limits is still added in order to keep both logs in sync. Its
+// log index is then returned along with the error, so the HTLC can be
+// cancelled once it's locked in. Only an incoming HTLC exceeding the remote
+// party's balance, which no valid commitment can include, is refused.
+func (lc *LightningChannel) AddHTLC(htlc *lnwire.HTLCAddRequest, incoming bool) (uint32, error) {
+	validateErr := lc.validateAddHTLC(htlc.Amount, incoming)
+	if validateErr != nil && (!incoming ||
+		validateErr == ErrInsufficientBalance) {
+
+		return 0, validateErr
+	}
+
 	pd := &PaymentDescriptor{
-		entryType:  Add,
-		RHash:      PaymentHash(htlc.RedemptionHashes[0]),
-		Timeout:    htlc.Expiry,
-		Amount:     btcutil.Amount(htlc.Amount),
-		IsIncoming: incoming,
+		entryType:        Add,
+		RHash:            PaymentHash(htlc.RedemptionHashes[0]),
+		ContractType:     htlc.ContractType,
+		RedemptionHashes: htlc.RedemptionHashes,
+		Timeout:          htlc.Expiry,
+		Amount:           htlc.Amount,
+		IsIncoming:       incoming,
+		Payload:          htlc.OnionBlob,
 	}
 
 	var index uint32
@@ -1019,6 +1204,58 @@ func (lc *LightningChannel) AddHTLC(htlc *lnwire.HTLCAddRequest, incoming bool)
 	pd.Index = index
 	lc.stateUpdateLog.PushBack(pd)
 
+	return index, validateErr
+}
+
+// validateAddHTLC checks that adding an HTLC of the passed value in the
+// passed direction respects the flow-control limits imposed on the sender of
+// the HTLC. Limits which are unset are left unenforced, however the sender's
+// balance may never dip below their channel reserve. An HTLC exceeding the
+// sender's balance outright is reported ahead of any other violation.
+func (lc *LightningChannel) validateAddHTLC(amt lnwire.MilliSatoshi, incoming bool) error {
+	constraints := lc.channelState.RemoteConstraints
+	if incoming {
+		constraints = lc.channelState.LocalConstraints
+	}
+
+	available := lc.availableBalance(incoming)
+	if amt > available {
+		return ErrInsufficientBalance
+	}
+	if amt < lnwire.NewMSatFromSatoshis(constraints.MinHTLC) {
+		return ErrBelowMinHTLC
+	}
+
+	// Every HTLC the sender has added which hasn't yet been settled, or
+	// timed out is counted as in flight.
+	var numPending uint16
+	var valueInFlight lnwire.MilliSatoshi
+	for e := lc.stateUpdateLog.Front(); e != nil; e = e.Next() {
+		htlc := e.Value.(*PaymentDescriptor)
+		if htlc.entryType != Add || htlc.IsIncoming != incoming ||
+			htlc.settled {
+
+			continue
+		}
+
+		numPending++
+		valueInFlight += htlc.Amount
+	}
+
+	if constraints.MaxPendingHTLCs != 0 &&
+		numPending >= constraints.MaxPendingHTLCs {
+		return ErrMaxHTLCNumber
+	}
+	maxValueInFlight := lnwire.NewMSatFromSatoshis(constraints.MaxValueInFlight)
+	if maxValueInFlight != 0 && valueInFlight+amt > maxValueInFlight {
+		return ErrMaxValueInFlight
+	}
+
+	reserve := lnwire.NewMSatFromSatoshis(constraints.ChannelReserve)
+	if available-amt < reserve {
+		return ErrBelowChanReserve
+	}
+
 	return nil
 }
 
@@ -1028,34 +1265,144 @@ func (lc *LightningChannel) AddHTLC(htlc *lnwire.HTLCAddRequest, incoming bool)
 // the value of incoming should be true. If the settlement fails due to an
 // invalid preimage, then an error is returned.
 func (lc *LightningChannel) SettleHTLC(preimage [32]byte, incoming bool) (uint32, error) {
-	var targetHTLC *list.Element
-
 	// TODO(roasbeef): optimize
-	paymentHash := fastsha256.Sum256(preimage[:])
-	for e := lc.stateUpdateLog.Back(); e != nil; e = e.Next() {
+	preimages := [][32]byte{preimage}
+	targetHTLC := lc.findActiveHTLC(func(htlc *PaymentDescriptor) bool {
+		return htlc.IsIncoming != incoming &&
+			htlc.validPreimages(preimages)
+	})
+	if targetHTLC == nil {
+		return 0, fmt.Errorf("invalid payment hash")
+	}
+
+	lc.appendRemoveEntry(targetHTLC, Settle, incoming)
+
+	return targetHTLC.Value.(*PaymentDescriptor).Index, nil
+}
+
+// SettleIndexedHTLC settles the active HTLC with the passed log index. As
+// with SettleHTLC, the value of incoming should be false when settling an
+// incoming HTLC, and true when receiving the settlement of an outgoing HTLC.
+// Unlike SettleHTLC, the exact HTLC is settled even if several HTLCs share
+// the same payment hash, and several preimages may be presented in order to
+// settle N-of-M "multi-sig" HTLCs. An error is returned if the preimages
+// don't fulfill the HTLC's contract. The payment hash of the settled HTLC is
+// returned.
+func (lc *LightningChannel) SettleIndexedHTLC(preimages [][32]byte,
+	logIndex uint32, incoming bool) ([32]byte, error) {
+
+	targetHTLC := lc.findActiveHTLC(func(htlc *PaymentDescriptor) bool {
+		return htlc.IsIncoming != incoming && htlc.Index == logIndex
+	})
+	if targetHTLC == nil {
+		return [32]byte{}, fmt.Errorf("no active HTLC with log index %v",
+			logIndex)
+	}
+
+	htlc := targetHTLC.Value.(*PaymentDescriptor)
+	if !htlc.validPreimages(preimages) {
+		return [32]byte{}, fmt.Errorf("invalid preimage for HTLC with "+
+			"log index %v", logIndex)
+	}
+
+	lc.appendRemoveEntry(targetHTLC, Settle, incoming)
+
+	return [32]byte(htlc.RHash), nil
+}
+
+// TimeoutHTLC cancels the active incoming HTLC with the passed log index,
+// returning its value to the remote party once the cancellation has been
+// committed.
+func (lc *LightningChannel) TimeoutHTLC(logIndex uint32) error {
+	targetHTLC := lc.findActiveHTLC(func(htlc *PaymentDescriptor) bool {
+		return htlc.IsIncoming && htlc.Index == logIndex
+	})
+	if targetHTLC == nil {
+		return fmt.Errorf("no active incoming HTLC with log index %v",
+			logIndex)
+	}
+
+	lc.appendRemoveEntry(targetHTLC, Timeout, false)
+
+	return nil
+}
+
+// ReceiveTimeoutHTLC processes the cancellation of the outgoing HTLC with the
+// passed log index by the remote party. Once the cancellation has been
+// committed, the value of the HTLC is returned to our balance. The payment
+// hash of the cancelled HTLC is returned.
+func (lc *LightningChannel) ReceiveTimeoutHTLC(logIndex uint32) ([32]byte, error) {
+	targetHTLC := lc.findActiveHTLC(func(htlc *PaymentDescriptor) bool {
+		return !htlc.IsIncoming && htlc.Index == logIndex
+	})
+	if targetHTLC == nil {
+		return [32]byte{}, fmt.Errorf("no active outgoing HTLC with "+
+			"log index %v", logIndex)
+	}
+
+	lc.appendRemoveEntry(targetHTLC, Timeout, true)
+
+	return [32]byte(targetHTLC.Value.(*PaymentDescriptor).RHash), nil
+}
+
+// validPreimages returns true if the passed preimages fulfill the HTLC's
+// contract. Regular HTLCs require the preimage of their payment hash, while
+// N-of-M "multi-sig" HTLCs require the preimages of N distinct redemption
+// hashes.
+func (p *PaymentDescriptor) validPreimages(preimages [][32]byte) bool {
+	redemptionHashes := p.RedemptionHashes
+	if len(redemptionHashes) == 0 {
+		redemptionHashes = [][32]byte{[32]byte(p.RHash)}
+	}
+	numRequired, _ := lnwire.ParseContractType(p.ContractType)
+
+	fulfilled := make(map[[32]byte]struct{})
+	for _, preimage := range preimages {
+		paymentHash := fastsha256.Sum256(preimage[:])
+		for _, redemptionHash := range redemptionHashes {
+			if paymentHash == redemptionHash {
+				fulfilled[paymentHash] = struct{}{}
+			}
+		}
+	}
+
+	return len(fulfilled) >= int(numRequired)
+}
+
+// findActiveHTLC returns the log entry of the most recently added HTLC which
+// hasn't yet been settled, or timed out, and satisfies the passed predicate.
+// If no such HTLC exists, then nil is returned.
+func (lc *LightningChannel) findActiveHTLC(pred func(*PaymentDescriptor) bool) *list.Element {
+	for e := lc.stateUpdateLog.Back(); e != nil; e = e.Prev() {
 		htlc := e.Value.(*PaymentDescriptor)
-		if htlc.entryType != Add {
+		if htlc.entryType != Add || htlc.settled {
 			continue
 		}
 
-		if bytes.Equal(htlc.RHash[:], paymentHash[:]) && !htlc.settled {
-			htlc.settled = true
-			targetHTLC = e
-			break
+		if pred(htlc) {
+			return e
 		}
 	}
-	if targetHTLC == nil {
-		return 0, fmt.Errorf("invalid payment hash")
-	}
+
+	return nil
+}
+
+// appendRemoveEntry adds a new entry to the state update log which either
+// settles, or times out the HTLC referenced by the passed log entry. The
+// value of incoming indicates whether the removal was initiated by the
+// remote party.
+func (lc *LightningChannel) appendRemoveEntry(targetHTLC *list.Element,
+	entryType updateType, incoming bool) {
 
 	parentPd := targetHTLC.Value.(*PaymentDescriptor)
+	parentPd.settled = true
 
 	// TODO(roasbeef): maybe make the log entries an interface?
 	pd := &PaymentDescriptor{}
 	pd.IsIncoming = parentPd.IsIncoming
 	pd.Amount = parentPd.Amount
 	pd.parent = targetHTLC
-	pd.entryType = Settle
+	pd.entryType = entryType
 
 	var index uint32
 	if !incoming {
@@ -1068,15 +1415,136 @@ func (lc *LightningChannel) SettleHTLC(preimage [32]byte, incoming bool) (uint32
 
 	pd.Index = index
 	lc.stateUpdateLog.PushBack(pd)
+}
 
-	return targetHTLC.Value.(*PaymentDescriptor).Index, nil
+// AddForwardingFee credits the passed fee, earned by forwarding an HTLC which
+// arrived over this channel, to the channel's running total of fees.
+func (lc *LightningChannel) AddForwardingFee(fee lnwire.MilliSatoshi) error {
+	return lc.channelState.AddNetFees(fee)
+}
+
+// AvailableBandwidth returns the amount we're able to send across the channel
+// within new HTLCs. It's our balance within the latest local commitment, less
+// the amounts of any outgoing HTLCs not yet included within it, as those funds
+// are reserved for the HTLCs already in flight.
+func (lc *LightningChannel) AvailableBandwidth() lnwire.MilliSatoshi {
+	return lc.availableBalance(false)
 }
 
-// TimeoutHTLC...
-func (lc *LightningChannel) TimeoutHTLC() error {
+// availableBalance returns the balance the sender of HTLCs in the passed
+// direction has left to send. Our own balance is taken from the latest local
+// commitment, less any outgoing HTLCs not yet included within it. The remote
+// party's balance is computed in the same manner from the latest remote
+// commitment, which is the remote party's own view of their balance once
+// they've received our signature for it. If the sender is the initiator of
+// the channel, then the fee of the commitment is deducted as well.
+func (lc *LightningChannel) availableBalance(incoming bool) lnwire.MilliSatoshi {
+	var balance lnwire.MilliSatoshi
+	if incoming {
+		tip := lc.remoteCommitChain.tip()
+		balance = tip.theirBalance
+		if !lc.channelState.IsInitiator {
+			balance -= lnwire.NewMSatFromSatoshis(tip.fee)
+		}
+	} else {
+		tip := lc.localCommitChain.tip()
+		balance = tip.ourBalance
+		if lc.channelState.IsInitiator {
+			balance -= lnwire.NewMSatFromSatoshis(tip.fee)
+		}
+	}
+
+	for e := lc.stateUpdateLog.Front(); e != nil; e = e.Next() {
+		htlc := e.Value.(*PaymentDescriptor)
+		if htlc.entryType != Add || htlc.IsIncoming != incoming {
+			continue
+		}
+
+		committed := htlc.addCommitHeightLocal != 0
+		if incoming {
+			committed = htlc.addCommitHeightRemote != 0
+		}
+		if committed {
+			continue
+		}
+
+		balance -= htlc.Amount
+	}
+
+	return balance
+}
+
+// UpdateFee adds a new fee update to the log, which sets the fee rate of the
+// commitment transactions in satoshis per kilobyte starting from the next
+// state. Only the initiator of the channel, who pays the commitment fee, may
+// update the fee rate.
+func (lc *LightningChannel) UpdateFee(feePerKb btcutil.Amount) error {
+	if !lc.channelState.IsInitiator {
+		return ErrNotInitiator
+	}
+
+	lc.appendFeeUpdate(feePerKb, false)
+
+	return nil
+}
+
+// ReceiveUpdateFee adds a fee update sent by the remote party to the log. The
+// new fee rate will be committed to within the next state. Only the initiator
+// of the channel may update the fee rate, so an error is returned if we're
+// the initiator.
+func (lc *LightningChannel) ReceiveUpdateFee(feePerKb btcutil.Amount) error {
+	if lc.channelState.IsInitiator {
+		return ErrNotInitiator
+	}
+
+	lc.appendFeeUpdate(feePerKb, true)
+
 	return nil
 }
 
+// appendFeeUpdate adds a new fee update entry to the state update log. The
+// value of incoming indicates whether the update was sent by the remote party.
+func (lc *LightningChannel) appendFeeUpdate(feePerKb btcutil.Amount,
+	incoming bool) {
+
+	pd := &PaymentDescriptor{
+		entryType:  FeeUpdate,
+		FeePerKb:   feePerKb,
+		IsIncoming: incoming,
+	}
+
+	if !incoming {
+		pd.Index = lc.ourLogIndex
+		lc.ourLogIndex++
+	} else {
+		pd.Index = lc.theirLogIndex
+		lc.theirLogIndex++
+	}
+
+	lc.stateUpdateLog.PushBack(pd)
+}
+
+// PendingFeeRate returns the fee rate in satoshis per kilobyte which will be
+// used by the next commitment transaction. This is the rate of the most
+// recent fee update within the log, or the rate of the latest local
+// commitment if no fee updates are pending.
+func (lc *LightningChannel) PendingFeeRate() btcutil.Amount {
+	for e := lc.stateUpdateLog.Back(); e != nil; e = e.Prev() {
+		pd := e.Value.(*PaymentDescriptor)
+		if pd.entryType == FeeUpdate {
+			return pd.FeePerKb
+		}
+	}
+
+	return lc.localCommitChain.tip().feePerKb
+}
+
+// IsInitiator returns true if we initiated the funding workflow for the
+// channel, and are therefore responsible for paying the commitment fee.
+func (lc *LightningChannel) IsInitiator() bool {
+	return lc.channelState.IsInitiator
+}
+
 // ChannelPoint returns the outpoint of the original funding transaction which
 // created this active channel. This outpoint is used throughout various
 // sub-systems to uniquely identify an open channel.
@@ -1096,6 +1564,13 @@ func (lc *LightningChannel) addHTLC(commitTx *wire.MsgTx, ourCommit bool,
 	timeout := paymentDesc.Timeout
 	rHash := paymentDesc.RHash
 
+	// N-of-M "multi-sig" HTLCs use the multi-hash versions of the scripts
+	// below, which are otherwise identical.
+	if paymentDesc.ContractType != 0 {
+		return lc.addMultiHashHTLC(commitTx, ourCommit, paymentDesc,
+			revocation, delay)
+	}
+
 	// Generate the proper redeem scripts for the HTLC output modified by
 	// two-bits denoting if this is an incoming HTLC, and if the HTLC is
 	// being applied to their commitment transaction or ours.
@@ -1133,13 +1608,58 @@ func (lc *LightningChannel) addHTLC(commitTx *wire.MsgTx, ourCommit bool,
 
 	// Now that we have the redeem scripts, create the P2WSH public key
 	// script for the output itself.
-	htlcP2WSH, err := witnessScriptHash(pkScript)
+	htlcP2WSH, err := WitnessScriptHash(pkScript)
 	if err != nil {
 		return err
 	}
 
 	// Add the new HTLC outputs to the respective commitment transactions.
-	amountPending := int64(paymentDesc.Amount)
+	amountPending := int64(paymentDesc.Amount.ToSatoshis())
+	commitTx.AddTxOut(wire.NewTxOut(amountPending, htlcP2WSH))
+
+	return nil
+}
+
+// addMultiHashHTLC adds a new N-of-M "multi-sig" HTLC to the passed
+// commitment transaction. As with addHTLC, the script used depends on if the
+// HTLC is incoming, and on whose commitment transaction it's being applied
+// to.
+func (lc *LightningChannel) addMultiHashHTLC(commitTx *wire.MsgTx,
+	ourCommit bool, paymentDesc *PaymentDescriptor, revocation [32]byte,
+	delay uint32) error {
+
+	localKey := lc.channelState.OurCommitKey.PubKey()
+	remoteKey := lc.channelState.TheirCommitKey
+	timeout := paymentDesc.Timeout
+	numRequired, _ := lnwire.ParseContractType(paymentDesc.ContractType)
+	hashes := paymentDesc.RedemptionHashes
+
+	var pkScript []byte
+	var err error
+	switch {
+	case paymentDesc.IsIncoming && ourCommit:
+		pkScript, err = receiverMultiHashHTLCScript(timeout, delay,
+			remoteKey, localKey, revocation[:], numRequired, hashes)
+	case paymentDesc.IsIncoming && !ourCommit:
+		pkScript, err = senderMultiHashHTLCScript(timeout, delay,
+			remoteKey, localKey, revocation[:], numRequired, hashes)
+	case !paymentDesc.IsIncoming && ourCommit:
+		pkScript, err = senderMultiHashHTLCScript(timeout, delay,
+			localKey, remoteKey, revocation[:], numRequired, hashes)
+	case !paymentDesc.IsIncoming && !ourCommit:
+		pkScript, err = receiverMultiHashHTLCScript(timeout, delay,
+			localKey, remoteKey, revocation[:], numRequired, hashes)
+	}
+	if err != nil {
+		return err
+	}
+
+	htlcP2WSH, err := WitnessScriptHash(pkScript)
+	if err != nil {
+		return err
+	}
+
+	amountPending := int64(paymentDesc.Amount.ToSatoshis())
 	commitTx.AddTxOut(wire.NewTxOut(amountPending, htlcP2WSH))
 
 	return nil
@@ -1161,9 +1681,11 @@ func (lc *LightningChannel) ForceClose() error {
 // of an unresponsive remote party, the initiator can either choose to execute
 // a force closure, or backoff for a period of time, and retry the cooperative
 // closure.
+// The closure transaction pays a fee at the passed fee rate in satoshis per
+// kilobyte, which must be sent to the remote party along with our signature.
 // TODO(roasbeef): caller should initiate signal to reject all incoming HTLCs,
 // settle any inflight.
-func (lc *LightningChannel) InitCooperativeClose() ([]byte, *wire.ShaHash, error) {
+func (lc *LightningChannel) InitCooperativeClose(feePerKb btcutil.Amount) ([]byte, *wire.ShaHash, error) {
 	lc.Lock()
 	defer lc.Unlock() // TODO(roasbeef): coarser graiend locking
 
@@ -1179,9 +1701,10 @@ func (lc *LightningChannel) InitCooperativeClose() ([]byte, *wire.ShaHash, error
 
 	// TODO(roasbeef): assumes initiator pays fees
 	closeTx := createCooperativeCloseTx(lc.fundingTxIn,
-		lc.channelState.OurBalance, lc.channelState.TheirBalance,
+		lc.channelState.OurBalance.ToSatoshis(),
+		lc.channelState.TheirBalance.ToSatoshis(),
 		lc.channelState.OurDeliveryScript, lc.channelState.TheirDeliveryScript,
-		true)
+		true, feePerKb)
 	closeTxSha := closeTx.TxSha()
 
 	// Finally, sign the completed cooperative closure transaction. As the
@@ -1204,8 +1727,11 @@ func (lc *LightningChannel) InitCooperativeClose() ([]byte, *wire.ShaHash, error
 // active lightning channel. This method should be called in response to the
 // remote node initating a cooperative channel closure. A fully signed closure
 // transaction is returned. It is the duty of the responding node to broadcast
-// a signed+valid closure transaction to the network.
-func (lc *LightningChannel) CompleteCooperativeClose(remoteSig []byte) (*wire.MsgTx, error) {
+// a signed+valid closure transaction to the network. The fee rate in satoshis
+// per kilobyte must match the fee rate requested by the remote node.
+func (lc *LightningChannel) CompleteCooperativeClose(remoteSig []byte,
+	feePerKb btcutil.Amount) (*wire.MsgTx, error) {
+
 	lc.Lock()
 	defer lc.Unlock() // TODO(roasbeef): coarser graiend locking
 
@@ -1221,9 +1747,10 @@ func (lc *LightningChannel) CompleteCooperativeClose(remoteSig []byte) (*wire.Ms
 	// on this active channel back to both parties. In this current model,
 	// the initiator pays full fees for the cooperative close transaction.
 	closeTx := createCooperativeCloseTx(lc.fundingTxIn,
-		lc.channelState.OurBalance, lc.channelState.TheirBalance,
+		lc.channelState.OurBalance.ToSatoshis(),
+		lc.channelState.TheirBalance.ToSatoshis(),
 		lc.channelState.OurDeliveryScript, lc.channelState.TheirDeliveryScript,
-		false)
+		false, feePerKb)
 
 	// With the transaction created, we can finally generate our half of
 	// the 2-of-2 multi-sig needed to redeem the funding output.
@@ -1272,7 +1799,7 @@ func (lc *LightningChannel) StateSnapshot() *channeldb.ChannelSnapshot {
 // counter-party within the channel, which can be spent immediately.
 func createCommitTx(fundingOutput *wire.TxIn, selfKey, theirKey *btcec.PublicKey,
 	revokeKey *btcec.PublicKey, csvTimeout uint32, amountToSelf,
-	amountToThem btcutil.Amount) (*wire.MsgTx, error) {
+	amountToThem, dustLimit btcutil.Amount) (*wire.MsgTx, error) {
 
 	// First, we create the script for the delayed "pay-to-self" output.
 	// This output has 2 main redemption clauses: either we can redeem the
@@ -1284,7 +1811,7 @@ func createCommitTx(fundingOutput *wire.TxIn, selfKey, theirKey *btcec.PublicKey
 	if err != nil {
 		return nil, err
 	}
-	payToUsScriptHash, err := witnessScriptHash(ourRedeemScript)
+	payToUsScriptHash, err := WitnessScriptHash(ourRedeemScript)
 	if err != nil {
 		return nil, err
 	}
@@ -1302,9 +1829,15 @@ func createCommitTx(fundingOutput *wire.TxIn, selfKey, theirKey *btcec.PublicKey
 	commitTx := wire.NewMsgTx()
 	commitTx.Version = 2
 	commitTx.AddTxIn(fundingOutput)
-	// TODO(roasbeef): don't make 0 BTC output...
-	commitTx.AddTxOut(wire.NewTxOut(int64(amountToSelf), payToUsScriptHash))
-	commitTx.AddTxOut(wire.NewTxOut(int64(amountToThem), theirWitnessKeyHash))
+
+	// Balance outputs below the dust limit are omitted, as they'd render
+	// the transaction non-standard. Their value is instead paid as fees.
+	if amountToSelf >= dustLimit {
+		commitTx.AddTxOut(wire.NewTxOut(int64(amountToSelf), payToUsScriptHash))
+	}
+	if amountToThem >= dustLimit {
+		commitTx.AddTxOut(wire.NewTxOut(int64(amountToThem), theirWitnessKeyHash))
+	}
 
 	return commitTx, nil
 }
@@ -1314,11 +1847,11 @@ func createCommitTx(fundingOutput *wire.TxIn, selfKey, theirKey *btcec.PublicKey
 // of the closure transaction is modified by a boolean indicating if the party
 // constructing the channel is the initiator of the closure. Currently it is
 // expected that the initiator pays the transaction fees for the closing
-// transaction in full.
+// transaction in full, at the passed fee rate in satoshis per kilobyte.
 func createCooperativeCloseTx(fundingTxIn *wire.TxIn,
 	ourBalance, theirBalance btcutil.Amount,
 	ourDeliveryScript, theirDeliveryScript []byte,
-	initiator bool) *wire.MsgTx {
+	initiator bool, feePerKb btcutil.Amount) *wire.MsgTx {
 
 	// Construct the transaction to perform a cooperative closure of the
 	// channel. In the event that one side doesn't have any settled funds
@@ -1329,11 +1862,11 @@ func createCooperativeCloseTx(fundingTxIn *wire.TxIn,
 
 	// The initiator the a cooperative closure pays the fee in entirety.
 	// Determine if we're the initiator so we can compute fees properly.
+	fee := feePerKb * closeTxSize / 1000
 	if initiator {
-		// TODO(roasbeef): take sat/byte here instead of properly calc
-		ourBalance -= 5000
+		_, ourBalance = deductFee(fee, ourBalance)
 	} else {
-		theirBalance -= 5000
+		_, theirBalance = deductFee(fee, theirBalance)
 	}
 
 	// TODO(roasbeef): dust check...
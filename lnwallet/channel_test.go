@@ -2,6 +2,7 @@ package lnwallet
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -77,12 +78,12 @@ func createTestChannels() (*LightningChannel, *LightningChannel, func(), error)
 	aliceRevokeKey := deriveRevocationPubkey(bobKeyPub, aliceFirstRevoke[:])
 
 	aliceCommitTx, err := createCommitTx(fundingTxIn, aliceKeyPub,
-		bobKeyPub, aliceRevokeKey, csvTimeoutAlice, channelBal, channelBal)
+		bobKeyPub, aliceRevokeKey, csvTimeoutAlice, channelBal, channelBal, 0)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 	bobCommitTx, err := createCommitTx(fundingTxIn, bobKeyPub,
-		aliceKeyPub, bobRevokeKey, csvTimeoutBob, channelBal, channelBal)
+		aliceKeyPub, bobRevokeKey, csvTimeoutBob, channelBal, channelBal, 0)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -106,8 +107,8 @@ func createTestChannels() (*LightningChannel, *LightningChannel, func(), error)
 		OurCommitKey:           aliceKeyPriv,
 		TheirCommitKey:         bobKeyPub,
 		Capacity:               channelCapacity,
-		OurBalance:             channelBal,
-		TheirBalance:           channelBal,
+		OurBalance:             lnwire.NewMSatFromSatoshis(channelBal),
+		TheirBalance:           lnwire.NewMSatFromSatoshis(channelBal),
 		OurCommitTx:            aliceCommitTx,
 		FundingOutpoint:        prevOut,
 		OurMultiSigKey:         aliceKeyPriv,
@@ -126,8 +127,8 @@ func createTestChannels() (*LightningChannel, *LightningChannel, func(), error)
 		OurCommitKey:           bobKeyPriv,
 		TheirCommitKey:         aliceKeyPub,
 		Capacity:               channelCapacity,
-		OurBalance:             channelBal,
-		TheirBalance:           channelBal,
+		OurBalance:             lnwire.NewMSatFromSatoshis(channelBal),
+		TheirBalance:           lnwire.NewMSatFromSatoshis(channelBal),
 		OurCommitTx:            bobCommitTx,
 		FundingOutpoint:        prevOut,
 		OurMultiSigKey:         bobKeyPriv,
@@ -219,19 +220,27 @@ func TestSimpleAddSettleWorkflow(t *testing.T) {
 	htlc := &lnwire.HTLCAddRequest{
 		RedemptionHashes: [][32]byte{paymentHash},
 		// TODO(roasbeef): properly switch to credits: (1 msat)
-		Amount: lnwire.CreditsAmount(1e8),
+		Amount: lnwire.NewMSatFromSatoshis(1e8),
 		Expiry: uint32(5),
 	}
 
 	// First Alice adds the outgoing HTLC to her local channel's state
 	// update log.
-	if err := aliceChannel.AddHTLC(htlc, false); err != nil {
+	if _, err := aliceChannel.AddHTLC(htlc, false); err != nil {
 		t.Fatalf("unable to add htlc to alice's channel: %v", err)
 	}
 
+	// The amount of the HTLC should be reserved from Alice's bandwidth,
+	// even though it isn't yet committed.
+	expectedBandwidth := lnwire.NewMSatFromSatoshis(4 * 1e8)
+	if bandwidth := aliceChannel.AvailableBandwidth(); bandwidth != expectedBandwidth {
+		t.Fatalf("alice has incorrect bandwidth %v vs %v", bandwidth,
+			expectedBandwidth)
+	}
+
 	// Then Alice sends this wire message over to Bob who also adds this
 	// htlc to his local state update log.
-	if err := bobChannel.AddHTLC(htlc, true); err != nil {
+	if _, err := bobChannel.AddHTLC(htlc, true); err != nil {
 		t.Fatalf("unable to add htlc bob's channel: %v", err)
 	}
 
@@ -287,8 +296,8 @@ func TestSimpleAddSettleWorkflow(t *testing.T) {
 
 	// At this point, both sides should have the proper balance, and
 	// commitment height updated within their local channel state.
-	aliceBalance := btcutil.Amount(4 * 1e8)
-	bobBalance := btcutil.Amount(5 * 1e8)
+	aliceBalance := lnwire.NewMSatFromSatoshis(4 * 1e8)
+	bobBalance := lnwire.NewMSatFromSatoshis(5 * 1e8)
 	if aliceChannel.channelState.OurBalance != aliceBalance {
 		t.Fatalf("alice has incorrect local balance %v vs %v",
 			aliceChannel.channelState.OurBalance, aliceBalance)
@@ -371,8 +380,8 @@ func TestSimpleAddSettleWorkflow(t *testing.T) {
 	// At this point, bob should have 6BTC settled, with Alice still having
 	// 4 BTC. They should also be at a commitment height at two, with the
 	// revocation window extended by by 1 (5).
-	aliceSettleBalance := btcutil.Amount(4 * 1e8)
-	bobSettleBalance := btcutil.Amount(6 * 1e8)
+	aliceSettleBalance := lnwire.NewMSatFromSatoshis(4 * 1e8)
+	bobSettleBalance := lnwire.NewMSatFromSatoshis(6 * 1e8)
 	if aliceChannel.channelState.OurBalance != aliceSettleBalance {
 		t.Fatalf("alice has incorrect local balance %v vs %v",
 			aliceChannel.channelState.OurBalance, aliceSettleBalance)
@@ -381,6 +390,14 @@ func TestSimpleAddSettleWorkflow(t *testing.T) {
 		t.Fatalf("alice has incorrect remote balance %v vs %v",
 			aliceChannel.channelState.TheirBalance, bobSettleBalance)
 	}
+	if bandwidth := aliceChannel.AvailableBandwidth(); bandwidth != aliceSettleBalance {
+		t.Fatalf("alice has incorrect bandwidth %v vs %v", bandwidth,
+			aliceSettleBalance)
+	}
+	if bandwidth := bobChannel.AvailableBandwidth(); bandwidth != bobSettleBalance {
+		t.Fatalf("bob has incorrect bandwidth %v vs %v", bandwidth,
+			bobSettleBalance)
+	}
 	if bobChannel.channelState.OurBalance != bobSettleBalance {
 		t.Fatalf("bob has incorrect local balance %v vs %v",
 			bobChannel.channelState.OurBalance, bobSettleBalance)
@@ -420,3 +437,579 @@ func TestSimpleAddSettleWorkflow(t *testing.T) {
 			"instead", bobLogLen)
 	}
 }
+
+// TestSettleIndexedHTLC tests that HTLCs sharing a payment hash, such as the
+// shards of a multi-path payment, can each be settled by their log index.
+func TestSettleIndexedHTLC(t *testing.T) {
+	aliceChannel, bobChannel, cleanUp, err := createTestChannels()
+	if err != nil {
+		t.Fatalf("unable to create test channels: %v", err)
+	}
+	defer cleanUp()
+
+	var preimage [32]byte
+	copy(preimage[:], bytes.Repeat([]byte{2}, 32))
+	paymentHash := fastsha256.Sum256(preimage[:])
+	preimages := [][32]byte{preimage}
+
+	// Alice sends Bob two HTLCs sharing the same payment hash.
+	var indexes []uint32
+	for i := 0; i < 2; i++ {
+		htlc := &lnwire.HTLCAddRequest{
+			RedemptionHashes: [][32]byte{paymentHash},
+			Amount:           lnwire.NewMSatFromSatoshis(1e8),
+			Expiry:           uint32(5),
+		}
+		aliceIndex, err := aliceChannel.AddHTLC(htlc, false)
+		if err != nil {
+			t.Fatalf("alice unable to add htlc: %v", err)
+		}
+		bobIndex, err := bobChannel.AddHTLC(htlc, true)
+		if err != nil {
+			t.Fatalf("bob unable to add htlc: %v", err)
+		}
+		if aliceIndex != bobIndex {
+			t.Fatalf("log indexes don't match: alice has %v, bob "+
+				"has %v", aliceIndex, bobIndex)
+		}
+		indexes = append(indexes, bobIndex)
+	}
+
+	// A settle with the wrong preimage should be rejected.
+	if _, err := bobChannel.SettleIndexedHTLC([][32]byte{{}}, indexes[0], false); err == nil {
+		t.Fatalf("settle with invalid preimage accepted")
+	}
+
+	// Settling the first HTLC should leave the second active on both
+	// sides.
+	if _, err := bobChannel.SettleIndexedHTLC(preimages, indexes[0], false); err != nil {
+		t.Fatalf("bob unable to settle htlc: %v", err)
+	}
+	if _, err := aliceChannel.SettleIndexedHTLC(preimages, indexes[0], true); err != nil {
+		t.Fatalf("alice unable to accept settle of htlc: %v", err)
+	}
+	if _, err := bobChannel.SettleIndexedHTLC(preimages, indexes[0], false); err == nil {
+		t.Fatalf("settled htlc settled twice")
+	}
+	if _, err := bobChannel.SettleIndexedHTLC(preimages, indexes[1], false); err != nil {
+		t.Fatalf("bob unable to settle second htlc: %v", err)
+	}
+	if _, err := aliceChannel.SettleIndexedHTLC(preimages, indexes[1], true); err != nil {
+		t.Fatalf("alice unable to accept settle of second htlc: %v", err)
+	}
+}
+
+// TestMultiHashHTLC tests that an N-of-M "multi-sig" HTLC can be committed
+// to by both sides, and is only settled once enough preimages are presented.
+func TestMultiHashHTLC(t *testing.T) {
+	aliceChannel, bobChannel, cleanUp, err := createTestChannels()
+	if err != nil {
+		t.Fatalf("unable to create test channels: %v", err)
+	}
+	defer cleanUp()
+	if err := initRevocationWindows(aliceChannel, bobChannel, 3); err != nil {
+		t.Fatalf("unable to init revocation windows: %v", err)
+	}
+
+	// Alice sends Bob a 2-of-3 HTLC.
+	preimages := [][32]byte{{1}, {2}, {3}}
+	hashes := make([][32]byte, len(preimages))
+	for i, preimage := range preimages {
+		hashes[i] = fastsha256.Sum256(preimage[:])
+	}
+	htlc := &lnwire.HTLCAddRequest{
+		ContractType:     lnwire.NewMultiHashContract(2, 3),
+		RedemptionHashes: hashes,
+		Amount:           lnwire.NewMSatFromSatoshis(1e8),
+		Expiry:           uint32(5),
+	}
+	if _, err := aliceChannel.AddHTLC(htlc, false); err != nil {
+		t.Fatalf("alice unable to add htlc: %v", err)
+	}
+	bobIndex, err := bobChannel.AddHTLC(htlc, true)
+	if err != nil {
+		t.Fatalf("bob unable to add htlc: %v", err)
+	}
+	if err := forceStateTransition(aliceChannel, bobChannel); err != nil {
+		t.Fatalf("unable to complete state transition: %v", err)
+	}
+
+	// A single preimage, or the same preimage presented twice, isn't
+	// enough to settle the HTLC.
+	_, err = bobChannel.SettleIndexedHTLC(preimages[:1], bobIndex, false)
+	if err == nil {
+		t.Fatalf("htlc settled with a single preimage")
+	}
+	_, err = bobChannel.SettleIndexedHTLC([][32]byte{preimages[1],
+		preimages[1]}, bobIndex, false)
+	if err == nil {
+		t.Fatalf("htlc settled with a duplicate preimage")
+	}
+
+	// Any two of the three preimages settle the HTLC.
+	settlePreimages := [][32]byte{preimages[0], preimages[2]}
+	_, err = bobChannel.SettleIndexedHTLC(settlePreimages, bobIndex, false)
+	if err != nil {
+		t.Fatalf("bob unable to settle htlc: %v", err)
+	}
+	_, err = aliceChannel.SettleIndexedHTLC(settlePreimages, bobIndex, true)
+	if err != nil {
+		t.Fatalf("alice unable to accept settle of htlc: %v", err)
+	}
+	if err := forceStateTransition(bobChannel, aliceChannel); err != nil {
+		t.Fatalf("unable to complete state transition: %v", err)
+	}
+
+	bobBalance := lnwire.NewMSatFromSatoshis(6 * 1e8)
+	if bobChannel.channelState.OurBalance != bobBalance {
+		t.Fatalf("bob has incorrect balance %v vs %v",
+			bobChannel.channelState.OurBalance, bobBalance)
+	}
+}
+
+// TestChannelConstraints tests that HTLCs violating the flow-control limits
+// of a channel are refused by the sender, and flagged by the receiver without
+// corrupting the state of either log, so they can be cancelled once locked in.
+func TestChannelConstraints(t *testing.T) {
+	aliceChannel, bobChannel, cleanUp, err := createTestChannels()
+	if err != nil {
+		t.Fatalf("unable to create test channels: %v", err)
+	}
+	defer cleanUp()
+	if err := initRevocationWindows(aliceChannel, bobChannel, 3); err != nil {
+		t.Fatalf("unable to init revocation windows: %v", err)
+	}
+
+	// Bob only accepts up to two HTLCs from Alice, worth at most 3 BTC in
+	// total, of at least 1000 satoshis each. Alice must also keep 1 BTC
+	// on her side of the channel.
+	constraints := channeldb.ChannelConstraints{
+		MaxPendingHTLCs:  2,
+		MaxValueInFlight: btcutil.Amount(3e8),
+		MinHTLC:          btcutil.Amount(1000),
+		ChannelReserve:   btcutil.Amount(1e8),
+	}
+	aliceChannel.channelState.RemoteConstraints = constraints
+	bobChannel.channelState.LocalConstraints = constraints
+
+	newHTLC := func(amt btcutil.Amount) *lnwire.HTLCAddRequest {
+		return &lnwire.HTLCAddRequest{
+			RedemptionHashes: [][32]byte{fastsha256.Sum256([]byte{byte(amt)})},
+			Amount:           lnwire.NewMSatFromSatoshis(amt),
+			Expiry:           uint32(5),
+		}
+	}
+
+	// Alice should refuse to send an HTLC below Bob's minimum, and also
+	// one which exceeds the max value in flight.
+	if _, err := aliceChannel.AddHTLC(newHTLC(999), false); err != ErrBelowMinHTLC {
+		t.Fatalf("expected ErrBelowMinHTLC, got %v", err)
+	}
+	if _, err := aliceChannel.AddHTLC(newHTLC(4e8), false); err != ErrMaxValueInFlight {
+		t.Fatalf("expected ErrMaxValueInFlight, got %v", err)
+	}
+
+	// Two HTLCs within the limits should be accepted by both sides.
+	for i := 0; i < 2; i++ {
+		htlc := newHTLC(btcutil.Amount(1e8))
+		if _, err := aliceChannel.AddHTLC(htlc, false); err != nil {
+			t.Fatalf("alice unable to add htlc: %v", err)
+		}
+		if _, err := bobChannel.AddHTLC(htlc, true); err != nil {
+			t.Fatalf("bob unable to add htlc: %v", err)
+		}
+	}
+
+	// A third HTLC exceeds the max number of pending HTLCs.
+	if _, err := aliceChannel.AddHTLC(newHTLC(1000), false); err != ErrMaxHTLCNumber {
+		t.Fatalf("expected ErrMaxHTLCNumber, got %v", err)
+	}
+
+	// Lift the limit on the number of HTLCs on both sides. Alice now has
+	// 3 BTC left, so sending another 2.5 BTC would dip her below her
+	// reserve.
+	aliceChannel.channelState.RemoteConstraints.MaxPendingHTLCs = 0
+	aliceChannel.channelState.RemoteConstraints.MaxValueInFlight = 0
+	bobChannel.channelState.LocalConstraints.MaxPendingHTLCs = 0
+	bobChannel.channelState.LocalConstraints.MaxValueInFlight = 0
+	if _, err := aliceChannel.AddHTLC(newHTLC(2.5e8), false); err != ErrBelowChanReserve {
+		t.Fatalf("expected ErrBelowChanReserve, got %v", err)
+	}
+
+	// If Alice ignores her own limits, then Bob should flag the HTLC as
+	// violating his, while still adding it to his log, as Alice may
+	// already have signed a commitment including it.
+	aliceChannel.channelState.RemoteConstraints = channeldb.ChannelConstraints{}
+	rejectedHTLC := newHTLC(2.5e8)
+	aliceIndex, err := aliceChannel.AddHTLC(rejectedHTLC, false)
+	if err != nil {
+		t.Fatalf("alice unable to add htlc: %v", err)
+	}
+	bobIndex, err := bobChannel.AddHTLC(rejectedHTLC, true)
+	if err != ErrBelowChanReserve {
+		t.Fatalf("expected ErrBelowChanReserve, got %v", err)
+	}
+	if aliceIndex != bobIndex {
+		t.Fatalf("log indexes don't match: alice has %v, bob has %v",
+			aliceIndex, bobIndex)
+	}
+
+	// An HTLC exceeding Alice's balance can't be included within any
+	// commitment, so Bob should refuse it outright.
+	if _, err := bobChannel.AddHTLC(newHTLC(1e8), true); err !=
+		ErrInsufficientBalance {
+
+		t.Fatalf("expected ErrInsufficientBalance, got %v", err)
+	}
+
+	// Both sides should be able to commit to the violating HTLC, which
+	// Bob then cancels through the normal timeout path.
+	if err := forceStateTransition(aliceChannel, bobChannel); err != nil {
+		t.Fatalf("unable to complete state transition: %v", err)
+	}
+	if err := bobChannel.TimeoutHTLC(bobIndex); err != nil {
+		t.Fatalf("bob unable to cancel htlc: %v", err)
+	}
+	payHash, err := aliceChannel.ReceiveTimeoutHTLC(aliceIndex)
+	if err != nil {
+		t.Fatalf("alice unable to process htlc cancel: %v", err)
+	}
+	if payHash != rejectedHTLC.RedemptionHashes[0] {
+		t.Fatalf("wrong payment hash for cancelled htlc: expected %x, "+
+			"got %x", rejectedHTLC.RedemptionHashes[0], payHash)
+	}
+	if err := forceStateTransition(bobChannel, aliceChannel); err != nil {
+		t.Fatalf("unable to complete state transition: %v", err)
+	}
+
+	// Once the cancellation is committed, Alice should have her 3 BTC
+	// available once again.
+	if bandwidth := aliceChannel.AvailableBandwidth(); bandwidth !=
+		lnwire.NewMSatFromSatoshis(3e8) {
+
+		t.Fatalf("alice should have 3 BTC available, instead has %v",
+			bandwidth)
+	}
+
+	// With room for only one more HTLC alongside the two still pending,
+	// an HTLC which has been cancelled should no longer count towards the
+	// limit, even before its log entries are pruned.
+	bobChannel.channelState.LocalConstraints.MaxPendingHTLCs = 3
+	aliceChannel.channelState.RemoteConstraints =
+		bobChannel.channelState.LocalConstraints
+	htlc := newHTLC(btcutil.Amount(1e6))
+	aliceIndex, err = aliceChannel.AddHTLC(htlc, false)
+	if err != nil {
+		t.Fatalf("alice unable to add htlc: %v", err)
+	}
+	bobIndex, err = bobChannel.AddHTLC(htlc, true)
+	if err != nil {
+		t.Fatalf("bob unable to add htlc: %v", err)
+	}
+	if err := forceStateTransition(aliceChannel, bobChannel); err != nil {
+		t.Fatalf("unable to complete state transition: %v", err)
+	}
+	if _, err := aliceChannel.AddHTLC(newHTLC(1000), false); err != ErrMaxHTLCNumber {
+		t.Fatalf("expected ErrMaxHTLCNumber, got %v", err)
+	}
+	if err := bobChannel.TimeoutHTLC(bobIndex); err != nil {
+		t.Fatalf("bob unable to cancel htlc: %v", err)
+	}
+	if _, err := aliceChannel.ReceiveTimeoutHTLC(aliceIndex); err != nil {
+		t.Fatalf("alice unable to process htlc cancel: %v", err)
+	}
+	htlc = newHTLC(1000)
+	if _, err := aliceChannel.AddHTLC(htlc, false); err != nil {
+		t.Fatalf("alice unable to add htlc: %v", err)
+	}
+	if _, err := bobChannel.AddHTLC(htlc, true); err != nil {
+		t.Fatalf("bob unable to add htlc: %v", err)
+	}
+}
+
+// TestDustTrimming tests that HTLCs, and balances below the dust limit of a
+// commitment transaction's owner are trimmed from it, with both sides
+// arriving at identical commitment transactions.
+func TestDustTrimming(t *testing.T) {
+	aliceChannel, bobChannel, cleanUp, err := createTestChannels()
+	if err != nil {
+		t.Fatalf("unable to create test channels: %v", err)
+	}
+	defer cleanUp()
+	if err := initRevocationWindows(aliceChannel, bobChannel, 3); err != nil {
+		t.Fatalf("unable to init revocation windows: %v", err)
+	}
+
+	// Alice's commitment has a dust limit of 1000 satoshis, while Bob's
+	// has a dust limit of 5000 satoshis.
+	aliceDustLimit := btcutil.Amount(1000)
+	bobDustLimit := btcutil.Amount(5000)
+	aliceChannel.channelState.LocalConstraints.DustLimit = aliceDustLimit
+	aliceChannel.channelState.RemoteConstraints.DustLimit = bobDustLimit
+	bobChannel.channelState.LocalConstraints.DustLimit = bobDustLimit
+	bobChannel.channelState.RemoteConstraints.DustLimit = aliceDustLimit
+
+	// Alice sends Bob an HTLC which is above her dust limit, yet below
+	// Bob's. Once committed, the HTLC should only receive an output within
+	// Alice's commitment.
+	htlc := &lnwire.HTLCAddRequest{
+		RedemptionHashes: [][32]byte{fastsha256.Sum256([]byte("dust"))},
+		Amount:           lnwire.NewMSatFromSatoshis(3000),
+		Expiry:           uint32(5),
+	}
+	if _, err := aliceChannel.AddHTLC(htlc, false); err != nil {
+		t.Fatalf("alice unable to add htlc: %v", err)
+	}
+	if _, err := bobChannel.AddHTLC(htlc, true); err != nil {
+		t.Fatalf("bob unable to add htlc: %v", err)
+	}
+	if err := forceStateTransition(aliceChannel, bobChannel); err != nil {
+		t.Fatalf("unable to complete state transition: %v", err)
+	}
+
+	aliceOutputs := len(aliceChannel.channelState.OurCommitTx.TxOut)
+	if aliceOutputs != 3 {
+		t.Fatalf("alice's commitment should have 3 outputs, instead "+
+			"has %v", aliceOutputs)
+	}
+	bobOutputs := len(bobChannel.channelState.OurCommitTx.TxOut)
+	if bobOutputs != 2 {
+		t.Fatalf("bob's commitment should have 2 outputs, instead "+
+			"has %v", bobOutputs)
+	}
+
+	// A balance below the dust limit shouldn't receive an output either.
+	fundingTxIn := aliceChannel.fundingTxIn
+	selfKey := aliceChannel.channelState.OurCommitKey.PubKey()
+	theirKey := aliceChannel.channelState.TheirCommitKey
+	commitTx, err := createCommitTx(fundingTxIn, selfKey, theirKey, selfKey,
+		5, btcutil.Amount(1e8), aliceDustLimit-1, aliceDustLimit)
+	if err != nil {
+		t.Fatalf("unable to create commitment transaction: %v", err)
+	}
+	if len(commitTx.TxOut) != 1 {
+		t.Fatalf("dust balance output not trimmed, commitment has %v "+
+			"outputs", len(commitTx.TxOut))
+	}
+	if commitTx.TxOut[0].Value != 1e8 {
+		t.Fatalf("wrong output remaining: expected value of %v, got %v",
+			1e8, commitTx.TxOut[0].Value)
+	}
+}
+
+// TestUpdateFee tests that a fee update sent by the initiator of a channel is
+// committed within the next state of both parties, with the fee paid out of
+// the initiator's balance, and that fee updates from the other party are
+// rejected.
+func TestUpdateFee(t *testing.T) {
+	aliceChannel, bobChannel, cleanUp, err := createTestChannels()
+	if err != nil {
+		t.Fatalf("unable to create test channels: %v", err)
+	}
+	defer cleanUp()
+	if err := initRevocationWindows(aliceChannel, bobChannel, 3); err != nil {
+		t.Fatalf("unable to init revocation windows: %v", err)
+	}
+
+	// Alice initiated the channel, so only she may update the fee.
+	aliceChannel.channelState.IsInitiator = true
+	if err := bobChannel.UpdateFee(1000); err != ErrNotInitiator {
+		t.Fatalf("bob shouldn't be able to update the fee, "+
+			"instead got: %v", err)
+	}
+	if err := aliceChannel.ReceiveUpdateFee(1000); err != ErrNotInitiator {
+		t.Fatalf("alice shouldn't accept a fee update, instead "+
+			"got: %v", err)
+	}
+
+	feePerKb := btcutil.Amount(10000)
+	if err := aliceChannel.UpdateFee(feePerKb); err != nil {
+		t.Fatalf("alice unable to update fee: %v", err)
+	}
+	if err := bobChannel.ReceiveUpdateFee(feePerKb); err != nil {
+		t.Fatalf("bob unable to receive fee update: %v", err)
+	}
+	if aliceChannel.PendingFeeRate() != feePerKb {
+		t.Fatalf("wrong pending fee rate: expected %v, got %v",
+			feePerKb, aliceChannel.PendingFeeRate())
+	}
+	if err := forceStateTransition(aliceChannel, bobChannel); err != nil {
+		t.Fatalf("unable to complete state transition: %v", err)
+	}
+
+	// Both commitments should now pay the new fee out of Alice's balance,
+	// while Bob's balance remains untouched.
+	fee := commitFee(feePerKb, 0)
+	channelBal := aliceChannel.channelState.Capacity / 2
+	for _, channel := range []*LightningChannel{aliceChannel, bobChannel} {
+		if channel.channelState.MinFeePerKb != feePerKb {
+			t.Fatalf("fee rate not updated: expected %v, got %v",
+				feePerKb, channel.channelState.MinFeePerKb)
+		}
+
+		var aliceOutput, bobOutput int64
+		for _, txOut := range channel.channelState.OurCommitTx.TxOut {
+			switch txOut.Value {
+			case int64(channelBal - fee):
+				aliceOutput = txOut.Value
+			case int64(channelBal):
+				bobOutput = txOut.Value
+			}
+		}
+		if aliceOutput == 0 || bobOutput == 0 {
+			t.Fatalf("commitment doesn't pay the fee from alice's " +
+				"balance")
+		}
+	}
+
+	// The fee is reserved from Alice's bandwidth.
+	aliceBandwidth := lnwire.NewMSatFromSatoshis(channelBal - fee)
+	if aliceChannel.AvailableBandwidth() != aliceBandwidth {
+		t.Fatalf("wrong bandwidth: expected %v, got %v",
+			aliceBandwidth, aliceChannel.AvailableBandwidth())
+	}
+	bobBandwidth := lnwire.NewMSatFromSatoshis(channelBal)
+	if bobChannel.AvailableBandwidth() != bobBandwidth {
+		t.Fatalf("wrong bandwidth: expected %v, got %v",
+			bobBandwidth, bobChannel.AvailableBandwidth())
+	}
+}
+
+// initRevocationWindows extends the revocation windows of both channels, as
+// done by two peers at the start of each session.
+func initRevocationWindows(chanA, chanB *LightningChannel, windowSize int) error {
+	for i := 0; i < windowSize; i++ {
+		aliceNextRevoke, err := chanA.ExtendRevocationWindow()
+		if err != nil {
+			return err
+		}
+		if _, err := chanB.ReceiveRevocation(aliceNextRevoke); err != nil {
+			return err
+		}
+
+		bobNextRevoke, err := chanB.ExtendRevocationWindow()
+		if err != nil {
+			return err
+		}
+		if _, err := chanA.ReceiveRevocation(bobNextRevoke); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forceStateTransition executes a full state transition initiated by chanA,
+// after which all the updates within both logs are committed within both
+// commitment chains.
+func forceStateTransition(chanA, chanB *LightningChannel) error {
+	aSig, bIndex, err := chanA.SignNextCommitment()
+	if err != nil {
+		return err
+	}
+	if err := chanB.ReceiveNewCommitment(aSig, bIndex); err != nil {
+		return err
+	}
+	bSig, aIndex, err := chanB.SignNextCommitment()
+	if err != nil {
+		return err
+	}
+	bRevocation, err := chanB.RevokeCurrentCommitment()
+	if err != nil {
+		return err
+	}
+
+	if err := chanA.ReceiveNewCommitment(bSig, aIndex); err != nil {
+		return err
+	}
+	if _, err := chanA.ReceiveRevocation(bRevocation); err != nil {
+		return err
+	}
+	aRevocation, err := chanA.RevokeCurrentCommitment()
+	if err != nil {
+		return err
+	}
+	if _, err := chanB.ReceiveRevocation(aRevocation); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// benchmarkHTLCBatches measures the cost of sending, then settling a single
+// HTLC when the updates to the channel are batched, with up to batchSize
+// HTLCs covered by each commitment.
+func benchmarkHTLCBatches(b *testing.B, batchSize int) {
+	aliceChannel, bobChannel, cleanUp, err := createTestChannels()
+	if err != nil {
+		b.Fatalf("unable to create test channels: %v", err)
+	}
+	defer cleanUp()
+
+	if err := initRevocationWindows(aliceChannel, bobChannel, 3); err != nil {
+		b.Fatalf("unable to init revocation windows: %v", err)
+	}
+
+	// Each batch of HTLCs is locked in by a single state transition, then
+	// settled together by another.
+	var preimages [][32]byte
+	commitBatch := func() {
+		if err := forceStateTransition(aliceChannel, bobChannel); err != nil {
+			b.Fatalf("unable to lock in htlcs: %v", err)
+		}
+		for _, preimage := range preimages {
+			if _, err := bobChannel.SettleHTLC(preimage, false); err != nil {
+				b.Fatalf("bob unable to settle htlc: %v", err)
+			}
+			if _, err := aliceChannel.SettleHTLC(preimage, true); err != nil {
+				b.Fatalf("alice unable to accept settle: %v", err)
+			}
+		}
+		if err := forceStateTransition(bobChannel, aliceChannel); err != nil {
+			b.Fatalf("unable to commit settles: %v", err)
+		}
+		preimages = preimages[:0]
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var preimage [32]byte
+		binary.BigEndian.PutUint64(preimage[:], uint64(i))
+		htlc := &lnwire.HTLCAddRequest{
+			RedemptionHashes: [][32]byte{fastsha256.Sum256(preimage[:])},
+			Amount:           lnwire.NewMSatFromSatoshis(1000),
+			Expiry:           uint32(5),
+		}
+		if _, err := aliceChannel.AddHTLC(htlc, false); err != nil {
+			b.Fatalf("alice unable to add htlc: %v", err)
+		}
+		if _, err := bobChannel.AddHTLC(htlc, true); err != nil {
+			b.Fatalf("bob unable to add htlc: %v", err)
+		}
+
+		preimages = append(preimages, preimage)
+		if len(preimages) == batchSize {
+			commitBatch()
+		}
+	}
+	if len(preimages) != 0 {
+		commitBatch()
+	}
+}
+
+// BenchmarkHTLCsUnbatched commits each HTLC within its own commitment, as is
+// done without a trickle delay.
+func BenchmarkHTLCsUnbatched(b *testing.B) {
+	benchmarkHTLCBatches(b, 1)
+}
+
+// BenchmarkHTLCsBatch10 commits batches of 10 HTLCs.
+func BenchmarkHTLCsBatch10(b *testing.B) {
+	benchmarkHTLCBatches(b, 10)
+}
+
+// BenchmarkHTLCsBatch20 commits batches of 20 HTLCs, the default maximum
+// batch size.
+func BenchmarkHTLCsBatch20(b *testing.B) {
+	benchmarkHTLCBatches(b, 20)
+}
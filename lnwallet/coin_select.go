@@ -1,73 +1,120 @@
 package lnwallet
 
 import (
-	"encoding/hex"
+	"sort"
 
-	"github.com/roasbeef/btcd/btcjson"
+	"github.com/roasbeef/btcd/txscript"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcutil"
-	"github.com/roasbeef/btcutil/coinset"
+	"github.com/roasbeef/btcutil/txsort"
 )
 
-// lnCoin represents a single unspet output. Its purpose is to convert a regular
-// output to a struct adhering to the coinset.Coin interface
-type lnCoin struct {
-	hash     *wire.ShaHash
-	index    uint32
-	value    btcutil.Amount
-	pkScript []byte
-	numConfs int64
-	valueAge int64
-}
+const (
+	// txOverheadSize is the size in bytes of the fields common to all
+	// witness transactions: the version, marker, flag, input and output
+	// counts, and the lock time.
+	txOverheadSize = 4 + 2 + 1 + 1 + 4
 
-func (l *lnCoin) Hash() *wire.ShaHash   { return l.hash }
-func (l *lnCoin) Index() uint32         { return l.index }
-func (l *lnCoin) Value() btcutil.Amount { return l.value }
-func (l *lnCoin) PkScript() []byte      { return l.pkScript }
-func (l *lnCoin) NumConfs() int64       { return l.numConfs }
-func (l *lnCoin) ValueAge() int64       { return l.valueAge }
+	// p2wkhInputSize is the estimated size in bytes of an input spending a
+	// p2wkh output: the outpoint, an empty sigScript, the sequence number,
+	// and a witness holding a signature along with a compressed public
+	// key.
+	p2wkhInputSize = 36 + 1 + 4 + 1 + 1 + 73 + 1 + 33
 
-// Ensure lnCoin adheres to the coinset.Coin interface.
-var _ coinset.Coin = (*lnCoin)(nil)
+	// p2wkhOutputSize is the size in bytes of a p2wkh output: the value,
+	// along with a length prefixed 22 byte pkScript.
+	p2wkhOutputSize = 8 + 1 + 22
 
-// newLnCoin creates a new "coin" from the passed output. Coins are required
-// in order to perform coin selection upon.
-func newLnCoin(output *btcjson.ListUnspentResult) (coinset.Coin, error) {
-	txid, err := wire.NewShaHashFromStr(output.TxID)
-	if err != nil {
-		return nil, err
+	// p2wshOutputSize is the size in bytes of a p2wsh output: the value,
+	// along with a length prefixed 34 byte pkScript.
+	p2wshOutputSize = 8 + 1 + 34
+
+	// minChangeAmount is the smallest change output the wallet will
+	// create. Any change below this amount is donated to the miners.
+	minChangeAmount = btcutil.Amount(10000)
+)
+
+// utxoByValue implements sort.Interface in order to sort a slice of outputs
+// by descending value.
+type utxoByValue []*Utxo
+
+func (u utxoByValue) Len() int           { return len(u) }
+func (u utxoByValue) Less(i, j int) bool { return u[i].Value > u[j].Value }
+func (u utxoByValue) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+
+// coinSelect attempts to select a set of the passed outputs which sum to at
+// least amt satoshis, plus the fee of the transaction spending them at the
+// passed fee rate in satoshis per byte. The size of the transaction is
+// estimated as baseSize bytes, plus the size of each p2wkh input selected.
+// Outputs are selected greedily largest first in order to minimize the number
+// of inputs, and therefore the size of the final transaction. The selected
+// outputs along with the change left over are returned. If the outputs are
+// insufficient, then ErrInsufficientFunds is returned.
+// TODO(roasbeef): Should extend with optimal coin selection heuristics for
+// our use case.
+func coinSelect(feePerByte, amt btcutil.Amount, baseSize int,
+	coins []*Utxo) ([]*Utxo, btcutil.Amount, error) {
+
+	sortedCoins := make([]*Utxo, len(coins))
+	copy(sortedCoins, coins)
+	sort.Sort(utxoByValue(sortedCoins))
+
+	var selectedTotal btcutil.Amount
+	for i, coin := range sortedCoins {
+		selectedTotal += coin.Value
+
+		txSize := baseSize + (i+1)*p2wkhInputSize
+		amtNeeded := amt + feePerByte*btcutil.Amount(txSize)
+		if selectedTotal >= amtNeeded {
+			return sortedCoins[:i+1], selectedTotal - amtNeeded, nil
+		}
+	}
+
+	return nil, 0, ErrInsufficientFunds
+}
+
+// fundTransaction creates a new unsigned transaction paying to the passed
+// outputs, selecting inputs from the passed set of available outputs. The
+// inputs will also be sufficient to pay a fee at the passed fee rate in
+// satoshis per byte, a fee rate of zero funds the outputs alone. If the change
+// left over is above the dust limit, then a change output paying to changeAddr
+// is also added. The final transaction is sorted according to BIP-69.
+func fundTransaction(utxos []*Utxo, outputs []*wire.TxOut,
+	changeAddr btcutil.Address, feePerByte btcutil.Amount) (*wire.MsgTx, error) {
+
+	// The size of the transaction is estimated assuming a change output
+	// will be present.
+	var amtNeeded btcutil.Amount
+	baseSize := txOverheadSize + p2wkhOutputSize
+	for _, output := range outputs {
+		amtNeeded += btcutil.Amount(output.Value)
+		baseSize += output.SerializeSize()
 	}
 
-	pkScript, err := hex.DecodeString(output.ScriptPubKey)
+	selectedCoins, changeAmt, err := coinSelect(feePerByte, amtNeeded,
+		baseSize, utxos)
 	if err != nil {
 		return nil, err
 	}
 
-	return &lnCoin{
-		hash: txid,
-		// btcjson.ListUnspentResult shows the amount in BTC,
-		// translate into Satoshi so coin selection can work properly.
-		value:    btcutil.Amount(output.Amount * 1e8),
-		index:    output.Vout,
-		pkScript: pkScript,
-		numConfs: output.Confirmations,
-		// TODO(roasbeef): output.Amount should be a int64, damn json-RPC :/
-		valueAge: output.Confirmations * int64(output.Amount),
-	}, nil
-}
+	tx := wire.NewMsgTx()
+	for _, coin := range selectedCoins {
+		outPoint := coin.OutPoint
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+	}
+	for _, output := range outputs {
+		tx.AddTxOut(output)
+	}
 
-// outputsToCoins converts a slice of transaction outputs to a coin-selectable
-// slice of "Coins"s.
-func outputsToCoins(outputs []*btcjson.ListUnspentResult) ([]coinset.Coin, error) {
-	coins := make([]coinset.Coin, len(outputs))
-	for i, output := range outputs {
-		coin, err := newLnCoin(output)
+	if changeAmt >= minChangeAmount {
+		changeScript, err := txscript.PayToAddrScript(changeAddr)
 		if err != nil {
 			return nil, err
 		}
-
-		coins[i] = coin
+		tx.AddTxOut(wire.NewTxOut(int64(changeAmt), changeScript))
 	}
 
-	return coins, nil
+	txsort.InPlaceSort(tx)
+
+	return tx, nil
 }
@@ -0,0 +1,180 @@
+package lnwallet
+
+import (
+	"sort"
+
+	"github.com/lightningnetwork/lnd/uspv"
+	"github.com/roasbeef/btcrpcclient"
+	"github.com/roasbeef/btcutil"
+)
+
+// FeeEstimator provides the ability to estimate the fee rate a transaction
+// should pay in order to be confirmed within a target number of blocks.
+// Implementations may source their estimates from a full node, from the
+// blocks recently seen by a light client, or simply return a fixed rate.
+type FeeEstimator interface {
+	// EstimateFeePerByte returns the fee rate in satoshis per byte which
+	// a transaction should pay in order to be confirmed within numBlocks
+	// blocks.
+	EstimateFeePerByte(numBlocks uint32) (btcutil.Amount, error)
+}
+
+// StaticFeeEstimator is an implementation of the FeeEstimator interface which
+// returns the same fee rate regardless of the confirmation target.
+type StaticFeeEstimator struct {
+	// FeeRate is the fee rate in satoshis per byte returned for all
+	// confirmation targets.
+	FeeRate btcutil.Amount
+}
+
+// A compile time check to ensure StaticFeeEstimator implements the
+// FeeEstimator interface.
+var _ FeeEstimator = (*StaticFeeEstimator)(nil)
+
+// EstimateFeePerByte returns the static fee rate.
+//
+// This is a part of the FeeEstimator interface.
+func (s *StaticFeeEstimator) EstimateFeePerByte(numBlocks uint32) (btcutil.Amount, error) {
+	return s.FeeRate, nil
+}
+
+// BtcdFeeEstimator is an implementation of the FeeEstimator interface backed
+// by the estimatefee RPC call of a btcd node, or of a bitcoind node, which
+// shares the same interface. If the node has yet to gather enough data to
+// produce an estimate, then the fallback fee rate is returned instead.
+type BtcdFeeEstimator struct {
+	fallbackFeeRate btcutil.Amount
+
+	chainConn *btcrpcclient.Client
+}
+
+// A compile time check to ensure BtcdFeeEstimator implements the
+// FeeEstimator interface.
+var _ FeeEstimator = (*BtcdFeeEstimator)(nil)
+
+// NewBtcdFeeEstimator creates a new BtcdFeeEstimator which queries the btcd
+// node detailed in the passed configuration. As the estimator only issues
+// one-off queries, the node's RPC interface is accessed via HTTP POST
+// requests rather than a persistent websockets connection.
+func NewBtcdFeeEstimator(rpcConfig btcrpcclient.ConnConfig,
+	fallbackFeeRate btcutil.Amount) (*BtcdFeeEstimator, error) {
+
+	rpcConfig.HTTPPostMode = true
+	chainConn, err := btcrpcclient.New(&rpcConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BtcdFeeEstimator{
+		fallbackFeeRate: fallbackFeeRate,
+		chainConn:       chainConn,
+	}, nil
+}
+
+// EstimateFeePerByte queries btcd for the fee rate required to be confirmed
+// within numBlocks blocks.
+//
+// This is a part of the FeeEstimator interface.
+func (b *BtcdFeeEstimator) EstimateFeePerByte(numBlocks uint32) (btcutil.Amount, error) {
+	btcPerKB, err := b.chainConn.EstimateFee(int64(numBlocks))
+	if err != nil {
+		return 0, err
+	}
+
+	// btcd returns a negative fee rate if it's unable to produce an
+	// estimate for the target.
+	if btcPerKB <= 0 {
+		return b.fallbackFeeRate, nil
+	}
+
+	satPerKB, err := btcutil.NewAmount(btcPerKB)
+	if err != nil {
+		return 0, err
+	}
+
+	return satPerByteFromKB(satPerKB), nil
+}
+
+// SPVFeeEstimator is an implementation of the FeeEstimator interface which
+// derives fee rates from the blocks recently ingested by a uspv connection.
+// The average fee rate paid within each block is known, so shorter
+// confirmation targets are estimated using the rates of the most expensive
+// recent blocks. If no blocks have been ingested yet, then the fallback fee
+// rate is returned instead.
+//
+// NOTE: Only the full blocks ingested in hard mode reveal their fees, so a
+// connection which syncs filtered blocks always returns the fallback rate.
+type SPVFeeEstimator struct {
+	fallbackFeeRate btcutil.Amount
+
+	con feeRateSource
+}
+
+// feeRateSource is the source of the recent block fee rates an
+// SPVFeeEstimator estimates from, satisfied by uspv.SPVCon.
+type feeRateSource interface {
+	// RecentFeeRates returns the average fee rates, in satoshis per byte,
+	// paid within the most recently ingested blocks.
+	RecentFeeRates() []int64
+}
+
+// A compile time check to ensure SPVFeeEstimator implements the FeeEstimator
+// interface.
+var _ FeeEstimator = (*SPVFeeEstimator)(nil)
+
+// NewSPVFeeEstimator creates a new SPVFeeEstimator which estimates fee rates
+// from the blocks ingested by the passed uspv connection.
+func NewSPVFeeEstimator(con *uspv.SPVCon,
+	fallbackFeeRate btcutil.Amount) *SPVFeeEstimator {
+
+	return &SPVFeeEstimator{
+		fallbackFeeRate: fallbackFeeRate,
+		con:             con,
+	}
+}
+
+// EstimateFeePerByte estimates the fee rate required to be confirmed within
+// numBlocks blocks. The recent block fee rates are sorted in descending
+// order, and the rate numBlocks-1/numBlocks of the way through is selected,
+// so a target of a single block pays the highest recent rate, while longer
+// targets approach the lowest.
+//
+// This is a part of the FeeEstimator interface.
+func (s *SPVFeeEstimator) EstimateFeePerByte(numBlocks uint32) (btcutil.Amount, error) {
+	blockRates := s.con.RecentFeeRates()
+	if len(blockRates) == 0 {
+		return s.fallbackFeeRate, nil
+	}
+
+	feeRates := make(feeRateSlice, len(blockRates))
+	for i, rate := range blockRates {
+		feeRates[i] = btcutil.Amount(rate)
+	}
+	sort.Sort(sort.Reverse(feeRates))
+
+	if numBlocks == 0 {
+		numBlocks = 1
+	}
+	index := (len(feeRates) - 1) * int(numBlocks-1) / int(numBlocks)
+
+	return feeRates[index], nil
+}
+
+// feeRateSlice implements sort.Interface in order to sort a slice of fee rates
+// in ascending order.
+type feeRateSlice []btcutil.Amount
+
+func (f feeRateSlice) Len() int           { return len(f) }
+func (f feeRateSlice) Less(i, j int) bool { return f[i] < f[j] }
+func (f feeRateSlice) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+// satPerByteFromKB converts the passed fee rate in satoshis per kilobyte to a
+// fee rate in satoshis per byte. The rate is rounded up, so a transaction never
+// pays less than the estimated rate, and a non-zero rate never becomes zero.
+func satPerByteFromKB(satPerKB btcutil.Amount) btcutil.Amount {
+	if satPerKB <= 0 {
+		return 0
+	}
+
+	return (satPerKB + 999) / 1000
+}
@@ -0,0 +1,150 @@
+package lnwallet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/roasbeef/btcrpcclient"
+	"github.com/roasbeef/btcutil"
+)
+
+// mockFeeRateSource is a feeRateSource returning a fixed set of block fee
+// rates.
+type mockFeeRateSource struct {
+	feeRates []int64
+}
+
+func (m *mockFeeRateSource) RecentFeeRates() []int64 {
+	return m.feeRates
+}
+
+func TestSatPerByteFromKB(t *testing.T) {
+	tests := []struct {
+		satPerKB   btcutil.Amount
+		satPerByte btcutil.Amount
+	}{
+		{-1000, 0},
+		{0, 0},
+		{1, 1},
+		{999, 1},
+		{1000, 1},
+		{1001, 2},
+		{12345, 13},
+	}
+
+	for _, test := range tests {
+		satPerByte := satPerByteFromKB(test.satPerKB)
+		if satPerByte != test.satPerByte {
+			t.Fatalf("expected %v sat/kB to be %v sat/byte, got %v",
+				test.satPerKB, test.satPerByte, satPerByte)
+		}
+	}
+}
+
+func TestBtcdFeeEstimator(t *testing.T) {
+	// The node replies to estimatefee with whatever fee rate, in BTC/kB,
+	// is currently set.
+	var btcPerKB float64
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Method string      `json:"method"`
+				ID     interface{} `json:"id"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if req.Method != "estimatefee" {
+				t.Errorf("unexpected method %v", req.Method)
+			}
+
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"result": btcPerKB,
+				"error":  nil,
+				"id":     req.ID,
+			})
+		},
+	))
+	defer server.Close()
+
+	fallbackFeeRate := btcutil.Amount(50)
+	estimator, err := NewBtcdFeeEstimator(btcrpcclient.ConnConfig{
+		Host:       strings.TrimPrefix(server.URL, "http://"),
+		DisableTLS: true,
+	}, fallbackFeeRate)
+	if err != nil {
+		t.Fatalf("unable to create estimator: %v", err)
+	}
+
+	// Until the node has gathered enough data, it returns a negative fee
+	// rate, so the fallback rate should be used.
+	btcPerKB = -1
+	feeRate, err := estimator.EstimateFeePerByte(2)
+	if err != nil {
+		t.Fatalf("unable to estimate fee: %v", err)
+	}
+	if feeRate != fallbackFeeRate {
+		t.Fatalf("expected fallback fee rate %v, got %v",
+			fallbackFeeRate, feeRate)
+	}
+
+	// Otherwise the estimate should be converted to sat/byte, rounding
+	// up any fraction of a satoshi.
+	btcPerKB = 0.00012345
+	feeRate, err = estimator.EstimateFeePerByte(2)
+	if err != nil {
+		t.Fatalf("unable to estimate fee: %v", err)
+	}
+	if feeRate != 13 {
+		t.Fatalf("expected fee rate of 13 sat/byte, got %v", feeRate)
+	}
+}
+
+func TestSPVFeeEstimator(t *testing.T) {
+	fallbackFeeRate := btcutil.Amount(50)
+	source := &mockFeeRateSource{}
+	estimator := &SPVFeeEstimator{
+		fallbackFeeRate: fallbackFeeRate,
+		con:             source,
+	}
+
+	// With no blocks ingested, the fallback rate should be used.
+	feeRate, err := estimator.EstimateFeePerByte(1)
+	if err != nil {
+		t.Fatalf("unable to estimate fee: %v", err)
+	}
+	if feeRate != fallbackFeeRate {
+		t.Fatalf("expected fallback fee rate %v, got %v",
+			fallbackFeeRate, feeRate)
+	}
+
+	// Shorter targets should select the rates of the more expensive
+	// blocks, with a target of a single block paying the highest rate.
+	source.feeRates = []int64{5, 1, 3, 2, 4}
+	tests := []struct {
+		numBlocks uint32
+		feeRate   btcutil.Amount
+	}{
+		{0, 5},
+		{1, 5},
+		{2, 3},
+		{3, 3},
+		{5, 2},
+		{100, 2},
+	}
+	for _, test := range tests {
+		feeRate, err := estimator.EstimateFeePerByte(test.numBlocks)
+		if err != nil {
+			t.Fatalf("unable to estimate fee: %v", err)
+		}
+		if feeRate != test.feeRate {
+			t.Fatalf("expected fee rate %v for a target of %v "+
+				"blocks, got %v", test.feeRate, test.numBlocks,
+				feeRate)
+		}
+	}
+}
@@ -1,11 +1,32 @@
 package lnwallet
 
 import (
+	"errors"
+
 	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/txscript"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcutil"
 )
 
+var (
+	// ErrNotMine is returned when a WalletController is asked about an
+	// output, or address which isn't under its control.
+	ErrNotMine = errors.New("the passed output doesn't belong to the wallet")
+
+	// ErrNoUtxoSet is returned by a WalletController which is unable to
+	// query the UTXO set for outputs that it doesn't control. SPV wallets,
+	// for example, have no view of the UTXO set beyond their own outputs.
+	ErrNoUtxoSet = errors.New("wallet is unable to query the utxo set")
+)
+
+// Utxo is an unspent output denoted by its outpoint, and output value of the
+// original output.
+type Utxo struct {
+	Value btcutil.Amount
+	wire.OutPoint
+}
+
 // WalletController defines an abstract interface for controlling a local Pure
 // Go wallet, a local or remote wallet via an RPC mechanism, or possibly even
 // a daemon assisted hardware wallet. This interface serves the purpose of
@@ -21,7 +42,7 @@ type WalletController interface {
 	// that have at least confs confirmations. If confs is set to zero,
 	// then all unspent outputs, including those currently in the mempool
 	// will be included in the final sum.
-	ConfirmedBalance(confs int32) btcutil.Amount
+	ConfirmedBalance(confs int32) (btcutil.Amount, error)
 
 	// NewAddress returns the next external address for the wallet. The
 	// type of address returned is dictated by the wallet's capabilities,
@@ -37,7 +58,7 @@ type WalletController interface {
 	// passed address. If the wallet is unable to locate this private key
 	// due to the address not being under control of the wallet, then an
 	// error should be returned.
-	GetPrivKey(a *btcutil.Address) (*btcec.PrivateKey, error)
+	GetPrivKey(a btcutil.Address) (*btcec.PrivateKey, error)
 
 	// NewRawKey returns a raw private key controlled by the wallet. These
 	// keys are used for the 2-of-2 multi-sig outputs for funding
@@ -52,10 +73,11 @@ type WalletController interface {
 
 	// FundTransaction creates a new unsigned transactions paying to the
 	// passed outputs, possibly using the specified change address. The
-	// includeFee parameter dictates if the wallet should also provide
-	// enough the funds necessary to create an adequate fee or not.
+	// wallet should also provide enough funds to pay a fee at the passed
+	// fee rate in satoshis per byte. A fee rate of zero indicates that
+	// only the outputs themselves should be funded.
 	FundTransaction(outputs []*wire.TxOut, changeAddr btcutil.Address,
-		includeFee bool) (*wire.MsgTx, error)
+		feePerByte btcutil.Amount) (*wire.MsgTx, error)
 
 	// SignTransaction performs potentially a sparse, or full signing of
 	// all inputs within the passed transaction that are spendable by the
@@ -67,16 +89,33 @@ type WalletController interface {
 	BroadcastTransaction(tx *wire.MsgTx) error
 
 	// SendMany funds, signs, and broadcasts a Bitcoin transaction paying
-	// out to the specified outputs. In the case the wallet has insufficient
-	// funds, or the outputs are non-standard, and error should be returned.
-	SendMany(outputs []*wire.TxOut) (*wire.ShaHash, error)
+	// out to the specified outputs, at the passed fee rate in satoshis per
+	// byte. In the case the wallet has insufficient funds, or the outputs
+	// are non-standard, and error should be returned.
+	SendMany(outputs []*wire.TxOut,
+		feePerByte btcutil.Amount) (*wire.ShaHash, error)
 
 	// ListUnspentWitness returns all unspent outputs which are version 0
 	// witness programs. The 'confirms' parameter indicates the minimum
 	// number of confirmations an output needs in order to be returned by
 	// this method. Passing -1 as 'confirms' indicates that even unconfirmed
 	// outputs should be returned.
-	ListUnspentWitness(confirms int32) ([]*wire.OutPoint, error)
+	ListUnspentWitness(confirms int32) ([]*Utxo, error)
+
+	// FetchInputInfo returns the previous output referenced by the passed
+	// outpoint if the output is under the control of the wallet. If the
+	// output isn't controlled by the wallet, then ErrNotMine should be
+	// returned. This method is used to sign the wallet's own inputs to
+	// funding transactions.
+	FetchInputInfo(prevOut *wire.OutPoint) (*wire.TxOut, error)
+
+	// GetUtxo returns the output referenced by the passed outpoint if it's
+	// currently unspent, regardless of whether the output is controlled
+	// by the wallet or not. This method is used to verify the inputs the
+	// counterparty contributes to a dual funded channel. Wallets without
+	// a view of the full UTXO set should return ErrNoUtxoSet for outputs
+	// they don't control.
+	GetUtxo(prevOut *wire.OutPoint) (*wire.TxOut, error)
 
 	// LockOutpoint marks an outpoint as locked meaning it will no longer
 	// be deemed as eligble for coin selection. Locking outputs are utilized
@@ -109,3 +148,55 @@ type WalletController interface {
 	// TODO(roasbeef): ImportPriv?
 	//  * segwitty flag?
 }
+
+// SignDescriptor houses the necessary information required to successfully
+// sign a given output. This struct is used by the Signer interface in order
+// to gain access to critical data needed to generate a valid signature.
+type SignDescriptor struct {
+	// PubKey is the public key to which the signature should be generated
+	// over. The Signer should then generate a signature with the private
+	// key corresponding to this public key.
+	PubKey *btcec.PublicKey
+
+	// RedeemScript is the full script required to properly redeem the
+	// output. This field will only be populated if a p2wsh or a p2sh
+	// output is being signed.
+	RedeemScript []byte
+
+	// Output is the target output which should be signed. The PkScript
+	// and Value fields within the output should be properly populated,
+	// otherwise an invalid signature may be generated.
+	Output *wire.TxOut
+
+	// HashType is the target sighash type that should be used when
+	// generating the final sighash, and signature.
+	HashType txscript.SigHashType
+
+	// SigHashes is the pre-computed sighash midstate to be used when
+	// generating the final sighash for signing.
+	SigHashes *txscript.TxSigHashes
+
+	// InputIndex is the target input within the transaction that should
+	// be signed.
+	InputIndex int
+}
+
+// Signer represents an abstract object capable of generating raw signatures
+// as well as full complete input scripts given a valid SignDescriptor and
+// transaction. This interface fully abstracts away signing paving the way for
+// Signer implementations such as hardware wallets, hardware tokens, HSM's, or
+// simply a regular wallet.
+type Signer interface {
+	// SignOutputRaw generates a signature for the passed transaction
+	// according to the data within the passed SignDescriptor. The
+	// returned signature has the sighash type of the descriptor appended.
+	SignOutputRaw(tx *wire.MsgTx, signDesc *SignDescriptor) ([]byte, error)
+
+	// ComputeInputScript generates a complete InputScript for the passed
+	// transaction with the signature as defined within the passed
+	// SignDescriptor. This method should be capable of generating the
+	// proper input script for both regular p2wkh outputs, as well as
+	// p2wkh outputs nested within a regular p2sh output. If the output
+	// isn't controlled by the Signer, then ErrNotMine should be returned.
+	ComputeInputScript(tx *wire.MsgTx, signDesc *SignDescriptor) (*InputScript, error)
+}
@@ -0,0 +1,453 @@
+package lnwallet
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/chaincfg"
+	"github.com/roasbeef/btcd/txscript"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+	"github.com/roasbeef/btcutil/hdkeychain"
+)
+
+// memUtxo is an output tracked by the MemWallet.
+type memUtxo struct {
+	*wire.TxOut
+
+	// confirmed is true if the output was credited to the wallet directly
+	// rather than created by a transaction broadcast through the wallet.
+	confirmed bool
+}
+
+// MemWallet is a purely in-memory implementation of the WalletController,
+// and Signer interfaces. The wallet has no chain backend: outputs are credited
+// via AddUtxo, and broadcast transactions are applied to the wallet's view of
+// the UTXO set rather than relayed. MemWallet serves as a reference
+// implementation of both interfaces, and allows the funding, and channel
+// workflows to be exercised without a full node.
+type MemWallet struct {
+	sync.RWMutex
+
+	rootKey *hdkeychain.ExtendedKey
+
+	// nextKeyIndex is the index of the next child key to be derived from
+	// the root key. Index 0 is reserved for the identity key.
+	nextKeyIndex uint32
+
+	// keys maps the hash160 of each public key derived by the wallet to
+	// its private key.
+	keys map[[20]byte]*btcec.PrivateKey
+
+	// utxoSet is the wallet's view of the UTXO set. It includes outputs
+	// foreign to the wallet which have been credited via AddUtxo, allowing
+	// GetUtxo to verify the counterparty's inputs.
+	utxoSet map[wire.OutPoint]*memUtxo
+
+	lockedOutpoints map[wire.OutPoint]struct{}
+
+	// publishedTxns is every transaction broadcast via the wallet, in
+	// order.
+	publishedTxns []*wire.MsgTx
+
+	netParams *chaincfg.Params
+}
+
+// A compile time check to ensure that MemWallet implements the
+// WalletController, and Signer interfaces.
+var _ WalletController = (*MemWallet)(nil)
+var _ Signer = (*MemWallet)(nil)
+
+// NewMemWallet creates a new MemWallet whose keys are derived from the passed
+// HD seed.
+func NewMemWallet(seed []byte, netParams *chaincfg.Params) (*MemWallet, error) {
+	rootKey, err := hdkeychain.NewMaster(seed, netParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MemWallet{
+		rootKey:         rootKey,
+		nextKeyIndex:    1,
+		keys:            make(map[[20]byte]*btcec.PrivateKey),
+		utxoSet:         make(map[wire.OutPoint]*memUtxo),
+		lockedOutpoints: make(map[wire.OutPoint]struct{}),
+		netParams:       netParams,
+	}, nil
+}
+
+// AddUtxo credits the passed output to the wallet's view of the UTXO set as a
+// confirmed output. If the output pays to one of the wallet's keys, then it
+// becomes available for coin selection.
+func (m *MemWallet) AddUtxo(op wire.OutPoint, output *wire.TxOut) {
+	m.Lock()
+	m.utxoSet[op] = &memUtxo{output, true}
+	m.Unlock()
+}
+
+// PublishedTransactions returns every transaction broadcast through the
+// wallet, in the order they were broadcast.
+func (m *MemWallet) PublishedTransactions() []*wire.MsgTx {
+	m.RLock()
+	defer m.RUnlock()
+
+	txns := make([]*wire.MsgTx, len(m.publishedTxns))
+	copy(txns, m.publishedTxns)
+	return txns
+}
+
+// deriveKey derives the private key at the passed hardened child index, and
+// records it within the wallet's key set.
+//
+// NOTE: The mutex MUST be held when calling this method.
+func (m *MemWallet) deriveKey(index uint32) (*btcec.PrivateKey, error) {
+	child, err := m.rootKey.Child(index + hdkeychain.HardenedKeyStart)
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := child.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkHash [20]byte
+	copy(pkHash[:], btcutil.Hash160(privKey.PubKey().SerializeCompressed()))
+	m.keys[pkHash] = privKey
+
+	return privKey, nil
+}
+
+// isMine returns true if the passed pkScript pays to one of the wallet's keys.
+//
+// NOTE: The mutex MUST be held when calling this method.
+func (m *MemWallet) isMine(pkScript []byte) bool {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, m.netParams)
+	if err != nil || len(addrs) != 1 {
+		return false
+	}
+
+	var pkHash [20]byte
+	copy(pkHash[:], addrs[0].ScriptAddress())
+	_, ok := m.keys[pkHash]
+	return ok
+}
+
+// Start is a no-op, as the MemWallet has no chain backend.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) Start() error {
+	return nil
+}
+
+// Stop is a no-op, as the MemWallet has no chain backend.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) Stop() error {
+	return nil
+}
+
+// WaitForShutdown returns immediately.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) WaitForShutdown() error {
+	return nil
+}
+
+// ConfirmedBalance returns the sum of all the wallet's unspent outputs. If
+// confs is greater than zero, then outputs created by transactions broadcast
+// through the wallet are excluded.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) ConfirmedBalance(confs int32) (btcutil.Amount, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var balance btcutil.Amount
+	for _, utxo := range m.utxoSet {
+		if !m.isMine(utxo.PkScript) {
+			continue
+		}
+		if confs > 0 && !utxo.confirmed {
+			continue
+		}
+
+		balance += btcutil.Amount(utxo.Value)
+	}
+
+	return balance, nil
+}
+
+// NewAddress returns the address of a freshly derived key. If witness is true,
+// then a p2wkh address is returned.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) NewAddress(witness bool) (btcutil.Address, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	privKey, err := m.deriveKey(m.nextKeyIndex)
+	if err != nil {
+		return nil, err
+	}
+	m.nextKeyIndex++
+
+	pkHash := btcutil.Hash160(privKey.PubKey().SerializeCompressed())
+	if witness {
+		return btcutil.NewAddressWitnessPubKeyHash(pkHash, m.netParams)
+	}
+	return btcutil.NewAddressPubKeyHash(pkHash, m.netParams)
+}
+
+// NewChangeAddress returns a fresh address. The MemWallet doesn't distinguish
+// between internal, and external keys.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) NewChangeAddress(witness bool) (btcutil.Address, error) {
+	return m.NewAddress(witness)
+}
+
+// GetPrivKey retrieves the private key controlling the passed address.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) GetPrivKey(a btcutil.Address) (*btcec.PrivateKey, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var pkHash [20]byte
+	copy(pkHash[:], a.ScriptAddress())
+	privKey, ok := m.keys[pkHash]
+	if !ok {
+		return nil, fmt.Errorf("address %v not in wallet", a)
+	}
+
+	return privKey, nil
+}
+
+// NewRawKey returns a freshly derived private key.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) NewRawKey() (*btcec.PrivateKey, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	privKey, err := m.deriveKey(m.nextKeyIndex)
+	if err != nil {
+		return nil, err
+	}
+	m.nextKeyIndex++
+
+	return privKey, nil
+}
+
+// FetchIdentityKey returns the key at the reserved identity index.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) FetchIdentityKey() (*btcec.PrivateKey, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.deriveKey(0)
+}
+
+// FundTransaction creates a new unsigned transaction paying to the passed
+// outputs, using the wallet's unlocked confirmed outputs as inputs.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) FundTransaction(outputs []*wire.TxOut,
+	changeAddr btcutil.Address, feePerByte btcutil.Amount) (*wire.MsgTx, error) {
+
+	m.RLock()
+	var utxos []*Utxo
+	for op, utxo := range m.utxoSet {
+		if !utxo.confirmed || !m.isMine(utxo.PkScript) {
+			continue
+		}
+		if _, ok := m.lockedOutpoints[op]; ok {
+			continue
+		}
+
+		utxos = append(utxos, &Utxo{
+			Value:    btcutil.Amount(utxo.Value),
+			OutPoint: op,
+		})
+	}
+	m.RUnlock()
+
+	return fundTransaction(utxos, outputs, changeAddr, feePerByte)
+}
+
+// SignTransaction signs all inputs within the passed transaction which spend
+// outputs controlled by the wallet.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) SignTransaction(tx *wire.MsgTx) error {
+	return signWalletInputs(m, tx, m.netParams)
+}
+
+// BroadcastTransaction applies the passed transaction to the wallet's view of
+// the UTXO set, and records it as published.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) BroadcastTransaction(tx *wire.MsgTx) error {
+	m.Lock()
+	defer m.Unlock()
+
+	// Ensure the transaction doesn't spend any outputs which are unknown,
+	// or already spent before mutating any state.
+	for _, txIn := range tx.TxIn {
+		if _, ok := m.utxoSet[txIn.PreviousOutPoint]; !ok {
+			return fmt.Errorf("output %v is unknown or already "+
+				"spent", txIn.PreviousOutPoint)
+		}
+	}
+
+	for _, txIn := range tx.TxIn {
+		delete(m.utxoSet, txIn.PreviousOutPoint)
+		delete(m.lockedOutpoints, txIn.PreviousOutPoint)
+	}
+
+	txid := tx.TxSha()
+	for i, txOut := range tx.TxOut {
+		op := wire.OutPoint{Hash: txid, Index: uint32(i)}
+		m.utxoSet[op] = &memUtxo{txOut, false}
+	}
+
+	m.publishedTxns = append(m.publishedTxns, tx)
+
+	return nil
+}
+
+// SendMany funds, signs, and broadcasts a transaction paying out to the
+// specified outputs at the passed fee rate.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) SendMany(outputs []*wire.TxOut,
+	feePerByte btcutil.Amount) (*wire.ShaHash, error) {
+
+	changeAddr, err := m.NewChangeAddress(true)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.FundTransaction(outputs, changeAddr, feePerByte)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.SignTransaction(tx); err != nil {
+		return nil, err
+	}
+	if err := m.BroadcastTransaction(tx); err != nil {
+		return nil, err
+	}
+
+	txid := tx.TxSha()
+	return &txid, nil
+}
+
+// ListUnspentWitness returns all unlocked p2wkh outputs controlled by the
+// wallet. Unless confirms is less than one, outputs created by transactions
+// broadcast through the wallet are excluded.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) ListUnspentWitness(confirms int32) ([]*Utxo, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var utxos []*Utxo
+	for op, utxo := range m.utxoSet {
+		if !txscript.IsPayToWitnessPubKeyHash(utxo.PkScript) ||
+			!m.isMine(utxo.PkScript) {
+			continue
+		}
+		if confirms > 0 && !utxo.confirmed {
+			continue
+		}
+		if _, ok := m.lockedOutpoints[op]; ok {
+			continue
+		}
+
+		utxos = append(utxos, &Utxo{
+			Value:    btcutil.Amount(utxo.Value),
+			OutPoint: op,
+		})
+	}
+
+	return utxos, nil
+}
+
+// FetchInputInfo returns the output referenced by the passed outpoint if it's
+// controlled by the wallet.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) FetchInputInfo(prevOut *wire.OutPoint) (*wire.TxOut, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	utxo, ok := m.utxoSet[*prevOut]
+	if !ok || !m.isMine(utxo.PkScript) {
+		return nil, ErrNotMine
+	}
+
+	return utxo.TxOut, nil
+}
+
+// GetUtxo returns the output referenced by the passed outpoint if it's
+// present within the wallet's view of the UTXO set.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) GetUtxo(prevOut *wire.OutPoint) (*wire.TxOut, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	utxo, ok := m.utxoSet[*prevOut]
+	if !ok {
+		return nil, fmt.Errorf("output %v not found", prevOut)
+	}
+
+	return utxo.TxOut, nil
+}
+
+// LockOutpoint marks an outpoint as locked, excluding it from coin selection.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) LockOutpoint(o wire.OutPoint) {
+	m.Lock()
+	m.lockedOutpoints[o] = struct{}{}
+	m.Unlock()
+}
+
+// UnlockOutpoint unlocks a previously locked outpoint.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) UnlockOutpoint(o wire.OutPoint) {
+	m.Lock()
+	delete(m.lockedOutpoints, o)
+	m.Unlock()
+}
+
+// ImportScript is a no-op, as the MemWallet only tracks outputs explicitly
+// credited to it.
+//
+// This is a part of the WalletController interface.
+func (m *MemWallet) ImportScript(b []byte) error {
+	return nil
+}
+
+// SignOutputRaw generates a signature for the passed transaction according to
+// the data within the passed SignDescriptor.
+//
+// This is a part of the Signer interface.
+func (m *MemWallet) SignOutputRaw(tx *wire.MsgTx, signDesc *SignDescriptor) ([]byte, error) {
+	return signOutputRaw(m, tx, signDesc, m.netParams)
+}
+
+// ComputeInputScript generates a complete InputScript for the passed
+// transaction spending an output controlled by the wallet.
+//
+// This is a part of the Signer interface.
+func (m *MemWallet) ComputeInputScript(tx *wire.MsgTx,
+	signDesc *SignDescriptor) (*InputScript, error) {
+
+	return signWalletInput(m, tx, signDesc, m.netParams)
+}
@@ -0,0 +1,238 @@
+package lnwallet
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/chainntfs"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/chaincfg"
+	"github.com/roasbeef/btcd/txscript"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+)
+
+// mockNotifier is a ChainNotifier which considers every transaction confirmed
+// as soon as a notification is registered for it.
+type mockNotifier struct {
+}
+
+func (m *mockNotifier) RegisterConfirmationsNtfn(txid *wire.ShaHash,
+	numConfs uint32) (*chainntnfs.ConfirmationEvent, error) {
+
+	confirmed := make(chan int32, 1)
+	confirmed <- 1
+	return &chainntnfs.ConfirmationEvent{
+		Confirmed:    confirmed,
+		NegativeConf: make(chan int32),
+	}, nil
+}
+func (m *mockNotifier) RegisterSpendNtfn(outpoint *wire.OutPoint) (*chainntnfs.SpendEvent, error) {
+	return &chainntnfs.SpendEvent{make(chan *chainntnfs.SpendDetail)}, nil
+}
+func (m *mockNotifier) RegisterBlockEpochNtfn(targetHeight int32) (*chainntnfs.BlockEpochEvent, error) {
+	return nil, nil
+}
+func (m *mockNotifier) Start() error {
+	return nil
+}
+func (m *mockNotifier) Stop() error {
+	return nil
+}
+
+// createMemTestWallet creates a LightningWallet backed by a MemWallet which
+// has been credited with numOutputs p2wkh outputs of btcPerOutput each.
+func createMemTestWallet(netParams *chaincfg.Params, numOutputs,
+	btcPerOutput int) (string, *LightningWallet, *MemWallet, error) {
+
+	tempTestDir, err := ioutil.TempDir("", "memwallet")
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	cdb, err := channeldb.Open(tempTestDir, netParams)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	cdb.RegisterCryptoSystem(&MockEncryptorDecryptor{})
+
+	memWallet, err := NewMemWallet(testHdSeed[:], netParams)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	for i := 0; i < numOutputs; i++ {
+		addr, err := memWallet.NewAddress(true)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		op := wire.OutPoint{Hash: wire.ShaHash{0x01}, Index: uint32(i)}
+		memWallet.AddUtxo(op, wire.NewTxOut(int64(btcPerOutput*1e8), pkScript))
+	}
+
+	wallet, err := NewLightningWallet(cdb, &mockNotifier{}, memWallet,
+		memWallet, netParams)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if err := wallet.Startup(); err != nil {
+		return "", nil, nil, err
+	}
+
+	return tempTestDir, wallet, memWallet, nil
+}
+
+// newMemBobNode creates a bobNode whose single 7BTC funding output has been
+// credited to the passed MemWallet's view of the UTXO set.
+func newMemBobNode(w *MemWallet, netParams *chaincfg.Params,
+	amt btcutil.Amount) (*bobNode, error) {
+
+	privKey, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), bobsPrivKey)
+	pkHash := btcutil.Hash160(pubKey.SerializeCompressed())
+	bobAddr, err := btcutil.NewAddressWitnessPubKeyHash(pkHash, netParams)
+	if err != nil {
+		return nil, err
+	}
+	bobAddrScript, err := txscript.PayToAddrScript(bobAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	prevOut := wire.OutPoint{Hash: wire.ShaHash{0x02}}
+	w.AddUtxo(prevOut, wire.NewTxOut(7e8, bobAddrScript))
+
+	var revocation [32]byte
+	copy(revocation[:], bobsPrivKey)
+	revocation[0] = 0xff
+
+	var id [wire.HashSize]byte
+	id[0] = 0xff
+
+	return &bobNode{
+		id:               id,
+		privKey:          privKey,
+		channelKey:       pubKey,
+		deliveryAddress:  bobAddr,
+		revocation:       revocation,
+		fundingAmt:       amt,
+		delay:            5,
+		availableOutputs: []*wire.TxIn{wire.NewTxIn(&prevOut, nil, nil)},
+		changeOutputs:    []*wire.TxOut{wire.NewTxOut(2e8, bobAddrScript)},
+	}, nil
+}
+
+// TestMemWalletDualFunding executes a dual funded reservation workflow
+// against a LightningWallet backed by the in-memory wallet controller, and
+// signer. No full node is required.
+func TestMemWalletDualFunding(t *testing.T) {
+	netParams := &chaincfg.SimNetParams
+
+	testDir, lnwallet, memWallet, err := createMemTestWallet(netParams, 10, 4)
+	if err != nil {
+		t.Fatalf("unable to create test ln wallet: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+	defer lnwallet.Shutdown()
+
+	assertProperBalance(t, lnwallet, 1, 40)
+
+	fundingAmount := btcutil.Amount(5 * 1e8)
+	bobNode, err := newMemBobNode(memWallet, netParams, fundingAmount)
+	if err != nil {
+		t.Fatalf("unable to create bob node: %v", err)
+	}
+
+	chanReservation, err := lnwallet.InitChannelReservation(fundingAmount*2,
+		fundingAmount, bobNode.id, numReqConfs, 4, 0,
+		testFundingFeeRate)
+	if err != nil {
+		t.Fatalf("unable to initialize funding reservation: %v", err)
+	}
+	ourContribution := chanReservation.OurContribution()
+	if len(ourContribution.Inputs) != 2 {
+		t.Fatalf("outputs for funding tx not properly selected, have %v "+
+			"outputs should have 2", len(ourContribution.Inputs))
+	}
+
+	bobContribution := bobNode.Contribution(ourContribution.CommitKey)
+	if err := chanReservation.ProcessContribution(bobContribution); err != nil {
+		t.Fatalf("unable to add bob's funds to the funding tx: %v", err)
+	}
+	ourFundingSigs, ourCommitSig := chanReservation.OurSignatures()
+	if len(ourFundingSigs) != 2 {
+		t.Fatalf("only %v of our sigs present, should have 2",
+			len(ourFundingSigs))
+	}
+	if ourCommitSig == nil {
+		t.Fatalf("commitment sig not found")
+	}
+
+	// Record the outputs spent by the funding transaction before they're
+	// removed from the wallet's UTXO set by the broadcast.
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	for _, txIn := range chanReservation.fundingTx.TxIn {
+		prevOut, err := memWallet.GetUtxo(&txIn.PreviousOutPoint)
+		if err != nil {
+			t.Fatalf("unable to find funding input: %v", err)
+		}
+		prevOuts[txIn.PreviousOutPoint] = prevOut
+	}
+
+	bobsSigs, err := bobNode.signFundingTx(chanReservation.fundingTx)
+	if err != nil {
+		t.Fatalf("unable to sign inputs for bob: %v", err)
+	}
+	commitSig, err := bobNode.signCommitTx(
+		chanReservation.partialState.OurCommitTx,
+		chanReservation.partialState.FundingRedeemScript,
+		10e8)
+	if err != nil {
+		t.Fatalf("bob is unable to sign alice's commit tx: %v", err)
+	}
+	if err := chanReservation.CompleteReservation(bobsSigs, commitSig); err != nil {
+		t.Fatalf("unable to complete funding tx: %v", err)
+	}
+
+	// The fully signed funding transaction should have been broadcast via
+	// the wallet controller.
+	fundingTx := chanReservation.FinalFundingTx()
+	published := memWallet.PublishedTransactions()
+	if len(published) != 1 || published[0].TxSha() != fundingTx.TxSha() {
+		t.Fatalf("funding transaction wasn't broadcast")
+	}
+
+	// Each of the funding transaction's inputs should be fully signed.
+	hashCache := txscript.NewTxSigHashes(fundingTx)
+	for i, txIn := range fundingTx.TxIn {
+		prevOut, ok := prevOuts[txIn.PreviousOutPoint]
+		if !ok {
+			t.Fatalf("funding tx spends unknown output %v",
+				txIn.PreviousOutPoint)
+		}
+
+		vm, err := txscript.NewEngine(prevOut.PkScript, fundingTx, i,
+			txscript.StandardVerifyFlags, nil, hashCache, prevOut.Value)
+		if err != nil {
+			t.Fatalf("unable to create engine: %v", err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Fatalf("funding input %v is invalid: %v", i, err)
+		}
+	}
+
+	select {
+	case lnc := <-chanReservation.DispatchChan():
+		if lnc == nil {
+			t.Fatalf("channel failed to open")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatalf("channel never opened")
+	}
+}
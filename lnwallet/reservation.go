@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/roasbeef/btcd/btcec"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcutil"
@@ -48,6 +49,10 @@ type ChannelContribution struct {
 	// CsvDelay The delay (in blocks) to be used for the pay-to-self output
 	// in this party's version of the commitment transaction.
 	CsvDelay uint32
+
+	// Constraints are the flow-control limits this party imposes on the
+	// HTLCs the counterparty may add to the channel.
+	Constraints channeldb.ChannelConstraints
 }
 
 // InputScripts represents any script inputs required to redeem a previous
@@ -157,8 +162,8 @@ func newChannelReservation(capacity, fundingAmt btcutil.Amount, minFeeRate btcut
 		},
 		partialState: &channeldb.OpenChannel{
 			Capacity:     capacity,
-			OurBalance:   ourBalance,
-			TheirBalance: theirBalance,
+			OurBalance:   lnwire.NewMSatFromSatoshis(ourBalance),
+			TheirBalance: lnwire.NewMSatFromSatoshis(theirBalance),
 			MinFeePerKb:  minFeeRate,
 			Db:           wallet.channelDB,
 		},
@@ -180,6 +185,18 @@ func (r *ChannelReservation) OurContribution() *ChannelContribution {
 	return r.ourContribution
 }
 
+// SetOurConstraints sets the flow-control limits the wallet will impose on the
+// HTLCs added by the counterparty once the channel is open. The constraints
+// are carried within our contribution, and MUST be set before the
+// contribution is sent to the counterparty.
+func (r *ChannelReservation) SetOurConstraints(c channeldb.ChannelConstraints) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.ourContribution.Constraints = c
+	r.partialState.LocalConstraints = c
+}
+
 // ProcesContribution verifies the counterparty's contribution to the pending
 // payment channel. As a result of this incoming message, lnwallet is able to
 // build the funding transaction, and both commitment transactions. Once this
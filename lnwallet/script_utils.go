@@ -10,6 +10,7 @@ import (
 
 	"github.com/btcsuite/fastsha256"
 	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/chaincfg"
 	"github.com/roasbeef/btcd/txscript"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcutil"
@@ -23,9 +24,9 @@ var (
 	OP_CHECKSEQUENCEVERIFY  byte = txscript.OP_NOP3
 )
 
-// witnessScriptHash generates a pay-to-witness-script-hash public key script
+// WitnessScriptHash generates a pay-to-witness-script-hash public key script
 // paying to a version 0 witness program paying to the passed redeem script.
-func witnessScriptHash(redeemScript []byte) ([]byte, error) {
+func WitnessScriptHash(redeemScript []byte) ([]byte, error) {
 	bldr := txscript.NewScriptBuilder()
 
 	bldr.AddOp(txscript.OP_0)
@@ -34,9 +35,9 @@ func witnessScriptHash(redeemScript []byte) ([]byte, error) {
 	return bldr.Script()
 }
 
-// genMultiSigScript generates the non-p2sh'd multisig script for 2 of 2
+// GenMultiSigScript generates the non-p2sh'd multisig script for 2 of 2
 // pubkeys.
-func genMultiSigScript(aPub, bPub []byte) ([]byte, error) {
+func GenMultiSigScript(aPub, bPub []byte) ([]byte, error) {
 	if len(aPub) != 33 || len(bPub) != 33 {
 		return nil, fmt.Errorf("Pubkey size error. Compressed pubkeys only")
 	}
@@ -68,14 +69,14 @@ func genFundingPkScript(aPub, bPub []byte, amt int64) ([]byte, *wire.TxOut, erro
 	}
 
 	// First, create the 2-of-2 multi-sig script itself.
-	redeemScript, err := genMultiSigScript(aPub, bPub)
+	redeemScript, err := GenMultiSigScript(aPub, bPub)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// With the 2-of-2 script in had, generate a p2wsh script which pays
 	// to the funding script.
-	pkScript, err := witnessScriptHash(redeemScript)
+	pkScript, err := WitnessScriptHash(redeemScript)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -516,6 +517,246 @@ func receiverHtlcSpendTimeout(commitScript []byte, outputAmt btcutil.Amount,
 	return witnessStack, nil
 }
 
+// addMultiHashLock adds to the passed script builder a clause which verifies
+// that the pre-images of at least numRequired of the passed payment hashes
+// are present on the stack. The witness must provide a pre-image slot for
+// every hash, with the slot of the first hash on top of the stack. Unknown
+// pre-images are left empty. As with regular HTLCs, only pre-images which
+// are exactly 32 bytes are counted, so no redemption asymmetries arise in
+// the multi-hop scenario.
+//
+//	0
+//	(for each payment hash)
+//	    OP_SWAP
+//	    OP_SIZE 32 OP_EQUAL
+//	    OP_SWAP
+//	    OP_SHA256 <payment hash> OP_EQUAL
+//	    OP_BOOLAND OP_ADD
+//	<num required> OP_GREATERTHANOREQUAL OP_VERIFY
+func addMultiHashLock(builder *txscript.ScriptBuilder, numRequired uint8,
+	paymentHashes [][32]byte) {
+
+	// A counter of the valid pre-images presented is kept on top of the
+	// stack, beneath which lies the pre-image of the next hash.
+	builder.AddInt64(0)
+	for _, paymentHash := range paymentHashes {
+		builder.AddOp(txscript.OP_SWAP)
+		builder.AddOp(txscript.OP_SIZE)
+		builder.AddInt64(32)
+		builder.AddOp(txscript.OP_EQUAL)
+		builder.AddOp(txscript.OP_SWAP)
+		builder.AddOp(txscript.OP_SHA256)
+		builder.AddData(paymentHash[:])
+		builder.AddOp(txscript.OP_EQUAL)
+		builder.AddOp(txscript.OP_BOOLAND)
+		builder.AddOp(txscript.OP_ADD)
+	}
+
+	builder.AddInt64(int64(numRequired))
+	builder.AddOp(txscript.OP_GREATERTHANOREQUAL)
+	builder.AddOp(txscript.OP_VERIFY)
+}
+
+// multiHashWitness returns the witness stack items presenting the passed
+// pre-images to a multi-hash clause, one for each of the HTLC's payment
+// hashes in order. The items are reversed such that the pre-image of the
+// first payment hash ends up on top of the stack.
+func multiHashWitness(paymentPreimages [][]byte) [][]byte {
+	items := make([][]byte, len(paymentPreimages))
+	for i, preimage := range paymentPreimages {
+		items[len(paymentPreimages)-1-i] = preimage
+	}
+
+	return items
+}
+
+// senderMultiHashHTLCScript constructs the public key script for an outgoing
+// N-of-M multi-hash HTLC output for the sender's version of the commitment
+// transaction. The HTLC may only be redeemed by the receiver once the
+// pre-images of numRequired of the payment hashes are presented:
+//
+// Possible Input Scripts:
+//
+//	SENDR: <sig> 0
+//	RECVR: <sig> <preimage M-1> ... <preimage 0> 0 1
+//	REVOK: <sig> <preimage> 1 1
+//
+//	OP_IF
+//	    //Receiver
+//	    OP_IF
+//	        //Revoke
+//	        OP_SHA256 <revocation hash> OP_EQUALVERIFY
+//	    OP_ELSE
+//	        //Receive
+//	        <multi-hash lock>
+//	    OP_ENDIF
+//	    <recv key> OP_CHECKSIG
+//	OP_ELSE
+//	    //Sender
+//	    <absolute blockheight> OP_CHECKLOCKTIMEVERIFY
+//	    <relative blockheight> OP_CHECKSEQUENCEVERIFY
+//	    OP_2DROP
+//	    <sendr key> OP_CHECKSIG
+//	OP_ENDIF
+func senderMultiHashHTLCScript(absoluteTimeout, relativeTimeout uint32,
+	senderKey, receiverKey *btcec.PublicKey, revokeHash []byte,
+	numRequired uint8, paymentHashes [][32]byte) ([]byte, error) {
+
+	builder := txscript.NewScriptBuilder()
+
+	// The receiver places a 1 as the first item in the witness stack,
+	// followed by a 1 if they're claiming the output of a revoked
+	// commitment transaction, or a 0 if they're redeeming the HTLC.
+	builder.AddOp(txscript.OP_IF)
+	builder.AddOp(txscript.OP_IF)
+	builder.AddOp(txscript.OP_SHA256)
+	builder.AddData(revokeHash)
+	builder.AddOp(txscript.OP_EQUALVERIFY)
+	builder.AddOp(txscript.OP_ELSE)
+	addMultiHashLock(builder, numRequired, paymentHashes)
+	builder.AddOp(txscript.OP_ENDIF)
+
+	// In either case, we require a valid signature by the receiver.
+	builder.AddData(receiverKey.SerializeCompressed())
+	builder.AddOp(txscript.OP_CHECKSIG)
+
+	// Otherwise, the sender sweeps the funds back after both the absolute
+	// HTLC timeout, and the relative timeout have passed, exactly as with
+	// a regular HTLC.
+	builder.AddOp(txscript.OP_ELSE)
+	builder.AddInt64(int64(absoluteTimeout))
+	builder.AddOp(txscript.OP_CHECKLOCKTIMEVERIFY)
+	builder.AddInt64(int64(relativeTimeout))
+	builder.AddOp(OP_CHECKSEQUENCEVERIFY)
+	builder.AddOp(txscript.OP_2DROP)
+	builder.AddData(senderKey.SerializeCompressed())
+	builder.AddOp(txscript.OP_CHECKSIG)
+
+	builder.AddOp(txscript.OP_ENDIF)
+
+	return builder.Script()
+}
+
+// senderMultiHashHtlcSpendRedeem constructs a valid witness allowing the
+// receiver of a multi-hash HTLC to redeem the pending output in the scenario
+// that the sender broadcasts their version of the commitment transaction.
+// The passed pre-images correspond to the HTLC's payment hashes in order,
+// with unknown pre-images left empty. The output is only spendable if enough
+// of them are valid. The revocation and timeout clauses are spent with the
+// witnesses of a regular HTLC.
+func senderMultiHashHtlcSpendRedeem(commitScript []byte,
+	outputAmt btcutil.Amount, reciverKey *btcec.PrivateKey,
+	sweepTx *wire.MsgTx, paymentPreimages [][]byte) (wire.TxWitness, error) {
+
+	hashCache := txscript.NewTxSigHashes(sweepTx)
+	sweepSig, err := txscript.RawTxInWitnessSignature(
+		sweepTx, hashCache, 0, int64(outputAmt), commitScript,
+		txscript.SigHashAll, reciverKey)
+	if err != nil {
+		return nil, err
+	}
+
+	witnessStack := wire.TxWitness([][]byte{sweepSig})
+	witnessStack = append(witnessStack, multiHashWitness(paymentPreimages)...)
+	witnessStack = append(witnessStack, []byte{0}, []byte{1}, commitScript)
+
+	return witnessStack, nil
+}
+
+// receiverMultiHashHTLCScript constructs the public key script for an
+// incoming N-of-M multi-hash HTLC output for the receiver's version of the
+// commitment transaction:
+//
+// Possible Input Scripts:
+//
+//	RECVR: <sig> <preimage M-1> ... <preimage 0> 1
+//	REVOK: <sig> <preimage> 1 0
+//	SENDR: <sig> 0 0
+//
+//	OP_IF
+//	    //Receiver
+//	    <multi-hash lock>
+//	    <relative blockheight> OP_CHECKSEQUENCEVERIFY OP_DROP
+//	    <receiver key> OP_CHECKSIG
+//	OP_ELSE
+//	    //Sender
+//	    OP_IF
+//	        //Revocation
+//	        OP_SHA256 <revoke hash> OP_EQUALVERIFY
+//	    OP_ELSE
+//	        //Refund
+//	        <absolute blockheight> OP_CHECKLOCKTIMEVERIFY OP_DROP
+//	    OP_ENDIF
+//	    <sender key> OP_CHECKSIG
+//	OP_ENDIF
+func receiverMultiHashHTLCScript(absoluteTimeout, relativeTimeout uint32,
+	senderKey, receiverKey *btcec.PublicKey, revokeHash []byte,
+	numRequired uint8, paymentHashes [][32]byte) ([]byte, error) {
+
+	builder := txscript.NewScriptBuilder()
+
+	// The receiver redeems the HTLC after a relative timeout by placing a
+	// 1 as the first item of the witness stack, above the pre-images.
+	builder.AddOp(txscript.OP_IF)
+	addMultiHashLock(builder, numRequired, paymentHashes)
+	builder.AddInt64(int64(relativeTimeout))
+	builder.AddOp(OP_CHECKSEQUENCEVERIFY)
+	builder.AddOp(txscript.OP_DROP)
+	builder.AddData(receiverKey.SerializeCompressed())
+	builder.AddOp(txscript.OP_CHECKSIG)
+
+	// Otherwise, the sender either claims the output of a revoked
+	// commitment transaction, or sweeps the HTLC after its absolute
+	// timeout, exactly as with a regular HTLC.
+	builder.AddOp(txscript.OP_ELSE)
+	builder.AddOp(txscript.OP_IF)
+	builder.AddOp(txscript.OP_SHA256)
+	builder.AddData(revokeHash)
+	builder.AddOp(txscript.OP_EQUALVERIFY)
+	builder.AddOp(txscript.OP_ELSE)
+	builder.AddInt64(int64(absoluteTimeout))
+	builder.AddOp(txscript.OP_CHECKLOCKTIMEVERIFY)
+	builder.AddOp(txscript.OP_DROP)
+	builder.AddOp(txscript.OP_ENDIF)
+	builder.AddData(senderKey.SerializeCompressed())
+	builder.AddOp(txscript.OP_CHECKSIG)
+
+	builder.AddOp(txscript.OP_ENDIF)
+
+	return builder.Script()
+}
+
+// receiverMultiHashHtlcSpendRedeem constructs a valid witness allowing the
+// receiver of a multi-hash HTLC to redeem the conditional payment in the
+// event that their commitment transaction is broadcast. As with
+// senderMultiHashHtlcSpendRedeem, the passed pre-images correspond to the
+// HTLC's payment hashes in order, and a relative time delay is required
+// before the output can be spent.
+func receiverMultiHashHtlcSpendRedeem(commitScript []byte,
+	outputAmt btcutil.Amount, reciverKey *btcec.PrivateKey,
+	sweepTx *wire.MsgTx, paymentPreimages [][]byte,
+	relativeTimeout uint32) (wire.TxWitness, error) {
+
+	// The sequence number, and version of the sweeping transaction are set
+	// in order to satisfy OP_CHECKSEQUENCEVERIFY.
+	sweepTx.TxIn[0].Sequence = lockTimeToSequence(false, relativeTimeout)
+	sweepTx.Version = 2
+
+	hashCache := txscript.NewTxSigHashes(sweepTx)
+	sweepSig, err := txscript.RawTxInWitnessSignature(
+		sweepTx, hashCache, 0, int64(outputAmt), commitScript,
+		txscript.SigHashAll, reciverKey)
+	if err != nil {
+		return nil, err
+	}
+
+	witnessStack := wire.TxWitness([][]byte{sweepSig})
+	witnessStack = append(witnessStack, multiHashWitness(paymentPreimages)...)
+	witnessStack = append(witnessStack, []byte{1}, commitScript)
+
+	return witnessStack, nil
+}
+
 // lockTimeToSequence converts the passed relative locktime to a sequence
 // number in accordance to BIP-68.
 // See: https://github.com/bitcoin/bips/blob/master/bip-0068.mediawiki
@@ -761,3 +1002,143 @@ func deriveElkremRoot(localMultiSigKey *btcec.PrivateKey,
 
 	return elkremRoot
 }
+
+// signOutputRaw generates a signature for the input described by the passed
+// SignDescriptor using the private key held by the passed WalletController
+// for signDesc.PubKey. The wallet locates the key via the public key's hash.
+func signOutputRaw(wc WalletController, tx *wire.MsgTx,
+	signDesc *SignDescriptor, netParams *chaincfg.Params) ([]byte, error) {
+
+	pubKeyHash := btcutil.Hash160(signDesc.PubKey.SerializeCompressed())
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, netParams)
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := wc.GetPrivKey(addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get private key: %v", err)
+	}
+
+	return txscript.RawTxInWitnessSignature(tx, signDesc.SigHashes,
+		signDesc.InputIndex, signDesc.Output.Value,
+		signDesc.RedeemScript, signDesc.HashType, privKey)
+}
+
+// signWalletInput generates a valid InputScript for the input described by
+// the passed SignDescriptor. The output being spent MUST be controlled by the
+// passed WalletController. Regular p2pkh outputs, p2wkh outputs, and p2wkh
+// outputs nested within p2sh outputs are supported.
+func signWalletInput(wc WalletController, tx *wire.MsgTx,
+	signDesc *SignDescriptor, netParams *chaincfg.Params) (*InputScript, error) {
+
+	prevOut := signDesc.Output
+	hashCache := signDesc.SigHashes
+	idx := signDesc.InputIndex
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(prevOut.PkScript,
+		netParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, ErrNotMine
+	}
+
+	// The wallet is expected to be able to map the extracted address,
+	// including the p2sh address of a nested witness output, back to the
+	// private key controlling it.
+	privKey, err := wc.GetPrivKey(addrs[0])
+	if err != nil {
+		return nil, fmt.Errorf("cannot get private key: %v", err)
+	}
+
+	inputScript := &InputScript{}
+	switch {
+	// If we're spending p2wkh output nested within a p2sh output, then
+	// we'll need to attach a sigScript in addition to witness data.
+	case txscript.IsPayToScriptHash(prevOut.PkScript):
+		pubKey := privKey.PubKey()
+		pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+
+		// Next, we'll generate a valid sigScript that'll allow us to
+		// spend the p2sh output. The sigScript will contain only a
+		// single push of the p2wkh witness program corresponding to
+		// the matching public key of this address.
+		p2wkhAddr, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash,
+			netParams)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create p2wkh addr: %v", err)
+		}
+		witnessProgram, err := txscript.PayToAddrScript(p2wkhAddr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create witness "+
+				"program: %v", err)
+		}
+		bldr := txscript.NewScriptBuilder()
+		bldr.AddData(witnessProgram)
+		sigScript, err := bldr.Script()
+		if err != nil {
+			return nil, fmt.Errorf("unable to create scriptsig: %v", err)
+		}
+		inputScript.ScriptSig = sigScript
+
+		inputScript.Witness, err = txscript.WitnessScript(tx, hashCache,
+			idx, prevOut.Value, witnessProgram, signDesc.HashType,
+			privKey, true)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create witnessscript: %v", err)
+		}
+
+	case txscript.IsPayToWitnessPubKeyHash(prevOut.PkScript):
+		inputScript.Witness, err = txscript.WitnessScript(tx, hashCache,
+			idx, prevOut.Value, prevOut.PkScript, signDesc.HashType,
+			privKey, true)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create witnessscript: %v", err)
+		}
+
+	// Otherwise, this is a regular p2pkh output, so a sigScript is all
+	// that's required.
+	default:
+		inputScript.ScriptSig, err = txscript.SignatureScript(tx, idx,
+			prevOut.PkScript, signDesc.HashType, privKey, true)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create sigscript: %v", err)
+		}
+	}
+
+	return inputScript, nil
+}
+
+// signWalletInputs signs each input within the passed transaction which
+// spends an output controlled by the passed WalletController. Inputs which
+// spend outputs foreign to the wallet are left untouched.
+func signWalletInputs(wc WalletController, tx *wire.MsgTx,
+	netParams *chaincfg.Params) error {
+
+	hashCache := txscript.NewTxSigHashes(tx)
+	for i, txIn := range tx.TxIn {
+		prevOut, err := wc.FetchInputInfo(&txIn.PreviousOutPoint)
+		if err == ErrNotMine {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		signDesc := &SignDescriptor{
+			Output:     prevOut,
+			HashType:   txscript.SigHashAll,
+			SigHashes:  hashCache,
+			InputIndex: i,
+		}
+		inputScript, err := signWalletInput(wc, tx, signDesc, netParams)
+		if err != nil {
+			return err
+		}
+
+		txIn.SignatureScript = inputScript.ScriptSig
+		txIn.Witness = inputScript.Witness
+	}
+
+	return nil
+}
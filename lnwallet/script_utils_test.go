@@ -51,7 +51,7 @@ func TestCommitmentSpendValidation(t *testing.T) {
 	// of 5 blocks before sweeping the output, while bob can spend
 	// immediately with either the revocation key, or his regular key.
 	commitmentTx, err := createCommitTx(fakeFundingTxIn, aliceKeyPub,
-		bobKeyPub, revokePubKey, csvTimeout, channelBalance, channelBalance)
+		bobKeyPub, revokePubKey, csvTimeout, channelBalance, channelBalance, 0)
 	if err != nil {
 		t.Fatalf("unable to create commitment transaction: %v", nil)
 	}
@@ -225,7 +225,7 @@ func TestHTLCSenderSpendValidation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unable to create htlc sender script: %v", err)
 	}
-	htlcWitnessScript, err := witnessScriptHash(htlcScript)
+	htlcWitnessScript, err := WitnessScriptHash(htlcScript)
 	if err != nil {
 		t.Fatalf("unable to create p2wsh htlc script: %v", err)
 	}
@@ -397,7 +397,7 @@ func TestHTLCReceiverSpendValidation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unable to create htlc sender script: %v", err)
 	}
-	htlcWitnessScript, err := witnessScriptHash(htlcScript)
+	htlcWitnessScript, err := WitnessScriptHash(htlcScript)
 	if err != nil {
 		t.Fatalf("unable to create p2wsh htlc script: %v", err)
 	}
@@ -523,3 +523,197 @@ func TestHTLCReceiverSpendValidation(t *testing.T) {
 		}
 	}
 }
+
+// TestMultiHashHTLCSpendValidation tests the redemption paths of the scripts
+// used for N-of-M "multi-sig" HTLC outputs, within both the sender's and the
+// receiver's commitment transactions. The revocation and timeout paths are
+// spent exactly as those of regular HTLCs, while redemption requires the
+// pre-images of N of the M payment hashes.
+func TestMultiHashHTLCSpendValidation(t *testing.T) {
+	fundingOut := &wire.OutPoint{
+		Hash:  testHdSeed,
+		Index: 50,
+	}
+
+	revokePreimage := testHdSeed[:]
+	revokeHash := fastsha256.Sum256(revokePreimage)
+
+	// The HTLC requires the pre-images of 2 of its 3 payment hashes.
+	paymentPreimages := [][32]byte{{1}, {2}, {3}}
+	paymentHashes := make([][32]byte, len(paymentPreimages))
+	for i, preimage := range paymentPreimages {
+		paymentHashes[i] = fastsha256.Sum256(preimage[:])
+	}
+	twoPreimages := [][]byte{paymentPreimages[0][:], nil,
+		paymentPreimages[2][:]}
+	onePreimage := [][]byte{nil, paymentPreimages[1][:], nil}
+
+	aliceKeyPriv, aliceKeyPub := btcec.PrivKeyFromBytes(btcec.S256(),
+		testWalletPrivKey)
+	bobKeyPriv, bobKeyPub := btcec.PrivKeyFromBytes(btcec.S256(),
+		bobsPrivKey)
+	paymentAmt := btcutil.Amount(1 * 10e8)
+	cltvTimeout := uint32(8)
+	csvTimeout := uint32(5)
+
+	senderScript, err := senderMultiHashHTLCScript(cltvTimeout,
+		csvTimeout, aliceKeyPub, bobKeyPub, revokeHash[:], 2,
+		paymentHashes)
+	if err != nil {
+		t.Fatalf("unable to create htlc sender script: %v", err)
+	}
+	receiverScript, err := receiverMultiHashHTLCScript(cltvTimeout,
+		csvTimeout, aliceKeyPub, bobKeyPub, revokeHash[:], 2,
+		paymentHashes)
+	if err != nil {
+		t.Fatalf("unable to create htlc receiver script: %v", err)
+	}
+
+	// Each test case sweeps the HTLC output of a fresh commitment
+	// transaction paying to the passed script.
+	testCases := []struct {
+		script  []byte
+		witness func(*wire.MsgTx) (wire.TxWitness, error)
+		valid   bool
+	}{
+		{
+			// sender script: revoke w/ sig
+			senderScript,
+			func(sweepTx *wire.MsgTx) (wire.TxWitness, error) {
+				return senderHtlcSpendRevoke(senderScript,
+					paymentAmt, bobKeyPriv, sweepTx,
+					revokePreimage)
+			},
+			true,
+		},
+		{
+			// sender script: redeem w/ 2 of 3 pre-images
+			senderScript,
+			func(sweepTx *wire.MsgTx) (wire.TxWitness, error) {
+				return senderMultiHashHtlcSpendRedeem(senderScript,
+					paymentAmt, bobKeyPriv, sweepTx,
+					twoPreimages)
+			},
+			true,
+		},
+		{
+			// sender script: redeem w/ only 1 of 3 pre-images
+			senderScript,
+			func(sweepTx *wire.MsgTx) (wire.TxWitness, error) {
+				return senderMultiHashHtlcSpendRedeem(senderScript,
+					paymentAmt, bobKeyPriv, sweepTx,
+					onePreimage)
+			},
+			false,
+		},
+		{
+			// sender script: valid lock-time+sequence, valid sig
+			senderScript,
+			func(sweepTx *wire.MsgTx) (wire.TxWitness, error) {
+				return senderHtlcSpendTimeout(senderScript,
+					paymentAmt, aliceKeyPriv, sweepTx,
+					cltvTimeout, csvTimeout)
+			},
+			true,
+		},
+		{
+			// receiver script: redeem w/ 2 of 3 pre-images
+			receiverScript,
+			func(sweepTx *wire.MsgTx) (wire.TxWitness, error) {
+				return receiverMultiHashHtlcSpendRedeem(
+					receiverScript, paymentAmt, bobKeyPriv,
+					sweepTx, twoPreimages, csvTimeout)
+			},
+			true,
+		},
+		{
+			// receiver script: redeem w/ only 1 of 3 pre-images
+			receiverScript,
+			func(sweepTx *wire.MsgTx) (wire.TxWitness, error) {
+				return receiverMultiHashHtlcSpendRedeem(
+					receiverScript, paymentAmt, bobKeyPriv,
+					sweepTx, onePreimage, csvTimeout)
+			},
+			false,
+		},
+		{
+			// receiver script: redeem w/ invalid sequence
+			receiverScript,
+			func(sweepTx *wire.MsgTx) (wire.TxWitness, error) {
+				return receiverMultiHashHtlcSpendRedeem(
+					receiverScript, paymentAmt, bobKeyPriv,
+					sweepTx, twoPreimages, csvTimeout-2)
+			},
+			false,
+		},
+		{
+			// receiver script: revoke w/ sig
+			receiverScript,
+			func(sweepTx *wire.MsgTx) (wire.TxWitness, error) {
+				return receiverHtlcSpendRevoke(receiverScript,
+					paymentAmt, aliceKeyPriv, sweepTx,
+					revokePreimage)
+			},
+			true,
+		},
+		{
+			// receiver script: refund w/ valid lock time
+			receiverScript,
+			func(sweepTx *wire.MsgTx) (wire.TxWitness, error) {
+				return receiverHtlcSpendTimeout(receiverScript,
+					paymentAmt, aliceKeyPriv, sweepTx,
+					cltvTimeout)
+			},
+			true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		htlcWitnessScript, err := WitnessScriptHash(testCase.script)
+		if err != nil {
+			t.Fatalf("unable to create p2wsh htlc script: %v", err)
+		}
+
+		commitTx := wire.NewMsgTx()
+		commitTx.AddTxIn(wire.NewTxIn(fundingOut, nil, nil))
+		commitTx.AddTxOut(&wire.TxOut{
+			Value:    int64(paymentAmt),
+			PkScript: htlcWitnessScript,
+		})
+
+		// The input of the sweeping transaction isn't final, otherwise
+		// OP_CHECKLOCKTIMEVERIFY would fail regardless of the locktime.
+		sweepTx := wire.NewMsgTx()
+		sweepTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{
+			Hash:  commitTx.TxSha(),
+			Index: 0,
+		}, nil, nil))
+		sweepTx.TxIn[0].Sequence = 0
+		sweepTx.AddTxOut(&wire.TxOut{
+			PkScript: []byte("doesn't matter"),
+			Value:    1 * 10e8,
+		})
+
+		witness, err := testCase.witness(sweepTx)
+		if err != nil {
+			t.Fatalf("unable to create witness test case: %v", err)
+		}
+		sweepTx.TxIn[0].Witness = witness
+
+		vm, err := txscript.NewEngine(htlcWitnessScript,
+			sweepTx, 0, txscript.StandardVerifyFlags, nil,
+			nil, int64(paymentAmt))
+		if err != nil {
+			t.Fatalf("unable to create engine: %v", err)
+		}
+
+		err = vm.Execute()
+		if err != nil && testCase.valid {
+			t.Fatalf("spend test case #%v failed, spend should be "+
+				"valid: %v", i, err)
+		} else if err == nil && !testCase.valid {
+			t.Fatalf("spend test case #%v succeeded, spend should "+
+				"be invalid", i)
+		}
+	}
+}
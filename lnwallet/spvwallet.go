@@ -0,0 +1,552 @@
+package lnwallet
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/btcsuite/fastsha256"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/uspv"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/chaincfg"
+	"github.com/roasbeef/btcd/txscript"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	spvKeyFileName    = "spvkey.hex"
+	spvHeaderFileName = "headers.bin"
+	spvDbFileName     = "utxo.db"
+)
+
+// SPVConfig houses the configuration required to create an SPVWallet.
+type SPVConfig struct {
+	// DataDir is the directory in which the wallet's key file, block
+	// headers, and utxo database are stored.
+	DataDir string
+
+	// RemoteNode is the host:port of the full node the wallet syncs
+	// headers, and blocks from.
+	RemoteNode string
+
+	// Birthday is the block height to begin syncing from when the wallet
+	// is created for the first time.
+	Birthday int32
+
+	// NetParams is the bitcoin network the wallet operates on.
+	NetParams *chaincfg.Params
+
+	// Broadcaster, if non-nil, is used to publish the wallet's
+	// transactions in place of relaying them to the remote node.
+	Broadcaster TxBroadcaster
+}
+
+// TxBroadcaster is an alternative means of publishing transactions to the
+// network, such as the JSON-RPC interface of a full node.
+type TxBroadcaster interface {
+	// BroadcastTransaction publishes the passed transaction to the
+	// network.
+	BroadcastTransaction(tx *wire.MsgTx) error
+}
+
+// SPVWallet is an implementation of the WalletController, and Signer
+// interfaces backed by uspv. The wallet maintains a connection to a single
+// full node from which it syncs block headers, and full blocks ("hard mode").
+type SPVWallet struct {
+	started int32 // To be used atomically.
+	stopped int32 // To be used atomically.
+
+	// birthday is the height syncing begins from when the utxo database
+	// is fresh.
+	birthday int32
+
+	// ts houses all the wallet's keys, addresses, and outputs.
+	ts *uspv.TxStore
+
+	// con is the connection to the remote node. It's used to sync the
+	// TxStore, and to broadcast transactions.
+	con *uspv.SPVCon
+
+	// broadcaster, if non-nil, is used to publish transactions rather
+	// than the SPV connection.
+	broadcaster TxBroadcaster
+
+	// lockedOutpoints is the set of outputs currently reserved for
+	// pending funding transactions. Locked outputs are excluded from coin
+	// selection.
+	lockedOutpoints map[wire.OutPoint]struct{}
+	lockMtx         sync.Mutex
+
+	netParams *chaincfg.Params
+}
+
+// A compile time check to ensure that SPVWallet implements the
+// WalletController, and Signer interfaces.
+var _ WalletController = (*SPVWallet)(nil)
+var _ Signer = (*SPVWallet)(nil)
+
+// NewSPVWallet opens (creating if needed) the key file, and utxo database
+// within the configured data directory, then connects to the remote node.
+// Syncing doesn't begin until Start is called.
+func NewSPVWallet(cfg *SPVConfig) (*SPVWallet, error) {
+	keyFile := filepath.Join(cfg.DataDir, spvKeyFileName)
+	rootPriv, err := uspv.ReadKeyFileToECPriv(keyFile, cfg.NetParams)
+	if err != nil {
+		return nil, err
+	}
+
+	// The TxStore must be set up before the SPVCon, as the SPVCon writes
+	// everything it learns about into the store.
+	store := uspv.NewTxStore(rootPriv, cfg.NetParams)
+
+	headerFile := filepath.Join(cfg.DataDir, spvHeaderFileName)
+	dbFile := filepath.Join(cfg.DataDir, spvDbFileName)
+	con, err := uspv.OpenSPV(cfg.RemoteNode, headerFile, dbFile, &store,
+		true, false, cfg.NetParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SPVWallet{
+		birthday:        cfg.Birthday,
+		ts:              &store,
+		con:             con,
+		broadcaster:     cfg.Broadcaster,
+		lockedOutpoints: make(map[wire.OutPoint]struct{}),
+		netParams:       cfg.NetParams,
+	}, nil
+}
+
+// SPVCon returns the wallet's connection to the remote node. The connection
+// can be used to receive full blocks as they're synced.
+func (s *SPVWallet) SPVCon() *uspv.SPVCon {
+	return s.con
+}
+
+// Start begins syncing the wallet's headers, and blocks from the remote node.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) Start() error {
+	if atomic.AddInt32(&s.started, 1) != 1 {
+		return nil
+	}
+
+	// If the database has never been synced, then we'll start syncing from
+	// the wallet's birthday rather than the genesis block.
+	tip, err := s.ts.GetDBSyncHeight()
+	if err != nil {
+		return err
+	}
+	if tip == 0 {
+		if err := s.ts.SetDBSyncHeight(s.birthday); err != nil {
+			return err
+		}
+	}
+
+	// Once we're connected, initiate the headers sync. Blocks will be
+	// requested once all headers have been synced.
+	return s.con.AskForHeaders()
+}
+
+// Stop signals the wallet for shutdown.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) Stop() error {
+	if atomic.AddInt32(&s.stopped, 1) != 1 {
+		return nil
+	}
+
+	// TODO(roasbeef): uspv has no notion of shutdown yet, so the
+	// connection to the remote node is simply abandoned.
+	return nil
+}
+
+// WaitForShutdown blocks until the wallet finishes shutting down.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) WaitForShutdown() error {
+	return nil
+}
+
+// numConfs returns the number of confirmations the passed output has given
+// the current sync height.
+func numConfs(u *uspv.Utxo, syncHeight int32) int32 {
+	if u.AtHeight == 0 {
+		return 0
+	}
+
+	return syncHeight - u.AtHeight + 1
+}
+
+// spendableUtxos returns all unlocked outputs controlled by the wallet with
+// at least minConfs confirmations. If witnessOnly is true, then only p2wkh
+// outputs are returned. A minConfs of -1 includes unconfirmed outputs.
+func (s *SPVWallet) spendableUtxos(minConfs int32,
+	witnessOnly bool) ([]*uspv.Utxo, error) {
+
+	utxos, err := s.ts.GetAllUtxos()
+	if err != nil {
+		return nil, err
+	}
+	syncHeight, err := s.ts.GetDBSyncHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	s.lockMtx.Lock()
+	defer s.lockMtx.Unlock()
+
+	spendable := make([]*uspv.Utxo, 0, len(utxos))
+	for _, u := range utxos {
+		if witnessOnly && !u.IsWit {
+			continue
+		}
+		if minConfs > 0 && numConfs(u, syncHeight) < minConfs {
+			continue
+		}
+		if _, ok := s.lockedOutpoints[u.Op]; ok {
+			continue
+		}
+
+		spendable = append(spendable, u)
+	}
+
+	return spendable, nil
+}
+
+// ConfirmedBalance returns the sum of all the wallet's unspent outputs that
+// have at least confs confirmations.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) ConfirmedBalance(confs int32) (btcutil.Amount, error) {
+	utxos, err := s.ts.GetAllUtxos()
+	if err != nil {
+		return 0, err
+	}
+	syncHeight, err := s.ts.GetDBSyncHeight()
+	if err != nil {
+		return 0, err
+	}
+
+	var balance btcutil.Amount
+	for _, u := range utxos {
+		if numConfs(u, syncHeight) >= confs {
+			balance += btcutil.Amount(u.Value)
+		}
+	}
+
+	return balance, nil
+}
+
+// NewAddress returns a fresh address controlled by the wallet. If witness is
+// true, then the p2wkh version of the address is returned.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) NewAddress(witness bool) (btcutil.Address, error) {
+	addr, err := s.ts.NewAdr()
+	if err != nil {
+		return nil, err
+	}
+
+	if !witness {
+		return addr, nil
+	}
+
+	return btcutil.NewAddressWitnessPubKeyHash(addr.ScriptAddress(),
+		s.netParams)
+}
+
+// NewChangeAddress returns a fresh change address. As uspv doesn't
+// distinguish between internal and external keys, this is identical to
+// NewAddress.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) NewChangeAddress(witness bool) (btcutil.Address, error) {
+	return s.NewAddress(witness)
+}
+
+// GetPrivKey retrieves the private key controlling the passed address.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) GetPrivKey(a btcutil.Address) (*btcec.PrivateKey, error) {
+	return s.ts.GetPrivKey(a)
+}
+
+// NewRawKey returns a fresh private key controlled by the wallet. In order
+// to ensure the key is never reused, a new address is reserved for it.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) NewRawKey() (*btcec.PrivateKey, error) {
+	addr, err := s.ts.NewAdr()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ts.GetPrivKey(addr)
+}
+
+// FetchIdentityKey returns the private key used as the node's identity within
+// the Lightning Network.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) FetchIdentityKey() (*btcec.PrivateKey, error) {
+	return s.ts.IdentityKey()
+}
+
+// FundTransaction creates a new unsigned transaction paying to the passed
+// outputs, using the wallet's unlocked confirmed outputs as inputs.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) FundTransaction(outputs []*wire.TxOut,
+	changeAddr btcutil.Address, feePerByte btcutil.Amount) (*wire.MsgTx, error) {
+
+	spendable, err := s.spendableUtxos(1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]*Utxo, len(spendable))
+	for i, u := range spendable {
+		utxos[i] = &Utxo{
+			Value:    btcutil.Amount(u.Value),
+			OutPoint: u.Op,
+		}
+	}
+
+	return fundTransaction(utxos, outputs, changeAddr, feePerByte)
+}
+
+// SignTransaction signs all inputs within the passed transaction which spend
+// outputs controlled by the wallet.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) SignTransaction(tx *wire.MsgTx) error {
+	return signWalletInputs(s, tx, s.netParams)
+}
+
+// BroadcastTransaction records the transaction within the wallet's database,
+// then announces it to the remote node. If the wallet was configured with a
+// TxBroadcaster, then the transaction is published via the broadcaster
+// instead.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) BroadcastTransaction(tx *wire.MsgTx) error {
+	if s.broadcaster == nil {
+		return s.con.NewOutgoingTx(tx)
+	}
+
+	txid := tx.TxSha()
+	if err := s.ts.AddTxid(&txid, 0); err != nil {
+		return err
+	}
+	if _, err := s.ts.Ingest(tx, 0); err != nil {
+		return err
+	}
+
+	return s.broadcaster.BroadcastTransaction(tx)
+}
+
+// SendMany funds, signs, and broadcasts a transaction paying out to the
+// specified outputs at the passed fee rate.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) SendMany(outputs []*wire.TxOut,
+	feePerByte btcutil.Amount) (*wire.ShaHash, error) {
+
+	changeAddr, err := s.NewChangeAddress(true)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.FundTransaction(outputs, changeAddr, feePerByte)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.SignTransaction(tx); err != nil {
+		return nil, err
+	}
+	if err := s.BroadcastTransaction(tx); err != nil {
+		return nil, err
+	}
+
+	txid := tx.TxSha()
+	return &txid, nil
+}
+
+// ListUnspentWitness returns all unlocked p2wkh outputs controlled by the
+// wallet with at least confirms confirmations.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) ListUnspentWitness(confirms int32) ([]*Utxo, error) {
+	spendable, err := s.spendableUtxos(confirms, true)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]*Utxo, len(spendable))
+	for i, u := range spendable {
+		utxos[i] = &Utxo{
+			Value:    btcutil.Amount(u.Value),
+			OutPoint: u.Op,
+		}
+	}
+
+	return utxos, nil
+}
+
+// FetchInputInfo returns the output referenced by the passed outpoint if it's
+// controlled by the wallet.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) FetchInputInfo(prevOut *wire.OutPoint) (*wire.TxOut, error) {
+	utxos, err := s.ts.GetAllUtxos()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range utxos {
+		if u.Op != *prevOut {
+			continue
+		}
+
+		// uspv only stores the key index of each output, so we'll need
+		// to re-derive the pkScript from the address at that index.
+		if int(u.KeyIdx) >= len(s.ts.Adrs) {
+			return nil, fmt.Errorf("unknown key index %v", u.KeyIdx)
+		}
+		var addr btcutil.Address = s.ts.Adrs[u.KeyIdx].PkhAdr
+		if u.IsWit {
+			addr, err = btcutil.NewAddressWitnessPubKeyHash(
+				addr.ScriptAddress(), s.netParams)
+			if err != nil {
+				return nil, err
+			}
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return wire.NewTxOut(u.Value, pkScript), nil
+	}
+
+	return nil, ErrNotMine
+}
+
+// GetUtxo returns the output referenced by the passed outpoint. As an SPV
+// wallet has no view of the UTXO set, only outputs controlled by the wallet
+// can be returned.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) GetUtxo(prevOut *wire.OutPoint) (*wire.TxOut, error) {
+	output, err := s.FetchInputInfo(prevOut)
+	if err == ErrNotMine {
+		return nil, ErrNoUtxoSet
+	}
+
+	return output, err
+}
+
+// LockOutpoint marks an outpoint as locked, excluding it from coin selection.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) LockOutpoint(o wire.OutPoint) {
+	s.lockMtx.Lock()
+	s.lockedOutpoints[o] = struct{}{}
+	s.lockMtx.Unlock()
+}
+
+// UnlockOutpoint unlocks a previously locked outpoint.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) UnlockOutpoint(o wire.OutPoint) {
+	s.lockMtx.Lock()
+	delete(s.lockedOutpoints, o)
+	s.lockMtx.Unlock()
+}
+
+// ImportScript is a no-op for the SPVWallet. Outputs paying to imported
+// scripts are tracked by the chain notifier rather than the wallet itself.
+//
+// This is a part of the WalletController interface.
+func (s *SPVWallet) ImportScript(b []byte) error {
+	return nil
+}
+
+// SignOutputRaw generates a signature for the passed transaction according to
+// the data within the passed SignDescriptor.
+//
+// This is a part of the Signer interface.
+func (s *SPVWallet) SignOutputRaw(tx *wire.MsgTx, signDesc *SignDescriptor) ([]byte, error) {
+	return signOutputRaw(s, tx, signDesc, s.netParams)
+}
+
+// ComputeInputScript generates a complete InputScript for the passed
+// transaction spending an output controlled by the wallet.
+//
+// This is a part of the Signer interface.
+func (s *SPVWallet) ComputeInputScript(tx *wire.MsgTx,
+	signDesc *SignDescriptor) (*InputScript, error) {
+
+	return signWalletInput(s, tx, signDesc, s.netParams)
+}
+
+// CryptoSystem returns an implementation of the channeldb.EncryptorDecryptor
+// interface keyed by a secret derived from the wallet's identity key.
+func (s *SPVWallet) CryptoSystem() (channeldb.EncryptorDecryptor, error) {
+	idKey, err := s.ts.IdentityKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var c secretboxCryptoSystem
+	c.key = fastsha256.Sum256(append(idKey.Serialize(),
+		[]byte("channeldb")...))
+
+	return &c, nil
+}
+
+// secretboxCryptoSystem implements the channeldb.EncryptorDecryptor
+// interface using nacl's secretbox.
+type secretboxCryptoSystem struct {
+	key [32]byte
+}
+
+// Encrypt encrypts the passed plaintext under a fresh random nonce. The nonce
+// is prepended to the returned ciphertext.
+func (c *secretboxCryptoSystem) Encrypt(p []byte) ([]byte, error) {
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+
+	return secretbox.Seal(nonce[:], p, &nonce, &c.key), nil
+}
+
+// Decrypt decrypts a ciphertext created by Encrypt.
+func (c *secretboxCryptoSystem) Decrypt(ct []byte) ([]byte, error) {
+	if len(ct) < 24+secretbox.Overhead {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], ct[:24])
+
+	p, ok := secretbox.Open(nil, ct[24:], &nonce, &c.key)
+	if !ok {
+		return nil, errors.New("unable to decrypt ciphertext")
+	}
+
+	return p, nil
+}
+
+// OverheadSize returns the number of bytes encryption adds to a plaintext.
+func (c *secretboxCryptoSystem) OverheadSize() uint32 {
+	return 24 + secretbox.Overhead
+}
@@ -0,0 +1,161 @@
+package lnwallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/uspv"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/chaincfg"
+	"github.com/roasbeef/btcd/txscript"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+	"github.com/roasbeef/btcutil/hdkeychain"
+)
+
+// createTestSPVWallet creates an SPVWallet backed by a fresh utxo database
+// within a temporary directory. The wallet isn't connected to a remote node,
+// so transactions must be ingested into the TxStore directly.
+func createTestSPVWallet(netParams *chaincfg.Params) (string, *SPVWallet, error) {
+	tempTestDir, err := ioutil.TempDir("", "spvwallet")
+	if err != nil {
+		return "", nil, err
+	}
+
+	rootKey, err := hdkeychain.NewMaster(testHdSeed[:], netParams)
+	if err != nil {
+		return "", nil, err
+	}
+
+	store := uspv.NewTxStore(rootKey, netParams)
+	if err := store.OpenDB(filepath.Join(tempTestDir, spvDbFileName)); err != nil {
+		return "", nil, err
+	}
+	if err := store.Refilter(); err != nil {
+		return "", nil, err
+	}
+
+	return tempTestDir, &SPVWallet{
+		ts:              &store,
+		lockedOutpoints: make(map[wire.OutPoint]struct{}),
+		netParams:       netParams,
+	}, nil
+}
+
+func TestSPVWalletFundAndSign(t *testing.T) {
+	netParams := &chaincfg.SegNet4Params
+
+	testDir, wallet, err := createTestSPVWallet(netParams)
+	if err != nil {
+		t.Fatalf("unable to create spv wallet: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+	defer wallet.ts.StateDB.Close()
+
+	// Credit the wallet with a single 5BTC witness output confirmed at
+	// height 100.
+	walletAddr, err := wallet.NewAddress(true)
+	if err != nil {
+		t.Fatalf("unable to generate address: %v", err)
+	}
+	walletScript, err := txscript.PayToAddrScript(walletAddr)
+	if err != nil {
+		t.Fatalf("unable to create pkscript: %v", err)
+	}
+	creditTx := wire.NewMsgTx()
+	creditTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: wire.ShaHash{0x01}}, nil, nil))
+	creditTx.AddTxOut(wire.NewTxOut(5e8, walletScript))
+	if _, err := wallet.ts.Ingest(creditTx, 100); err != nil {
+		t.Fatalf("unable to ingest credit tx: %v", err)
+	}
+	if err := wallet.ts.SetDBSyncHeight(105); err != nil {
+		t.Fatalf("unable to set sync height: %v", err)
+	}
+
+	balance, err := wallet.ConfirmedBalance(6)
+	if err != nil {
+		t.Fatalf("unable to query balance: %v", err)
+	}
+	if balance != 5e8 {
+		t.Fatalf("balance should be 5BTC, instead is %v", balance)
+	}
+	balance, err = wallet.ConfirmedBalance(7)
+	if err != nil {
+		t.Fatalf("unable to query balance: %v", err)
+	}
+	if balance != 0 {
+		t.Fatalf("output shouldn't have 7 confs, balance is %v", balance)
+	}
+
+	utxos, err := wallet.ListUnspentWitness(1)
+	if err != nil {
+		t.Fatalf("unable to list outputs: %v", err)
+	}
+	if len(utxos) != 1 || utxos[0].Value != 5e8 {
+		t.Fatalf("wallet should have a single 5BTC output, instead "+
+			"has %v", len(utxos))
+	}
+
+	// Locked outputs shouldn't be eligible for coin selection.
+	lockedOutpoint := utxos[0].OutPoint
+	wallet.LockOutpoint(lockedOutpoint)
+	utxos, err = wallet.ListUnspentWitness(1)
+	if err != nil {
+		t.Fatalf("unable to list outputs: %v", err)
+	}
+	if len(utxos) != 0 {
+		t.Fatalf("locked output returned as unspent")
+	}
+	wallet.UnlockOutpoint(lockedOutpoint)
+
+	// Fund a transaction paying 1BTC to bob, then sign it with the
+	// wallet's keys.
+	_, bobPub := btcec.PrivKeyFromBytes(btcec.S256(), bobsPrivKey)
+	bobAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(bobPub.SerializeCompressed()), netParams)
+	if err != nil {
+		t.Fatalf("unable to create bob's address: %v", err)
+	}
+	bobScript, err := txscript.PayToAddrScript(bobAddr)
+	if err != nil {
+		t.Fatalf("unable to create pkscript: %v", err)
+	}
+	changeAddr, err := wallet.NewChangeAddress(true)
+	if err != nil {
+		t.Fatalf("unable to generate change address: %v", err)
+	}
+
+	outputs := []*wire.TxOut{wire.NewTxOut(1e8, bobScript)}
+	tx, err := wallet.FundTransaction(outputs, changeAddr, 10)
+	if err != nil {
+		t.Fatalf("unable to fund transaction: %v", err)
+	}
+	if len(tx.TxIn) != 1 || len(tx.TxOut) != 2 {
+		t.Fatalf("funded transaction should have 1 input, and 2 "+
+			"outputs, instead has %v, and %v", len(tx.TxIn),
+			len(tx.TxOut))
+	}
+	if err := wallet.SignTransaction(tx); err != nil {
+		t.Fatalf("unable to sign transaction: %v", err)
+	}
+
+	// Finally, the signed input should be a valid spend of the wallet's
+	// output.
+	vm, err := txscript.NewEngine(walletScript, tx, 0,
+		txscript.StandardVerifyFlags, nil, nil, 5e8)
+	if err != nil {
+		t.Fatalf("unable to create engine: %v", err)
+	}
+	if err := vm.Execute(); err != nil {
+		t.Fatalf("wallet signature is invalid: %v", err)
+	}
+
+	// An output the wallet doesn't control can't be looked up, as an SPV
+	// wallet has no view of the utxo set.
+	foreignOutpoint := &wire.OutPoint{Hash: wire.ShaHash{0x02}}
+	if _, err := wallet.GetUtxo(foreignOutpoint); err != ErrNoUtxoSet {
+		t.Fatalf("expected ErrNoUtxoSet, instead got %v", err)
+	}
+}
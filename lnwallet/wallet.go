@@ -1,30 +1,22 @@
 package lnwallet
 
 import (
-	"encoding/hex"
 	"errors"
 	"fmt"
-	"math"
 	"sync"
 	"sync/atomic"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/lightningnetwork/lnd/chainntfs"
-	"github.com/lightningnetwork/lnd/chainntfs/btcdnotify"
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/elkrem"
-	"github.com/roasbeef/btcd/btcjson"
 
 	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/chaincfg"
 	"github.com/roasbeef/btcd/txscript"
 	"github.com/roasbeef/btcd/wire"
-	"github.com/roasbeef/btcrpcclient"
 	"github.com/roasbeef/btcutil"
-	"github.com/roasbeef/btcutil/coinset"
 	"github.com/roasbeef/btcutil/txsort"
-	"github.com/roasbeef/btcwallet/chain"
-	"github.com/roasbeef/btcwallet/waddrmgr"
-	btcwallet "github.com/roasbeef/btcwallet/wallet"
 )
 
 const (
@@ -68,13 +60,16 @@ type initFundingReserveMsg struct {
 	// the remote party contributes (if any).
 	capacity btcutil.Amount
 
-	// The minimum accepted satoshis/KB fee for the funding transaction. In
-	// order to ensure timely confirmation, it is recomened that this fee
-	// should be generous, paying some multiple of the accepted base fee
-	// rate of the network.
-	// TODO(roasbeef): integrate fee estimation project...
+	// The satoshis/KB fee rate paid by the initial commitment
+	// transactions. The fee is paid by the initiator of the channel.
 	minFeeRate btcutil.Amount
 
+	// The satoshis/byte fee rate paid by the funding transaction. In order
+	// to ensure timely confirmation, it is recomened that this fee should
+	// be generous, paying some multiple of the accepted base fee rate of
+	// the network.
+	fundingFeeRate btcutil.Amount
+
 	// The ID of the remote node we would like to open a channel with.
 	// TODO(roasbeef): switch to just reg pubkey?
 	nodeID [32]byte
@@ -210,12 +205,10 @@ type channelOpenMsg struct {
 // operations. Interaction has been designed independant of any peer-to-peer
 // communication protocol, allowing the wallet to be self-contained and embeddable
 // within future projects interacting with the Lightning Network.
-// NOTE: At the moment the wallet requires a btcd full node, as it's dependant
-// on btcd's websockets notifications as even triggers during the lifetime of
-// a channel. However, once the chainntnfs package is complete, the wallet
-// will be compatible with multiple RPC/notification services such as Electrum,
-// Bitcoin Core + ZeroMQ, etc. Eventually, the wallet won't require a full-node
-// at all, as SPV support is integrated inot btcwallet.
+// NOTE: The wallet itself is agnostic to the source of its funds, and the
+// backing chain. All non Lightning Network specific interaction is proxied to
+// a WalletController, such as the btcd backed BtcWallet, or the SPVWallet
+// backed by uspv.
 type LightningWallet struct {
 	// This mutex is to be held when generating external keys to be used
 	// as multi-sig, and commitment keys within the channel.
@@ -239,16 +232,13 @@ type LightningWallet struct {
 
 	// The core wallet, all non Lightning Network specific interaction is
 	// proxied to the internal wallet.
-	*btcwallet.Wallet
+	WalletController
 
-	// An active RPC connection to a full-node. In the case of a btcd node,
-	// websockets are used for notifications. If using Bitcoin Core,
-	// notifications are either generated via long-polling or the usage of
-	// ZeroMQ.
-	// TODO(roasbeef): make into interface need: getrawtransaction + gettxout
-	//  * getrawtransaction -> verify proof of channel links
-	//  * gettxout -> verify inputs to funding tx exist and are unspent
-	rpc *chain.RPCClient
+	// Signer is the wallet's current Signer implementation. This Signer is
+	// used to generate signature for all inputs to potential funding
+	// transactions, as well as for spends from the funding transaction to
+	// update the commitment state.
+	Signer Signer
 
 	// All messages to the wallet are to be sent accross this channel.
 	msgChan chan interface{}
@@ -265,7 +255,7 @@ type LightningWallet struct {
 	// TODO(roasbeef): zombie garbage collection routine to solve
 	// lost-object/starvation problem/attack.
 
-	cfg *Config
+	netParams *chaincfg.Params
 
 	started  int32
 	shutdown int32
@@ -276,123 +266,44 @@ type LightningWallet struct {
 	// TODO(roasbeef): handle wallet lock/unlock
 }
 
-// NewLightningWallet creates/opens and initializes a LightningWallet instance.
-// If the wallet has never been created (according to the passed dataDir), first-time
-// setup is executed.
-func NewLightningWallet(config *Config, cdb *channeldb.DB) (*LightningWallet, error) {
-	// Ensure the wallet exists or create it when the create flag is set.
-	netDir := networkDir(config.DataDir, config.NetParams)
-
-	var pubPass []byte
-	if config.PublicPass == nil {
-		pubPass = defaultPubPassphrase
-	} else {
-		pubPass = config.PublicPass
-	}
-
-	loader := btcwallet.NewLoader(config.NetParams, netDir)
-	walletExists, err := loader.WalletExists()
-	if err != nil {
-		return nil, err
-	}
-
-	var createID bool
-	var wallet *btcwallet.Wallet
-	if !walletExists {
-		// Wallet has never been created, perform initial set up.
-		wallet, err = loader.CreateNewWallet(pubPass, config.PrivatePass,
-			config.HdSeed)
-		if err != nil {
-			return nil, err
-		}
-
-		createID = true
-	} else {
-		// Wallet has been created and been initialized at this point, open it
-		// along with all the required DB namepsaces, and the DB itself.
-		wallet, err = loader.OpenExistingWallet(pubPass, false)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	if err := wallet.Manager.Unlock(config.PrivatePass); err != nil {
-		return nil, err
-	}
-
-	// If we just created the wallet, then reserve, and store a key for
-	// our ID within the Lightning Network.
-	if createID {
-		account := uint32(waddrmgr.DefaultAccountNum)
-		adrs, err := wallet.Manager.NextInternalAddresses(account, 1, waddrmgr.WitnessPubKey)
-		if err != nil {
-			return nil, err
-		}
-
-		idPubkeyHash := adrs[0].Address().ScriptAddress()
-		if err := cdb.PutIdKey(idPubkeyHash); err != nil {
-			return nil, err
-		}
-		log.Infof("stored identity key pubkey hash in channeldb")
-	}
-
-	// Create a special websockets rpc client for btcd which will be used
-	// by the wallet for notifications, calls, etc.
-	rpcc, err := chain.NewRPCClient(config.NetParams, config.RpcHost,
-		config.RpcUser, config.RpcPass, config.CACert, false, 20)
-	if err != nil {
-		return nil, err
-	}
-
-	// Using the same authentication info, create a config for a second
-	// rpcclient which will be used by the current default chain
-	// notifier implemenation.
-	rpcConfig := &btcrpcclient.ConnConfig{
-		Host:                 config.RpcHost,
-		Endpoint:             "ws",
-		User:                 config.RpcUser,
-		Pass:                 config.RpcPass,
-		Certificates:         config.CACert,
-		DisableTLS:           false,
-		DisableConnectOnNew:  true,
-		DisableAutoReconnect: false,
-	}
-	chainNotifier, err := btcdnotify.NewBtcdNotifier(rpcConfig)
-	if err != nil {
-		return nil, err
-	}
+// NewLightningWallet creates a new LightningWallet instance which proxies all
+// regular wallet operations to the passed WalletController, signs all inputs,
+// and commitment transactions with the passed Signer, and uses the passed
+// ChainNotifier in order to track the confirmation of funding transactions.
+func NewLightningWallet(cdb *channeldb.DB, notifier chainntnfs.ChainNotifier,
+	wallet WalletController, signer Signer,
+	netParams *chaincfg.Params) (*LightningWallet, error) {
 
 	// TODO(roasbeef): logging
 	return &LightningWallet{
-		ChainNotifier: chainNotifier,
-		rpc:           rpcc,
-		Wallet:        wallet,
-		channelDB:     cdb,
-		msgChan:       make(chan interface{}, msgBufferSize),
+		ChainNotifier:    notifier,
+		WalletController: wallet,
+		Signer:           signer,
+		channelDB:        cdb,
+		msgChan:          make(chan interface{}, msgBufferSize),
 		// TODO(roasbeef): make this atomic.Uint32 instead? Which is
 		// faster, locks or CAS? I'm guessing CAS because assembly:
 		//  * https://golang.org/src/sync/atomic/asm_amd64.s
 		nextFundingID: 0,
-		cfg:           config,
+		netParams:     netParams,
 		fundingLimbo:  make(map[uint64]*ChannelReservation),
 		quit:          make(chan struct{}),
 	}, nil
 }
 
-// Startup establishes a connection to the RPC source, and spins up all
-// goroutines required to handle incoming messages.
+// Startup starts the underlying WalletController, the chain notifier, and
+// spins up all goroutines required to handle incoming messages.
 func (l *LightningWallet) Startup() error {
 	// Already started?
 	if atomic.AddInt32(&l.started, 1) != 1 {
 		return nil
 	}
 
-	// Establish an RPC connection in additino to starting the goroutines
-	// in the underlying wallet.
-	if err := l.rpc.Start(); err != nil {
+	// Start the underlying wallet controller, this'll establish any
+	// required connections, and begin syncing to the current chain state.
+	if err := l.Start(); err != nil {
 		return err
 	}
-	l.Start()
 
 	// Start the notification server. This is used so channel managment
 	// goroutines can be notified when a funding transaction reaches a
@@ -403,10 +314,6 @@ func (l *LightningWallet) Startup() error {
 		return err
 	}
 
-	// Pass the rpc client into the wallet so it can sync up to the current
-	// main chain.
-	l.SynchronizeRPC(l.rpc)
-
 	l.wg.Add(1)
 	// TODO(roasbeef): multiple request handlers?
 	go l.requestHandler()
@@ -425,8 +332,6 @@ func (l *LightningWallet) Shutdown() error {
 	l.Stop()
 	l.WaitForShutdown()
 
-	l.rpc.Shutdown()
-
 	l.ChainNotifier.Stop()
 
 	close(l.quit)
@@ -483,21 +388,28 @@ out:
 // and final step verifies all signatures for the inputs of the funding
 // transaction, and that the signature we records for our version of the
 // commitment transaction is valid.
+//
+// The initial commitment transactions pay a fee at minFeeRate satoshis per
+// kilobyte, while the funding transaction, if we contribute any funds to it,
+// pays a fee at fundingFeeRate satoshis per byte.
 func (l *LightningWallet) InitChannelReservation(capacity,
 	ourFundAmt btcutil.Amount, theirID [32]byte, numConfs uint16,
-	csvDelay uint32) (*ChannelReservation, error) {
+	csvDelay uint32, minFeeRate,
+	fundingFeeRate btcutil.Amount) (*ChannelReservation, error) {
 
 	errChan := make(chan error, 1)
 	respChan := make(chan *ChannelReservation, 1)
 
 	l.msgChan <- &initFundingReserveMsg{
-		capacity:      capacity,
-		numConfs:      numConfs,
-		fundingAmount: ourFundAmt,
-		csvDelay:      csvDelay,
-		nodeID:        theirID,
-		err:           errChan,
-		resp:          respChan,
+		capacity:       capacity,
+		numConfs:       numConfs,
+		fundingAmount:  ourFundAmt,
+		csvDelay:       csvDelay,
+		nodeID:         theirID,
+		minFeeRate:     minFeeRate,
+		fundingFeeRate: fundingFeeRate,
+		err:            errChan,
+		resp:           respChan,
 	}
 
 	return <-respChan, <-errChan
@@ -526,12 +438,17 @@ func (l *LightningWallet) handleFundingReserveRequest(req *initFundingReserveMsg
 	ourContribution.CsvDelay = req.csvDelay
 	reservation.partialState.LocalCsvDelay = req.csvDelay
 
+	// Within a single funder channel, the initiator is the only side
+	// committing funds, and as a result pays the commitment fees.
+	// TODO(roasbeef): explicit initiator once dual funder is supported
+	reservation.partialState.IsInitiator = req.fundingAmount != 0
+
 	// If we're on the receiving end of a single funder channel then we
 	// don't need to perform any coin selection. Otherwise, attempt to
 	// obtain enough coins to meet the required funding amount.
 	if req.fundingAmount != 0 {
-		if err := l.selectCoinsAndChange(req.fundingAmount,
-			ourContribution); err != nil {
+		if err := l.selectCoinsAndChange(req.fundingFeeRate,
+			req.fundingAmount, ourContribution); err != nil {
 			req.err <- err
 			req.resp <- nil
 			return
@@ -560,8 +477,7 @@ func (l *LightningWallet) handleFundingReserveRequest(req *initFundingReserveMsg
 
 	// Generate a fresh address to be used in the case of a cooperative
 	// channel close.
-	deliveryAddress, err := l.NewAddress(waddrmgr.DefaultAccountNum,
-		waddrmgr.WitnessPubKey)
+	deliveryAddress, err := l.NewAddress(true)
 	if err != nil {
 		req.err <- err
 		req.resp <- nil
@@ -675,17 +591,9 @@ func (l *LightningWallet) handleContributionMsg(req *addContributionMsg) {
 	}
 	pendingReservation.partialState.FundingRedeemScript = redeemScript
 
-	// Register intent for notifications related to the funding output.
-	// This'll allow us to properly track the number of confirmations the
-	// funding tx has once it has been broadcasted.
+	// Import the redeem script of the funding output into the wallet.
 	// TODO(roasbeef): remove
-	lastBlock := l.Manager.SyncedTo()
-	scriptAddr, err := l.Manager.ImportScript(redeemScript, &lastBlock)
-	if err != nil {
-		req.err <- err
-		return
-	}
-	if err := l.rpc.NotifyReceived([]btcutil.Address{scriptAddr.Address()}); err != nil {
+	if err := l.ImportScript(redeemScript); err != nil {
 		req.err <- err
 		return
 	}
@@ -701,78 +609,31 @@ func (l *LightningWallet) handleContributionMsg(req *addContributionMsg) {
 	pendingReservation.ourFundingInputScripts = make([]*InputScript, 0, len(ourContribution.Inputs))
 	hashCache := txscript.NewTxSigHashes(fundingTx)
 	for i, txIn := range fundingTx.TxIn {
-		// Does the wallet know about the txin?
-		txDetail, _ := l.TxStore.TxDetails(&txIn.PreviousOutPoint.Hash)
-		if txDetail == nil {
+		// Does the wallet know about the txin? If not, then this is one
+		// of the counterparty's inputs, so we skip it.
+		prevOut, err := l.FetchInputInfo(&txIn.PreviousOutPoint)
+		if err == ErrNotMine {
 			continue
-		}
-
-		// Is this our txin?
-		prevIndex := txIn.PreviousOutPoint.Index
-		prevOut := txDetail.TxRecord.MsgTx.TxOut[prevIndex]
-		_, addrs, _, _ := txscript.ExtractPkScriptAddrs(prevOut.PkScript, l.cfg.NetParams)
-		apkh := addrs[0]
-
-		ai, err := l.Manager.Address(apkh)
-		if err != nil {
-			req.err <- fmt.Errorf("cannot get address info: %v", err)
-			return
-		}
-		pka := ai.(waddrmgr.ManagedPubKeyAddress)
-		privKey, err := pka.PrivKey()
-		if err != nil {
-			req.err <- fmt.Errorf("cannot get private key: %v", err)
+		} else if err != nil {
+			req.err <- err
 			return
 		}
 
-		var witnessProgram []byte
-		inputScript := &InputScript{}
-
-		// If we're spending p2wkh output nested within a p2sh output,
-		// then we'll need to attach a sigScript in addition to witness
-		// data.
-		if pka.IsNestedWitness() {
-			pubKey := privKey.PubKey()
-			pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
-
-			// Next, we'll generate a valid sigScript that'll allow us to spend
-			// the p2sh output. The sigScript will contain only a single push of
-			// the p2wkh witness program corresponding to the matching public key
-			// of this address.
-			p2wkhAddr, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash,
-				l.cfg.NetParams)
-			if err != nil {
-				req.err <- fmt.Errorf("unable to create p2wkh addr: %v", err)
-				return
-			}
-			witnessProgram, err = txscript.PayToAddrScript(p2wkhAddr)
-			if err != nil {
-				req.err <- fmt.Errorf("unable to create witness program: %v", err)
-				return
-			}
-			bldr := txscript.NewScriptBuilder()
-			bldr.AddData(witnessProgram)
-			sigScript, err := bldr.Script()
-			if err != nil {
-				req.err <- fmt.Errorf("unable to create scriptsig: %v", err)
-				return
-			}
-			txIn.SignatureScript = sigScript
-			inputScript.ScriptSig = sigScript
-		} else {
-			witnessProgram = prevOut.PkScript
+		// Generate a valid sigScript and/or witness stack for the
+		// input.
+		signDesc := &SignDescriptor{
+			Output:     prevOut,
+			HashType:   txscript.SigHashAll,
+			SigHashes:  hashCache,
+			InputIndex: i,
 		}
-
-		// Generate a valid witness stack for the input.
-		inputValue := prevOut.Value
-		witnessScript, err := txscript.WitnessScript(fundingTx, hashCache, i,
-			inputValue, witnessProgram, txscript.SigHashAll, privKey, true)
+		inputScript, err := l.Signer.ComputeInputScript(fundingTx, signDesc)
 		if err != nil {
-			req.err <- fmt.Errorf("cannot create witnessscript: %s", err)
+			req.err <- err
 			return
 		}
-		txIn.Witness = witnessScript
-		inputScript.Witness = witnessScript
+		txIn.SignatureScript = inputScript.ScriptSig
+		txIn.Witness = inputScript.Witness
 
 		pendingReservation.ourFundingInputScripts = append(
 			pendingReservation.ourFundingInputScripts,
@@ -817,19 +678,20 @@ func (l *LightningWallet) handleContributionMsg(req *addContributionMsg) {
 	// With the funding tx complete, create both commitment transactions.
 	// TODO(roasbeef): much cleanup + de-duplication
 	pendingReservation.fundingLockTime = theirContribution.CsvDelay
-	ourBalance := ourContribution.FundingAmount
-	theirBalance := theirContribution.FundingAmount
+	ourBalance, theirBalance := initialCommitBalances(
+		pendingReservation.partialState, ourContribution.FundingAmount,
+		theirContribution.FundingAmount)
 	ourCommitKey := ourContribution.CommitKey
 	ourCommitTx, err := createCommitTx(fundingTxIn, ourCommitKey, theirCommitKey,
 		ourRevokeKey, ourContribution.CsvDelay,
-		ourBalance, theirBalance)
+		ourBalance, theirBalance, ourContribution.Constraints.DustLimit)
 	if err != nil {
 		req.err <- err
 		return
 	}
 	theirCommitTx, err := createCommitTx(fundingTxIn, theirCommitKey, ourCommitKey,
 		theirContribution.RevocationKey, theirContribution.CsvDelay,
-		theirBalance, ourBalance)
+		theirBalance, ourBalance, theirContribution.Constraints.DustLimit)
 	if err != nil {
 		req.err <- err
 		return
@@ -849,6 +711,7 @@ func (l *LightningWallet) handleContributionMsg(req *addContributionMsg) {
 
 	// Record newly available information witin the open channel state.
 	pendingReservation.partialState.RemoteCsvDelay = theirContribution.CsvDelay
+	pendingReservation.partialState.RemoteConstraints = theirContribution.Constraints
 	pendingReservation.partialState.TheirDeliveryScript = deliveryScript
 	pendingReservation.partialState.ChanID = fundingOutpoint
 	pendingReservation.partialState.TheirCommitKey = theirCommitKey
@@ -860,8 +723,15 @@ func (l *LightningWallet) handleContributionMsg(req *addContributionMsg) {
 	// transaction.
 	hashCache = txscript.NewTxSigHashes(theirCommitTx)
 	channelBalance := pendingReservation.partialState.Capacity
-	sigTheirCommit, err := txscript.RawTxInWitnessSignature(theirCommitTx, hashCache, 0,
-		int64(channelBalance), redeemScript, txscript.SigHashAll, ourKey)
+	signDesc := &SignDescriptor{
+		PubKey:       ourKey.PubKey(),
+		RedeemScript: redeemScript,
+		Output:       wire.NewTxOut(int64(channelBalance), nil),
+		HashType:     txscript.SigHashAll,
+		SigHashes:    hashCache,
+		InputIndex:   0,
+	}
+	sigTheirCommit, err := l.Signer.SignOutputRaw(theirCommitTx, signDesc)
 	if err != nil {
 		req.err <- err
 		return
@@ -934,6 +804,7 @@ func (l *LightningWallet) handleSingleContribution(req *addSingleContributionMsg
 		return
 	}
 	pendingReservation.partialState.RemoteCsvDelay = theirContribution.CsvDelay
+	pendingReservation.partialState.RemoteConstraints = theirContribution.Constraints
 	pendingReservation.partialState.TheirDeliveryScript = deliveryScript
 	pendingReservation.partialState.TheirCommitKey = theirContribution.CommitKey
 	pendingReservation.partialState.TheirMultiSigKey = theirContribution.MultiSigKey
@@ -979,25 +850,16 @@ func (l *LightningWallet) handleFundingCounterPartySigs(msg *addCounterPartySigs
 			// Fetch the alleged previous output along with the
 			// pkscript referenced by this input.
 			prevOut := txin.PreviousOutPoint
-			output, err := l.rpc.GetTxOut(&prevOut.Hash, prevOut.Index, false)
-			if output == nil {
-				msg.err <- fmt.Errorf("input to funding tx does not exist: %v", err)
-				return
-			}
-
-			pkScript, err := hex.DecodeString(output.ScriptPubKey.Hex)
+			output, err := l.GetUtxo(&prevOut)
 			if err != nil {
-				msg.err <- err
+				msg.err <- fmt.Errorf("input to funding tx does not exist: %v", err)
 				return
 			}
-			// Sadly, gettxout returns the output value in BTC
-			// instead of satoshis.
-			inputValue := int64(output.Value) * 1e8
 
 			// Ensure that the witness+sigScript combo is valid.
-			vm, err := txscript.NewEngine(pkScript,
+			vm, err := txscript.NewEngine(output.PkScript,
 				fundingTx, i, txscript.StandardVerifyFlags, nil,
-				fundingHashCache, inputValue)
+				fundingHashCache, output.Value)
 			if err != nil {
 				// TODO(roasbeef): cancel at this stage if invalid sigs?
 				msg.err <- fmt.Errorf("cannot create script engine: %s", err)
@@ -1023,7 +885,7 @@ func (l *LightningWallet) handleFundingCounterPartySigs(msg *addCounterPartySigs
 	// redeemScript script, but include the p2sh output as the subscript
 	// for verification.
 	redeemScript := pendingReservation.partialState.FundingRedeemScript
-	p2wsh, err := witnessScriptHash(redeemScript)
+	p2wsh, err := WitnessScriptHash(redeemScript)
 	if err != nil {
 		msg.err <- err
 		return
@@ -1032,8 +894,15 @@ func (l *LightningWallet) handleFundingCounterPartySigs(msg *addCounterPartySigs
 	// First, we sign our copy of the commitment transaction ourselves.
 	channelValue := int64(pendingReservation.partialState.Capacity)
 	hashCache := txscript.NewTxSigHashes(commitTx)
-	ourCommitSig, err := txscript.RawTxInWitnessSignature(commitTx, hashCache, 0,
-		channelValue, redeemScript, txscript.SigHashAll, ourKey)
+	signDesc := &SignDescriptor{
+		PubKey:       ourKey.PubKey(),
+		RedeemScript: redeemScript,
+		Output:       wire.NewTxOut(channelValue, nil),
+		HashType:     txscript.SigHashAll,
+		SigHashes:    hashCache,
+		InputIndex:   0,
+	}
+	ourCommitSig, err := l.Signer.SignOutputRaw(commitTx, signDesc)
 	if err != nil {
 		msg.err <- err
 		return
@@ -1080,7 +949,7 @@ func (l *LightningWallet) handleFundingCounterPartySigs(msg *addCounterPartySigs
 		spew.Sdump(fundingTx))
 
 	// Broacast the finalized funding transaction to the network.
-	if err := l.PublishTransaction(fundingTx); err != nil {
+	if err := l.BroadcastTransaction(fundingTx); err != nil {
 		msg.err <- err
 		return
 	}
@@ -1127,18 +996,22 @@ func (l *LightningWallet) handleSingleFunderSigs(req *addSingleFunderSigsMsg) {
 	// remote node's commitment transactions.
 	ourCommitKey := pendingReservation.ourContribution.CommitKey
 	theirCommitKey := pendingReservation.theirContribution.CommitKey
-	ourBalance := pendingReservation.ourContribution.FundingAmount
-	theirBalance := pendingReservation.theirContribution.FundingAmount
+	ourBalance, theirBalance := initialCommitBalances(
+		pendingReservation.partialState,
+		pendingReservation.ourContribution.FundingAmount,
+		pendingReservation.theirContribution.FundingAmount)
 	ourCommitTx, err := createCommitTx(fundingTxIn, ourCommitKey, theirCommitKey,
 		pendingReservation.ourContribution.RevocationKey,
-		pendingReservation.ourContribution.CsvDelay, ourBalance, theirBalance)
+		pendingReservation.ourContribution.CsvDelay, ourBalance, theirBalance,
+		pendingReservation.ourContribution.Constraints.DustLimit)
 	if err != nil {
 		req.err <- err
 		return
 	}
 	theirCommitTx, err := createCommitTx(fundingTxIn, theirCommitKey, ourCommitKey,
 		req.revokeKey, pendingReservation.theirContribution.CsvDelay,
-		theirBalance, ourBalance)
+		theirBalance, ourBalance,
+		pendingReservation.theirContribution.Constraints.DustLimit)
 	if err != nil {
 		req.err <- err
 		return
@@ -1158,7 +1031,7 @@ func (l *LightningWallet) handleSingleFunderSigs(req *addSingleFunderSigsMsg) {
 	// TODO(roasbeef): replace with regular sighash calculation once the PR
 	// is merged.
 	redeemScript := pendingReservation.partialState.FundingRedeemScript
-	p2wsh, err := witnessScriptHash(redeemScript)
+	p2wsh, err := WitnessScriptHash(redeemScript)
 	if err != nil {
 		req.err <- err
 		return
@@ -1170,8 +1043,15 @@ func (l *LightningWallet) handleSingleFunderSigs(req *addSingleFunderSigsMsg) {
 	hashCache := txscript.NewTxSigHashes(ourCommitTx)
 	theirKey := pendingReservation.theirContribution.MultiSigKey
 	ourKey := pendingReservation.partialState.OurMultiSigKey
-	ourCommitSig, err := txscript.RawTxInWitnessSignature(ourCommitTx, hashCache, 0,
-		channelValue, redeemScript, txscript.SigHashAll, ourKey)
+	signDesc := &SignDescriptor{
+		PubKey:       ourKey.PubKey(),
+		RedeemScript: redeemScript,
+		Output:       wire.NewTxOut(channelValue, nil),
+		HashType:     txscript.SigHashAll,
+		SigHashes:    hashCache,
+		InputIndex:   0,
+	}
+	ourCommitSig, err := l.Signer.SignOutputRaw(ourCommitTx, signDesc)
 	if err != nil {
 		req.err <- err
 		return
@@ -1208,9 +1088,8 @@ func (l *LightningWallet) handleSingleFunderSigs(req *addSingleFunderSigsMsg) {
 	// With their signature for our version of the commitment transactions
 	// verified, we can now generate a signature for their version,
 	// allowing the funding transaction to be safely broadcast.
-	hashCache = txscript.NewTxSigHashes(theirCommitTx)
-	sigTheirCommit, err := txscript.RawTxInWitnessSignature(theirCommitTx, hashCache, 0,
-		channelValue, redeemScript, txscript.SigHashAll, ourKey)
+	signDesc.SigHashes = txscript.NewTxSigHashes(theirCommitTx)
+	sigTheirCommit, err := l.Signer.SignOutputRaw(theirCommitTx, signDesc)
 	if err != nil {
 		req.err <- err
 		return
@@ -1308,55 +1187,17 @@ func (l *LightningWallet) getNextRawKey() (*btcec.PrivateKey, error) {
 	l.KeyGenMtx.Lock()
 	defer l.KeyGenMtx.Unlock()
 
-	nextAddr, err := l.Manager.NextExternalAddresses(waddrmgr.DefaultAccountNum,
-		1, waddrmgr.WitnessPubKey)
-	if err != nil {
-		return nil, err
-	}
-
-	pkAddr := nextAddr[0].(waddrmgr.ManagedPubKeyAddress)
-
-	return pkAddr.PrivKey()
-}
-
-// ListUnspentWitness returns a slice of all the unspent outputs the wallet
-// controls which pay to witness programs either directly or indirectly.
-func (l *LightningWallet) ListUnspentWitness(minConfs int32) ([]*btcjson.ListUnspentResult, error) {
-	// First, grab all the unfiltered currently unspent outputs.
-	maxConfs := int32(math.MaxInt32)
-	unspentOutputs, err := l.ListUnspent(minConfs, maxConfs, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// Next, we'll run through all the regular outputs, only saving those
-	// which are p2wkh outputs or a p2wsh output nested within a p2sh output.
-	witnessOutputs := make([]*btcjson.ListUnspentResult, 0, len(unspentOutputs))
-	for _, output := range unspentOutputs {
-		pkScript, err := hex.DecodeString(output.ScriptPubKey)
-		if err != nil {
-			return nil, err
-		}
-
-		// TODO(roasbeef): this assumes all p2sh outputs returned by
-		// the wallet are nested p2sh...
-		if txscript.IsPayToWitnessPubKeyHash(pkScript) ||
-			txscript.IsPayToScriptHash(pkScript) {
-			witnessOutputs = append(witnessOutputs, output)
-		}
-
-	}
-
-	return witnessOutputs, nil
+	return l.NewRawKey()
 }
 
 // selectCoinsAndChange performs coin selection in order to obtain witness
-// outputs which sum to at least 'numCoins' amount of satoshis. If coin
-// selection is succesful/possible, then the selected coins are available within
-// the passed contribution's inputs. If necessary, a change address will also be
-// generated.
-// TODO(roasbeef): remove hardcoded fees and req'd confs for outputs.
-func (l *LightningWallet) selectCoinsAndChange(numCoins btcutil.Amount,
+// outputs which sum to at least 'numCoins' amount of satoshis, plus the fee
+// for our portion of the funding transaction at the passed fee rate in
+// satoshis per byte. If coin selection is succesful/possible, then the
+// selected coins are available within the passed contribution's inputs. If
+// necessary, a change address will also be generated.
+// TODO(roasbeef): remove hardcoded req'd confs for outputs.
+func (l *LightningWallet) selectCoinsAndChange(feePerByte, numCoins btcutil.Amount,
 	contribution *ChannelContribution) error {
 
 	// We hold the coin select mutex while querying for outputs, and
@@ -1380,26 +1221,13 @@ func (l *LightningWallet) selectCoinsAndChange(numCoins btcutil.Amount,
 		return err
 	}
 
-	// Convert the outputs to coins for coin selection below.
-	coins, err := outputsToCoins(unspentOutputs)
-	if err != nil {
-		l.coinSelectMtx.Unlock()
-		return err
-	}
-
 	// Peform coin selection over our available, unlocked unspent outputs
-	// in order to find enough coins to meet the funding amount requirements.
-	//
-	// TODO(roasbeef): Should extend coinset with optimal coin selection
-	// heuristics for our use case.
-	// NOTE: this current selection assumes "priority" is still a thing.
-	selector := &coinset.MaxValueAgeCoinSelector{
-		MaxInputs:       10,
-		MinChangeAmount: 10000,
-	}
-	// TODO(roasbeef): don't hardcode fee...
-	totalWithFee := numCoins + 10000
-	selectedCoins, err := selector.CoinSelect(totalWithFee, coins)
+	// in order to find enough coins to meet the funding amount
+	// requirements. The fee covers the funding output, and a possible
+	// change output along with the inputs selected.
+	baseSize := txOverheadSize + p2wshOutputSize + p2wkhOutputSize
+	selectedCoins, changeAmt, err := coinSelect(feePerByte, numCoins,
+		baseSize, unspentOutputs)
 	if err != nil {
 		l.coinSelectMtx.Unlock()
 		return err
@@ -1408,27 +1236,24 @@ func (l *LightningWallet) selectCoinsAndChange(numCoins btcutil.Amount,
 	// Lock the selected coins. These coins are now "reserved", this
 	// prevents concurrent funding requests from referring to and this
 	// double-spending the same set of coins.
-	contribution.Inputs = make([]*wire.TxIn, len(selectedCoins.Coins()))
-	for i, coin := range selectedCoins.Coins() {
-		txout := wire.NewOutPoint(coin.Hash(), coin.Index())
-		l.LockOutpoint(*txout)
+	contribution.Inputs = make([]*wire.TxIn, len(selectedCoins))
+	for i, coin := range selectedCoins {
+		l.LockOutpoint(coin.OutPoint)
 
 		// Empty sig script, we'll actually sign if this reservation is
 		// queued up to be completed (the other side accepts).
-		outPoint := wire.NewOutPoint(coin.Hash(), coin.Index())
-		contribution.Inputs[i] = wire.NewTxIn(outPoint, nil, nil)
+		outPoint := coin.OutPoint
+		contribution.Inputs[i] = wire.NewTxIn(&outPoint, nil, nil)
 	}
 
 	l.coinSelectMtx.Unlock()
 
 	// Create some possibly neccessary change outputs.
-	selectedTotalValue := coinset.NewCoinSet(selectedCoins.Coins()).TotalValue()
-	if selectedTotalValue > totalWithFee {
+	if changeAmt > 0 {
 		// Change is necessary. Query for an available change address to
 		// send the remainder to.
 		contribution.ChangeOutputs = make([]*wire.TxOut, 1)
-		changeAddr, err := l.NewChangeAddress(waddrmgr.DefaultAccountNum,
-			waddrmgr.WitnessPubKey)
+		changeAddr, err := l.NewChangeAddress(true)
 		if err != nil {
 			return err
 		}
@@ -1438,27 +1263,25 @@ func (l *LightningWallet) selectCoinsAndChange(numCoins btcutil.Amount,
 			return err
 		}
 
-		changeAmount := selectedTotalValue - totalWithFee
-		contribution.ChangeOutputs[0] = wire.NewTxOut(int64(changeAmount),
+		contribution.ChangeOutputs[0] = wire.NewTxOut(int64(changeAmt),
 			changeAddrScript)
 	}
 
-	// TODO(roasbeef): re-calculate fees here to minFeePerKB, may need more inputs
 	return nil
 }
 
-type WaddrmgrEncryptorDecryptor struct {
-	M *waddrmgr.Manager
-}
+// initialCommitBalances returns the balances of both parties within the
+// initial commitment transactions of the passed channel. The fee of the
+// commitment transactions is deducted from the balance of the initiator.
+func initialCommitBalances(state *channeldb.OpenChannel, ourBalance,
+	theirBalance btcutil.Amount) (btcutil.Amount, btcutil.Amount) {
 
-func (w *WaddrmgrEncryptorDecryptor) Encrypt(p []byte) ([]byte, error) {
-	return w.M.Encrypt(waddrmgr.CKTPrivate, p)
-}
-
-func (w *WaddrmgrEncryptorDecryptor) Decrypt(c []byte) ([]byte, error) {
-	return w.M.Decrypt(waddrmgr.CKTPrivate, c)
-}
+	fee := commitFee(state.MinFeePerKb, 0)
+	if state.IsInitiator {
+		_, ourBalance = deductFee(fee, ourBalance)
+	} else {
+		_, theirBalance = deductFee(fee, theirBalance)
+	}
 
-func (w *WaddrmgrEncryptorDecryptor) OverheadSize() uint32 {
-	return 24
+	return ourBalance, theirBalance
 }
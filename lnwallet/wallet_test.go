@@ -12,6 +12,7 @@ import (
 
 	"github.com/Roasbeef/btcutil/txsort"
 	"github.com/boltdb/bolt"
+	"github.com/lightningnetwork/lnd/chainntfs/btcdnotify"
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/roasbeef/btcd/chaincfg"
 
@@ -20,8 +21,6 @@ import (
 	"github.com/roasbeef/btcd/txscript"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcutil"
-	"github.com/roasbeef/btcutil/coinset"
-	"github.com/roasbeef/btcwallet/waddrmgr"
 )
 
 var (
@@ -54,13 +53,17 @@ var (
 	// The number of confirmations required to consider any created channel
 	// open.
 	numReqConfs = uint16(1)
+
+	// The fee rate in satoshis per byte paid by the funding transactions
+	// of the test channels.
+	testFundingFeeRate = btcutil.Amount(10)
 )
 
 // assertProperBalance asserts than the total value of the unspent outputs
 // within the wallet are *exactly* amount. If unable to retrieve the current
 // balance, or the assertion fails, the test will halt with a fatal error.
 func assertProperBalance(t *testing.T, lw *LightningWallet, numConfirms int32, amount int64) {
-	balance, err := lw.CalculateBalance(numConfirms)
+	balance, err := lw.ConfirmedBalance(numConfirms)
 	if err != nil {
 		t.Fatalf("unable to query for balance: %v", err)
 	}
@@ -257,7 +260,7 @@ func loadTestCredits(miner *rpctest.Harness, w *LightningWallet, numOutputs, btc
 	addrs := make([]btcutil.Address, 0, numOutputs)
 	for i := 0; i < numOutputs; i++ {
 		// Grab a fresh address from the wallet to house this output.
-		walletAddr, err := w.NewAddress(waddrmgr.DefaultAccountNum, waddrmgr.WitnessPubKey)
+		walletAddr, err := w.NewAddress(true)
 		if err != nil {
 			return err
 		}
@@ -290,12 +293,13 @@ func loadTestCredits(miner *rpctest.Harness, w *LightningWallet, numOutputs, btc
 	}
 
 	// Wait until the wallet has finished syncing up to the main chain.
+	btcWallet := w.WalletController.(*BtcWallet)
 	ticker := time.NewTicker(100 * time.Millisecond)
 out:
 	for {
 		select {
 		case <-ticker.C:
-			if w.Manager.SyncedTo().Height == bestHeight {
+			if btcWallet.wallet.Manager.SyncedTo().Height == bestHeight {
 				break out
 			}
 		}
@@ -304,7 +308,7 @@ out:
 
 	// Trigger a re-scan to ensure the wallet knows of the newly created
 	// outputs it can spend.
-	if err := w.Rescan(addrs, nil); err != nil {
+	if err := btcWallet.wallet.Rescan(addrs, nil); err != nil {
 		return err
 	}
 
@@ -338,15 +342,25 @@ func createTestWallet(miningNode *rpctest.Harness, netParams *chaincfg.Params) (
 		return "", nil, err
 	}
 
-	wallet, err := NewLightningWallet(config, cdb)
+	btcWallet, err := NewBtcWallet(config, cdb)
 	if err != nil {
 		return "", nil, err
 	}
-	if err := wallet.Startup(); err != nil {
+	cdb.RegisterCryptoSystem(btcWallet.CryptoSystem())
+
+	notifier, err := btcdnotify.NewBtcdNotifier(&rpcConfig, nil)
+	if err != nil {
 		return "", nil, err
 	}
 
-	cdb.RegisterCryptoSystem(&WaddrmgrEncryptorDecryptor{wallet.Manager})
+	wallet, err := NewLightningWallet(cdb, notifier, btcWallet, btcWallet,
+		netParams)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := wallet.Startup(); err != nil {
+		return "", nil, err
+	}
 
 	// Load our test wallet with 10 outputs each holding 4BTC.
 	if err := loadTestCredits(miningNode, wallet, 10, 4); err != nil {
@@ -368,7 +382,8 @@ func testDualFundingReservationWorkflow(miner *rpctest.Harness, lnwallet *Lightn
 	// Bob initiates a channel funded with 5 BTC for each side, so 10
 	// BTC total. He also generates 2 BTC in change.
 	chanReservation, err := lnwallet.InitChannelReservation(fundingAmount*2,
-		fundingAmount, bobNode.id, numReqConfs, 4)
+		fundingAmount, bobNode.id, numReqConfs, 4, 0,
+		testFundingFeeRate)
 	if err != nil {
 		t.Fatalf("unable to initialize funding reservation: %v", err)
 	}
@@ -482,7 +497,8 @@ func testDualFundingReservationWorkflow(miner *rpctest.Harness, lnwallet *Lightn
 
 	// Now that the channel is open, execute a cooperative closure of the
 	// now open channel.
-	aliceCloseSig, _, err := lnc.InitCooperativeClose()
+	closeFeeRate := testFundingFeeRate * 1000
+	aliceCloseSig, _, err := lnc.InitCooperativeClose(closeFeeRate)
 	if err != nil {
 		t.Fatalf("unable to init cooperative closure: %v", err)
 	}
@@ -492,9 +508,10 @@ func testDualFundingReservationWorkflow(miner *rpctest.Harness, lnwallet *Lightn
 	fundingOut := lnc.ChannelPoint()
 	fundingTxIn := wire.NewTxIn(fundingOut, nil, nil)
 	bobCloseTx := createCooperativeCloseTx(fundingTxIn,
-		lnc.channelState.TheirBalance, lnc.channelState.OurBalance,
+		lnc.channelState.TheirBalance.ToSatoshis(),
+		lnc.channelState.OurBalance.ToSatoshis(),
 		lnc.channelState.TheirDeliveryScript, lnc.channelState.OurDeliveryScript,
-		false)
+		false, closeFeeRate)
 	bobSig, err := bobNode.signCommitTx(bobCloseTx,
 		redeemScript,
 		int64(lnc.channelState.Capacity))
@@ -509,7 +526,7 @@ func testDualFundingReservationWorkflow(miner *rpctest.Harness, lnwallet *Lightn
 	witness := spendMultiSig(redeemScript, ourKey, aliceCloseSig,
 		theirKey, bobSig)
 	bobCloseTx.TxIn[0].Witness = witness
-	if err := lnwallet.PublishTransaction(bobCloseTx); err != nil {
+	if err := lnwallet.BroadcastTransaction(bobCloseTx); err != nil {
 		t.Fatalf("broadcast of close tx rejected: %v", err)
 	}
 }
@@ -521,12 +538,14 @@ func testFundingTransactionLockedOutputs(miner *rpctest.Harness, lnwallet *Light
 	//  * also func for below
 	fundingAmount := btcutil.Amount(8 * 1e8)
 	chanReservation1, err := lnwallet.InitChannelReservation(fundingAmount,
-		fundingAmount, testHdSeed, numReqConfs, 4)
+		fundingAmount, testHdSeed, numReqConfs, 4, 0,
+		testFundingFeeRate)
 	if err != nil {
 		t.Fatalf("unable to initialize funding reservation 1: %v", err)
 	}
 	chanReservation2, err := lnwallet.InitChannelReservation(fundingAmount,
-		fundingAmount, testHdSeed, numReqConfs, 4)
+		fundingAmount, testHdSeed, numReqConfs, 4, 0,
+		testFundingFeeRate)
 	if err != nil {
 		t.Fatalf("unable to initialize funding reservation 2: %v", err)
 	}
@@ -555,11 +574,12 @@ func testFundingTransactionLockedOutputs(miner *rpctest.Harness, lnwallet *Light
 	// this should fail.
 	amt := btcutil.Amount(90 * 1e8)
 	failedReservation, err := lnwallet.InitChannelReservation(amt, amt,
-		testHdSeed, numReqConfs, 4)
+		testHdSeed, numReqConfs, 4, 0,
+		testFundingFeeRate)
 	if err == nil {
 		t.Fatalf("not error returned, should fail on coin selection")
 	}
-	if err != coinset.ErrCoinsNoSelectionAvailable {
+	if err != ErrInsufficientFunds {
 		t.Fatalf("error not coinselect error: %v", err)
 	}
 	if failedReservation != nil {
@@ -571,13 +591,14 @@ func testFundingCancellationNotEnoughFunds(miner *rpctest.Harness, lnwallet *Lig
 	// Create a reservation for 22 BTC.
 	fundingAmount := btcutil.Amount(22 * 1e8)
 	chanReservation, err := lnwallet.InitChannelReservation(fundingAmount,
-		fundingAmount, testHdSeed, numReqConfs, 4)
+		fundingAmount, testHdSeed, numReqConfs, 4, 0,
+		testFundingFeeRate)
 	if err != nil {
 		t.Fatalf("unable to initialize funding reservation: %v", err)
 	}
 
 	// There should be three locked outpoints.
-	lockedOutPoints := lnwallet.LockedOutpoints()
+	lockedOutPoints := lnwallet.WalletController.(*BtcWallet).wallet.LockedOutpoints()
 	if len(lockedOutPoints) != 6 {
 		t.Fatalf("two outpoints should now be locked, instead %v are",
 			len(lockedOutPoints))
@@ -585,8 +606,9 @@ func testFundingCancellationNotEnoughFunds(miner *rpctest.Harness, lnwallet *Lig
 
 	// Attempt to create another channel with 22 BTC, this should fail.
 	failedReservation, err := lnwallet.InitChannelReservation(fundingAmount,
-		fundingAmount, testHdSeed, numReqConfs, 4)
-	if err != coinset.ErrCoinsNoSelectionAvailable {
+		fundingAmount, testHdSeed, numReqConfs, 4, 0,
+		testFundingFeeRate)
+	if err != ErrInsufficientFunds {
 		t.Fatalf("coin selection succeded should have insufficient funds: %+v",
 			failedReservation)
 	}
@@ -597,7 +619,7 @@ func testFundingCancellationNotEnoughFunds(miner *rpctest.Harness, lnwallet *Lig
 	}
 
 	// Those outpoints should no longer be locked.
-	lockedOutPoints = lnwallet.LockedOutpoints()
+	lockedOutPoints = lnwallet.WalletController.(*BtcWallet).wallet.LockedOutpoints()
 	if len(lockedOutPoints) != 0 {
 		t.Fatalf("outpoints still locked")
 	}
@@ -614,7 +636,8 @@ func testFundingCancellationNotEnoughFunds(miner *rpctest.Harness, lnwallet *Lig
 
 	// Request to fund a new channel should now succeeed.
 	_, err = lnwallet.InitChannelReservation(fundingAmount, fundingAmount,
-		testHdSeed, numReqConfs, 4)
+		testHdSeed, numReqConfs, 4, 0,
+		testFundingFeeRate)
 	if err != nil {
 		t.Fatalf("unable to initialize funding reservation: %v", err)
 	}
@@ -645,7 +668,8 @@ func testSingleFunderReservationWorkflowInitiator(miner *rpctest.Harness, lnwall
 	// Initialize a reservation for a channel with 4 BTC funded solely by us.
 	fundingAmt := btcutil.Amount(4 * 1e8)
 	chanReservation, err := lnwallet.InitChannelReservation(fundingAmt,
-		fundingAmt, bobNode.id, numReqConfs, 4)
+		fundingAmt, bobNode.id, numReqConfs, 4, 0,
+		testFundingFeeRate)
 	if err != nil {
 		t.Fatalf("unable to init channel reservation: %v", err)
 	}
@@ -775,7 +799,8 @@ func testSingleFunderReservationWorkflowResponder(miner *rpctest.Harness, lnwall
 	// contribution and the necessary resources.
 	fundingAmt := btcutil.Amount(0)
 	chanReservation, err := lnwallet.InitChannelReservation(capacity,
-		fundingAmt, bobNode.id, numReqConfs, 4)
+		fundingAmt, bobNode.id, numReqConfs, 4, 0,
+		testFundingFeeRate)
 	if err != nil {
 		t.Fatalf("unable to init channel reservation: %v", err)
 	}
@@ -869,7 +894,7 @@ func testSingleFunderReservationWorkflowResponder(miner *rpctest.Harness, lnwall
 	fundingTxIn := wire.NewTxIn(fundingOutpoint, nil, nil)
 	aliceCommitTx, err := createCommitTx(fundingTxIn, ourContribution.CommitKey,
 		bobContribution.CommitKey, ourContribution.RevocationKey,
-		ourContribution.CsvDelay, 0, capacity)
+		ourContribution.CsvDelay, 0, capacity, 0)
 	if err != nil {
 		t.Fatalf("unable to create alice's commit tx: %v", err)
 	}
@@ -932,7 +957,7 @@ type testLnWallet struct {
 func clearWalletState(w *LightningWallet) error {
 	w.nextFundingID = 0
 	w.fundingLimbo = make(map[uint64]*ChannelReservation)
-	w.ResetLockedOutpoints()
+	w.WalletController.(*BtcWallet).wallet.ResetLockedOutpoints()
 
 	// TODO(roasbeef): should also restore outputs to original state.
 
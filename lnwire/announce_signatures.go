@@ -0,0 +1,100 @@
+package lnwire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/wire"
+)
+
+// AnnounceSignatures is exchanged by the two endpoints of a newly opened
+// channel, carrying the sender's half of the signatures of the channel's
+// ChannelAnnouncement. Once each node holds the signatures of the other, it
+// assembles the complete announcement, and broadcasts it to the network.
+type AnnounceSignatures struct {
+	// ChannelPoint is the funding outpoint of the channel being
+	// announced.
+	ChannelPoint *wire.OutPoint
+
+	// NodeSignature is the signature of the sender's identity key over
+	// the channel announcement.
+	NodeSignature *btcec.Signature
+
+	// BitcoinSignature is the signature of the sender's funding key over
+	// the channel announcement.
+	BitcoinSignature *btcec.Signature
+}
+
+// A compile time check to ensure AnnounceSignatures implements the
+// lnwire.Message interface.
+var _ Message = (*AnnounceSignatures)(nil)
+
+// Decode deserializes a serialized AnnounceSignatures stored in the passed
+// io.Reader observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (a *AnnounceSignatures) Decode(r io.Reader, pver uint32) error {
+	// ChannelPoint (36)
+	// NodeSignature (73)
+	// BitcoinSignature (73)
+	return readElements(r,
+		&a.ChannelPoint,
+		&a.NodeSignature,
+		&a.BitcoinSignature)
+}
+
+// Encode serializes the target AnnounceSignatures into the passed io.Writer
+// observing the protocol version specified.
+//
+// This is part of the lnwire.Message interface.
+func (a *AnnounceSignatures) Encode(w io.Writer, pver uint32) error {
+	return writeElements(w,
+		a.ChannelPoint,
+		a.NodeSignature,
+		a.BitcoinSignature)
+}
+
+// Command returns the integer uniquely identifying this message type on the
+// wire.
+//
+// This is part of the lnwire.Message interface.
+func (a *AnnounceSignatures) Command() uint32 {
+	return CmdAnnounceSignatures
+}
+
+// MaxPayloadLength returns the maximum allowed payload size for this message
+// observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (a *AnnounceSignatures) MaxPayloadLength(pver uint32) uint32 {
+	// 36 + 2*74
+	return 184
+}
+
+// Validate performs any necessary sanity checks to ensure all fields present
+// on the AnnounceSignatures are valid. The signatures themselves are
+// verified by the receiver.
+//
+// This is part of the lnwire.Message interface.
+func (a *AnnounceSignatures) Validate() error {
+	if a.ChannelPoint == nil {
+		return fmt.Errorf("channel point must be set")
+	}
+	if a.NodeSignature == nil || a.BitcoinSignature == nil {
+		return fmt.Errorf("both signatures must be set")
+	}
+
+	// We're good!
+	return nil
+}
+
+// String returns the string representation of the target
+// AnnounceSignatures.
+//
+// This is part of the lnwire.Message interface.
+func (a *AnnounceSignatures) String() string {
+	return fmt.Sprintf("\n--- Begin AnnounceSignatures ---\n") +
+		fmt.Sprintf("ChannelPoint:\t\t%v\n", a.ChannelPoint) +
+		fmt.Sprintf("--- End AnnounceSignatures ---\n")
+}
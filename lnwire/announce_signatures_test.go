@@ -0,0 +1,36 @@
+package lnwire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAnnounceSignaturesEncodeDecode(t *testing.T) {
+	as := &AnnounceSignatures{
+		ChannelPoint:     outpoint1,
+		NodeSignature:    commitSig,
+		BitcoinSignature: commitSig,
+	}
+	if err := as.Validate(); err != nil {
+		t.Fatalf("valid message failed validation: %v", err)
+	}
+
+	// Next encode the AS message into an empty bytes buffer.
+	var b bytes.Buffer
+	if err := as.Encode(&b, 0); err != nil {
+		t.Fatalf("unable to encode AnnounceSignatures: %v", err)
+	}
+
+	// Deserialize the encoded AS message into a new empty struct.
+	as2 := &AnnounceSignatures{}
+	if err := as2.Decode(&b, 0); err != nil {
+		t.Fatalf("unable to decode AnnounceSignatures: %v", err)
+	}
+
+	// Assert equality of the two instances.
+	if !reflect.DeepEqual(as, as2) {
+		t.Fatalf("encode/decode error messages don't match %#v vs %#v",
+			as, as2)
+	}
+}
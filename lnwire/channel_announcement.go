@@ -0,0 +1,193 @@
+package lnwire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/wire"
+)
+
+// ChannelAnnouncement is broadcast to the network in order to prove the
+// existence of a channel between two nodes. The announcement is signed by
+// both node identity keys, proving that both nodes agreed to announce the
+// channel, and by both keys of the 2-of-2 multi-sig funding output, binding
+// the nodes to the funding output referenced by the ChannelPoint.
+type ChannelAnnouncement struct {
+	// FirstNodeSig is the signature of the first node's identity key
+	// over the announcement.
+	FirstNodeSig *btcec.Signature
+
+	// SecondNodeSig is the signature of the second node's identity key
+	// over the announcement.
+	SecondNodeSig *btcec.Signature
+
+	// FirstBitcoinSig is the signature of the first node's funding key
+	// over the announcement.
+	FirstBitcoinSig *btcec.Signature
+
+	// SecondBitcoinSig is the signature of the second node's funding key
+	// over the announcement.
+	SecondBitcoinSig *btcec.Signature
+
+	// ChannelPoint is the funding outpoint of the channel.
+	ChannelPoint *wire.OutPoint
+
+	// FirstNodeID is the identity key of the first node. The serialized
+	// key of the first node must sort before that of the second node.
+	FirstNodeID *btcec.PublicKey
+
+	// SecondNodeID is the identity key of the second node.
+	SecondNodeID *btcec.PublicKey
+
+	// FirstBitcoinKey is the first node's key within the 2-of-2 multi-sig
+	// funding output.
+	FirstBitcoinKey *btcec.PublicKey
+
+	// SecondBitcoinKey is the second node's key within the 2-of-2
+	// multi-sig funding output.
+	SecondBitcoinKey *btcec.PublicKey
+}
+
+// A compile time check to ensure ChannelAnnouncement implements the
+// lnwire.Message interface.
+var _ Message = (*ChannelAnnouncement)(nil)
+
+// Decode deserializes a serialized ChannelAnnouncement stored in the passed
+// io.Reader observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelAnnouncement) Decode(r io.Reader, pver uint32) error {
+	// FirstNodeSig (73)
+	// SecondNodeSig (73)
+	// FirstBitcoinSig (73)
+	// SecondBitcoinSig (73)
+	// ChannelPoint (36)
+	// FirstNodeID (33)
+	// SecondNodeID (33)
+	// FirstBitcoinKey (33)
+	// SecondBitcoinKey (33)
+	err := readElements(r,
+		&c.FirstNodeSig,
+		&c.SecondNodeSig,
+		&c.FirstBitcoinSig,
+		&c.SecondBitcoinSig,
+		&c.ChannelPoint,
+		&c.FirstNodeID,
+		&c.SecondNodeID,
+		&c.FirstBitcoinKey,
+		&c.SecondBitcoinKey)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Encode serializes the target ChannelAnnouncement into the passed io.Writer
+// observing the protocol version specified.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelAnnouncement) Encode(w io.Writer, pver uint32) error {
+	err := writeElements(w,
+		c.FirstNodeSig,
+		c.SecondNodeSig,
+		c.FirstBitcoinSig,
+		c.SecondBitcoinSig)
+	if err != nil {
+		return err
+	}
+
+	return c.encodeSignedData(w)
+}
+
+// encodeSignedData serializes all the fields of the announcement which are
+// covered by its signatures.
+func (c *ChannelAnnouncement) encodeSignedData(w io.Writer) error {
+	return writeElements(w,
+		c.ChannelPoint,
+		c.FirstNodeID,
+		c.SecondNodeID,
+		c.FirstBitcoinKey,
+		c.SecondBitcoinKey)
+}
+
+// DataToSign returns the double-sha256 digest of the announcement which each
+// of the four signatures must commit to.
+func (c *ChannelAnnouncement) DataToSign() ([]byte, error) {
+	var b bytes.Buffer
+	if err := c.encodeSignedData(&b); err != nil {
+		return nil, err
+	}
+
+	return wire.DoubleSha256(b.Bytes()), nil
+}
+
+// Command returns the integer uniquely identifying this message type on the
+// wire.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelAnnouncement) Command() uint32 {
+	return CmdChannelAnnouncement
+}
+
+// MaxPayloadLength returns the maximum allowed payload size for this message
+// observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelAnnouncement) MaxPayloadLength(pver uint32) uint32 {
+	// 4*74 + 36 + 4*33
+	return 464
+}
+
+// Validate performs any necessary sanity checks to ensure all fields present
+// on the ChannelAnnouncement are valid. The signatures themselves are
+// verified by the receiver.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelAnnouncement) Validate() error {
+	if c.ChannelPoint == nil {
+		return fmt.Errorf("channel point must be set")
+	}
+	if c.FirstNodeSig == nil || c.SecondNodeSig == nil ||
+		c.FirstBitcoinSig == nil || c.SecondBitcoinSig == nil {
+		return fmt.Errorf("all four signatures must be set")
+	}
+	if c.FirstNodeID == nil || c.SecondNodeID == nil ||
+		c.FirstBitcoinKey == nil || c.SecondBitcoinKey == nil {
+		return fmt.Errorf("all four keys must be set")
+	}
+
+	// The node IDs must be in canonical order, ensuring there's a single
+	// valid announcement for each channel.
+	firstNode := c.FirstNodeID.SerializeCompressed()
+	secondNode := c.SecondNodeID.SerializeCompressed()
+	if bytes.Compare(firstNode, secondNode) != -1 {
+		return fmt.Errorf("node IDs must be distinct, and in " +
+			"ascending order")
+	}
+
+	// We're good!
+	return nil
+}
+
+// String returns the string representation of the target
+// ChannelAnnouncement.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelAnnouncement) String() string {
+	var firstNode, secondNode []byte
+	if c.FirstNodeID != nil {
+		firstNode = c.FirstNodeID.SerializeCompressed()
+	}
+	if c.SecondNodeID != nil {
+		secondNode = c.SecondNodeID.SerializeCompressed()
+	}
+
+	return fmt.Sprintf("\n--- Begin ChannelAnnouncement ---\n") +
+		fmt.Sprintf("ChannelPoint:\t\t%v\n", c.ChannelPoint) +
+		fmt.Sprintf("FirstNodeID:\t\t%x\n", firstNode) +
+		fmt.Sprintf("SecondNodeID:\t\t%x\n", secondNode) +
+		fmt.Sprintf("--- End ChannelAnnouncement ---\n")
+}
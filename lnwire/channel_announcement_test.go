@@ -0,0 +1,90 @@
+package lnwire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/roasbeef/btcd/btcec"
+)
+
+func TestChannelAnnouncementEncodeDecode(t *testing.T) {
+	keys := make([]*btcec.PrivateKey, 4)
+	for i := range keys {
+		key, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Fatalf("unable to generate key: %v", err)
+		}
+		keys[i] = key
+	}
+
+	// The node IDs must be in canonical order.
+	if bytes.Compare(keys[0].PubKey().SerializeCompressed(),
+		keys[1].PubKey().SerializeCompressed()) == 1 {
+		keys[0], keys[1] = keys[1], keys[0]
+	}
+
+	ca := &ChannelAnnouncement{
+		ChannelPoint:     outpoint1,
+		FirstNodeID:      keys[0].PubKey(),
+		SecondNodeID:     keys[1].PubKey(),
+		FirstBitcoinKey:  keys[2].PubKey(),
+		SecondBitcoinKey: keys[3].PubKey(),
+	}
+	digest, err := ca.DataToSign()
+	if err != nil {
+		t.Fatalf("unable to compute digest: %v", err)
+	}
+	sigs := make([]*btcec.Signature, 4)
+	for i, key := range keys {
+		sigs[i], err = key.Sign(digest)
+		if err != nil {
+			t.Fatalf("unable to sign announcement: %v", err)
+		}
+	}
+	ca.FirstNodeSig = sigs[0]
+	ca.SecondNodeSig = sigs[1]
+	ca.FirstBitcoinSig = sigs[2]
+	ca.SecondBitcoinSig = sigs[3]
+
+	if err := ca.Validate(); err != nil {
+		t.Fatalf("valid announcement failed validation: %v", err)
+	}
+
+	// Next encode the CA message into an empty bytes buffer.
+	var b bytes.Buffer
+	if err := ca.Encode(&b, 0); err != nil {
+		t.Fatalf("unable to encode ChannelAnnouncement: %v", err)
+	}
+	if uint32(b.Len()) > ca.MaxPayloadLength(0) {
+		t.Fatalf("encoded size %v exceeds max payload length", b.Len())
+	}
+
+	// Deserialize the encoded CA message into a new empty struct.
+	ca2 := &ChannelAnnouncement{}
+	if err := ca2.Decode(&b, 0); err != nil {
+		t.Fatalf("unable to decode ChannelAnnouncement: %v", err)
+	}
+
+	// Assert equality of the two instances.
+	if !reflect.DeepEqual(ca, ca2) {
+		t.Fatalf("encode/decode error messages don't match %#v vs %#v",
+			ca, ca2)
+	}
+
+	// The signatures should still verify against the decoded message.
+	digest2, err := ca2.DataToSign()
+	if err != nil {
+		t.Fatalf("unable to compute digest: %v", err)
+	}
+	if !ca2.FirstNodeSig.Verify(digest2, ca2.FirstNodeID) {
+		t.Fatalf("signature invalid after decoding")
+	}
+
+	// Swapping the node IDs should fail validation, as they're no longer
+	// in canonical order.
+	ca2.FirstNodeID, ca2.SecondNodeID = ca2.SecondNodeID, ca2.FirstNodeID
+	if err := ca2.Validate(); err == nil {
+		t.Fatalf("unordered node IDs passed validation")
+	}
+}
@@ -0,0 +1,191 @@
+package lnwire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+)
+
+// ChannelUpdateAnnouncement is broadcast by one of the endpoints of a channel
+// in order to advertise the forwarding policy it applies to HTLCs flowing out
+// across the channel. The announcement is signed by the announcing node's
+// identity key, and each new announcement must carry a later timestamp than
+// the last in order to supersede it.
+type ChannelUpdateAnnouncement struct {
+	// Signature is the signature of the announcing node's identity key
+	// over the announcement.
+	Signature *btcec.Signature
+
+	// ChannelPoint is the funding outpoint of the channel.
+	ChannelPoint *wire.OutPoint
+
+	// Timestamp is the unix timestamp at which the announcement was
+	// created.
+	Timestamp uint32
+
+	// NodeID is the identity key of the announcing node.
+	NodeID *btcec.PublicKey
+
+	// TimeLockDelta is the minimum number of blocks by which the expiry
+	// of an incoming HTLC must exceed that of the HTLC forwarded across
+	// the channel.
+	TimeLockDelta uint16
+
+	// MinHTLC is the smallest HTLC the node will forward across the
+	// channel.
+	MinHTLC btcutil.Amount
+
+	// MaxHTLC is the largest HTLC the node will forward across the
+	// channel. A value of zero indicates that there's no maximum.
+	MaxHTLC btcutil.Amount
+
+	// FeeBase is the flat fee charged for forwarding an HTLC across the
+	// channel.
+	FeeBase btcutil.Amount
+
+	// FeeRate is the proportional fee charged for forwarding an HTLC
+	// across the channel, in millionths of the forwarded amount.
+	FeeRate uint32
+}
+
+// A compile time check to ensure ChannelUpdateAnnouncement implements the
+// lnwire.Message interface.
+var _ Message = (*ChannelUpdateAnnouncement)(nil)
+
+// Decode deserializes a serialized ChannelUpdateAnnouncement stored in the
+// passed io.Reader observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelUpdateAnnouncement) Decode(r io.Reader, pver uint32) error {
+	// Signature (73)
+	// ChannelPoint (36)
+	// Timestamp (4)
+	// NodeID (33)
+	// TimeLockDelta (2)
+	// MinHTLC (8)
+	// MaxHTLC (8)
+	// FeeBase (8)
+	// FeeRate (4)
+	err := readElements(r,
+		&c.Signature,
+		&c.ChannelPoint,
+		&c.Timestamp,
+		&c.NodeID,
+		&c.TimeLockDelta,
+		&c.MinHTLC,
+		&c.MaxHTLC,
+		&c.FeeBase,
+		&c.FeeRate)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Encode serializes the target ChannelUpdateAnnouncement into the passed
+// io.Writer observing the protocol version specified.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelUpdateAnnouncement) Encode(w io.Writer, pver uint32) error {
+	if err := writeElement(w, c.Signature); err != nil {
+		return err
+	}
+
+	return c.encodeSignedData(w)
+}
+
+// encodeSignedData serializes all the fields of the announcement which are
+// covered by its signature.
+func (c *ChannelUpdateAnnouncement) encodeSignedData(w io.Writer) error {
+	return writeElements(w,
+		c.ChannelPoint,
+		c.Timestamp,
+		c.NodeID,
+		c.TimeLockDelta,
+		c.MinHTLC,
+		c.MaxHTLC,
+		c.FeeBase,
+		c.FeeRate)
+}
+
+// DataToSign returns the double-sha256 digest of the announcement which the
+// signature must commit to.
+func (c *ChannelUpdateAnnouncement) DataToSign() ([]byte, error) {
+	var b bytes.Buffer
+	if err := c.encodeSignedData(&b); err != nil {
+		return nil, err
+	}
+
+	return wire.DoubleSha256(b.Bytes()), nil
+}
+
+// Command returns the integer uniquely identifying this message type on the
+// wire.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelUpdateAnnouncement) Command() uint32 {
+	return CmdChannelUpdateAnnouncement
+}
+
+// MaxPayloadLength returns the maximum allowed payload size for this message
+// observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelUpdateAnnouncement) MaxPayloadLength(pver uint32) uint32 {
+	// 74 + 36 + 4 + 33 + 2 + 8 + 8 + 8 + 4
+	return 177
+}
+
+// Validate performs any necessary sanity checks to ensure all fields present
+// on the ChannelUpdateAnnouncement are valid. The signature itself is
+// verified by the receiver.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelUpdateAnnouncement) Validate() error {
+	if c.Signature == nil {
+		return fmt.Errorf("signature must be set")
+	}
+	if c.ChannelPoint == nil {
+		return fmt.Errorf("channel point must be set")
+	}
+	if c.NodeID == nil {
+		return fmt.Errorf("node ID must be set")
+	}
+	if c.MinHTLC < 0 || c.MaxHTLC < 0 || c.FeeBase < 0 {
+		return fmt.Errorf("policy amounts must be positive")
+	}
+	if c.MaxHTLC != 0 && c.MaxHTLC < c.MinHTLC {
+		return fmt.Errorf("max HTLC of %v is below min HTLC of %v",
+			c.MaxHTLC, c.MinHTLC)
+	}
+
+	// We're good!
+	return nil
+}
+
+// String returns the string representation of the target
+// ChannelUpdateAnnouncement.
+//
+// This is part of the lnwire.Message interface.
+func (c *ChannelUpdateAnnouncement) String() string {
+	var nodeID []byte
+	if c.NodeID != nil {
+		nodeID = c.NodeID.SerializeCompressed()
+	}
+
+	return fmt.Sprintf("\n--- Begin ChannelUpdateAnnouncement ---\n") +
+		fmt.Sprintf("ChannelPoint:\t\t%v\n", c.ChannelPoint) +
+		fmt.Sprintf("NodeID:\t\t\t%x\n", nodeID) +
+		fmt.Sprintf("Timestamp:\t\t%v\n", c.Timestamp) +
+		fmt.Sprintf("TimeLockDelta:\t\t%v\n", c.TimeLockDelta) +
+		fmt.Sprintf("MinHTLC:\t\t%v\n", c.MinHTLC) +
+		fmt.Sprintf("MaxHTLC:\t\t%v\n", c.MaxHTLC) +
+		fmt.Sprintf("FeeBase:\t\t%v\n", c.FeeBase) +
+		fmt.Sprintf("FeeRate:\t\t%v\n", c.FeeRate) +
+		fmt.Sprintf("--- End ChannelUpdateAnnouncement ---\n")
+}
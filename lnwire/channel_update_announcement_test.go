@@ -0,0 +1,57 @@
+package lnwire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestChannelUpdateAnnouncementEncodeDecode(t *testing.T) {
+	cua := &ChannelUpdateAnnouncement{
+		ChannelPoint:  outpoint1,
+		Timestamp:     1466000000,
+		NodeID:        pubKey,
+		TimeLockDelta: 144,
+		MinHTLC:       1000,
+		MaxHTLC:       1e7,
+		FeeBase:       10,
+		FeeRate:       500,
+	}
+	digest, err := cua.DataToSign()
+	if err != nil {
+		t.Fatalf("unable to compute digest: %v", err)
+	}
+	cua.Signature, err = privKey.Sign(digest)
+	if err != nil {
+		t.Fatalf("unable to sign announcement: %v", err)
+	}
+
+	if err := cua.Validate(); err != nil {
+		t.Fatalf("valid announcement failed validation: %v", err)
+	}
+
+	// Next encode the CUA message into an empty bytes buffer.
+	var b bytes.Buffer
+	if err := cua.Encode(&b, 0); err != nil {
+		t.Fatalf("unable to encode ChannelUpdateAnnouncement: %v", err)
+	}
+
+	// Deserialize the encoded CUA message into a new empty struct.
+	cua2 := &ChannelUpdateAnnouncement{}
+	if err := cua2.Decode(&b, 0); err != nil {
+		t.Fatalf("unable to decode ChannelUpdateAnnouncement: %v", err)
+	}
+
+	// Assert equality of the two instances.
+	if !reflect.DeepEqual(cua, cua2) {
+		t.Fatalf("encode/decode error messages don't match %#v vs %#v",
+			cua, cua2)
+	}
+
+	// A maximum HTLC below the minimum should fail validation.
+	cua2.MaxHTLC = cua2.MinHTLC - 1
+	if err := cua2.Validate(); err == nil {
+		t.Fatalf("announcement with max HTLC below min HTLC passed " +
+			"validation")
+	}
+}
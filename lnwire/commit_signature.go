@@ -10,8 +10,7 @@ import (
 )
 
 // CommitSignature is sent by either side to stage any pending HTLC's in the
-// reciever's pending set which has not explcitly been rejected via an
-// HTLCAddReject message. Implictly, the new commitment transaction constructed
+// reciever's pending set. Implictly, the new commitment transaction constructed
 // which has been signed by CommitSig includes all HTLC's in the remote node's
 // pending set. A CommitSignature message may be sent after a series of HTLCAdd
 // messages in order to batch add several HTLC's with a single signature
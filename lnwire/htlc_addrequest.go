@@ -27,8 +27,8 @@ type HTLCAddRequest struct {
 	// Amount to pay in the hop
 	// Difference between hop and first item in blob is the fee to complete
 
-	// Amount is the number of credits this HTLC is worth.
-	Amount CreditsAmount
+	// Amount is the number of milli-satoshis this HTLC is worth.
+	Amount MilliSatoshi
 
 	// RefundContext is for payment cancellation
 	// TODO(j): not currently in use, add later
@@ -37,14 +37,14 @@ type HTLCAddRequest struct {
 	// ContractType defines the particular output script to be used for
 	// this HTLC. This value defaults to zero for regular HTLCs. For
 	// multi-sig HTLCs, then first 4 bit represents N, while the second 4
-	// bits are M, within the N-of-M multi-sig.
+	// bits are M, within the N-of-M multi-sig. See NewMultiHashContract.
 	ContractType uint8
 
 	// RedemptionHashes are the hashes to be used within the HTLC script.
 	// An HTLC is only fufilled once Bob is provided with the required
 	// number of pre-images for each of the listed hashes. For regular HTLC's
 	// this slice only has one hash. However, for "multi-sig" HTLC's, the
-	// length of this slice should be N.
+	// length of this slice should be M, N of whose pre-images are required.
 	RedemptionHashes [][32]byte
 
 	// OnionBlob is the raw serialized mix header used to route an HTLC in
@@ -60,6 +60,29 @@ type HTLCAddRequest struct {
 	OnionBlob []byte
 }
 
+// MaxRedemptionHashes is the maximum number of redemption hashes a
+// "multi-sig" HTLC may list, as M is encoded within four bits of its
+// ContractType.
+const MaxRedemptionHashes = 15
+
+// NewMultiHashContract returns the ContractType of an N-of-M "multi-sig"
+// HTLC, which is only fulfilled once the pre-images of n of its m redemption
+// hashes are presented.
+func NewMultiHashContract(n, m uint8) uint8 {
+	return n<<4 | m&0x0f
+}
+
+// ParseContractType returns the number of pre-images required to fulfill an
+// HTLC of the passed ContractType, along with the number of redemption hashes
+// it lists. Regular HTLCs require the pre-image of their single hash.
+func ParseContractType(contractType uint8) (uint8, uint8) {
+	if contractType == 0 {
+		return 1, 1
+	}
+
+	return contractType >> 4, contractType & 0x0f
+}
+
 // NewHTLCAddRequest returns a new empty HTLCAddRequest message.
 func NewHTLCAddRequest() *HTLCAddRequest {
 	return &HTLCAddRequest{}
@@ -76,7 +99,7 @@ var _ Message = (*HTLCAddRequest)(nil)
 func (c *HTLCAddRequest) Decode(r io.Reader, pver uint32) error {
 	// ChannelPoint(8)
 	// Expiry(4)
-	// Amount(4)
+	// Amount(8)
 	// ContractType(1)
 	// RedemptionHashes (numOfHashes * 32 + numOfHashes)
 	// OnionBlog
@@ -143,6 +166,27 @@ func (c *HTLCAddRequest) Validate() error {
 		// negative payments. Maybe for some wallets, but not this one!
 		return fmt.Errorf("Amount paid cannot be negative.")
 	}
+
+	// The redemption hashes must match the N-of-M contract type, and be
+	// distinct, otherwise a single pre-image could be counted twice.
+	n, m := ParseContractType(c.ContractType)
+	if n == 0 || n > m {
+		return fmt.Errorf("invalid contract type %v, requires %v of %v "+
+			"pre-images", c.ContractType, n, m)
+	}
+	if len(c.RedemptionHashes) != int(m) {
+		return fmt.Errorf("contract type %v requires %v redemption "+
+			"hashes, got %v", c.ContractType, m,
+			len(c.RedemptionHashes))
+	}
+	seen := make(map[[32]byte]struct{}, len(c.RedemptionHashes))
+	for _, hash := range c.RedemptionHashes {
+		if _, ok := seen[hash]; ok {
+			return fmt.Errorf("duplicate redemption hash %x", hash)
+		}
+		seen[hash] = struct{}{}
+	}
+
 	// We're good!
 	return nil
 }
@@ -160,7 +204,7 @@ func (c *HTLCAddRequest) String() string {
 	return fmt.Sprintf("\n--- Begin HTLCAddRequest ---\n") +
 		fmt.Sprintf("ChannelPoint:\t%v\n", c.ChannelPoint) +
 		fmt.Sprintf("Expiry:\t\t%d\n", c.Expiry) +
-		fmt.Sprintf("Amount\t\t%d\n", c.Amount) +
+		fmt.Sprintf("Amount\t\t%v\n", c.Amount) +
 		fmt.Sprintf("ContractType:\t%d (%b)\n", c.ContractType, c.ContractType) +
 		fmt.Sprintf("RedemptionHashes:") +
 		redemptionHashes +
@@ -14,7 +14,7 @@ func TestHTLCAddRequestEncodeDecode(t *testing.T) {
 	addReq := &HTLCAddRequest{
 		ChannelPoint:     outpoint1,
 		Expiry:           uint32(144),
-		Amount:           CreditsAmount(123456000),
+		Amount:           MilliSatoshi(123456789),
 		ContractType:     uint8(17),
 		RedemptionHashes: redemptionHashes,
 		OnionBlob:        []byte{255, 0, 255, 0, 255, 0, 255, 0},
@@ -38,3 +38,45 @@ func TestHTLCAddRequestEncodeDecode(t *testing.T) {
 			addReq, addReq2)
 	}
 }
+
+func TestHTLCAddRequestValidateContractType(t *testing.T) {
+	hashes := [][32]byte{{1}, {2}, {3}}
+
+	tests := []struct {
+		contractType uint8
+		hashes       [][32]byte
+		valid        bool
+	}{
+		// A regular HTLC lists a single hash.
+		{0, hashes[:1], true},
+		{0, hashes[:2], false},
+
+		// A 2-of-3 HTLC lists exactly three distinct hashes.
+		{NewMultiHashContract(2, 3), hashes, true},
+		{NewMultiHashContract(2, 3), hashes[:2], false},
+		{NewMultiHashContract(2, 3), [][32]byte{{1}, {1}, {2}}, false},
+
+		// N must be non-zero, and can't exceed M.
+		{NewMultiHashContract(0, 3), hashes, false},
+		{NewMultiHashContract(4, 3), hashes, false},
+	}
+	for i, test := range tests {
+		addReq := &HTLCAddRequest{
+			Amount:           MilliSatoshi(1000),
+			ContractType:     test.contractType,
+			RedemptionHashes: test.hashes,
+		}
+		err := addReq.Validate()
+		if test.valid && err != nil {
+			t.Fatalf("test #%v: valid HTLC rejected: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Fatalf("test #%v: invalid HTLC accepted", i)
+		}
+	}
+
+	n, m := ParseContractType(NewMultiHashContract(2, 3))
+	if n != 2 || m != 3 {
+		t.Fatalf("expected 2-of-3 contract, got %v-of-%v", n, m)
+	}
+}
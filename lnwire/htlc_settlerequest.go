@@ -104,6 +104,14 @@ func (c *HTLCSettleRequest) MaxPayloadLength(uint32) uint32 {
 //
 // This is part of the lnwire.Message interface.
 func (c *HTLCSettleRequest) Validate() error {
+	if len(c.RedemptionProofs) == 0 {
+		return fmt.Errorf("at least one redemption proof is required")
+	}
+	if len(c.RedemptionProofs) > MaxRedemptionHashes {
+		return fmt.Errorf("%v redemption proofs exceeds maximum of %v",
+			len(c.RedemptionProofs), MaxRedemptionHashes)
+	}
+
 	// We're good!
 	return nil
 }
@@ -7,6 +7,86 @@ import (
 	"github.com/roasbeef/btcd/wire"
 )
 
+// FailCode describes why an HTLC was cancelled before reaching, or being
+// settled by its final destination.
+type FailCode uint16
+
+const (
+	// FailCodeNone indicates that no reason was given for the
+	// cancellation, e.g. the HTLC simply expired.
+	FailCodeNone FailCode = 0
+
+	// FailCodeUnknownNextPeer indicates that the erring node has no
+	// active link to the next node within the route.
+	FailCodeUnknownNextPeer FailCode = 1
+
+	// FailCodeChannelPolicy indicates that the HTLC violated the
+	// forwarding policy of the erring node's outgoing channel.
+	FailCodeChannelPolicy FailCode = 2
+
+	// FailCodeInsufficientCapacity indicates that the erring node's
+	// outgoing channel lacked the bandwidth to carry the HTLC.
+	FailCodeInsufficientCapacity FailCode = 3
+
+	// FailCodeUnknownPaymentHash indicates that the final node of the
+	// route has no invoice for the HTLC's payment hash.
+	FailCodeUnknownPaymentHash FailCode = 4
+
+	// FailCodeTemporaryNodeFailure indicates that the erring node was
+	// unable to process the HTLC.
+	FailCodeTemporaryNodeFailure FailCode = 5
+
+	// FailCodeMPPTimeout indicates that the final node gave up waiting
+	// for the remaining shards of a multi-path payment to arrive.
+	FailCodeMPPTimeout FailCode = 6
+
+	// FailCodeFinalExpiryTooSoon indicates that the HTLC arriving at the
+	// final node expired too soon for the node to safely settle it.
+	FailCodeFinalExpiryTooSoon FailCode = 7
+
+	// FailCodeInvoiceExpired indicates that the invoice paid by the HTLC
+	// has expired.
+	FailCodeInvoiceExpired FailCode = 8
+
+	// FailCodeInvoiceAlreadyPaid indicates that the invoice paid by the
+	// HTLC has already been settled, and can't be paid again.
+	FailCodeInvoiceAlreadyPaid FailCode = 9
+
+	// FailCodeInvalidOnion indicates that the erring node was unable to
+	// decode the routing payload of the HTLC.
+	FailCodeInvalidOnion FailCode = 10
+)
+
+// String returns a human readable representation of the FailCode.
+func (f FailCode) String() string {
+	switch f {
+	case FailCodeNone:
+		return "None"
+	case FailCodeUnknownNextPeer:
+		return "UnknownNextPeer"
+	case FailCodeChannelPolicy:
+		return "ChannelPolicy"
+	case FailCodeInsufficientCapacity:
+		return "InsufficientCapacity"
+	case FailCodeUnknownPaymentHash:
+		return "UnknownPaymentHash"
+	case FailCodeTemporaryNodeFailure:
+		return "TemporaryNodeFailure"
+	case FailCodeMPPTimeout:
+		return "MPPTimeout"
+	case FailCodeFinalExpiryTooSoon:
+		return "FinalExpiryTooSoon"
+	case FailCodeInvoiceExpired:
+		return "InvoiceExpired"
+	case FailCodeInvoiceAlreadyPaid:
+		return "InvoiceAlreadyPaid"
+	case FailCodeInvalidOnion:
+		return "InvalidOnion"
+	default:
+		return "Unknown"
+	}
+}
+
 // HTLCTimeoutRequest is sent by Alice to Bob in order to timeout a previously
 // added HTLC. Upon receipt of an HTLCTimeoutRequest the HTLC should be removed
 // from the next commitment transaction, with the HTLCTimeoutRequest propgated
@@ -19,6 +99,14 @@ type HTLCTimeoutRequest struct {
 	// HTLCKey references which HTLC on the remote node's commitment
 	// transaction has timed out.
 	HTLCKey HTLCKey
+
+	// FailCode describes why the HTLC was cancelled.
+	FailCode FailCode
+
+	// ErringNode is the lightning ID of the node which cancelled the
+	// HTLC. It's propagated unchanged back to the sender of the payment,
+	// allowing the sender to avoid the failing hop in further attempts.
+	ErringNode [32]byte
 }
 
 // Decode deserializes a serialized HTLCTimeoutRequest message stored in the passed
@@ -28,13 +116,19 @@ type HTLCTimeoutRequest struct {
 func (c *HTLCTimeoutRequest) Decode(r io.Reader, pver uint32) error {
 	// ChannelPoint(8)
 	// HTLCKey(8)
+	// FailCode(2)
+	// ErringNode(32)
+	var failCode uint16
 	err := readElements(r,
 		&c.ChannelPoint,
 		&c.HTLCKey,
+		&failCode,
+		&c.ErringNode,
 	)
 	if err != nil {
 		return err
 	}
+	c.FailCode = FailCode(failCode)
 
 	return nil
 }
@@ -56,6 +150,8 @@ func (c *HTLCTimeoutRequest) Encode(w io.Writer, pver uint32) error {
 	err := writeElements(w,
 		c.ChannelPoint,
 		c.HTLCKey,
+		uint16(c.FailCode),
+		c.ErringNode,
 	)
 	if err != nil {
 		return err
@@ -77,8 +173,8 @@ func (c *HTLCTimeoutRequest) Command() uint32 {
 //
 // This is part of the lnwire.Message interface.
 func (c *HTLCTimeoutRequest) MaxPayloadLength(uint32) uint32 {
-	// 36 + 8
-	return 44
+	// 36 + 8 + 2 + 32
+	return 78
 }
 
 // Validate performs any necessary sanity checks to ensure all fields present
@@ -96,5 +192,7 @@ func (c *HTLCTimeoutRequest) String() string {
 	return fmt.Sprintf("\n--- Begin HTLCTimeoutRequest ---\n") +
 		fmt.Sprintf("ChannelPoint:\t%d\n", c.ChannelPoint) +
 		fmt.Sprintf("HTLCKey:\t%d\n", c.HTLCKey) +
+		fmt.Sprintf("FailCode:\t%v\n", c.FailCode) +
+		fmt.Sprintf("ErringNode:\t%x\n", c.ErringNode[:]) +
 		fmt.Sprintf("--- End HTLCTimeoutRequest ---\n")
 }
@@ -11,6 +11,8 @@ func TestHTLCTimeoutRequestEncodeDecode(t *testing.T) {
 	timeoutReq := &HTLCTimeoutRequest{
 		ChannelPoint: outpoint1,
 		HTLCKey:      22,
+		FailCode:     FailCodeChannelPolicy,
+		ErringNode:   [32]byte{0x01, 0x02},
 	}
 
 	// Next encode the HTLCTR message into an empty bytes buffer.
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"net"
 
 	"github.com/roasbeef/btcd/btcec"
 	"github.com/roasbeef/btcd/txscript"
@@ -16,6 +17,10 @@ import (
 // the wire protocol.
 const MaxSliceLength = 65535
 
+// MaxNodeAddresses is the maximum number of addresses a node may advertise
+// within a NodeAnnouncement.
+const MaxNodeAddresses = 8
+
 // PkScript is simple type definition which represents a raw serialized public
 // key script.
 type PkScript []byte
@@ -36,32 +41,6 @@ type HTLCKey int64
 // HTLCReject messages referencing a particular HTLCKey.
 type CommitHeight uint64
 
-// CreditsAmount are the native currency unit used within the Lightning Network.
-// Credits are denominated in sub-satoshi amounts, so micro-satoshis (1/1000).
-// This value is purposefully signed in order to allow the expression of negative
-// fees.
-//
-// "In any science-fiction movie, anywhere in the galaxy, currency is referred
-// to as 'credits.'"
-// 	--Sam Humphries. Ebert, Roger (1999). Ebert's bigger little movie
-// 	glossary. Andrews McMeel. p. 172.
-//
-// https://en.wikipedia.org/wiki/List_of_fictional_currencies
-// https://en.wikipedia.org/wiki/Fictional_currency#Trends_in_the_use_of_fictional_currencies
-// http://tvtropes.org/pmwiki/pmwiki.php/Main/WeWillSpendCreditsInTheFuture
-// US Display format: 1 BTC = 100,000,000'000 XCB
-// Or in BTC = 1.00000000'000
-// Credits (XCB, accountants should use XCB :^)
-type CreditsAmount int64
-
-// ToSatoshi converts an amount in Credits to the coresponding amount
-// expressed in Satoshis.
-//
-// NOTE: This function rounds down by default (floor).
-func (c CreditsAmount) ToSatoshi() int64 {
-	return int64(c / 1000)
-}
-
 // writeElement is a one-stop shop to write the big endian representation of
 // any element which is to be serialized for the wire protocol. The passed
 // io.Writer should be backed by an appropriatly sized byte slice, or be able
@@ -84,7 +63,7 @@ func writeElement(w io.Writer, element interface{}) error {
 		if _, err := w.Write(b[:]); err != nil {
 			return err
 		}
-	case CreditsAmount:
+	case MilliSatoshi:
 		if err := binary.Write(w, binary.BigEndian, int64(e)); err != nil {
 			return err
 		}
@@ -223,6 +202,32 @@ func writeElement(w io.Writer, element interface{}) error {
 		if err := wire.WriteVarString(w, 0, e); err != nil {
 			return err
 		}
+	case []*net.TCPAddr:
+		// Enforce the maximum number of addresses a node may announce.
+		if len(e) > MaxNodeAddresses {
+			return fmt.Errorf("Too many addresses")
+		}
+		if err := writeElement(w, uint8(len(e))); err != nil {
+			return err
+		}
+
+		// Each address is written as its IP address, prefixed by its
+		// length, followed by the port.
+		for _, addr := range e {
+			ip := addr.IP.To4()
+			if ip == nil {
+				ip = addr.IP.To16()
+			}
+			if ip == nil {
+				return fmt.Errorf("Invalid IP address: %v", addr.IP)
+			}
+			if err := wire.WriteVarBytes(w, 0, ip); err != nil {
+				return err
+			}
+			if err := writeElement(w, uint16(addr.Port)); err != nil {
+				return err
+			}
+		}
 	case []*wire.TxIn:
 		// Write the size (1-byte)
 		if len(e) > 127 {
@@ -308,12 +313,12 @@ func readElement(r io.Reader, element interface{}) error {
 			return err
 		}
 		*e = binary.BigEndian.Uint16(b[:])
-	case *CreditsAmount:
+	case *MilliSatoshi:
 		var b [8]byte
 		if _, err := io.ReadFull(r, b[:]); err != nil {
 			return err
 		}
-		*e = CreditsAmount(int64(binary.BigEndian.Uint64(b[:])))
+		*e = MilliSatoshi(int64(binary.BigEndian.Uint64(b[:])))
 	case *uint32:
 		var b [4]byte
 		if _, err := io.ReadFull(r, b[:]); err != nil {
@@ -456,6 +461,37 @@ func readElement(r io.Reader, element interface{}) error {
 			return err
 		}
 		*e = str
+	case *[]*net.TCPAddr:
+		var numAddrs uint8
+		if err := readElement(r, &numAddrs); err != nil {
+			return err
+		}
+		if numAddrs > MaxNodeAddresses {
+			return fmt.Errorf("Too many addresses")
+		}
+
+		addrs := make([]*net.TCPAddr, 0, numAddrs)
+		for i := uint8(0); i < numAddrs; i++ {
+			ip, err := wire.ReadVarBytes(r, 0, net.IPv6len, "ip")
+			if err != nil {
+				return err
+			}
+			if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+				return fmt.Errorf("Invalid IP address length: %v",
+					len(ip))
+			}
+
+			var port uint16
+			if err := readElement(r, &port); err != nil {
+				return err
+			}
+
+			addrs = append(addrs, &net.TCPAddr{
+				IP:   net.IP(ip),
+				Port: int(port),
+			})
+		}
+		*e = addrs
 	case *[]*wire.TxIn:
 		// Read the size (1-byte number of txins)
 		var numScripts uint8
@@ -42,13 +42,13 @@ const (
 	// Commands for negotiating HTLCs.
 	CmdHTLCAddRequest     = uint32(1000)
 	CmdHTLCAddAccept      = uint32(1010)
-	CmdHTLCAddReject      = uint32(1020)
 	CmdHTLCSettleRequest  = uint32(1100)
 	CmdHTLCTimeoutRequest = uint32(1300)
 
 	// Commands for modifying commitment transactions.
 	CmdCommitSignature  = uint32(2000)
 	CmdCommitRevocation = uint32(2010)
+	CmdUpdateFee        = uint32(2020)
 
 	// Commands for routing
 	CmdNeighborHelloMessage        = uint32(3000)
@@ -58,6 +58,12 @@ const (
 	CmdRoutingTableRequestMessage  = uint32(3040)
 	CmdRoutingTableTransferMessage = uint32(3050)
 
+	// Commands for announcing authenticated channels, and nodes.
+	CmdChannelAnnouncement       = uint32(3100)
+	CmdNodeAnnouncement          = uint32(3110)
+	CmdChannelUpdateAnnouncement = uint32(3120)
+	CmdAnnounceSignatures        = uint32(3130)
+
 	// Commands for reporting protocol errors.
 	CmdErrorGeneric = uint32(4000)
 )
@@ -104,8 +110,6 @@ func makeEmptyMessage(command uint32) (Message, error) {
 		msg = &CloseComplete{}
 	case CmdHTLCAddRequest:
 		msg = &HTLCAddRequest{}
-	case CmdHTLCAddReject:
-		msg = &HTLCAddReject{}
 	case CmdHTLCSettleRequest:
 		msg = &HTLCSettleRequest{}
 	case CmdHTLCTimeoutRequest:
@@ -114,6 +118,8 @@ func makeEmptyMessage(command uint32) (Message, error) {
 		msg = &CommitSignature{}
 	case CmdCommitRevocation:
 		msg = &CommitRevocation{}
+	case CmdUpdateFee:
+		msg = &UpdateFee{}
 	case CmdErrorGeneric:
 		msg = &ErrorGeneric{}
 	case CmdNeighborHelloMessage:
@@ -128,6 +134,14 @@ func makeEmptyMessage(command uint32) (Message, error) {
 		msg = &RoutingTableRequestMessage{}
 	case CmdRoutingTableTransferMessage:
 		msg = &RoutingTableTransferMessage{}
+	case CmdChannelAnnouncement:
+		msg = &ChannelAnnouncement{}
+	case CmdNodeAnnouncement:
+		msg = &NodeAnnouncement{}
+	case CmdChannelUpdateAnnouncement:
+		msg = &ChannelUpdateAnnouncement{}
+	case CmdAnnounceSignatures:
+		msg = &AnnounceSignatures{}
 	default:
 		return nil, fmt.Errorf("unhandled command [%d]", command)
 	}
@@ -0,0 +1,44 @@
+package lnwire
+
+import (
+	"fmt"
+
+	"github.com/roasbeef/btcutil"
+)
+
+// mSatScale is the number of milli-satoshis within a single satoshi.
+const mSatScale = 1000
+
+// MilliSatoshi are the native unit of the Lightning Network. A milli-satoshi
+// is one thousandth of a satoshi. Amounts within HTLCs and channel balances
+// are tracked in milli-satoshis so small payments, and proportional fees
+// aren't rounded away, while on-chain outputs are rounded down to whole
+// satoshis only once a transaction is constructed. Like btcutil.Amount, this
+// value is signed so differences between amounts, such as fees, may be
+// expressed directly.
+type MilliSatoshi int64
+
+// NewMSatFromSatoshis creates a new MilliSatoshi instance from a target amount
+// of satoshis.
+func NewMSatFromSatoshis(sat btcutil.Amount) MilliSatoshi {
+	return MilliSatoshi(int64(sat) * mSatScale)
+}
+
+// ToSatoshis converts the target MilliSatoshi amount to satoshis.
+//
+// NOTE: This function rounds down by default (floor).
+func (m MilliSatoshi) ToSatoshis() btcutil.Amount {
+	return btcutil.Amount(int64(m) / mSatScale)
+}
+
+// ToBTC converts the target MilliSatoshi amount to its corresponding value
+// when expressed in BTC.
+func (m MilliSatoshi) ToBTC() float64 {
+	sat := m.ToSatoshis()
+	return sat.ToBTC()
+}
+
+// String returns the string representation of the mSAT amount.
+func (m MilliSatoshi) String() string {
+	return fmt.Sprintf("%v mSAT", int64(m))
+}
@@ -0,0 +1,47 @@
+package lnwire
+
+import (
+	"testing"
+
+	"github.com/roasbeef/btcutil"
+)
+
+func TestMilliSatoshiConversion(t *testing.T) {
+	tests := []struct {
+		mSatAmount MilliSatoshi
+		satAmount  btcutil.Amount
+	}{
+		{
+			mSatAmount: 0,
+			satAmount:  0,
+		},
+		{
+			mSatAmount: 999,
+			satAmount:  0,
+		},
+		{
+			mSatAmount: 1000,
+			satAmount:  1,
+		},
+		{
+			mSatAmount: 123456789,
+			satAmount:  123456,
+		},
+	}
+
+	for i, test := range tests {
+		// Conversions to satoshis should round down.
+		if test.mSatAmount.ToSatoshis() != test.satAmount {
+			t.Fatalf("#%v: wrong sat amount, expected %v got %v", i,
+				test.satAmount, test.mSatAmount.ToSatoshis())
+		}
+
+		// Converting the satoshi amount back should yield the mSAT
+		// amount without its sub-satoshi remainder.
+		expected := test.mSatAmount - test.mSatAmount%1000
+		if NewMSatFromSatoshis(test.satAmount) != expected {
+			t.Fatalf("#%v: wrong mSAT amount, expected %v got %v",
+				i, expected, NewMSatFromSatoshis(test.satAmount))
+		}
+	}
+}
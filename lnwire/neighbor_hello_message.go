@@ -5,48 +5,78 @@
 package lnwire
 
 import (
-	"io"
-	"github.com/BitfuryLightning/tools/rt"
-	"encoding/gob"
 	"fmt"
+	"io"
 )
 
-type NeighborHelloMessage struct{
+// NeighborHelloMessage is sent to a newly connected neighbor, and carries our
+// complete routing table.
+type NeighborHelloMessage struct {
 	RoutingMessageBase
-	RT *rt.RoutingTable
+
+	// Channels is the list of all channels within the sender's routing
+	// table. Only ChannelAdd operations are allowed.
+	Channels []ChannelOperation
 }
 
-func (msg *NeighborHelloMessage) Decode(r io.Reader, pver uint32) error{
-	decoder := gob.NewDecoder(r)
-	rt1 := rt.NewRoutingTable()
-	err := decoder.Decode(rt1.G)
-	msg.RT = rt1
-	return err
+// A compile time check to ensure NeighborHelloMessage implements the
+// lnwire.Message interface.
+var _ Message = (*NeighborHelloMessage)(nil)
+
+// Decode deserializes a serialized NeighborHelloMessage stored in the
+// passed io.Reader observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborHelloMessage) Decode(r io.Reader, pver uint32) error {
+	// NumOperations (2)
+	// Operations (109 each)
+	ops, err := readChannelOperations(r)
+	if err != nil {
+		return err
+	}
+	msg.Channels = ops
+
+	return nil
 }
 
-func (msg *NeighborHelloMessage) Encode(w io.Writer, pver uint32) error{
-	encoder := gob.NewEncoder(w)
-	err := encoder.Encode(msg.RT.G)
-	return err
+// Encode serializes the target NeighborHelloMessage into the passed
+// io.Writer observing the protocol version specified.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborHelloMessage) Encode(w io.Writer, pver uint32) error {
+	return writeChannelOperations(w, msg.Channels)
 }
 
-func (msg *NeighborHelloMessage) Command() uint32{
+// Command returns the integer uniquely identifying this message type on the
+// wire.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborHelloMessage) Command() uint32 {
 	return CmdNeighborHelloMessage
 }
 
-func (msg *NeighborHelloMessage) MaxPayloadLength(uint32) uint32{
-	// TODO: Insert some estimations
-	return 1000000
+// MaxPayloadLength returns the maximum allowed payload size for this message
+// observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborHelloMessage) MaxPayloadLength(uint32) uint32 {
+	// 2 + MaxChannelOperations*109
+	return maxChannelOperationsPayload
 }
 
-func (msg *NeighborHelloMessage) Validate() error{
-	// TODO: Add validation
-	return nil
+// Validate performs any necessary sanity checks to ensure all fields present
+// on the NeighborHelloMessage are valid.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborHelloMessage) Validate() error {
+	return validateChannelOperations(msg.Channels, true)
 }
 
-func (msg *NeighborHelloMessage) String() string{
-	return fmt.Sprintf("NeighborHelloMessage{%v %v %v}", msg.SenderID, msg.ReceiverID, msg.RT)
+// String returns the string representation of the target
+// NeighborHelloMessage.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborHelloMessage) String() string {
+	return fmt.Sprintf("NeighborHelloMessage{%v %v %v}", msg.SenderID,
+		msg.ReceiverID, msg.Channels)
 }
-
-
-var _  Message = (*NeighborHelloMessage)(nil)
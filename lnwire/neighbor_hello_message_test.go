@@ -5,37 +5,103 @@
 package lnwire
 
 import (
-	"testing"
 	"bytes"
-	"github.com/BitfuryLightning/tools/rt"
-	"github.com/BitfuryLightning/tools/rt/graph"
+	"reflect"
+	"testing"
 )
 
-func TestNeighborHelloMessageEncodeDecode(t *testing.T){
-	Id1 := graph.NewID(1)
-	Id2 := graph.NewID(2)
-	rt1 := rt.NewRoutingTable()
-	rt1.AddChannel(Id1, Id2, graph.NewEdgeID("1"), &rt.ChannelInfo{1, 1})
-	b := new(bytes.Buffer)
-	msg1 := NeighborHelloMessage{RT:rt1}
-	err := msg1.Encode(b, 0)
-	if err != nil{
-		t.Fatalf("Can't encode message ", err)
+func TestNeighborHelloMessageEncodeDecode(t *testing.T) {
+	msg1 := &NeighborHelloMessage{
+		Channels: []ChannelOperation{
+			{
+				NodeID1:      [32]byte{1},
+				NodeID2:      [32]byte{2},
+				ChannelPoint: outpoint1,
+				Capacity:     100000,
+				Operation:    ChannelAdd,
+			},
+			{
+				NodeID1:      [32]byte{2},
+				NodeID2:      [32]byte{1},
+				ChannelPoint: outpoint1,
+				Capacity:     100000,
+				Operation:    ChannelAdd,
+			},
+		},
 	}
-	msg2 := new(NeighborHelloMessage)
-	err = msg2.Decode(b, 0)
-	if err != nil{
-		t.Fatalf("Can't decode message ", err)
+	if err := msg1.Validate(); err != nil {
+		t.Fatalf("valid message failed validation: %v", err)
 	}
-	if msg2.RT == nil{
-		t.Fatal("After decoding RT should not be nil")
+
+	var b bytes.Buffer
+	if err := msg1.Encode(&b, 0); err != nil {
+		t.Fatalf("unable to encode NeighborHelloMessage: %v", err)
 	}
-	if !msg2.RT.HasChannel(Id1, Id2, nil){
-		t.Errorf("msg2.RT.HasChannel(Id1, Id2) = false, want true")
+	if uint32(b.Len()) != 2+2*channelOperationSize {
+		t.Fatalf("wrong encoded size: %v", b.Len())
+	}
+
+	msg2 := &NeighborHelloMessage{}
+	if err := msg2.Decode(&b, 0); err != nil {
+		t.Fatalf("unable to decode NeighborHelloMessage: %v", err)
 	}
-	if !msg2.RT.HasChannel(Id2, Id1, nil){
-		t.Errorf("msg2.RT.HasChannel(Id2, Id1) = false, want true")
+	if !reflect.DeepEqual(msg1, msg2) {
+		t.Fatalf("encode/decode error messages don't match %v vs %v",
+			msg1, msg2)
+	}
+
+	// A complete routing table may not contain channel removals.
+	msg1.Channels[0].Operation = ChannelRemove
+	if err := msg1.Validate(); err == nil {
+		t.Fatalf("routing table with channel removal passed validation")
 	}
 }
 
+func TestNeighborUpdMessageValidation(t *testing.T) {
+	validOp := ChannelOperation{
+		NodeID1:      [32]byte{1},
+		NodeID2:      [32]byte{2},
+		ChannelPoint: outpoint1,
+		Capacity:     100000,
+		Operation:    ChannelRemove,
+	}
+	msg := &NeighborUpdMessage{Updates: []ChannelOperation{validOp}}
+	if err := msg.Validate(); err != nil {
+		t.Fatalf("valid message failed validation: %v", err)
+	}
+
+	var b bytes.Buffer
+	if err := msg.Encode(&b, 0); err != nil {
+		t.Fatalf("unable to encode NeighborUpdMessage: %v", err)
+	}
+	msg2 := &NeighborUpdMessage{}
+	if err := msg2.Decode(&b, 0); err != nil {
+		t.Fatalf("unable to decode NeighborUpdMessage: %v", err)
+	}
+	if !reflect.DeepEqual(msg, msg2) {
+		t.Fatalf("encode/decode error messages don't match %v vs %v",
+			msg, msg2)
+	}
 
+	invalidOps := []ChannelOperation{validOp, validOp, validOp, validOp}
+	invalidOps[0].Operation = 7
+	invalidOps[1].ChannelPoint = nil
+	invalidOps[2].NodeID2 = invalidOps[2].NodeID1
+	invalidOps[3].Capacity = -1
+	for i, op := range invalidOps {
+		msg := &NeighborUpdMessage{Updates: []ChannelOperation{op}}
+		if err := msg.Validate(); err == nil {
+			t.Fatalf("invalid operation #%v passed validation", i)
+		}
+	}
+
+	// A message claiming to carry more than the maximum number of
+	// operations should be rejected before any of them are read.
+	var tooMany bytes.Buffer
+	if err := writeElement(&tooMany, uint16(MaxChannelOperations+1)); err != nil {
+		t.Fatalf("unable to write operation count: %v", err)
+	}
+	if err := msg2.Decode(&tooMany, 0); err == nil {
+		t.Fatalf("message with too many operations decoded")
+	}
+}
@@ -6,46 +6,77 @@ package lnwire
 
 import (
 	"fmt"
-	"github.com/BitfuryLightning/tools/rt"
-	"encoding/gob"
 	"io"
 )
 
+// NeighborUpdMessage carries the changes made to the sender's routing table
+// since its last update.
 type NeighborUpdMessage struct {
 	RoutingMessageBase
-	DiffBuff *rt.DifferenceBuffer
+
+	// Updates is the list of channels added to, or removed from the
+	// sender's routing table.
+	Updates []ChannelOperation
 }
 
-func (msg *NeighborUpdMessage) Decode(r io.Reader, pver uint32) error{
-	decoder := gob.NewDecoder(r)
-	diffBuff := new(rt.DifferenceBuffer)
-	err := decoder.Decode(diffBuff)
-	msg.DiffBuff = diffBuff
-	return err
+// A compile time check to ensure NeighborUpdMessage implements the
+// lnwire.Message interface.
+var _ Message = (*NeighborUpdMessage)(nil)
+
+// Decode deserializes a serialized NeighborUpdMessage stored in the
+// passed io.Reader observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborUpdMessage) Decode(r io.Reader, pver uint32) error {
+	// NumOperations (2)
+	// Operations (109 each)
+	ops, err := readChannelOperations(r)
+	if err != nil {
+		return err
+	}
+	msg.Updates = ops
+
+	return nil
 }
 
-func (msg *NeighborUpdMessage) Encode(w io.Writer, pver uint32) error{
-	encoder := gob.NewEncoder(w)
-	err := encoder.Encode(msg.DiffBuff)
-	return err
+// Encode serializes the target NeighborUpdMessage into the passed
+// io.Writer observing the protocol version specified.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborUpdMessage) Encode(w io.Writer, pver uint32) error {
+	return writeChannelOperations(w, msg.Updates)
 }
 
-func (msg *NeighborUpdMessage) Command() uint32{
+// Command returns the integer uniquely identifying this message type on the
+// wire.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborUpdMessage) Command() uint32 {
 	return CmdNeighborUpdMessage
 }
 
-func (msg *NeighborUpdMessage) MaxPayloadLength(uint32) uint32{
-	// TODO: Insert some estimations
-	return 1000000
+// MaxPayloadLength returns the maximum allowed payload size for this message
+// observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborUpdMessage) MaxPayloadLength(uint32) uint32 {
+	// 2 + MaxChannelOperations*109
+	return maxChannelOperationsPayload
 }
 
-func (msg *NeighborUpdMessage) Validate() error{
-	// TODO: Add validation
-	return nil
+// Validate performs any necessary sanity checks to ensure all fields present
+// on the NeighborUpdMessage are valid.
+//
+// This is part of the lnwire.Message interface.
+func (msg *NeighborUpdMessage) Validate() error {
+	return validateChannelOperations(msg.Updates, false)
 }
 
+// String returns the string representation of the target
+// NeighborUpdMessage.
+//
+// This is part of the lnwire.Message interface.
 func (msg *NeighborUpdMessage) String() string {
-	return fmt.Sprintf("NeighborUpdMessage{%v %v %v}", msg.SenderID, msg.ReceiverID, *msg.DiffBuff)
+	return fmt.Sprintf("NeighborUpdMessage{%v %v %v}", msg.SenderID,
+		msg.ReceiverID, msg.Updates)
 }
-
-var _  Message = (*NeighborUpdMessage)(nil)
\ No newline at end of file
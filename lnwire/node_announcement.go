@@ -0,0 +1,163 @@
+package lnwire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/wire"
+)
+
+// MaxAliasLength is the maximum length of the alias a node may advertise
+// within a NodeAnnouncement.
+const MaxAliasLength = 32
+
+// NodeAnnouncement is broadcast by a node in order to advertise how it can be
+// reached, along with a human readable alias. The announcement is signed by
+// the node's identity key, and each new announcement must carry a later
+// timestamp than the last in order to supersede it.
+type NodeAnnouncement struct {
+	// Signature is the signature of the node's identity key over the
+	// announcement.
+	Signature *btcec.Signature
+
+	// Timestamp is the unix timestamp at which the announcement was
+	// created.
+	Timestamp uint32
+
+	// NodeID is the identity key of the announcing node.
+	NodeID *btcec.PublicKey
+
+	// Addresses is the list of addresses the node accepts incoming
+	// connections on.
+	Addresses []*net.TCPAddr
+
+	// Alias is an optional human readable name for the node.
+	Alias string
+}
+
+// A compile time check to ensure NodeAnnouncement implements the
+// lnwire.Message interface.
+var _ Message = (*NodeAnnouncement)(nil)
+
+// Decode deserializes a serialized NodeAnnouncement stored in the passed
+// io.Reader observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (n *NodeAnnouncement) Decode(r io.Reader, pver uint32) error {
+	// Signature (73)
+	// Timestamp (4)
+	// NodeID (33)
+	// Addresses (1 + 8*(1+16+2))
+	// Alias (1 + 32)
+	err := readElements(r,
+		&n.Signature,
+		&n.Timestamp,
+		&n.NodeID,
+		&n.Addresses,
+		&n.Alias)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Encode serializes the target NodeAnnouncement into the passed io.Writer
+// observing the protocol version specified.
+//
+// This is part of the lnwire.Message interface.
+func (n *NodeAnnouncement) Encode(w io.Writer, pver uint32) error {
+	if err := writeElement(w, n.Signature); err != nil {
+		return err
+	}
+
+	return n.encodeSignedData(w)
+}
+
+// encodeSignedData serializes all the fields of the announcement which are
+// covered by its signature.
+func (n *NodeAnnouncement) encodeSignedData(w io.Writer) error {
+	return writeElements(w,
+		n.Timestamp,
+		n.NodeID,
+		n.Addresses,
+		n.Alias)
+}
+
+// DataToSign returns the double-sha256 digest of the announcement which the
+// signature must commit to.
+func (n *NodeAnnouncement) DataToSign() ([]byte, error) {
+	var b bytes.Buffer
+	if err := n.encodeSignedData(&b); err != nil {
+		return nil, err
+	}
+
+	return wire.DoubleSha256(b.Bytes()), nil
+}
+
+// Command returns the integer uniquely identifying this message type on the
+// wire.
+//
+// This is part of the lnwire.Message interface.
+func (n *NodeAnnouncement) Command() uint32 {
+	return CmdNodeAnnouncement
+}
+
+// MaxPayloadLength returns the maximum allowed payload size for this message
+// observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (n *NodeAnnouncement) MaxPayloadLength(pver uint32) uint32 {
+	// 74 + 4 + 33 + 153 + 33
+	return 297
+}
+
+// Validate performs any necessary sanity checks to ensure all fields present
+// on the NodeAnnouncement are valid. The signature itself is verified by the
+// receiver.
+//
+// This is part of the lnwire.Message interface.
+func (n *NodeAnnouncement) Validate() error {
+	if n.Signature == nil {
+		return fmt.Errorf("signature must be set")
+	}
+	if n.NodeID == nil {
+		return fmt.Errorf("node ID must be set")
+	}
+	if len(n.Addresses) > MaxNodeAddresses {
+		return fmt.Errorf("too many addresses: %v, max is %v",
+			len(n.Addresses), MaxNodeAddresses)
+	}
+	for _, addr := range n.Addresses {
+		if addr.Port == 0 || addr.Port > 65535 {
+			return fmt.Errorf("invalid port for address %v", addr)
+		}
+	}
+	if len(n.Alias) > MaxAliasLength {
+		return fmt.Errorf("alias too long: %v bytes, max is %v",
+			len(n.Alias), MaxAliasLength)
+	}
+
+	// We're good!
+	return nil
+}
+
+// String returns the string representation of the target NodeAnnouncement.
+//
+// This is part of the lnwire.Message interface.
+func (n *NodeAnnouncement) String() string {
+	var nodeID []byte
+	if n.NodeID != nil {
+		nodeID = n.NodeID.SerializeCompressed()
+	}
+
+	return fmt.Sprintf("\n--- Begin NodeAnnouncement ---\n") +
+		fmt.Sprintf("NodeID:\t\t%x\n", nodeID) +
+		fmt.Sprintf("Timestamp:\t%v\n", n.Timestamp) +
+		fmt.Sprintf("Addresses:\t%v\n", n.Addresses) +
+		fmt.Sprintf("Alias:\t\t%v\n", n.Alias) +
+		fmt.Sprintf("--- End NodeAnnouncement ---\n")
+}
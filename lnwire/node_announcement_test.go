@@ -0,0 +1,57 @@
+package lnwire
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNodeAnnouncementEncodeDecode(t *testing.T) {
+	na := &NodeAnnouncement{
+		Timestamp: 1466000000,
+		NodeID:    pubKey,
+		Addresses: []*net.TCPAddr{
+			{IP: net.ParseIP("127.0.0.1").To4(), Port: 10011},
+			{IP: net.ParseIP("2001:db8::1"), Port: 10012},
+		},
+		Alias: "satoshi",
+	}
+	digest, err := na.DataToSign()
+	if err != nil {
+		t.Fatalf("unable to compute digest: %v", err)
+	}
+	na.Signature, err = privKey.Sign(digest)
+	if err != nil {
+		t.Fatalf("unable to sign announcement: %v", err)
+	}
+
+	if err := na.Validate(); err != nil {
+		t.Fatalf("valid announcement failed validation: %v", err)
+	}
+
+	// Next encode the NA message into an empty bytes buffer.
+	var b bytes.Buffer
+	if err := na.Encode(&b, 0); err != nil {
+		t.Fatalf("unable to encode NodeAnnouncement: %v", err)
+	}
+
+	// Deserialize the encoded NA message into a new empty struct.
+	na2 := &NodeAnnouncement{}
+	if err := na2.Decode(&b, 0); err != nil {
+		t.Fatalf("unable to decode NodeAnnouncement: %v", err)
+	}
+
+	// Assert equality of the two instances.
+	if !reflect.DeepEqual(na, na2) {
+		t.Fatalf("encode/decode error messages don't match %#v vs %#v",
+			na, na2)
+	}
+
+	// An alias exceeding the maximum length should fail validation.
+	na2.Alias = strings.Repeat("a", MaxAliasLength+1)
+	if err := na2.Validate(); err == nil {
+		t.Fatalf("announcement with long alias passed validation")
+	}
+}
@@ -5,19 +5,39 @@
 package lnwire
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/BitfuryLightning/tools/rt/graph"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
 )
 
+// MaxChannelOperations is the maximum number of channel operations a single
+// routing message may carry. Larger routing tables must be split across
+// several messages.
+const MaxChannelOperations = 8192
+
+// channelOperationSize is the size of a serialized ChannelOperation:
+// 32 + 32 + 36 + 8 + 1.
+const channelOperationSize = 109
+
+// maxChannelOperationsPayload is the maximum payload of a routing message
+// carrying a list of channel operations: the 2-byte number of operations,
+// followed by the operations themselves.
+const maxChannelOperationsPayload = 2 +
+	MaxChannelOperations*channelOperationSize
+
 type RoutingMessageBase struct {
-	SenderID graph.ID
+	SenderID   graph.ID
 	ReceiverID graph.ID
 }
 
-func (msg RoutingMessageBase) GetReceiverID() graph.ID{
+func (msg RoutingMessageBase) GetReceiverID() graph.ID {
 	return msg.ReceiverID
 }
 
-func (msg RoutingMessageBase) GetSenderID() graph.ID{
+func (msg RoutingMessageBase) GetSenderID() graph.ID {
 	return msg.SenderID
 }
 
@@ -26,3 +46,154 @@ type RoutingMessage interface {
 	GetSenderID() graph.ID
 	GetReceiverID() graph.ID
 }
+
+// ChannelOperationType denotes whether a ChannelOperation adds a channel to
+// the routing table, or removes one from it.
+type ChannelOperationType uint8
+
+const (
+	// ChannelAdd indicates that the channel should be added to the
+	// routing table.
+	ChannelAdd ChannelOperationType = 0
+
+	// ChannelRemove indicates that the channel should be removed from the
+	// routing table.
+	ChannelRemove ChannelOperationType = 1
+)
+
+// String returns a human readable name for the operation type.
+func (c ChannelOperationType) String() string {
+	switch c {
+	case ChannelAdd:
+		return "Add"
+	case ChannelRemove:
+		return "Remove"
+	default:
+		return "Unknown"
+	}
+}
+
+// ChannelOperation describes a single directed channel within a routing
+// table, along with whether it's being added or removed.
+type ChannelOperation struct {
+	// NodeID1 is the lightning ID of the node the channel originates
+	// from.
+	NodeID1 [32]byte
+
+	// NodeID2 is the lightning ID of the node at the other end of the
+	// channel.
+	NodeID2 [32]byte
+
+	// ChannelPoint is the funding outpoint of the channel.
+	ChannelPoint *wire.OutPoint
+
+	// Capacity is the total capacity of the channel.
+	Capacity btcutil.Amount
+
+	// Operation is the change to be applied to the routing table.
+	Operation ChannelOperationType
+}
+
+// String returns the string representation of the target ChannelOperation.
+func (c *ChannelOperation) String() string {
+	return fmt.Sprintf("%v{%x -> %x, %v, %v}", c.Operation, c.NodeID1[:],
+		c.NodeID2[:], c.ChannelPoint, c.Capacity)
+}
+
+// writeChannelOperations serializes the passed operations, prefixed by their
+// number as a uint16.
+func writeChannelOperations(w io.Writer, ops []ChannelOperation) error {
+	if len(ops) > MaxChannelOperations {
+		return fmt.Errorf("too many channel operations: %v, max is %v",
+			len(ops), MaxChannelOperations)
+	}
+
+	if err := writeElement(w, uint16(len(ops))); err != nil {
+		return err
+	}
+	for _, op := range ops {
+		err := writeElements(w,
+			op.NodeID1,
+			op.NodeID2,
+			op.ChannelPoint,
+			op.Capacity,
+			uint8(op.Operation))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readChannelOperations deserializes a list of operations serialized by
+// writeChannelOperations. The number of operations is checked against
+// MaxChannelOperations before any of them are read.
+func readChannelOperations(r io.Reader) ([]ChannelOperation, error) {
+	var numOps uint16
+	if err := readElement(r, &numOps); err != nil {
+		return nil, err
+	}
+	if numOps > MaxChannelOperations {
+		return nil, fmt.Errorf("too many channel operations: %v, max "+
+			"is %v", numOps, MaxChannelOperations)
+	}
+	if numOps == 0 {
+		return nil, nil
+	}
+
+	ops := make([]ChannelOperation, numOps)
+	for i := range ops {
+		var opType uint8
+		err := readElements(r,
+			&ops[i].NodeID1,
+			&ops[i].NodeID2,
+			&ops[i].ChannelPoint,
+			&ops[i].Capacity,
+			&opType)
+		if err != nil {
+			return nil, err
+		}
+		ops[i].Operation = ChannelOperationType(opType)
+	}
+
+	return ops, nil
+}
+
+// validateChannelOperations ensures each of the passed operations is well
+// formed. If addOnly is true, then channels may only be added, as is the case
+// when transferring a complete routing table.
+func validateChannelOperations(ops []ChannelOperation, addOnly bool) error {
+	if len(ops) > MaxChannelOperations {
+		return fmt.Errorf("too many channel operations: %v, max is %v",
+			len(ops), MaxChannelOperations)
+	}
+
+	for _, op := range ops {
+		switch op.Operation {
+		case ChannelAdd:
+		case ChannelRemove:
+			if addOnly {
+				return fmt.Errorf("channel removal not " +
+					"allowed within routing table")
+			}
+		default:
+			return fmt.Errorf("unknown channel operation: %d",
+				uint8(op.Operation))
+		}
+
+		if op.ChannelPoint == nil {
+			return fmt.Errorf("channel point must be set")
+		}
+		if op.NodeID1 == op.NodeID2 {
+			return fmt.Errorf("channel %v connects node %x to "+
+				"itself", op.ChannelPoint, op.NodeID1[:])
+		}
+		if op.Capacity < 0 {
+			return fmt.Errorf("capacity of channel %v must be "+
+				"positive", op.ChannelPoint)
+		}
+	}
+
+	return nil
+}
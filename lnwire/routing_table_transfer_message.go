@@ -5,47 +5,79 @@
 package lnwire
 
 import (
-	"io"
-	"github.com/BitfuryLightning/tools/rt"
-	"encoding/gob"
 	"fmt"
+	"io"
 )
 
+// RoutingTableTransferMessage is sent in response to a
+// RoutingTableRequestMessage, and carries the sender's complete routing
+// table.
 type RoutingTableTransferMessage struct {
 	RoutingMessageBase
-	RT *rt.RoutingTable
-}
 
-func (msg *RoutingTableTransferMessage) String() string {
-	return fmt.Sprintf("RoutingTableTransferMessage{%v %v %v}", msg.SenderID, msg.ReceiverID, msg.RT)
+	// Channels is the list of all channels within the sender's routing
+	// table. Only ChannelAdd operations are allowed.
+	Channels []ChannelOperation
 }
 
-func (msg *RoutingTableTransferMessage) Decode(r io.Reader, pver uint32) error{
-	decoder := gob.NewDecoder(r)
-	rt1 := rt.NewRoutingTable()
-	err := decoder.Decode(rt1.G)
-	msg.RT = rt1
-	return err
+// A compile time check to ensure RoutingTableTransferMessage implements the
+// lnwire.Message interface.
+var _ Message = (*RoutingTableTransferMessage)(nil)
+
+// Decode deserializes a serialized RoutingTableTransferMessage stored in the
+// passed io.Reader observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (msg *RoutingTableTransferMessage) Decode(r io.Reader, pver uint32) error {
+	// NumOperations (2)
+	// Operations (109 each)
+	ops, err := readChannelOperations(r)
+	if err != nil {
+		return err
+	}
+	msg.Channels = ops
+
+	return nil
 }
 
-func (msg *RoutingTableTransferMessage) Encode(w io.Writer, pver uint32) error{
-	encoder := gob.NewEncoder(w)
-	err := encoder.Encode(msg.RT.G)
-	return err
+// Encode serializes the target RoutingTableTransferMessage into the passed
+// io.Writer observing the protocol version specified.
+//
+// This is part of the lnwire.Message interface.
+func (msg *RoutingTableTransferMessage) Encode(w io.Writer, pver uint32) error {
+	return writeChannelOperations(w, msg.Channels)
 }
 
-func (msg *RoutingTableTransferMessage) Command() uint32{
+// Command returns the integer uniquely identifying this message type on the
+// wire.
+//
+// This is part of the lnwire.Message interface.
+func (msg *RoutingTableTransferMessage) Command() uint32 {
 	return CmdRoutingTableTransferMessage
 }
 
-func (msg *RoutingTableTransferMessage) MaxPayloadLength(uint32) uint32{
-	// TODO: Insert some estimations
-	return 1000000
+// MaxPayloadLength returns the maximum allowed payload size for this message
+// observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (msg *RoutingTableTransferMessage) MaxPayloadLength(uint32) uint32 {
+	// 2 + MaxChannelOperations*109
+	return maxChannelOperationsPayload
 }
 
-func (msg *RoutingTableTransferMessage) Validate() error{
-	// TODO: Add validation
-	return nil
+// Validate performs any necessary sanity checks to ensure all fields present
+// on the RoutingTableTransferMessage are valid.
+//
+// This is part of the lnwire.Message interface.
+func (msg *RoutingTableTransferMessage) Validate() error {
+	return validateChannelOperations(msg.Channels, true)
 }
 
-var _  Message = (*RoutingTableTransferMessage)(nil)
+// String returns the string representation of the target
+// RoutingTableTransferMessage.
+//
+// This is part of the lnwire.Message interface.
+func (msg *RoutingTableTransferMessage) String() string {
+	return fmt.Sprintf("RoutingTableTransferMessage{%v %v %v}", msg.SenderID,
+		msg.ReceiverID, msg.Channels)
+}
@@ -49,6 +49,26 @@ type SingleFundingRequest struct {
 	// in the pay-to-self output of both commitment transactions.
 	CsvDelay uint32
 
+	// DustLimit is the threshold below which outputs are trimmed from the
+	// initiator's commitment transaction.
+	DustLimit btcutil.Amount
+
+	// MaxPendingHTLCs is the maximum number of HTLCs the initiator will
+	// accept from the responder at any given time.
+	MaxPendingHTLCs uint16
+
+	// MaxValueInFlight is the maximum total value of outstanding HTLCs
+	// the initiator will accept from the responder.
+	MaxValueInFlight btcutil.Amount
+
+	// MinHTLC is the smallest HTLC the initiator will accept from the
+	// responder.
+	MinHTLC btcutil.Amount
+
+	// ChannelReserve is the amount the responder must keep on their side
+	// of the channel at all times.
+	ChannelReserve btcutil.Amount
+
 	// CommitmentKey is key the initiator of the funding workflow wishes to
 	// use within their versino of the commitment transaction for any
 	// delayed (CSV) or immediate outputs to them.
@@ -73,8 +93,10 @@ type SingleFundingRequest struct {
 
 // NewSingleFundingRequest creates, and returns a new empty SingleFundingRequest.
 func NewSingleFundingRequest(chanID uint64, chanType uint8, coinType uint64,
-	fee btcutil.Amount, amt btcutil.Amount, delay uint32, ck,
-	cdp *btcec.PublicKey, deliveryScript PkScript) *SingleFundingRequest {
+	fee btcutil.Amount, amt btcutil.Amount, delay uint32,
+	dustLimit btcutil.Amount, maxPendingHTLCs uint16, maxValueInFlight,
+	minHTLC, reserve btcutil.Amount, ck, cdp *btcec.PublicKey,
+	deliveryScript PkScript) *SingleFundingRequest {
 
 	return &SingleFundingRequest{
 		ChannelID:              chanID,
@@ -83,6 +105,11 @@ func NewSingleFundingRequest(chanID uint64, chanType uint8, coinType uint64,
 		FeePerKb:               fee,
 		FundingAmount:          amt,
 		CsvDelay:               delay,
+		DustLimit:              dustLimit,
+		MaxPendingHTLCs:        maxPendingHTLCs,
+		MaxValueInFlight:       maxValueInFlight,
+		MinHTLC:                minHTLC,
+		ChannelReserve:         reserve,
 		CommitmentKey:          ck,
 		ChannelDerivationPoint: cdp,
 		DeliveryPkScript:       deliveryScript,
@@ -101,6 +128,11 @@ func (c *SingleFundingRequest) Decode(r io.Reader, pver uint32) error {
 	// FeePerKb (8)
 	// PaymentAmount (8)
 	// Delay (4)
+	// DustLimit (8)
+	// MaxPendingHTLCs (2)
+	// MaxValueInFlight (8)
+	// MinHTLC (8)
+	// ChannelReserve (8)
 	// Pubkey (33)
 	// Pubkey (33)
 	// DeliveryPkScript (final delivery)
@@ -111,6 +143,11 @@ func (c *SingleFundingRequest) Decode(r io.Reader, pver uint32) error {
 		&c.FeePerKb,
 		&c.FundingAmount,
 		&c.CsvDelay,
+		&c.DustLimit,
+		&c.MaxPendingHTLCs,
+		&c.MaxValueInFlight,
+		&c.MinHTLC,
+		&c.ChannelReserve,
 		&c.CommitmentKey,
 		&c.ChannelDerivationPoint,
 		&c.DeliveryPkScript)
@@ -133,6 +170,11 @@ func (c *SingleFundingRequest) Encode(w io.Writer, pver uint32) error {
 	// FeePerKb (8)
 	// PaymentAmount (8)
 	// Delay (4)
+	// DustLimit (8)
+	// MaxPendingHTLCs (2)
+	// MaxValueInFlight (8)
+	// MinHTLC (8)
+	// ChannelReserve (8)
 	// Pubkey (33)
 	// Pubkey (33)
 	// DeliveryPkScript (final delivery)
@@ -143,6 +185,11 @@ func (c *SingleFundingRequest) Encode(w io.Writer, pver uint32) error {
 		c.FeePerKb,
 		c.FundingAmount,
 		c.CsvDelay,
+		c.DustLimit,
+		c.MaxPendingHTLCs,
+		c.MaxValueInFlight,
+		c.MinHTLC,
+		c.ChannelReserve,
 		c.CommitmentKey,
 		c.ChannelDerivationPoint,
 		c.DeliveryPkScript)
@@ -165,11 +212,12 @@ func (c *SingleFundingRequest) Command() uint32 {
 // SingleFundingRequest. This is calculated by summing the max length of all
 // the fields within a SingleFundingRequest. To enforce a maximum
 // DeliveryPkScript size, the size of a P2PKH public key script is used.
-// Therefore, the final breakdown is: 8 + 1 + 8 + 8 + 8 + 4 + 33 + 33 + 25 = 158.
+// Therefore, the final breakdown is: 8 + 1 + 8 + 8 + 8 + 4 + 8 + 2 + 8 + 8 +
+// 8 + 33 + 33 + 25 = 192.
 //
 // This is part of the lnwire.Message interface.
 func (c *SingleFundingRequest) MaxPayloadLength(uint32) uint32 {
-	return 158
+	return 192
 }
 
 // Validate examines each populated field within the SingleFundingRequest for
@@ -186,6 +234,12 @@ func (c *SingleFundingRequest) Validate() error {
 		return fmt.Errorf("FundingAmount cannot be negative")
 	}
 
+	// The flow-control limits MUST NOT be negative either.
+	if c.DustLimit < 0 || c.MaxValueInFlight < 0 || c.MinHTLC < 0 ||
+		c.ChannelReserve < 0 {
+		return fmt.Errorf("Channel constraints cannot be negative")
+	}
+
 	// The CSV delay MUST be non-zero.
 	if c.CsvDelay == 0 {
 		return fmt.Errorf("Commitment transaction must have non-zero " +
@@ -232,6 +286,11 @@ func (c *SingleFundingRequest) String() string {
 		fmt.Sprintf("FeePerKb:\t\t\t%s\n", c.FeePerKb.String()) +
 		fmt.Sprintf("FundingAmount:\t\t\t%s\n", c.FundingAmount.String()) +
 		fmt.Sprintf("CsvDelay\t\t\t%d\n", c.CsvDelay) +
+		fmt.Sprintf("DustLimit\t\t\t%s\n", c.DustLimit.String()) +
+		fmt.Sprintf("MaxPendingHTLCs\t\t\t%d\n", c.MaxPendingHTLCs) +
+		fmt.Sprintf("MaxValueInFlight\t\t%s\n", c.MaxValueInFlight.String()) +
+		fmt.Sprintf("MinHTLC\t\t\t%s\n", c.MinHTLC.String()) +
+		fmt.Sprintf("ChannelReserve\t\t\t%s\n", c.ChannelReserve.String()) +
 		fmt.Sprintf("ChannelDerivationPoint\t\t\t\t%x\n", serializedPubkey) +
 		fmt.Sprintf("DeliveryPkScript\t\t%x\n", c.DeliveryPkScript) +
 		fmt.Sprintf("--- End SingleFundingRequest ---\n")
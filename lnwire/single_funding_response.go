@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcutil"
 )
 
 // SingleFundingResponse is the message Bob sends to Alice after she initiates
@@ -40,6 +41,26 @@ type SingleFundingResponse struct {
 	// in the pay-to-self output of both commitment transactions.
 	CsvDelay uint32
 
+	// DustLimit is the threshold below which outputs are trimmed from the
+	// responder's commitment transaction.
+	DustLimit btcutil.Amount
+
+	// MaxPendingHTLCs is the maximum number of HTLCs the responder will
+	// accept from the initiator at any given time.
+	MaxPendingHTLCs uint16
+
+	// MaxValueInFlight is the maximum total value of outstanding HTLCs
+	// the responder will accept from the initiator.
+	MaxValueInFlight btcutil.Amount
+
+	// MinHTLC is the smallest HTLC the responder will accept from the
+	// initiator.
+	MinHTLC btcutil.Amount
+
+	// ChannelReserve is the amount the initiator must keep on their side
+	// of the channel at all times.
+	ChannelReserve btcutil.Amount
+
 	// DeliveryPkScript defines the public key script that the initiator
 	// would like to use to receive their balance in the case of a
 	// cooperative close. Only the following script templates are
@@ -50,7 +71,9 @@ type SingleFundingResponse struct {
 // NewSingleFundingResponse creates, and returns a new empty
 // SingleFundingResponse.
 func NewSingleFundingResponse(chanID uint64, rk, ck, cdp *btcec.PublicKey,
-	delay uint32, deliveryScript PkScript) *SingleFundingResponse {
+	delay uint32, dustLimit btcutil.Amount, maxPendingHTLCs uint16,
+	maxValueInFlight, minHTLC, reserve btcutil.Amount,
+	deliveryScript PkScript) *SingleFundingResponse {
 
 	return &SingleFundingResponse{
 		ChannelID:              chanID,
@@ -58,6 +81,11 @@ func NewSingleFundingResponse(chanID uint64, rk, ck, cdp *btcec.PublicKey,
 		CommitmentKey:          ck,
 		RevocationKey:          rk,
 		CsvDelay:               delay,
+		DustLimit:              dustLimit,
+		MaxPendingHTLCs:        maxPendingHTLCs,
+		MaxValueInFlight:       maxValueInFlight,
+		MinHTLC:                minHTLC,
+		ChannelReserve:         reserve,
 		DeliveryPkScript:       deliveryScript,
 	}
 }
@@ -77,6 +105,11 @@ func (c *SingleFundingResponse) Decode(r io.Reader, pver uint32) error {
 	// CommitmentKey (33)
 	// RevocationKey (33)
 	// CsvDelay (4)
+	// DustLimit (8)
+	// MaxPendingHTLCs (2)
+	// MaxValueInFlight (8)
+	// MinHTLC (8)
+	// ChannelReserve (8)
 	// DeliveryPkScript (final delivery)
 	err := readElements(r,
 		&c.ChannelID,
@@ -84,6 +117,11 @@ func (c *SingleFundingResponse) Decode(r io.Reader, pver uint32) error {
 		&c.CommitmentKey,
 		&c.RevocationKey,
 		&c.CsvDelay,
+		&c.DustLimit,
+		&c.MaxPendingHTLCs,
+		&c.MaxValueInFlight,
+		&c.MinHTLC,
+		&c.ChannelReserve,
 		&c.DeliveryPkScript)
 	if err != nil {
 		return err
@@ -103,6 +141,11 @@ func (c *SingleFundingResponse) Encode(w io.Writer, pver uint32) error {
 	// CommitmentKey (33)
 	// RevocationKey (33)
 	// CsvDelay (4)
+	// DustLimit (8)
+	// MaxPendingHTLCs (2)
+	// MaxValueInFlight (8)
+	// MinHTLC (8)
+	// ChannelReserve (8)
 	// DeliveryPkScript (final delivery)
 	err := writeElements(w,
 		c.ChannelID,
@@ -110,6 +153,11 @@ func (c *SingleFundingResponse) Encode(w io.Writer, pver uint32) error {
 		c.CommitmentKey,
 		c.RevocationKey,
 		c.CsvDelay,
+		c.DustLimit,
+		c.MaxPendingHTLCs,
+		c.MaxValueInFlight,
+		c.MinHTLC,
+		c.ChannelReserve,
 		c.DeliveryPkScript)
 	if err != nil {
 		return err
@@ -130,11 +178,11 @@ func (c *SingleFundingResponse) Command() uint32 {
 // SingleFundingResponse. This is calculated by summing the max length of all
 // the fields within a SingleFundingResponse. To enforce a maximum
 // DeliveryPkScript size, the size of a P2PKH public key script is used.
-// Therefore, the final breakdown is: 8 + (33 * 3) + 8 + 25
+// Therefore, the final breakdown is: 8 + (33 * 3) + 8 + 8 + 2 + (8 * 3) + 25
 //
 // This is part of the lnwire.Message interface.
 func (c *SingleFundingResponse) MaxPayloadLength(uint32) uint32 {
-	return 140
+	return 174
 }
 
 // Validate examines each populated field within the SingleFundingResponse for
@@ -153,6 +201,12 @@ func (c *SingleFundingResponse) Validate() error {
 	//		"y-coordinate")
 	//}
 
+	// None of the flow-control limits may be negative.
+	if c.DustLimit < 0 || c.MaxValueInFlight < 0 || c.MinHTLC < 0 ||
+		c.ChannelReserve < 0 {
+		return fmt.Errorf("Channel constraints cannot be negative")
+	}
+
 	// The delivery pkScript must be amongst the supported script
 	// templates.
 	if !isValidPkScript(c.DeliveryPkScript) {
@@ -187,6 +241,11 @@ func (c *SingleFundingResponse) String() string {
 		fmt.Sprintf("CommitmentKey\t\t\t\t%x\n", ck) +
 		fmt.Sprintf("RevocationKey\t\t\t\t%x\n", rk) +
 		fmt.Sprintf("CsvDelay\t\t%d\n", c.CsvDelay) +
+		fmt.Sprintf("DustLimit\t\t%s\n", c.DustLimit.String()) +
+		fmt.Sprintf("MaxPendingHTLCs\t\t%d\n", c.MaxPendingHTLCs) +
+		fmt.Sprintf("MaxValueInFlight\t\t%s\n", c.MaxValueInFlight.String()) +
+		fmt.Sprintf("MinHTLC\t\t%s\n", c.MinHTLC.String()) +
+		fmt.Sprintf("ChannelReserve\t\t%s\n", c.ChannelReserve.String()) +
 		fmt.Sprintf("DeliveryPkScript\t\t%x\n", c.DeliveryPkScript) +
 		fmt.Sprintf("--- End SingleFundingResponse ---\n")
 }
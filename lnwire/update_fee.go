@@ -0,0 +1,110 @@
+package lnwire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+)
+
+// UpdateFee is sent by the funder of a channel in order to update the fee
+// rate paid by both commitment transactions. Only the funder of the channel
+// may send an UpdateFee message, as they're the party paying the fee. Much
+// like an HTLC, the update is added to the update log of both sides, taking
+// effect once it has been committed within the next state.
+type UpdateFee struct {
+	// ChannelPoint references the particular active channel to which this
+	// UpdateFee message is binded to.
+	ChannelPoint *wire.OutPoint
+
+	// FeePerKb is the new fee rate, in satoshis per kilobyte, to be paid
+	// by the commitment transactions.
+	FeePerKb btcutil.Amount
+}
+
+// NewUpdateFee returns a new UpdateFee message for the target channel.
+func NewUpdateFee(chanPoint *wire.OutPoint, feePerKb btcutil.Amount) *UpdateFee {
+	return &UpdateFee{
+		ChannelPoint: chanPoint,
+		FeePerKb:     feePerKb,
+	}
+}
+
+// A compile time check to ensure UpdateFee implements the lnwire.Message
+// interface.
+var _ Message = (*UpdateFee)(nil)
+
+// Decode deserializes a serialized UpdateFee message stored in the passed
+// io.Reader observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (c *UpdateFee) Decode(r io.Reader, pver uint32) error {
+	// ChannelPoint (36)
+	// FeePerKb (8)
+	err := readElements(r,
+		&c.ChannelPoint,
+		&c.FeePerKb,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Encode serializes the target UpdateFee into the passed io.Writer observing
+// the protocol version specified.
+//
+// This is part of the lnwire.Message interface.
+func (c *UpdateFee) Encode(w io.Writer, pver uint32) error {
+	err := writeElements(w,
+		c.ChannelPoint,
+		c.FeePerKb,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Command returns the integer uniquely identifying this message type on the
+// wire.
+//
+// This is part of the lnwire.Message interface.
+func (c *UpdateFee) Command() uint32 {
+	return CmdUpdateFee
+}
+
+// MaxPayloadLength returns the maximum allowed payload size for an UpdateFee
+// message observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (c *UpdateFee) MaxPayloadLength(uint32) uint32 {
+	// 36 + 8
+	return 44
+}
+
+// Validate performs any necessary sanity checks to ensure all fields present
+// on the UpdateFee are valid.
+//
+// This is part of the lnwire.Message interface.
+func (c *UpdateFee) Validate() error {
+	if c.FeePerKb < 0 {
+		return fmt.Errorf("FeePerKb cannot be negative")
+	}
+
+	// We're good!
+	return nil
+}
+
+// String returns the string representation of the target UpdateFee.
+//
+// This is part of the lnwire.Message interface.
+func (c *UpdateFee) String() string {
+	return fmt.Sprintf("\n--- Begin UpdateFee ---\n") +
+		fmt.Sprintf("ChannelPoint:\t\t%v\n", c.ChannelPoint) +
+		fmt.Sprintf("FeePerKb:\t\t%v\n", c.FeePerKb) +
+		fmt.Sprintf("--- End UpdateFee ---\n")
+}
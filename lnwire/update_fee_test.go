@@ -0,0 +1,30 @@
+package lnwire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateFeeEncodeDecode(t *testing.T) {
+	// First create a new UpdateFee message.
+	updateFee := NewUpdateFee(outpoint1, 12000)
+
+	// Next encode the UpdateFee message into an empty bytes buffer.
+	var b bytes.Buffer
+	if err := updateFee.Encode(&b, 0); err != nil {
+		t.Fatalf("unable to encode UpdateFee: %v", err)
+	}
+
+	// Deserialize the encoded UpdateFee message into a new empty struct.
+	updateFee2 := &UpdateFee{}
+	if err := updateFee2.Decode(&b, 0); err != nil {
+		t.Fatalf("unable to decode UpdateFee: %v", err)
+	}
+
+	// Assert equality of the two instances.
+	if !reflect.DeepEqual(updateFee, updateFee2) {
+		t.Fatalf("encode/decode error messages don't match %#v vs %#v",
+			updateFee, updateFee2)
+	}
+}
@@ -26,6 +26,9 @@ var (
 	ntfnLog    = btclog.Disabled
 	chdbLog    = btclog.Disabled
 	hswcLog    = btclog.Disabled
+	gsprLog    = btclog.Disabled
+	pymtLog    = btclog.Disabled
+	rtngLog    = btclog.Disabled
 )
 
 // subsystemLoggers maps each subsystem identifier to its associated logger.
@@ -39,6 +42,9 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"CHDB": chdbLog,
 	"FNDG": fndgLog,
 	"HSWC": hswcLog,
+	"GSPR": gsprLog,
+	"PYMT": pymtLog,
+	"RTNG": rtngLog,
 }
 
 // useLogger updates the logger references for subsystemID to logger.  Invalid
@@ -79,6 +85,15 @@ func useLogger(subsystemID string, logger btclog.Logger) {
 
 	case "HSWC":
 		hswcLog = logger
+
+	case "GSPR":
+		gsprLog = logger
+
+	case "PYMT":
+		pymtLog = logger
+
+	case "RTNG":
+		rtngLog = logger
 	}
 }
 
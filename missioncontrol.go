@@ -0,0 +1,138 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/pathfind"
+	"github.com/roasbeef/btcd/wire"
+)
+
+const (
+	// failurePenalty is the cost added to an edge, or all the edges out
+	// of a node, immediately after it fails to forward a payment. It's
+	// measured in milli-satoshis, the same units as the fees of a route,
+	// so a failed edge is only retried straight away if avoiding it would
+	// cost more than this in fees.
+	failurePenalty = 100000000
+
+	// penaltyHalfLife is the time after which the penalty of a failure
+	// has decayed to half its initial value.
+	penaltyHalfLife = 5 * time.Minute
+
+	// maxPenaltyAge is the age after which a failure is forgotten
+	// entirely.
+	maxPenaltyAge = 10 * penaltyHalfLife
+)
+
+// missionControl is an in-memory store of the channels and nodes which have
+// recently failed to forward our payments. It penalizes them during path
+// finding, with each penalty decaying over time, so that failing hops are
+// avoided when retrying a payment, yet eventually given another chance.
+type missionControl struct {
+	sync.Mutex
+
+	// edgeFailures maps each failed directed edge to the time of its
+	// latest failure.
+	edgeFailures map[edgeDirection]time.Time
+
+	// nodeFailures maps each failed node to the time of its latest
+	// failure.
+	nodeFailures map[[32]byte]time.Time
+
+	// now returns the current time, and is overridden within tests.
+	now func() time.Time
+}
+
+// edgeDirection identifies a channel along with the direction it's
+// traversed in.
+type edgeDirection struct {
+	chanPoint wire.OutPoint
+	from      [32]byte
+}
+
+// A compile time check to ensure missionControl implements the
+// pathfind.EdgePenalizer interface.
+var _ pathfind.EdgePenalizer = (*missionControl)(nil)
+
+// newMissionControl creates a new, empty missionControl.
+func newMissionControl() *missionControl {
+	return &missionControl{
+		edgeFailures: make(map[edgeDirection]time.Time),
+		nodeFailures: make(map[[32]byte]time.Time),
+		now:          time.Now,
+	}
+}
+
+// reportEdgeFailure records that the edge failed to forward a payment.
+func (m *missionControl) reportEdgeFailure(edge *channeldb.ChannelEdge) {
+	m.Lock()
+	defer m.Unlock()
+
+	key := edgeDirection{edge.ChannelPoint, edge.From}
+	m.edgeFailures[key] = m.now()
+	m.prune()
+}
+
+// reportNodeFailure records that the node failed to forward a payment.
+func (m *missionControl) reportNodeFailure(node [32]byte) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.nodeFailures[node] = m.now()
+	m.prune()
+}
+
+// EdgePenalty returns the decayed penalty of any recent failures of either
+// the edge itself, or the node at its start.
+//
+// This is part of the pathfind.EdgePenalizer interface.
+func (m *missionControl) EdgePenalty(edge *channeldb.ChannelEdge,
+	amt lnwire.MilliSatoshi) int64 {
+
+	m.Lock()
+	defer m.Unlock()
+
+	var penalty int64
+	key := edgeDirection{edge.ChannelPoint, edge.From}
+	if failTime, ok := m.edgeFailures[key]; ok {
+		penalty += m.decayedPenalty(failTime)
+	}
+	if failTime, ok := m.nodeFailures[edge.From]; ok {
+		penalty += m.decayedPenalty(failTime)
+	}
+
+	return penalty
+}
+
+// decayedPenalty returns the penalty of a failure which occurred at the
+// passed time, halving for every penaltyHalfLife which has since elapsed.
+func (m *missionControl) decayedPenalty(failTime time.Time) int64 {
+	age := m.now().Sub(failTime)
+	if age >= maxPenaltyAge {
+		return 0
+	}
+
+	halfLives := float64(age) / float64(penaltyHalfLife)
+	return int64(failurePenalty * math.Exp2(-halfLives))
+}
+
+// prune forgets all failures older than maxPenaltyAge.
+//
+// NOTE: The mutex MUST be held when calling this method.
+func (m *missionControl) prune() {
+	now := m.now()
+	for key, failTime := range m.edgeFailures {
+		if now.Sub(failTime) >= maxPenaltyAge {
+			delete(m.edgeFailures, key)
+		}
+	}
+	for node, failTime := range m.nodeFailures {
+		if now.Sub(failTime) >= maxPenaltyAge {
+			delete(m.nodeFailures, node)
+		}
+	}
+}
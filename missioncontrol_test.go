@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/roasbeef/btcd/wire"
+)
+
+func TestMissionControlDecay(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	mc := newMissionControl()
+	mc.now = func() time.Time { return now }
+
+	edge := &channeldb.ChannelEdge{
+		ChannelPoint: wire.OutPoint{Hash: wire.ShaHash{0x01}},
+		From:         [32]byte{0x02},
+		To:           [32]byte{0x03},
+	}
+	reverseEdge := &channeldb.ChannelEdge{
+		ChannelPoint: edge.ChannelPoint,
+		From:         edge.To,
+		To:           edge.From,
+	}
+
+	// Immediately after a failure, the edge should receive the full
+	// penalty, while the reverse direction remains unpenalized.
+	mc.reportEdgeFailure(edge)
+	if penalty := mc.EdgePenalty(edge, 1000); penalty != failurePenalty {
+		t.Fatalf("expected penalty of %v, got %v", failurePenalty,
+			penalty)
+	}
+	if penalty := mc.EdgePenalty(reverseEdge, 1000); penalty != 0 {
+		t.Fatalf("reverse edge shouldn't be penalized, got %v", penalty)
+	}
+
+	// After a half-life the penalty should have halved.
+	now = now.Add(penaltyHalfLife)
+	if penalty := mc.EdgePenalty(edge, 1000); penalty != failurePenalty/2 {
+		t.Fatalf("expected penalty of %v, got %v", failurePenalty/2,
+			penalty)
+	}
+
+	// A failure of the node at the start of the edge adds to the edge's
+	// penalty.
+	mc.reportNodeFailure(edge.From)
+	expected := int64(failurePenalty + failurePenalty/2)
+	if penalty := mc.EdgePenalty(edge, 1000); penalty != expected {
+		t.Fatalf("expected penalty of %v, got %v", expected, penalty)
+	}
+
+	// Once the failures are old enough, they should be forgotten.
+	now = now.Add(maxPenaltyAge)
+	if penalty := mc.EdgePenalty(edge, 1000); penalty != 0 {
+		t.Fatalf("expected no penalty, got %v", penalty)
+	}
+	mc.reportNodeFailure([32]byte{0x04})
+	if len(mc.edgeFailures) != 0 || len(mc.nodeFailures) != 1 {
+		t.Fatalf("stale failures not pruned: %v edges, %v nodes",
+			len(mc.edgeFailures), len(mc.nodeFailures))
+	}
+}
@@ -0,0 +1,36 @@
+package pathfind
+
+// distItem is an entry within the distanceHeap, pairing a node with its
+// tentative distance to the target of a path search.
+type distItem struct {
+	node [32]byte
+	dist int64
+}
+
+// distanceHeap is a min-heap of nodes ordered by their distance to the
+// target of a path search. It implements the heap.Interface.
+type distanceHeap []*distItem
+
+// Len returns the number of items within the heap.
+func (d distanceHeap) Len() int { return len(d) }
+
+// Less returns true if the item at index i is closer to the target than the
+// item at index j.
+func (d distanceHeap) Less(i, j int) bool { return d[i].dist < d[j].dist }
+
+// Swap swaps the items at the passed indices.
+func (d distanceHeap) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+
+// Push adds a new item to the heap.
+func (d *distanceHeap) Push(x interface{}) {
+	*d = append(*d, x.(*distItem))
+}
+
+// Pop removes the last item from the heap.
+func (d *distanceHeap) Pop() interface{} {
+	old := *d
+	n := len(old)
+	x := old[n-1]
+	*d = old[0 : n-1]
+	return x
+}
@@ -0,0 +1,525 @@
+package pathfind
+
+import (
+	"container/heap"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/wire"
+)
+
+const (
+	// HopLimit is the maximum number of hops a route may consist of.
+	HopLimit = 20
+
+	// DefaultFinalCLTVDelta is the default number of blocks the HTLC
+	// arriving at the final node of a route remains valid for. It's also
+	// the default minimum a node requires of the HTLCs paying its
+	// invoices, so a sender should only deviate from it when told to by
+	// the receiver.
+	DefaultFinalCLTVDelta = 9
+
+	// timeLockRiskFactor is the cost, in billionths of the forwarded
+	// amount, attributed to each block of time-lock delta along a route.
+	// It captures the opportunity cost of having funds locked up within
+	// an HTLC in the case of a stalled payment.
+	timeLockRiskFactor = 15
+
+	// capacityRiskFactor is the cost, in millionths of the forwarded
+	// amount, attributed to an edge whose capacity would be completely
+	// consumed by the payment. The penalty scales linearly with the
+	// fraction of the capacity used, favouring larger channels which are
+	// more likely to have sufficient balance in the needed direction.
+	capacityRiskFactor = 1000
+)
+
+var (
+	// ErrNoPathFound is returned when no route to the target node exists
+	// which is able to carry the requested amount.
+	ErrNoPathFound = fmt.Errorf("unable to find a path to destination")
+
+	// ErrTargetNotInGraph is returned when the target of a route isn't
+	// known within the channel graph.
+	ErrTargetNotInGraph = fmt.Errorf("target not found in channel graph")
+
+	// ErrMaxHopsExceeded is returned when the only routes to the target
+	// node are longer than HopLimit.
+	ErrMaxHopsExceeded = fmt.Errorf("route exceeds maximum number of hops")
+)
+
+// ChannelGraph is the view of the channel graph required in order to find
+// routes. It's satisfied by channeldb.DB.
+type ChannelGraph interface {
+	// SourceNode returns our own node, which all routes start from.
+	SourceNode() (*channeldb.LightningNode, error)
+
+	// ForEachChannelEdge executes the passed callback for each directed
+	// edge within the graph.
+	ForEachChannelEdge(func(*channeldb.ChannelEdge) error) error
+}
+
+// Compile time check to ensure channeldb.DB meets the ChannelGraph
+// interface.
+var _ ChannelGraph = (*channeldb.DB)(nil)
+
+// EdgePenalizer assigns an additional cost to edges, allowing the caller to
+// steer path finding away from edges which are believed to be unreliable,
+// e.g. as they've recently failed to forward a payment.
+type EdgePenalizer interface {
+	// EdgePenalty returns the additional cost, in milli-satoshis, of
+	// sending amt across the edge.
+	EdgePenalty(edge *channeldb.ChannelEdge, amt lnwire.MilliSatoshi) int64
+}
+
+// Hop is a single hop within a route, describing the HTLC sent across one
+// channel edge.
+type Hop struct {
+	// Channel is the edge the HTLC is sent across.
+	Channel *channeldb.ChannelEdge
+
+	// AmtToForward is the amount of the HTLC sent across the edge.
+	AmtToForward lnwire.MilliSatoshi
+
+	// Fee is the fee charged by the node at the start of the edge for
+	// forwarding the HTLC across it. The first hop of a route is always
+	// free, as it originates from our own node.
+	Fee lnwire.MilliSatoshi
+
+	// Expiry is the number of blocks the HTLC sent across the edge
+	// remains valid for.
+	Expiry uint32
+}
+
+// Route is a path through the channel graph from our own node to a target
+// node, along with the amounts, and time-locks of the HTLCs sent across each
+// of its hops.
+type Route struct {
+	// TotalTimeLock is the expiry of the HTLC we'll send across the first
+	// hop of the route.
+	TotalTimeLock uint32
+
+	// TotalFees is the sum of the fees charged by all the hops within the
+	// route.
+	TotalFees lnwire.MilliSatoshi
+
+	// TotalAmount is the amount which must be sent across the first hop
+	// in order to deliver the payment, including all fees.
+	TotalAmount lnwire.MilliSatoshi
+
+	// Hops is the ordered list of hops, starting at our own node.
+	Hops []*Hop
+
+	// weight is the cost of the route used to rank alternative routes,
+	// measured in milli-satoshis.
+	weight int64
+}
+
+// FindRoutes returns up to numRoutes of the cheapest routes able to deliver
+// amt to the target node, ordered from cheapest to most expensive. The cost
+// of a route accounts for the fees charged along it, the total time-lock
+// delta, and how much of the capacity of each channel the payment consumes.
+// Edges which can't carry the amount required of them are excluded. The
+// optional bandwidth hints map the channel points of our own channels to
+// their available local balance; all other edges are limited by their
+// capacity. If a penalizer is passed, then the penalty it assigns to each
+// edge is added to the edge's cost. The HTLC arriving at the target node
+// remains valid for finalCLTVDelta blocks, which must satisfy the minimum
+// the target requires.
+func FindRoutes(graph ChannelGraph, target [32]byte, amt lnwire.MilliSatoshi,
+	finalCLTVDelta uint32, numRoutes int,
+	bandwidthHints map[wire.OutPoint]lnwire.MilliSatoshi,
+	penalizer EdgePenalizer) ([]*Route, error) {
+
+	g, err := newGraphSnapshot(graph, bandwidthHints, penalizer)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := g.incoming[target]; !ok {
+		return nil, ErrTargetNotInGraph
+	}
+	if target == g.source {
+		return nil, fmt.Errorf("unable to route payment to self")
+	}
+
+	shortestPath, err := g.findPath(g.source, target, amt, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	shortestRoute, err := g.newRoute(shortestPath, amt, finalCLTVDelta)
+	if err != nil {
+		return nil, err
+	}
+
+	// With the shortest path found, we'll use Yen's algorithm to find the
+	// next best paths. Each path is a deviation from one of the previous
+	// paths, branching off at a spur node, while sharing the root path up
+	// to it.
+	paths := [][]*channeldb.ChannelEdge{shortestPath}
+	routes := []*Route{shortestRoute}
+
+	var (
+		candidatePaths  [][]*channeldb.ChannelEdge
+		candidateRoutes []*Route
+	)
+	for len(routes) < numRoutes {
+		prevPath := paths[len(paths)-1]
+		for i := range prevPath {
+			spurNode := prevPath[i].From
+			rootPath := prevPath[:i]
+
+			// To ensure the spur path deviates from all
+			// previously found paths sharing the same root, their
+			// next edges are ignored. The nodes within the root
+			// path are ignored as well in order to avoid loops.
+			ignoredEdges := make(map[edgeKey]struct{})
+			for _, path := range paths {
+				if len(path) > i && samePath(path[:i], rootPath) {
+					ignoredEdges[newEdgeKey(path[i])] = struct{}{}
+				}
+			}
+			ignoredNodes := make(map[[32]byte]struct{})
+			for _, edge := range rootPath {
+				ignoredNodes[edge.From] = struct{}{}
+			}
+
+			spurPath, err := g.findPath(spurNode, target, amt,
+				ignoredNodes, ignoredEdges)
+			if err == ErrNoPathFound {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			newPath := make([]*channeldb.ChannelEdge, 0,
+				len(rootPath)+len(spurPath))
+			newPath = append(newPath, rootPath...)
+			newPath = append(newPath, spurPath...)
+			if containsPath(paths, newPath) ||
+				containsPath(candidatePaths, newPath) {
+				continue
+			}
+
+			// As the fees of the spur path may differ, the edges
+			// within the root path might no longer be able to
+			// carry the amount required of them.
+			route, err := g.newRoute(newPath, amt, finalCLTVDelta)
+			if err != nil {
+				continue
+			}
+
+			candidatePaths = append(candidatePaths, newPath)
+			candidateRoutes = append(candidateRoutes, route)
+		}
+
+		if len(candidatePaths) == 0 {
+			break
+		}
+
+		// The cheapest candidate is the next best path.
+		best := 0
+		for i, route := range candidateRoutes {
+			if route.weight < candidateRoutes[best].weight {
+				best = i
+			}
+		}
+		paths = append(paths, candidatePaths[best])
+		routes = append(routes, candidateRoutes[best])
+
+		candidatePaths = append(candidatePaths[:best],
+			candidatePaths[best+1:]...)
+		candidateRoutes = append(candidateRoutes[:best],
+			candidateRoutes[best+1:]...)
+	}
+
+	return routes, nil
+}
+
+// graphSnapshot is an in-memory copy of the channel graph, indexed by the
+// incoming edges of each node, which is used for the duration of a single
+// path finding attempt.
+type graphSnapshot struct {
+	source [32]byte
+
+	incoming map[[32]byte][]*channeldb.ChannelEdge
+
+	bandwidthHints map[wire.OutPoint]lnwire.MilliSatoshi
+
+	penalizer EdgePenalizer
+}
+
+// newGraphSnapshot loads the complete channel graph into memory.
+func newGraphSnapshot(graph ChannelGraph,
+	bandwidthHints map[wire.OutPoint]lnwire.MilliSatoshi,
+	penalizer EdgePenalizer) (*graphSnapshot, error) {
+
+	sourceNode, err := graph.SourceNode()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &graphSnapshot{
+		source:         sourceNode.ID,
+		incoming:       make(map[[32]byte][]*channeldb.ChannelEdge),
+		bandwidthHints: bandwidthHints,
+		penalizer:      penalizer,
+	}
+	err = graph.ForEachChannelEdge(func(edge *channeldb.ChannelEdge) error {
+		if _, ok := g.incoming[edge.From]; !ok {
+			g.incoming[edge.From] = nil
+		}
+		g.incoming[edge.To] = append(g.incoming[edge.To], edge)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// canCarry returns true if an HTLC of the passed amount can be sent across
+// the edge.
+func (g *graphSnapshot) canCarry(edge *channeldb.ChannelEdge,
+	amt lnwire.MilliSatoshi) bool {
+
+	if amt < lnwire.NewMSatFromSatoshis(edge.MinHTLC) {
+		return false
+	}
+	if edge.MaxHTLC != 0 && amt > lnwire.NewMSatFromSatoshis(edge.MaxHTLC) {
+		return false
+	}
+
+	bandwidth := lnwire.NewMSatFromSatoshis(edge.Capacity)
+	if edge.From == g.source {
+		if hint, ok := g.bandwidthHints[edge.ChannelPoint]; ok {
+			bandwidth = hint
+		}
+	} else if edge.Capacity == 0 {
+		// The capacity of channels accepted without a view of the
+		// UTXO set is unknown, so they're assumed to be able to carry
+		// the HTLC.
+		return true
+	}
+
+	return amt <= bandwidth
+}
+
+// nodeDist is the tentative distance from a node to the target of a path
+// search.
+type nodeDist struct {
+	// dist is the cost of the path from the node to the target.
+	dist int64
+
+	// amt is the amount which must arrive at the node in order to
+	// deliver the payment across the rest of the path, including the fee
+	// charged by the node itself.
+	amt lnwire.MilliSatoshi
+}
+
+// findPath finds the cheapest path from the source to the target node able
+// to deliver amt, skipping all ignored nodes and edges. The search is
+// carried out backwards, starting at the target, as the amount which must be
+// sent across each edge depends on the fees charged by all the hops after
+// it.
+func (g *graphSnapshot) findPath(source, target [32]byte, amt lnwire.MilliSatoshi,
+	ignoredNodes map[[32]byte]struct{},
+	ignoredEdges map[edgeKey]struct{}) ([]*channeldb.ChannelEdge, error) {
+
+	distance := map[[32]byte]nodeDist{
+		target: {dist: 0, amt: amt},
+	}
+	next := make(map[[32]byte]*channeldb.ChannelEdge)
+
+	nodeHeap := &distanceHeap{{node: target, dist: 0}}
+	for nodeHeap.Len() != 0 {
+		item := heap.Pop(nodeHeap).(*distItem)
+
+		// Skip stale entries which have since been superseded by a
+		// cheaper path.
+		current := distance[item.node]
+		if item.dist > current.dist {
+			continue
+		}
+		if item.node == source {
+			break
+		}
+
+		for _, edge := range g.incoming[item.node] {
+			if _, ok := ignoredNodes[edge.From]; ok {
+				continue
+			}
+			if _, ok := ignoredEdges[newEdgeKey(edge)]; ok {
+				continue
+			}
+			if !g.canCarry(edge, current.amt) {
+				continue
+			}
+
+			var fee lnwire.MilliSatoshi
+			if edge.From != g.source {
+				fee = computeFee(current.amt, edge)
+			}
+
+			newDist := current.dist + g.edgeWeight(current.amt, fee, edge)
+			if d, ok := distance[edge.From]; ok && d.dist <= newDist {
+				continue
+			}
+
+			distance[edge.From] = nodeDist{
+				dist: newDist,
+				amt:  current.amt + fee,
+			}
+			next[edge.From] = edge
+			heap.Push(nodeHeap, &distItem{
+				node: edge.From,
+				dist: newDist,
+			})
+		}
+	}
+
+	if _, ok := next[source]; !ok {
+		return nil, ErrNoPathFound
+	}
+
+	var path []*channeldb.ChannelEdge
+	for node := source; node != target; node = next[node].To {
+		path = append(path, next[node])
+	}
+
+	return path, nil
+}
+
+// newRoute computes the amounts, fees, and time-locks of each hop along the
+// passed path in order to deliver amt to its final node, with an HTLC valid
+// for finalCLTVDelta blocks. An error is returned if any of the edges is
+// unable to carry the amount required of it.
+func (g *graphSnapshot) newRoute(path []*channeldb.ChannelEdge,
+	amt lnwire.MilliSatoshi, finalCLTVDelta uint32) (*Route, error) {
+
+	if len(path) > HopLimit {
+		return nil, ErrMaxHopsExceeded
+	}
+
+	route := &Route{
+		Hops: make([]*Hop, len(path)),
+	}
+
+	// Starting at the final hop, we'll work backwards towards our own
+	// node, as each node expects to receive the amount it forwards plus
+	// its fee, and an HTLC which expires its time-lock delta after the
+	// outgoing one.
+	amtToForward := amt
+	expiry := finalCLTVDelta
+	for i := len(path) - 1; i >= 0; i-- {
+		edge := path[i]
+		if !g.canCarry(edge, amtToForward) {
+			return nil, ErrNoPathFound
+		}
+
+		hop := &Hop{
+			Channel:      edge,
+			AmtToForward: amtToForward,
+			Expiry:       expiry,
+		}
+		if i != 0 {
+			hop.Fee = computeFee(amtToForward, edge)
+		}
+		route.Hops[i] = hop
+
+		route.TotalFees += hop.Fee
+		route.weight += g.edgeWeight(amtToForward, hop.Fee, edge)
+
+		amtToForward += hop.Fee
+		if i != 0 {
+			expiry += uint32(edge.TimeLockDelta)
+		}
+	}
+
+	route.TotalAmount = amtToForward
+	route.TotalTimeLock = route.Hops[0].Expiry
+
+	return route, nil
+}
+
+// computeFee returns the fee charged for forwarding amt across the edge. The
+// fee is computed in milli-satoshis, matching the fee the node at the start of
+// the edge will demand when forwarding the HTLC.
+func computeFee(amt lnwire.MilliSatoshi,
+	edge *channeldb.ChannelEdge) lnwire.MilliSatoshi {
+
+	baseFee := lnwire.NewMSatFromSatoshis(edge.FeeBase)
+	return baseFee + (amt*lnwire.MilliSatoshi(edge.FeeRate))/1000000
+}
+
+// edgeWeight returns the cost of sending amt across the edge, including any
+// penalty assigned to the edge by the snapshot's penalizer.
+func (g *graphSnapshot) edgeWeight(amt, fee lnwire.MilliSatoshi,
+	edge *channeldb.ChannelEdge) int64 {
+
+	weight := edgeWeight(amt, fee, edge)
+	if g.penalizer != nil {
+		weight += g.penalizer.EdgePenalty(edge, amt)
+	}
+
+	return weight
+}
+
+// edgeWeight returns the cost of sending amt across the edge in
+// milli-satoshis, given the fee charged for doing so. Every edge costs at
+// least one, so that shorter paths are preferred among otherwise equal ones.
+func edgeWeight(amt, fee lnwire.MilliSatoshi, edge *channeldb.ChannelEdge) int64 {
+	timeLockPenalty := int64(amt) * int64(edge.TimeLockDelta) *
+		timeLockRiskFactor / 1000000000
+
+	var capacityPenalty int64
+	if edge.Capacity > 0 {
+		capacity := lnwire.NewMSatFromSatoshis(edge.Capacity)
+		utilization := float64(amt) / float64(capacity)
+		capacityPenalty = int64(float64(amt) * utilization *
+			capacityRiskFactor / 1000000)
+	}
+
+	return 1 + int64(fee) + timeLockPenalty + capacityPenalty
+}
+
+// edgeKey uniquely identifies a directed edge within the graph.
+type edgeKey struct {
+	chanPoint wire.OutPoint
+	from      [32]byte
+}
+
+// newEdgeKey returns the key of the passed edge.
+func newEdgeKey(edge *channeldb.ChannelEdge) edgeKey {
+	return edgeKey{
+		chanPoint: edge.ChannelPoint,
+		from:      edge.From,
+	}
+}
+
+// samePath returns true if both paths consist of the same edges.
+func samePath(a, b []*channeldb.ChannelEdge) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if newEdgeKey(a[i]) != newEdgeKey(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsPath returns true if the target path is among the passed paths.
+func containsPath(paths [][]*channeldb.ChannelEdge,
+	target []*channeldb.ChannelEdge) bool {
+
+	for _, path := range paths {
+		if samePath(path, target) {
+			return true
+		}
+	}
+
+	return false
+}
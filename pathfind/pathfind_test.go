@@ -0,0 +1,222 @@
+package pathfind
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/chaincfg"
+	"github.com/roasbeef/btcd/wire"
+)
+
+// testGraph creates a channel graph of the following shape, with the source
+// node S, and the target node T:
+//
+//	     +--- A ---+
+//	     |         |
+//	S ---+         +--- T
+//	     |         |
+//	     +--- B ---+
+//
+// The path through B is cheaper, however the channel between B and T has a
+// smaller capacity.
+func testGraph() (*channeldb.DB, func(), error) {
+	tempDirName, err := ioutil.TempDir("", "pathfind")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cdb, err := channeldb.Open(tempDirName, &chaincfg.SegNet4Params)
+	if err != nil {
+		os.RemoveAll(tempDirName)
+		return nil, nil, err
+	}
+	cleanUp := func() {
+		cdb.Close()
+		os.RemoveAll(tempDirName)
+	}
+
+	if err := cdb.SetSourceNode(&channeldb.LightningNode{ID: nodeS}); err != nil {
+		cleanUp()
+		return nil, nil, err
+	}
+
+	edges := []*channeldb.ChannelEdge{
+		{
+			ChannelPoint:  wire.OutPoint{Hash: wire.ShaHash{0x01}},
+			From:          nodeS,
+			To:            nodeA,
+			Capacity:      1e6,
+			TimeLockDelta: 10,
+		},
+		{
+			ChannelPoint:  wire.OutPoint{Hash: wire.ShaHash{0x02}},
+			From:          nodeA,
+			To:            nodeT,
+			Capacity:      1e6,
+			TimeLockDelta: 20,
+			FeeBase:       10,
+			FeeRate:       1000,
+		},
+		{
+			ChannelPoint:  wire.OutPoint{Hash: wire.ShaHash{0x03}},
+			From:          nodeS,
+			To:            nodeB,
+			Capacity:      1e6,
+			TimeLockDelta: 10,
+		},
+		{
+			ChannelPoint:  wire.OutPoint{Hash: wire.ShaHash{0x04}},
+			From:          nodeB,
+			To:            nodeT,
+			Capacity:      5e4,
+			TimeLockDelta: 30,
+			FeeBase:       1,
+			FeeRate:       100,
+		},
+	}
+	for _, edge := range edges {
+		if err := cdb.AddChannelEdge(edge); err != nil {
+			cleanUp()
+			return nil, nil, err
+		}
+	}
+
+	return cdb, cleanUp, nil
+}
+
+var (
+	nodeS = [32]byte{0x01}
+	nodeA = [32]byte{0x0a}
+	nodeB = [32]byte{0x0b}
+	nodeT = [32]byte{0x0f}
+)
+
+func TestFindRoutes(t *testing.T) {
+	graph, cleanUp, err := testGraph()
+	if err != nil {
+		t.Fatalf("unable to create test graph: %v", err)
+	}
+	defer cleanUp()
+
+	// The target requires a final CLTV delta above the default, which
+	// the final hop of each route should leave it with.
+	finalCLTVDelta := uint32(DefaultFinalCLTVDelta + 11)
+	routes, err := FindRoutes(graph, nodeT, 10010000, finalCLTVDelta, 5,
+		nil, nil)
+	if err != nil {
+		t.Fatalf("unable to find routes: %v", err)
+	}
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %v", len(routes))
+	}
+
+	// The cheapest route should be through B, paying B a fee of
+	// 1000 + 10010000*100/1e6 = 2001 mSAT.
+	route := routes[0]
+	if route.Hops[0].Channel.To != nodeB {
+		t.Fatalf("expected first route to go through B")
+	}
+	if route.TotalFees != 2001 || route.TotalAmount != 10012001 {
+		t.Fatalf("wrong fees for route: fees=%v, amt=%v",
+			route.TotalFees, route.TotalAmount)
+	}
+	if route.Hops[1].AmtToForward != 10010000 || route.Hops[1].Fee != 2001 {
+		t.Fatalf("wrong final hop: amt=%v, fee=%v",
+			route.Hops[1].AmtToForward, route.Hops[1].Fee)
+	}
+	if route.Hops[1].Expiry != finalCLTVDelta {
+		t.Fatalf("expected final hop expiry of %v, got %v",
+			finalCLTVDelta, route.Hops[1].Expiry)
+	}
+	if route.TotalTimeLock != finalCLTVDelta+30 {
+		t.Fatalf("expected total time-lock of %v, got %v",
+			finalCLTVDelta+30, route.TotalTimeLock)
+	}
+
+	// The second route should go through A, paying A a fee of
+	// 10000 + 10010000*1000/1e6 = 20010 mSAT.
+	route = routes[1]
+	if route.Hops[0].Channel.To != nodeA {
+		t.Fatalf("expected second route to go through A")
+	}
+	if route.TotalFees != 20010 || route.TotalAmount != 10030010 {
+		t.Fatalf("wrong fees for route: fees=%v, amt=%v",
+			route.TotalFees, route.TotalAmount)
+	}
+}
+
+func TestFindRoutesBandwidth(t *testing.T) {
+	graph, cleanUp, err := testGraph()
+	if err != nil {
+		t.Fatalf("unable to create test graph: %v", err)
+	}
+	defer cleanUp()
+
+	// The channel between B and T can't carry the payment, so only the
+	// route through A should be found.
+	routes, err := FindRoutes(graph, nodeT, 1e8, DefaultFinalCLTVDelta, 5,
+		nil, nil)
+	if err != nil {
+		t.Fatalf("unable to find routes: %v", err)
+	}
+	if len(routes) != 1 || routes[0].Hops[0].Channel.To != nodeA {
+		t.Fatalf("expected a single route through A")
+	}
+
+	// If our own channel with A lacks the local balance to send the
+	// payment, then no route should be found at all.
+	hints := map[wire.OutPoint]lnwire.MilliSatoshi{
+		{Hash: wire.ShaHash{0x01}}: 5e7,
+	}
+	_, err = FindRoutes(graph, nodeT, 1e8, DefaultFinalCLTVDelta, 5, hints,
+		nil)
+	if err != ErrNoPathFound {
+		t.Fatalf("expected ErrNoPathFound, got %v", err)
+	}
+
+	var unknownNode [32]byte
+	unknownNode[0] = 0xff
+	_, err = FindRoutes(graph, unknownNode, 1e8, DefaultFinalCLTVDelta, 1,
+		nil, nil)
+	if err != ErrTargetNotInGraph {
+		t.Fatalf("expected ErrTargetNotInGraph, got %v", err)
+	}
+}
+
+// penalizeNode penalizes all edges out of a single node.
+type penalizeNode [32]byte
+
+func (p penalizeNode) EdgePenalty(edge *channeldb.ChannelEdge,
+	amt lnwire.MilliSatoshi) int64 {
+
+	if edge.From == [32]byte(p) {
+		return 1000000
+	}
+	return 0
+}
+
+func TestFindRoutesPenalty(t *testing.T) {
+	graph, cleanUp, err := testGraph()
+	if err != nil {
+		t.Fatalf("unable to create test graph: %v", err)
+	}
+	defer cleanUp()
+
+	// With B penalized, the more expensive route through A should now be
+	// preferred.
+	routes, err := FindRoutes(graph, nodeT, 10010000,
+		DefaultFinalCLTVDelta, 1, nil, penalizeNode(nodeB))
+	if err != nil {
+		t.Fatalf("unable to find routes: %v", err)
+	}
+	if routes[0].Hops[0].Channel.To != nodeA {
+		t.Fatalf("expected route to go through A")
+	}
+	if routes[0].TotalFees != 20010 {
+		t.Fatalf("penalty shouldn't affect fees, got %v",
+			routes[0].TotalFees)
+	}
+}
@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/pathfind"
+	"github.com/roasbeef/btcd/wire"
+)
+
+const (
+	// defaultPaymentTimeout is the time after which no further attempts
+	// are made to deliver a payment, if the request doesn't specify one.
+	defaultPaymentTimeout = 60 * time.Second
+
+	// defaultFeeLimitPercent is the maximum fee paid for a payment, as a
+	// percentage of its amount, if the request doesn't specify one.
+	defaultFeeLimitPercent = 5
+
+	// numRouteCandidates is the number of routes requested from path
+	// finding for each attempt, allowing routes which have already been
+	// tried, or exceed the fee limit to be skipped.
+	numRouteCandidates = 5
+
+	// minShardAmt is the smallest amount a payment is split into when no
+	// single route is able to carry the full amount.
+	minShardAmt lnwire.MilliSatoshi = 1000000
+)
+
+var (
+	// errPaymentShutdown is returned if the server shuts down while some
+	// shards of a payment are still in flight.
+	errPaymentShutdown = fmt.Errorf("server shutting down with payment " +
+		"in flight")
+)
+
+// paymentRequest describes a payment to be sent by the paymentController.
+type paymentRequest struct {
+	// dest is the lightning ID of the final node of the payment.
+	dest [32]byte
+
+	// amt is the amount to deliver to the final node.
+	amt lnwire.MilliSatoshi
+
+	// finalCLTVDelta is the number of blocks the HTLCs arriving at the
+	// final node remain valid for, as required by the receiver.
+	finalCLTVDelta uint32
+
+	// payHash is the payment hash of the payment's HTLCs.
+	payHash [32]byte
+
+	// contractType, and redemptionHashes are set when paying an escrow
+	// invoice, whose HTLCs require the pre-images of N of the M
+	// redemption hashes, as encoded by the contract type. The payment
+	// hash is then the first of the redemption hashes.
+	contractType     uint8
+	redemptionHashes [][32]byte
+
+	// feeLimit is the maximum total fee the payment may pay.
+	feeLimit lnwire.MilliSatoshi
+
+	// timeout is the time after which no further attempts are made.
+	timeout time.Duration
+}
+
+// paymentController drives the lifecycle of the payments initiated by the
+// daemon itself. For each payment, it finds a route, dispatches an HTLC over
+// it through the htlcSwitch, and interprets the outcome. A payment which no
+// single route can carry is split into several shards sharing its payment
+// hash, each sent over its own route. If an HTLC is cancelled, then the
+// failing channel or node is penalized within mission control, and another
+// route is attempted until the payment succeeds, the fee limit prevents any
+// further routes, or the timeout expires.
+type paymentController struct {
+	server *server
+
+	missionControl *missionControl
+}
+
+// newPaymentController creates a new paymentController which sends payments
+// through the passed server's htlcSwitch.
+func newPaymentController(s *server) *paymentController {
+	return &paymentController{
+		server:         s,
+		missionControl: newMissionControl(),
+	}
+}
+
+// reconcilePayments marks the payments left in flight by an earlier run as
+// interrupted. The HTLCs of a channel don't survive a restart, so no circuit
+// or channel state remains through which their outcome could be learnt.
+// Interrupted payments still prevent their hash from being paid again, as
+// their HTLCs may yet be claimed by the receiver.
+func (p *paymentController) reconcilePayments() error {
+	numInterrupted, err := p.server.chanDB.InterruptPayments()
+	if err != nil {
+		return err
+	}
+	if numInterrupted > 0 {
+		pymtLog.Warnf("%v payments were in flight at shutdown, marked "+
+			"as interrupted", numInterrupted)
+	}
+
+	return nil
+}
+
+// sendPayment delivers the requested payment, returning the pre-images
+// revealed by the receiver along with the routes of the successful shards.
+// The first pre-image is that of the payment hash, unless an escrow invoice
+// was paid, in which case its settlement may instead reveal the pre-images
+// of any N of its redemption hashes. The payment,
+// and each attempt are recorded within the payment store. Payments of the
+// debug hash aren't recorded, as the debug invoice may be paid any number of
+// times.
+//
+// NOTE: The timeout only prevents further attempts from being made, an
+// attempt which is already in flight is always waited upon, as its HTLC may
+// still be settled. If the server shuts down first, then the payment is left
+// in flight, and marked as interrupted once the server restarts.
+func (p *paymentController) sendPayment(req *paymentRequest) ([][32]byte,
+	[]*pathfind.Route, error) {
+
+	if req.payHash == [32]byte(debugHash) {
+		return p.attemptPayment(req)
+	}
+
+	payment := &channeldb.OutgoingPayment{
+		PaymentHash:  req.payHash,
+		Value:        req.amt,
+		Status:       channeldb.StatusInFlight,
+		CreationDate: time.Now(),
+	}
+	if err := p.server.chanDB.InitPayment(payment); err != nil {
+		return nil, nil, err
+	}
+
+	preimages, routes, err := p.attemptPayment(req)
+	if err == errPaymentShutdown {
+		return nil, nil, err
+	} else if err != nil {
+		failErr := p.server.chanDB.FailPayment(req.payHash, err.Error())
+		if failErr != nil {
+			pymtLog.Errorf("unable to fail payment %x: %v",
+				req.payHash[:], failErr)
+		}
+		return nil, nil, err
+	}
+
+	err = p.server.chanDB.SettlePayment(req.payHash, preimages[0])
+	if err != nil {
+		pymtLog.Errorf("unable to settle payment %x: %v",
+			req.payHash[:], err)
+	}
+
+	return preimages, routes, nil
+}
+
+// shardAttempt is a single shard of a payment, sent over a route.
+type shardAttempt struct {
+	route   *pathfind.Route
+	attempt *channeldb.PaymentAttempt
+
+	// result is the outcome of the shard's HTLC.
+	result *paymentResult
+}
+
+// attemptPayment delivers the payment as one or more shards which share its
+// payment hash. Shards are dispatched over the next best untried routes
+// until the full amount is in flight. If no route can carry a shard, then
+// the shard is split in half, down to minShardAmt. The amount of each failed
+// shard is re-sent over other routes, until either the receiver settles all
+// the shards, or no further attempts can be made.
+func (p *paymentController) attemptPayment(req *paymentRequest) ([][32]byte,
+	[]*pathfind.Route, error) {
+
+	deadline := time.Now().Add(req.timeout)
+	triedRoutes := make(map[string]struct{})
+	results := make(chan *shardAttempt)
+
+	var (
+		remaining     = req.amt
+		maxShardAmt   = req.amt
+		feesCommitted lnwire.MilliSatoshi
+		numInFlight   int
+		numAttempts   int
+
+		settled       bool
+		preimages     [][32]byte
+		settledRoutes []*pathfind.Route
+
+		// sendErr is set once no further shards can be sent, and
+		// lastErr is the failure of the latest failed shard.
+		sendErr error
+		lastErr error
+	)
+	for {
+		// Dispatch shards until the full amount is in flight. Once a
+		// shard has been settled, the receiver holds the full amount,
+		// so no further shards are needed.
+		for remaining > 0 && !settled && sendErr == nil {
+			if numAttempts > 0 && time.Now().After(deadline) {
+				sendErr = fmt.Errorf("payment timed out after "+
+					"%v attempts", numAttempts)
+				break
+			}
+
+			shardAmt := remaining
+			if shardAmt > maxShardAmt {
+				shardAmt = maxShardAmt
+			}
+
+			route, err := p.nextRoute(req, shardAmt,
+				req.feeLimit-feesCommitted, triedRoutes)
+			switch {
+			case (err == pathfind.ErrNoPathFound ||
+				err == errNoUntriedRoute) && shardAmt/2 >= minShardAmt:
+
+				maxShardAmt = shardAmt / 2
+				continue
+			case err != nil:
+				sendErr = err
+				continue
+			}
+
+			attempt := &channeldb.PaymentAttempt{
+				Path:        routePath(route),
+				Amount:      shardAmt,
+				Fee:         route.TotalFees,
+				AttemptTime: time.Now(),
+			}
+			err = p.dispatchShard(req, route, attempt, results)
+			if err != nil {
+				sendErr = err
+				continue
+			}
+
+			numAttempts++
+			numInFlight++
+			remaining -= shardAmt
+			feesCommitted += route.TotalFees
+		}
+
+		if numInFlight == 0 {
+			if settled {
+				return preimages, settledRoutes, nil
+			}
+			if lastErr != nil {
+				return nil, nil, fmt.Errorf("%v, last "+
+					"failure: %v", sendErr, lastErr)
+			}
+			return nil, nil, sendErr
+		}
+
+		// Wait for the outcome of the next shard in flight.
+		var shard *shardAttempt
+		select {
+		case shard = <-results:
+		case <-p.server.quit:
+			return nil, nil, errPaymentShutdown
+		}
+		numInFlight--
+
+		var permanent bool
+		if shard.result.settled {
+			settled = true
+			preimages = shard.result.preimages
+			settledRoutes = append(settledRoutes, shard.route)
+		} else {
+			permanent, lastErr = p.processFailure(shard.route,
+				shard.result)
+			shard.attempt.FailureReason = lastErr.Error()
+
+			// The amount of the failed shard must now be sent
+			// over another route.
+			triedRoutes[shardKey(shard.route)] = struct{}{}
+			remaining += shard.attempt.Amount
+			feesCommitted -= shard.route.TotalFees
+		}
+
+		if req.payHash != [32]byte(debugHash) {
+			err := p.server.chanDB.AddPaymentAttempt(req.payHash,
+				shard.attempt)
+			if err != nil {
+				pymtLog.Errorf("unable to record attempt of "+
+					"payment %x: %v", req.payHash[:], err)
+			}
+		}
+
+		switch {
+		case settled:
+		case permanent:
+			sendErr = lastErr
+		default:
+			pymtLog.Debugf("shard of %v of payment %x failed: %v, "+
+				"retrying", shard.attempt.Amount, req.payHash[:],
+				lastErr)
+		}
+	}
+}
+
+var (
+	// errNoUntriedRoute is returned by nextRoute if every route found has
+	// already failed to carry a shard of the same amount.
+	errNoUntriedRoute = fmt.Errorf("no untried route to destination")
+)
+
+// nextRoute returns the best route carrying the passed amount to the
+// payment's destination, which hasn't yet failed to carry a shard of the same
+// amount, and whose fees are within the passed fee budget. The bandwidth of
+// our own channels is that reported by the switch, which already excludes the
+// shards still in flight.
+func (p *paymentController) nextRoute(req *paymentRequest,
+	amt lnwire.MilliSatoshi, feeBudget lnwire.MilliSatoshi,
+	triedRoutes map[string]struct{}) (*pathfind.Route, error) {
+
+	bandwidthHints, err := p.server.htlcSwitch.LinkBandwidths()
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := pathfind.FindRoutes(p.server.chanDB, req.dest, amt,
+		req.finalCLTVDelta, numRouteCandidates, bandwidthHints,
+		p.missionControl)
+	if err != nil {
+		return nil, err
+	}
+
+	var feeLimitExceeded bool
+	for _, route := range routes {
+		if _, ok := triedRoutes[shardKey(route)]; ok {
+			continue
+		}
+		if route.TotalFees > feeBudget {
+			feeLimitExceeded = true
+			continue
+		}
+
+		return route, nil
+	}
+
+	if feeLimitExceeded {
+		return nil, fmt.Errorf("no untried route within remaining "+
+			"fee budget of %v", feeBudget)
+	}
+	return nil, errNoUntriedRoute
+}
+
+// dispatchShard sends the HTLC of a shard of the payment over the passed
+// route. Once the HTLC is resolved, the shard is delivered over the results
+// channel along with its outcome.
+//
+// TODO(roasbeef): fail the attempt if the outgoing link is closed before the
+// HTLC is resolved.
+func (p *paymentController) dispatchShard(req *paymentRequest,
+	route *pathfind.Route, attempt *channeldb.PaymentAttempt,
+	results chan<- *shardAttempt) error {
+
+	redemptionHashes := req.redemptionHashes
+	if len(redemptionHashes) == 0 {
+		redemptionHashes = [][32]byte{req.payHash}
+	}
+
+	firstHop := route.Hops[0].Channel
+	htlcPkt := &htlcPacket{
+		dest:         wire.ShaHash(firstHop.To),
+		payHash:      req.payHash,
+		outgoingChan: &firstHop.ChannelPoint,
+		result:       make(chan *paymentResult, 1),
+		msg: &lnwire.HTLCAddRequest{
+			Expiry:           route.TotalTimeLock,
+			Amount:           route.TotalAmount,
+			ContractType:     req.contractType,
+			RedemptionHashes: redemptionHashes,
+			OnionBlob:        encodeHopPayloads(newRoutePayloads(route)),
+		},
+	}
+	if err := p.server.htlcSwitch.SendHTLC(htlcPkt); err != nil {
+		return err
+	}
+
+	go func() {
+		var result *paymentResult
+		select {
+		case result = <-htlcPkt.result:
+		case <-p.server.quit:
+			return
+		}
+
+		shard := &shardAttempt{
+			route:   route,
+			attempt: attempt,
+			result:  result,
+		}
+		select {
+		case results <- shard:
+		case <-p.server.quit:
+		}
+	}()
+
+	return nil
+}
+
+// processFailure penalizes the channel or node responsible for the
+// cancellation of an attempt within mission control, returning an error
+// describing the failure. If the failure is permanent, meaning no other
+// route would succeed, then true is returned.
+func (p *paymentController) processFailure(route *pathfind.Route,
+	result *paymentResult) (bool, error) {
+
+	failErr := fmt.Errorf("%v from node %x", result.failCode,
+		result.erringNode[:])
+
+	// Locate the erring node within the route, along with the edge it
+	// was unable to forward the HTLC across, if any. Our own node is the
+	// start of the first hop.
+	erringIndex := -1
+	switch {
+	case result.erringNode == p.server.lightningID:
+		erringIndex = 0
+	default:
+		for i, hop := range route.Hops {
+			if hop.Channel.To == result.erringNode {
+				erringIndex = i + 1
+				break
+			}
+		}
+	}
+
+	// If the erring node isn't within the route, then the failure can't
+	// be attributed, so the entire route is penalized.
+	if erringIndex == -1 {
+		for _, hop := range route.Hops {
+			p.missionControl.reportEdgeFailure(hop.Channel)
+		}
+		return false, failErr
+	}
+
+	var outgoingEdge *channeldb.ChannelEdge
+	if erringIndex < len(route.Hops) {
+		outgoingEdge = route.Hops[erringIndex].Channel
+	}
+
+	switch result.failCode {
+	// If the final node gave up waiting for the rest of the shards of the
+	// payment, then no node along the route is at fault.
+	case lnwire.FailCodeMPPTimeout:
+
+	// If the final node doesn't know the payment hash, or refuses to
+	// settle the invoice, then no other route will succeed.
+	case lnwire.FailCodeUnknownPaymentHash,
+		lnwire.FailCodeFinalExpiryTooSoon,
+		lnwire.FailCodeInvoiceExpired,
+		lnwire.FailCodeInvoiceAlreadyPaid:
+
+		if outgoingEdge == nil {
+			return true, failErr
+		}
+		p.missionControl.reportNodeFailure(result.erringNode)
+
+	// Failures to forward across the next channel only penalize that
+	// channel, as the erring node may well be able to forward across
+	// its other channels.
+	case lnwire.FailCodeUnknownNextPeer, lnwire.FailCodeChannelPolicy,
+		lnwire.FailCodeInsufficientCapacity:
+
+		if outgoingEdge != nil {
+			p.missionControl.reportEdgeFailure(outgoingEdge)
+		} else {
+			p.missionControl.reportNodeFailure(result.erringNode)
+		}
+
+	default:
+		p.missionControl.reportNodeFailure(result.erringNode)
+	}
+
+	return false, failErr
+}
+
+// newRoutePayloads returns the hop payloads instructing each node along the
+// route, after our own, how to forward the HTLC.
+func newRoutePayloads(route *pathfind.Route) []*hopPayload {
+	payloads := make([]*hopPayload, len(route.Hops))
+	for i, hop := range route.Hops {
+		// The final node receives an exit payload, describing the HTLC
+		// which should reach it.
+		if i == len(route.Hops)-1 {
+			payloads[i] = &hopPayload{
+				amtToForward:   hop.AmtToForward,
+				outgoingExpiry: hop.Expiry,
+			}
+			continue
+		}
+
+		nextHop := route.Hops[i+1]
+		payloads[i] = &hopPayload{
+			nextNode:       nextHop.Channel.To,
+			amtToForward:   nextHop.AmtToForward,
+			outgoingExpiry: nextHop.Expiry,
+		}
+	}
+
+	return payloads
+}
+
+// routePath returns the lightning ID of each hop within the route.
+func routePath(route *pathfind.Route) [][32]byte {
+	path := make([][32]byte, len(route.Hops))
+	for i, hop := range route.Hops {
+		path[i] = hop.Channel.To
+	}
+
+	return path
+}
+
+// shardKey returns a key uniquely identifying the channels of the route, and
+// the amount it carries.
+func shardKey(route *pathfind.Route) string {
+	var b bytes.Buffer
+	for _, hop := range route.Hops {
+		b.WriteString(hop.Channel.ChannelPoint.String())
+		b.WriteString(hex.EncodeToString(hop.Channel.From[:]))
+	}
+	fmt.Fprintf(&b, ":%d", route.TotalAmount)
+
+	return b.String()
+}
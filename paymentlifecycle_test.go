@@ -0,0 +1,160 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/pathfind"
+	"github.com/roasbeef/btcd/wire"
+)
+
+func TestProcessPaymentFailure(t *testing.T) {
+	selfID := [32]byte{0x01}
+	nodeA := [32]byte{0x02}
+	nodeB := [32]byte{0x03}
+
+	edgeSA := &channeldb.ChannelEdge{
+		ChannelPoint: wire.OutPoint{Hash: wire.ShaHash{0x10}},
+		From:         selfID,
+		To:           nodeA,
+	}
+	edgeAB := &channeldb.ChannelEdge{
+		ChannelPoint: wire.OutPoint{Hash: wire.ShaHash{0x11}},
+		From:         nodeA,
+		To:           nodeB,
+	}
+	route := &pathfind.Route{
+		Hops: []*pathfind.Hop{
+			{Channel: edgeSA, AmtToForward: 1001, Expiry: 29},
+			{Channel: edgeAB, AmtToForward: 1000, Expiry: 9},
+		},
+	}
+
+	tests := []struct {
+		failCode      lnwire.FailCode
+		erringNode    [32]byte
+		permanent     bool
+		penalizedEdge *channeldb.ChannelEdge
+		penalizedNode [32]byte
+	}{
+		// A's outgoing channel lacked capacity, so only the channel
+		// between A and B should be penalized.
+		{
+			failCode:      lnwire.FailCodeInsufficientCapacity,
+			erringNode:    nodeA,
+			penalizedEdge: edgeAB,
+		},
+		// Our own link to A was missing.
+		{
+			failCode:      lnwire.FailCodeUnknownNextPeer,
+			erringNode:    selfID,
+			penalizedEdge: edgeSA,
+		},
+		// A was unable to process the HTLC at all.
+		{
+			failCode:      lnwire.FailCodeTemporaryNodeFailure,
+			erringNode:    nodeA,
+			penalizedNode: nodeA,
+		},
+		// The destination gave up waiting for the rest of the
+		// payment's shards, which is no fault of the route.
+		{
+			failCode:   lnwire.FailCodeMPPTimeout,
+			erringNode: nodeB,
+		},
+		// The destination doesn't know the payment hash, so no other
+		// route would succeed.
+		{
+			failCode:   lnwire.FailCodeUnknownPaymentHash,
+			erringNode: nodeB,
+			permanent:  true,
+		},
+	}
+	for i, test := range tests {
+		p := &paymentController{
+			server:         &server{lightningID: selfID},
+			missionControl: newMissionControl(),
+		}
+
+		permanent, err := p.processFailure(route, &paymentResult{
+			failCode:   test.failCode,
+			erringNode: test.erringNode,
+		})
+		if err == nil {
+			t.Fatalf("test #%v: expected failure error", i)
+		}
+		if permanent != test.permanent {
+			t.Fatalf("test #%v: expected permanent=%v, got %v", i,
+				test.permanent, permanent)
+		}
+
+		mc := p.missionControl
+		switch {
+		case test.penalizedEdge != nil:
+			if len(mc.edgeFailures) != 1 || len(mc.nodeFailures) != 0 {
+				t.Fatalf("test #%v: expected a single edge "+
+					"failure", i)
+			}
+			key := edgeDirection{
+				test.penalizedEdge.ChannelPoint,
+				test.penalizedEdge.From,
+			}
+			if _, ok := mc.edgeFailures[key]; !ok {
+				t.Fatalf("test #%v: wrong edge penalized", i)
+			}
+		case test.penalizedNode != [32]byte{}:
+			if len(mc.edgeFailures) != 0 || len(mc.nodeFailures) != 1 {
+				t.Fatalf("test #%v: expected a single node "+
+					"failure", i)
+			}
+			if _, ok := mc.nodeFailures[test.penalizedNode]; !ok {
+				t.Fatalf("test #%v: wrong node penalized", i)
+			}
+		default:
+			if len(mc.edgeFailures) != 0 || len(mc.nodeFailures) != 0 {
+				t.Fatalf("test #%v: nothing should be "+
+					"penalized", i)
+			}
+		}
+	}
+}
+
+func TestNewRoutePayloads(t *testing.T) {
+	nodeA := [32]byte{0x02}
+	nodeB := [32]byte{0x03}
+	route := &pathfind.Route{
+		Hops: []*pathfind.Hop{
+			{
+				Channel:      &channeldb.ChannelEdge{To: nodeA},
+				AmtToForward: 1001,
+				Expiry:       29,
+			},
+			{
+				Channel:      &channeldb.ChannelEdge{To: nodeB},
+				AmtToForward: 1000,
+				Expiry:       9,
+			},
+		},
+	}
+
+	// A should be instructed to forward to B, while B receives an exit
+	// payload. Each node strips its own payload before forwarding.
+	blob := encodeHopPayloads(newRoutePayloads(route))
+	hop, blob, err := decodeHopPayload(blob)
+	if err != nil {
+		t.Fatalf("unable to decode payload: %v", err)
+	}
+	if hop.nextNode != nodeB || hop.amtToForward != 1000 ||
+		hop.outgoingExpiry != 9 {
+		t.Fatalf("wrong payload for A: %+v", *hop)
+	}
+
+	hop, blob, err = decodeHopPayload(blob)
+	if err != nil {
+		t.Fatalf("unable to decode payload: %v", err)
+	}
+	if !hop.isExit() || hop.amtToForward != 1000 || len(blob) != 0 {
+		t.Fatalf("wrong payload for B: %+v", *hop)
+	}
+}
@@ -32,6 +32,19 @@ const (
 	// messages to be sent across the wire, requested by objects outside
 	// this struct.
 	outgoingQueueLen = 50
+
+	// feeUpdateInterval is the interval at which channels we've initiated
+	// compare their commitment fee rate against the current fee estimate.
+	feeUpdateInterval = 10 * time.Minute
+
+	// feeUpdateThreshold is the percentage by which the estimated fee rate
+	// must drift from a channel's commitment fee rate before the fee is
+	// updated.
+	feeUpdateThreshold = 20
+
+	// commitConfTarget is the number of blocks within which commitment
+	// transactions are targeted to confirm, should they be broadcast.
+	commitConfTarget = 3
 )
 
 // outgoinMsg packages an lnwire.Message to be sent out on the wire, along with
@@ -62,6 +75,7 @@ type peer struct {
 
 	lightningAddr *lndc.LNAdr
 	lightningID   wire.ShaHash
+	identityPub   *btcec.PublicKey
 
 	inbound bool
 	id      int32
@@ -149,6 +163,7 @@ func newPeer(conn net.Conn, server *server, net wire.BitcoinNet, inbound bool) (
 	p := &peer{
 		conn:        conn,
 		lightningID: wire.ShaHash(fastsha256.Sum256(nodePub.SerializeCompressed())),
+		identityPub: nodePub,
 		id:          atomic.AddInt32(&numNodes, 1),
 		chainNet:    net,
 		inbound:     inbound,
@@ -222,15 +237,19 @@ func (p *peer) loadActiveChannels(chans []*channeldb.OpenChannel) error {
 		// Register this new channel link with the HTLC Switch. This is
 		// necessary to properly route multi-hop payments, and forward
 		// new payments triggered by RPC clients.
-		downstreamLink := make(chan lnwire.Message)
+		downstreamLink := make(chan *htlcPacket)
+		bandwidth := &linkBandwidth{
+			bandwidth: lnChan.AvailableBandwidth(),
+		}
 		plexChan := p.server.htlcSwitch.RegisterLink(p,
-			dbChan.Snapshot(), downstreamLink)
+			dbChan.Snapshot(), downstreamLink, bandwidth)
 
 		// TODO(roasbeef): buffer?
 		upstreamLink := make(chan lnwire.Message)
 		p.htlcManagers[chanPoint] = upstreamLink
 		p.wg.Add(1)
-		go p.htlcManager(lnChan, plexChan, downstreamLink, upstreamLink)
+		go p.htlcManager(lnChan, plexChan, downstreamLink, upstreamLink,
+			bandwidth)
 	}
 
 	return nil
@@ -360,9 +379,15 @@ out:
 		case *lnwire.HTLCAddRequest:
 			isChanUpate = true
 			targetChan = msg.ChannelPoint
+		case *lnwire.UpdateFee:
+			isChanUpate = true
+			targetChan = msg.ChannelPoint
 		case *lnwire.HTLCSettleRequest:
 			isChanUpate = true
 			targetChan = msg.ChannelPoint
+		case *lnwire.HTLCTimeoutRequest:
+			isChanUpate = true
+			targetChan = msg.ChannelPoint
 		case *lnwire.CommitRevocation:
 			isChanUpate = true
 			targetChan = msg.ChannelPoint
@@ -378,6 +403,10 @@ out:
 		*lnwire.RoutingTableTransferMessage:
 			p.server.routingMgr.ChIn <- msg
 			// TODO(mkl): determine sender and receiver of message
+		case *lnwire.ChannelAnnouncement, *lnwire.NodeAnnouncement,
+			*lnwire.ChannelUpdateAnnouncement,
+			*lnwire.AnnounceSignatures:
+			p.server.gossiper.ProcessAnnouncement(msg, p)
 		}
 
 		if isChanUpate {
@@ -579,9 +608,12 @@ out:
 			// Now that the channel is open, notify the Htlc
 			// Switch of a new active link.
 			chanSnapShot := newChan.StateSnapshot()
-			downstreamLink := make(chan lnwire.Message)
+			downstreamLink := make(chan *htlcPacket)
+			bandwidth := &linkBandwidth{
+				bandwidth: newChan.AvailableBandwidth(),
+			}
 			plexChan := p.server.htlcSwitch.RegisterLink(p,
-				chanSnapShot, downstreamLink)
+				chanSnapShot, downstreamLink, bandwidth)
 
 			// With the channel registered to the HtlcSwitch spawn
 			// a goroutine to handle commitment updates for this
@@ -589,7 +621,8 @@ out:
 			upstreamLink := make(chan lnwire.Message)
 			p.htlcManagers[chanPoint] = upstreamLink
 			p.wg.Add(1)
-			go p.htlcManager(newChan, plexChan, downstreamLink, upstreamLink)
+			go p.htlcManager(newChan, plexChan, downstreamLink,
+				upstreamLink, bandwidth)
 
 			// Close the active channel barrier signalling the
 			// readHandler that commitment related modifications to
@@ -628,7 +661,7 @@ func (p *peer) handleLocalClose(req *closeLinkReq) {
 	// generates a signature for the closing tx, as well as a txid of the
 	// closing tx itself, allowing us to watch the network to determine
 	// when the remote node broadcasts the fully signed closing transaction.
-	sig, txid, err := channel.InitCooperativeClose()
+	sig, txid, err := channel.InitCooperativeClose(req.feePerKb)
 	if err != nil {
 		req.resp <- nil
 		req.err <- err
@@ -648,7 +681,7 @@ func (p *peer) handleLocalClose(req *closeLinkReq) {
 		req.err <- err
 		return
 	}
-	closeReq := lnwire.NewCloseRequest(chanPoint, closeSig)
+	closeReq := lnwire.NewCloseRequest(chanPoint, closeSig, req.feePerKb)
 	p.queueMsg(closeReq, nil)
 
 	// Finally, launch a goroutine which will request to be notified by the
@@ -703,7 +736,7 @@ func (p *peer) handleRemoteClose(req *lnwire.CloseRequest) {
 	// signature.
 	sig := req.RequesterCloseSig
 	closeSig := append(sig.Serialize(), byte(txscript.SigHashAll))
-	closeTx, err := channel.CompleteCooperativeClose(closeSig)
+	closeTx, err := channel.CompleteCooperativeClose(closeSig, req.Fee)
 	if err != nil {
 		peerLog.Errorf("unable to complete cooperative "+
 			"close for ChannelPoint(%v): %v",
@@ -716,7 +749,7 @@ func (p *peer) handleRemoteClose(req *lnwire.CloseRequest) {
 	peerLog.Infof("Broadcasting cooperative close tx: %v", newLogClosure(func() string {
 		return spew.Sdump(closeTx)
 	}))
-	if err := p.server.lnwallet.PublishTransaction(closeTx); err != nil {
+	if err := p.server.lnwallet.BroadcastTransaction(closeTx); err != nil {
 		peerLog.Errorf("channel close tx from "+
 			"ChannelPoint(%v) rejected: %v",
 			chanPoint, err)
@@ -748,6 +781,20 @@ func wipeChannel(p *peer, channel *lnwallet.LightningChannel) {
 		peerLog.Errorf("Unable to delete ChannelPoint(%v) "+
 			"from db %v", chanID, err)
 	}
+
+	// The channel is now closed, so it's no longer usable for routing.
+	_, err := p.server.chanDB.PruneGraph([]*wire.OutPoint{chanID})
+	if err != nil {
+		peerLog.Errorf("Unable to prune ChannelPoint(%v) from "+
+			"channel graph: %v", chanID, err)
+	}
+}
+
+// pendingCancel is a locked-in incoming HTLC which is to be cancelled, along
+// with the reason it was rejected.
+type pendingCancel struct {
+	logIndex uint32
+	failCode lnwire.FailCode
 }
 
 // commitmentState is the volatile+persistent state of an active channel's
@@ -756,8 +803,37 @@ func wipeChannel(p *peer, channel *lnwallet.LightningChannel) {
 type commitmentState struct {
 	pendingLogLen uint32
 
-	htlcsToSettle [][32]byte
-	sigPending    bool
+	// htlcsToCancel are the locked-in incoming HTLCs which were rejected
+	// by the invoice registry, and are to be cancelled.
+	htlcsToCancel []*pendingCancel
+
+	// rejectedAdds are the log indexes of the incoming HTLCs which violate
+	// the flow-control limits we've imposed on the remote node, along with
+	// the code they're to be failed with. The remote node may already have
+	// signed a commitment including them, so they're cancelled once
+	// locked in.
+	rejectedAdds map[uint32]lnwire.FailCode
+
+	// heldShards are the log indexes of the locked-in incoming HTLCs held
+	// by the invoice registry until the invoice total arrives, indexed by
+	// their payment hash.
+	heldShards map[[32]byte][]uint32
+
+	// circuits maps the log index of each outgoing HTLC sent on behalf of
+	// the switch to the ID of the HTLC's circuit.
+	circuits map[uint32]uint64
+
+	// bandwidth is updated with the channel's available bandwidth after
+	// each update to the commitment state, and numAdds is the number of
+	// HTLCs received from the switch which have been processed.
+	bandwidth *linkBandwidth
+	numAdds   uint64
+
+	// pendingUpdates is the number of updates added to the channel's log
+	// which aren't yet covered by a commitment we've signed.
+	pendingUpdates int
+
+	sigPending bool
 
 	channel   *lnwallet.LightningChannel
 	chanPoint *wire.OutPoint
@@ -771,10 +847,12 @@ type commitmentState struct {
 // used which sends htlc packets to the switch for forwarding. Additionally,
 // the htlcManager handles acting upon all timeouts for any active HTLC's,
 // manages the channel's revocation window, and also the htlc trickle
-// queue+timer for this active channels.
+// queue+timer for this active channels. After each update to the channel's
+// commitment state, the channel's available bandwidth is reported to the
+// switch through the passed linkBandwidth.
 func (p *peer) htlcManager(channel *lnwallet.LightningChannel,
-	htlcPlex chan<- *htlcPacket, downstreamLink <-chan lnwire.Message,
-	upstreamLink <-chan lnwire.Message) {
+	htlcPlex chan<- *htlcPacket, downstreamLink <-chan *htlcPacket,
+	upstreamLink <-chan lnwire.Message, bandwidth *linkBandwidth) {
 
 	chanStats := channel.StateSnapshot()
 	peerLog.Tracef("HTLC manager for ChannelPoint(%v) started, "+
@@ -794,29 +872,99 @@ func (p *peer) htlcManager(channel *lnwallet.LightningChannel,
 	}
 
 	state := &commitmentState{
-		channel:   channel,
-		chanPoint: channel.ChannelPoint(),
+		rejectedAdds: make(map[uint32]lnwire.FailCode),
+		heldShards:   make(map[[32]byte][]uint32),
+		circuits:     make(map[uint32]uint64),
+		bandwidth:    bandwidth,
+		channel:      channel,
+		chanPoint:    channel.ChannelPoint(),
+	}
+
+	// Incoming HTLCs paying to our invoices are held by the invoice
+	// registry, which delivers their resolution once the invoice total
+	// has arrived, or the shards have been held for too long.
+	shardResolutions := make(chan *shardResolution)
+	holder := &shardHolder{
+		resolutions: shardResolutions,
+		quit:        p.quit,
 	}
+
+	// Rather than signing a new commitment for each update we add to the
+	// log, the updates are batched, then committed once either the batch
+	// is full, or the trickle timer ticks.
+	batchTicker := time.NewTicker(p.server.trickleDelay)
+	defer batchTicker.Stop()
+
+	// If we initiated the channel, then we periodically check whether the
+	// commitment fee rate should be updated to track the on-chain fee
+	// rate.
+	feeTicker := time.NewTicker(feeUpdateInterval)
+	defer feeTicker.Stop()
 out:
 	for {
 		select {
-		case msg := <-downstreamLink:
-			switch htlc := msg.(type) {
+		case pkt := <-downstreamLink:
+			switch htlc := pkt.msg.(type) {
 			case *lnwire.HTLCAddRequest:
 				// A new payment has been initiated via the
 				// downstream channel, so we add the new HTLC
 				// to our local log, then update the commitment
 				// chains.
-				channel.AddHTLC(htlc, false)
+				state.numAdds++
+				logIndex, err := channel.AddHTLC(htlc, false)
+				if err != nil {
+					// Rather than sending an HTLC the
+					// remote node would reject, it's
+					// failed back along its circuit.
+					peerLog.Errorf("unable to add htlc: %v", err)
+					failCode, _ := rejectFailCode(err)
+					p.sendToSwitch(htlcPlex, p.newRejectPacket(state,
+						pkt.circuitID, htlc, failCode))
+					state.reportBandwidth()
+					continue
+				}
+				state.circuits[logIndex] = pkt.circuitID
+				p.queueMsg(htlc, nil)
+			case *lnwire.HTLCSettleRequest:
+				// An HTLC we forwarded has been settled by the
+				// next hop, so we can now settle the incoming
+				// HTLC with the same pre-images, claiming the
+				// fee we charged for the forward.
+				pre := htlc.RedemptionProofs
+				logIndex := uint32(htlc.HTLCKey)
+				_, err := channel.SettleIndexedHTLC(pre, logIndex, false)
+				if err != nil {
+					peerLog.Errorf("unable to settle "+
+						"forwarded htlc: %v", err)
+					continue
+				}
 				p.queueMsg(htlc, nil)
 
-				// TODO(roasbeef): batch trickle timer + cap
-				if err := p.updateCommitTx(state); err != nil {
-					peerLog.Errorf("unable to update "+
-						"commitment: %v", err)
+				if err := channel.AddForwardingFee(pkt.fee); err != nil {
+					peerLog.Errorf("unable to record "+
+						"forwarding fee: %v", err)
+				}
+			case *lnwire.HTLCTimeoutRequest:
+				// The incoming HTLC either couldn't be
+				// forwarded, or was timed out by a later hop,
+				// so we time it out in turn.
+				logIndex := uint32(htlc.HTLCKey)
+				if err := channel.TimeoutHTLC(logIndex); err != nil {
+					peerLog.Errorf("unable to timeout "+
+						"htlc: %v", err)
+					continue
 				}
-				state.sigPending = true
+				p.queueMsg(htlc, nil)
+			default:
+				continue
 			}
+
+			state.pendingUpdates++
+			p.maybeCommitBatch(state)
+		case <-batchTicker.C:
+			p.commitBatch(state)
+		case <-feeTicker.C:
+			p.maybeUpdateFee(state)
 		case msg, ok := <-upstreamLink:
 			// If the upstream message link is closed, this signals
 			// that the channel itself is being closed, therefore
@@ -830,26 +978,77 @@ out:
 			case *lnwire.HTLCAddRequest:
 				// We just received an add request from an
 				// upstream peer, so we add it to our state
-				// machine, then add the HTLC to our "settle"
-				// list in the event that we know the pre-image
-				channel.AddHTLC(htlcPkt, true)
-
-				rHash := htlcPkt.RedemptionHashes[0]
-				if invoice, found := p.server.invoices.lookupInvoice(rHash); found {
-					// TODO(roasbeef): check value
-					//  * onion layer strip should also be before invoice lookup
-					pre := invoice.paymentPreimage
-					state.htlcsToSettle = append(state.htlcsToSettle, pre)
+				// machine. Once it's locked in, it's either
+				// forwarded, or matched against our invoices.
+				logIndex, err := channel.AddHTLC(htlcPkt, true)
+				if err == nil {
+					continue
+				}
+
+				// HTLCs violating the flow-control limits
+				// we've imposed on the remote node are
+				// cancelled once locked in, any other failure
+				// is fatal.
+				failCode, ok := rejectFailCode(err)
+				if !ok {
+					peerLog.Errorf("unable to add htlc: %v", err)
+					p.Disconnect()
+					break out
+				}
+
+				peerLog.Warnf("rejecting htlc from %v: %v", p, err)
+				state.rejectedAdds[logIndex] = failCode
+			case *lnwire.UpdateFee:
+				// The remote node has updated the fee rate of
+				// the commitment transactions, which will be
+				// committed to within the next state.
+				err := channel.ReceiveUpdateFee(htlcPkt.FeePerKb)
+				if err != nil {
+					peerLog.Errorf("unable to apply fee "+
+						"update: %v", err)
+					p.Disconnect()
+					break out
 				}
 			case *lnwire.HTLCSettleRequest:
-				// TODO(roasbeef): this assumes no "multi-sig"
-				pre := htlcPkt.RedemptionProofs[0]
-				if _, err := channel.SettleHTLC(pre, true); err != nil {
+				pre := htlcPkt.RedemptionProofs
+				logIndex := uint32(htlcPkt.HTLCKey)
+				payHash, err := channel.SettleIndexedHTLC(pre, logIndex, true)
+				if err != nil {
 					// TODO(roasbeef): broadcast on-chain
 					peerLog.Errorf("settle for outgoing HTLC rejected: %v", err)
 					p.Disconnect()
 					break out
 				}
+
+				// The switch propagates the settle backwards
+				// along the HTLC's circuit, either to the
+				// channel it was forwarded from, or to the
+				// local payment which sent it.
+				circuitID := state.circuits[logIndex]
+				delete(state.circuits, logIndex)
+				p.sendToSwitch(htlcPlex, &htlcPacket{
+					payHash:   payHash,
+					srcLink:   state.chanPoint,
+					circuitID: circuitID,
+					msg:       htlcPkt,
+				})
+			case *lnwire.HTLCTimeoutRequest:
+				logIndex := uint32(htlcPkt.HTLCKey)
+				payHash, err := channel.ReceiveTimeoutHTLC(logIndex)
+				if err != nil {
+					peerLog.Errorf("timeout for outgoing HTLC rejected: %v", err)
+					p.Disconnect()
+					break out
+				}
+
+				circuitID := state.circuits[logIndex]
+				delete(state.circuits, logIndex)
+				p.sendToSwitch(htlcPlex, &htlcPacket{
+					payHash:   payHash,
+					srcLink:   state.chanPoint,
+					circuitID: circuitID,
+					msg:       htlcPkt,
+				})
 			case *lnwire.CommitSignature:
 				// We just received a new update to our local
 				// commitment chain, validate this new
@@ -889,6 +1088,7 @@ out:
 					continue
 				}
 				p.queueMsg(nextRevocation, nil)
+				state.reportBandwidth()
 			case *lnwire.CommitRevocation:
 				// We've received a revocation from the remote
 				// chain, if valid, this moves the remote chain
@@ -899,51 +1099,163 @@ out:
 					p.Disconnect()
 					break out
 				}
-				// TODO(roasbeef): send the locked-in HTLC's
-				// over the plex chan to the switch.
 				peerLog.Debugf("htlcs ready to forward: %v",
 					spew.Sdump(htlcsToForward))
+				state.reportBandwidth()
+
+				// Any incoming HTLCs which have now been
+				// locked in, and aren't destined for us, are
+				// sent over the plex chan to the switch.
+				for _, htlc := range htlcsToForward {
+					if !htlc.IsIncoming {
+						continue
+					}
+
+					// An HTLC violating our flow-control
+					// limits is cancelled, rather than
+					// forwarded.
+					failCode, ok := state.rejectedAdds[htlc.Index]
+					if ok {
+						delete(state.rejectedAdds, htlc.Index)
+						state.htlcsToCancel = append(
+							state.htlcsToCancel,
+							&pendingCancel{
+								logIndex: htlc.Index,
+								failCode: failCode,
+							},
+						)
+						continue
+					}
+
+					// An HTLC whose payload can't be
+					// decoded can be neither forwarded,
+					// nor settled, so it's cancelled.
+					fwdPkt, err := newForwardPacket(state.chanPoint, htlc)
+					if err != nil {
+						peerLog.Errorf("unable to forward "+
+							"htlc: %v", err)
+						state.htlcsToCancel = append(
+							state.htlcsToCancel,
+							&pendingCancel{
+								logIndex: htlc.Index,
+								failCode: lnwire.FailCodeInvalidOnion,
+							},
+						)
+						continue
+					}
+					if fwdPkt != nil {
+						p.sendToSwitch(htlcPlex, fwdPkt)
+						continue
+					}
+
+					// The HTLC terminates with us, so it's
+					// held until the remaining shards of
+					// the payment arrive, and for a hold
+					// invoice, until it's settled. If the
+					// invoice registry rejects it, then
+					// the HTLC is cancelled, so the sender
+					// can promptly give up on it.
+					rHash := [32]byte(htlc.RHash)
+					err = p.server.invoices.acceptShard(
+						wire.ShaHash(rHash), htlc.Amount,
+						htlc.Timeout, htlc.ContractType,
+						htlc.RedemptionHashes, holder,
+					)
+					if err != nil {
+						peerLog.Debugf("rejecting htlc "+
+							"paying %x: %v",
+							rHash[:], err)
+						state.htlcsToCancel = append(
+							state.htlcsToCancel,
+							&pendingCancel{
+								logIndex: htlc.Index,
+								failCode: invoiceFailCode(err),
+							},
+						)
+						continue
+					}
+					state.heldShards[rHash] = append(
+						state.heldShards[rHash], htlc.Index,
+					)
+				}
 
 				// A full state transition has been completed,
-				// if we don't need to settle any HTLC's, then
+				// if we don't need to cancel any HTLC's, then
 				// we're done.
-				if len(state.htlcsToSettle) == 0 {
+				if len(state.htlcsToCancel) == 0 {
 					continue
 				}
 
-				// Otherwise, we have some pending HTLC's which
-				// we can pull funds from, thereby settling.
-				peerLog.Tracef("settling %v HTLC's", len(state.htlcsToSettle))
-				for _, pre := range state.htlcsToSettle {
-					// Add each HTLC settle update to the
-					// channel's state update log, also
-					// sending the log update to the remote
-					// party.
-					logIndex, err := channel.SettleHTLC(pre, false)
+				// HTLCs rejected by the invoice registry,
+				// violating our flow-control limits, or with
+				// an undecodable payload are cancelled,
+				// naming ourselves as the erring node.
+				for _, cancel := range state.htlcsToCancel {
+					err := channel.TimeoutHTLC(cancel.logIndex)
 					if err != nil {
-						peerLog.Errorf("unable to settle htlc: %v", err)
+						peerLog.Errorf("unable to cancel htlc: %v", err)
 						continue
 					}
-					settleMsg := &lnwire.HTLCSettleRequest{
-						ChannelPoint:     state.chanPoint,
-						HTLCKey:          lnwire.HTLCKey(logIndex),
-						RedemptionProofs: [][32]byte{pre},
+					p.queueMsg(&lnwire.HTLCTimeoutRequest{
+						ChannelPoint: state.chanPoint,
+						HTLCKey:      lnwire.HTLCKey(cancel.logIndex),
+						FailCode:     cancel.failCode,
+						ErringNode:   p.server.lightningID,
+					}, nil)
+					state.pendingUpdates++
+				}
+				state.htlcsToCancel = nil
+
+				// The cancel updates are committed along with
+				// the rest of the current batch.
+				p.maybeCommitBatch(state)
+			}
+		case res := <-shardResolutions:
+			// The invoice registry has resolved a payment we hold
+			// shards of, so they're all either settled together,
+			// or cancelled if the rest of the payment never
+			// arrived.
+			logIndexes := state.heldShards[res.payHash]
+			delete(state.heldShards, res.payHash)
+			if len(logIndexes) == 0 {
+				continue
+			}
+
+			peerLog.Tracef("resolving %v shards of payment %x, "+
+				"settle=%v", len(logIndexes), res.payHash[:],
+				res.settle)
+
+			for _, logIndex := range logIndexes {
+				if !res.settle {
+					if err := channel.TimeoutHTLC(logIndex); err != nil {
+						peerLog.Errorf("unable to cancel htlc: %v", err)
+						continue
 					}
-					p.queueMsg(settleMsg, nil)
+					p.queueMsg(&lnwire.HTLCTimeoutRequest{
+						ChannelPoint: state.chanPoint,
+						HTLCKey:      lnwire.HTLCKey(logIndex),
+						FailCode:     lnwire.FailCodeMPPTimeout,
+						ErringNode:   p.server.lightningID,
+					}, nil)
+					state.pendingUpdates++
+					continue
 				}
 
-				// With all the settle updates added to the
-				// local and remote HTLC logs, initiate a state
-				// transition by updating the remote commitment
-				// chain.
-				if err := p.updateCommitTx(state); err != nil {
-					peerLog.Errorf("unable to update "+
-						"commitment: %v", err)
+				_, err := channel.SettleIndexedHTLC(res.preimages,
+					logIndex, false)
+				if err != nil {
+					peerLog.Errorf("unable to settle htlc: %v", err)
 					continue
 				}
-				state.sigPending = true
-				state.htlcsToSettle = nil
+				p.queueMsg(&lnwire.HTLCSettleRequest{
+					ChannelPoint:     state.chanPoint,
+					HTLCKey:          lnwire.HTLCKey(logIndex),
+					RedemptionProofs: res.preimages,
+				}, nil)
+				state.pendingUpdates++
 			}
+
+			p.maybeCommitBatch(state)
 		case <-p.quit:
 			break out
 		}
@@ -953,6 +1265,156 @@ out:
 	peerLog.Tracef("htlcManager for peer %v done", p)
 }
 
+// sendToSwitch hands the passed packet off to the htlc switch, giving up if
+// the peer is disconnected first.
+func (p *peer) sendToSwitch(htlcPlex chan<- *htlcPacket, pkt *htlcPacket) {
+	select {
+	case htlcPlex <- pkt:
+	case <-p.quit:
+	}
+}
+
+// newForwardPacket creates the packet which forwards the passed incoming
+// HTLC, received over the target channel, to the next hop named within the
+// HTLC's payload. If the HTLC has no payload, or we're the final hop, then nil
+// is returned.
+func newForwardPacket(chanPoint *wire.OutPoint,
+	htlc *lnwallet.PaymentDescriptor) (*htlcPacket, error) {
+
+	if len(htlc.Payload) == 0 {
+		return nil, nil
+	}
+
+	hop, nextPayload, err := decodeHopPayload(htlc.Payload)
+	if err != nil {
+		return nil, err
+	}
+	if hop.isExit() {
+		return nil, nil
+	}
+
+	return &htlcPacket{
+		dest:           wire.ShaHash(hop.nextNode),
+		payHash:        [32]byte(htlc.RHash),
+		srcLink:        chanPoint,
+		incomingIndex:  htlc.Index,
+		incomingAmt:    htlc.Amount,
+		incomingExpiry: htlc.Timeout,
+		msg: &lnwire.HTLCAddRequest{
+			Expiry:           hop.outgoingExpiry,
+			Amount:           hop.amtToForward,
+			ContractType:     htlc.ContractType,
+			RedemptionHashes: htlc.RedemptionHashes,
+			OnionBlob:        nextPayload,
+		},
+	}, nil
+}
+
+// newRejectPacket creates the packet which fails the passed outgoing HTLC,
+// refused for violating the flow-control limits of the target channel, back
+// along the HTLC's circuit. As the HTLC never left the channel, we name
+// ourselves as the erring node.
+func (p *peer) newRejectPacket(state *commitmentState, circuitID uint64,
+	htlc *lnwire.HTLCAddRequest, failCode lnwire.FailCode) *htlcPacket {
+
+	return &htlcPacket{
+		payHash:   htlc.RedemptionHashes[0],
+		srcLink:   state.chanPoint,
+		circuitID: circuitID,
+		msg: &lnwire.HTLCTimeoutRequest{
+			ChannelPoint: state.chanPoint,
+			FailCode:     failCode,
+			ErringNode:   p.server.lightningID,
+		},
+	}
+}
+
+// rejectFailCode maps the error returned when adding an HTLC which violates
+// the flow-control limits of a channel to the FailCode the HTLC is failed
+// back along its circuit with. The returned boolean is false if the error
+// isn't a flow-control violation, in which case a temporary node failure is
+// returned.
+func rejectFailCode(err error) (lnwire.FailCode, bool) {
+	switch err {
+	case lnwallet.ErrBelowMinHTLC:
+		return lnwire.FailCodeChannelPolicy, true
+	case lnwallet.ErrMaxHTLCNumber, lnwallet.ErrMaxValueInFlight,
+		lnwallet.ErrBelowChanReserve:
+
+		return lnwire.FailCodeInsufficientCapacity, true
+	default:
+		return lnwire.FailCodeTemporaryNodeFailure, false
+	}
+}
+
+// maybeUpdateFee updates the commitment fee rate of a channel we initiated if
+// the current fee estimate has drifted from the fee rate of the channel by
+// more than feeUpdateThreshold percent. The fee update is batched along with
+// any other pending updates.
+func (p *peer) maybeUpdateFee(state *commitmentState) {
+	if !state.channel.IsInitiator() {
+		return
+	}
+
+	feePerByte, err := p.server.feeEstimator.EstimateFeePerByte(
+		commitConfTarget)
+	if err != nil {
+		peerLog.Errorf("unable to estimate fee rate: %v", err)
+		return
+	}
+	newFeeRate := feePerByte * 1000
+
+	oldFeeRate := state.channel.PendingFeeRate()
+	drift := newFeeRate - oldFeeRate
+	if drift < 0 {
+		drift = -drift
+	}
+	if drift == 0 ||
+		(oldFeeRate != 0 && drift*100 <= oldFeeRate*feeUpdateThreshold) {
+		return
+	}
+
+	if err := state.channel.UpdateFee(newFeeRate); err != nil {
+		peerLog.Errorf("unable to update fee: %v", err)
+		return
+	}
+
+	peerLog.Infof("Updating commitment fee rate of ChannelPoint(%v) "+
+		"from %v to %v per kb", state.chanPoint, oldFeeRate, newFeeRate)
+
+	p.queueMsg(lnwire.NewUpdateFee(state.chanPoint, newFeeRate), nil)
+
+	state.pendingUpdates++
+	p.maybeCommitBatch(state)
+}
+
+// maybeCommitBatch commits the batch of pending updates once it's full. A
+// batch which isn't yet full is instead committed on the next tick of the
+// trickle timer.
+func (p *peer) maybeCommitBatch(state *commitmentState) {
+	if state.pendingUpdates >= p.server.maxBatchSize {
+		p.commitBatch(state)
+	}
+}
+
+// commitBatch initiates a state transition covering all the pending updates
+// within the current batch by updating the remote commitment chain. If there
+// are no pending updates, then this is a no-op.
+func (p *peer) commitBatch(state *commitmentState) {
+	if state.pendingUpdates == 0 {
+		return
+	}
+
+	peerLog.Tracef("committing batch of %v updates for "+
+		"ChannelPoint(%v)", state.pendingUpdates, state.chanPoint)
+
+	if err := p.updateCommitTx(state); err != nil {
+		peerLog.Errorf("unable to update commitment: %v", err)
+		return
+	}
+	state.sigPending = true
+}
+
 // updateCommitTx signs, then sends an update to the remote peer adding a new
 // commitment to their commitment chain which includes all the latest updates
 // we've received+processed up to this point.
@@ -973,8 +1435,16 @@ func (p *peer) updateCommitTx(state *commitmentState) error {
 		LogIndex:     uint64(logIndexTheirs),
 	}
 	p.queueMsg(commitSig, nil)
+	state.pendingUpdates = 0
+	state.reportBandwidth()
 
 	return nil
 }
 
+// reportBandwidth reports the channel's available bandwidth to the switch,
+// following an update to the channel's commitment state.
+func (s *commitmentState) reportBandwidth() {
+	s.bandwidth.report(s.channel.AvailableBandwidth(), s.numAdds)
+}
+
 // TODO(roasbeef): make all start/stop mutexes a CAS
@@ -1 +1,151 @@
 package main
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+)
+
+// createTestCommitmentState returns the commitment state of a channel funded
+// evenly by both sides, which has been given a full revocation window by the
+// remote node, and so is able to sign new commitments.
+func createTestCommitmentState(t *testing.T) *commitmentState {
+	ourKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	theirKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	redeemScript, err := lnwallet.GenMultiSigScript(
+		ourKey.PubKey().SerializeCompressed(),
+		theirKey.PubKey().SerializeCompressed())
+	if err != nil {
+		t.Fatalf("unable to generate funding script: %v", err)
+	}
+
+	capacity := btcutil.Amount(10 * 1e8)
+	chanPoint := &wire.OutPoint{Index: 0}
+	chanState := &channeldb.OpenChannel{
+		ChanID:              chanPoint,
+		FundingOutpoint:     chanPoint,
+		OurCommitKey:        ourKey,
+		TheirCommitKey:      theirKey.PubKey(),
+		OurMultiSigKey:      ourKey,
+		TheirMultiSigKey:    theirKey.PubKey(),
+		FundingRedeemScript: redeemScript,
+		Capacity:            capacity,
+		OurBalance:          lnwire.NewMSatFromSatoshis(capacity / 2),
+		TheirBalance:        lnwire.NewMSatFromSatoshis(capacity / 2),
+		LocalCsvDelay:       5,
+		RemoteCsvDelay:      4,
+		IsInitiator:         true,
+	}
+	channel, err := lnwallet.NewLightningChannel(nil, nil, nil, chanState)
+	if err != nil {
+		t.Fatalf("unable to create channel: %v", err)
+	}
+
+	// As the revocation keys aren't verified until they're revoked, any
+	// key will do for the remote node's window.
+	for i := 0; i < lnwallet.InitialRevocationWindow; i++ {
+		rev := &lnwire.CommitRevocation{
+			ChannelPoint:      chanPoint,
+			NextRevocationKey: theirKey.PubKey(),
+		}
+		if _, err := channel.ReceiveRevocation(rev); err != nil {
+			t.Fatalf("unable to extend revocation window: %v", err)
+		}
+	}
+
+	return &commitmentState{
+		bandwidth: &linkBandwidth{},
+		channel:   channel,
+		chanPoint: chanPoint,
+	}
+}
+
+// assertCommitSig asserts whether or not the peer has queued a signature for
+// a new commitment.
+func assertCommitSig(t *testing.T, p *peer, expected bool) {
+	select {
+	case msg := <-p.outgoingQueue:
+		if !expected {
+			t.Fatalf("unexpected message queued: %T", msg.msg)
+		}
+		if _, ok := msg.msg.(*lnwire.CommitSignature); !ok {
+			t.Fatalf("expected commitment signature, got %T",
+				msg.msg)
+		}
+	default:
+		if expected {
+			t.Fatalf("expected commitment signature to be queued")
+		}
+	}
+}
+
+// TestCommitBatch tests that the batched updates of a channel are committed
+// once the batch is full, and otherwise only once the trickle timer ticks.
+func TestCommitBatch(t *testing.T) {
+	p := &peer{
+		server:        &server{maxBatchSize: 3},
+		outgoingQueue: make(chan outgoinMsg, outgoingQueueLen),
+	}
+	state := createTestCommitmentState(t)
+
+	// With no pending updates, a tick of the trickle timer shouldn't
+	// produce a new commitment.
+	p.commitBatch(state)
+	assertCommitSig(t, p, false)
+	if state.sigPending {
+		t.Fatalf("empty batch shouldn't be committed")
+	}
+
+	// The batch shouldn't be committed until it's full.
+	for i := 1; i < p.server.maxBatchSize; i++ {
+		state.pendingUpdates++
+		p.maybeCommitBatch(state)
+		assertCommitSig(t, p, false)
+	}
+
+	// Filling the batch should commit it, resetting the number of pending
+	// updates, and reporting the channel's bandwidth to the switch.
+	state.pendingUpdates++
+	p.maybeCommitBatch(state)
+	assertCommitSig(t, p, true)
+	if !state.sigPending {
+		t.Fatalf("full batch should be awaiting a revocation")
+	}
+	if state.pendingUpdates != 0 {
+		t.Fatalf("expected no pending updates, got %v",
+			state.pendingUpdates)
+	}
+	bandwidth, _ := state.bandwidth.latest()
+	if bandwidth != state.channel.AvailableBandwidth() {
+		t.Fatalf("expected bandwidth of %v to be reported, got %v",
+			state.channel.AvailableBandwidth(), bandwidth)
+	}
+
+	// A partial batch should instead be committed on the next tick of the
+	// trickle timer.
+	state.sigPending = false
+	state.pendingUpdates++
+	p.maybeCommitBatch(state)
+	assertCommitSig(t, p, false)
+
+	p.commitBatch(state)
+	assertCommitSig(t, p, true)
+	if !state.sigPending {
+		t.Fatalf("partial batch should be awaiting a revocation")
+	}
+	if state.pendingUpdates != 0 {
+		t.Fatalf("expected no pending updates, got %v",
+			state.pendingUpdates)
+	}
+}
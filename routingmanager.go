@@ -0,0 +1,543 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/wire"
+	"github.com/roasbeef/btcutil"
+
+	"github.com/BitfuryLightning/tools/rt/graph"
+)
+
+// routingManager maintains the routing table exchanged with our direct
+// neighbors, those nodes we share a channel with. A new neighbor is sent our
+// complete routing table within a NeighborHelloMessage, after which the
+// changes made to the table are relayed within NeighborUpdMessages.
+// Messages destined to neighbors are sent out over ChOut, while messages
+// received from them are fed into ChIn.
+type routingManager struct {
+	started  int32 // atomic
+	shutdown int32 // atomic
+
+	selfID [32]byte
+
+	// ChIn receives the routing messages sent to us by our neighbors.
+	ChIn chan lnwire.Message
+
+	// ChOut delivers the routing messages to be sent to our neighbors.
+	// Each message implements lnwire.RoutingMessage, so the target
+	// neighbor is given by its receiver ID.
+	ChOut chan lnwire.Message
+
+	// table is the routing table, keyed by the channel point of each
+	// channel. It's only accessed by the messageHandler goroutine.
+	table map[wire.OutPoint]lnwire.ChannelOperation
+
+	// neighbors is the set of connected nodes we share a channel with.
+	// It's only accessed by the messageHandler goroutine.
+	neighbors map[[32]byte]struct{}
+
+	// connected is the set of nodes we're currently connected to,
+	// regardless of whether we share a channel with them. It's only
+	// accessed by the messageHandler goroutine.
+	connected map[[32]byte]struct{}
+
+	queries chan interface{}
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newRoutingManager creates a new routing manager for the node of the passed
+// lightning ID.
+func newRoutingManager(selfID [32]byte) *routingManager {
+	return &routingManager{
+		selfID:    selfID,
+		ChIn:      make(chan lnwire.Message, 20),
+		ChOut:     make(chan lnwire.Message, 20),
+		table:     make(map[wire.OutPoint]lnwire.ChannelOperation),
+		neighbors: make(map[[32]byte]struct{}),
+		connected: make(map[[32]byte]struct{}),
+		queries:   make(chan interface{}),
+		quit:      make(chan struct{}),
+	}
+}
+
+// Start launches the goroutine which handles the routing messages.
+func (r *routingManager) Start() error {
+	if atomic.AddInt32(&r.started, 1) != 1 {
+		return nil
+	}
+
+	r.wg.Add(1)
+	go r.messageHandler()
+
+	return nil
+}
+
+// Stop signals the routing manager for a graceful shutdown.
+func (r *routingManager) Stop() error {
+	if atomic.AddInt32(&r.shutdown, 1) != 1 {
+		return nil
+	}
+
+	close(r.quit)
+	r.wg.Wait()
+
+	return nil
+}
+
+// addChannelMsg requests the addition of a channel to the routing table.
+type addChannelMsg struct {
+	op lnwire.ChannelOperation
+}
+
+// removeChannelMsg requests the removal of a channel from the routing table.
+type removeChannelMsg struct {
+	chanPoint wire.OutPoint
+}
+
+// neighborConnectedMsg notifies the routing manager of a new connection.
+type neighborConnectedMsg struct {
+	nodeID [32]byte
+}
+
+// neighborDisconnectedMsg notifies the routing manager that the connection
+// to a node has been lost.
+type neighborDisconnectedMsg struct {
+	nodeID [32]byte
+}
+
+// routingTableMsg queries a snapshot of the routing table.
+type routingTableMsg struct {
+	resp chan []lnwire.ChannelOperation
+}
+
+// AddChannel adds the channel between the two passed nodes to the routing
+// table, relaying the addition to our neighbors.
+func (r *routingManager) AddChannel(node1, node2 [32]byte,
+	chanPoint *wire.OutPoint, capacity btcutil.Amount) {
+
+	r.query(&addChannelMsg{
+		op: lnwire.ChannelOperation{
+			NodeID1:      node1,
+			NodeID2:      node2,
+			ChannelPoint: chanPoint,
+			Capacity:     capacity,
+			Operation:    lnwire.ChannelAdd,
+		},
+	})
+}
+
+// RemoveChannel removes the channel of the passed channel point from the
+// routing table, relaying the removal to our neighbors.
+func (r *routingManager) RemoveChannel(chanPoint *wire.OutPoint) {
+	r.query(&removeChannelMsg{chanPoint: *chanPoint})
+}
+
+// NeighborConnected notifies the routing manager of a new connection to the
+// node of the passed ID. If we share a channel with the node, then it's sent
+// our routing table.
+func (r *routingManager) NeighborConnected(nodeID [32]byte) {
+	r.query(&neighborConnectedMsg{nodeID: nodeID})
+}
+
+// NeighborDisconnected notifies the routing manager that the connection to
+// the node of the passed ID has been lost.
+func (r *routingManager) NeighborDisconnected(nodeID [32]byte) {
+	r.query(&neighborDisconnectedMsg{nodeID: nodeID})
+}
+
+// RoutingTable returns a snapshot of the routing table, ordered by channel
+// point.
+func (r *routingManager) RoutingTable() []lnwire.ChannelOperation {
+	resp := make(chan []lnwire.ChannelOperation, 1)
+	if !r.query(&routingTableMsg{resp: resp}) {
+		return nil
+	}
+
+	select {
+	case ops := <-resp:
+		return ops
+	case <-r.quit:
+		return nil
+	}
+}
+
+// query hands the passed query to the messageHandler goroutine, returning
+// false if the routing manager is shutting down.
+func (r *routingManager) query(q interface{}) bool {
+	select {
+	case r.queries <- q:
+		return true
+	case <-r.quit:
+		return false
+	}
+}
+
+// messageHandler is the main event loop of the routing manager. It applies
+// the changes made to the routing table, whether locally or by our
+// neighbors, and queues the resulting messages to be sent out over ChOut.
+// Outgoing messages are queued without bound, so the goroutine never blocks
+// on the consumer of ChOut.
+//
+// NOTE: This MUST be run as a goroutine.
+func (r *routingManager) messageHandler() {
+	defer r.wg.Done()
+
+	var pending []lnwire.Message
+	for {
+		var (
+			out  chan lnwire.Message
+			next lnwire.Message
+		)
+		if len(pending) > 0 {
+			out = r.ChOut
+			next = pending[0]
+		}
+
+		var msgs []lnwire.Message
+		select {
+		case out <- next:
+			pending[0] = nil
+			pending = pending[1:]
+			continue
+
+		case msg := <-r.ChIn:
+			msgs = r.handleRoutingMessage(msg)
+
+		case query := <-r.queries:
+			switch q := query.(type) {
+			case *addChannelMsg:
+				msgs = r.handleAddChannel(q.op)
+			case *removeChannelMsg:
+				msgs = r.handleRemoveChannel(q.chanPoint)
+			case *neighborConnectedMsg:
+				r.connected[q.nodeID] = struct{}{}
+				msgs = r.maybeAddNeighbor(q.nodeID)
+			case *neighborDisconnectedMsg:
+				delete(r.connected, q.nodeID)
+				delete(r.neighbors, q.nodeID)
+			case *routingTableMsg:
+				q.resp <- r.sortedTable()
+			}
+
+		case <-r.quit:
+			return
+		}
+
+		pending = append(pending, msgs...)
+	}
+}
+
+// handleAddChannel adds the passed channel to the routing table. If the
+// channel is one of our own, then the node at its other end becomes our
+// neighbor, and is sent our routing table.
+func (r *routingManager) handleAddChannel(
+	op lnwire.ChannelOperation) []lnwire.Message {
+
+	if !r.applyOperation(op) {
+		return nil
+	}
+
+	var newNeighbor *[32]byte
+	switch r.selfID {
+	case op.NodeID1:
+		newNeighbor = &op.NodeID2
+	case op.NodeID2:
+		newNeighbor = &op.NodeID1
+	}
+
+	// A new neighbor already learns of the channel from our routing
+	// table, so the update is only relayed to the others.
+	var (
+		msgs    []lnwire.Message
+		exclude *[32]byte
+	)
+	if newNeighbor != nil {
+		msgs = r.maybeAddNeighbor(*newNeighbor)
+		if len(msgs) > 0 {
+			exclude = newNeighbor
+		}
+	}
+
+	return append(msgs, r.relayUpdates(
+		[]lnwire.ChannelOperation{op}, exclude)...)
+}
+
+// handleRemoveChannel removes the channel of the passed channel point from
+// the routing table. If it was the last channel we shared with a neighbor,
+// then the node is no longer considered our neighbor.
+func (r *routingManager) handleRemoveChannel(
+	chanPoint wire.OutPoint) []lnwire.Message {
+
+	op, ok := r.table[chanPoint]
+	if !ok {
+		return nil
+	}
+	op.Operation = lnwire.ChannelRemove
+	r.applyOperation(op)
+
+	// The removal is relayed before pruning our neighbors, so the node
+	// at the other end of a closed channel learns of it too.
+	msgs := r.relayUpdates([]lnwire.ChannelOperation{op}, nil)
+	for nodeID := range r.neighbors {
+		if !r.hasChannelWith(nodeID) {
+			delete(r.neighbors, nodeID)
+		}
+	}
+
+	return msgs
+}
+
+// handleRoutingMessage processes a routing message received from one of our
+// neighbors, returning the messages to be sent in response.
+func (r *routingManager) handleRoutingMessage(
+	msg lnwire.Message) []lnwire.Message {
+
+	routingMsg, ok := msg.(lnwire.RoutingMessage)
+	if !ok {
+		return nil
+	}
+	senderID := routingMsg.GetSenderID().ToByte32()
+
+	switch m := msg.(type) {
+	case *lnwire.NeighborHelloMessage:
+		// The sender has opened a channel with us, so it's now our
+		// neighbor. The table it carries is merged into our own.
+		r.neighbors[senderID] = struct{}{}
+		msgs := []lnwire.Message{&lnwire.NeighborAckMessage{
+			RoutingMessageBase: r.messageBase(senderID),
+		}}
+		return append(msgs, r.mergeOperations(m.Channels, senderID)...)
+
+	case *lnwire.NeighborUpdMessage:
+		return r.mergeOperations(m.Updates, senderID)
+
+	case *lnwire.RoutingTableRequestMessage:
+		return r.routingTableMessages(senderID, false)
+
+	case *lnwire.RoutingTableTransferMessage:
+		return r.mergeOperations(m.Channels, senderID)
+
+	case *lnwire.NeighborRstMessage:
+		delete(r.neighbors, senderID)
+
+	case *lnwire.NeighborAckMessage:
+		rtngLog.Debugf("Neighbor %x acknowledged our routing table",
+			senderID[:])
+	}
+
+	return nil
+}
+
+// mergeOperations applies the operations received from the passed neighbor
+// to the routing table, relaying those which changed it to our other
+// neighbors. Operations leaving the table unchanged aren't relayed, so
+// updates don't loop between neighbors.
+func (r *routingManager) mergeOperations(ops []lnwire.ChannelOperation,
+	senderID [32]byte) []lnwire.Message {
+
+	var applied []lnwire.ChannelOperation
+	for _, op := range ops {
+		// Our own channels are only ever changed locally.
+		if op.NodeID1 == r.selfID || op.NodeID2 == r.selfID {
+			continue
+		}
+
+		if r.applyOperation(op) {
+			applied = append(applied, op)
+		}
+	}
+
+	return r.relayUpdates(applied, &senderID)
+}
+
+// applyOperation applies the passed operation to the routing table,
+// returning true if the table was changed.
+func (r *routingManager) applyOperation(op lnwire.ChannelOperation) bool {
+	if op.ChannelPoint == nil {
+		return false
+	}
+	chanPoint := *op.ChannelPoint
+
+	existing, ok := r.table[chanPoint]
+	switch op.Operation {
+	case lnwire.ChannelAdd:
+		if ok && existing.NodeID1 == op.NodeID1 &&
+			existing.NodeID2 == op.NodeID2 &&
+			existing.Capacity == op.Capacity {
+
+			return false
+		}
+		r.table[chanPoint] = op
+
+	case lnwire.ChannelRemove:
+		if !ok {
+			return false
+		}
+		delete(r.table, chanPoint)
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// maybeAddNeighbor makes the passed node our neighbor if we're connected to
+// it and share a channel with it, returning the messages carrying our
+// routing table to the new neighbor.
+func (r *routingManager) maybeAddNeighbor(nodeID [32]byte) []lnwire.Message {
+	if _, ok := r.neighbors[nodeID]; ok {
+		return nil
+	}
+	if _, ok := r.connected[nodeID]; !ok {
+		return nil
+	}
+	if !r.hasChannelWith(nodeID) {
+		return nil
+	}
+
+	r.neighbors[nodeID] = struct{}{}
+	return r.routingTableMessages(nodeID, true)
+}
+
+// hasChannelWith returns true if the routing table holds a channel between
+// our node, and the node of the passed ID.
+func (r *routingManager) hasChannelWith(nodeID [32]byte) bool {
+	for _, op := range r.table {
+		if (op.NodeID1 == r.selfID && op.NodeID2 == nodeID) ||
+			(op.NodeID2 == r.selfID && op.NodeID1 == nodeID) {
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// routingTableMessages returns the messages carrying our complete routing
+// table to the passed node. Tables larger than lnwire.MaxChannelOperations
+// are split across several messages. If hello is true, then the first
+// message is a NeighborHelloMessage and the rest NeighborUpdMessages,
+// otherwise they're all RoutingTableTransferMessages.
+func (r *routingManager) routingTableMessages(receiverID [32]byte,
+	hello bool) []lnwire.Message {
+
+	ops := r.sortedTable()
+
+	var msgs []lnwire.Message
+	for len(msgs) == 0 || len(ops) > 0 {
+		chunk := ops
+		if len(chunk) > lnwire.MaxChannelOperations {
+			chunk = chunk[:lnwire.MaxChannelOperations]
+		}
+		ops = ops[len(chunk):]
+
+		base := r.messageBase(receiverID)
+		switch {
+		case hello && len(msgs) == 0:
+			msgs = append(msgs, &lnwire.NeighborHelloMessage{
+				RoutingMessageBase: base,
+				Channels:           chunk,
+			})
+		case hello:
+			msgs = append(msgs, &lnwire.NeighborUpdMessage{
+				RoutingMessageBase: base,
+				Updates:            chunk,
+			})
+		default:
+			msgs = append(msgs, &lnwire.RoutingTableTransferMessage{
+				RoutingMessageBase: base,
+				Channels:           chunk,
+			})
+		}
+	}
+
+	return msgs
+}
+
+// relayUpdates returns the NeighborUpdMessages carrying the passed
+// operations to each of our neighbors, apart from the excluded one.
+func (r *routingManager) relayUpdates(ops []lnwire.ChannelOperation,
+	exclude *[32]byte) []lnwire.Message {
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	var msgs []lnwire.Message
+	for nodeID := range r.neighbors {
+		if exclude != nil && nodeID == *exclude {
+			continue
+		}
+
+		for i := 0; i < len(ops); i += lnwire.MaxChannelOperations {
+			end := i + lnwire.MaxChannelOperations
+			if end > len(ops) {
+				end = len(ops)
+			}
+
+			msgs = append(msgs, &lnwire.NeighborUpdMessage{
+				RoutingMessageBase: r.messageBase(nodeID),
+				Updates:            ops[i:end],
+			})
+		}
+	}
+
+	return msgs
+}
+
+// messageBase returns the routing message base of a message we send to the
+// passed node.
+func (r *routingManager) messageBase(
+	receiverID [32]byte) lnwire.RoutingMessageBase {
+
+	return lnwire.RoutingMessageBase{
+		SenderID:   graph.NewID(r.selfID),
+		ReceiverID: graph.NewID(receiverID),
+	}
+}
+
+// opsByChanPoint sorts channel operations by their channel point.
+type opsByChanPoint []lnwire.ChannelOperation
+
+func (o opsByChanPoint) Len() int      { return len(o) }
+func (o opsByChanPoint) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+func (o opsByChanPoint) Less(i, j int) bool {
+	a, b := o[i].ChannelPoint, o[j].ChannelPoint
+	if c := bytes.Compare(a.Hash[:], b.Hash[:]); c != 0 {
+		return c < 0
+	}
+	return a.Index < b.Index
+}
+
+// sortedTable returns the channels within the routing table, ordered by
+// their channel point.
+func (r *routingManager) sortedTable() []lnwire.ChannelOperation {
+	ops := make([]lnwire.ChannelOperation, 0, len(r.table))
+	for _, op := range r.table {
+		ops = append(ops, op)
+	}
+	sort.Sort(opsByChanPoint(ops))
+
+	return ops
+}
+
+// routingTableString returns a human readable representation of the passed
+// routing table.
+func routingTableString(ops []lnwire.ChannelOperation) string {
+	var b bytes.Buffer
+	for _, op := range ops {
+		fmt.Fprintf(&b, "%x -> %x, %v, capacity %v\n", op.NodeID1[:],
+			op.NodeID2[:], op.ChannelPoint, op.Capacity)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/roasbeef/btcd/wire"
+)
+
+func TestRoutingManagerExchange(t *testing.T) {
+	nodeA := [32]byte{0x0a}
+	nodeB := [32]byte{0x0b}
+	nodeC := [32]byte{0x0c}
+
+	mgrA := newRoutingManager(nodeA)
+	mgrB := newRoutingManager(nodeB)
+	mgrA.Start()
+	defer mgrA.Stop()
+	mgrB.Start()
+	defer mgrB.Stop()
+
+	nextMsg := func(mgr *routingManager) lnwire.Message {
+		select {
+		case msg := <-mgr.ChOut:
+			return msg
+		case <-time.After(time.Second * 5):
+			t.Fatalf("routing message not sent")
+		}
+		return nil
+	}
+
+	// Once node A opens a channel with the connected node B, it sends B
+	// its routing table.
+	chanAB := &wire.OutPoint{Hash: wire.ShaHash{0x01}}
+	mgrA.NeighborConnected(nodeB)
+	mgrA.AddChannel(nodeA, nodeB, chanAB, 1000)
+
+	hello, ok := nextMsg(mgrA).(*lnwire.NeighborHelloMessage)
+	if !ok {
+		t.Fatalf("expected NeighborHelloMessage")
+	}
+	if hello.GetReceiverID().ToByte32() != nodeB {
+		t.Fatalf("hello sent to wrong node")
+	}
+	if len(hello.Channels) != 1 || *hello.Channels[0].ChannelPoint != *chanAB {
+		t.Fatalf("hello doesn't carry the routing table: %v",
+			hello.Channels)
+	}
+
+	// Node B acknowledges the routing table, and makes A its neighbor.
+	mgrB.NeighborConnected(nodeA)
+	mgrB.AddChannel(nodeA, nodeB, chanAB, 1000)
+	mgrB.ChIn <- hello
+	for {
+		msg := nextMsg(mgrB)
+		if _, ok := msg.(*lnwire.NeighborAckMessage); ok {
+			break
+		}
+	}
+
+	// A channel learnt by B is relayed to its neighbor A, which then
+	// adds it to its own routing table.
+	chanBC := &wire.OutPoint{Hash: wire.ShaHash{0x02}}
+	mgrB.AddChannel(nodeB, nodeC, chanBC, 2000)
+
+	upd, ok := nextMsg(mgrB).(*lnwire.NeighborUpdMessage)
+	if !ok {
+		t.Fatalf("expected NeighborUpdMessage")
+	}
+	if len(upd.Updates) != 1 || *upd.Updates[0].ChannelPoint != *chanBC {
+		t.Fatalf("update doesn't carry the new channel: %v",
+			upd.Updates)
+	}
+
+	// Channels involving node A itself are only changed locally, so the
+	// update from B only adds B's channel with C.
+	mgrA.ChIn <- upd
+	deadline := time.Now().Add(time.Second * 5)
+	for len(mgrA.RoutingTable()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("update not applied to routing table: %v",
+				mgrA.RoutingTable())
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// Once the channel between A and B is closed, B is told of its
+	// removal, and is no longer A's neighbor.
+	mgrA.RemoveChannel(chanAB)
+	upd, ok = nextMsg(mgrA).(*lnwire.NeighborUpdMessage)
+	if !ok {
+		t.Fatalf("expected NeighborUpdMessage")
+	}
+	if len(upd.Updates) != 1 ||
+		upd.Updates[0].Operation != lnwire.ChannelRemove {
+
+		t.Fatalf("update doesn't remove the channel: %v", upd.Updates)
+	}
+
+	table := mgrA.RoutingTable()
+	if len(table) != 1 || *table[0].ChannelPoint != *chanBC {
+		t.Fatalf("channel not removed from routing table: %v", table)
+	}
+	select {
+	case msg := <-mgrA.ChOut:
+		t.Fatalf("unexpected message sent: %v", msg)
+	default:
+	}
+}
@@ -4,24 +4,23 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"math"
 
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/lndc"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/pathfind"
 	"github.com/roasbeef/btcd/txscript"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcutil"
-	"github.com/roasbeef/btcwallet/waddrmgr"
 	"golang.org/x/net/context"
 )
 
-var (
-	defaultAccount uint32 = waddrmgr.DefaultAccountNum
-)
-
 // rpcServer is a gRPC, RPC front end to the lnd daemon.
 type rpcServer struct {
 	started  int32 // To be used atomically.
@@ -87,16 +86,47 @@ func addrPairsToOutputs(addrPairs map[string]int64) ([]*wire.TxOut, error) {
 	return outputs, nil
 }
 
+// defaultConfTarget is the number of blocks within which on-chain
+// transactions are targeted to confirm if a request specifies neither a
+// confirmation target nor an explicit fee rate.
+const defaultConfTarget = 6
+
+// determineFeePerByte returns the fee rate in satoshis per byte an on-chain
+// transaction should pay. An explicit fee rate takes precedence, otherwise
+// the server's fee estimator is queried for the passed confirmation target,
+// falling back to defaultConfTarget if none was specified.
+func (r *rpcServer) determineFeePerByte(targetConf int32,
+	satPerByte int64) (btcutil.Amount, error) {
+
+	switch {
+	case targetConf < 0:
+		return 0, fmt.Errorf("target_conf cannot be negative")
+	case satPerByte < 0:
+		return 0, fmt.Errorf("sat_per_byte cannot be negative")
+	case targetConf != 0 && satPerByte != 0:
+		return 0, fmt.Errorf("either target_conf or sat_per_byte " +
+			"may be set, but not both")
+	case satPerByte != 0:
+		return btcutil.Amount(satPerByte), nil
+	case targetConf == 0:
+		targetConf = defaultConfTarget
+	}
+
+	return r.server.feeEstimator.EstimateFeePerByte(uint32(targetConf))
+}
+
 // sendCoinsOnChain makes an on-chain transaction in or to send coins to one or
 // more addresses specified in the passed payment map. The payment map maps an
 // address to a specified output value to be sent to that address.
-func (r *rpcServer) sendCoinsOnChain(paymentMap map[string]int64) (*wire.ShaHash, error) {
+func (r *rpcServer) sendCoinsOnChain(paymentMap map[string]int64,
+	feePerByte btcutil.Amount) (*wire.ShaHash, error) {
+
 	outputs, err := addrPairsToOutputs(paymentMap)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.server.lnwallet.SendOutputs(outputs, defaultAccount, 1)
+	return r.server.lnwallet.SendMany(outputs, feePerByte)
 }
 
 // SendCoins executes a request to send coins to a particular address. Unlike
@@ -104,10 +134,16 @@ func (r *rpcServer) sendCoinsOnChain(paymentMap map[string]int64) (*wire.ShaHash
 func (r *rpcServer) SendCoins(ctx context.Context,
 	in *lnrpc.SendCoinsRequest) (*lnrpc.SendCoinsResponse, error) {
 
-	rpcsLog.Infof("[sendcoins] addr=%v, amt=%v", in.Addr, btcutil.Amount(in.Amount))
+	feePerByte, err := r.determineFeePerByte(in.TargetConf, in.SatPerByte)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcsLog.Infof("[sendcoins] addr=%v, amt=%v, sat/byte=%v", in.Addr,
+		btcutil.Amount(in.Amount), int64(feePerByte))
 
 	paymentMap := map[string]int64{in.Addr: in.Amount}
-	txid, err := r.sendCoinsOnChain(paymentMap)
+	txid, err := r.sendCoinsOnChain(paymentMap, feePerByte)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +158,15 @@ func (r *rpcServer) SendCoins(ctx context.Context,
 func (r *rpcServer) SendMany(ctx context.Context,
 	in *lnrpc.SendManyRequest) (*lnrpc.SendManyResponse, error) {
 
-	txid, err := r.sendCoinsOnChain(in.AddrToAmount)
+	feePerByte, err := r.determineFeePerByte(in.TargetConf, in.SatPerByte)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcsLog.Infof("[sendmany] outputs=%v, sat/byte=%v",
+		len(in.AddrToAmount), int64(feePerByte))
+
+	txid, err := r.sendCoinsOnChain(in.AddrToAmount, feePerByte)
 	if err != nil {
 		return nil, err
 	}
@@ -141,18 +185,20 @@ func (r *rpcServer) NewAddress(ctx context.Context,
 
 	// Translate the gRPC proto address type to the wallet controller's
 	// available address types.
-	var addrType waddrmgr.AddressType
+	var witness bool
 	switch in.Type {
 	case lnrpc.NewAddressRequest_WITNESS_PUBKEY_HASH:
-		addrType = waddrmgr.WitnessPubKey
+		witness = true
 	case lnrpc.NewAddressRequest_NESTED_PUBKEY_HASH:
-		addrType = waddrmgr.NestedWitnessPubKey
+		// TODO(roasbeef): add nested p2sh to the WalletController
+		// interface.
+		return nil, fmt.Errorf("nested witness addresses are not " +
+			"supported")
 	case lnrpc.NewAddressRequest_PUBKEY_HASH:
-		addrType = waddrmgr.PubKeyHash
+		witness = false
 	}
 
-	addr, err := r.server.lnwallet.NewAddress(defaultAccount,
-		addrType)
+	addr, err := r.server.lnwallet.NewAddress(witness)
 	if err != nil {
 		return nil, err
 	}
@@ -201,8 +247,18 @@ func (r *rpcServer) OpenChannel(in *lnrpc.OpenChannelRequest,
 	remoteFundingAmt := btcutil.Amount(in.RemoteFundingAmount)
 	target := in.TargetPeerId
 	numConfs := in.NumConfs
+
+	// The fee rate of the funding transaction is determined by the
+	// request, while the fee of the commitment transactions is
+	// estimated by the funding manager.
+	fundingFeeRate, err := r.determineFeePerByte(in.TargetConf,
+		in.SatPerByte)
+	if err != nil {
+		return err
+	}
+
 	respChan, errChan := r.server.OpenChannel(target, localFundingAmt,
-		remoteFundingAmt, numConfs)
+		remoteFundingAmt, numConfs, fundingFeeRate)
 	if err := <-errChan; err != nil {
 		rpcsLog.Errorf("unable to open channel to peerid(%v): %v",
 			target, err)
@@ -244,10 +300,19 @@ func (r *rpcServer) CloseChannel(in *lnrpc.CloseChannelRequest,
 	}
 	targetChannelPoint := wire.NewOutPoint(txid, index)
 
-	rpcsLog.Tracef("[closechannel] request for ChannelPoint(%v)",
-		targetChannelPoint)
+	// The closure transaction's fee rate is expressed in satoshis per
+	// kilobyte on the wire.
+	feePerByte, err := r.determineFeePerByte(in.TargetConf, in.SatPerByte)
+	if err != nil {
+		return err
+	}
+	feePerKb := feePerByte * 1000
+
+	rpcsLog.Tracef("[closechannel] request for ChannelPoint(%v), "+
+		"sat/kb=%v", targetChannelPoint, int64(feePerKb))
 
-	respChan, errChan := r.server.htlcSwitch.CloseLink(targetChannelPoint)
+	respChan, errChan := r.server.htlcSwitch.CloseLink(targetChannelPoint,
+		feePerKb)
 	if err := <-errChan; err != nil {
 		rpcsLog.Errorf("Unable to close ChannelPoint(%v): %v",
 			targetChannelPoint, err)
@@ -331,9 +396,12 @@ func (r *rpcServer) ListPeers(ctx context.Context,
 				RemoteId:      lnID,
 				ChannelPoint:  chanSnapshot.ChannelPoint.String(),
 				Capacity:      int64(chanSnapshot.Capacity),
-				LocalBalance:  int64(chanSnapshot.LocalBalance),
-				RemoteBalance: int64(chanSnapshot.RemoteBalance),
+				LocalBalance:  int64(chanSnapshot.LocalBalance.ToSatoshis()),
+				RemoteBalance: int64(chanSnapshot.RemoteBalance.ToSatoshis()),
 				NumUpdates:    chanSnapshot.NumUpdates,
+
+				LocalBalanceMsat:  int64(chanSnapshot.LocalBalance),
+				RemoteBalanceMsat: int64(chanSnapshot.RemoteBalance),
 			}
 			peer.Channels = append(peer.Channels, channel)
 		}
@@ -361,17 +429,15 @@ func (r *rpcServer) WalletBalance(ctx context.Context,
 			return nil, err
 		}
 
-		// We need to convert from BTC to satoshi here otherwise, and
-		// incorrect sum will be returned.
 		var outputSum btcutil.Amount
 		for _, witnessOutput := range witnessOutputs {
-			outputSum += btcutil.Amount(witnessOutput.Amount * 1e8)
+			outputSum += witnessOutput.Value
 		}
 
 		balance = outputSum.ToBTC()
 	} else {
 		// TODO(roasbeef): make num confs a param
-		outputSum, err := r.server.lnwallet.CalculateBalance(1)
+		outputSum, err := r.server.lnwallet.ConfirmedBalance(1)
 		if err != nil {
 			return nil, err
 		}
@@ -437,32 +503,96 @@ func (r *rpcServer) SendPayment(paymentStream lnrpc.Lightning_SendPaymentServer)
 			return err
 		}
 
-		// Craft an HTLC packet to send to the routing sub-system. The
-		// meta-data within this packet will be used to route the
-		// payment through the network.
-		htlcAdd := &lnwire.HTLCAddRequest{
-			Amount:           lnwire.CreditsAmount(nextPayment.Amt),
-			RedemptionHashes: [][32]byte{debugHash},
+		if len(nextPayment.Dest) != 32 {
+			return fmt.Errorf("dest must be a 32-byte lightning ID")
 		}
-		destAddr, err := wire.NewShaHash(nextPayment.Dest)
-		if err != nil {
-			return err
+
+		// Amounts in millisatoshis take precedence over those in
+		// satoshis if they're set.
+		amt := lnwire.MilliSatoshi(nextPayment.AmtMsat)
+		if amt == 0 {
+			amt = lnwire.NewMSatFromSatoshis(
+				btcutil.Amount(nextPayment.Amt))
 		}
-		htlcPkt := &htlcPacket{
-			dest: *destAddr,
-			msg:  htlcAdd,
+		feeLimit := lnwire.MilliSatoshi(nextPayment.FeeLimitMsat)
+		if feeLimit == 0 {
+			feeLimit = lnwire.NewMSatFromSatoshis(
+				btcutil.Amount(nextPayment.FeeLimit))
+		}
+		if amt <= 0 {
+			return fmt.Errorf("payment amount must be positive")
 		}
 
-		// Finally, send this next packet to the routing layer in order
-		// to complete the next payment.
-		// TODO(roasbeef): this should go through the L3 router once
-		// multi-hop is in place.
-		if err := r.server.htlcSwitch.SendHTLC(htlcPkt); err != nil {
-			return err
+		req := &paymentRequest{
+			amt:            amt,
+			finalCLTVDelta: nextPayment.FinalCltvDelta,
+			payHash:        [32]byte(debugHash),
+			feeLimit:       feeLimit,
+			timeout:        time.Duration(nextPayment.TimeoutSeconds) * time.Second,
+		}
+		copy(req.dest[:], nextPayment.Dest)
+
+		// If the payment doesn't specify a payment hash, then the
+		// debug hash is paid. As the debug invoice may be paid any
+		// number of times, its payments aren't recorded.
+		if len(nextPayment.PaymentHash) != 0 {
+			if len(nextPayment.PaymentHash) != 32 {
+				return fmt.Errorf("payment hash must be 32 bytes")
+			}
+			copy(req.payHash[:], nextPayment.PaymentHash)
 		}
 
-		// TODO(roasbeef): proper responses
+		// An escrow invoice is paid by HTLCs listing all of its
+		// redemption hashes, the first of which is the payment hash.
+		if len(nextPayment.RedemptionHashes) != 0 {
+			if len(nextPayment.PaymentHash) != 0 {
+				return fmt.Errorf("payment hash may not be " +
+					"set along with redemption hashes")
+			}
+
+			hashes, err := parseHashes(nextPayment.RedemptionHashes)
+			if err != nil {
+				return err
+			}
+			req.contractType, err = newContractType(
+				nextPayment.NumRequired, len(hashes))
+			if err != nil {
+				return err
+			}
+			req.payHash = hashes[0]
+			req.redemptionHashes = hashes
+		}
+		if req.feeLimit <= 0 {
+			req.feeLimit = req.amt * defaultFeeLimitPercent / 100
+		}
+		if req.timeout <= 0 {
+			req.timeout = defaultPaymentTimeout
+		}
+		if req.finalCLTVDelta == 0 {
+			req.finalCLTVDelta = pathfind.DefaultFinalCLTVDelta
+		}
+
+		// Hand the payment off to the payment controller, which will
+		// split it into shards if needed, and retry them over
+		// alternative routes until it either succeeds, or no further
+		// attempts can be made. Failures are reported within the
+		// response, leaving the stream open for further payments.
 		resp := &lnrpc.SendResponse{}
+		preimages, routes, err := r.server.payments.sendPayment(req)
+		if err != nil {
+			resp.PaymentError = err.Error()
+		} else {
+			resp.PaymentPreimage = preimages[0][:]
+			for i := range preimages {
+				resp.PaymentPreimages = append(
+					resp.PaymentPreimages, preimages[i][:])
+			}
+			for _, route := range routes {
+				resp.PaymentRoutes = append(resp.PaymentRoutes,
+					marshalRoute(route))
+			}
+		}
+
 		if err := paymentStream.Send(resp); err != nil {
 			return err
 		}
@@ -474,8 +604,621 @@ func (r *rpcServer) SendPayment(paymentStream lnrpc.Lightning_SendPaymentServer)
 func (r *rpcServer) ShowRoutingTable(ctx context.Context,
 	in *lnrpc.ShowRoutingTableRequest) (*lnrpc.ShowRoutingTableResponse, error) {
 	rpcsLog.Debugf("[ShowRoutingTable]")
-	rtCopy := r.server.routingMgr.GetRTCopy()
+	routingTable := r.server.routingMgr.RoutingTable()
 	return &lnrpc.ShowRoutingTableResponse{
-		Rt: rtCopy.String(),
+		Rt: routingTableString(routingTable),
+	}, nil
+}
+
+// QueryRoutes attempts to find up to the requested number of routes able to
+// deliver the target amount to the destination node, ranked from cheapest to
+// most expensive. Each route details the fee, and expiry of every hop.
+func (r *rpcServer) QueryRoutes(ctx context.Context,
+	in *lnrpc.QueryRoutesRequest) (*lnrpc.QueryRoutesResponse, error) {
+
+	rpcsLog.Debugf("[queryroutes] dest=%x, amt=%v, num_routes=%v",
+		in.Dest, in.Amt, in.NumRoutes)
+
+	if len(in.Dest) != 32 {
+		return nil, fmt.Errorf("dest must be a 32-byte lightning ID")
+	}
+	var dest [32]byte
+	copy(dest[:], in.Dest)
+
+	numRoutes := int(in.NumRoutes)
+	if numRoutes < 1 {
+		numRoutes = 1
+	}
+
+	// The routes are ranked just as they would be when sending a
+	// payment, accounting for the bandwidth of our links, and the recent
+	// failures tracked by mission control.
+	bandwidthHints, err := r.server.htlcSwitch.LinkBandwidths()
+	if err != nil {
+		return nil, err
+	}
+	amt := lnwire.MilliSatoshi(in.AmtMsat)
+	if amt == 0 {
+		amt = lnwire.NewMSatFromSatoshis(btcutil.Amount(in.Amt))
+	}
+	finalCLTVDelta := in.FinalCltvDelta
+	if finalCLTVDelta == 0 {
+		finalCLTVDelta = pathfind.DefaultFinalCLTVDelta
+	}
+	routes, err := pathfind.FindRoutes(r.server.chanDB, dest,
+		amt, finalCLTVDelta, numRoutes, bandwidthHints,
+		r.server.payments.missionControl)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &lnrpc.QueryRoutesResponse{
+		Routes: make([]*lnrpc.Route, 0, len(routes)),
+	}
+	for _, route := range routes {
+		resp.Routes = append(resp.Routes, marshalRoute(route))
+	}
+
+	return resp, nil
+}
+
+// marshalRoute converts a route found by path finding into its RPC
+// representation.
+func marshalRoute(route *pathfind.Route) *lnrpc.Route {
+	rpcRoute := &lnrpc.Route{
+		TotalTimeLock: route.TotalTimeLock,
+		TotalFees:     int64(route.TotalFees.ToSatoshis()),
+		TotalAmt:      int64(route.TotalAmount.ToSatoshis()),
+		Hops:          make([]*lnrpc.Hop, 0, len(route.Hops)),
+		TotalFeesMsat: int64(route.TotalFees),
+		TotalAmtMsat:  int64(route.TotalAmount),
+	}
+	for _, hop := range route.Hops {
+		rpcRoute.Hops = append(rpcRoute.Hops, &lnrpc.Hop{
+			ChanPoint:    hop.Channel.ChannelPoint.String(),
+			NodeId:       hex.EncodeToString(hop.Channel.To[:]),
+			ChanCapacity: int64(hop.Channel.Capacity),
+			AmtToForward: int64(hop.AmtToForward.ToSatoshis()),
+			Fee:          int64(hop.Fee.ToSatoshis()),
+			Expiry:       hop.Expiry,
+
+			AmtToForwardMsat: int64(hop.AmtToForward),
+			FeeMsat:          int64(hop.Fee),
+		})
+	}
+
+	return rpcRoute
+}
+
+// UpdateChannelPolicy sets the forwarding policy of either a single channel,
+// or all our active channels if no channel is specified. The new policy is
+// persisted, enforced by the htlcSwitch for all subsequent forwards, and
+// advertised to the network.
+func (r *rpcServer) UpdateChannelPolicy(ctx context.Context,
+	in *lnrpc.PolicyUpdateRequest) (*lnrpc.PolicyUpdateResponse, error) {
+
+	rpcsLog.Debugf("[updatechanpolicy] chan_point=%v, base_fee=%v, "+
+		"fee_rate=%v, time_lock_delta=%v, min_htlc=%v, max_htlc=%v",
+		in.ChanPoint, in.BaseFee, in.FeeRate, in.TimeLockDelta,
+		in.MinHtlc, in.MaxHtlc)
+
+	switch {
+	case in.BaseFee < 0:
+		return nil, fmt.Errorf("base fee must be positive")
+	case in.MinHtlc < 0 || in.MaxHtlc < 0:
+		return nil, fmt.Errorf("htlc limits must be positive")
+	case in.MaxHtlc != 0 && in.MaxHtlc < in.MinHtlc:
+		return nil, fmt.Errorf("max htlc must be at least min htlc")
+	case in.TimeLockDelta > math.MaxUint16:
+		return nil, fmt.Errorf("time lock delta must be below %v",
+			math.MaxUint16)
+	}
+
+	policy := &channeldb.ForwardingPolicy{
+		BaseFee:       btcutil.Amount(in.BaseFee),
+		FeeRate:       in.FeeRate,
+		TimeLockDelta: uint16(in.TimeLockDelta),
+		MinHTLC:       btcutil.Amount(in.MinHtlc),
+		MaxHTLC:       btcutil.Amount(in.MaxHtlc),
+	}
+
+	var activeChans []*wire.OutPoint
+	for _, serverPeer := range r.server.Peers() {
+		for _, snapshot := range serverPeer.ChannelSnapshots() {
+			activeChans = append(activeChans, snapshot.ChannelPoint)
+		}
+	}
+
+	// If a channel was specified, then it must be one of our active
+	// channels.
+	chanPoints := activeChans
+	if in.ChanPoint != nil {
+		txid, err := wire.NewShaHash(in.ChanPoint.FundingTxid)
+		if err != nil {
+			return nil, err
+		}
+		targetChan := wire.NewOutPoint(txid, in.ChanPoint.OutputIndex)
+
+		chanPoints = nil
+		for _, chanPoint := range activeChans {
+			if *chanPoint == *targetChan {
+				chanPoints = append(chanPoints, chanPoint)
+				break
+			}
+		}
+		if len(chanPoints) == 0 {
+			return nil, fmt.Errorf("ChannelPoint(%v) isn't an "+
+				"active channel", targetChan)
+		}
+	}
+
+	for _, chanPoint := range chanPoints {
+		err := r.server.chanDB.PutForwardingPolicy(chanPoint, policy)
+		if err != nil {
+			return nil, err
+		}
+
+		r.server.htlcSwitch.UpdateLinkPolicy(chanPoint, policy)
+
+		err = r.server.gossiper.AnnounceChannelPolicy(chanPoint, policy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &lnrpc.PolicyUpdateResponse{}, nil
+}
+
+// defaultNumForwardingEvents is the number of events returned by
+// ForwardingHistory if the request doesn't specify a maximum.
+const defaultNumForwardingEvents = 100
+
+// ForwardingHistory returns a page of the events within the forwarding log
+// which fall within the requested time range.
+func (r *rpcServer) ForwardingHistory(ctx context.Context,
+	in *lnrpc.ForwardingHistoryRequest) (*lnrpc.ForwardingHistoryResponse, error) {
+
+	rpcsLog.Debugf("[fwdinghistory] start_time=%v, end_time=%v, "+
+		"index_offset=%v, num_max_events=%v", in.StartTime, in.EndTime,
+		in.IndexOffset, in.NumMaxEvents)
+
+	endTime := time.Now()
+	if in.EndTime != 0 {
+		endTime = time.Unix(in.EndTime, 0)
+	}
+	startTime := time.Unix(in.StartTime, 0)
+	if startTime.After(endTime) {
+		return nil, fmt.Errorf("start time must precede end time")
+	}
+
+	numMaxEvents := in.NumMaxEvents
+	if numMaxEvents == 0 {
+		numMaxEvents = defaultNumForwardingEvents
+	}
+
+	timeSlice, err := r.server.chanDB.QueryForwardingLog(
+		channeldb.ForwardingLogQuery{
+			StartTime:    startTime,
+			EndTime:      endTime,
+			IndexOffset:  in.IndexOffset,
+			NumMaxEvents: numMaxEvents,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &lnrpc.ForwardingHistoryResponse{
+		ForwardingEvents: make([]*lnrpc.ForwardingEvent, 0,
+			len(timeSlice.ForwardingEvents)),
+		LastOffsetIndex: timeSlice.LastIndexOffset,
+	}
+	for _, event := range timeSlice.ForwardingEvents {
+		resp.ForwardingEvents = append(resp.ForwardingEvents,
+			&lnrpc.ForwardingEvent{
+				Timestamp:    event.Timestamp.Unix(),
+				ChanPointIn:  event.IncomingChan.String(),
+				ChanPointOut: event.OutgoingChan.String(),
+				AmtIn:        int64(event.AmtIn.ToSatoshis()),
+				AmtOut:       int64(event.AmtOut.ToSatoshis()),
+				Fee:          int64(event.Fee().ToSatoshis()),
+				AmtInMsat:    int64(event.AmtIn),
+				AmtOutMsat:   int64(event.AmtOut),
+				FeeMsat:      int64(event.Fee()),
+			})
+	}
+
+	return resp, nil
+}
+
+// FeeReport returns the forwarding policy of each of our active channels,
+// along with the fees earned by forwarding HTLCs out across each channel
+// within the past day, week and month.
+func (r *rpcServer) FeeReport(ctx context.Context,
+	in *lnrpc.FeeReportRequest) (*lnrpc.FeeReportResponse, error) {
+
+	rpcsLog.Debugf("[feereport]")
+
+	resp := &lnrpc.FeeReportResponse{}
+
+	// Each active channel is included within the report, even if it
+	// hasn't forwarded any HTLCs. The forwarding policy of each channel is
+	// only filled in once the forwarding events have been read, as the
+	// policies can't be fetched within the events' database transaction.
+	reports := make(map[wire.OutPoint]*lnrpc.ChannelFeeReport)
+	var chanPoints []wire.OutPoint
+	addReport := func(chanPoint wire.OutPoint) *lnrpc.ChannelFeeReport {
+		report := &lnrpc.ChannelFeeReport{
+			ChanPoint: chanPoint.String(),
+		}
+		reports[chanPoint] = report
+		chanPoints = append(chanPoints, chanPoint)
+		resp.ChannelFees = append(resp.ChannelFees, report)
+
+		return report
+	}
+	for _, serverPeer := range r.server.Peers() {
+		for _, snapshot := range serverPeer.ChannelSnapshots() {
+			addReport(*snapshot.ChannelPoint)
+		}
+	}
+
+	now := time.Now()
+	dayAgo := now.AddDate(0, 0, -1)
+	weekAgo := now.AddDate(0, 0, -7)
+	monthAgo := now.AddDate(0, -1, 0)
+
+	// Fees are attributed to the outgoing channel of each forward, as
+	// it's that channel's policy which determined the fee charged.
+	err := r.server.chanDB.ForEachForwardingEvent(monthAgo, now,
+		func(event *channeldb.ForwardingEvent) error {
+			report, ok := reports[event.OutgoingChan]
+			if !ok {
+				report = addReport(event.OutgoingChan)
+			}
+
+			fee := int64(event.Fee())
+			report.MonthFeeSumMsat += fee
+			resp.MonthFeeSumMsat += fee
+			if !event.Timestamp.Before(weekAgo) {
+				report.WeekFeeSumMsat += fee
+				resp.WeekFeeSumMsat += fee
+			}
+			if !event.Timestamp.Before(dayAgo) {
+				report.DayFeeSumMsat += fee
+				resp.DayFeeSumMsat += fee
+			}
+
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, chanPoint := range chanPoints {
+		policy, err := r.server.chanDB.FetchForwardingPolicy(&chanPoint)
+		switch {
+		case err == channeldb.ErrForwardingPolicyNotFound:
+			policy = &defaultForwardingPolicy
+		case err != nil:
+			return nil, err
+		}
+
+		resp.ChannelFees[i].BaseFee = int64(policy.BaseFee)
+		resp.ChannelFees[i].FeeRate = policy.FeeRate
+	}
+
+	// The sums in satoshis are rounded down only once the fees of every
+	// forward have been totalled, so sub-satoshi fees aren't lost.
+	toSats := func(msat int64) int64 {
+		return int64(lnwire.MilliSatoshi(msat).ToSatoshis())
+	}
+	for _, report := range resp.ChannelFees {
+		report.DayFeeSum = toSats(report.DayFeeSumMsat)
+		report.WeekFeeSum = toSats(report.WeekFeeSumMsat)
+		report.MonthFeeSum = toSats(report.MonthFeeSumMsat)
+	}
+	resp.DayFeeSum = toSats(resp.DayFeeSumMsat)
+	resp.WeekFeeSum = toSats(resp.WeekFeeSumMsat)
+	resp.MonthFeeSum = toSats(resp.MonthFeeSumMsat)
+
+	return resp, nil
+}
+
+// ListPayments returns all the payments the daemon has attempted to send,
+// ordered by their creation date.
+func (r *rpcServer) ListPayments(ctx context.Context,
+	in *lnrpc.ListPaymentsRequest) (*lnrpc.ListPaymentsResponse, error) {
+
+	rpcsLog.Debugf("[listpayments]")
+
+	payments, err := r.server.chanDB.FetchAllPayments()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &lnrpc.ListPaymentsResponse{
+		Payments: make([]*lnrpc.Payment, 0, len(payments)),
+	}
+	for _, payment := range payments {
+		rpcPayment := &lnrpc.Payment{
+			PaymentHash:   hex.EncodeToString(payment.PaymentHash[:]),
+			Value:         int64(payment.Value.ToSatoshis()),
+			Fee:           int64(payment.Fee.ToSatoshis()),
+			Path:          make([]string, 0, len(payment.Path)),
+			Status:        lnrpc.Payment_PaymentStatus(payment.Status),
+			FailureReason: payment.FailureReason,
+			CreationDate:  payment.CreationDate.Unix(),
+			ValueMsat:     int64(payment.Value),
+			FeeMsat:       int64(payment.Fee),
+		}
+		if payment.Status == channeldb.StatusSucceeded {
+			rpcPayment.PaymentPreimage = hex.EncodeToString(
+				payment.PaymentPreimage[:])
+		}
+		if !payment.ResolutionDate.IsZero() {
+			rpcPayment.ResolutionDate = payment.ResolutionDate.Unix()
+		}
+		for _, hop := range payment.Path {
+			rpcPayment.Path = append(rpcPayment.Path,
+				hex.EncodeToString(hop[:]))
+		}
+		for _, attempt := range payment.Attempts {
+			rpcAttempt := &lnrpc.PaymentAttempt{
+				Path:          make([]string, 0, len(attempt.Path)),
+				Amt:           int64(attempt.Amount.ToSatoshis()),
+				Fee:           int64(attempt.Fee.ToSatoshis()),
+				AttemptTime:   attempt.AttemptTime.Unix(),
+				FailureReason: attempt.FailureReason,
+				AmtMsat:       int64(attempt.Amount),
+				FeeMsat:       int64(attempt.Fee),
+			}
+			for _, hop := range attempt.Path {
+				rpcAttempt.Path = append(rpcAttempt.Path,
+					hex.EncodeToString(hop[:]))
+			}
+
+			rpcPayment.Attempts = append(rpcPayment.Attempts,
+				rpcAttempt)
+		}
+
+		resp.Payments = append(resp.Payments, rpcPayment)
+	}
+
+	return resp, nil
+}
+
+// DeletePayments deletes the records of all completed payments, or only
+// those which failed if requested. In-flight payments are never deleted.
+func (r *rpcServer) DeletePayments(ctx context.Context,
+	in *lnrpc.DeletePaymentsRequest) (*lnrpc.DeletePaymentsResponse, error) {
+
+	rpcsLog.Debugf("[deletepayments] failed_only=%v", in.FailedOnly)
+
+	numDeleted, err := r.server.chanDB.DeletePayments(in.FailedOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.DeletePaymentsResponse{
+		NumDeleted: uint32(numDeleted),
 	}, nil
 }
+
+// AddHoldInvoice adds a hold invoice identified by only its payment hash. The
+// HTLCs paying the invoice are held until it's either settled via
+// SettleInvoice, or cancelled via CancelInvoice.
+func (r *rpcServer) AddHoldInvoice(ctx context.Context,
+	in *lnrpc.AddHoldInvoiceRequest) (*lnrpc.AddHoldInvoiceResponse, error) {
+
+	if len(in.PaymentHash) != 32 {
+		return nil, fmt.Errorf("payment hash must be 32 bytes")
+	}
+	var payHash [32]byte
+	copy(payHash[:], in.PaymentHash)
+
+	value := lnwire.MilliSatoshi(in.ValueMsat)
+	if value == 0 {
+		value = lnwire.NewMSatFromSatoshis(btcutil.Amount(in.Value))
+	}
+	if value < 0 || in.Expiry < 0 {
+		return nil, fmt.Errorf("invoice value and expiry may not be " +
+			"negative")
+	}
+
+	expiry := time.Duration(in.Expiry) * time.Second
+	if expiry == 0 {
+		expiry = defaultHoldInvoiceExpiry
+	}
+
+	rpcsLog.Debugf("[addholdinvoice] payment_hash=%x, value=%v, "+
+		"expiry=%v", payHash[:], value, expiry)
+
+	err := r.server.invoices.addHoldInvoice(value, payHash, expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.AddHoldInvoiceResponse{}, nil
+}
+
+// SettleInvoice settles the accepted hold invoice of the passed pre-image,
+// settling the HTLCs paying it.
+func (r *rpcServer) SettleInvoice(ctx context.Context,
+	in *lnrpc.SettleInvoiceRequest) (*lnrpc.SettleInvoiceResponse, error) {
+
+	if len(in.Preimage) != 32 {
+		return nil, fmt.Errorf("preimage must be 32 bytes")
+	}
+	var preimage [32]byte
+	copy(preimage[:], in.Preimage)
+
+	rpcsLog.Debugf("[settleinvoice]")
+
+	if err := r.server.invoices.settleHoldInvoice(preimage); err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.SettleInvoiceResponse{}, nil
+}
+
+// CancelInvoice cancels the unresolved hold invoice of the passed payment
+// hash, cancelling any HTLCs paying it.
+func (r *rpcServer) CancelInvoice(ctx context.Context,
+	in *lnrpc.CancelInvoiceRequest) (*lnrpc.CancelInvoiceResponse, error) {
+
+	if len(in.PaymentHash) != 32 {
+		return nil, fmt.Errorf("payment hash must be 32 bytes")
+	}
+	var payHash [32]byte
+	copy(payHash[:], in.PaymentHash)
+
+	rpcsLog.Debugf("[cancelinvoice] payment_hash=%x", payHash[:])
+
+	if err := r.server.invoices.cancelHoldInvoice(payHash); err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.CancelInvoiceResponse{}, nil
+}
+
+// SubscribeInvoices streams an update each time the state of an invoice
+// changes.
+func (r *rpcServer) SubscribeInvoices(in *lnrpc.InvoiceSubscription,
+	updateStream lnrpc.Lightning_SubscribeInvoicesServer) error {
+
+	sub := r.server.invoices.subscribe()
+	defer r.server.invoices.unsubscribe(sub)
+
+	for {
+		select {
+		case invoice := <-sub.updates:
+			if err := updateStream.Send(marshalInvoice(invoice)); err != nil {
+				return err
+			}
+		case <-updateStream.Context().Done():
+			return nil
+		case <-r.quit:
+			return nil
+		}
+	}
+}
+
+// marshalInvoice converts an invoice into its RPC representation.
+func marshalInvoice(invoice *channeldb.Invoice) *lnrpc.Invoice {
+	rpcInvoice := &lnrpc.Invoice{
+		PaymentHash:  hex.EncodeToString(invoice.PaymentHash[:]),
+		ValueMsat:    int64(invoice.Value),
+		AmtPaidMsat:  int64(invoice.AmtPaid),
+		State:        lnrpc.Invoice_InvoiceState(invoice.State),
+		CreationDate: invoice.CreationDate.Unix(),
+		Expiry:       int64(invoice.Expiry / time.Second),
+	}
+	if invoice.State == channeldb.ContractSettled {
+		rpcInvoice.PaymentPreimage = hex.EncodeToString(
+			invoice.PaymentPreimage[:])
+	}
+	if !invoice.ResolutionDate.IsZero() {
+		rpcInvoice.ResolutionDate = invoice.ResolutionDate.Unix()
+	}
+
+	return rpcInvoice
+}
+
+// AddEscrowInvoice adds an escrow invoice, which is paid by HTLCs requiring
+// the preimages of N of its M redemption hashes. Any preimages which aren't
+// yet known may later be revealed via RevealPreimage.
+func (r *rpcServer) AddEscrowInvoice(ctx context.Context,
+	in *lnrpc.AddEscrowInvoiceRequest) (*lnrpc.AddEscrowInvoiceResponse, error) {
+
+	redemptionHashes, err := parseHashes(in.RedemptionHashes)
+	if err != nil {
+		return nil, err
+	}
+	contractType, err := newContractType(in.NumRequired,
+		len(redemptionHashes))
+	if err != nil {
+		return nil, err
+	}
+	preimages, err := parseHashes(in.Preimages)
+	if err != nil {
+		return nil, err
+	}
+
+	value := lnwire.MilliSatoshi(in.ValueMsat)
+	if value == 0 {
+		value = lnwire.NewMSatFromSatoshis(btcutil.Amount(in.Value))
+	}
+	if value < 0 || in.Expiry < 0 {
+		return nil, fmt.Errorf("invoice value and expiry may not be " +
+			"negative")
+	}
+	expiry := time.Duration(in.Expiry) * time.Second
+
+	rpcsLog.Debugf("[addescrowinvoice] payment_hash=%x, value=%v, "+
+		"contract_type=%v, expiry=%v", redemptionHashes[0][:], value,
+		contractType, expiry)
+
+	err = r.server.invoices.addMultiHashInvoice(value, contractType,
+		redemptionHashes, preimages, expiry, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.AddEscrowInvoiceResponse{}, nil
+}
+
+// RevealPreimage records the preimage of a redemption hash of an escrow
+// invoice, settling the HTLCs paying it once enough preimages are known.
+func (r *rpcServer) RevealPreimage(ctx context.Context,
+	in *lnrpc.RevealPreimageRequest) (*lnrpc.RevealPreimageResponse, error) {
+
+	if len(in.Preimage) != 32 {
+		return nil, fmt.Errorf("preimage must be 32 bytes")
+	}
+	var preimage [32]byte
+	copy(preimage[:], in.Preimage)
+
+	rpcsLog.Debugf("[revealpreimage]")
+
+	if err := r.server.invoices.revealPreimage(preimage); err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.RevealPreimageResponse{}, nil
+}
+
+// parseHashes converts the passed hashes, or preimages, each of which must be
+// 32 bytes, from their RPC representation.
+func parseHashes(rawHashes [][]byte) ([][32]byte, error) {
+	hashes := make([][32]byte, len(rawHashes))
+	for i, rawHash := range rawHashes {
+		if len(rawHash) != 32 {
+			return nil, fmt.Errorf("hashes, and preimages must be " +
+				"32 bytes")
+		}
+		copy(hashes[i][:], rawHash)
+	}
+
+	return hashes, nil
+}
+
+// newContractType returns the ContractType of an HTLC requiring the
+// preimages of numRequired of its numHashes redemption hashes. A contract
+// with a single hash is a regular HTLC.
+func newContractType(numRequired uint32, numHashes int) (uint8, error) {
+	switch {
+	case numHashes == 0:
+		return 0, fmt.Errorf("at least one redemption hash is required")
+	case numHashes > lnwire.MaxRedemptionHashes:
+		return 0, fmt.Errorf("at most %v redemption hashes are "+
+			"permitted", lnwire.MaxRedemptionHashes)
+	case numRequired == 0 || numRequired > uint32(numHashes):
+		return 0, fmt.Errorf("between 1 and %v preimages must be "+
+			"required", numHashes)
+	case numHashes == 1:
+		return 0, nil
+	}
+
+	return lnwire.NewMultiHashContract(uint8(numRequired),
+		uint8(numHashes)), nil
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/btcsuite/fastsha256"
 	"github.com/lightningnetwork/lnd/channeldb"
@@ -15,11 +16,6 @@ import (
 	"github.com/roasbeef/btcd/btcec"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcutil"
-
-	"github.com/roasbeef/btcwallet/waddrmgr"
-	"github.com/BitfuryLightning/tools/routing"
-	"github.com/BitfuryLightning/tools/rt"
-	"github.com/BitfuryLightning/tools/rt/graph"
 )
 
 // server is the main server of the Lightning Network Daemon. The server
@@ -52,8 +48,26 @@ type server struct {
 	htlcSwitch *htlcSwitch
 	invoices   *invoiceRegistry
 
+	// payments drives the lifecycle of all payments we send, retrying
+	// them over alternative routes as they fail.
+	payments *paymentController
+
 	// ROUTING ADDED
-	routingMgr *routing.RoutingManager
+	routingMgr *routingManager
+
+	gossiper *gossiper
+
+	// trickleDelay is the interval at which each channel commits the
+	// updates batched since its last commitment, and maxBatchSize is the
+	// number of updates which are committed immediately.
+	trickleDelay time.Duration
+	maxBatchSize int
+
+	// feeEstimator is used to estimate the fee rate paid by all on-chain
+	// transactions we create. Channels we've initiated also update their
+	// commitment fee once the estimate drifts far enough from the fee rate
+	// they currently pay.
+	feeEstimator lnwallet.FeeEstimator
 
 	newPeers  chan *peer
 	donePeers chan *peer
@@ -66,9 +80,11 @@ type server struct {
 // newServer creates a new instance of the server which is to listen using the
 // passed listener address.
 func newServer(listenAddrs []string, wallet *lnwallet.LightningWallet,
-	chanDB *channeldb.DB) (*server, error) {
+	chanDB *channeldb.DB, feeEstimator lnwallet.FeeEstimator,
+	chanRefreshInterval time.Duration, trickleDelay time.Duration,
+	maxBatchSize int, finalCLTVDelta uint32) (*server, error) {
 
-	privKey, err := getIdentityPrivKey(chanDB, wallet)
+	privKey, err := getIdentityPrivKey(wallet)
 	if err != nil {
 		return nil, err
 	}
@@ -82,14 +98,18 @@ func newServer(listenAddrs []string, wallet *lnwallet.LightningWallet,
 	}
 
 	serializedPubKey := privKey.PubKey().SerializeCompressed()
+	lightningID := fastsha256.Sum256(serializedPubKey)
 	s := &server{
 		chanDB:       chanDB,
-		fundingMgr:   newFundingManager(wallet),
-		htlcSwitch:   newHtlcSwitch(),
-		invoices:     newInvoiceRegistry(),
+		fundingMgr:   newFundingManager(wallet, feeEstimator),
+		htlcSwitch:   newHtlcSwitch(lightningID, chanDB),
+		invoices:     newInvoiceRegistry(chanDB, finalCLTVDelta),
 		lnwallet:     wallet,
 		identityPriv: privKey,
-		lightningID:  fastsha256.Sum256(serializedPubKey),
+		lightningID:  lightningID,
+		trickleDelay: trickleDelay,
+		maxBatchSize: maxBatchSize,
+		feeEstimator: feeEstimator,
 		listeners:    listeners,
 		peers:        make(map[int32]*peer),
 		newPeers:     make(chan *peer, 100),
@@ -100,11 +120,37 @@ func newServer(listenAddrs []string, wallet *lnwallet.LightningWallet,
 
 
 	// TODO(roasbeef): remove
-	s.invoices.addInvoice(1000*1e8, *debugPre)
+	// The debug invoice has no set value so that it settles a payment of
+	// any amount, rather than holding its shards indefinitely. It never
+	// expires, and may be paid any number of times.
+	s.invoices.addInvoice(0, *debugPre, 0, true)
+
+	// Hold invoices are persisted, so those created before a restart are
+	// restored to the registry.
+	if err := s.invoices.loadHoldInvoices(); err != nil {
+		return nil, err
+	}
 
 	// ROUTING ADDED
-	s.routingMgr = routing.NewRoutingManager(graph.NewID(s.lightningID), nil)
+	s.routingMgr = newRoutingManager(s.lightningID)
+
+	// Our own node is the source of the persistent channel graph, from
+	// which all paths are found.
+	selfNode := &channeldb.LightningNode{
+		ID:         s.lightningID,
+		PubKey:     privKey.PubKey(),
+		LastUpdate: time.Now(),
+	}
+	if err := chanDB.SetSourceNode(selfNode); err != nil {
+		return nil, err
+	}
 
+	s.gossiper = newGossiper(s, wallet.ChainNotifier, chanRefreshInterval)
+
+	s.payments = newPaymentController(s)
+	if err := s.payments.reconcilePayments(); err != nil {
+		return nil, err
+	}
 
 	s.rpcServer = newRpcServer(s)
 
@@ -131,6 +177,20 @@ func (s *server) Start() {
 	// ROUTING ADDED
 	s.routingMgr.Start()
 
+	// Populate the routing manager with the channel graph persisted
+	// before our last shutdown.
+	if err := s.loadChannelGraph(); err != nil {
+		srvrLog.Errorf("unable to load channel graph: %v", err)
+	}
+
+	if err := s.gossiper.Start(); err != nil {
+		srvrLog.Errorf("unable to start gossiper: %v", err)
+	}
+
+	if err := s.invoices.start(s.lnwallet.ChainNotifier); err != nil {
+		srvrLog.Errorf("unable to start invoice registry: %v", err)
+	}
+
 	s.wg.Add(1)
 	go s.queryHandler()
 
@@ -153,14 +213,21 @@ func (s *server) Stop() error {
 		}
 	}
 
-	// Shutdown the wallet, funding manager, and the rpc server.
+	// Shutdown the wallet, funding manager, and the rpc server. The
+	// invoice registry is stopped first, as it consumes the block epochs
+	// of the wallet's chain notifier.
 	s.rpcServer.Stop()
+	s.invoices.stop()
 	s.lnwallet.Shutdown()
 	s.fundingMgr.Stop()
 
 	// ROUTING ADDED
 	s.routingMgr.Stop()
 
+	// The gossiper queries the server for its set of peers, so it must be
+	// stopped before the server's own goroutines.
+	s.gossiper.Stop()
+
 	// Signal all the lingering goroutines to quit.
 	close(s.quit)
 	s.wg.Wait()
@@ -187,6 +254,12 @@ func (s *server) addPeer(p *peer) {
 	}
 
 	s.peers[p.id] = p
+
+	// If we share a channel with the peer, then it's sent our routing
+	// table, and the announcement of any of our channels with it which
+	// hasn't yet been assembled is resumed.
+	s.routingMgr.NeighborConnected(p.lightningID)
+	s.gossiper.PeerConnected(p)
 }
 
 // removePeer removes the passed peer from the server's state of all active
@@ -205,6 +278,7 @@ func (s *server) removePeer(p *peer) {
 	}
 
 	delete(s.peers, p.id)
+	s.routingMgr.NeighborDisconnected(p.lightningID)
 }
 
 // connectPeerMsg is a message requesting the server to open a connection to a
@@ -238,6 +312,10 @@ type openChanReq struct {
 
 	numConfs uint32
 
+	// fundingFeeRate is the fee rate in satoshis per byte paid by the
+	// funding transaction.
+	fundingFeeRate btcutil.Amount
+
 	resp chan *openChanResp
 	err  chan error
 }
@@ -381,6 +459,88 @@ func (s *server) handleConnectPeer(msg *connectPeerMsg) {
 	}()
 }
 
+// loadChannelGraph adds each channel edge stored within the persistent
+// channel graph to the routing manager.
+func (s *server) loadChannelGraph() error {
+	return s.chanDB.ForEachChannelEdge(func(edge *channeldb.ChannelEdge) error {
+		s.routingMgr.AddChannel(edge.From, edge.To,
+			&edge.ChannelPoint, edge.Capacity)
+		return nil
+	})
+}
+
+// addChannelToGraph records a newly opened channel with the target peer
+// within both the persistent channel graph, and the routing manager. An
+// edge is added in each direction, as both endpoints are able to route
+// payments across the channel.
+func (s *server) addChannelToGraph(p *peer, chanPoint *wire.OutPoint,
+	capacity btcutil.Amount) {
+
+	peerID := [32]byte(p.lightningID)
+
+	// Record the peer's identity key if it isn't yet known, without
+	// clobbering any information it may have announced. The update time
+	// is left unset, so any announcement from the peer supersedes it.
+	_, err := s.chanDB.FetchLightningNode(peerID)
+	if err == channeldb.ErrGraphNodeNotFound {
+		err = s.chanDB.AddLightningNode(&channeldb.LightningNode{
+			ID:     peerID,
+			PubKey: p.identityPub,
+		})
+	}
+	if err != nil {
+		srvrLog.Errorf("unable to add node %x to channel graph: %v",
+			peerID[:], err)
+	}
+
+	for _, nodes := range [][2][32]byte{
+		{s.lightningID, peerID},
+		{peerID, s.lightningID},
+	} {
+		edge := &channeldb.ChannelEdge{
+			ChannelPoint: *chanPoint,
+			From:         nodes[0],
+			To:           nodes[1],
+			Capacity:     capacity,
+			LastUpdate:   time.Now(),
+		}
+		if err := s.chanDB.AddChannelEdge(edge); err != nil {
+			srvrLog.Errorf("unable to add ChannelPoint(%v) to "+
+				"channel graph: %v", chanPoint, err)
+		}
+	}
+
+	s.routingMgr.AddChannel(s.lightningID, peerID, chanPoint, capacity)
+
+	// Once the channel is closed, the gossiper will prune it from the
+	// graph.
+	s.gossiper.WatchChannel(chanPoint)
+
+	// The channel is announced to the network once both we and the peer
+	// have signed its announcement.
+	channels, err := s.chanDB.FetchOpenChannels(&p.lightningID)
+	if err != nil {
+		srvrLog.Errorf("unable to fetch channels with %v: %v", p, err)
+	}
+	for _, channel := range channels {
+		if *channel.ChanID == *chanPoint {
+			s.gossiper.AnnounceChannel(p, channel)
+		}
+	}
+
+	// Finally, advertise the policy we'll apply to HTLCs forwarded out
+	// across the new channel.
+	policy, err := s.chanDB.FetchForwardingPolicy(chanPoint)
+	if err != nil {
+		defaultPolicy := defaultForwardingPolicy
+		policy = &defaultPolicy
+	}
+	if err := s.gossiper.AnnounceChannelPolicy(chanPoint, policy); err != nil {
+		srvrLog.Errorf("unable to announce policy of "+
+			"ChannelPoint(%v): %v", chanPoint, err)
+	}
+}
+
 // handleOpenChanReq first locates the target peer, and if found hands off the
 // request to the funding manager allowing it to initiate the channel funding
 // workflow.
@@ -412,15 +572,8 @@ func (s *server) handleOpenChanReq(req *openChanReq) {
 		fundingID, err := s.fundingMgr.initFundingWorkflow(targetPeer, req)
 		if err == nil {
 			// ROUTING ADDED
-			capacity := float64(req.localFundingAmt + req.remoteFundingAmt)
-			s.routingMgr.AddChannel(
-				graph.NewID(s.lightningID),
-				graph.NewID([32]byte(targetPeer.lightningID)),
-				graph.NewEdgeID(fundingID.String()),
-				&rt.ChannelInfo{
-					Cpt: capacity,
-				},
-			)
+			capacity := req.localFundingAmt + req.remoteFundingAmt
+			s.addChannelToGraph(targetPeer, fundingID, capacity)
 		}
 		req.resp <- &openChanResp{fundingID}
 		req.err <- err
@@ -442,7 +595,7 @@ func (s *server) ConnectToPeer(addr *lndc.LNAdr) (int32, error) {
 // OpenChannel sends a request to the server to open a channel to the specified
 // peer identified by ID with the passed channel funding paramters.
 func (s *server) OpenChannel(nodeID int32, localAmt, remoteAmt btcutil.Amount,
-	numConfs uint32) (chan *openChanResp, chan error) {
+	numConfs uint32, fundingFeeRate btcutil.Amount) (chan *openChanResp, chan error) {
 
 	errChan := make(chan error, 1)
 	respChan := make(chan *openChanResp, 1)
@@ -452,6 +605,7 @@ func (s *server) OpenChannel(nodeID int32, localAmt, remoteAmt btcutil.Amount,
 		localFundingAmt:  localAmt,
 		remoteFundingAmt: remoteAmt,
 		numConfs:         numConfs,
+		fundingFeeRate:   fundingFeeRate,
 
 		resp: respChan,
 		err:  errChan,
@@ -502,32 +656,15 @@ func (s *server) listener(l net.Listener) {
 	s.wg.Done()
 }
 
-// getIdentityPrivKey gets the identity private key out of the wallet DB.
-func getIdentityPrivKey(c *channeldb.DB,
-	w *lnwallet.LightningWallet) (*btcec.PrivateKey, error) {
-
-	// First retrieve the current identity address for this peer.
-	adr, err := c.GetIdAdr()
+// getIdentityPrivKey gets the identity private key out of the wallet.
+func getIdentityPrivKey(w *lnwallet.LightningWallet) (*btcec.PrivateKey, error) {
+	priv, err := w.FetchIdentityKey()
 	if err != nil {
 		return nil, err
 	}
 
-	// Using the ID address, request the private key coresponding to the
-	// address from the wallet's address manager.
-	adr2, err := w.Manager.Address(adr)
-	if err != nil {
-		return nil, err
-	}
-
-	serializedKey := adr2.(waddrmgr.ManagedPubKeyAddress).PubKey().SerializeCompressed()
-	keyEncoded := hex.EncodeToString(serializedKey)
-	ltndLog.Infof("identity address: %v", adr)
+	keyEncoded := hex.EncodeToString(priv.PubKey().SerializeCompressed())
 	ltndLog.Infof("identity pubkey retrieved: %v", keyEncoded)
 
-	priv, err := adr2.(waddrmgr.ManagedPubKeyAddress).PrivKey()
-	if err != nil {
-		return nil, err
-	}
-
 	return priv, nil
 }
@@ -53,6 +53,16 @@ type SPVCon struct {
 	// waitState is a channel that is empty while in the header and block
 	// sync modes, but when in the idle state has a "true" in it.
 	inWaitState chan bool
+
+	// blockSubs are channels which get sent every full block ingested.
+	// subMutex protects the slice since subscribers can show up any time.
+	subMutex  sync.Mutex
+	blockSubs []chan *BlockNtfn
+
+	// feeRates are the average fee rates in satoshis per byte paid within
+	// the most recently ingested full blocks, oldest first.
+	feeMutex sync.Mutex
+	feeRates []int64
 }
 
 // AskForTx requests a tx we heard about from an inv message.
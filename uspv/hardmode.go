@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/roasbeef/btcd/blockchain"
 	"github.com/roasbeef/btcd/wire"
 	"github.com/roasbeef/btcutil"
 	"github.com/roasbeef/btcutil/bloom"
@@ -14,6 +15,9 @@ var (
 	WitMagicBytes = []byte{0x6a, 0x24, 0xaa, 0x21, 0xa9, 0xed}
 )
 
+// feeRateWindow is the number of recent blocks whose fee rates are kept.
+const feeRateWindow = 48
+
 // BlockRootOK checks for block self-consistency.
 // If the block has no wintess txs, and no coinbase witness commitment,
 // it only checks the tx merkle root.  If either a witness commitment or
@@ -216,6 +220,11 @@ func (s *SPVCon) IngestBlock(m *wire.MsgBlock) {
 	fmt.Printf("ingested full block %s height %d OK\n",
 		m.Header.BlockSha().String(), hah.height)
 
+	s.recordFeeRate(m, hah.height)
+
+	// let everyone who's interested know about the new block
+	s.notifyBlockSubs(m, hah.height)
+
 	if hah.final { // check sync end
 		// don't set waitstate; instead, ask for headers again!
 		// this way the only thing that triggers waitstate is asking for headers,
@@ -229,3 +238,79 @@ func (s *SPVCon) IngestBlock(m *wire.MsgBlock) {
 	}
 	return
 }
+
+// BlockNtfn is a full block along with its height.  These get sent to
+// subscribers after the block has been ingested.
+type BlockNtfn struct {
+	Block  *wire.MsgBlock
+	Height int32
+}
+
+// SubscribeBlocks returns a channel which will be sent every full block
+// ingested from now on.  Only full blocks are sent, so this only does
+// anything in hard mode.  Sends block, so subscribers need to keep up.
+func (s *SPVCon) SubscribeBlocks() chan *BlockNtfn {
+	s.subMutex.Lock()
+	defer s.subMutex.Unlock()
+
+	sub := make(chan *BlockNtfn, 20)
+	s.blockSubs = append(s.blockSubs, sub)
+	return sub
+}
+
+// recordFeeRate adds the average fee rate, in satoshis per byte, paid by the
+// transactions within the block to the window of recent fee rates.  The values
+// of the outputs spent by the block aren't known, so the total fee is taken to
+// be whatever the coinbase claims on top of the block subsidy.  Blocks with no
+// transactions besides the coinbase say nothing about fees and are skipped.
+// The rate is rounded up, so blocks paying under a satoshi per byte aren't
+// recorded as paying nothing.  Only called for full blocks, so hard mode only.
+func (s *SPVCon) recordFeeRate(m *wire.MsgBlock, height int32) {
+	if len(m.Transactions) < 2 {
+		return
+	}
+
+	var claimed int64
+	for _, txOut := range m.Transactions[0].TxOut {
+		claimed += txOut.Value
+	}
+	fees := claimed - blockchain.CalcBlockSubsidy(height, s.TS.Param)
+	if fees <= 0 {
+		return
+	}
+
+	var size int64
+	for _, tx := range m.Transactions[1:] {
+		size += int64(tx.SerializeSize())
+	}
+
+	s.feeMutex.Lock()
+	defer s.feeMutex.Unlock()
+
+	s.feeRates = append(s.feeRates, (fees+size-1)/size)
+	if len(s.feeRates) > feeRateWindow {
+		s.feeRates = append([]int64(nil), s.feeRates[1:]...)
+	}
+}
+
+// RecentFeeRates returns the average fee rates, in satoshis per byte, paid
+// within the most recently ingested full blocks, oldest first.  Only full
+// blocks are ingested in hard mode, so this is empty otherwise.
+func (s *SPVCon) RecentFeeRates() []int64 {
+	s.feeMutex.Lock()
+	defer s.feeMutex.Unlock()
+
+	feeRates := make([]int64, len(s.feeRates))
+	copy(feeRates, s.feeRates)
+	return feeRates
+}
+
+// notifyBlockSubs sends the block to everyone who called SubscribeBlocks.
+func (s *SPVCon) notifyBlockSubs(m *wire.MsgBlock, height int32) {
+	s.subMutex.Lock()
+	defer s.subMutex.Unlock()
+
+	for _, sub := range s.blockSubs {
+		sub <- &BlockNtfn{Block: m, Height: height}
+	}
+}
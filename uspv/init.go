@@ -13,9 +13,9 @@ import (
 
 // OpenPV starts a
 func OpenSPV(remoteNode string, hfn, dbfn string,
-	inTs *TxStore, hard bool, iron bool, p *chaincfg.Params) (SPVCon, error) {
+	inTs *TxStore, hard bool, iron bool, p *chaincfg.Params) (*SPVCon, error) {
 	// create new SPVCon
-	var s SPVCon
+	s := new(SPVCon)
 	s.HardMode = hard
 	s.Ironman = iron
 	// I should really merge SPVCon and TxStore, they're basically the same
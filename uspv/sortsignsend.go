@@ -188,14 +188,15 @@ func (s *SPVCon) SendOne(u Utxo, adr btcutil.Address) error {
 
 // SendCoins does send coins, but it's very rudimentary
 // wit makes it into p2wpkh.  Which is not yet spendable.
-func (s *SPVCon) SendCoins(adrs []btcutil.Address, sendAmts []int64) error {
+// satPerByte is the fee rate to pay, in satoshis per byte.
+func (s *SPVCon) SendCoins(
+	adrs []btcutil.Address, sendAmts []int64, satPerByte int64) error {
 	if len(adrs) != len(sendAmts) {
 		return fmt.Errorf("%d addresses and %d amounts", len(adrs), len(sendAmts))
 	}
 	var err error
 	var score, totalSend, fee int64
 	dustCutoff := int64(20000) // below this amount, just give to miners
-	satPerByte := int64(80)    // satoshis per byte fee; have as arg later
 	rawUtxos, err := s.TS.GetAllUtxos()
 	if err != nil {
 		return err
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/roasbeef/btcd/blockchain"
+	"github.com/roasbeef/btcd/btcec"
 	"github.com/roasbeef/btcd/chaincfg"
 
 	"github.com/boltdb/bolt"
@@ -67,6 +68,36 @@ func NewTxStore(rootkey *hdkeychain.ExtendedKey, p *chaincfg.Params) TxStore {
 	return txs
 }
 
+// identityKeyIdx is the hardened child index of the key used as our
+// identity.  Way up at the top so it never runs into the address keys.
+const identityKeyIdx = hdkeychain.HardenedKeyStart - 1
+
+// PrivKeyAt returns the private key for the address at the given key index.
+func (t *TxStore) PrivKeyAt(idx uint32) (*btcec.PrivateKey, error) {
+	child, err := t.rootPrivKey.Child(idx + hdkeychain.HardenedKeyStart)
+	if err != nil {
+		return nil, err
+	}
+	return child.ECPrivKey()
+}
+
+// GetPrivKey returns the private key for one of our addresses.  Both the
+// p2pkh and p2wpkh versions of an address work since they share a pubkey hash.
+func (t *TxStore) GetPrivKey(adr btcutil.Address) (*btcec.PrivateKey, error) {
+	for _, a := range t.Adrs {
+		if bytes.Equal(a.PkhAdr.ScriptAddress(), adr.ScriptAddress()) {
+			return t.PrivKeyAt(a.KeyIdx)
+		}
+	}
+	return nil, fmt.Errorf("address %s not in wallet", adr.String())
+}
+
+// IdentityKey returns the private key used as our identity on the lightning
+// network.  It's not the key for any address so it never ends up on chain.
+func (t *TxStore) IdentityKey() (*btcec.PrivateKey, error) {
+	return t.PrivKeyAt(identityKeyIdx)
+}
+
 // add txid of interest
 func (t *TxStore) AddTxid(txid *wire.ShaHash, height int32) error {
 	if txid == nil {